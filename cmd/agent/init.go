@@ -2,18 +2,32 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gdyunin/metricol.git/internal/agent/agent"
+	"github.com/gdyunin/metricol.git/internal/agent/collect"
+	"github.com/gdyunin/metricol.git/internal/agent/collect/stategies"
 	"github.com/gdyunin/metricol.git/internal/agent/config"
+	"github.com/gdyunin/metricol.git/internal/agent/discovery"
+	"github.com/gdyunin/metricol.git/internal/agent/kube"
+	"github.com/gdyunin/metricol.git/internal/agent/replay"
+	"github.com/gdyunin/metricol.git/internal/agent/send"
+	"github.com/gdyunin/metricol.git/internal/agent/throttle"
+	"github.com/gdyunin/metricol.git/pkg/buildinfo"
 	"github.com/gdyunin/metricol.git/pkg/convert"
 	"github.com/gdyunin/metricol.git/pkg/logging"
+	"github.com/gdyunin/metricol.git/pkg/shutdown"
+	"github.com/gdyunin/metricol.git/pkg/tracing"
 
 	"go.uber.org/zap"
 )
@@ -21,10 +35,34 @@ import (
 const (
 	// LoggerNameAgent is the logger name for the main agent.
 	loggerNameAgent = "agent"
+	// LoggerNameThrottle is the logger name for the collection throttling.
+	loggerNameThrottle = "throttle"
+	// LoggerNameScrape is the logger name for the scrape strategy.
+	loggerNameScrape = "scrape_strategy"
+	// LoggerNameProcess is the logger name for the process stats strategy.
+	loggerNameProcess = "process_strategy"
+	// LoggerNameClockDrift is the logger name for the clock drift strategy.
+	loggerNameClockDrift = "clock_drift_strategy"
+	// LoggerNameReload is the logger name for the configuration reloads.
+	loggerNameReload = "reload"
+	// LoggerNameDiscovery is the logger name for the server discovery.
+	loggerNameDiscovery = "discovery"
+	// DefaultSRVRefresh is the period of re-resolving the discovered server targets when it is not configured.
+	defaultSRVRefresh = 30 * time.Second
 	// LoggerNameGracefulShutdown is the logger name for the graceful shutdown events.
 	loggerNameGracefulShutdown = "graceful_shutdown"
 	// GracefulShutdownTimeout is the time to wait for ongoing tasks to complete during shutdown.
 	gracefulShutdownTimeout = 5 * time.Second
+	// ComponentAgent names the metrics agent in the shutdown report.
+	componentAgent = "agent"
+	// ComponentProf names the profiling server in the shutdown report.
+	componentProf = "profiling server"
+	// ComponentStatus names the status server in the shutdown report.
+	componentStatus = "status server"
+	// LoggerNameConfig is the logger name for the configuration report.
+	loggerNameConfig = "config"
+	// ServiceName names the agent in exported traces.
+	serviceName = "metricol-agent"
 )
 
 var (
@@ -71,19 +109,132 @@ func loadConfig() (*config.Config, error) {
 	return cfg, nil
 }
 
+// setupTracing installs the OpenTelemetry tracer provider exporting to the configured collector.
+//
+// Parameters:
+//   - ctx: The context of the exporter setup.
+//   - cfg: The agent configuration.
+//   - logger: The structured logger instance.
+//
+// Returns:
+//   - func(): Flushes the pending spans; call it once the agent stopped sending.
+func setupTracing(ctx context.Context, cfg *config.Config, logger *zap.SugaredLogger) func() {
+	flush, err := tracing.Setup(ctx, tracing.Config{
+		Endpoint:       cfg.TraceEndpoint,
+		ServiceName:    serviceName,
+		ServiceVersion: buildVersion,
+		SampleRatio:    cfg.TraceRatio,
+	})
+	if err != nil {
+		logger.Fatalf("failed to set up tracing: %v", err)
+	}
+	if cfg.TraceEndpoint != "" {
+		logger.Infof("Exporting traces to %s, sampling %.0f%% of the batches", cfg.TraceEndpoint, cfg.TraceRatio*100)
+	}
+	return func() {
+		// The main context is canceled by now, so flushing gets a context of its own.
+		flushCtx, cancel := context.WithTimeout(context.Background(), gracefulShutdownTimeout)
+		defer cancel()
+		if err := flush(flushCtx); err != nil {
+			logger.Errorf("Failed to flush traces: %v", err)
+		}
+	}
+}
+
 // initAgent initializes the agent, including the
 // metrics collectors, metrics senders.
-func initAgent(cfg *config.Config, logger *zap.SugaredLogger) *agent.Agent {
-	var crptKey string
-	if cfg.CryptoKey != "" {
-		keyData, err := os.ReadFile(cfg.CryptoKey)
+//
+// Returns:
+//   - *agent.Agent: The agent.
+//   - func(): Closes the recording of the collected batches, if any; call it once the agent stopped.
+func initAgent(ctx context.Context, cfg *config.Config, logger *zap.SugaredLogger) (*agent.Agent, func()) {
+	crptKey, tlsCfg, sendOpts := senderOptions(ctx, cfg, logger)
+
+	if err := collect.CheckStrategies(cfg.Collect); err != nil {
+		logger.Fatalf("invalid collection strategies: %v", err)
+	}
+	strategySettings, err := collect.ParseStrategySettings(cfg.Strategies)
+	if err != nil {
+		logger.Fatalf("failed to parse strategy settings: %v", err)
+	}
+	strategyCache, err := collect.ParseStrategyCache(cfg.StrategyCache)
+	if err != nil {
+		logger.Fatalf("failed to parse strategy cache settings: %v", err)
+	}
+	metricRenames, err := collect.ParseMetricRenames(cfg.MetricRename)
+	if err != nil {
+		logger.Fatalf("failed to parse metric renames: %v", err)
+	}
+	metricRules, err := collect.NewMetricRules(cfg.MetricInclude, cfg.MetricExclude, metricRenames)
+	if err != nil {
+		logger.Fatalf("failed to build metric rules: %v", err)
+	}
+	labels, err := metricLabels(cfg)
+	if err != nil {
+		logger.Fatalf("failed to build metric labels: %v", err)
+	}
+	virtualHosts, err := collect.NewVirtualHosts(cfg.VirtualHosts, cfg.VirtualChurn, cfg.VirtualMode)
+	if err != nil {
+		logger.Fatalf("failed to set up virtual hosts: %v", err)
+	}
+	if virtualHosts != nil {
+		logger.Infof("Simulating %d virtual hosts, every metric is reported once per host", cfg.VirtualHosts)
+	}
+
+	if err = throttle.ApplyProcessLimits(cfg.MaxProcs, cfg.Nice); err != nil {
+		logger.Warnf("Failed to apply process limits: %v", err)
+	}
+	batchSize, queueSize := memoryLimits(cfg, logger.Named(loggerNameThrottle))
+
+	closeRecording := func() {}
+	collectOpts := []collect.Option(nil)
+	if cfg.RecordFile != "" {
+		recorder, err := replay.NewRecorder(cfg.RecordFile)
 		if err != nil {
-			logger.Fatalf("failed to read crypto key from file: %v", err)
+			logger.Fatalf("failed to open the recording: %v", err)
+		}
+		logger.Infof("Recording the collected batches to %s", cfg.RecordFile)
+		collectOpts = append(collectOpts, collect.WithBatchRecorder(recorder))
+		closeRecording = func() {
+			if err := recorder.Close(); err != nil {
+				logger.Errorf("Failed to close the recording: %v", err)
+			}
 		}
-		crptKey = string(keyData)
 	}
 
-	return agent.NewAgent(
+	agentOpts := []agent.Option{
+		agent.WithStrategyNames(cfg.Collect...),
+		agent.WithSendOptions(append(sendOpts, send.WithMaxBatchSize(batchSize))...),
+		agent.WithCollectOptions(collectOpts...),
+		agent.WithCollectOptions(
+			collect.WithCycleGuard(throttle.NewLoadGuard(cfg.MaxLoad, logger.Named(loggerNameThrottle)).Allow),
+			collect.WithAdaptiveInterval(minPollInterval(cfg), convert.IntegerToSeconds(cfg.MaxPollInterval)),
+			collect.WithStrategyTimeout(convert.IntegerToSeconds(cfg.StrategyTimeout)),
+			collect.WithStrategySettings(strategySettings),
+			collect.WithStrategyCache(strategyCache),
+			collect.WithMetricRules(metricRules),
+			collect.WithMetricLabels(labels),
+			collect.WithVirtualHosts(virtualHosts),
+		),
+		agent.WithCrashDumps(cfg.CrashDumpDir),
+		agent.WithSendQueueSize(queueSize),
+	}
+	if cfg.Heartbeat {
+		agentOpts = append(agentOpts, agent.WithHeartbeat())
+	}
+	if len(cfg.ScrapeTargets) > 0 {
+		agentOpts = append(agentOpts, agent.WithStrategies(scrapeStrategy(cfg, logger)))
+	}
+	if len(cfg.Processes) > 0 {
+		if strategy := processStrategy(cfg, logger); strategy != nil {
+			agentOpts = append(agentOpts, agent.WithStrategies(strategy))
+		}
+	}
+	if cfg.ClockSource != "" {
+		agentOpts = append(agentOpts, agent.WithStrategies(clockDriftStrategy(cfg, tlsCfg, logger)))
+	}
+
+	metricsAgent := agent.NewAgent(
 		convert.IntegerToSeconds(cfg.PollInterval),
 		convert.IntegerToSeconds(cfg.ReportInterval),
 		logger.Named(loggerNameAgent),
@@ -91,7 +242,363 @@ func initAgent(cfg *config.Config, logger *zap.SugaredLogger) *agent.Agent {
 		cfg.ServerAddress,
 		cfg.SigningKey,
 		crptKey,
+		agentOpts...,
 	)
+	go reloadConfig(ctx, cfg, metricsAgent, metricRules, logger.Named(loggerNameReload))
+	return metricsAgent, closeRecording
+}
+
+// senderOptions discovers the server, loads the keys and queries the server capabilities.
+//
+// Returns:
+//   - string: The public key payloads are encrypted with.
+//   - *tls.Config: The client TLS configuration, or nil without TLS.
+//   - []send.Option: The settings of the metrics sender.
+func senderOptions(
+	ctx context.Context,
+	cfg *config.Config,
+	logger *zap.SugaredLogger,
+) (string, *tls.Config, []send.Option) {
+	dial := discoverServer(ctx, cfg, logger)
+	tlsCfg := loadTLSConfig(cfg, logger)
+
+	crptKey, err := loadCryptoKey(ctx, cfg, tlsCfg, logger)
+	if err != nil {
+		logger.Fatalf("failed to load crypto key: %v", err)
+	}
+
+	caps, err := send.FetchCapabilities(ctx, cfg.ServerAddress, send.WithClientTLS(tlsCfg))
+	if err != nil {
+		logger.Warnf("Failed to query server capabilities, using default send options: %v", err)
+	}
+
+	return crptKey, tlsCfg, []send.Option{
+		send.WithKeyRotation(cfg.NextSigningKey, cfg.NextKeyPin, cfg.KeyFetch && cfg.KeyFingerprint == ""),
+		send.WithAgentID(agentID(cfg, logger)),
+		send.WithAPIKey(cfg.APIKey),
+		send.WithBuildInfo(buildinfo.New(buildVersion, buildDate, buildCommit)),
+		send.WithCapabilities(caps),
+		send.WithDialer(dial),
+		send.WithTLS(tlsCfg),
+	}
+}
+
+// memoryLimits returns the maximum batch size and the send queue size. With a memory budget, it derives
+// the sizes not configured explicitly from the budget and sets the Go runtime soft memory limit.
+func memoryLimits(cfg *config.Config, logger *zap.SugaredLogger) (int, int) {
+	batchSize, queueSize := cfg.MaxBatchSize, cfg.SendQueueSize
+	if cfg.MemoryBudget == 0 {
+		return batchSize, queueSize
+	}
+
+	limits, err := throttle.DeriveMemoryLimits(cfg.MemoryBudget, cfg.RateLimit)
+	if err != nil {
+		logger.Fatalf("invalid memory budget: %v", err)
+	}
+	if !throttle.ApplyMemoryLimit(limits) {
+		logger.Info("GOMEMLIMIT is set and takes precedence over the memory budget for the runtime memory limit")
+	}
+	if batchSize == 0 {
+		batchSize = limits.BatchSize
+	}
+	if queueSize == 0 {
+		queueSize = limits.QueueSize
+	}
+	logger.Infof(
+		"Memory budget of %d MB: batch size %d, send queue size %d",
+		cfg.MemoryBudget,
+		batchSize,
+		queueSize,
+	)
+	return batchSize, queueSize
+}
+
+// reloadConfig reloads the metric filtering and renaming rules, the poll and report intervals, the rate limit,
+// the server address and the signing key from the environment and the configuration file on SIGHUP until
+// the context is canceled, and applies them to the running agent without dropping the queued batches.
+// Rules or settings that fail to load are logged and leave the previous ones in effect.
+func reloadConfig(
+	ctx context.Context,
+	cfg *config.Config,
+	metricsAgent *agent.Agent,
+	rules *collect.MetricRules,
+	logger *zap.SugaredLogger,
+) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+			if err := updateMetricRules(cfg, rules); err != nil {
+				logger.Errorf("Failed to reload metric rules, keeping the previous ones: %v", err)
+			} else {
+				logger.Info("Reloaded metric rules")
+			}
+			if err := updateSettings(cfg, metricsAgent); err != nil {
+				logger.Errorf("Failed to reload agent settings, keeping the previous ones: %v", err)
+			} else {
+				logger.Info("Reloaded agent settings")
+			}
+		}
+	}
+}
+
+// updateMetricRules reads the metric filtering and renaming settings again and puts them in effect.
+func updateMetricRules(cfg *config.Config, rules *collect.MetricRules) error {
+	filters, err := cfg.ReloadMetricFilters()
+	if err != nil {
+		return fmt.Errorf("failed to read metric filters: %w", err)
+	}
+	renames, err := collect.ParseMetricRenames(filters.Rename)
+	if err != nil {
+		return fmt.Errorf("failed to parse metric renames: %w", err)
+	}
+	if err := rules.Update(filters.Include, filters.Exclude, renames); err != nil {
+		return fmt.Errorf("failed to build metric rules: %w", err)
+	}
+	return nil
+}
+
+// updateSettings reads the poll and report intervals, the rate limit, the server address and the signing key
+// again and applies them to the agent.
+func updateSettings(cfg *config.Config, metricsAgent *agent.Agent) error {
+	tuning, err := cfg.ReloadTuning()
+	if err != nil {
+		return fmt.Errorf("failed to read agent settings: %w", err)
+	}
+	metricsAgent.Reconfigure(agent.Settings{
+		ServerAddress:  reloadedServerAddress(cfg, tuning.ServerAddress),
+		SigningKey:     tuning.SigningKey,
+		PollInterval:   convert.IntegerToSeconds(tuning.PollInterval),
+		ReportInterval: convert.IntegerToSeconds(tuning.ReportInterval),
+		MaxSendRate:    tuning.RateLimit,
+	})
+	return nil
+}
+
+// reloadedServerAddress returns the server address to apply on reload. While the server is discovered,
+// the discovered one is kept; otherwise, as at startup, an address without a scheme gets https with TLS.
+func reloadedServerAddress(cfg *config.Config, addr string) string {
+	if cfg.ServerSRV != "" || cfg.ServerRegistry != "" {
+		return cfg.ServerAddress
+	}
+	if (cfg.TLSCert != "" || cfg.TLSCA != "") &&
+		!strings.HasPrefix(addr, "http://") && !strings.HasPrefix(addr, "https://") {
+		return "https://" + addr
+	}
+	return addr
+}
+
+// metricLabels builds the labels attached to all metrics: the Kubernetes labels of the pod the agent runs in,
+// overridden by the configured ones.
+func metricLabels(cfg *config.Config) (*collect.MetricLabels, error) {
+	labels, err := kube.Labels(os.LookupEnv, cfg.PodInfoDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Kubernetes labels: %w", err)
+	}
+	configured, err := collect.ParseMetricLabels(cfg.MetricLabels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metric labels: %w", err)
+	}
+	for name, value := range configured {
+		labels[name] = value
+	}
+	return collect.NewMetricLabels(labels) //nolint:wrapcheck // the caller adds the context.
+}
+
+// scrapeStrategy builds the strategy scraping the configured Prometheus endpoints of co-located applications.
+func scrapeStrategy(cfg *config.Config, logger *zap.SugaredLogger) *stategies.ScrapeStrategy {
+	targets, err := stategies.ParseScrapeTargets(cfg.ScrapeTargets)
+	if err != nil {
+		logger.Fatalf("failed to parse scrape targets: %v", err)
+	}
+	strategy, err := stategies.NewScrapeStrategy(targets, cfg.ScrapeSelect, logger.Named(loggerNameScrape))
+	if err != nil {
+		logger.Fatalf("failed to build scrape strategy: %v", err)
+	}
+	return strategy
+}
+
+// processStrategy builds the strategy tracking the configured processes, e.g. sidecars of the agent.
+// It returns nil with a warning when the strategy is left out of this build.
+func processStrategy(cfg *config.Config, logger *zap.SugaredLogger) *stategies.ProcessStatsCollectStrategy {
+	targets, err := stategies.ParseProcessTargets(cfg.Processes)
+	if err != nil {
+		logger.Fatalf("failed to parse process targets: %v", err)
+	}
+	strategy, err := stategies.NewProcessStatsCollectStrategy(targets, logger.Named(loggerNameProcess))
+	if errors.Is(err, stategies.ErrProcessStatsUnavailable) {
+		logger.Warnf("Not tracking processes: %v", err)
+		return nil
+	}
+	if err != nil {
+		logger.Fatalf("failed to build process strategy: %v", err)
+	}
+	return strategy
+}
+
+// clockDriftStrategy builds the strategy measuring the local clock drift against the server clock.
+func clockDriftStrategy(
+	cfg *config.Config,
+	tlsCfg *tls.Config,
+	logger *zap.SugaredLogger,
+) *stategies.ClockDriftStrategy {
+	clock, err := send.NewServerClock(cfg.ServerAddress, cfg.ClockSource, send.WithClientTLS(tlsCfg))
+	if err != nil {
+		logger.Fatalf("failed to build clock drift strategy: %v", err)
+	}
+	return stategies.NewClockDriftStrategy(clock, logger.Named(loggerNameClockDrift))
+}
+
+// discoverServer resolves the server from the configured DNS SRV records or service registry, if any.
+// The resolved target replaces the server address, so the one-off requests made at startup go to it,
+// and the returned dialer lets the sender follow the targets as they change.
+//
+// Parameters:
+//   - ctx: The context of the lookup.
+//   - cfg: The application configuration; its server address is replaced with the resolved target.
+//   - logger: The structured logger instance.
+//
+// Returns:
+//   - func: The dialer connecting to the resolved targets; nil if discovery is disabled.
+func discoverServer(
+	ctx context.Context,
+	cfg *config.Config,
+	logger *zap.SugaredLogger,
+) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if cfg.ServerSRV == "" && cfg.ServerRegistry == "" {
+		return nil
+	}
+	refresh := defaultSRVRefresh
+	if cfg.SRVRefresh > 0 {
+		refresh = convert.IntegerToSeconds(cfg.SRVRefresh)
+	}
+
+	var resolver *discovery.Resolver
+	if cfg.ServerRegistry != "" {
+		var err error
+		resolver, err = discovery.NewRegistryResolver(cfg.ServerRegistry, refresh, logger.Named(loggerNameDiscovery))
+		if err != nil {
+			logger.Fatalf("failed to create registry resolver: %v", err)
+		}
+	} else {
+		resolver = discovery.NewSRVResolver(cfg.ServerSRV, refresh, logger.Named(loggerNameDiscovery))
+	}
+	addr, err := resolver.Resolve(ctx)
+	if err != nil {
+		logger.Fatalf("failed to discover server: %v", err)
+	}
+	logger.Infof("Discovered server %s from %s", addr, resolver.Name())
+	cfg.ServerAddress = addr
+	return resolver.DialContext
+}
+
+// loadTLSConfig builds the TLS settings of the connections to the server from the configured client
+// certificate and CA bundle, if any. A server address without a scheme is switched to https.
+//
+// Parameters:
+//   - cfg: The application configuration; its server address may get the https scheme.
+//   - logger: The structured logger instance.
+//
+// Returns:
+//   - *tls.Config: The TLS settings; nil if neither a client certificate nor a CA bundle is configured.
+func loadTLSConfig(cfg *config.Config, logger *zap.SugaredLogger) *tls.Config {
+	if cfg.TLSCert == "" && cfg.TLSCA == "" {
+		return nil
+	}
+	tlsCfg, err := send.LoadTLSConfig(cfg.TLSCert, cfg.TLSKey, cfg.TLSCA)
+	if err != nil {
+		logger.Fatalf("failed to load TLS settings: %v", err)
+	}
+	switch {
+	case strings.HasPrefix(cfg.ServerAddress, "http://"):
+		logger.Warnf("TLS settings are ignored for the plain HTTP server address %s", cfg.ServerAddress)
+	case !strings.HasPrefix(cfg.ServerAddress, "https://"):
+		cfg.ServerAddress = "https://" + cfg.ServerAddress
+	}
+	return tlsCfg
+}
+
+// minPollInterval returns the shortest poll interval the adaptive polling may use,
+// defaulting to one second when it is not configured.
+func minPollInterval(cfg *config.Config) time.Duration {
+	if cfg.MinPollInterval <= 0 {
+		return time.Second
+	}
+	return convert.IntegerToSeconds(cfg.MinPollInterval)
+}
+
+// agentID returns the configured agent identifier, falling back to the hostname.
+// An empty result lets the server identify the agent by its address.
+func agentID(cfg *config.Config, logger *zap.SugaredLogger) string {
+	if cfg.AgentID != "" {
+		return cfg.AgentID
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		logger.Warnf("Failed to resolve hostname for agent ID: %v", err)
+		return ""
+	}
+	return hostname
+}
+
+// loadCryptoKey returns the public key used to encrypt payloads.
+// The key is read from the configured file or, if fetching is enabled, downloaded from the server.
+// In both cases it is checked against the pinned fingerprint when one is configured.
+//
+// Parameters:
+//   - ctx: The context controlling the key fetch.
+//   - cfg: The application configuration.
+//   - tlsCfg: The TLS settings of the connections to the server; nil for the defaults.
+//   - logger: The structured logger instance.
+//
+// Returns:
+//   - string: The public key in PEM format; empty if encryption is disabled.
+//   - error: An error if the key cannot be loaded or does not match the pinned fingerprint.
+func loadCryptoKey(
+	ctx context.Context,
+	cfg *config.Config,
+	tlsCfg *tls.Config,
+	logger *zap.SugaredLogger,
+) (string, error) {
+	if cfg.CryptoKey != "" {
+		keyData, err := os.ReadFile(cfg.CryptoKey)
+		if err != nil {
+			return "", fmt.Errorf("failed to read crypto key from file: %w", err)
+		}
+		if cfg.KeyFingerprint == "" {
+			return string(keyData), nil
+		}
+		key, _, err := send.VerifyPublicKey(string(keyData), cfg.KeyFingerprint)
+		if err != nil {
+			return "", fmt.Errorf("crypto key file verification failed: %w", err)
+		}
+		return key, nil
+	}
+
+	if !cfg.KeyFetch {
+		return "", nil
+	}
+
+	key, fingerprint, err := send.FetchPublicKey(
+		ctx, cfg.ServerAddress, cfg.KeyFingerprint, logger, send.WithClientTLS(tlsCfg),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch crypto key from server: %w", err)
+	}
+	if cfg.KeyFingerprint == "" {
+		logger.Warnf(
+			"Crypto key fetched without verification, pin it with -crypto-key-fingerprint=%s",
+			fingerprint,
+		)
+	} else {
+		logger.Infof("Crypto key fetched and verified, fingerprint %s", fingerprint)
+	}
+	return key, nil
 }
 
 // setupGracefulShutdown establishes a mechanism to gracefully shut down the
@@ -105,26 +612,73 @@ func initAgent(cfg *config.Config, logger *zap.SugaredLogger) *agent.Agent {
 //   - ctx: The context representing the application's lifecycle. Cancellation
 //     of this context initiates the shutdown process.
 //     This function will be called to signal the application to shut down.
-func setupGracefulShutdown(ctx context.Context, logger *zap.SugaredLogger) {
+//   - logger: The structured logger instance for shutdown events.
+//   - report: The shutdown report, begun on cancellation and emitted before a forced exit.
+//   - reportPath: The file the shutdown report is written to; empty to only log it.
+func setupGracefulShutdown(
+	ctx context.Context,
+	logger *zap.SugaredLogger,
+	report *shutdown.Report,
+	reportPath string,
+) {
 	go func() {
 		<-ctx.Done()
+		report.Begin()
 		logger.Infof(
 			"Context canceled. Allowing %d seconds for cleanup operations before forced application exit...",
 			gracefulShutdownTimeout/time.Second,
 		)
 		time.Sleep(gracefulShutdownTimeout) // Wait for a graceful shutdown.
 		logger.Warn("Timeout reached. Forcing application to exit.")
+		emitShutdownReport(report, logger, reportPath)
 		os.Exit(1) // Exit the application.
 	}()
 }
 
+// emitShutdownReport logs the shutdown report and writes it to a file if a path is given.
+//
+// Parameters:
+//   - report: The shutdown report.
+//   - logger: The structured logger instance for shutdown events.
+//   - path: The file the report is written to; empty to only log it.
+func emitShutdownReport(report *shutdown.Report, logger *zap.SugaredLogger, path string) {
+	if err := report.Emit(logger, path); err != nil {
+		logger.Errorf("Failed to write the shutdown report: %v", err)
+	}
+}
+
 func startProf(ctx context.Context, addr string) error {
-	srv := &http.Server{Addr: addr, Handler: nil}
+	if err := serve(ctx, &http.Server{Addr: addr, Handler: nil}); err != nil {
+		return fmt.Errorf("profiling server error: %w", err)
+	}
+	return nil
+}
+
+// startStatus serves the agent health at agent.StatusPath until the context is canceled.
+//
+// Parameters:
+//   - ctx: The context stopping the server when canceled.
+//   - addr: The address to listen on.
+//   - a: The agent whose health is served.
+//
+// Returns:
+//   - error: An error if the server cannot run or shut down.
+func startStatus(ctx context.Context, addr string, a *agent.Agent) error {
+	mux := http.NewServeMux()
+	mux.Handle(agent.StatusPath, agent.NewStatusHandler(a.Health))
+	if err := serve(ctx, &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: time.Second}); err != nil {
+		return fmt.Errorf("status server error: %w", err)
+	}
+	return nil
+}
+
+// serve runs an HTTP server until the context is canceled and then shuts it down.
+func serve(ctx context.Context, srv *http.Server) error {
 	errCh := make(chan error)
 
 	go func() {
 		if err := srv.ListenAndServe(); err != nil {
-			errCh <- fmt.Errorf("error profiling server run: %w", err)
+			errCh <- fmt.Errorf("server run error: %w", err)
 			close(errCh)
 		}
 	}()
@@ -134,10 +688,10 @@ func startProf(ctx context.Context, addr string) error {
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
 		defer cancel()
 		if err := srv.Shutdown(shutdownCtx); err != nil {
-			return fmt.Errorf("shutdown profiling server error: %w", err)
+			return fmt.Errorf("shutdown error: %w", err)
 		}
 	case listenErr := <-errCh:
-		return fmt.Errorf("running profiling server error: %w", listenErr)
+		return fmt.Errorf("running error: %w", listenErr)
 	}
 
 	return nil
@@ -3,6 +3,10 @@ package main
 import (
 	"sync"
 
+	"github.com/gdyunin/metricol.git/pkg/clock"
+	"github.com/gdyunin/metricol.git/pkg/configaudit"
+	"github.com/gdyunin/metricol.git/pkg/shutdown"
+
 	"github.com/labstack/gommon/log"
 )
 
@@ -19,32 +23,63 @@ func main() {
 		}
 	}()
 
-	setupGracefulShutdown(mainCtx, logger.Named(loggerNameGracefulShutdown))
-
 	appCfg, err := loadConfig()
 	if err != nil {
 		logger.Fatalf("Error occurred while parsing the application configuration: %v", err)
 	}
+	configaudit.Log(logger.Named(loggerNameConfig), appCfg.Audit())
+
+	shutdownLogger := logger.Named(loggerNameGracefulShutdown)
+	report := shutdown.NewReport("agent", clock.Real())
+	setupGracefulShutdown(mainCtx, shutdownLogger, report, appCfg.ShutdownReport)
+	flushTraces := setupTracing(mainCtx, appCfg, logger)
+
+	if appCfg.ReplayFile != "" {
+		replayRecording(mainCtx, appCfg, logger)
+		flushTraces()
+		return
+	}
 
-	metricsAgent := initAgent(appCfg, logger)
+	metricsAgent, closeRecording := initAgent(mainCtx, appCfg, logger)
 
 	var wg sync.WaitGroup
 
+	report.Expect(componentAgent)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		metricsAgent.Start(mainCtx)
+		report.Stopped(componentAgent, metricsAgent.Unsent(), nil)
 	}()
 
 	if appCfg.PprofFlag {
+		report.Expect(componentProf)
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			if err = startProf(mainCtx, ":34658"); err != nil {
+			err := startProf(mainCtx, ":34658")
+			report.Stopped(componentProf, 0, err)
+			if err != nil {
 				logger.Fatalf("Profiling server error: %v", err)
 			}
 		}()
 	}
 
+	if appCfg.StatusAddress != "" {
+		report.Expect(componentStatus)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := startStatus(mainCtx, appCfg.StatusAddress, metricsAgent)
+			report.Stopped(componentStatus, 0, err)
+			if err != nil {
+				logger.Errorf("Status server error: %v", err)
+			}
+		}()
+	}
+
 	wg.Wait()
+	closeRecording()
+	flushTraces()
+	emitShutdownReport(report, shutdownLogger, appCfg.ShutdownReport)
 }
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/gdyunin/metricol.git/internal/agent/config"
+	"github.com/gdyunin/metricol.git/internal/agent/replay"
+	"github.com/gdyunin/metricol.git/internal/agent/send"
+	"github.com/gdyunin/metricol.git/pkg/convert"
+
+	"go.uber.org/zap"
+)
+
+// loggerNameReplay is the logger name for the replay of a recording.
+const loggerNameReplay = "replay"
+
+// replayRecording sends the batches of the configured recording to the server, through the metrics sender
+// or, with direct replay, as plain JSON. A batch that cannot be sent stops the agent with an error.
+//
+// Parameters:
+//   - ctx: The context of the replay; canceling it stops the replay.
+//   - cfg: The agent configuration.
+//   - logger: The structured logger instance.
+func replayRecording(ctx context.Context, cfg *config.Config, logger *zap.SugaredLogger) {
+	logger = logger.Named(loggerNameReplay)
+
+	playerOpts := []replay.PlayerOption{replay.WithSpeed(cfg.ReplaySpeed)}
+	if cfg.ReplayRetime {
+		playerOpts = append(playerOpts, replay.WithRetime())
+	}
+	player, err := replay.NewPlayer(playerOpts...)
+	if err != nil {
+		logger.Fatalf("failed to set up the replay: %v", err)
+	}
+
+	var sender replay.Sender
+	if cfg.ReplayDirect {
+		sender = replay.NewDirectSender(cfg.ServerAddress, nil)
+	} else {
+		crptKey, _, sendOpts := senderOptions(ctx, cfg, logger)
+		sender = send.NewStreamSender(
+			nil,
+			convert.IntegerToSeconds(cfg.ReportInterval),
+			cfg.RateLimit,
+			cfg.ServerAddress,
+			cfg.SigningKey,
+			crptKey,
+			logger.Named("stream_sender"),
+			sendOpts...,
+		)
+	}
+
+	recording, err := os.Open(cfg.ReplayFile)
+	if err != nil {
+		logger.Fatalf("failed to open the recording: %v", err)
+	}
+	defer func() { _ = recording.Close() }()
+
+	logger.Infof("Replaying %s to %s", cfg.ReplayFile, cfg.ServerAddress)
+	sent, err := player.Play(ctx, recording, sender)
+	if err != nil {
+		logger.Fatalf("replay stopped after %d batches: %v", sent, err)
+	}
+	logger.Infof("Replayed %d batches", sent)
+}
@@ -1,31 +1,51 @@
-// Package main provides a CLI tool for generating RSA key pairs and saving them to files.
+// Package main provides a CLI tool for generating RSA or X25519 key pairs and saving them to files.
 package main
 
 import (
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
 	"flag"
 	"fmt"
 	"os"
+
+	"github.com/gdyunin/metricol.git/pkg/pubkey"
+	"github.com/gdyunin/metricol.git/pkg/x25519box"
 )
 
-const defaultKeySize = 2048
+const (
+	defaultKeySize = 2048
+	keyTypeRSA     = "rsa"
+	keyTypeX25519  = "x25519"
+)
 
 func main() {
 	// Command-line flags
 	var (
 		privateKeyPath string
 		publicKeyPath  string
+		keyType        string
 		keySize        int
 	)
 
 	flag.StringVar(&privateKeyPath, "private", "private_key.pem", "Path to save the private key")
 	flag.StringVar(&publicKeyPath, "public", "public_key.pem", "Path to save the public key")
-	flag.IntVar(&keySize, "size", defaultKeySize, "Key size in bits (2048 or 4096 is recommended)")
+	flag.StringVar(&keyType, "type", keyTypeRSA, "Key type: rsa or x25519")
+	flag.IntVar(&keySize, "size", defaultKeySize, "RSA key size in bits (2048 or 4096 is recommended)")
 	flag.Parse()
 
+	switch keyType {
+	case keyTypeRSA:
+	case keyTypeX25519:
+		generateX25519(privateKeyPath, publicKeyPath)
+		return
+	default:
+		panic(fmt.Errorf("unknown key type %q, use %s or %s", keyType, keyTypeRSA, keyTypeX25519))
+	}
+
 	fmt.Println("Generating RSA key pair...")
 
 	// Generate the RSA private key
@@ -47,6 +67,42 @@ func main() {
 	fmt.Println("Keys successfully generated!")
 	fmt.Println("Private key saved to:", privateKeyPath)
 	fmt.Println("Public key saved to:", publicKeyPath)
+
+	// Print the fingerprint agents pin when fetching the key from the server
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		panic(fmt.Errorf("failed to serialize public key: %w", err))
+	}
+	fingerprint := sha256.Sum256(der)
+	fmt.Println("Public key fingerprint:", hex.EncodeToString(fingerprint[:]))
+}
+
+// generateX25519 generates an X25519 key pair, saves it to the specified files in PEM format
+// and prints the fingerprint of the public key.
+func generateX25519(privateKeyPath, publicKeyPath string) {
+	fmt.Println("Generating X25519 key pair...")
+
+	privateKeyPEM, publicKeyPEM, err := x25519box.GenerateKey()
+	if err != nil {
+		panic(err)
+	}
+	if err = os.WriteFile(privateKeyPath, []byte(privateKeyPEM), 0o600); err != nil {
+		panic(fmt.Errorf("failed to write private key file: %w", err))
+	}
+	//nolint:gosec // public keys are not secret.
+	if err = os.WriteFile(publicKeyPath, []byte(publicKeyPEM), 0o644); err != nil {
+		panic(fmt.Errorf("failed to write public key file: %w", err))
+	}
+
+	fmt.Println("Keys successfully generated!")
+	fmt.Println("Private key saved to:", privateKeyPath)
+	fmt.Println("Public key saved to:", publicKeyPath)
+
+	fingerprint, err := pubkey.Fingerprint(publicKeyPEM)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("Public key fingerprint:", fingerprint)
 }
 
 // savePrivateKey saves the RSA private key to the specified file in PEM format.
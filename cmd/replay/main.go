@@ -0,0 +1,74 @@
+// Package main provides a CLI tool replaying captured requests against a metricol server,
+// e.g. to reproduce a production incident in staging.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/replay"
+)
+
+const defaultRequestTimeout = 10 * time.Second
+
+func main() {
+	// Command-line flags
+	var (
+		inputPath string
+		target    string
+		signKey   string
+		speed     float64
+	)
+
+	flag.StringVar(&inputPath, "f", "requests.json", "Path to recorded requests (/debug/requests output) or a HAR file")
+	flag.StringVar(&target, "a", "http://localhost:8080", "Base URL of the target server")
+	flag.StringVar(&signKey, "k", "", "Signing key used to sign replayed request bodies")
+	flag.Float64Var(&speed, "speed", 1, "Replay speed factor: 1 keeps the original timing, 0 sends without pauses")
+	flag.Parse()
+
+	if err := run(inputPath, target, signKey, speed); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run loads the captured requests and replays them, printing the outcome of every request.
+func run(inputPath, target, signKey string, speed float64) error {
+	f, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("failed to open captured traffic: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	entries, err := replay.Load(f)
+	if err != nil {
+		return fmt.Errorf("failed to load captured traffic: %w", err)
+	}
+	fmt.Printf("Replaying %d requests against %s\n", len(entries), target)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	failed := 0
+	replayer := replay.NewReplayer(&http.Client{Timeout: defaultRequestTimeout}, target, speed, signKey)
+	err = replayer.Run(ctx, entries, func(r replay.Result) {
+		if r.Err != nil {
+			failed++
+			fmt.Printf("%s %s: %v\n", r.Entry.Method, r.Entry.Path, r.Err)
+			return
+		}
+		fmt.Printf("%s %s: %d in %s\n", r.Entry.Method, r.Entry.Path, r.Status, r.Elapsed.Round(time.Millisecond))
+	})
+	if err != nil {
+		return fmt.Errorf("replay stopped: %w", err)
+	}
+
+	fmt.Printf("Replay completed, %d of %d requests failed to send\n", failed, len(entries))
+	return nil
+}
@@ -0,0 +1,70 @@
+// Package main provides a CLI tool exporting the metric wire model as a JSON schema or a protobuf definition
+// and generating typed client structs from it, so agents, the server and third-party clients stay in sync.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+	"github.com/gdyunin/metricol.git/pkg/schema"
+)
+
+const (
+	formatJSONSchema = "jsonschema"
+	formatProto      = "proto"
+	formatGo         = "go"
+)
+
+func main() {
+	var (
+		format    string
+		output    string
+		protoPkg  string
+		goPackage string
+	)
+
+	flag.StringVar(&format, "format", formatJSONSchema, "Output format: jsonschema, proto or go")
+	flag.StringVar(&output, "o", "", "Path to write the output to (default: standard output)")
+	flag.StringVar(&protoPkg, "proto-package", "metricol.v1", "Package of the protobuf definition")
+	flag.StringVar(&goPackage, "go-package", "client", "Package of the generated Go structs")
+	flag.Parse()
+
+	m, err := model.MetricSchema()
+	if err != nil {
+		panic(err)
+	}
+	out, err := render(m, format, protoPkg, goPackage)
+	if err != nil {
+		panic(err)
+	}
+
+	if output == "" {
+		if _, err = os.Stdout.Write(out); err != nil {
+			panic(fmt.Errorf("failed to write output: %w", err))
+		}
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(output), 0o750); err != nil {
+		panic(fmt.Errorf("failed to create output directory: %w", err))
+	}
+	if err = os.WriteFile(output, out, 0o600); err != nil {
+		panic(fmt.Errorf("failed to write output file: %w", err))
+	}
+}
+
+// render renders the model in the requested format.
+func render(m *schema.Model, format, protoPkg, goPackage string) ([]byte, error) {
+	switch format {
+	case formatJSONSchema:
+		return m.JSONSchema() //nolint:wrapcheck // The error names the model.
+	case formatProto:
+		return m.Proto(protoPkg), nil
+	case formatGo:
+		return m.Go(goPackage) //nolint:wrapcheck // The error names the model.
+	default:
+		return nil, fmt.Errorf("unknown format %q, use %s, %s or %s", format, formatJSONSchema, formatProto, formatGo)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gdyunin/metricol.git/internal/server/config"
+	"github.com/gdyunin/metricol.git/internal/server/delivery"
+	"github.com/gdyunin/metricol.git/internal/server/keyring"
+	"github.com/gdyunin/metricol.git/internal/server/provisioning"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/gdyunin/metricol.git/pkg/clock"
+	"github.com/gdyunin/metricol.git/pkg/shutdown"
+
+	"go.uber.org/zap"
+)
+
+// provider builds one part of the application from the configuration and the parts built before it,
+// and registers the services it runs and the actions that release it.
+type provider struct {
+	provide func(a *app) error // provide builds the part.
+	name    string             // name identifies the part in errors.
+}
+
+// service is a long-running part of the application.
+type service struct {
+	run  func(ctx context.Context) error // run blocks until ctx is canceled or the service fails.
+	name string                          // name identifies the service in logs.
+}
+
+// shutdownAction releases a part of the application on shutdown.
+type shutdownAction struct {
+	release func() // release releases the part.
+	name    string // name identifies the part in the shutdown report.
+}
+
+// app holds the parts of the server wired by providers, with the services to run and the actions
+// to execute on shutdown. Providers run in order, so a provider may use the fields set by earlier ones.
+type app struct {
+	cfg             *config.Config
+	logger          *zap.SugaredLogger
+	repo            repository.Repository     // repo is the metric storage, set by the repository provider.
+	ring            *keyring.Keyring          // ring holds the signing and encryption keys, set by the keyring provider.
+	provisioner     *provisioning.Provisioner // provisioner holds the provisioning file, set by the provisioning provider.
+	server          *delivery.EchoServer      // server is the HTTP server, set by the delivery provider.
+	report          *shutdown.Report          // report records how the services and parts stopped.
+	services        []service                 // services are started by run.
+	shutdownActions []shutdownAction          // shutdownActions release the parts in reverse order of their providers.
+	stopped         chan struct{}             // stopped is closed by run once every service has stopped.
+}
+
+// newApp wires the application by running providers in order. If a provider fails,
+// the parts built so far are released.
+//
+// Parameters:
+//   - cfg: The application configuration.
+//   - logger: The structured logger instance.
+//   - providers: The providers building the application.
+//
+// Returns:
+//   - *app: The wired application.
+//   - error: An error if a provider fails.
+func newApp(cfg *config.Config, logger *zap.SugaredLogger, providers ...provider) (*app, error) {
+	a := &app{
+		cfg:     cfg,
+		logger:  logger,
+		report:  shutdown.NewReport("server", clock.Real()),
+		stopped: make(chan struct{}),
+	}
+	for _, p := range providers {
+		if err := p.provide(a); err != nil {
+			for _, act := range a.shutdownActions {
+				act.release()
+			}
+			return nil, fmt.Errorf("%s: %w", p.name, err)
+		}
+	}
+	return a, nil
+}
+
+// addService registers a service started by run.
+//
+// Parameters:
+//   - name: The service name used in logs.
+//   - run: The function running the service until ctx is canceled.
+func (a *app) addService(name string, run func(ctx context.Context) error) {
+	a.services = append(a.services, service{name: name, run: run})
+}
+
+// onShutdown registers an action releasing a part of the application. Actions run in reverse order
+// of registration, so a part is released before the parts it depends on.
+//
+// Parameters:
+//   - name: The part name used in the shutdown report.
+//   - release: The action.
+func (a *app) onShutdown(name string, release func()) {
+	a.shutdownActions = append([]shutdownAction{{name: name, release: release}}, a.shutdownActions...)
+}
+
+// run starts every service and waits for all of them to stop, recording each one in the shutdown report,
+// and then closes stopped. A failing service stops the application.
+//
+// Parameters:
+//   - ctx: The context canceled to stop the services.
+func (a *app) run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, s := range a.services {
+		a.report.Expect(s.name)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.run(ctx)
+			a.report.Stopped(s.name, 0, err)
+			if err != nil {
+				a.logger.Fatalf("Service %s failed: %v", s.name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(a.stopped)
+}
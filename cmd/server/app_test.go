@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/config"
+	"github.com/gdyunin/metricol.git/internal/server/registration"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNewApp(t *testing.T) {
+	var released []string
+	releasing := func(name string) provider {
+		return provider{name: name, provide: func(a *app) error {
+			a.onShutdown(name, func() { released = append(released, name) })
+			return nil
+		}}
+	}
+	failing := provider{name: "broken", provide: func(*app) error { return errors.New("boom") }}
+
+	t.Run("Providers run in order", func(t *testing.T) {
+		released = nil
+		a, err := newApp(&config.Config{}, zap.NewNop().Sugar(), releasing("first"), releasing("second"))
+		require.NoError(t, err)
+		assert.Empty(t, released)
+
+		for _, act := range a.shutdownActions {
+			act.release()
+		}
+		assert.Equal(t, []string{"second", "first"}, released, "parts are released in reverse order")
+	})
+
+	t.Run("Failure releases the parts built so far", func(t *testing.T) {
+		released = nil
+		_, err := newApp(&config.Config{}, zap.NewNop().Sugar(), releasing("first"), failing, releasing("second"))
+		require.EqualError(t, err, "broken: boom")
+		assert.Equal(t, []string{"first"}, released)
+	})
+}
+
+func TestApp_Run(t *testing.T) {
+	a, err := newApp(&config.Config{}, zap.NewNop().Sugar(), provider{name: "services", provide: func(a *app) error {
+		for _, name := range []string{"one", "two"} {
+			a.addService(name, func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			})
+		}
+		return nil
+	}})
+	require.NoError(t, err)
+	require.Len(t, a.services, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		a.run(ctx)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("run did not return after the context was canceled")
+	}
+	select {
+	case <-a.stopped:
+	default:
+		t.Fatal("stopped is not closed once the services stopped")
+	}
+	summary := a.report.Summary()
+	assert.True(t, summary.Complete, "every service is recorded in the shutdown report")
+	assert.Len(t, summary.Components, 2)
+}
+
+func TestServerProviders(t *testing.T) {
+	// The server loads its templates relative to the repository root.
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir("../.."))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	cfg := &config.Config{ServerAddress: "localhost:0"}
+	a, err := newApp(cfg, zap.NewNop().Sugar(), serverProviders()...)
+	require.NoError(t, err)
+
+	assert.IsType(t, &repository.InMemoryRepository{}, a.repo)
+	assert.NotNil(t, a.ring)
+	assert.NotNil(t, a.server)
+	names := make([]string, 0, len(a.services))
+	for _, s := range a.services {
+		names = append(names, s.name)
+	}
+	assert.Equal(t, []string{"delivery", "tombstone purger"}, names, "profiling is disabled")
+	assert.Len(t, a.shutdownActions, 1)
+}
+
+func TestProvideReports(t *testing.T) {
+	cfg := &config.Config{
+		ReportSchedule: "@daily",
+		ReportSMTP:     "smtp.example.com:25",
+		ReportFrom:     "metricol@example.com",
+		ReportTo:       "ops@example.com",
+	}
+	a, err := newApp(cfg, zap.NewNop().Sugar(), provider{name: "reports", provide: provideReports})
+	require.NoError(t, err)
+	require.Len(t, a.services, 1)
+	assert.Equal(t, "reports", a.services[0].name)
+
+	_, err = newApp(&config.Config{ReportSchedule: "@daily"}, zap.NewNop().Sugar(),
+		provider{name: "reports", provide: provideReports})
+	assert.Error(t, err, "reports need a mail server")
+}
+
+func TestProvideAlerting(t *testing.T) {
+	provisioningFile := filepath.Join(t.TempDir(), "provisioning.yaml")
+	rules := []byte("alert_rules:\n  - name: high-heap\n    metric: HeapAlloc\n    op: \">\"\n    threshold: 100\n")
+	require.NoError(t, os.WriteFile(provisioningFile, rules, 0o600))
+	providers := []provider{
+		{name: "provisioning", provide: provideProvisioning},
+		{name: "alerting", provide: provideAlerting},
+	}
+
+	a, err := newApp(&config.Config{}, zap.NewNop().Sugar(), providers...)
+	require.NoError(t, err)
+	assert.Empty(t, a.services, "nothing is watched without notifiers")
+
+	cfg := &config.Config{
+		Provisioning:   provisioningFile,
+		AlertSlack:     "https://hooks.slack.com/services/T/B/X",
+		AlertInterval:  30,
+		AlertRateLimit: 10,
+	}
+	a, err = newApp(cfg, zap.NewNop().Sugar(), providers...)
+	require.NoError(t, err)
+	require.Len(t, a.services, 1)
+	assert.Equal(t, "alerting", a.services[0].name)
+
+	cfg.Provisioning = ""
+	_, err = newApp(cfg, zap.NewNop().Sugar(), providers...)
+	assert.Error(t, err, "notifiers need alert rules")
+}
+
+func TestAdvertisedInstance(t *testing.T) {
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		cfg      config.Config
+		expected registration.Instance
+	}{
+		{
+			name: "Advertise address",
+			cfg:  config.Config{ServerAddress: ":8080", Advertise: "10.0.0.1:9090"},
+			expected: registration.Instance{
+				ID: "metricol-10.0.0.1-9090", Service: "metricol", Host: "10.0.0.1", Port: 9090,
+			},
+		},
+		{
+			name: "Wildcard listen address",
+			cfg:  config.Config{ServerAddress: "[::]:8080"},
+			expected: registration.Instance{
+				ID: "metricol-" + hostname + "-8080", Service: "metricol", Host: hostname, Port: 8080,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := advertisedInstance(&tt.cfg, "metricol")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
@@ -2,20 +2,34 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
+	"github.com/gdyunin/metricol.git/internal/server/alerting"
+	"github.com/gdyunin/metricol.git/internal/server/audit"
+	"github.com/gdyunin/metricol.git/internal/server/backup"
 	"github.com/gdyunin/metricol.git/internal/server/config"
 	"github.com/gdyunin/metricol.git/internal/server/delivery"
+	"github.com/gdyunin/metricol.git/internal/server/keyring"
+	"github.com/gdyunin/metricol.git/internal/server/provisioning"
+	"github.com/gdyunin/metricol.git/internal/server/registration"
+	"github.com/gdyunin/metricol.git/internal/server/report"
 	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/gdyunin/metricol.git/pkg/buildinfo"
 	"github.com/gdyunin/metricol.git/pkg/convert"
 	"github.com/gdyunin/metricol.git/pkg/logging"
+	"github.com/gdyunin/metricol.git/pkg/shutdown"
+	"github.com/gdyunin/metricol.git/pkg/tracing"
 
+	"go.opentelemetry.io/otel"
 	"go.uber.org/zap"
 )
 
@@ -30,6 +44,26 @@ const (
 	gracefulShutdownTimeout = 5 * time.Second
 	// DefaultBackupFileName is the name of the default backup file.
 	defaultBackupFileName = "backup.txt"
+	// LoggerNamePurger is the logger name for the tombstone purger.
+	loggerNamePurger = "tombstone_purger"
+	// TombstonePurgeInterval is the period between purges of expired deleted metrics.
+	tombstonePurgeInterval = time.Minute
+	// PprofAddress is the address the profiling server listens on.
+	pprofAddress = ":34659"
+	// LoggerNameRegistration is the logger name for the service registration.
+	loggerNameRegistration = "registration"
+	// RegistrationInterval is the period of the registry health checks and registration refreshes.
+	registrationInterval = 10 * time.Second
+	// LoggerNameConfig is the logger name for the configuration report.
+	loggerNameConfig = "config"
+	// LoggerNameReports is the logger name for the report emails.
+	loggerNameReports = "reports"
+	// LoggerNameAlerting is the logger name for the alert notifications.
+	loggerNameAlerting = "alerting"
+	// LoggerNameAudit is the logger name for the audit log.
+	loggerNameAudit = "audit"
+	// ServiceName names the server in exported traces.
+	serviceName = "metricol-server"
 )
 
 var (
@@ -77,68 +111,455 @@ func loadConfig() (*config.Config, error) {
 	return cfg, nil
 }
 
-// deliveryWithShutdown holds the Echo server and shutdown actions.
+// serverProviders returns the providers building the server, in dependency order.
 //
-// Fields:
-//   - server: The Echo server instance.
-//   - shutdownActions: A list of functions to execute during shutdown.
-type deliveryWithShutdown struct {
-	server          *delivery.EchoServer
-	shutdownActions []func()
+// Returns:
+//   - []provider: The providers.
+func serverProviders() []provider {
+	return []provider{
+		{name: "tracing", provide: provideTracing},
+		{name: "repository", provide: provideRepository},
+		{name: "keyring", provide: provideKeyring},
+		{name: "provisioning", provide: provideProvisioning},
+		{name: "delivery", provide: provideDelivery},
+		{name: "tombstone purger", provide: providePurger},
+		{name: "reports", provide: provideReports},
+		{name: "alerting", provide: provideAlerting},
+		{name: "service registration", provide: provideRegistration},
+		{name: "profiling server", provide: provideProf},
+	}
+}
+
+// provideTracing installs the OpenTelemetry tracer provider exporting to the configured collector
+// and flushes the pending spans on shutdown. Without a collector only trace context is propagated.
+func provideTracing(a *app) error {
+	flush, err := tracing.Setup(context.Background(), tracing.Config{
+		Endpoint:       a.cfg.TraceEndpoint,
+		ServiceName:    serviceName,
+		ServiceVersion: buildVersion,
+		SampleRatio:    a.cfg.TraceRatio,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	if a.cfg.TraceEndpoint == "" {
+		return nil
+	}
+	a.logger.Infof("Exporting traces to %s, sampling %.0f%% of new traces", a.cfg.TraceEndpoint, a.cfg.TraceRatio*100)
+	a.onShutdown("tracing", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), gracefulShutdownTimeout)
+		defer cancel()
+		if err := flush(ctx); err != nil {
+			a.logger.Errorf("Failed to flush traces: %v", err)
+		}
+	})
+	return nil
 }
 
-// initComponentsWithShutdownActs initializes application components and generates shutdown actions.
+// provideRepository opens the configured storage, wrapped with fault injection and auditing if they are enabled,
+// and closes it on shutdown.
+func provideRepository(a *app) error {
+	repo, shutdown, err := initRepo(a.cfg, a.logger.Named(loggerNameRepository))
+	if err != nil {
+		return fmt.Errorf("failed to create repository: %w", err)
+	}
+	a.onShutdown("repository", shutdown)
+
+	if a.cfg.FaultDelayMs > 0 || a.cfg.FaultErrorRate > 0 {
+		a.logger.Warnf(
+			"Storage fault injection is enabled: %dms delay, %.0f%% errors; do not use it in production",
+			a.cfg.FaultDelayMs,
+			a.cfg.FaultErrorRate*100,
+		)
+		repo = repository.NewFaultyRepository(
+			repo,
+			convert.IntegerToMilliseconds(a.cfg.FaultDelayMs),
+			a.cfg.FaultErrorRate,
+		)
+	}
+	if a.cfg.AuditDSN != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), gracefulShutdownTimeout)
+		defer cancel()
+		sink, err := audit.Open(ctx, a.cfg.AuditDSN)
+		if err != nil {
+			return fmt.Errorf("failed to open audit log: %w", err)
+		}
+		logger := a.logger.Named(loggerNameAudit)
+		a.onShutdown("audit log", func() {
+			if err := sink.Close(); err != nil {
+				logger.Errorf("Failed to close audit log: %v", err)
+			}
+		})
+		repo = repository.NewAuditedRepository(repo, sink, logger)
+	}
+	if a.cfg.TraceEndpoint != "" {
+		// Storage spans are only worth their cost when they are exported.
+		storage, err := a.cfg.Storage()
+		if err != nil {
+			return fmt.Errorf("invalid storage configuration: %w", err)
+		}
+		repo = repository.NewTracedRepository(repo, otel.GetTracerProvider(), storage.Kind)
+	}
+	a.repo = repo
+	return nil
+}
+
+// provideKeyring loads the signing and encryption keys.
+func provideKeyring(a *app) error {
+	ring, err := initKeyring(a.cfg)
+	if err != nil {
+		return fmt.Errorf("failed to load keys: %w", err)
+	}
+	a.ring = ring
+	return nil
+}
+
+// provideProvisioning loads the provisioning file, if one is configured, for the HTTP server, the reports
+// and the alert notifications.
+func provideProvisioning(a *app) error {
+	if a.cfg.Provisioning == "" {
+		return nil
+	}
+	provisioner, err := provisioning.NewProvisioner(a.cfg.Provisioning)
+	if err != nil {
+		return fmt.Errorf("failed to load provisioning: %w", err)
+	}
+	a.provisioner = provisioner
+	return nil
+}
+
+// provideDelivery builds the HTTP server on top of the repository and the keyring.
+func provideDelivery(a *app) error {
+	opts, err := deliveryOptions(a.cfg, a.provisioner)
+	if err != nil {
+		return err
+	}
+	a.server = delivery.NewEchoServer(
+		a.cfg.ServerAddress,
+		a.ring,
+		a.repo,
+		a.logger.Named(loggerNameDelivery),
+		opts...,
+	)
+	a.addService("delivery", func(ctx context.Context) error {
+		a.server.Start(ctx)
+		return nil
+	})
+	return nil
+}
+
+// deliveryOptions translates the configuration into the options of the HTTP server.
 //
 // Parameters:
 //   - cfg: The application configuration.
-//   - logger: The structured logger instance.
+//   - provisioner: The provisioning file, nil without one.
 //
 // Returns:
-//   - *deliveryWithShutdown: A wrapper for Echo server and its shutdown actions.
-//   - error: An error if initialization fails.
-func initComponentsWithShutdownActs(
-	cfg *config.Config,
-	logger *zap.SugaredLogger,
-) (*deliveryWithShutdown, error) {
-	shutdownActions := make([]func(), 0)
-
-	repoWithShutdownFunc, err := initRepo(cfg, logger.Named(loggerNameRepository))
+//   - []delivery.Option: The server options.
+//   - error: An error if a setting cannot be parsed.
+func deliveryOptions(cfg *config.Config, provisioner *provisioning.Provisioner) ([]delivery.Option, error) {
+	prefixLimits, err := cfg.CardinalityPrefixLimits()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cardinality prefix limits: %w", err)
+	}
+	allowNames, denyNames, err := cfg.MetricNameFilter()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metric name filter: %w", err)
+	}
+	peers, err := cfg.FederationPeerURLs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse federation peers: %w", err)
+	}
+	smoothing, err := cfg.GaugeSmoothingAlphas()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse gauge smoothing: %w", err)
+	}
+	quotas, err := cfg.QuotaLimits()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse API quotas: %w", err)
+	}
+	trustedNet, err := cfg.TrustedNet()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create repository: %w", err)
+		return nil, fmt.Errorf("failed to parse trusted subnet: %w", err)
+	}
+	retentionPolicy, err := cfg.RetentionPolicy()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse retention policy: %w", err)
 	}
-	shutdownActions = append(shutdownActions, repoWithShutdownFunc.shutdown)
 
-	var crptKey string
-	if cfg.CryptoKey != "" {
-		keyData, err := os.ReadFile(cfg.CryptoKey)
+	opts := []delivery.Option{
+		delivery.WithMetricRateLimit(cfg.MetricRate),
+		delivery.WithCardinalityLimits(cfg.MaxSeries, prefixLimits),
+		delivery.WithMetricNameFilter(allowNames, denyNames, cfg.RejectFiltered),
+		delivery.WithGaugeSmoothing(smoothing),
+		delivery.WithStream(cfg.StreamBuffer, cfg.StreamPolicy),
+		delivery.WithMaxClockSkew(convert.IntegerToSeconds(cfg.MaxClockSkew)),
+		delivery.WithWriteBuffer(convert.IntegerToMilliseconds(cfg.WriteBufferMs), cfg.WriteBufferSize),
+		delivery.WithAdminCredentials(cfg.AdminToken, cfg.AdminUser, cfg.AdminPassword),
+		delivery.WithAdminAddress(cfg.AdminAddress),
+		delivery.WithBuildInfo(buildinfo.New(buildVersion, buildDate, buildCommit)),
+		delivery.WithConfigAudit(cfg.Audit()),
+		delivery.WithMinAgentVersion(cfg.MinAgentVersion),
+		delivery.WithFederation(cfg.FederationName, peers),
+		delivery.WithHistory(convert.IntegerToSeconds(cfg.SampleRetention)),
+		delivery.WithQuotas(quotas),
+		delivery.WithRetention(retentionPolicy, convert.IntegerToSeconds(cfg.RetentionSweep)),
+		delivery.WithTrustedSubnet(trustedNet),
+		delivery.WithClientRateLimit(cfg.ClientRate, cfg.ClientBurst, cfg.ClientRateBy == config.RateLimitByAPIKey),
+	}
+	if cfg.RecordRequests {
+		opts = append(opts, delivery.WithRequestRecording(cfg.RecordBuffer))
+	}
+	if cfg.AuditDSN != "" {
+		opts = append(opts, delivery.WithAuditRequests())
+	}
+	if provisioner != nil {
+		opts = append(opts, delivery.WithProvisioning(provisioner))
+	}
+	if cfg.BackupKey != "" || cfg.BackupRecipient != "" {
+		recipient, err := readKeyFile(cfg.BackupRecipient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup recipient key: %w", err)
+		}
+		codec, err := backup.New(cfg.BackupKey, recipient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure backups: %w", err)
+		}
+		opts = append(opts, delivery.WithBackup(codec))
+	}
+	if cfg.BackupDir != "" {
+		dir, err := backup.NewDir(cfg.BackupDir)
 		if err != nil {
-			logger.Fatalf("failed to read crypto key from file: %v", err)
+			return nil, fmt.Errorf("failed to open backup directory: %w", err)
 		}
-		crptKey = string(keyData)
+		opts = append(opts, delivery.WithBackupDir(dir))
 	}
+	return opts, nil
+}
 
-	echoDelivery := delivery.NewEchoServer(
-		cfg.ServerAddress,
-		cfg.SigningKey,
-		crptKey,
-		repoWithShutdownFunc.repository,
-		logger.Named(loggerNameDelivery),
+// providePurger runs the background job removing expired deleted metrics from the repository.
+func providePurger(a *app) error {
+	purger := repository.NewTombstonePurger(
+		a.repo,
+		convert.IntegerToSeconds(a.cfg.TombstoneTTL),
+		tombstonePurgeInterval,
+		a.logger.Named(loggerNamePurger),
+	)
+	a.addService("tombstone purger", func(ctx context.Context) error {
+		purger.Start(ctx)
+		return nil
+	})
+	return nil
+}
+
+// provideReports emails the reports of the stored metrics on the configured schedule.
+func provideReports(a *app) error {
+	schedule, mailer, err := a.cfg.Reports()
+	if err != nil {
+		return fmt.Errorf("failed to configure reports: %w", err)
+	}
+	if schedule == nil {
+		return nil
+	}
+	var rules report.AlertRules
+	if a.provisioner != nil {
+		rules = a.provisioner
+	}
+	job := report.NewJob(schedule, a.repo, rules, mailer, a.logger.Named(loggerNameReports))
+	a.addService("reports", func(ctx context.Context) error {
+		job.Start(ctx)
+		return nil
+	})
+	return nil
+}
+
+// provideAlerting evaluates the provisioned alert rules in the background and notifies the configured
+// chat integrations when a rule starts or stops firing.
+func provideAlerting(a *app) error {
+	notifiers, err := a.cfg.Notifiers()
+	if err != nil {
+		return fmt.Errorf("failed to configure notifiers: %w", err)
+	}
+	if len(notifiers) == 0 {
+		return nil
+	}
+	if a.provisioner == nil {
+		return errors.New("notifiers need alert rules from a provisioning file")
+	}
+	tmpl, err := a.cfg.NotificationTemplate()
+	if err != nil {
+		return fmt.Errorf("failed to load notification template: %w", err)
+	}
+	watcher := alerting.NewWatcher(
+		convert.IntegerToSeconds(a.cfg.AlertInterval),
+		a.repo,
+		a.provisioner,
+		notifiers,
+		a.logger.Named(loggerNameAlerting),
+		alerting.WithTemplate(tmpl),
+		alerting.WithRateLimit(a.cfg.AlertRateLimit),
+	)
+	a.addService("alerting", func(ctx context.Context) error {
+		watcher.Start(ctx)
+		return nil
+	})
+	return nil
+}
+
+// provideRegistration keeps the server registered in the configured service registry while it is ready.
+func provideRegistration(a *app) error {
+	if a.cfg.Registry == "" {
+		return nil
+	}
+	registry, service, err := registration.New(a.cfg.Registry, registrationInterval)
+	if err != nil {
+		return fmt.Errorf("failed to create registry client: %w", err)
+	}
+	instance, err := advertisedInstance(a.cfg, service)
+	if err != nil {
+		return err
+	}
+	registrar := registration.NewRegistrar(
+		registry,
+		instance,
+		a.server.Ready,
+		registrationInterval,
+		a.logger.Named(loggerNameRegistration),
 	)
+	a.addService("service registration", func(ctx context.Context) error {
+		registrar.Start(ctx)
+		return nil
+	})
+	return nil
+}
 
-	return &deliveryWithShutdown{
-		server:          echoDelivery,
-		shutdownActions: shutdownActions,
+// advertisedInstance describes the server as agents reach it: at the advertise address if it is set,
+// otherwise at the listen address with the hostname in place of a wildcard host.
+//
+// Parameters:
+//   - cfg: The application configuration.
+//   - service: The service name to register under.
+//
+// Returns:
+//   - registration.Instance: The server instance.
+//   - error: An error if the address cannot be parsed or the hostname cannot be determined.
+func advertisedInstance(cfg *config.Config, service string) (registration.Instance, error) {
+	addr := cfg.Advertise
+	if addr == "" {
+		addr = cfg.ServerAddress
+	}
+	host, rawPort, err := net.SplitHostPort(addr)
+	if err != nil {
+		return registration.Instance{}, fmt.Errorf("invalid advertise address %q: %w", addr, err)
+	}
+	port, err := strconv.Atoi(rawPort)
+	if err != nil {
+		return registration.Instance{}, fmt.Errorf("invalid advertise port %q: %w", rawPort, err)
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		if host, err = os.Hostname(); err != nil {
+			return registration.Instance{}, fmt.Errorf("failed to determine the hostname to advertise: %w", err)
+		}
+	}
+	return registration.Instance{
+		ID:      fmt.Sprintf("%s-%s-%d", service, host, port),
+		Service: service,
+		Host:    host,
+		Port:    port,
 	}, nil
 }
 
-// repoWithShutdown holds the repository instance and its shutdown function.
+// provideProf runs the profiling server if it is enabled.
+func provideProf(a *app) error {
+	if !a.cfg.PprofFlag {
+		return nil
+	}
+	a.addService("profiling server", func(ctx context.Context) error {
+		return startProf(ctx, pprofAddress)
+	})
+	return nil
+}
+
+// initKeyring loads the signing and encryption keys, including the ones being rotated in.
+//
+// Parameters:
+//   - cfg: The application configuration.
 //
-// Fields:
-//   - repository: The repository instance.
-//   - shutdown: The function to cleanly shut down the repository.
-type repoWithShutdown struct {
-	repository repository.Repository
-	shutdown   func()
+// Returns:
+//   - *keyring.Keyring: The keyring used by the delivery layer.
+//   - error: An error if a key file cannot be read or parsed.
+func initKeyring(cfg *config.Config) (*keyring.Keyring, error) {
+	cryptoKey, err := readKeyFile(cfg.CryptoKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read crypto key from file: %w", err)
+	}
+	nextCryptoKey, err := readKeyFile(cfg.NextCryptoKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read next crypto key from file: %w", err)
+	}
+
+	ring, err := keyring.New(
+		cfg.SigningKey,
+		cfg.NextSigningKey,
+		cryptoKey,
+		nextCryptoKey,
+		convert.IntegerToSeconds(cfg.RotationGrace),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create keyring: %w", err)
+	}
+	return ring, nil
+}
+
+// readKeyFile returns the content of the key file at path, or an empty string if path is empty.
+func readKeyFile(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %q: %w", path, err)
+	}
+	return string(data), nil
+}
+
+// runMigrationCommand runs the one-shot migration command requested in the configuration
+// and prints the resulting migration status.
+//
+// Parameters:
+//   - cfg: The application configuration.
+//
+// Returns:
+//   - error: An error if the database cannot be reached or the migration fails.
+func runMigrationCommand(cfg *config.Config) error {
+	storage, err := cfg.Storage()
+	if err != nil {
+		return fmt.Errorf("invalid storage: %w", err)
+	}
+	migrator, err := repository.NewMigrator(storage.Location)
+	if err != nil {
+		return fmt.Errorf("failed to create migrator: %w", err)
+	}
+	defer migrator.Close()
+
+	switch {
+	case cfg.MigrateUp:
+		err = migrator.Up()
+	case cfg.MigrateDown:
+		err = migrator.Down()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to migrate: %w", err)
+	}
+
+	status, err := migrator.Status()
+	if err != nil {
+		return fmt.Errorf("failed to get migration status: %w", err)
+	}
+	fmt.Printf("Schema version: %d (dirty: %t)\n", status.Version, status.Dirty)
+	fmt.Printf("Applied migrations: %v\n", status.Applied)
+	fmt.Printf("Pending migrations: %v\n", status.Pending)
+	return nil
 }
 
 // initRepo initializes the repository component and its shutdown function.
@@ -148,60 +569,114 @@ type repoWithShutdown struct {
 //   - logger: The structured logger instance for the repository.
 //
 // Returns:
-//   - *repoWithShutdown: A wrapper for repository and its shutdown function.
+//   - repository.Repository: The repository.
+//   - func(): The function to cleanly shut down the repository.
 //   - error: An error if initialization fails.
-func initRepo(cfg *config.Config, logger *zap.SugaredLogger) (*repoWithShutdown, error) {
+func initRepo(cfg *config.Config, logger *zap.SugaredLogger) (repository.Repository, func(), error) {
 	var doNothing = func() {}
 
-	if cfg.DatabaseDSN != "" {
-		r, err := repository.NewPostgreSQL(logger, cfg.DatabaseDSN)
-		if err != nil {
-			return nil, fmt.Errorf("failed to initialize PostgreSQL repository: %w", err)
-		}
-		return &repoWithShutdown{repository: r, shutdown: r.Shutdown}, nil
+	storage, err := cfg.Storage()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid storage configuration: %w", err)
+	}
+	if storage.Kind == config.StoragePostgres && cfg.StorageDSN == "" && cfg.FileStoragePath != "" {
+		logger.Warnf(
+			"Both the database DSN and the file storage path are set, the file storage path %s is ignored; "+
+				"select the storage explicitly with STORAGE_DSN",
+			cfg.FileStoragePath,
+		)
 	}
+	logger.Infof("Using %s", storage)
 
-	if cfg.FileStoragePath != "" {
-		r := repository.NewInFileRepository(
+	switch storage.Kind {
+	case config.StoragePostgres:
+		r, err := repository.NewPostgreSQL(
 			logger,
-			cfg.FileStoragePath,
+			storage.Location,
+			repository.WithReplica(cfg.ReplicaDSN),
+			repository.WithAutoMigrate(cfg.AutoMigrate),
+			repository.WithLazyConnect(cfg.LazyConnect),
+			repository.WithConnPool(
+				cfg.DBMaxOpenConns,
+				cfg.DBMaxIdleConns,
+				convert.IntegerToSeconds(cfg.DBConnLifetime),
+			),
+			repository.WithStatementCache(cfg.DBStmtCache),
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to initialize PostgreSQL repository: %w", err)
+		}
+		return r, r.Shutdown, nil
+	case config.StorageFile:
+		fsyncPolicy, err := repository.ParseFsyncPolicy(cfg.FsyncPolicy)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid file repository configuration: %w", err)
+		}
+		r, err := repository.NewInFileRepository(
+			logger,
+			storage.Location,
 			defaultBackupFileName,
 			convert.IntegerToSeconds(cfg.StoreInterval),
 			cfg.Restore,
+			repository.WithMaxFileSize(int64(cfg.MaxFileSize)),
+			repository.WithFsyncPolicy(fsyncPolicy, convert.IntegerToSeconds(cfg.FsyncInterval)),
 		)
-		return &repoWithShutdown{repository: r, shutdown: r.Shutdown}, nil
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to initialize file repository: %w", err)
+		}
+		return r, r.Shutdown, nil
+	default:
+		return repository.NewInMemoryRepository(logger), doNothing, nil
 	}
-
-	return &repoWithShutdown{
-		repository: repository.NewInMemoryRepository(logger),
-		shutdown:   doNothing,
-	}, nil
 }
 
 // setupGracefulShutdown configures the graceful shutdown mechanism for the application.
+// The shutdown actions run once the services have stopped, so no service uses a released part, e.g. the
+// HTTP server flushing buffered updates into the repository. They run one after another, in the given order,
+// and are recorded in the shutdown report, which is emitted before a forced exit.
 //
 // Parameters:
 //   - ctxCancel: The cancel function to terminate the application context.
 //   - logger: The structured logger instance for shutdown events.
-//   - shutdownActions: A variadic list of functions to execute during shutdown.
+//   - report: The shutdown report.
+//   - reportPath: The file the shutdown report is written to; empty to only log it.
+//   - servicesStopped: A channel closed once every service has stopped.
+//   - shutdownActions: A variadic list of actions to execute during shutdown.
+//
+// Returns:
+//   - <-chan struct{}: A channel closed once every shutdown action has run.
 func setupGracefulShutdown(
 	ctxCancel context.CancelFunc,
 	logger *zap.SugaredLogger,
-	shutdownActions ...func(),
-) {
+	report *shutdown.Report,
+	reportPath string,
+	servicesStopped <-chan struct{},
+	shutdownActions ...shutdownAction,
+) <-chan struct{} {
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM, syscall.SIGINT, syscall.SIGTERM)
 
+	for _, act := range shutdownActions {
+		report.Expect(act.name)
+	}
+	done := make(chan struct{})
+
 	go func() {
 		<-signalChan
 		logger.Info("Received termination signal (SIGTERM or SIGINT). Initiating graceful shutdown...")
+		report.Begin()
 		ctxCancel() // Cancel the application context.
 
-		for _, act := range shutdownActions {
-			go func(fn func()) {
-				fn()
-			}(act)
-		}
+		go func() {
+			<-servicesStopped
+			for _, act := range shutdownActions {
+				report.Run(act.name, func() error {
+					act.release()
+					return nil
+				})
+			}
+			close(done)
+		}()
 
 		logger.Infof(
 			"Context canceled. Allowing %d seconds for cleanup operations before forced application exit...",
@@ -209,8 +684,22 @@ func setupGracefulShutdown(
 		)
 		time.Sleep(gracefulShutdownTimeout) // Wait for a graceful shutdown.
 		logger.Warn("Timeout reached. Forcing application to exit.")
+		emitShutdownReport(report, logger, reportPath)
 		os.Exit(0) // Exit the application.
 	}()
+	return done
+}
+
+// emitShutdownReport logs the shutdown report and writes it to a file if a path is given.
+//
+// Parameters:
+//   - report: The shutdown report.
+//   - logger: The structured logger instance for shutdown events.
+//   - path: The file the report is written to; empty to only log it.
+func emitShutdownReport(report *shutdown.Report, logger *zap.SugaredLogger, path string) {
+	if err := report.Emit(logger, path); err != nil {
+		logger.Errorf("Failed to write the shutdown report: %v", err)
+	}
 }
 
 func startProf(ctx context.Context, addr string) error {
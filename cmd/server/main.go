@@ -1,7 +1,7 @@
 package main
 
 import (
-	"sync"
+	"github.com/gdyunin/metricol.git/pkg/configaudit"
 
 	"github.com/labstack/gommon/log"
 )
@@ -23,35 +23,31 @@ func main() {
 	if err != nil {
 		logger.Fatalf("Error occurred while parsing the application configuration: %v", err)
 	}
+	configaudit.Log(logger.Named(loggerNameConfig), appCfg.Audit())
 
-	deliveryWithShutdownActs, err := initComponentsWithShutdownActs(appCfg, logger)
+	if appCfg.MigrationCommand() {
+		if err = runMigrationCommand(appCfg); err != nil {
+			logger.Fatalf("Error occurred while running the migration command: %v", err)
+		}
+		return
+	}
+
+	application, err := newApp(appCfg, logger, serverProviders()...)
 	if err != nil {
 		logger.Fatalf("Error occurred while initialize the application components: %v", err)
 	}
 
-	setupGracefulShutdown(
+	shutdownLogger := logger.Named(loggerNameGracefulShutdown)
+	shutdownDone := setupGracefulShutdown(
 		mainCtxCancel,
-		logger.Named(loggerNameGracefulShutdown),
-		deliveryWithShutdownActs.shutdownActions...,
+		shutdownLogger,
+		application.report,
+		appCfg.ShutdownReport,
+		application.stopped,
+		application.shutdownActions...,
 	)
 
-	var wg sync.WaitGroup
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		deliveryWithShutdownActs.server.Start(mainCtx)
-	}()
-
-	if appCfg.PprofFlag {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			if err = startProf(mainCtx, ":34659"); err != nil {
-				logger.Fatalf("Profiling server error: %v", err)
-			}
-		}()
-	}
-
-	wg.Wait()
+	application.run(mainCtx)
+	<-shutdownDone
+	emitShutdownReport(application.report, shutdownLogger, appCfg.ShutdownReport)
 }
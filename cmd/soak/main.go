@@ -0,0 +1,77 @@
+// Package main provides a CLI tool running the agent and the server in-process for hours under
+// a randomized workload and checking that no goroutines leak, memory stays bounded and counters
+// never go backwards, e.g. to validate a redesign of the sender before it is released.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/soak"
+	"github.com/gdyunin/metricol.git/pkg/logging"
+)
+
+func main() {
+	cfg := soak.Config{}
+
+	flag.Int64Var(&cfg.Seed, "seed", time.Now().UnixNano(), "Seed of the randomized workload, to reproduce a run")
+	flag.DurationVar(&cfg.Duration, "duration", time.Hour, "How long the workload runs")
+	flag.DurationVar(&cfg.CheckInterval, "check-interval", 10*time.Second, "Period between invariant checks")
+	flag.DurationVar(&cfg.PollInterval, "poll", 2*time.Second, "Collection interval of the agent")
+	flag.DurationVar(&cfg.ReportInterval, "report", 10*time.Second, "Send interval of the agent")
+	flag.IntVar(&cfg.Workers, "workers", 8, "Number of concurrent workload clients")
+	flag.IntVar(&cfg.Counters, "counters", 20, "Number of counters the workload updates")
+	flag.IntVar(&cfg.Gauges, "gauges", 20, "Number of gauges the workload updates")
+	flag.IntVar(&cfg.MaxBatch, "batch", 100, "Largest batch the workload sends at once")
+	flag.IntVar(&cfg.MaxHeapMB, "max-heap-mb", 256, "Heap size in MB the run must stay below; 0 disables the check")
+	flag.BoolVar(&cfg.Verbose, "v", false, "Pass the logs of the agent and the server through")
+	flag.Parse()
+
+	ok, err := run(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// run performs the soak and prints its outcome. Interrupting it ends the workload early,
+// the final checks still run.
+func run(cfg soak.Config) (bool, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger := logging.Logger(logging.LevelINFO)
+	defer func() { _ = logger.Sync() }()
+
+	result, err := soak.Run(ctx, cfg, logger)
+	if err != nil {
+		return false, fmt.Errorf("soak failed to start: %w", err)
+	}
+
+	fmt.Printf(
+		"Soak finished: seed=%d, requests=%d, failed=%d, checks=%d, peak heap=%dMB, peak goroutines=%d\n",
+		cfg.Seed,
+		result.Requests,
+		result.Failed,
+		result.Checks,
+		result.PeakHeapBytes>>20,
+		result.PeakGoroutines,
+	)
+	if result.Passed() {
+		fmt.Println("All invariants held")
+		return true, nil
+	}
+	fmt.Printf("%d invariant violations:\n", len(result.Violations))
+	for _, v := range result.Violations {
+		fmt.Printf("  - %s\n", v)
+	}
+	return false, nil
+}
@@ -8,18 +8,46 @@ package agent
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gdyunin/metricol.git/internal/agent/collect"
 	"github.com/gdyunin/metricol.git/internal/agent/collect/stategies"
+	"github.com/gdyunin/metricol.git/internal/agent/crash"
 	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/agent/lifecycle"
 	"github.com/gdyunin/metricol.git/internal/agent/send"
 
 	"go.uber.org/zap"
 )
 
-// sendQueueSizeCoefficient is used to calculate the size of the send queue based on the maximum send rate.
-const sendQueueSizeCoefficient = 5
+const (
+	// sendQueueSizeCoefficient is used to calculate the size of the send queue based on the maximum send rate.
+	sendQueueSizeCoefficient = 5
+	// componentCollector is the name of the metrics collector in health reports.
+	componentCollector = "collector"
+	// componentSender is the name of the metrics sender in health reports.
+	componentSender = "sender"
+	// defaultMinRestartBackoff is the delay before a failed component is restarted for the first time.
+	defaultMinRestartBackoff = time.Second
+	// defaultMaxRestartBackoff caps the delay between restarts of a component that keeps failing.
+	defaultMaxRestartBackoff = time.Minute
+)
+
+// DefaultStrategies returns the names of the collection strategies run when none are configured.
+// Strategies left out of the build, such as gopsutil in builds with the nogopsutil tag, are omitted.
+//
+// Returns:
+//   - []string: The strategy names.
+func DefaultStrategies() []string {
+	var names []string
+	for _, name := range []string{stategies.MemStatsStrategyName, stategies.GopsStatsStrategyName} {
+		if collect.StrategyAvailable(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
 
 // Collector defines an interface for collecting and exporting metrics.
 // Implementations of Collector should gather metrics from the system or application and provide
@@ -56,12 +84,26 @@ type Sender interface {
 type Agent struct {
 	logger         *zap.SugaredLogger
 	sendQueue      chan *entity.Metrics
+	crash          *crash.Reporter                // crash records panics of the agent goroutines.
+	components     map[string]lifecycle.Component // components are the supervised collector and sender by name.
+	collector      *collect.StreamCollector       // collector is the collector run by Start, nil before.
+	sender         *send.StreamSender             // sender is the sender run by Start, nil before.
 	serverAddress  string
 	signKey        string
 	cryptoKey      string
+	sendOpts       []send.Option
+	collectOpts    []collect.Option
+	strategies     []collect.Strategy // strategies are run next to the named collection strategies.
+	strategyNames  []string           // strategyNames are the registered collection strategies to run.
+	mu             sync.Mutex         // mu protects components, collector, sender and the settings changed by Reconfigure.
+	unsent         atomic.Int64       // unsent is the number of metrics left in the send queue when Start returned.
 	pollInterval   time.Duration
 	reportInterval time.Duration
+	minBackoff     time.Duration // minBackoff is the delay before a failed component is restarted.
+	maxBackoff     time.Duration // maxBackoff caps the delay between restarts of a failing component.
 	maxSendRate    int
+	queueSize      int  // queueSize is the capacity of the send queue, derived from maxSendRate if zero.
+	heartbeat      bool // heartbeat enables sending the agent health to the server.
 }
 
 // NewAgent creates and initializes a new Agent.
@@ -73,6 +115,8 @@ type Agent struct {
 //   - maxSendRate: Maximum number of metric batches that can be sent per report interval (int).
 //   - serverAddress: Address of the remote server to which metrics are sent (string).
 //   - signKey: Signing key used for authentication when sending metrics (string).
+//   - cryptoKey: Public key used for payload encryption (string).
+//   - opts: Optional agent settings ([]Option).
 //
 // Returns:
 //   - *Agent: A pointer to the initialized Agent.
@@ -84,28 +128,42 @@ func NewAgent(
 	serverAddress string,
 	signKey string,
 	cryptoKey string,
+	opts ...Option,
 ) *Agent {
 	logger.Infof(
 		"Initializing Agent: pollInterval=%ds, reportInterval=%ds",
 		pollInterval/time.Second,
 		reportInterval/time.Second,
 	)
-	return &Agent{
+	a := &Agent{
 		pollInterval:   pollInterval,
 		reportInterval: reportInterval,
 		logger:         logger,
-		sendQueue:      make(chan *entity.Metrics, maxSendRate*sendQueueSizeCoefficient),
 		maxSendRate:    maxSendRate,
 		serverAddress:  serverAddress,
 		signKey:        signKey,
 		cryptoKey:      cryptoKey,
+		minBackoff:     defaultMinRestartBackoff,
+		maxBackoff:     defaultMaxRestartBackoff,
+		crash:          crash.NewReporter("", logger.Named("crash")),
+		strategyNames:  DefaultStrategies(),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.queueSize <= 0 {
+		a.queueSize = maxSendRate * sendQueueSizeCoefficient
 	}
+	a.sendQueue = make(chan *entity.Metrics, a.queueSize)
+	return a
 }
 
 // Start begins the operation of the Agent.
 // This method runs indefinitely, managing the collection and sending of metrics based on the configured intervals.
-// It launches separate goroutines for collecting and sending metrics,
-// and can be stopped by canceling the provided context.
+// It launches separate goroutines for collecting and sending metrics, each under a supervisor that restarts
+// the component with backoff if it panics or stops on its own, and can be stopped by canceling the provided context.
+// The send queue is closed once both components have stopped, and the metrics left in it are counted
+// as unsent.
 //
 // Parameters:
 //   - ctx: Context for managing the lifecycle of the Agent (context.Context).
@@ -113,27 +171,42 @@ func NewAgent(
 // Returns:
 //   - This function does not return any value.
 func (a *Agent) Start(ctx context.Context) {
+	// Initialize collection strategies for gathering metrics.
+	collectStrategies, err := collect.NewStrategies(a.strategyNames, a.logger.Named("strategy"))
+	if err != nil {
+		a.logger.Errorf("Failed to create collection strategies, only the additional ones are run: %v", err)
+	}
+	collectStrategies = append(collectStrategies, a.strategies...)
+	collectStrategies = append(collectStrategies, a.crash)
+
+	// The settings are read under the lock, so none changed by Reconfigure meanwhile is lost.
+	a.mu.Lock()
 	a.logger.Infof(
 		"Agent started: pollInterval=%ds, reportInterval=%ds",
 		a.pollInterval/time.Second,
 		a.reportInterval/time.Second,
 	)
 
-	// Initialize collection strategies for gathering metrics.
-	collectStrategies := []collect.Strategy{
-		stategies.NewMemStatsCollectStrategy(a.logger.Named("mem_strategy")),
-		stategies.GopsMemStatsCollectStrategy(a.logger.Named("gops_strategy")),
-	}
-
 	// Create a new stream collector that gathers metrics and sends them to the sendQueue.
+	// The queue outlives collector restarts, so it is closed here rather than by the collector.
+	collectOpts := append(
+		a.collectOpts[:len(a.collectOpts):len(a.collectOpts)],
+		collect.WithOpenStream(),
+		collect.WithCrashReporter(a.crash),
+	)
 	streamCollector := collect.NewStreamCollector(
 		a.sendQueue,
 		a.pollInterval,
 		collectStrategies,
 		a.logger.Named("collector"),
+		collectOpts...,
 	)
 
 	// Create a new stream sender that sends metrics from the sendQueue to the remote server.
+	sendOpts := append(a.sendOpts[:len(a.sendOpts):len(a.sendOpts)], send.WithCrashReporter(a.crash))
+	if a.heartbeat {
+		sendOpts = append(sendOpts[:len(sendOpts):len(sendOpts)], send.WithHeartbeat(a.Health))
+	}
 	streamSenderLogger := a.logger.Named("stream_sender")
 	streamSender := send.NewStreamSender(
 		a.sendQueue,
@@ -143,24 +216,128 @@ func (a *Agent) Start(ctx context.Context) {
 		a.signKey,
 		a.cryptoKey,
 		streamSenderLogger,
+		sendOpts...,
 	)
 
-	// Define workers for collection and sending.
-	workers := []func(context.Context){
-		streamCollector.StartStreaming,
-		streamSender.StartStreaming,
+	supervisorLogger := a.logger.Named("supervisor")
+	onPanic := lifecycle.WithPanicHandler(func(name string, value any, stack []byte) {
+		a.crash.Record(name, value, stack, "")
+	})
+	components := map[string]lifecycle.Component{
+		componentCollector: lifecycle.NewSupervisor(
+			componentCollector, streamCollector, a.minBackoff, a.maxBackoff, supervisorLogger, onPanic,
+		),
+		componentSender: lifecycle.NewSupervisor(
+			componentSender, streamSender, a.minBackoff, a.maxBackoff, supervisorLogger, onPanic,
+		),
 	}
+	a.components = components
+	a.collector, a.sender = streamCollector, streamSender
+	a.mu.Unlock()
 
 	var wg sync.WaitGroup
-	// Start each worker in its own goroutine.
-	for _, worker := range workers {
+	// Start each component in its own goroutine.
+	for _, component := range components {
 		wg.Add(1)
-		go func(w func(context.Context)) {
+		go func(c lifecycle.Component) {
 			defer wg.Done()
-			w(ctx)
-		}(worker)
+			defer a.crash.Recover("agent", nil)
+			c.Start(ctx)
+		}(component)
 	}
 
-	// Wait for all workers to finish.
+	// Wait for all components to finish.
 	wg.Wait()
+	close(a.sendQueue)
+	for batch := range a.sendQueue {
+		if batch != nil {
+			a.unsent.Add(int64(len(*batch)))
+		}
+	}
+}
+
+// Settings holds the agent settings that can be changed while it runs.
+type Settings struct {
+	ServerAddress  string        // ServerAddress is the address of the server metrics are sent to.
+	SigningKey     string        // SigningKey signs the requests.
+	PollInterval   time.Duration // PollInterval is the interval of collecting metrics.
+	ReportInterval time.Duration // ReportInterval is the interval of sending metrics.
+	MaxSendRate    int           // MaxSendRate is the maximum number of batches sent per report interval.
+}
+
+// Reconfigure applies new settings to the agent, whether it runs or not. The batches waiting in the send queue
+// are kept and sent with the new settings; the queue keeps its capacity. Only the settings that differ from
+// the ones in effect are applied, so a signing key switched by the key rotation is kept unless the configured
+// key changes. Non-positive intervals and rates are ignored.
+//
+// Parameters:
+//   - settings: The new settings.
+func (a *Agent) Reconfigure(settings Settings) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if settings.PollInterval > 0 && settings.PollInterval != a.pollInterval {
+		a.logger.Infof("Poll interval changed from %s to %s", a.pollInterval, settings.PollInterval)
+		a.pollInterval = settings.PollInterval
+		if a.collector != nil {
+			a.collector.SetInterval(settings.PollInterval)
+		}
+	}
+	if settings.ReportInterval > 0 && settings.ReportInterval != a.reportInterval {
+		a.logger.Infof("Report interval changed from %s to %s", a.reportInterval, settings.ReportInterval)
+		a.reportInterval = settings.ReportInterval
+		if a.sender != nil {
+			a.sender.SetInterval(settings.ReportInterval)
+		}
+	}
+	if settings.MaxSendRate > 0 && settings.MaxSendRate != a.maxSendRate {
+		a.logger.Infof("Send rate changed from %d to %d", a.maxSendRate, settings.MaxSendRate)
+		a.maxSendRate = settings.MaxSendRate
+		if a.sender != nil {
+			a.sender.SetMaxPoolSize(settings.MaxSendRate)
+		}
+	}
+	if settings.ServerAddress != "" && settings.ServerAddress != a.serverAddress {
+		a.logger.Infof("Server address changed from %s to %s", a.serverAddress, settings.ServerAddress)
+		a.serverAddress = settings.ServerAddress
+		if a.sender != nil {
+			a.sender.SetServerAddress(settings.ServerAddress)
+		}
+	}
+	if settings.SigningKey != a.signKey {
+		a.logger.Info("Signing key changed")
+		a.signKey = settings.SigningKey
+		if a.sender != nil {
+			a.sender.SetSigningKey(settings.SigningKey)
+		}
+	}
+}
+
+// Unsent returns the number of metrics collected but never sent because they were still queued
+// when the agent stopped. It is meaningful once Start has returned.
+//
+// Returns:
+//   - int: The number of unsent metrics.
+func (a *Agent) Unsent() int {
+	return int(a.unsent.Load())
+}
+
+// Stop stops the components of an agent started with Start, which then returns.
+func (a *Agent) Stop() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, c := range a.components {
+		c.Stop()
+	}
+}
+
+// Health reports the health of the collector and the sender. Before Start it reports no components.
+//
+// Returns:
+//   - lifecycle.Health: The health of every component and the aggregated status.
+func (a *Agent) Health() lifecycle.Health {
+	a.mu.Lock()
+	components := a.components
+	a.mu.Unlock()
+	return lifecycle.Check(components)
 }
@@ -2,9 +2,16 @@ package agent
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/gdyunin/metricol.git/internal/agent/collect"
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/agent/lifecycle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -82,3 +89,101 @@ func TestAgent_Start(t *testing.T) {
 		})
 	}
 }
+
+func TestAgent_HealthAndStop(t *testing.T) {
+	a := NewAgent(time.Hour, time.Hour, zap.NewNop().Sugar(), 1, "http://localhost:8080", "", "", WithHeartbeat())
+
+	h := a.Health()
+	assert.Equal(t, lifecycle.StatusOK, h.Status)
+	assert.Empty(t, h.Components)
+
+	done := make(chan struct{})
+	go func() {
+		a.Start(context.Background())
+		close(done)
+	}()
+	require.Eventually(t, func() bool {
+		h = a.Health()
+		return h.Components[componentCollector] == lifecycle.StatusOK && h.Components[componentSender] == lifecycle.StatusOK
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, lifecycle.StatusOK, h.Status)
+
+	a.Stop()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Agent.Start did not return after Stop")
+	}
+
+	h = a.Health()
+	assert.Equal(t, lifecycle.StatusDegraded, h.Status)
+	assert.Equal(t, []string{componentCollector, componentSender}, h.Unhealthy())
+}
+
+func TestDefaultStrategies(t *testing.T) {
+	names := DefaultStrategies()
+	require.NotEmpty(t, names)
+	for _, name := range names {
+		assert.True(t, collect.StrategyAvailable(name), name)
+	}
+}
+
+func TestNewAgent_SendQueueSize(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+
+	a := NewAgent(time.Second, time.Second, logger, 2, "localhost:8080", "", "")
+	assert.Equal(t, 2*sendQueueSizeCoefficient, cap(a.sendQueue))
+
+	a = NewAgent(time.Second, time.Second, logger, 2, "localhost:8080", "", "", WithSendQueueSize(3))
+	assert.Equal(t, 3, cap(a.sendQueue))
+}
+
+func TestAgent_Unsent(t *testing.T) {
+	a := NewAgent(time.Hour, time.Hour, zap.NewNop().Sugar(), 2, "http://localhost:8080", "", "")
+	a.sendQueue <- &entity.Metrics{{Name: "Alloc"}, {Name: "HeapAlloc"}}
+	a.sendQueue <- &entity.Metrics{{Name: "PollCount"}}
+	assert.Zero(t, a.Unsent())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a.Start(ctx)
+
+	assert.Equal(t, 3, a.Unsent(), "metrics left in the queue are counted once the agent stops")
+}
+
+func TestAgent_Reconfigure(t *testing.T) {
+	received := make(chan string, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	a := NewAgent(time.Hour, time.Hour, zap.NewNop().Sugar(), 1, "localhost:1", "", "")
+	a.Reconfigure(Settings{ServerAddress: "localhost:2", PollInterval: -time.Second, MaxSendRate: 2})
+	assert.Equal(t, "localhost:2", a.serverAddress)
+	assert.Equal(t, time.Hour, a.pollInterval, "a non-positive interval is ignored")
+	assert.Equal(t, 2, a.maxSendRate)
+
+	a.sendQueue <- &entity.Metrics{{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.0}}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		a.Start(ctx)
+		close(done)
+	}()
+	require.Eventually(t, func() bool {
+		return a.Health().Components[componentSender] == lifecycle.StatusOK
+	}, time.Second, time.Millisecond)
+
+	a.Reconfigure(Settings{ServerAddress: ts.URL, PollInterval: time.Hour, ReportInterval: 10 * time.Millisecond})
+	select {
+	case path := <-received:
+		assert.Equal(t, "/updates", path, "the queued batch is sent to the new server")
+	case <-time.After(time.Second):
+		t.Fatal("expected the queued batch to be sent after reconfiguring")
+	}
+
+	cancel()
+	<-done
+}
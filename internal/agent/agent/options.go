@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/collect"
+	"github.com/gdyunin/metricol.git/internal/agent/crash"
+	"github.com/gdyunin/metricol.git/internal/agent/send"
+)
+
+// Option configures optional Agent settings.
+type Option func(*Agent)
+
+// WithSendOptions passes optional settings to the metrics sender.
+//
+// Parameters:
+//   - opts: The sender settings.
+//
+// Returns:
+//   - Option: An option applying the sender settings.
+func WithSendOptions(opts ...send.Option) Option {
+	return func(a *Agent) {
+		a.sendOpts = append(a.sendOpts, opts...)
+	}
+}
+
+// WithCollectOptions passes optional settings to the metrics collector.
+//
+// Parameters:
+//   - opts: The collector settings.
+//
+// Returns:
+//   - Option: An option applying the collector settings.
+func WithCollectOptions(opts ...collect.Option) Option {
+	return func(a *Agent) {
+		a.collectOpts = append(a.collectOpts, opts...)
+	}
+}
+
+// WithSendQueueSize sets how many collected batches may wait to be sent, bounding the memory they take
+// while the server is slow or unreachable; the collector waits while the queue is full.
+// A non-positive size keeps the default derived from the send rate.
+//
+// Parameters:
+//   - size: The capacity of the send queue.
+//
+// Returns:
+//   - Option: An option applying the capacity.
+func WithSendQueueSize(size int) Option {
+	return func(a *Agent) {
+		a.queueSize = size
+	}
+}
+
+// WithHeartbeat makes the sender report the agent health to the server every report interval.
+//
+// Returns:
+//   - Option: An option enabling the heartbeat.
+func WithHeartbeat() Option {
+	return func(a *Agent) {
+		a.heartbeat = true
+	}
+}
+
+// WithStrategyNames sets the registered collection strategies the agent runs instead of DefaultStrategies.
+// An empty list keeps the defaults.
+//
+// Parameters:
+//   - names: The names the strategies are registered by with collect.RegisterStrategy.
+//
+// Returns:
+//   - Option: An option selecting the strategies.
+func WithStrategyNames(names ...string) Option {
+	return func(a *Agent) {
+		if len(names) > 0 {
+			a.strategyNames = names
+		}
+	}
+}
+
+// WithStrategies adds collection strategies run next to the named ones.
+//
+// Parameters:
+//   - strategies: The additional strategies.
+//
+// Returns:
+//   - Option: An option adding the strategies.
+func WithStrategies(strategies ...collect.Strategy) Option {
+	return func(a *Agent) {
+		a.strategies = append(a.strategies, strategies...)
+	}
+}
+
+// WithRestartBackoff sets how long the agent waits before restarting a collector or sender that failed.
+// The delay doubles after every failure in a row up to maxBackoff.
+//
+// Parameters:
+//   - minBackoff: The delay before the first restart.
+//   - maxBackoff: The maximum delay between restarts.
+//
+// Returns:
+//   - Option: An option applying the backoff.
+func WithRestartBackoff(minBackoff, maxBackoff time.Duration) Option {
+	return func(a *Agent) {
+		if minBackoff > 0 {
+			a.minBackoff = minBackoff
+		}
+		if maxBackoff > 0 {
+			a.maxBackoff = maxBackoff
+		}
+	}
+}
+
+// WithCrashDumps writes a crash dump file for every panic recovered in the agent goroutines.
+// Panics are recovered, logged and counted in the AgentPanics counter whether or not dumps are written.
+//
+// Parameters:
+//   - dir: The directory crash dumps are written to; empty disables the dumps.
+//
+// Returns:
+//   - Option: An option enabling crash dumps.
+func WithCrashDumps(dir string) Option {
+	return func(a *Agent) {
+		a.crash = crash.NewReporter(dir, a.logger.Named("crash"))
+	}
+}
@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gdyunin/metricol.git/internal/agent/lifecycle"
+)
+
+// StatusPath is the path the status handler is served at.
+const StatusPath = "/status"
+
+// NewStatusHandler creates an HTTP handler serving the agent health as JSON,
+// with 200 OK while all components are healthy and 503 Service Unavailable otherwise.
+//
+// Parameters:
+//   - health: The function reporting the agent health, usually Agent.Health.
+//
+// Returns:
+//   - http.Handler: The status handler.
+func NewStatusHandler(health func() lifecycle.Health) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		h := health()
+		status := http.StatusOK
+		if h.Status != lifecycle.StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(h)
+	})
+}
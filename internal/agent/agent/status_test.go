@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/agent/lifecycle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusHandler(t *testing.T) {
+	tests := []struct {
+		health         lifecycle.Health
+		name           string
+		method         string
+		expectedStatus int
+	}{
+		{
+			name:           "healthy",
+			method:         http.MethodGet,
+			health:         lifecycle.Health{Components: map[string]string{"sender": "ok"}, Status: lifecycle.StatusOK},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "degraded",
+			method: http.MethodGet,
+			health: lifecycle.Health{
+				Components: map[string]string{"sender": "last 3 sends failed"},
+				Status:     lifecycle.StatusDegraded,
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "wrong method",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewStatusHandler(func() lifecycle.Health { return tt.health })
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, StatusPath, http.NoBody))
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus == http.StatusMethodNotAllowed {
+				assert.Equal(t, "GET, HEAD", rec.Header().Get("Allow"))
+				return
+			}
+
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			var got lifecycle.Health
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tt.health, got)
+		})
+	}
+}
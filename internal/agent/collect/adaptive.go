@@ -0,0 +1,85 @@
+package collect
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+)
+
+const (
+	// fastChangeRate is the share of changed gauges above which the poll interval is halved.
+	fastChangeRate = 0.5
+	// stableChangeRate is the share of changed gauges below which the poll interval is doubled.
+	stableChangeRate = 0.1
+)
+
+// adaptiveInterval tunes the poll interval to how quickly metric values change. Only gauges are compared:
+// counters grow on every poll by design and would always look busy.
+type adaptiveInterval struct {
+	last    map[string]any // last holds the previous value of every observed gauge.
+	mu      sync.Mutex     // mu protects all fields.
+	current time.Duration  // current is the interval in effect.
+	min     time.Duration  // min is the shortest allowed interval.
+	max     time.Duration  // max is the longest allowed interval.
+	seen    int            // seen counts gauges compared with a previous value since the last adjustment.
+	changed int            // changed counts compared gauges whose value differed.
+}
+
+// newAdaptiveInterval creates an adaptiveInterval starting at initial, clamped to [minInterval, maxInterval].
+func newAdaptiveInterval(initial, minInterval, maxInterval time.Duration) *adaptiveInterval {
+	return &adaptiveInterval{
+		last:    make(map[string]any),
+		current: min(max(initial, minInterval), maxInterval),
+		min:     minInterval,
+		max:     maxInterval,
+	}
+}
+
+// restart starts tuning the interval over from initial, clamped to the allowed range.
+func (a *adaptiveInterval) restart(initial time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.current = min(max(initial, a.min), a.max)
+	a.seen, a.changed = 0, 0
+}
+
+// observe compares collected gauges with their previous values.
+func (a *adaptiveInterval) observe(metrics *entity.Metrics) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, m := range *metrics {
+		if m == nil || m.Type != entity.MetricTypeGauge {
+			continue
+		}
+		prev, ok := a.last[m.Name]
+		a.last[m.Name] = m.Value
+		if !ok {
+			continue
+		}
+		a.seen++
+		if prev != m.Value {
+			a.changed++
+		}
+	}
+}
+
+// next adjusts the interval to the change rate observed since the previous call and returns it.
+// Without observations the interval is kept.
+func (a *adaptiveInterval) next() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.seen > 0 {
+		rate := float64(a.changed) / float64(a.seen)
+		switch {
+		case rate >= fastChangeRate:
+			a.current = max(a.current/2, a.min)
+		case rate <= stableChangeRate:
+			a.current = min(a.current*2, a.max)
+		}
+	}
+	a.seen, a.changed = 0, 0
+	return a.current
+}
@@ -0,0 +1,103 @@
+package collect
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// gauges builds a batch of gauges with the given values, named a, b, ...
+func gauges(values ...float64) *entity.Metrics {
+	metrics := make(entity.Metrics, 0, len(values))
+	for i, v := range values {
+		metrics = append(metrics, &entity.Metric{Name: string(rune('a' + i)), Type: entity.MetricTypeGauge, Value: v})
+	}
+	return &metrics
+}
+
+func TestAdaptiveInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		batches  []*entity.Metrics
+		initial  time.Duration
+		expected []time.Duration
+	}{
+		{
+			name:     "stable metrics lengthen the interval up to max",
+			batches:  []*entity.Metrics{gauges(1, 2), gauges(1, 2), gauges(1, 2), gauges(1, 2)},
+			initial:  2 * time.Second,
+			expected: []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second},
+		},
+		{
+			name:     "changing metrics shorten the interval down to min",
+			batches:  []*entity.Metrics{gauges(1, 2), gauges(3, 4), gauges(5, 6), gauges(7, 8)},
+			initial:  4 * time.Second,
+			expected: []time.Duration{4 * time.Second, 2 * time.Second, time.Second, time.Second},
+		},
+		{
+			name:     "moderate change keeps the interval",
+			batches:  []*entity.Metrics{gauges(1, 2, 3, 4), gauges(1, 2, 3, 5)},
+			initial:  2 * time.Second,
+			expected: []time.Duration{2 * time.Second, 2 * time.Second},
+		},
+		{
+			name: "counters are ignored",
+			batches: []*entity.Metrics{
+				{{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(1)}},
+				{{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(2)}},
+			},
+			initial:  2 * time.Second,
+			expected: []time.Duration{2 * time.Second, 2 * time.Second},
+		},
+		{
+			name:     "initial interval is clamped",
+			batches:  []*entity.Metrics{gauges(1)},
+			initial:  time.Minute,
+			expected: []time.Duration{8 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAdaptiveInterval(tt.initial, time.Second, 8*time.Second)
+			for i, batch := range tt.batches {
+				a.observe(batch)
+				assert.Equal(t, tt.expected[i], a.next(), "interval after batch %d", i)
+			}
+		})
+	}
+}
+
+func TestWithAdaptiveInterval(t *testing.T) {
+	tests := []struct {
+		name    string
+		min     time.Duration
+		max     time.Duration
+		enabled bool
+	}{
+		{name: "valid bounds", min: time.Second, max: 10 * time.Second, enabled: true},
+		{name: "zero min", min: 0, max: 10 * time.Second, enabled: false},
+		{name: "min above max", min: 10 * time.Second, max: time.Second, enabled: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := NewStreamCollector(nil, 2*time.Second, nil, zap.NewNop().Sugar(), WithAdaptiveInterval(tt.min, tt.max))
+			assert.Equal(t, tt.enabled, sc.adaptive != nil)
+		})
+	}
+}
+
+func TestAdaptiveInterval_Restart(t *testing.T) {
+	a := newAdaptiveInterval(2*time.Second, time.Second, 8*time.Second)
+	a.observe(gauges(1, 2))
+	a.observe(gauges(1, 2))
+
+	a.restart(4 * time.Second)
+	assert.Equal(t, 4*time.Second, a.next(), "the observations before the restart are discarded")
+	a.restart(time.Minute)
+	assert.Equal(t, 8*time.Second, a.next(), "the new interval is clamped")
+}
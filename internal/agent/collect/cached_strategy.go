@@ -0,0 +1,82 @@
+package collect
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+)
+
+// CachedStrategy wraps an expensive Strategy and reuses its last result until the refresh interval
+// of the strategy elapses, so the collection cost does not depend on the poll interval.
+// Only gauges are reused: counters carry increments that the server would add again.
+// The strategy is safe for concurrent use.
+type CachedStrategy struct {
+	collectedAt time.Time        // collectedAt is the moment of the last successful collection.
+	strategy    Strategy         // strategy is the wrapped strategy.
+	last        *entity.Metrics  // last holds the gauges of the last successful collection.
+	now         func() time.Time // now returns the current time.
+	mu          sync.Mutex       // mu serializes collections and protects the cache.
+	ttl         time.Duration    // ttl is how long a result is reused.
+}
+
+// NewCachedStrategy wraps strategy with a cache that keeps its results for ttl.
+//
+// Parameters:
+//   - strategy: The strategy to cache.
+//   - ttl: How long a collected result is reused.
+//
+// Returns:
+//   - *CachedStrategy: The caching strategy.
+func NewCachedStrategy(strategy Strategy, ttl time.Duration) *CachedStrategy {
+	return &CachedStrategy{strategy: strategy, ttl: ttl, now: time.Now}
+}
+
+// Collect returns the cached gauges while the cache is fresh and collects from the wrapped strategy
+// otherwise. Errors are returned as is and are never cached.
+//
+// Returns:
+//   - *entity.Metrics: The collected or cached metrics.
+//   - error: An error if the wrapped strategy fails.
+func (c *CachedStrategy) Collect() (*entity.Metrics, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.last != nil && now.Sub(c.collectedAt) < c.ttl {
+		return cachedGauges(c.last), nil
+	}
+
+	metrics, err := c.strategy.Collect()
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh cached strategy: %w", err)
+	}
+	if metrics != nil {
+		// Stamp the result so replayed gauges keep the moment they were actually collected.
+		stampCollected(metrics, now)
+		c.last, c.collectedAt = cachedGauges(metrics), now
+	}
+	return metrics, nil
+}
+
+// Name returns the configuration name of the wrapped strategy.
+//
+// Returns:
+//   - string: The strategy name.
+func (c *CachedStrategy) Name() string {
+	return strategyName(c.strategy)
+}
+
+// cachedGauges copies the gauges of a cached result, so callers cannot modify the cache.
+func cachedGauges(metrics *entity.Metrics) *entity.Metrics {
+	gauges := make(entity.Metrics, 0, metrics.Length())
+	for _, m := range *metrics {
+		if m == nil || m.Type != entity.MetricTypeGauge {
+			continue
+		}
+		gauge := *m
+		gauges = append(gauges, &gauge)
+	}
+	return &gauges
+}
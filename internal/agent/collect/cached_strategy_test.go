@@ -0,0 +1,63 @@
+package collect
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedStrategy_Collect(t *testing.T) {
+	var calls int
+	var failNext bool
+	strategy := namedFuncStrategy{name: "expensive", funcStrategy: func() (*entity.Metrics, error) {
+		calls++
+		if failNext {
+			return nil, errors.New("collect error")
+		}
+		return &entity.Metrics{
+			{Name: "Load", Type: entity.MetricTypeGauge, Value: float64(calls)},
+			{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(1)},
+		}, nil
+	}}
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	cached := NewCachedStrategy(strategy, 10*time.Second)
+	cached.now = func() time.Time { return now }
+
+	assert.Equal(t, "expensive", cached.Name())
+
+	first, err := cached.Collect()
+	require.NoError(t, err)
+	require.Len(t, *first, 2)
+	assert.Equal(t, 1, calls)
+
+	now = start.Add(5 * time.Second)
+	hit, err := cached.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls, "fresh cache must not call the strategy")
+	require.Len(t, *hit, 1, "counters must not be replayed")
+	assert.Equal(t, 1.0, (*hit)[0].Value)
+	assert.Equal(t, start, (*hit)[0].Timestamp, "replayed gauges keep their collection time")
+
+	(*hit)[0].Value = 42.0
+	again, err := cached.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, (*again)[0].Value, "callers must not modify the cache")
+
+	now = start.Add(10 * time.Second)
+	failNext = true
+	_, err = cached.Collect()
+	require.Error(t, err)
+	assert.Equal(t, 2, calls)
+
+	failNext = false
+	refreshed, err := cached.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls, "errors must not be cached")
+	assert.Equal(t, 3.0, (*refreshed)[0].Value)
+}
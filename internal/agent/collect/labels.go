@@ -0,0 +1,84 @@
+package collect
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/promtext"
+)
+
+// MetricLabels attaches labels to every collected metric, such as the pod and node the agent runs on.
+// Labels are stored in the series name, e.g. `HeapAlloc{namespace="prod",pod="api-0"}`, the notation
+// the server already stores scraped and pushed series under. Labels a metric already carries win.
+type MetricLabels struct {
+	labels map[string]string // labels are the labels to attach.
+}
+
+// NewMetricLabels validates the label names and creates MetricLabels.
+//
+// Parameters:
+//   - labels: The labels to attach.
+//
+// Returns:
+//   - *MetricLabels: The labels; without labels they keep all metrics unchanged.
+//   - error: An error if a label name is not a valid Prometheus label name.
+func NewMetricLabels(labels map[string]string) (*MetricLabels, error) {
+	for name := range labels {
+		if !promtext.ValidLabelName(name) {
+			return nil, fmt.Errorf("invalid label name %q", name)
+		}
+	}
+	return &MetricLabels{labels: labels}, nil
+}
+
+// Apply adds the labels to the metric names in place. Nil labels keep the metrics unchanged,
+// and so do metrics whose names cannot be parsed as series names.
+//
+// Parameters:
+//   - metrics: The collected metrics.
+func (l *MetricLabels) Apply(metrics *entity.Metrics) {
+	if l == nil || len(l.labels) == 0 || metrics == nil {
+		return
+	}
+
+	for _, m := range *metrics {
+		if m == nil {
+			continue
+		}
+		name, own, err := promtext.SplitSeriesName(m.Name)
+		if err != nil {
+			continue
+		}
+		for k, v := range l.labels {
+			if _, exists := own[k]; !exists {
+				own[k] = v
+			}
+		}
+		m.Name = promtext.SeriesName(name, own)
+	}
+}
+
+// ParseMetricLabels parses labels given as "name=value" pairs.
+//
+// Parameters:
+//   - pairs: The label pairs.
+//
+// Returns:
+//   - map[string]string: The label values keyed by label name.
+//   - error: An error if a pair is malformed or a label is given twice.
+func ParseMetricLabels(pairs []string) (map[string]string, error) {
+	labels := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid label %q, expected name=value", pair)
+		}
+		if _, exists := labels[name]; exists {
+			return nil, fmt.Errorf("duplicate label %q", name)
+		}
+		labels[name] = value
+	}
+	return labels, nil
+}
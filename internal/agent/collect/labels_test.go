@@ -0,0 +1,69 @@
+package collect
+
+import (
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricLabels_Apply(t *testing.T) {
+	labels, err := NewMetricLabels(map[string]string{"namespace": "prod", "pod": "api-0"})
+	require.NoError(t, err)
+
+	metrics := entity.Metrics{
+		{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 1.0},
+		nil,
+		{Name: `up{job="app",pod="scraped"}`, Type: entity.MetricTypeGauge, Value: 1.0},
+		{Name: `broken{job=`, Type: entity.MetricTypeGauge, Value: 1.0},
+	}
+	labels.Apply(&metrics)
+
+	assert.Equal(t, "HeapAlloc{namespace=\"prod\",pod=\"api-0\"}", metrics[0].Name)
+	assert.Equal(t, `up{job="app",namespace="prod",pod="scraped"}`, metrics[2].Name, "own labels win")
+	assert.Equal(t, `broken{job=`, metrics[3].Name, "unparsable names are kept")
+}
+
+func TestMetricLabels_ApplyNil(t *testing.T) {
+	var labels *MetricLabels
+	metrics := entity.Metrics{{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.0}}
+	labels.Apply(&metrics)
+	assert.Equal(t, []string{"Alloc"}, names(&metrics))
+}
+
+func TestNewMetricLabels_InvalidName(t *testing.T) {
+	_, err := NewMetricLabels(map[string]string{"app.kubernetes.io/name": "api"})
+	assert.Error(t, err)
+}
+
+func TestParseMetricLabels(t *testing.T) {
+	tests := []struct {
+		expected map[string]string
+		name     string
+		pairs    []string
+		wantErr  bool
+	}{
+		{name: "empty", expected: map[string]string{}},
+		{
+			name:     "pairs",
+			pairs:    []string{"region=eu", " zone = eu-1a ", "empty="},
+			expected: map[string]string{"region": "eu", "zone": "eu-1a", "empty": ""},
+		},
+		{name: "missing separator", pairs: []string{"region"}, wantErr: true},
+		{name: "missing name", pairs: []string{"=eu"}, wantErr: true},
+		{name: "duplicate", pairs: []string{"region=a", "region=b"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels, err := ParseMetricLabels(tt.pairs)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, labels)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package collect
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// StrategyFactory creates a collection strategy registered with RegisterStrategy.
+//
+// Parameters:
+//   - logger: The logger of the strategy, named after it.
+//
+// Returns:
+//   - Strategy: The strategy.
+//   - error: An error if the strategy cannot be created.
+type StrategyFactory func(logger *zap.SugaredLogger) (Strategy, error)
+
+var (
+	// factories holds the registered strategy factories by name.
+	factories = make(map[string]StrategyFactory)
+	// unavailable holds the reasons strategies known by name are left out of this build.
+	unavailable = make(map[string]string)
+	// factoriesMu protects factories and unavailable.
+	factoriesMu sync.RWMutex
+)
+
+// RegisterStrategy makes a collection strategy available by name, so it can be enabled in the agent
+// configuration. It is meant to be called from the init function of the package providing the strategy,
+// and panics if the name is empty, the factory is nil or the name is already registered.
+//
+// Parameters:
+//   - name: The name the strategy is enabled by.
+//   - factory: The factory creating the strategy.
+func RegisterStrategy(name string, factory StrategyFactory) {
+	if name == "" {
+		panic("collect: RegisterStrategy with an empty name")
+	}
+	if factory == nil {
+		panic("collect: RegisterStrategy factory is nil for " + name)
+	}
+
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if registeredLocked(name) {
+		panic("collect: RegisterStrategy called twice for " + name)
+	}
+	factories[name] = factory
+}
+
+// RegisterUnavailableStrategy records that a strategy is known but left out of this build, e.g. by a build tag.
+// Configuring such a strategy is not an error: the agent warns and runs without it. It panics like
+// RegisterStrategy if the name is empty or already registered.
+//
+// Parameters:
+//   - name: The name the strategy is enabled by.
+//   - reason: Why the strategy is unavailable, e.g. the build tag that removed it.
+func RegisterUnavailableStrategy(name, reason string) {
+	if name == "" {
+		panic("collect: RegisterUnavailableStrategy with an empty name")
+	}
+
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if registeredLocked(name) {
+		panic("collect: RegisterUnavailableStrategy called for registered " + name)
+	}
+	unavailable[name] = reason
+}
+
+// StrategyAvailable reports whether a strategy is registered and compiled into this build.
+//
+// Parameters:
+//   - name: The strategy name.
+//
+// Returns:
+//   - bool: True if the strategy can be created.
+func StrategyAvailable(name string) bool {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	_, ok := factories[name]
+	return ok
+}
+
+// RegisteredStrategies returns the names of the registered strategies available in this build.
+//
+// Returns:
+//   - []string: The sorted strategy names.
+func RegisteredStrategies() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	return registeredNamesLocked()
+}
+
+// CheckStrategies reports whether every name refers to a registered strategy.
+// Strategies left out of this build are accepted, NewStrategies skips them.
+//
+// Parameters:
+//   - names: The strategy names.
+//
+// Returns:
+//   - error: An error naming the first unknown strategy.
+func CheckStrategies(names []string) error {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	for _, name := range names {
+		if !registeredLocked(name) {
+			return fmt.Errorf("unknown collection strategy %q, registered: %v", name, registeredNamesLocked())
+		}
+	}
+	return nil
+}
+
+// NewStrategies creates the registered strategies with the given names, in order.
+// Strategies left out of this build are skipped with a warning.
+//
+// Parameters:
+//   - names: The strategy names.
+//   - logger: The logger the strategy loggers are named from.
+//
+// Returns:
+//   - []Strategy: The strategies.
+//   - error: An error if a name is unknown or a factory fails.
+func NewStrategies(names []string, logger *zap.SugaredLogger) ([]Strategy, error) {
+	if err := CheckStrategies(names); err != nil {
+		return nil, err
+	}
+
+	strategies := make([]Strategy, 0, len(names))
+	for _, name := range names {
+		factoriesMu.RLock()
+		factory, ok := factories[name]
+		reason := unavailable[name]
+		factoriesMu.RUnlock()
+		if !ok {
+			logger.Warnf("Collection strategy %q is not available in this build and is skipped: %s", name, reason)
+			continue
+		}
+
+		strategy, err := factory(logger.Named(name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create collection strategy %q: %w", name, err)
+		}
+		strategies = append(strategies, strategy)
+	}
+	return strategies, nil
+}
+
+// registeredLocked reports whether a strategy is registered, available or not; factoriesMu must be held.
+func registeredLocked(name string) bool {
+	_, available := factories[name]
+	_, known := unavailable[name]
+	return available || known
+}
+
+// registeredNamesLocked returns the sorted names of the registered strategies; factoriesMu must be held.
+func registeredNamesLocked() []string {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
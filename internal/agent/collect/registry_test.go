@@ -0,0 +1,58 @@
+package collect
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRegisterStrategy(t *testing.T) {
+	RegisterStrategy("test_valid", func(*zap.SugaredLogger) (Strategy, error) { return &validStrategy{}, nil })
+	RegisterStrategy("test_failing", func(*zap.SugaredLogger) (Strategy, error) {
+		return nil, errors.New("no database")
+	})
+
+	assert.Subset(t, RegisteredStrategies(), []string{"test_failing", "test_valid"})
+	assert.Panics(t, func() {
+		RegisterStrategy("test_valid", func(*zap.SugaredLogger) (Strategy, error) { return &emptyStrategy{}, nil })
+	})
+	assert.Panics(t, func() { RegisterStrategy("test_nil", nil) })
+	assert.Panics(t, func() {
+		RegisterStrategy("", func(*zap.SugaredLogger) (Strategy, error) { return &emptyStrategy{}, nil })
+	})
+
+	strategies, err := NewStrategies([]string{"test_valid"}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	require.Len(t, strategies, 1)
+	assert.IsType(t, &validStrategy{}, strategies[0])
+
+	require.NoError(t, CheckStrategies([]string{"test_valid", "test_failing"}))
+	require.Error(t, CheckStrategies([]string{"test_valid", "test_unknown"}))
+
+	_, err = NewStrategies([]string{"test_unknown"}, zap.NewNop().Sugar())
+	require.Error(t, err)
+	_, err = NewStrategies([]string{"test_valid", "test_failing"}, zap.NewNop().Sugar())
+	require.ErrorContains(t, err, "no database")
+}
+
+func TestRegisterUnavailableStrategy(t *testing.T) {
+	RegisterStrategy("test_present", func(*zap.SugaredLogger) (Strategy, error) { return &validStrategy{}, nil })
+	RegisterUnavailableStrategy("test_absent", "built with the test tag")
+
+	assert.Panics(t, func() { RegisterUnavailableStrategy("test_present", "") })
+	assert.Panics(t, func() {
+		RegisterStrategy("test_absent", func(*zap.SugaredLogger) (Strategy, error) { return &emptyStrategy{}, nil })
+	})
+	assert.True(t, StrategyAvailable("test_present"))
+	assert.False(t, StrategyAvailable("test_absent"))
+	assert.NotContains(t, RegisteredStrategies(), "test_absent")
+
+	require.NoError(t, CheckStrategies([]string{"test_present", "test_absent"}))
+	strategies, err := NewStrategies([]string{"test_absent", "test_present"}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	require.Len(t, strategies, 1)
+	assert.IsType(t, &validStrategy{}, strategies[0])
+}
@@ -0,0 +1,171 @@
+package collect
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+)
+
+// MetricRules filters and renames collected metrics before they are queued for sending.
+// Filters match the original metric names with glob patterns (see path.Match), or with regular expressions
+// in RE2 syntax when a pattern is enclosed in slashes, e.g. "/^(Heap|Stack)Sys$/": a metric is kept if it
+// matches any include pattern, or no include patterns are given, and matches no exclude pattern.
+// Kept metrics are then renamed according to the rename mapping. The rules can be replaced with Update
+// while metrics are being collected.
+type MetricRules struct {
+	rules atomic.Pointer[ruleSet] // rules are the rules in effect.
+}
+
+// ruleSet holds the validated rules of MetricRules.
+type ruleSet struct {
+	rename  map[string]string // rename maps original metric names to the names to send.
+	include []namePattern     // include lists the patterns of metrics to keep.
+	exclude []namePattern     // exclude lists the patterns of metrics to drop.
+}
+
+// namePattern matches metric names with either a glob pattern or a regular expression.
+type namePattern struct {
+	re   *regexp.Regexp // re is the regular expression, or nil for a glob pattern.
+	glob string         // glob is the glob pattern.
+}
+
+// NewMetricRules validates the patterns and creates MetricRules.
+//
+// Parameters:
+//   - include: Patterns of metrics to keep; empty keeps all metrics.
+//   - exclude: Patterns of metrics to drop.
+//   - rename: Mapping from original metric names to new names.
+//
+// Returns:
+//   - *MetricRules: The rules; without patterns and mappings they keep all metrics unchanged.
+//   - error: An error if a pattern is malformed or a rename target is empty.
+func NewMetricRules(include, exclude []string, rename map[string]string) (*MetricRules, error) {
+	r := &MetricRules{}
+	if err := r.Update(include, exclude, rename); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Update validates the patterns and replaces the rules in effect. Invalid rules leave the previous ones
+// in effect.
+//
+// Parameters:
+//   - include: Patterns of metrics to keep; empty keeps all metrics.
+//   - exclude: Patterns of metrics to drop.
+//   - rename: Mapping from original metric names to new names.
+//
+// Returns:
+//   - error: An error if a pattern is malformed or a rename target is empty.
+func (r *MetricRules) Update(include, exclude []string, rename map[string]string) error {
+	includePatterns, err := compilePatterns(include)
+	if err != nil {
+		return err
+	}
+	excludePatterns, err := compilePatterns(exclude)
+	if err != nil {
+		return err
+	}
+	for from, to := range rename {
+		if to == "" {
+			return fmt.Errorf("empty new name for metric %q", from)
+		}
+	}
+	r.rules.Store(&ruleSet{include: includePatterns, exclude: excludePatterns, rename: rename})
+	return nil
+}
+
+// Apply filters and renames the metrics in place. Nil rules keep the metrics unchanged.
+//
+// Parameters:
+//   - metrics: The collected metrics.
+func (r *MetricRules) Apply(metrics *entity.Metrics) {
+	if r == nil || metrics == nil {
+		return
+	}
+	rules := r.rules.Load()
+
+	kept := (*metrics)[:0]
+	for _, m := range *metrics {
+		if m == nil || !rules.keep(m.Name) {
+			continue
+		}
+		if to, ok := rules.rename[m.Name]; ok {
+			m.Name = to
+		}
+		kept = append(kept, m)
+	}
+	*metrics = kept
+}
+
+// ParseMetricRenames parses rename mappings given as "old=new" pairs.
+//
+// Parameters:
+//   - pairs: The rename pairs.
+//
+// Returns:
+//   - map[string]string: The new names keyed by original metric name.
+//   - error: An error if a pair is malformed or a metric is renamed twice.
+func ParseMetricRenames(pairs []string) (map[string]string, error) {
+	rename := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		from, to, ok := strings.Cut(pair, "=")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("invalid metric rename %q, expected old=new", pair)
+		}
+		if _, exists := rename[from]; exists {
+			return nil, fmt.Errorf("duplicate rename for metric %q", from)
+		}
+		rename[from] = to
+	}
+	return rename, nil
+}
+
+// keep reports whether a metric with the given name passes the filters.
+func (r *ruleSet) keep(name string) bool {
+	if len(r.include) > 0 && !matchAny(r.include, name) {
+		return false
+	}
+	return !matchAny(r.exclude, name)
+}
+
+// compilePatterns validates glob patterns and compiles the regular expressions enclosed in slashes.
+func compilePatterns(patterns []string) ([]namePattern, error) {
+	compiled := make([]namePattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid metric regexp %q: %w", pattern, err)
+			}
+			compiled = append(compiled, namePattern{re: re})
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid metric pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, namePattern{glob: pattern})
+	}
+	return compiled, nil
+}
+
+// matchAny reports whether name matches any of the validated patterns.
+func matchAny(patterns []namePattern, name string) bool {
+	for _, p := range patterns {
+		if p.re != nil {
+			if p.re.MatchString(name) {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(p.glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package collect
+
+import (
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// names returns the names of the metrics in order.
+func names(metrics *entity.Metrics) []string {
+	result := make([]string, 0, metrics.Length())
+	for _, m := range *metrics {
+		result = append(result, m.Name)
+	}
+	return result
+}
+
+func TestMetricRules_Apply(t *testing.T) {
+	tests := []struct {
+		rename   map[string]string
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{
+			name:     "no rules keep everything",
+			expected: []string{"Alloc", "HeapAlloc", "HeapReleased", "CPUutilization1"},
+		},
+		{
+			name:     "include only",
+			include:  []string{"Heap*"},
+			expected: []string{"HeapAlloc", "HeapReleased"},
+		},
+		{
+			name:     "exclude only",
+			exclude:  []string{"Heap*", "CPU*"},
+			expected: []string{"Alloc"},
+		},
+		{
+			name:     "exclude wins over include",
+			include:  []string{"Heap*"},
+			exclude:  []string{"HeapReleased"},
+			expected: []string{"HeapAlloc"},
+		},
+		{
+			name:     "regexp patterns",
+			include:  []string{"/^Heap/", "/utilization[0-9]+$/"},
+			exclude:  []string{"/Released$/"},
+			expected: []string{"HeapAlloc", "CPUutilization1"},
+		},
+		{
+			name:     "rename after filtering by original name",
+			include:  []string{"Alloc", "HeapAlloc"},
+			rename:   map[string]string{"Alloc": "go_alloc", "HeapReleased": "unused"},
+			expected: []string{"go_alloc", "HeapAlloc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := NewMetricRules(tt.include, tt.exclude, tt.rename)
+			require.NoError(t, err)
+
+			metrics := entity.Metrics{
+				{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.0},
+				{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 2.0},
+				nil,
+				{Name: "HeapReleased", Type: entity.MetricTypeGauge, Value: 3.0},
+				{Name: "CPUutilization1", Type: entity.MetricTypeGauge, Value: 4.0},
+			}
+			rules.Apply(&metrics)
+			assert.Equal(t, tt.expected, names(&metrics))
+		})
+	}
+}
+
+func TestMetricRules_ApplyNil(t *testing.T) {
+	var rules *MetricRules
+	metrics := entity.Metrics{{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.0}}
+	rules.Apply(&metrics)
+	assert.Equal(t, []string{"Alloc"}, names(&metrics))
+}
+
+func TestNewMetricRules_Errors(t *testing.T) {
+	tests := []struct {
+		rename  map[string]string
+		name    string
+		include []string
+		exclude []string
+	}{
+		{name: "bad include pattern", include: []string{"Heap["}},
+		{name: "bad exclude pattern", exclude: []string{"[-]"}},
+		{name: "bad regexp", include: []string{"/Heap(/"}},
+		{name: "empty rename target", rename: map[string]string{"Alloc": ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMetricRules(tt.include, tt.exclude, tt.rename)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMetricRules_Update(t *testing.T) {
+	rules, err := NewMetricRules([]string{"Heap*"}, nil, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, rules.Update(nil, []string{"/^Heap/"}, map[string]string{"Alloc": "go_alloc"}))
+	metrics := entity.Metrics{
+		{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.0},
+		{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 2.0},
+	}
+	rules.Apply(&metrics)
+	assert.Equal(t, []string{"go_alloc"}, names(&metrics))
+
+	require.Error(t, rules.Update([]string{"/(/"}, nil, nil))
+	metrics = entity.Metrics{{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 2.0}}
+	rules.Apply(&metrics)
+	assert.Empty(t, metrics, "invalid rules leave the previous ones in effect")
+}
+
+func TestParseMetricRenames(t *testing.T) {
+	tests := []struct {
+		expected map[string]string
+		name     string
+		pairs    []string
+		wantErr  bool
+	}{
+		{name: "empty", expected: map[string]string{}},
+		{
+			name:     "pairs",
+			pairs:    []string{"Alloc=go_alloc", " HeapAlloc = heap_alloc "},
+			expected: map[string]string{"Alloc": "go_alloc", "HeapAlloc": "heap_alloc"},
+		},
+		{name: "missing separator", pairs: []string{"Alloc"}, wantErr: true},
+		{name: "missing new name", pairs: []string{"Alloc="}, wantErr: true},
+		{name: "duplicate", pairs: []string{"Alloc=a", "Alloc=b"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rename, err := ParseMetricRenames(tt.pairs)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rename)
+		})
+	}
+}
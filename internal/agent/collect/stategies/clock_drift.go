@@ -0,0 +1,83 @@
+package stategies
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"go.uber.org/zap"
+)
+
+const (
+	// ClockDriftStrategyName is the configuration name of ClockDriftStrategy.
+	ClockDriftStrategyName = "clockdrift"
+	// clockDriftTimeout bounds a single clock measurement.
+	clockDriftTimeout = 5 * time.Second
+	// clockDriftMetric is the gauge holding the offset of the local clock in seconds.
+	clockDriftMetric = "ClockDriftSeconds"
+	// clockRoundTripMetric is the gauge holding the round trip of the measurement in seconds.
+	clockRoundTripMetric = "ClockDriftRoundTripSeconds"
+)
+
+// ReferenceClock measures the offset of the local clock against a reference clock.
+type ReferenceClock interface {
+	// Measure compares the local clock with the reference clock once.
+	//
+	// Returns:
+	//   - time.Duration: The offset of the local clock, positive when it is ahead.
+	//   - time.Duration: The round trip of the measurement, which bounds its error.
+	//   - error: An error if the reference clock cannot be read.
+	Measure(ctx context.Context) (time.Duration, time.Duration, error)
+}
+
+// ClockDriftStrategy is a collection strategy that measures the drift of the local clock against
+// a reference clock, usually the server, and exports it as the ClockDriftSeconds gauge, positive when
+// the local clock is ahead. Metrics are timestamped with the local clock, so a large drift explains
+// history data landing at the wrong time. The ClockDriftRoundTripSeconds gauge bounds the measurement error.
+type ClockDriftStrategy struct {
+	logger    *zap.SugaredLogger
+	reference ReferenceClock // reference is the clock the local clock is compared with.
+}
+
+// NewClockDriftStrategy creates a ClockDriftStrategy comparing the local clock with the reference clock.
+//
+// Parameters:
+//   - reference: The reference clock.
+//   - logger: Logger instance for recording events.
+//
+// Returns:
+//   - *ClockDriftStrategy: A pointer to the newly created ClockDriftStrategy instance.
+func NewClockDriftStrategy(reference ReferenceClock, logger *zap.SugaredLogger) *ClockDriftStrategy {
+	logger.Info("Initializing ClockDriftStrategy")
+	return &ClockDriftStrategy{logger: logger, reference: reference}
+}
+
+// Name returns the configuration name of the strategy.
+//
+// Returns:
+//   - string: The strategy name.
+func (s *ClockDriftStrategy) Name() string {
+	return ClockDriftStrategyName
+}
+
+// Collect measures the clock drift and returns it with the round trip of the measurement.
+//
+// Returns:
+//   - *entity.Metrics: A pointer to the collected metrics.
+//   - error: An error if the reference clock cannot be read.
+func (s *ClockDriftStrategy) Collect() (*entity.Metrics, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), clockDriftTimeout)
+	defer cancel()
+
+	offset, roundTrip, err := s.reference.Measure(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure clock drift: %w", err)
+	}
+
+	now := time.Now()
+	return &entity.Metrics{
+		{Name: clockDriftMetric, Type: entity.MetricTypeGauge, Value: offset.Seconds(), Timestamp: now},
+		{Name: clockRoundTripMetric, Type: entity.MetricTypeGauge, Value: roundTrip.Seconds(), Timestamp: now},
+	}, nil
+}
@@ -0,0 +1,51 @@
+package stategies
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// stubClock is a ReferenceClock returning fixed measurements.
+type stubClock struct {
+	err       error
+	offset    time.Duration
+	roundTrip time.Duration
+}
+
+func (c stubClock) Measure(_ context.Context) (time.Duration, time.Duration, error) {
+	return c.offset, c.roundTrip, c.err
+}
+
+func TestClockDriftStrategy_Collect(t *testing.T) {
+	strategy := NewClockDriftStrategy(
+		stubClock{offset: -1500 * time.Millisecond, roundTrip: 20 * time.Millisecond},
+		zap.NewNop().Sugar(),
+	)
+	assert.Equal(t, ClockDriftStrategyName, strategy.Name())
+
+	metrics, err := strategy.Collect()
+	require.NoError(t, err)
+	require.Equal(t, 2, metrics.Length())
+
+	drift, roundTrip := (*metrics)[0], (*metrics)[1]
+	assert.Equal(t, clockDriftMetric, drift.Name)
+	assert.Equal(t, entity.MetricTypeGauge, drift.Type)
+	assert.InDelta(t, -1.5, drift.Value, 1e-9)
+	assert.Equal(t, clockRoundTripMetric, roundTrip.Name)
+	assert.InDelta(t, 0.02, roundTrip.Value, 1e-9)
+	assert.False(t, drift.Timestamp.IsZero())
+}
+
+func TestClockDriftStrategy_CollectError(t *testing.T) {
+	strategy := NewClockDriftStrategy(stubClock{err: errors.New("unreachable")}, zap.NewNop().Sugar())
+
+	_, err := strategy.Collect()
+	assert.ErrorContains(t, err, "unreachable")
+}
@@ -0,0 +1,107 @@
+//go:build !nogopsutil
+
+package stategies
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/shirou/gopsutil/v4/disk"
+	"go.uber.org/zap"
+)
+
+// DiskStatsCollectStrategy is a collection strategy that gathers the usage of every mounted physical
+// partition and the I/O counters of every disk using the gopsutil library. Usage is exported per mount
+// point, e.g. DiskUsedPercent_var_lib, and I/O per device, e.g. DiskReadBytes_sda; I/O counters are
+// the totals since boot, exported as gauges.
+type DiskStatsCollectStrategy struct {
+	logger *zap.SugaredLogger
+	// partitions lists the mounted partitions.
+	partitions func(ctx context.Context, all bool) ([]disk.PartitionStat, error)
+	// usage reads the usage of a mount point.
+	usage func(ctx context.Context, path string) (*disk.UsageStat, error)
+	// ioCounters reads the I/O counters of the disks.
+	ioCounters func(ctx context.Context, names ...string) (map[string]disk.IOCountersStat, error)
+}
+
+// NewDiskStatsCollectStrategy initializes and returns a new instance of DiskStatsCollectStrategy.
+//
+// Parameters:
+//   - logger: Logger instance for recording events.
+//
+// Returns:
+//   - *DiskStatsCollectStrategy: A pointer to the newly created DiskStatsCollectStrategy instance.
+func NewDiskStatsCollectStrategy(logger *zap.SugaredLogger) *DiskStatsCollectStrategy {
+	logger.Info("Initializing DiskStatsCollectStrategy")
+	return &DiskStatsCollectStrategy{
+		logger:     logger,
+		partitions: disk.PartitionsWithContext,
+		usage:      disk.UsageWithContext,
+		ioCounters: disk.IOCountersWithContext,
+	}
+}
+
+// Name returns the configuration name of the strategy.
+//
+// Returns:
+//   - string: The strategy name.
+func (s *DiskStatsCollectStrategy) Name() string {
+	return DiskStatsStrategyName
+}
+
+// Collect gathers the usage of the mounted partitions and the disk I/O counters.
+// Mount points whose usage cannot be read, e.g. for lack of permissions, are skipped, and so are the
+// I/O counters if they cannot be read, e.g. without /proc/diskstats in a container.
+//
+// Returns:
+//   - *entity.Metrics: A pointer to the collected metrics.
+//   - error: An error if the partitions cannot be listed.
+func (s *DiskStatsCollectStrategy) Collect() (*entity.Metrics, error) {
+	ctx := context.Background()
+
+	partitions, err := s.partitions(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed list partitions: %w", err)
+	}
+
+	var metrics entity.Metrics
+	seen := make(map[string]struct{}, len(partitions))
+	for _, p := range partitions {
+		if _, ok := seen[p.Mountpoint]; ok {
+			continue
+		}
+		seen[p.Mountpoint] = struct{}{}
+
+		usage, err := s.usage(ctx, p.Mountpoint)
+		if err != nil {
+			s.logger.Debugf("Skipping usage of mount point %s: %v", p.Mountpoint, err)
+			continue
+		}
+		suffix := metricSuffix(p.Mountpoint)
+		metrics = append(
+			metrics,
+			gauge("DiskTotal_"+suffix, float64(usage.Total)),
+			gauge("DiskUsed_"+suffix, float64(usage.Used)),
+			gauge("DiskFree_"+suffix, float64(usage.Free)),
+			gauge("DiskUsedPercent_"+suffix, usage.UsedPercent),
+		)
+	}
+
+	counters, err := s.ioCounters(ctx)
+	if err != nil {
+		s.logger.Debugf("Skipping disk I/O counters: %v", err)
+		return &metrics, nil
+	}
+	for device, c := range counters {
+		suffix := metricSuffix(device)
+		metrics = append(
+			metrics,
+			gauge("DiskReadBytes_"+suffix, float64(c.ReadBytes)),
+			gauge("DiskWriteBytes_"+suffix, float64(c.WriteBytes)),
+			gauge("DiskReads_"+suffix, float64(c.ReadCount)),
+			gauge("DiskWrites_"+suffix, float64(c.WriteCount)),
+		)
+	}
+	return &metrics, nil
+}
@@ -0,0 +1,107 @@
+//go:build !nogopsutil
+
+package stategies
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDiskStatsCollectStrategy_Collect(t *testing.T) {
+	strategy := NewDiskStatsCollectStrategy(zap.NewNop().Sugar())
+	strategy.partitions = func(context.Context, bool) ([]disk.PartitionStat, error) {
+		return []disk.PartitionStat{
+			{Mountpoint: "/"},
+			{Mountpoint: "/var/lib"},
+			{Mountpoint: "/var/lib"},
+			{Mountpoint: "/secret"},
+		}, nil
+	}
+	strategy.usage = func(_ context.Context, path string) (*disk.UsageStat, error) {
+		if path == "/secret" {
+			return nil, errors.New("permission denied")
+		}
+		return &disk.UsageStat{Total: 100, Used: 25, Free: 75, UsedPercent: 25}, nil
+	}
+	strategy.ioCounters = func(context.Context, ...string) (map[string]disk.IOCountersStat, error) {
+		return map[string]disk.IOCountersStat{"sda": {ReadBytes: 10, WriteBytes: 20, ReadCount: 1, WriteCount: 2}}, nil
+	}
+
+	metrics, err := strategy.Collect()
+	require.NoError(t, err)
+	assert.Len(t, *metrics, 12, "repeated and unreadable mount points are skipped")
+	for _, m := range *metrics {
+		assert.Equal(t, entity.MetricTypeGauge, m.Type)
+	}
+
+	values := metricValues(metrics)
+	assert.Equal(t, 25.0, values["DiskUsedPercent_root"])
+	assert.Equal(t, 100.0, values["DiskTotal_var_lib"])
+	assert.Equal(t, 75.0, values["DiskFree_var_lib"])
+	assert.Equal(t, 10.0, values["DiskReadBytes_sda"])
+	assert.Equal(t, 2.0, values["DiskWrites_sda"])
+	assert.NotContains(t, values, "DiskUsed_secret")
+}
+
+func TestDiskStatsCollectStrategy_CollectError(t *testing.T) {
+	strategy := NewDiskStatsCollectStrategy(zap.NewNop().Sugar())
+	strategy.partitions = func(context.Context, bool) ([]disk.PartitionStat, error) {
+		return nil, errors.New("no mounts")
+	}
+
+	_, err := strategy.Collect()
+	assert.Error(t, err)
+	assert.Equal(t, DiskStatsStrategyName, strategy.Name())
+}
+
+func TestDiskStatsCollectStrategy_CollectWithoutIO(t *testing.T) {
+	strategy := NewDiskStatsCollectStrategy(zap.NewNop().Sugar())
+	strategy.partitions = func(context.Context, bool) ([]disk.PartitionStat, error) {
+		return []disk.PartitionStat{{Mountpoint: "/"}}, nil
+	}
+	strategy.usage = func(context.Context, string) (*disk.UsageStat, error) {
+		return &disk.UsageStat{Total: 100, Used: 25, Free: 75, UsedPercent: 25}, nil
+	}
+	strategy.ioCounters = func(context.Context, ...string) (map[string]disk.IOCountersStat, error) {
+		return nil, errors.New("open /proc/diskstats: no such file or directory")
+	}
+
+	metrics, err := strategy.Collect()
+	require.NoError(t, err)
+	assert.Len(t, *metrics, 4, "usage is reported without the I/O counters")
+	assert.Equal(t, 25.0, metricValues(metrics)["DiskUsedPercent_root"])
+}
+
+func TestDiskStatsCollectStrategy_CollectHost(t *testing.T) {
+	metrics, err := NewDiskStatsCollectStrategy(zap.NewNop().Sugar()).Collect()
+	if err != nil {
+		t.Skipf("disk statistics are not available: %v", err)
+	}
+	require.NotNil(t, metrics)
+}
+
+func TestMetricSuffix(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "/", expected: "root"},
+		{name: "/var/lib/docker", expected: "var_lib_docker"},
+		{name: "C:", expected: "C"},
+		{name: "eth0", expected: "eth0"},
+		{name: "br-1a2b", expected: "br_1a2b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, metricSuffix(tt.name))
+		})
+	}
+}
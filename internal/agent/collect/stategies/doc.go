@@ -1,5 +1,11 @@
 // Package stategies provides implementations of metric collection strategies.
 // These strategies use system libraries such as gopsutil and the Go runtime to collect
-// various metrics, including memory and CPU usage. The collected metrics conform to the
+// various metrics, including memory and CPU usage, disk usage and I/O, network interface counters and the
+// resource usage of selected processes, scrape the Prometheus endpoints of co-located applications, or
+// measure the drift of the local clock against the server. The collected metrics conform to the
 // entity.Metrics type defined in the internal entity package.
+//
+// Building with the nogopsutil tag leaves the gopsutil, disk, net and process strategies and their
+// dependencies out of the agent, for minimal containers and embedded systems that only need the Go runtime
+// metrics.
 package stategies
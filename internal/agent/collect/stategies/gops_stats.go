@@ -1,3 +1,5 @@
+//go:build !nogopsutil
+
 package stategies
 
 import (
@@ -30,6 +32,14 @@ func GopsMemStatsCollectStrategy(logger *zap.SugaredLogger) *GopsStatsCollectStr
 	}
 }
 
+// Name returns the configuration name of the strategy.
+//
+// Returns:
+//   - string: The strategy name.
+func (m *GopsStatsCollectStrategy) Name() string {
+	return GopsStatsStrategyName
+}
+
 // Collect gathers memory and CPU metrics and returns them as a pointer to entity.Metrics.
 // If any error occurs during the collection of metrics, it returns an error.
 //
@@ -1,3 +1,5 @@
+//go:build !nogopsutil
+
 package stategies
 
 import (
@@ -121,3 +123,9 @@ func TestGopsStatsCollectStrategy_Collect(t *testing.T) {
 		})
 	}
 }
+
+func TestGopsStatsCollectStrategy_Name(t *testing.T) {
+	if name := GopsMemStatsCollectStrategy(zap.NewNop().Sugar()).Name(); name != GopsStatsStrategyName {
+		t.Errorf("expected name %q, got %q", GopsStatsStrategyName, name)
+	}
+}
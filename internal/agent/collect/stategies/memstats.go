@@ -9,6 +9,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// MemStatsStrategyName is the configuration name of MemStatsCollectStrategy.
+const MemStatsStrategyName = "memstats"
+
 // MemStatsCollectStrategy is a collection strategy that gathers memory statistics from the Go runtime
 // using runtime.ReadMemStats. It also collects metadata information to supplement the metrics.
 // The strategy is safe for concurrent use.
@@ -55,6 +58,14 @@ func (m *MemStatsCollectStrategy) Collect() (*entity.Metrics, error) {
 	return &metrics, nil
 }
 
+// Name returns the configuration name of the strategy.
+//
+// Returns:
+//   - string: The strategy name.
+func (m *MemStatsCollectStrategy) Name() string {
+	return MemStatsStrategyName
+}
+
 // exportMemoryMetrics converts runtime memory statistics into a slice of metrics.
 // This function is used internally to generate memory-related metrics.
 //
@@ -144,3 +144,9 @@ func BenchmarkMemStatsCollectStrategy_Collect(b *testing.B) {
 		}
 	}
 }
+
+func TestMemStatsCollectStrategy_Name(t *testing.T) {
+	if name := NewMemStatsCollectStrategy(zap.NewNop().Sugar()).Name(); name != MemStatsStrategyName {
+		t.Errorf("expected name %q, got %q", MemStatsStrategyName, name)
+	}
+}
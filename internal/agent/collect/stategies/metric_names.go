@@ -0,0 +1,42 @@
+package stategies
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+)
+
+// gauge creates a gauge metric.
+//
+// Parameters:
+//   - name: The metric name.
+//   - value: The metric value.
+//
+// Returns:
+//   - *entity.Metric: The gauge.
+func gauge(name string, value float64) *entity.Metric {
+	return &entity.Metric{Value: value, Name: name, Type: entity.MetricTypeGauge}
+}
+
+// metricSuffix turns a mount point, device, interface or process name into a metric name suffix: path
+// separators and other characters that are not letters or digits become underscores, leading and trailing
+// ones are dropped, and the root mount point becomes "root".
+//
+// Parameters:
+//   - name: The mount point, device, interface or process name.
+//
+// Returns:
+//   - string: The suffix.
+func metricSuffix(name string) string {
+	suffix := strings.Trim(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, name), "_")
+	if suffix == "" {
+		return "root"
+	}
+	return suffix
+}
@@ -0,0 +1,72 @@
+//go:build !nogopsutil
+
+package stategies
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/shirou/gopsutil/v4/net"
+	"go.uber.org/zap"
+)
+
+// NetStatsCollectStrategy is a collection strategy that gathers the counters of every network interface
+// using the gopsutil library, e.g. NetBytesRecv_eth0. The counters are the totals since the interface
+// came up, exported as gauges.
+type NetStatsCollectStrategy struct {
+	logger     *zap.SugaredLogger
+	ioCounters func(ctx context.Context, perNIC bool) ([]net.IOCountersStat, error) // ioCounters reads the interfaces.
+}
+
+// NewNetStatsCollectStrategy initializes and returns a new instance of NetStatsCollectStrategy.
+//
+// Parameters:
+//   - logger: Logger instance for recording events.
+//
+// Returns:
+//   - *NetStatsCollectStrategy: A pointer to the newly created NetStatsCollectStrategy instance.
+func NewNetStatsCollectStrategy(logger *zap.SugaredLogger) *NetStatsCollectStrategy {
+	logger.Info("Initializing NetStatsCollectStrategy")
+	return &NetStatsCollectStrategy{
+		logger:     logger,
+		ioCounters: net.IOCountersWithContext,
+	}
+}
+
+// Name returns the configuration name of the strategy.
+//
+// Returns:
+//   - string: The strategy name.
+func (s *NetStatsCollectStrategy) Name() string {
+	return NetStatsStrategyName
+}
+
+// Collect gathers the counters of the network interfaces.
+//
+// Returns:
+//   - *entity.Metrics: A pointer to the collected metrics.
+//   - error: An error if the interface counters cannot be read.
+func (s *NetStatsCollectStrategy) Collect() (*entity.Metrics, error) {
+	counters, err := s.ioCounters(context.Background(), true)
+	if err != nil {
+		return nil, fmt.Errorf("failed collect network io: %w", err)
+	}
+
+	metrics := make(entity.Metrics, 0, len(counters)*8)
+	for _, c := range counters {
+		suffix := metricSuffix(c.Name)
+		metrics = append(
+			metrics,
+			gauge("NetBytesRecv_"+suffix, float64(c.BytesRecv)),
+			gauge("NetBytesSent_"+suffix, float64(c.BytesSent)),
+			gauge("NetPacketsRecv_"+suffix, float64(c.PacketsRecv)),
+			gauge("NetPacketsSent_"+suffix, float64(c.PacketsSent)),
+			gauge("NetErrIn_"+suffix, float64(c.Errin)),
+			gauge("NetErrOut_"+suffix, float64(c.Errout)),
+			gauge("NetDropIn_"+suffix, float64(c.Dropin)),
+			gauge("NetDropOut_"+suffix, float64(c.Dropout)),
+		)
+	}
+	return &metrics, nil
+}
@@ -0,0 +1,50 @@
+//go:build !nogopsutil
+
+package stategies
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/shirou/gopsutil/v4/net"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNetStatsCollectStrategy_Collect(t *testing.T) {
+	strategy := NewNetStatsCollectStrategy(zap.NewNop().Sugar())
+	strategy.ioCounters = func(_ context.Context, perNIC bool) ([]net.IOCountersStat, error) {
+		assert.True(t, perNIC)
+		return []net.IOCountersStat{
+			{Name: "eth0", BytesRecv: 100, BytesSent: 50, PacketsRecv: 10, PacketsSent: 5, Errin: 1},
+			{Name: "lo", BytesRecv: 7, BytesSent: 7},
+		}, nil
+	}
+
+	metrics, err := strategy.Collect()
+	require.NoError(t, err)
+	assert.Len(t, *metrics, 16)
+	for _, m := range *metrics {
+		assert.Equal(t, entity.MetricTypeGauge, m.Type)
+	}
+
+	values := metricValues(metrics)
+	assert.Equal(t, 100.0, values["NetBytesRecv_eth0"])
+	assert.Equal(t, 50.0, values["NetBytesSent_eth0"])
+	assert.Equal(t, 1.0, values["NetErrIn_eth0"])
+	assert.Equal(t, 7.0, values["NetBytesSent_lo"])
+}
+
+func TestNetStatsCollectStrategy_CollectError(t *testing.T) {
+	strategy := NewNetStatsCollectStrategy(zap.NewNop().Sugar())
+	strategy.ioCounters = func(context.Context, bool) ([]net.IOCountersStat, error) {
+		return nil, errors.New("no interfaces")
+	}
+
+	_, err := strategy.Collect()
+	assert.Error(t, err)
+	assert.Equal(t, NetStatsStrategyName, strategy.Name())
+}
@@ -0,0 +1,221 @@
+//go:build !nogopsutil
+
+package stategies
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/shirou/gopsutil/v4/process"
+	"go.uber.org/zap"
+)
+
+// processHandle reads the statistics of a running process; *process.Process implements it.
+type processHandle interface {
+	NameWithContext(ctx context.Context) (string, error)
+	MemoryInfoWithContext(ctx context.Context) (*process.MemoryInfoStat, error)
+	PercentWithContext(ctx context.Context, interval time.Duration) (float64, error)
+	NumFDsWithContext(ctx context.Context) (int32, error)
+	NumThreadsWithContext(ctx context.Context) (int32, error)
+}
+
+// processUsage accumulates the statistics of the processes matching a target by metric name prefix; the
+// statistics unreadable for all the processes are absent.
+type processUsage map[string]float64
+
+// Metric name prefixes of the process statistics, followed by the target label.
+const (
+	processCount   = "ProcessCount_"
+	processRSS     = "ProcessRSS_"
+	processCPU     = "ProcessCPUPercent_"
+	processFDs     = "ProcessOpenFDs_"
+	processThreads = "ProcessThreads_"
+)
+
+// processStats lists the process statistics in the export order.
+var processStats = []string{processRSS, processCPU, processFDs, processThreads}
+
+// ProcessStatsCollectStrategy is a collection strategy that tracks the resource usage of selected processes,
+// e.g. sidecars running next to the agent, using the gopsutil library. Every target exports its resident
+// memory, CPU usage, open file descriptors and threads, e.g. ProcessRSS_nginx or ProcessThreads_pid_42,
+// summed over all processes matching the target, and the number of matching processes. CPU usage is the
+// percentage of one CPU used since the previous collection, so the first collection reports zero.
+// The strategy is safe for concurrent use.
+type ProcessStatsCollectStrategy struct {
+	logger *zap.SugaredLogger
+	mu     *sync.Mutex
+	// handles keeps the matched processes between collections to measure their CPU usage.
+	handles map[int32]processHandle
+	// pids lists the running processes.
+	pids func(ctx context.Context) ([]int32, error)
+	// open opens a running process.
+	open    func(ctx context.Context, pid int32) (processHandle, error)
+	targets []ProcessTarget
+}
+
+// NewProcessStatsCollectStrategy initializes and returns a new instance of ProcessStatsCollectStrategy.
+//
+// Parameters:
+//   - targets: The processes to track.
+//   - logger: Logger instance for recording events.
+//
+// Returns:
+//   - *ProcessStatsCollectStrategy: A pointer to the newly created ProcessStatsCollectStrategy instance.
+//   - error: Always nil; builds without gopsutil return ErrProcessStatsUnavailable.
+func NewProcessStatsCollectStrategy(
+	targets []ProcessTarget,
+	logger *zap.SugaredLogger,
+) (*ProcessStatsCollectStrategy, error) {
+	logger.Infof("Initializing ProcessStatsCollectStrategy with %d targets", len(targets))
+	return &ProcessStatsCollectStrategy{
+		logger:  logger,
+		mu:      &sync.Mutex{},
+		handles: make(map[int32]processHandle),
+		pids:    process.PidsWithContext,
+		open: func(ctx context.Context, pid int32) (processHandle, error) {
+			return process.NewProcessWithContext(ctx, pid)
+		},
+		targets: targets,
+	}, nil
+}
+
+// Name returns the configuration name of the strategy.
+//
+// Returns:
+//   - string: The strategy name.
+func (s *ProcessStatsCollectStrategy) Name() string {
+	return ProcessStatsStrategyName
+}
+
+// Collect gathers the statistics of the tracked processes. A target without running processes only
+// reports a zero process count; statistics that cannot be read, e.g. for lack of permissions, are skipped.
+//
+// Returns:
+//   - *entity.Metrics: A pointer to the collected metrics.
+//   - error: An error if the running processes cannot be listed.
+func (s *ProcessStatsCollectStrategy) Collect() (*entity.Metrics, error) {
+	ctx := context.Background()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pids, err := s.pids(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed list processes: %w", err)
+	}
+
+	byPID := make(map[int32]string, len(s.targets))
+	byName := make(map[string]string, len(s.targets))
+	for _, t := range s.targets {
+		if t.PID != 0 {
+			byPID[t.PID] = t.Label
+		} else {
+			byName[t.Name] = t.Label
+		}
+	}
+
+	usage := make(map[string]processUsage, len(s.targets))
+	handles := make(map[int32]processHandle, len(s.handles))
+	for _, pid := range pids {
+		label, h := s.match(ctx, pid, byPID, byName)
+		if h == nil {
+			continue
+		}
+		handles[pid] = h
+		u, ok := usage[label]
+		if !ok {
+			u = make(processUsage, len(processStats))
+			usage[label] = u
+		}
+		s.read(ctx, pid, h, u)
+	}
+	s.handles = handles
+
+	metrics := make(entity.Metrics, 0, len(s.targets)*5)
+	for _, t := range s.targets {
+		u := usage[t.Label]
+		metrics = append(metrics, gauge(processCount+t.Label, u[processCount]))
+		for _, stat := range processStats {
+			if value, ok := u[stat]; ok {
+				metrics = append(metrics, gauge(stat+t.Label, value))
+			}
+		}
+	}
+	return &metrics, nil
+}
+
+// match finds the target a running process belongs to.
+//
+// Parameters:
+//   - ctx: The collection context.
+//   - pid: The process identifier.
+//   - byPID: The labels of the targets by PID.
+//   - byName: The labels of the targets by process name.
+//
+// Returns:
+//   - string: The label of the matching target.
+//   - processHandle: The process, or nil if it matches no target or has exited.
+func (s *ProcessStatsCollectStrategy) match(
+	ctx context.Context,
+	pid int32,
+	byPID map[int32]string,
+	byName map[string]string,
+) (string, processHandle) {
+	label, ok := byPID[pid]
+	if !ok && len(byName) == 0 {
+		return "", nil
+	}
+
+	h, known := s.handles[pid]
+	if !known {
+		var err error
+		if h, err = s.open(ctx, pid); err != nil {
+			return "", nil
+		}
+	}
+	if ok {
+		return label, h
+	}
+
+	name, err := h.NameWithContext(ctx)
+	if err != nil {
+		return "", nil
+	}
+	if label, ok = byName[name]; !ok {
+		return "", nil
+	}
+	return label, h
+}
+
+// read adds the statistics of a process to the usage of its target.
+//
+// Parameters:
+//   - ctx: The collection context.
+//   - pid: The process identifier.
+//   - h: The process.
+//   - u: The usage of the target.
+func (s *ProcessStatsCollectStrategy) read(ctx context.Context, pid int32, h processHandle, u processUsage) {
+	u[processCount]++
+	if mem, err := h.MemoryInfoWithContext(ctx); err == nil {
+		u[processRSS] += float64(mem.RSS)
+	} else {
+		s.logger.Debugf("Skipping memory of process %d: %v", pid, err)
+	}
+	if cpu, err := h.PercentWithContext(ctx, 0); err == nil {
+		u[processCPU] += cpu
+	} else {
+		s.logger.Debugf("Skipping CPU usage of process %d: %v", pid, err)
+	}
+	if fds, err := h.NumFDsWithContext(ctx); err == nil {
+		u[processFDs] += float64(fds)
+	} else {
+		s.logger.Debugf("Skipping open files of process %d: %v", pid, err)
+	}
+	if threads, err := h.NumThreadsWithContext(ctx); err == nil {
+		u[processThreads] += float64(threads)
+	} else {
+		s.logger.Debugf("Skipping threads of process %d: %v", pid, err)
+	}
+}
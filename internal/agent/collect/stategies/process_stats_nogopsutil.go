@@ -0,0 +1,38 @@
+//go:build nogopsutil
+
+package stategies
+
+import (
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"go.uber.org/zap"
+)
+
+// ProcessStatsCollectStrategy tracks the resource usage of selected processes. It needs gopsutil and cannot be
+// created in builds with the nogopsutil tag.
+type ProcessStatsCollectStrategy struct{}
+
+// NewProcessStatsCollectStrategy reports that the strategy is left out of this build.
+//
+// Returns:
+//   - *ProcessStatsCollectStrategy: Always nil.
+//   - error: ErrProcessStatsUnavailable.
+func NewProcessStatsCollectStrategy([]ProcessTarget, *zap.SugaredLogger) (*ProcessStatsCollectStrategy, error) {
+	return nil, ErrProcessStatsUnavailable
+}
+
+// Name returns the configuration name of the strategy.
+//
+// Returns:
+//   - string: The strategy name.
+func (s *ProcessStatsCollectStrategy) Name() string {
+	return ProcessStatsStrategyName
+}
+
+// Collect reports that the strategy is left out of this build.
+//
+// Returns:
+//   - *entity.Metrics: Always nil.
+//   - error: ErrProcessStatsUnavailable.
+func (s *ProcessStatsCollectStrategy) Collect() (*entity.Metrics, error) {
+	return nil, ErrProcessStatsUnavailable
+}
@@ -0,0 +1,114 @@
+//go:build !nogopsutil
+
+package stategies
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeProcess struct {
+	name    string
+	rss     uint64
+	cpu     float64
+	fds     int32
+	threads int32
+	calls   int
+}
+
+func (p *fakeProcess) NameWithContext(context.Context) (string, error) {
+	return p.name, nil
+}
+
+func (p *fakeProcess) MemoryInfoWithContext(context.Context) (*process.MemoryInfoStat, error) {
+	return &process.MemoryInfoStat{RSS: p.rss}, nil
+}
+
+func (p *fakeProcess) PercentWithContext(context.Context, time.Duration) (float64, error) {
+	p.calls++
+	if p.calls == 1 {
+		return 0, nil
+	}
+	return p.cpu, nil
+}
+
+func (p *fakeProcess) NumFDsWithContext(context.Context) (int32, error) {
+	if p.fds < 0 {
+		return 0, errors.New("permission denied")
+	}
+	return p.fds, nil
+}
+
+func (p *fakeProcess) NumThreadsWithContext(context.Context) (int32, error) {
+	return p.threads, nil
+}
+
+func TestProcessStatsCollectStrategy_Collect(t *testing.T) {
+	running := map[int32]*fakeProcess{
+		1:  {name: "init", rss: 1},
+		10: {name: "nginx", rss: 100, cpu: 5, fds: 10, threads: 1},
+		11: {name: "nginx", rss: 200, cpu: 15, fds: 20, threads: 2},
+		42: {name: "envoy", rss: 300, cpu: 1.5, fds: -1, threads: 8},
+	}
+	targets, err := ParseProcessTargets([]string{"nginx", "42", "redis-server"})
+	require.NoError(t, err)
+	strategy, err := NewProcessStatsCollectStrategy(targets, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	strategy.pids = func(context.Context) ([]int32, error) {
+		pids := make([]int32, 0, len(running))
+		for pid := range running {
+			pids = append(pids, pid)
+		}
+		return pids, nil
+	}
+	strategy.open = func(_ context.Context, pid int32) (processHandle, error) {
+		p, ok := running[pid]
+		if !ok {
+			return nil, errors.New("process not found")
+		}
+		return p, nil
+	}
+
+	metrics, err := strategy.Collect()
+	require.NoError(t, err)
+	values := metricValues(metrics)
+	assert.Equal(t, 2.0, values["ProcessCount_nginx"])
+	assert.Equal(t, 300.0, values["ProcessRSS_nginx"])
+	assert.Equal(t, 30.0, values["ProcessOpenFDs_nginx"])
+	assert.Equal(t, 3.0, values["ProcessThreads_nginx"])
+	assert.Zero(t, values["ProcessCPUPercent_nginx"], "the first collection has no CPU usage baseline")
+	assert.Equal(t, 1.0, values["ProcessCount_pid_42"])
+	assert.Equal(t, 8.0, values["ProcessThreads_pid_42"])
+	assert.NotContains(t, values, "ProcessOpenFDs_pid_42", "unreadable statistics are skipped")
+	assert.Equal(t, 0.0, values["ProcessCount_redis_server"])
+	assert.NotContains(t, values, "ProcessRSS_redis_server")
+	assert.NotContains(t, values, "ProcessRSS_init")
+
+	delete(running, 11)
+	metrics, err = strategy.Collect()
+	require.NoError(t, err)
+	values = metricValues(metrics)
+	assert.Equal(t, 1.0, values["ProcessCount_nginx"])
+	assert.Equal(t, 5.0, values["ProcessCPUPercent_nginx"], "the processes are kept between collections")
+	assert.Equal(t, 1.5, values["ProcessCPUPercent_pid_42"])
+	assert.NotContains(t, strategy.handles, int32(11), "exited processes are forgotten")
+	assert.NotContains(t, strategy.handles, int32(1), "untracked processes are not kept")
+}
+
+func TestProcessStatsCollectStrategy_CollectError(t *testing.T) {
+	strategy, err := NewProcessStatsCollectStrategy(nil, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	strategy.pids = func(context.Context) ([]int32, error) {
+		return nil, errors.New("no procfs")
+	}
+
+	_, err = strategy.Collect()
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package stategies
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ProcessStatsStrategyName is the configuration name of ProcessStatsCollectStrategy.
+const ProcessStatsStrategyName = "process"
+
+// ErrProcessStatsUnavailable is returned when creating a ProcessStatsCollectStrategy in a build without gopsutil.
+var ErrProcessStatsUnavailable = errors.New(
+	"process stats are not available: the agent is built with the nogopsutil tag",
+)
+
+// ProcessTarget is a process, or a set of processes sharing a name, tracked by ProcessStatsCollectStrategy.
+type ProcessTarget struct {
+	Name  string // Name matches the processes by their executable name; empty when PID is set.
+	Label string // Label identifies the target in the metric names.
+	PID   int32  // PID matches a single process by its identifier; zero when Name is set.
+}
+
+// ParseProcessTargets parses the tracked processes given as process names or PIDs.
+// A target named by a PID is labelled "pid_<PID>", one named by a process name is labelled by the name.
+//
+// Parameters:
+//   - specs: The process names and PIDs.
+//
+// Returns:
+//   - []ProcessTarget: The parsed targets.
+//   - error: An error if a target is empty, a PID is not positive or a target is repeated.
+func ParseProcessTargets(specs []string) ([]ProcessTarget, error) {
+	targets := make([]ProcessTarget, 0, len(specs))
+	seen := make(map[string]struct{}, len(specs))
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			return nil, errors.New("empty process target")
+		}
+
+		var target ProcessTarget
+		if pid, err := strconv.ParseInt(spec, 10, 32); err == nil {
+			if pid <= 0 {
+				return nil, fmt.Errorf("invalid process id %d", pid)
+			}
+			target = ProcessTarget{PID: int32(pid), Label: "pid_" + spec}
+		} else {
+			target = ProcessTarget{Name: spec, Label: metricSuffix(spec)}
+		}
+
+		if _, ok := seen[target.Label]; ok {
+			return nil, fmt.Errorf("duplicate process target %q", spec)
+		}
+		seen[target.Label] = struct{}{}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
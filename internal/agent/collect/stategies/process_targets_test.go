@@ -0,0 +1,23 @@
+package stategies
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProcessTargets(t *testing.T) {
+	targets, err := ParseProcessTargets([]string{"nginx", " 42 ", "redis-server"})
+	require.NoError(t, err)
+	assert.Equal(t, []ProcessTarget{
+		{Name: "nginx", Label: "nginx"},
+		{PID: 42, Label: "pid_42"},
+		{Name: "redis-server", Label: "redis_server"},
+	}, targets)
+
+	for _, specs := range [][]string{{""}, {"0"}, {"-3"}, {"nginx", "nginx"}} {
+		_, err := ParseProcessTargets(specs)
+		assert.Error(t, err, "%v", specs)
+	}
+}
@@ -0,0 +1,25 @@
+package stategies
+
+import (
+	"github.com/gdyunin/metricol.git/internal/agent/collect"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// GopsStatsStrategyName is the configuration name of GopsStatsCollectStrategy.
+	GopsStatsStrategyName = "gopsutil"
+	// DiskStatsStrategyName is the configuration name of DiskStatsCollectStrategy.
+	DiskStatsStrategyName = "disk"
+	// NetStatsStrategyName is the configuration name of NetStatsCollectStrategy.
+	NetStatsStrategyName = "net"
+)
+
+// init registers the strategies that need no configuration, so they can be enabled by name.
+// The scrape and clock drift strategies depend on the agent settings and are added by the agent directly.
+// The gopsutil, disk and net strategies register themselves, as they are left out of builds with the nogopsutil tag.
+func init() {
+	collect.RegisterStrategy(MemStatsStrategyName, func(logger *zap.SugaredLogger) (collect.Strategy, error) {
+		return NewMemStatsCollectStrategy(logger), nil
+	})
+}
@@ -0,0 +1,22 @@
+//go:build !nogopsutil
+
+package stategies
+
+import (
+	"github.com/gdyunin/metricol.git/internal/agent/collect"
+
+	"go.uber.org/zap"
+)
+
+// init registers the strategies built on gopsutil.
+func init() {
+	collect.RegisterStrategy(GopsStatsStrategyName, func(logger *zap.SugaredLogger) (collect.Strategy, error) {
+		return GopsMemStatsCollectStrategy(logger), nil
+	})
+	collect.RegisterStrategy(DiskStatsStrategyName, func(logger *zap.SugaredLogger) (collect.Strategy, error) {
+		return NewDiskStatsCollectStrategy(logger), nil
+	})
+	collect.RegisterStrategy(NetStatsStrategyName, func(logger *zap.SugaredLogger) (collect.Strategy, error) {
+		return NewNetStatsCollectStrategy(logger), nil
+	})
+}
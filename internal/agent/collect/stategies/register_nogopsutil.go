@@ -0,0 +1,13 @@
+//go:build nogopsutil
+
+package stategies
+
+import "github.com/gdyunin/metricol.git/internal/agent/collect"
+
+// init records the strategies built on gopsutil as unavailable, so configurations enabling them keep working
+// in minimal builds that collect runtime metrics only.
+func init() {
+	for _, name := range []string{GopsStatsStrategyName, DiskStatsStrategyName, NetStatsStrategyName} {
+		collect.RegisterUnavailableStrategy(name, "the agent is built with the nogopsutil tag")
+	}
+}
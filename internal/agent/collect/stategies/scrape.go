@@ -0,0 +1,197 @@
+package stategies
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/promtext"
+	"go.uber.org/zap"
+)
+
+const (
+	// ScrapeStrategyName is the configuration name of ScrapeStrategy.
+	ScrapeStrategyName = "scrape"
+	// scrapeTimeout bounds a single scrape of a target.
+	scrapeTimeout = 5 * time.Second
+	// maxScrapeSize bounds the exposition read from a target.
+	maxScrapeSize = 16 << 20
+	// scrapeAccept asks targets for the text exposition format.
+	scrapeAccept = "text/plain;version=0.0.4"
+	// jobLabel is the label naming the target a series was scraped from.
+	jobLabel = "job"
+	// exportedJobLabel keeps a job label exposed by the target itself.
+	exportedJobLabel = "exported_job"
+)
+
+// ScrapeTarget is a local Prometheus endpoint scraped by ScrapeStrategy.
+type ScrapeTarget struct {
+	Name string // Name identifies the target and becomes the job label of its series.
+	URL  string // URL is the address of the /metrics endpoint.
+}
+
+// ScrapeStrategy is a collection strategy that scrapes the Prometheus /metrics endpoints of co-located
+// applications and forwards the selected series as gauges named after the series, e.g.
+// `http_requests_total{code="200",job="api"}`. Counters are forwarded as gauges holding the current total,
+// so the server keeps the value the application reported.
+type ScrapeStrategy struct {
+	logger    *zap.SugaredLogger
+	client    *http.Client
+	targets   []ScrapeTarget // targets are the endpoints scraped every collection.
+	selectors []string       // selectors are the glob patterns of metric names to forward; empty forwards all.
+}
+
+// NewScrapeStrategy validates the selectors and creates a ScrapeStrategy.
+//
+// Parameters:
+//   - targets: The endpoints to scrape.
+//   - selectors: Glob patterns of metric names to forward, matched without labels; empty forwards all.
+//   - logger: Logger instance for recording events.
+//
+// Returns:
+//   - *ScrapeStrategy: A pointer to the newly created ScrapeStrategy instance.
+//   - error: An error if a selector is malformed.
+func NewScrapeStrategy(targets []ScrapeTarget, selectors []string, logger *zap.SugaredLogger) (*ScrapeStrategy, error) {
+	for _, s := range selectors {
+		if _, err := path.Match(s, ""); err != nil {
+			return nil, fmt.Errorf("invalid scrape selector %q: %w", s, err)
+		}
+	}
+	logger.Infof("Initializing ScrapeStrategy with %d targets", len(targets))
+	return &ScrapeStrategy{
+		logger:    logger,
+		client:    &http.Client{Timeout: scrapeTimeout},
+		targets:   targets,
+		selectors: selectors,
+	}, nil
+}
+
+// ParseScrapeTargets parses scrape targets given as "name=url" pairs.
+//
+// Parameters:
+//   - pairs: The target pairs.
+//
+// Returns:
+//   - []ScrapeTarget: The targets in the given order.
+//   - error: An error if a pair is malformed or a name is repeated.
+func ParseScrapeTargets(pairs []string) ([]ScrapeTarget, error) {
+	targets := make([]ScrapeTarget, 0, len(pairs))
+	seen := make(map[string]struct{}, len(pairs))
+	for _, pair := range pairs {
+		name, url, ok := strings.Cut(pair, "=")
+		name, url = strings.TrimSpace(name), strings.TrimSpace(url)
+		if !ok || name == "" || url == "" {
+			return nil, fmt.Errorf("invalid scrape target %q: expected name=url", pair)
+		}
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			return nil, fmt.Errorf("invalid scrape target %q: the url must use http or https", pair)
+		}
+		if _, exists := seen[name]; exists {
+			return nil, fmt.Errorf("scrape target %q is repeated", name)
+		}
+		seen[name] = struct{}{}
+		targets = append(targets, ScrapeTarget{Name: name, URL: url})
+	}
+	return targets, nil
+}
+
+// Name returns the configuration name of the strategy.
+//
+// Returns:
+//   - string: The strategy name.
+func (s *ScrapeStrategy) Name() string {
+	return ScrapeStrategyName
+}
+
+// Collect scrapes every target and returns the selected series. A failing target is logged and skipped,
+// so one stopped application does not hide the others; an error is returned only if all targets fail.
+//
+// Returns:
+//   - *entity.Metrics: A pointer to the collected metrics.
+//   - error: An error if no target could be scraped; otherwise, nil.
+func (s *ScrapeStrategy) Collect() (*entity.Metrics, error) {
+	var (
+		metrics entity.Metrics
+		errs    []error
+	)
+	for _, target := range s.targets {
+		scraped, err := s.scrape(target)
+		if err != nil {
+			s.logger.Warnf("Failed to scrape %s: %v", target.Name, err)
+			errs = append(errs, err)
+			continue
+		}
+		metrics = append(metrics, scraped...)
+	}
+	if len(s.targets) > 0 && len(errs) == len(s.targets) {
+		return nil, fmt.Errorf("failed to scrape all targets: %w", errors.Join(errs...))
+	}
+	return &metrics, nil
+}
+
+// scrape fetches a target and converts its selected samples into gauges.
+func (s *ScrapeStrategy) scrape(target ScrapeTarget) (entity.Metrics, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.URL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("target %s: failed to build request: %w", target.Name, err)
+	}
+	req.Header.Set("Accept", scrapeAccept)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("target %s: %w", target.Name, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("target %s: unexpected status %s", target.Name, resp.Status)
+	}
+
+	samples, err := promtext.Parse(io.LimitReader(resp.Body, maxScrapeSize))
+	if err != nil {
+		return nil, fmt.Errorf("target %s: %w", target.Name, err)
+	}
+
+	metrics := make(entity.Metrics, 0, len(samples))
+	for _, sample := range samples {
+		if !promtext.IsFinite(sample.Value) || !s.selected(sample.Name) {
+			continue
+		}
+		if job, ok := sample.Labels[jobLabel]; ok {
+			sample.Labels[exportedJobLabel] = job
+		}
+		sample.Labels[jobLabel] = target.Name
+
+		m := &entity.Metric{
+			Name:  promtext.SeriesName(sample.Name, sample.Labels),
+			Type:  entity.MetricTypeGauge,
+			Value: sample.Value,
+		}
+		if sample.HasTimestamp {
+			m.Timestamp = time.UnixMilli(sample.Timestamp)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// selected reports whether a metric name matches the selectors.
+func (s *ScrapeStrategy) selected(name string) bool {
+	if len(s.selectors) == 0 {
+		return true
+	}
+	for _, pattern := range s.selectors {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
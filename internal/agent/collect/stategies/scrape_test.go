@@ -0,0 +1,181 @@
+package stategies
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// exposition is a /metrics body served by a scraped application.
+const exposition = `# HELP http_requests_total Handled requests.
+# TYPE http_requests_total counter
+http_requests_total{code="200"} 1027
+http_requests_total{code="500",job="legacy"} 3 1700000000000
+# TYPE go_goroutines gauge
+go_goroutines 12
+process_start_time_seconds NaN
+`
+
+// serveExposition starts a test server answering every request with the given status and body.
+func serveExposition(t *testing.T, status int, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, scrapeAccept, r.Header.Get("Accept"))
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// metricValues maps the collected metrics by name.
+func metricValues(metrics *entity.Metrics) map[string]any {
+	values := make(map[string]any, len(*metrics))
+	for _, m := range *metrics {
+		values[m.Name] = m.Value
+	}
+	return values
+}
+
+func TestScrapeStrategy_Collect(t *testing.T) {
+	srv := serveExposition(t, http.StatusOK, exposition)
+
+	tests := []struct {
+		expected  map[string]any
+		name      string
+		selectors []string
+	}{
+		{
+			name: "All series",
+			expected: map[string]any{
+				`http_requests_total{code="200",job="api"}`:                       1027.0,
+				`http_requests_total{code="500",exported_job="legacy",job="api"}`: 3.0,
+				`go_goroutines{job="api"}`:                                        12.0,
+			},
+		},
+		{
+			name:      "Selected series",
+			selectors: []string{"http_*"},
+			expected: map[string]any{
+				`http_requests_total{code="200",job="api"}`:                       1027.0,
+				`http_requests_total{code="500",exported_job="legacy",job="api"}`: 3.0,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy, err := NewScrapeStrategy(
+				[]ScrapeTarget{{Name: "api", URL: srv.URL}},
+				tt.selectors,
+				zap.NewNop().Sugar(),
+			)
+			require.NoError(t, err)
+
+			metrics, err := strategy.Collect()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, metricValues(metrics))
+			for _, m := range *metrics {
+				assert.Equal(t, entity.MetricTypeGauge, m.Type)
+			}
+		})
+	}
+}
+
+func TestScrapeStrategy_CollectTimestamps(t *testing.T) {
+	srv := serveExposition(t, http.StatusOK, exposition)
+	strategy, err := NewScrapeStrategy([]ScrapeTarget{{Name: "api", URL: srv.URL}}, nil, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	metrics, err := strategy.Collect()
+	require.NoError(t, err)
+	for _, m := range *metrics {
+		if m.Name == `http_requests_total{code="500",exported_job="legacy",job="api"}` {
+			assert.Equal(t, time.UnixMilli(1700000000000), m.Timestamp)
+		} else {
+			assert.True(t, m.Timestamp.IsZero(), m.Name)
+		}
+	}
+}
+
+func TestScrapeStrategy_CollectFailures(t *testing.T) {
+	ok := serveExposition(t, http.StatusOK, "up 1\n")
+	broken := serveExposition(t, http.StatusInternalServerError, "")
+	malformed := serveExposition(t, http.StatusOK, "up one\n")
+	logger := zap.NewNop().Sugar()
+
+	t.Run("Partial failure", func(t *testing.T) {
+		strategy, err := NewScrapeStrategy(
+			[]ScrapeTarget{{Name: "ok", URL: ok.URL}, {Name: "broken", URL: broken.URL}},
+			nil,
+			logger,
+		)
+		require.NoError(t, err)
+
+		metrics, err := strategy.Collect()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{`up{job="ok"}`: 1.0}, metricValues(metrics))
+	})
+
+	t.Run("All targets fail", func(t *testing.T) {
+		strategy, err := NewScrapeStrategy(
+			[]ScrapeTarget{{Name: "broken", URL: broken.URL}, {Name: "malformed", URL: malformed.URL}},
+			nil,
+			logger,
+		)
+		require.NoError(t, err)
+
+		_, err = strategy.Collect()
+		assert.Error(t, err)
+	})
+}
+
+func TestNewScrapeStrategy_InvalidSelector(t *testing.T) {
+	_, err := NewScrapeStrategy(nil, []string{"http_["}, zap.NewNop().Sugar())
+	assert.Error(t, err)
+}
+
+func TestParseScrapeTargets(t *testing.T) {
+	tests := []struct {
+		name        string
+		pairs       []string
+		expected    []ScrapeTarget
+		expectError bool
+	}{
+		{name: "Empty", expected: []ScrapeTarget{}},
+		{
+			name:  "Targets",
+			pairs: []string{"api=http://localhost:9100/metrics", " db = https://127.0.0.1:9187/metrics "},
+			expected: []ScrapeTarget{
+				{Name: "api", URL: "http://localhost:9100/metrics"},
+				{Name: "db", URL: "https://127.0.0.1:9187/metrics"},
+			},
+		},
+		{name: "Missing url", pairs: []string{"api="}, expectError: true},
+		{name: "Missing name", pairs: []string{"http://localhost:9100/metrics"}, expectError: true},
+		{name: "Unsupported scheme", pairs: []string{"api=localhost:9100/metrics"}, expectError: true},
+		{
+			name:        "Repeated name",
+			pairs:       []string{"api=http://a/metrics", "api=http://b/metrics"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, err := ParseScrapeTargets(tt.pairs)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, targets)
+		})
+	}
+}
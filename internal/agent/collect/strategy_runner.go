@@ -0,0 +1,115 @@
+package collect
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/crash"
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/clock"
+)
+
+var (
+	// ErrStrategyTimeout is returned when a strategy does not finish within its timeout.
+	ErrStrategyTimeout = errors.New("strategy timed out")
+	// ErrStrategyBusy is returned when a strategy is still running a previous, timed out collection.
+	ErrStrategyBusy = errors.New("strategy is still running a previous collection")
+)
+
+// collectResult holds the outcome of a single Collect call.
+type collectResult struct {
+	metrics *entity.Metrics // metrics is the collected batch.
+	err     error           // err is the collection error.
+}
+
+// strategyRunner runs a Strategy with a timeout and isolates the collector from its failures:
+// a panic is turned into an error and a hung call blocks neither the caller nor later cycles.
+type strategyRunner struct {
+	lastRun  time.Time       // lastRun is the moment of the last started collection.
+	strategy Strategy        // strategy is the wrapped collection strategy.
+	clock    clock.Clock     // clock times the collection timeout.
+	crash    *crash.Reporter // crash records panics of the strategy; nil only logs them as errors.
+	name     string          // name is the configuration name of the strategy.
+	busy     atomic.Bool     // busy reports that a Collect call has not returned yet.
+	timeout  time.Duration   // timeout bounds a single Collect call; zero means no timeout.
+	interval time.Duration   // interval overrides the collector interval; zero uses it.
+}
+
+// newStrategyRunner creates a strategyRunner for strategy.
+func newStrategyRunner(strategy Strategy, timeout, interval time.Duration) *strategyRunner {
+	return &strategyRunner{
+		strategy: strategy,
+		clock:    clock.Real(),
+		name:     strategyName(strategy),
+		timeout:  timeout,
+		interval: interval,
+	}
+}
+
+// due reports whether the strategy should run at now and, if so, records the run.
+// A tolerance absorbs ticker jitter so a run is not postponed by a whole tick.
+//
+// Parameters:
+//   - now: The current tick.
+//   - defaultInterval: The collector interval used when the strategy has no override.
+//   - tolerance: How early a run may start.
+//
+// Returns:
+//   - bool: True if the strategy should run.
+func (r *strategyRunner) due(now time.Time, defaultInterval, tolerance time.Duration) bool {
+	interval := r.interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	if !r.lastRun.IsZero() && now.Sub(r.lastRun) < interval-tolerance {
+		return false
+	}
+	r.lastRun = now
+	return true
+}
+
+// collect runs the strategy and waits for it at most the configured timeout. A call that times out keeps
+// running in the background; until it returns the strategy is skipped, so hung calls do not pile up.
+//
+// Returns:
+//   - *entity.Metrics: The collected metrics.
+//   - error: ErrStrategyBusy, ErrStrategyTimeout, a recovered panic or the strategy error.
+func (r *strategyRunner) collect() (*entity.Metrics, error) {
+	if !r.busy.CompareAndSwap(false, true) {
+		return nil, ErrStrategyBusy
+	}
+
+	resultCh := make(chan collectResult, 1)
+	go func() {
+		defer r.busy.Store(false)
+		defer func() {
+			if rec := recover(); rec != nil {
+				if r.crash != nil {
+					r.crash.Record("strategy-"+r.name, rec, debug.Stack(), "")
+				}
+				resultCh <- collectResult{err: fmt.Errorf("strategy panicked: %v", rec)}
+			}
+		}()
+
+		metrics, err := r.strategy.Collect()
+		resultCh <- collectResult{metrics: metrics, err: err}
+	}()
+
+	if r.timeout <= 0 {
+		res := <-resultCh
+		return res.metrics, res.err
+	}
+
+	timer := r.clock.NewTimer(r.timeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-resultCh:
+		return res.metrics, res.err
+	case <-timer.C():
+		return nil, fmt.Errorf("%w after %s", ErrStrategyTimeout, r.timeout)
+	}
+}
@@ -0,0 +1,173 @@
+package collect
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/crash"
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// funcStrategy adapts a function to the Strategy interface.
+type funcStrategy func() (*entity.Metrics, error)
+
+func (f funcStrategy) Collect() (*entity.Metrics, error) {
+	return f()
+}
+
+// blockingStrategy blocks until release is closed.
+func blockingStrategy(release chan struct{}) Strategy {
+	return funcStrategy(func() (*entity.Metrics, error) {
+		<-release
+		return &entity.Metrics{}, nil
+	})
+}
+
+func TestStrategyRunner_Collect(t *testing.T) {
+	batch := entity.Metrics{{Name: "test", Type: entity.MetricTypeGauge, Value: 1.0}}
+	collectErr := errors.New("collect error")
+
+	tests := []struct {
+		strategy    Strategy
+		expectedErr error
+		expected    *entity.Metrics
+		name        string
+		timeout     time.Duration
+		wantErr     bool
+	}{
+		{
+			name:     "success",
+			strategy: funcStrategy(func() (*entity.Metrics, error) { return &batch, nil }),
+			timeout:  time.Second,
+			expected: &batch,
+		},
+		{
+			name:     "success without timeout",
+			strategy: funcStrategy(func() (*entity.Metrics, error) { return &batch, nil }),
+			expected: &batch,
+		},
+		{
+			name:        "strategy error",
+			strategy:    funcStrategy(func() (*entity.Metrics, error) { return nil, collectErr }),
+			timeout:     time.Second,
+			expectedErr: collectErr,
+			wantErr:     true,
+		},
+		{
+			name:     "panic is recovered",
+			strategy: funcStrategy(func() (*entity.Metrics, error) { panic("boom") }),
+			timeout:  time.Second,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics, err := newStrategyRunner(tt.strategy, tt.timeout, 0).collect()
+			if tt.wantErr {
+				require.Error(t, err)
+				if tt.expectedErr != nil {
+					assert.ErrorIs(t, err, tt.expectedErr)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, metrics)
+		})
+	}
+}
+
+func TestStrategyRunner_PanicIsReported(t *testing.T) {
+	reporter := crash.NewReporter("", zap.NewNop().Sugar())
+	runner := newStrategyRunner(funcStrategy(func() (*entity.Metrics, error) { panic("boom") }), time.Second, 0)
+	runner.crash = reporter
+
+	_, err := runner.collect()
+	require.ErrorContains(t, err, "strategy panicked: boom")
+	assert.Equal(t, int64(1), reporter.Total())
+}
+
+// collectWithTimeout runs a collection and expires its timeout on a fake clock.
+func collectWithTimeout(t *testing.T, runner *strategyRunner) error {
+	t.Helper()
+	fake := clock.NewFake(time.Now())
+	runner.clock = fake
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := runner.collect()
+		errCh <- err
+	}()
+	fake.BlockUntil(1)
+	fake.Advance(runner.timeout)
+	return <-errCh
+}
+
+func TestStrategyRunner_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	runner := newStrategyRunner(blockingStrategy(release), time.Minute, 0)
+	assert.ErrorIs(t, collectWithTimeout(t, runner), ErrStrategyTimeout)
+
+	// The abandoned collection ends once the strategy returns.
+	close(release)
+	require.Eventually(t, func() bool { return !runner.busy.Load() }, time.Second, 5*time.Millisecond)
+}
+
+func TestStrategyRunner_SkipsWhileHung(t *testing.T) {
+	release := make(chan struct{})
+	runner := newStrategyRunner(blockingStrategy(release), time.Minute, 0)
+
+	err := collectWithTimeout(t, runner)
+	require.ErrorIs(t, err, ErrStrategyTimeout)
+
+	_, err = runner.collect()
+	require.ErrorIs(t, err, ErrStrategyBusy)
+
+	close(release)
+	require.Eventually(t, func() bool { return !runner.busy.Load() }, time.Second, 5*time.Millisecond)
+
+	_, err = runner.collect()
+	assert.NoError(t, err)
+}
+
+func TestStrategyRunner_Due(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		offsets  []time.Duration
+		expected []bool
+		interval time.Duration
+	}{
+		{
+			name:     "follows the collector interval",
+			offsets:  []time.Duration{0, time.Second, 2 * time.Second},
+			expected: []bool{true, true, true},
+		},
+		{
+			name:     "override skips ticks",
+			interval: 3 * time.Second,
+			offsets:  []time.Duration{0, time.Second, 2 * time.Second, 3 * time.Second},
+			expected: []bool{true, false, false, true},
+		},
+		{
+			name:     "early tick within tolerance runs",
+			interval: 3 * time.Second,
+			offsets:  []time.Duration{0, 2900 * time.Millisecond},
+			expected: []bool{true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newStrategyRunner(&emptyStrategy{}, 0, tt.interval)
+			for i, offset := range tt.offsets {
+				assert.Equal(t, tt.expected[i], runner.due(start.Add(offset), time.Second, 500*time.Millisecond), "tick %d", i)
+			}
+		})
+	}
+}
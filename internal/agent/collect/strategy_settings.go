@@ -0,0 +1,117 @@
+package collect
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// strategyDisabled is the strategy setting value that turns a strategy off.
+const strategyDisabled = "off"
+
+// NamedStrategy is implemented by strategies that can be configured by name.
+type NamedStrategy interface {
+	Strategy
+	// Name returns the name the strategy is configured by.
+	Name() string
+}
+
+// StrategySettings overrides the collector defaults for a single strategy.
+type StrategySettings struct {
+	Interval time.Duration // Interval is the poll interval of the strategy; zero uses the collector interval.
+	Disabled bool          // Disabled turns the strategy off.
+}
+
+// ParseStrategySettings parses per-strategy settings in the form "name=value,name=value",
+// where value is either an interval in seconds or "off".
+//
+// Parameters:
+//   - spec: The settings specification; an empty one yields no overrides.
+//
+// Returns:
+//   - map[string]StrategySettings: The settings keyed by strategy name.
+//   - error: An error if the specification is malformed.
+func ParseStrategySettings(spec string) (map[string]StrategySettings, error) {
+	values, err := splitStrategySpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := make(map[string]StrategySettings, len(values))
+	for name, value := range values {
+		if value == strategyDisabled {
+			settings[name] = StrategySettings{Disabled: true}
+			continue
+		}
+		interval, err := parseStrategySeconds(name, value)
+		if err != nil {
+			return nil, err
+		}
+		settings[name] = StrategySettings{Interval: interval}
+	}
+	return settings, nil
+}
+
+// ParseStrategyCache parses per-strategy cache lifetimes in the form "name=seconds,name=seconds".
+//
+// Parameters:
+//   - spec: The cache specification; an empty one caches nothing.
+//
+// Returns:
+//   - map[string]time.Duration: The cache lifetimes keyed by strategy name.
+//   - error: An error if the specification is malformed.
+func ParseStrategyCache(spec string) (map[string]time.Duration, error) {
+	values, err := splitStrategySpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	ttls := make(map[string]time.Duration, len(values))
+	for name, value := range values {
+		ttl, err := parseStrategySeconds(name, value)
+		if err != nil {
+			return nil, err
+		}
+		ttls[name] = ttl
+	}
+	return ttls, nil
+}
+
+// splitStrategySpec splits a "name=value,name=value" specification into values keyed by strategy name.
+func splitStrategySpec(spec string) (map[string]string, error) {
+	values := make(map[string]string)
+	if strings.TrimSpace(spec) == "" {
+		return values, nil
+	}
+
+	for _, item := range strings.Split(spec, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" || value == "" {
+			return nil, fmt.Errorf("invalid strategy setting %q, expected name=value", item)
+		}
+		if _, exists := values[name]; exists {
+			return nil, fmt.Errorf("duplicate setting for strategy %q", name)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// parseStrategySeconds parses a positive number of seconds configured for a strategy.
+func parseStrategySeconds(name, value string) (time.Duration, error) {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("invalid value %q for strategy %q, expected a positive number of seconds", value, name)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// strategyName returns the configuration name of a strategy, or its type for unnamed strategies.
+func strategyName(s Strategy) string {
+	if named, ok := s.(NamedStrategy); ok {
+		return named.Name()
+	}
+	return fmt.Sprintf("%T", s)
+}
@@ -0,0 +1,94 @@
+package collect
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStrategySettings(t *testing.T) {
+	tests := []struct {
+		expected map[string]StrategySettings
+		name     string
+		spec     string
+		wantErr  bool
+	}{
+		{
+			name:     "empty",
+			spec:     "  ",
+			expected: map[string]StrategySettings{},
+		},
+		{
+			name: "interval and disabled",
+			spec: "memstats=5, gopsutil=off",
+			expected: map[string]StrategySettings{
+				"memstats": {Interval: 5 * time.Second},
+				"gopsutil": {Disabled: true},
+			},
+		},
+		{name: "missing value", spec: "memstats=", wantErr: true},
+		{name: "missing separator", spec: "memstats", wantErr: true},
+		{name: "non-positive interval", spec: "memstats=0", wantErr: true},
+		{name: "invalid interval", spec: "memstats=fast", wantErr: true},
+		{name: "duplicate", spec: "memstats=5,memstats=off", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, err := ParseStrategySettings(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, settings)
+		})
+	}
+}
+
+// namedFuncStrategy is a funcStrategy with a configuration name.
+type namedFuncStrategy struct {
+	funcStrategy
+	name string
+}
+
+func (n namedFuncStrategy) Name() string {
+	return n.name
+}
+
+func TestStrategyName(t *testing.T) {
+	assert.Equal(t, "custom", strategyName(namedFuncStrategy{name: "custom"}))
+	assert.Equal(t, "*collect.errorStrategy", strategyName(&errorStrategy{}))
+}
+
+func TestParseStrategyCache(t *testing.T) {
+	tests := []struct {
+		expected map[string]time.Duration
+		name     string
+		spec     string
+		wantErr  bool
+	}{
+		{name: "empty", spec: "", expected: map[string]time.Duration{}},
+		{
+			name:     "lifetimes",
+			spec:     "gopsutil=30,memstats=5",
+			expected: map[string]time.Duration{"gopsutil": 30 * time.Second, "memstats": 5 * time.Second},
+		},
+		{name: "disabled is not a lifetime", spec: "gopsutil=off", wantErr: true},
+		{name: "malformed", spec: "gopsutil", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ttls, err := ParseStrategyCache(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ttls)
+		})
+	}
+}
@@ -6,13 +6,21 @@ package collect
 
 import (
 	"context"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/gdyunin/metricol.git/internal/agent/crash"
 	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/agent/lifecycle"
 	"go.uber.org/zap"
 )
 
+// staleIntervals is the number of the longest poll intervals without a collected batch
+// after which the collector reports itself unhealthy.
+const staleIntervals = 3
+
 // Strategy defines an interface for a metric collection strategy.
 // Implementations of Strategy should provide a Collect method that gathers metrics
 // and returns them along with an error if one occurs.
@@ -25,13 +33,196 @@ type Strategy interface {
 	Collect() (*entity.Metrics, error)
 }
 
+// BatchRecorder records the batches the collector streams, e.g. to replay them later.
+type BatchRecorder interface {
+	// Record records a batch collected by a strategy.
+	Record(strategy string, metrics *entity.Metrics) error
+}
+
 // StreamCollector periodically collects metrics from multiple strategies and streams
 // them to a specified channel. It is designed to work concurrently using a ticker.
 type StreamCollector struct {
-	streamTo          chan *entity.Metrics
-	logger            *zap.SugaredLogger
-	collectStrategies []Strategy
-	interval          time.Duration
+	streamTo        chan *entity.Metrics
+	logger          *zap.SugaredLogger
+	cycleGuard      func() bool
+	adaptive        *adaptiveInterval
+	settings        map[string]StrategySettings
+	cacheTTLs       map[string]time.Duration
+	rules           *MetricRules
+	labels          *MetricLabels
+	hosts           *VirtualHosts   // hosts fans the collected metrics out to simulated hosts; nil simulates none.
+	crash           *crash.Reporter // crash records panics of the collection goroutines.
+	recorder        BatchRecorder   // recorder records the streamed batches; nil records none.
+	retune          chan struct{}   // retune wakes the collection loop when the poll interval is changed.
+	runners         []*strategyRunner
+	life            lifecycle.Runner // life tracks the run started with Start.
+	keepStream      bool             // keepStream leaves streamTo open when a run ends, so the collector can restart.
+	startedAt       atomic.Int64     // startedAt is the Unix time in nanoseconds the collector was started at.
+	lastBatch       atomic.Int64     // lastBatch is the Unix time in nanoseconds of the last collected batch.
+	mu              sync.Mutex       // mu protects interval.
+	interval        time.Duration
+	strategyTimeout time.Duration
+}
+
+// Option configures optional StreamCollector settings.
+type Option func(*StreamCollector)
+
+// WithCycleGuard makes the collector ask guard before every collection cycle and skip the cycle
+// when it returns false, e.g. while the host is overloaded.
+//
+// Parameters:
+//   - guard: The function deciding whether a cycle may run.
+//
+// Returns:
+//   - Option: An option applying the guard.
+func WithCycleGuard(guard func() bool) Option {
+	return func(sc *StreamCollector) {
+		sc.cycleGuard = guard
+	}
+}
+
+// WithOpenStream leaves the stream channel open when the collector stops, so the collector can be started
+// again, e.g. by a lifecycle.Supervisor. The owner of the channel closes it once the collector is done.
+//
+// Returns:
+//   - Option: An option keeping the stream open.
+func WithOpenStream() Option {
+	return func(sc *StreamCollector) {
+		sc.keepStream = true
+	}
+}
+
+// WithCrashReporter records panics of the collection goroutines and strategies with reporter,
+// which writes crash dumps and counts the panics. By default panics are only logged.
+//
+// Parameters:
+//   - reporter: The crash reporter.
+//
+// Returns:
+//   - Option: An option applying the reporter.
+func WithCrashReporter(reporter *crash.Reporter) Option {
+	return func(sc *StreamCollector) {
+		if reporter != nil {
+			sc.crash = reporter
+		}
+	}
+}
+
+// WithStrategyTimeout bounds how long a single strategy may collect. A strategy that exceeds it is reported
+// as failed and skipped until its hung call returns; other strategies are not affected.
+// By default, and for a non-positive timeout, the timeout equals the poll interval.
+//
+// Parameters:
+//   - timeout: The maximum duration of a single Collect call.
+//
+// Returns:
+//   - Option: An option applying the timeout.
+func WithStrategyTimeout(timeout time.Duration) Option {
+	return func(sc *StreamCollector) {
+		if timeout > 0 {
+			sc.strategyTimeout = timeout
+		}
+	}
+}
+
+// WithStrategySettings overrides the interval of individual strategies or disables them, keyed by strategy
+// name (see NamedStrategy). Strategies without settings follow the collector interval.
+//
+// Parameters:
+//   - settings: The per-strategy settings.
+//
+// Returns:
+//   - Option: An option applying the settings.
+func WithStrategySettings(settings map[string]StrategySettings) Option {
+	return func(sc *StreamCollector) {
+		sc.settings = settings
+	}
+}
+
+// WithStrategyCache wraps the named strategies in a CachedStrategy, so their results are reused until
+// their cache lifetime elapses instead of being collected on every poll.
+//
+// Parameters:
+//   - ttls: The cache lifetimes keyed by strategy name.
+//
+// Returns:
+//   - Option: An option enabling the caches.
+func WithStrategyCache(ttls map[string]time.Duration) Option {
+	return func(sc *StreamCollector) {
+		sc.cacheTTLs = ttls
+	}
+}
+
+// WithMetricRules filters and renames collected metrics before they are streamed.
+//
+// Parameters:
+//   - rules: The filtering and renaming rules.
+//
+// Returns:
+//   - Option: An option applying the rules.
+func WithMetricRules(rules *MetricRules) Option {
+	return func(sc *StreamCollector) {
+		sc.rules = rules
+	}
+}
+
+// WithMetricLabels attaches labels to the collected metrics after the rules are applied.
+//
+// Parameters:
+//   - labels: The labels to attach.
+//
+// Returns:
+//   - Option: An option applying the labels.
+func WithMetricLabels(labels *MetricLabels) Option {
+	return func(sc *StreamCollector) {
+		sc.labels = labels
+	}
+}
+
+// WithVirtualHosts reports every collected metric once per simulated host, after the labels are attached.
+//
+// Parameters:
+//   - hosts: The simulated hosts.
+//
+// Returns:
+//   - Option: An option enabling the simulation.
+func WithVirtualHosts(hosts *VirtualHosts) Option {
+	return func(sc *StreamCollector) {
+		sc.hosts = hosts
+	}
+}
+
+// WithBatchRecorder records every batch right before it is streamed, after the metrics are filtered,
+// labeled and fanned out to the simulated hosts, so a recording holds exactly what the sender received.
+//
+// Parameters:
+//   - recorder: The recorder.
+//
+// Returns:
+//   - Option: An option enabling the recording.
+func WithBatchRecorder(recorder BatchRecorder) Option {
+	return func(sc *StreamCollector) {
+		sc.recorder = recorder
+	}
+}
+
+// WithAdaptiveInterval lets the collector lengthen the poll interval while metrics are stable and shorten it
+// while they change rapidly, keeping it within [minInterval, maxInterval]. The configured interval is the
+// starting point. The option is ignored unless 0 < minInterval <= maxInterval.
+//
+// Parameters:
+//   - minInterval: The shortest poll interval.
+//   - maxInterval: The longest poll interval.
+//
+// Returns:
+//   - Option: An option enabling the adaptive interval.
+func WithAdaptiveInterval(minInterval, maxInterval time.Duration) Option {
+	return func(sc *StreamCollector) {
+		if minInterval <= 0 || minInterval > maxInterval {
+			return
+		}
+		sc.adaptive = newAdaptiveInterval(sc.interval, minInterval, maxInterval)
+	}
 }
 
 // NewStreamCollector creates and initializes a new StreamCollector instance.
@@ -41,6 +232,7 @@ type StreamCollector struct {
 //   - interval: Time duration between successive metric collections (time.Duration).
 //   - collectStrategies: Slice of strategies to be used for collecting metrics ([]Strategy).
 //   - logger: Logger instance for logging events (*zap.SugaredLogger).
+//   - opts: Optional collector settings ([]Option).
 //
 // Returns:
 //   - *StreamCollector: A pointer to the newly created StreamCollector.
@@ -49,18 +241,55 @@ func NewStreamCollector(
 	interval time.Duration,
 	collectStrategies []Strategy,
 	logger *zap.SugaredLogger,
+	opts ...Option,
 ) *StreamCollector {
-	return &StreamCollector{
-		streamTo:          streamTo,
-		interval:          interval,
-		collectStrategies: collectStrategies,
-		logger:            logger,
+	sc := &StreamCollector{
+		streamTo:        streamTo,
+		interval:        interval,
+		logger:          logger,
+		strategyTimeout: interval,
+		crash:           crash.NewReporter("", logger),
+		retune:          make(chan struct{}, 1),
 	}
+	for _, opt := range opts {
+		opt(sc)
+	}
+
+	sc.runners = make([]*strategyRunner, 0, len(collectStrategies))
+	known := make(map[string]bool, len(collectStrategies))
+	for _, strategy := range collectStrategies {
+		name := strategyName(strategy)
+		known[name] = true
+		settings := sc.settings[name]
+		if settings.Disabled {
+			logger.Infof("Strategy %s is disabled", name)
+			continue
+		}
+		if ttl, ok := sc.cacheTTLs[name]; ok && ttl > 0 {
+			strategy = NewCachedStrategy(strategy, ttl)
+		}
+		runner := newStrategyRunner(strategy, sc.strategyTimeout, settings.Interval)
+		runner.crash = sc.crash
+		sc.runners = append(sc.runners, runner)
+	}
+	for name := range sc.settings {
+		if !known[name] {
+			logger.Warnf("Settings given for unknown strategy %s", name)
+		}
+	}
+	for name := range sc.cacheTTLs {
+		if !known[name] {
+			logger.Warnf("Cache configured for unknown strategy %s", name)
+		}
+	}
+	return sc
 }
 
 // StartStreaming begins the process of periodically collecting metrics using the defined strategies.
 // The function runs indefinitely until the provided context is canceled. Metrics collection is performed
-// concurrently and each successful collection is sent to the streamTo channel.
+// concurrently and each successful collection is sent to the streamTo channel. Every strategy runs with its
+// own timeout, so a failing, panicking or hung strategy does not hold back the others.
+// Metrics are stamped with the collection time so the server can order batches that were sent late.
 //
 // Parameters:
 //   - ctx: Context for managing the lifecycle of the metric streaming (context.Context).
@@ -68,13 +297,20 @@ func NewStreamCollector(
 // Returns:
 //   - This function does not return any value; it exits when the context is canceled.
 func (sc *StreamCollector) StartStreaming(ctx context.Context) {
-	ticker := time.NewTicker(sc.interval)
+	interval := sc.pollInterval()
+	if sc.adaptive != nil {
+		interval = sc.adaptive.next()
+	}
+	tick := sc.tickInterval(interval)
+	ticker := time.NewTicker(tick)
 	defer ticker.Stop()
 
 	var wg sync.WaitGroup
 	defer func() {
 		wg.Wait()
-		close(sc.streamTo)
+		if !sc.keepStream {
+			close(sc.streamTo)
+		}
 	}()
 
 	for {
@@ -82,29 +318,176 @@ func (sc *StreamCollector) StartStreaming(ctx context.Context) {
 		case <-ctx.Done():
 			sc.logger.Info("Context canceled: stopping stream.")
 			return
-		case <-ticker.C:
-			for _, strategy := range sc.collectStrategies {
+		case <-sc.retune:
+			interval = sc.pollInterval()
+			if sc.adaptive != nil {
+				interval = sc.adaptive.next()
+			}
+			tick = sc.tickInterval(interval)
+			ticker.Reset(tick)
+		case now := <-ticker.C:
+			if sc.adaptive != nil {
+				if next := sc.adaptive.next(); next != interval {
+					sc.logger.Infof("Poll interval adjusted from %s to %s", interval, next)
+					interval = next
+					tick = sc.tickInterval(interval)
+					ticker.Reset(tick)
+				}
+			}
+			if sc.cycleGuard != nil && !sc.cycleGuard() {
+				continue
+			}
+			for _, runner := range sc.runners {
+				if !runner.due(now, interval, tick/2) {
+					continue
+				}
 				// For review: Ideally, this should be done via a worker pool or semaphore.
 				// However, given the limited number of strategies, this limitation is acceptable
 				// at the current stage of the project.
 				wg.Add(1)
-				go func(s Strategy) {
+				go func(r *strategyRunner) {
 					defer wg.Done()
+					var collected *entity.Metrics
+					defer sc.crash.Recover("collector", func() string {
+						return r.name + ", " + crash.BatchSummary(collected)
+					})
 
-					collected, err := s.Collect()
+					collected, err := r.collect()
 					if err != nil {
-						sc.logger.Errorf("Collect failed with %T and error: %v", sc.collectStrategies, err)
+						sc.logger.Errorf("Collect failed with %s and error: %v", r.name, err)
 						return
 					}
 
 					if collected == nil || collected.Length() == 0 {
-						sc.logger.Errorf("Received empty batch from %T and skipping.", sc.collectStrategies)
+						sc.logger.Errorf("Received empty batch from %s and skipping.", r.name)
+						return
+					}
+					collectedAt := time.Now()
+					sc.lastBatch.Store(collectedAt.UnixNano())
+					stampCollected(collected, collectedAt)
+					if sc.adaptive != nil {
+						sc.adaptive.observe(collected)
+					}
+
+					sc.rules.Apply(collected)
+					if collected.Length() == 0 {
+						sc.logger.Debugf("All metrics from %s were filtered out.", r.name)
 						return
 					}
+					sc.labels.Apply(collected)
+					sc.hosts.Apply(collected)
+					if sc.recorder != nil {
+						if err := sc.recorder.Record(r.name, collected); err != nil {
+							sc.logger.Errorf("Failed to record batch from %s: %v", r.name, err)
+						}
+					}
 
-					sc.streamTo <- collected
-				}(strategy)
+					// The sender may have stopped with the queue full, so a blocked batch is dropped on shutdown.
+					select {
+					case sc.streamTo <- collected:
+					case <-ctx.Done():
+						sc.logger.Warnf("Dropped a batch of %d metrics from %s on shutdown", collected.Length(), r.name)
+					}
+				}(runner)
 			}
 		}
 	}
 }
+
+// SetInterval changes the poll interval of a running or stopped collector; the next collection happens
+// one new interval from now. With the adaptive interval, the adaptation starts over from the new interval.
+// Strategies with an interval override and the strategy timeout are not affected. A non-positive interval
+// is ignored.
+//
+// Parameters:
+//   - interval: The new poll interval.
+func (sc *StreamCollector) SetInterval(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	sc.mu.Lock()
+	sc.interval = interval
+	sc.mu.Unlock()
+	if sc.adaptive != nil {
+		sc.adaptive.restart(interval)
+	}
+	select {
+	case sc.retune <- struct{}{}:
+	default:
+	}
+}
+
+// Start runs the collector until the context is canceled or Stop is called. Like StartStreaming,
+// it closes the stream channel on return, so a collector can only be started once unless WithOpenStream is set.
+//
+// Parameters:
+//   - ctx: The context of the run.
+func (sc *StreamCollector) Start(ctx context.Context) {
+	sc.startedAt.Store(time.Now().UnixNano())
+	sc.life.Run(ctx, sc.StartStreaming)
+}
+
+// Stop stops a collector started with Start.
+func (sc *StreamCollector) Stop() {
+	sc.life.Stop()
+}
+
+// Healthy reports whether the collector runs and has collected a batch recently, that is within
+// staleIntervals of its longest poll interval.
+//
+// Returns:
+//   - error: lifecycle.ErrNotRunning, an error describing how long nothing was collected, or nil.
+func (sc *StreamCollector) Healthy() error {
+	if !sc.life.Running() {
+		return lifecycle.ErrNotRunning
+	}
+	last := max(sc.startedAt.Load(), sc.lastBatch.Load())
+	if idle := time.Since(time.Unix(0, last)); idle > staleIntervals*sc.longestInterval() {
+		return fmt.Errorf("no metrics collected for %s", idle.Round(time.Second))
+	}
+	return nil
+}
+
+// longestInterval returns the longest interval the collector or any of its strategies may poll at.
+func (sc *StreamCollector) longestInterval() time.Duration {
+	longest := sc.pollInterval()
+	if sc.adaptive != nil {
+		longest = max(longest, sc.adaptive.max)
+	}
+	for _, r := range sc.runners {
+		longest = max(longest, r.interval)
+	}
+	return longest
+}
+
+// pollInterval returns the configured poll interval.
+func (sc *StreamCollector) pollInterval() time.Duration {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.interval
+}
+
+// tickInterval returns the ticker period: the collector interval, or the shortest strategy interval
+// override if it is shorter.
+func (sc *StreamCollector) tickInterval(interval time.Duration) time.Duration {
+	tick := interval
+	for _, r := range sc.runners {
+		if r.interval > 0 && r.interval < tick {
+			tick = r.interval
+		}
+	}
+	return tick
+}
+
+// stampCollected sets the collection time on metrics that do not carry a timestamp yet.
+//
+// Parameters:
+//   - metrics: The collected metrics.
+//   - collectedAt: The moment of collection.
+func stampCollected(metrics *entity.Metrics, collectedAt time.Time) {
+	for _, m := range *metrics {
+		if m != nil && m.Timestamp.IsZero() {
+			m.Timestamp = collectedAt
+		}
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/agent/lifecycle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -134,3 +138,346 @@ func TestStreamCollector_StartStreaming(t *testing.T) {
 		})
 	}
 }
+
+func TestStampCollected(t *testing.T) {
+	collectedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	preset := collectedAt.Add(-time.Hour)
+	metrics := entity.Metrics{
+		{Name: "fresh", Type: entity.MetricTypeGauge, Value: 1.0},
+		{Name: "preset", Type: entity.MetricTypeGauge, Value: 2.0, Timestamp: preset},
+		nil,
+	}
+
+	stampCollected(&metrics, collectedAt)
+
+	if !metrics[0].Timestamp.Equal(collectedAt) {
+		t.Errorf("expected unstamped metric to get %v, got %v", collectedAt, metrics[0].Timestamp)
+	}
+	if !metrics[1].Timestamp.Equal(preset) {
+		t.Errorf("expected preset timestamp %v to be kept, got %v", preset, metrics[1].Timestamp)
+	}
+}
+
+func TestStreamCollector_CycleGuard(t *testing.T) {
+	tests := []struct {
+		name          string
+		allow         bool
+		expectedCount int
+	}{
+		{name: "cycle allowed", allow: true, expectedCount: 1},
+		{name: "cycle skipped", allow: false, expectedCount: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			streamTo := make(chan *entity.Metrics, 10)
+			var asked atomic.Int64
+			collector := NewStreamCollector(
+				streamTo,
+				50*time.Millisecond,
+				[]Strategy{&validStrategy{}},
+				zap.NewNop().Sugar(),
+				WithCycleGuard(func() bool {
+					asked.Add(1)
+					return tc.allow
+				}),
+			)
+			ctx, cancel := context.WithCancel(context.Background())
+			go collector.StartStreaming(ctx)
+
+			time.Sleep(75 * time.Millisecond)
+			cancel()
+
+			var count int
+			for batch := range streamTo {
+				if batch != nil && batch.Length() > 0 {
+					count++
+				}
+			}
+
+			if count != tc.expectedCount {
+				t.Errorf("expected %d valid batch(es) but got %d", tc.expectedCount, count)
+			}
+			if asked.Load() == 0 {
+				t.Error("expected the guard to be asked before the cycle")
+			}
+		})
+	}
+}
+
+func TestStreamCollector_HungStrategyIsolated(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	streamTo := make(chan *entity.Metrics, 10)
+	collector := NewStreamCollector(
+		streamTo,
+		50*time.Millisecond,
+		[]Strategy{blockingStrategy(release), &validStrategy{}},
+		zap.NewNop().Sugar(),
+		WithStrategyTimeout(10*time.Millisecond),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	go collector.StartStreaming(ctx)
+
+	time.Sleep(75 * time.Millisecond)
+	cancel()
+
+	done := make(chan int)
+	go func() {
+		var count int
+		for batch := range streamTo {
+			if batch != nil && batch.Length() > 0 {
+				count++
+			}
+		}
+		done <- count
+	}()
+
+	select {
+	case count := <-done:
+		if count != 1 {
+			t.Errorf("expected 1 valid batch but got %d", count)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("collector did not stop while a strategy was hung")
+	}
+}
+
+func TestStreamCollector_StrategySettings(t *testing.T) {
+	counting := func(calls *atomic.Int64, name string) Strategy {
+		return namedFuncStrategy{name: name, funcStrategy: func() (*entity.Metrics, error) {
+			calls.Add(1)
+			return &entity.Metrics{{Name: name, Type: entity.MetricTypeGauge, Value: 1.0}}, nil
+		}}
+	}
+	var fast, slow, disabled atomic.Int64
+
+	streamTo := make(chan *entity.Metrics, 100)
+	collector := NewStreamCollector(
+		streamTo,
+		100*time.Millisecond,
+		[]Strategy{counting(&fast, "fast"), counting(&slow, "slow"), counting(&disabled, "disabled")},
+		zap.NewNop().Sugar(),
+		WithStrategySettings(map[string]StrategySettings{
+			"fast":     {Interval: 20 * time.Millisecond},
+			"disabled": {Disabled: true},
+		}),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	go collector.StartStreaming(ctx)
+
+	time.Sleep(250 * time.Millisecond)
+	cancel()
+	for range streamTo {
+		// Drain the channel until the collector closes it.
+	}
+
+	if disabled.Load() != 0 {
+		t.Errorf("expected disabled strategy not to run, ran %d times", disabled.Load())
+	}
+	if slow.Load() < 1 || slow.Load() > 3 {
+		t.Errorf("expected default strategy to run 1-3 times, ran %d times", slow.Load())
+	}
+	if fast.Load() <= slow.Load() {
+		t.Errorf("expected overridden strategy to run more often than %d times, ran %d times", slow.Load(), fast.Load())
+	}
+}
+
+func TestNewStreamCollector_StrategyCache(t *testing.T) {
+	collector := NewStreamCollector(
+		nil,
+		time.Second,
+		[]Strategy{namedFuncStrategy{name: "cached"}, namedFuncStrategy{name: "plain"}},
+		zap.NewNop().Sugar(),
+		WithStrategyCache(map[string]time.Duration{"cached": time.Minute, "unknown": time.Minute}),
+	)
+
+	if _, ok := collector.runners[0].strategy.(*CachedStrategy); !ok {
+		t.Errorf("expected strategy %q to be cached, got %T", "cached", collector.runners[0].strategy)
+	}
+	if _, ok := collector.runners[1].strategy.(*CachedStrategy); ok {
+		t.Errorf("expected strategy %q not to be cached", "plain")
+	}
+	if name := collector.runners[0].name; name != "cached" {
+		t.Errorf("expected cached strategy to keep its name, got %q", name)
+	}
+}
+
+func TestStreamCollector_MetricRules(t *testing.T) {
+	rules, err := NewMetricRules(nil, []string{"test"}, nil)
+	if err != nil {
+		t.Fatalf("failed to build rules: %v", err)
+	}
+
+	streamTo := make(chan *entity.Metrics, 10)
+	collector := NewStreamCollector(
+		streamTo,
+		50*time.Millisecond,
+		[]Strategy{&validStrategy{}},
+		zap.NewNop().Sugar(),
+		WithMetricRules(rules),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	go collector.StartStreaming(ctx)
+
+	time.Sleep(75 * time.Millisecond)
+	cancel()
+
+	var count int
+	for range streamTo {
+		count++
+	}
+	if count != 0 {
+		t.Errorf("expected filtered batches not to be streamed, got %d", count)
+	}
+}
+
+// stubRecorder records the names of the strategies and the batches it is given.
+type stubRecorder struct {
+	strategies []string
+	batches    []entity.Metrics
+	mu         sync.Mutex
+}
+
+func (r *stubRecorder) Record(strategy string, metrics *entity.Metrics) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.strategies = append(r.strategies, strategy)
+	r.batches = append(r.batches, append(entity.Metrics(nil), *metrics...))
+	return nil
+}
+
+func TestStreamCollector_BatchRecorder(t *testing.T) {
+	labels, err := NewMetricLabels(map[string]string{"env": "prod"})
+	require.NoError(t, err)
+	recorder := &stubRecorder{}
+	streamTo := make(chan *entity.Metrics, 10)
+	collector := NewStreamCollector(
+		streamTo,
+		50*time.Millisecond,
+		[]Strategy{&validStrategy{}, &emptyStrategy{}},
+		zap.NewNop().Sugar(),
+		WithMetricLabels(labels),
+		WithBatchRecorder(recorder),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	go collector.StartStreaming(ctx)
+
+	time.Sleep(75 * time.Millisecond)
+	cancel()
+
+	var streamed []*entity.Metrics
+	for batch := range streamTo {
+		streamed = append(streamed, batch)
+	}
+	require.Len(t, streamed, 1)
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	require.Len(t, recorder.batches, 1, "empty batches are not recorded")
+	assert.NotEmpty(t, recorder.strategies[0])
+	assert.Equal(t, *streamed[0], recorder.batches[0], "the recording holds the labeled batch that was streamed")
+	assert.Equal(t, `test{env="prod"}`, recorder.batches[0][0].Name)
+}
+
+func TestStreamCollector_Healthy(t *testing.T) {
+	streamTo := make(chan *entity.Metrics, 1)
+	sc := NewStreamCollector(streamTo, 10*time.Millisecond, []Strategy{&validStrategy{}}, zap.NewNop().Sugar())
+	assert.ErrorIs(t, sc.Healthy(), lifecycle.ErrNotRunning)
+
+	done := make(chan struct{})
+	go func() {
+		sc.Start(context.Background())
+		close(done)
+	}()
+	require.Eventually(t, func() bool { return sc.lastBatch.Load() != 0 }, time.Second, time.Millisecond)
+	assert.NoError(t, sc.Healthy())
+
+	stale := time.Now().Add(-time.Minute).UnixNano()
+	sc.startedAt.Store(stale)
+	sc.lastBatch.Store(stale)
+	assert.ErrorContains(t, sc.Healthy(), "no metrics collected for 1m0s")
+
+	sc.Stop()
+	<-done
+	assert.ErrorIs(t, sc.Healthy(), lifecycle.ErrNotRunning)
+}
+
+func TestStreamCollector_OpenStreamRestart(t *testing.T) {
+	streamTo := make(chan *entity.Metrics, 10)
+	strategy := funcStrategy(func() (*entity.Metrics, error) {
+		return &entity.Metrics{{Name: "m", Type: entity.MetricTypeGauge, Value: 1.0}}, nil
+	})
+	sc := NewStreamCollector(streamTo, 10*time.Millisecond, []Strategy{strategy}, zap.NewNop().Sugar(), WithOpenStream())
+
+	for range 2 {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			sc.Start(ctx)
+			close(done)
+		}()
+		select {
+		case batch := <-streamTo:
+			assert.NotNil(t, batch)
+		case <-time.After(time.Second):
+			t.Fatal("expected a batch from the collector")
+		}
+		cancel()
+		<-done
+	}
+
+	// Drain the batches collected before the last stop; the stream must still be open.
+	for {
+		select {
+		case _, ok := <-streamTo:
+			if !ok {
+				t.Fatal("expected the stream to stay open")
+			}
+		default:
+			return
+		}
+	}
+}
+
+func TestStreamCollector_LongestInterval(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+
+	sc := NewStreamCollector(make(chan *entity.Metrics), time.Second, []Strategy{&emptyStrategy{}}, logger)
+	assert.Equal(t, time.Second, sc.longestInterval())
+
+	sc = NewStreamCollector(
+		make(chan *entity.Metrics),
+		time.Second,
+		[]Strategy{&emptyStrategy{}},
+		logger,
+		WithAdaptiveInterval(time.Second, 30*time.Second),
+	)
+	assert.Equal(t, 30*time.Second, sc.longestInterval())
+}
+
+func TestStreamCollector_SetInterval(t *testing.T) {
+	streamTo := make(chan *entity.Metrics, 10)
+	collector := NewStreamCollector(streamTo, time.Hour, []Strategy{&validStrategy{}}, zap.NewNop().Sugar())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		collector.StartStreaming(ctx)
+	}()
+
+	collector.SetInterval(0)
+	assert.Equal(t, time.Hour, collector.pollInterval(), "a non-positive interval is ignored")
+	collector.SetInterval(20 * time.Millisecond)
+	select {
+	case batch := <-streamTo:
+		assert.Equal(t, 1, batch.Length())
+	case <-time.After(time.Second):
+		t.Fatal("expected a batch collected at the new interval")
+	}
+	assert.Equal(t, 20*time.Millisecond, collector.longestInterval())
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,172 @@
+package collect
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/promtext"
+)
+
+const (
+	// VirtualHostLabel makes VirtualHosts tell the hosts apart by a host label, e.g. `HeapAlloc{host="vhost00001"}`.
+	VirtualHostLabel = "label"
+	// VirtualHostPrefix makes VirtualHosts tell the hosts apart by a name prefix, e.g. `vhost00001_HeapAlloc`.
+	VirtualHostPrefix = "prefix"
+	// virtualHostLabelName is the label naming the simulated host of a metric.
+	virtualHostLabelName = "host"
+	// virtualHostFormat formats the serial number of a simulated host into its name.
+	virtualHostFormat = "vhost%05d"
+	// minGaugeFactor and maxGaugeFactor bound the factor the gauges of a simulated host are scaled by,
+	// so the hosts report different but plausible values.
+	minGaugeFactor = 0.5
+	maxGaugeFactor = 1.5
+)
+
+// VirtualHosts simulates many hosts running the agent, for demos and for scale testing the server UI and
+// storage. Every collected metric is reported once per simulated host, with the gauges of each host scaled
+// by a factor of its own. Churn replaces hosts by new ones over time, as autoscaling groups do, so the
+// server keeps seeing new series.
+type VirtualHosts struct {
+	now     func() time.Time // now returns the current time; tests replace it.
+	random  *rand.Rand       // random picks the replaced hosts and the gauge factors.
+	mode    string           // mode is VirtualHostLabel or VirtualHostPrefix.
+	hosts   []string         // hosts are the names of the current hosts.
+	factors []float64        // factors are the gauge factors of the current hosts.
+	last    time.Time        // last is when the hosts were last churned.
+	churn   float64          // churn is the fraction of the hosts replaced per minute.
+	pending float64          // pending accumulates the fractional replacements not made yet.
+	serial  int              // serial is the serial number of the next new host.
+	mu      sync.Mutex       // mu protects the hosts, as batches of several strategies are expanded concurrently.
+}
+
+// NewVirtualHosts creates VirtualHosts.
+//
+// Parameters:
+//   - count: The number of simulated hosts; 0 disables the simulation.
+//   - churn: The fraction of the hosts replaced per minute, e.g. 0.1 replaces a tenth of them every minute.
+//   - mode: VirtualHostLabel or VirtualHostPrefix; empty selects VirtualHostLabel.
+//
+// Returns:
+//   - *VirtualHosts: The simulated hosts, or nil if count is 0; nil keeps all metrics unchanged.
+//   - error: An error if the count or the churn is negative or the mode is unknown.
+func NewVirtualHosts(count int, churn float64, mode string) (*VirtualHosts, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("virtual host count %d must not be negative", count)
+	}
+	if churn < 0 {
+		return nil, fmt.Errorf("virtual host churn %g must not be negative", churn)
+	}
+	if mode == "" {
+		mode = VirtualHostLabel
+	}
+	if mode != VirtualHostLabel && mode != VirtualHostPrefix {
+		return nil, fmt.Errorf("unknown virtual host mode %q, use %s or %s", mode, VirtualHostLabel, VirtualHostPrefix)
+	}
+	if count == 0 {
+		return nil, nil //nolint:nilnil // Without hosts nothing is simulated.
+	}
+
+	v := &VirtualHosts{
+		now:     time.Now,
+		random:  rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())), //nolint:gosec // Simulated data.
+		mode:    mode,
+		churn:   churn,
+		hosts:   make([]string, count),
+		factors: make([]float64, count),
+	}
+	for i := range count {
+		v.replace(i)
+	}
+	return v, nil
+}
+
+// Apply replaces the metrics with a copy for every simulated host. Nil hosts keep the metrics unchanged.
+// In label mode, metrics whose names cannot be parsed as series names are dropped, as their copies could
+// not be told apart.
+//
+// Parameters:
+//   - metrics: The collected metrics.
+func (v *VirtualHosts) Apply(metrics *entity.Metrics) {
+	if v == nil || metrics == nil {
+		return
+	}
+
+	v.mu.Lock()
+	v.churnHosts(v.now())
+	hosts, factors := v.hosts, v.factors
+	if v.churn > 0 {
+		hosts, factors = append([]string(nil), v.hosts...), append([]float64(nil), v.factors...)
+	}
+	v.mu.Unlock()
+
+	expanded := make(entity.Metrics, 0, len(hosts)*len(*metrics))
+	for _, m := range *metrics {
+		if m == nil {
+			continue
+		}
+		for i, host := range hosts {
+			name, ok := v.hostName(m.Name, host)
+			if !ok {
+				break
+			}
+			hosted := *m
+			hosted.Name = name
+			if value, isFloat := m.Value.(float64); isFloat && m.Type == entity.MetricTypeGauge {
+				hosted.Value = value * factors[i]
+			}
+			expanded = append(expanded, &hosted)
+		}
+	}
+	*metrics = expanded
+}
+
+// Hosts returns the names of the current hosts.
+//
+// Returns:
+//   - []string: The host names.
+func (v *VirtualHosts) Hosts() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return append([]string(nil), v.hosts...)
+}
+
+// hostName returns the name of the copy of a metric reported by a host.
+func (v *VirtualHosts) hostName(name, host string) (string, bool) {
+	if v.mode == VirtualHostPrefix {
+		return host + "_" + name, true
+	}
+	base, labels, err := promtext.SplitSeriesName(name)
+	if err != nil {
+		return "", false
+	}
+	labels[virtualHostLabelName] = host
+	return promtext.SeriesName(base, labels), true
+}
+
+// churnHosts replaces the share of the hosts that is due since the last churn. The caller holds mu.
+func (v *VirtualHosts) churnHosts(now time.Time) {
+	if v.churn == 0 {
+		return
+	}
+	if v.last.IsZero() {
+		v.last = now
+		return
+	}
+	v.pending += v.churn * float64(len(v.hosts)) * now.Sub(v.last).Minutes()
+	v.last = now
+	replaced := min(int(v.pending), len(v.hosts))
+	v.pending -= float64(int(v.pending))
+	for range replaced {
+		v.replace(v.random.IntN(len(v.hosts)))
+	}
+}
+
+// replace puts a new host in place of the host at index i. The caller holds mu.
+func (v *VirtualHosts) replace(i int) {
+	v.hosts[i] = fmt.Sprintf(virtualHostFormat, v.serial)
+	v.factors[i] = minGaugeFactor + v.random.Float64()*(maxGaugeFactor-minGaugeFactor)
+	v.serial++
+}
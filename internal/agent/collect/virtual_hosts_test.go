@@ -0,0 +1,138 @@
+package collect
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewVirtualHosts(t *testing.T) {
+	hosts, err := NewVirtualHosts(0, 0.5, "")
+	require.NoError(t, err)
+	assert.Nil(t, hosts)
+
+	for _, tt := range []struct {
+		name  string
+		mode  string
+		count int
+		churn float64
+	}{
+		{name: "Negative count", count: -1},
+		{name: "Negative churn", count: 1, churn: -0.1},
+		{name: "Unknown mode", count: 1, mode: "suffix"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewVirtualHosts(tt.count, tt.churn, tt.mode)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestVirtualHosts_Apply(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  string
+		want  []string
+		input entity.Metrics
+	}{
+		{
+			name: "Label",
+			mode: VirtualHostLabel,
+			input: entity.Metrics{
+				{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(1)},
+				nil,
+				{Name: `up{job="app"}`, Type: entity.MetricTypeGauge, Value: 1.0},
+				{Name: `broken{job=`, Type: entity.MetricTypeGauge, Value: 1.0},
+			},
+			want: []string{
+				`PollCount{host="vhost00000"}`, `PollCount{host="vhost00001"}`,
+				`up{host="vhost00000",job="app"}`, `up{host="vhost00001",job="app"}`,
+			},
+		},
+		{
+			name:  "Prefix",
+			mode:  VirtualHostPrefix,
+			input: entity.Metrics{{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(1)}},
+			want:  []string{"vhost00000_PollCount", "vhost00001_PollCount"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hosts, err := NewVirtualHosts(2, 0, tt.mode)
+			require.NoError(t, err)
+
+			metrics := tt.input
+			hosts.Apply(&metrics)
+			names := make([]string, 0, len(metrics))
+			for _, m := range metrics {
+				names = append(names, m.Name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+
+	var disabled *VirtualHosts
+	metrics := entity.Metrics{{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(1)}}
+	disabled.Apply(&metrics)
+	assert.Equal(t, "PollCount", metrics[0].Name)
+}
+
+func TestVirtualHosts_ScalesGauges(t *testing.T) {
+	hosts, err := NewVirtualHosts(20, 0, VirtualHostPrefix)
+	require.NoError(t, err)
+
+	metrics := entity.Metrics{
+		{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 100.0},
+		{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(3)},
+	}
+	hosts.Apply(&metrics)
+	require.Len(t, metrics, 40)
+
+	gauges := make(map[float64]bool)
+	for _, m := range metrics {
+		if m.Type == entity.MetricTypeCounter {
+			assert.Equal(t, int64(3), m.Value, "counters must not be scaled")
+			continue
+		}
+		value, ok := m.Value.(float64)
+		require.True(t, ok)
+		assert.GreaterOrEqual(t, value, 100*minGaugeFactor)
+		assert.Less(t, value, 100*maxGaugeFactor)
+		gauges[value] = true
+	}
+	assert.Greater(t, len(gauges), 1, "hosts must report different gauge values")
+}
+
+func TestVirtualHosts_Churn(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	hosts, err := NewVirtualHosts(10, 0.2, VirtualHostLabel)
+	require.NoError(t, err)
+	hosts.now = func() time.Time { return now }
+	hosts.random = rand.New(rand.NewPCG(1, 2)) //nolint:gosec // Deterministic test data.
+
+	apply := func() {
+		metrics := entity.Metrics{{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(1)}}
+		hosts.Apply(&metrics)
+		require.Len(t, metrics, 10, "churn must keep the number of hosts")
+	}
+	apply()
+	before := hosts.Hosts()
+
+	// A fifth of 10 hosts per minute is one host every 30 seconds.
+	now = now.Add(20 * time.Second)
+	apply()
+	assert.Equal(t, before, hosts.Hosts(), "less than a host is due")
+
+	now = now.Add(time.Minute)
+	apply()
+	after := hosts.Hosts()
+	assert.NotEqual(t, before, after)
+	assert.Equal(t, 12, hosts.serial, "two hosts are due after 80 seconds")
+	for _, host := range after {
+		assert.Regexp(t, `^vhost000(0\d|1[01])$`, host)
+	}
+}
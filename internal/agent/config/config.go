@@ -6,10 +6,14 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 
+	"github.com/gdyunin/metricol.git/pkg/configaudit"
+	"github.com/gdyunin/metricol.git/pkg/netaddr"
+
 	"github.com/caarlos0/env/v6"
 )
 
@@ -23,20 +27,155 @@ const (
 	defaultPprofFlag      = false
 	defaultCryptoKey      = ""
 	defaultConfigPath     = ""
+	defaultKeyFetch       = false
+	defaultKeyFingerprint = ""
+	defaultNextSigningKey = ""
+	defaultNextKeyPin     = ""
+	defaultAgentID        = ""
+	defaultAPIKey         = ""
+	defaultCrashDumpDir   = ""
+	defaultServerSRV      = ""
+	defaultServerRegistry = ""
+	defaultPodInfoDir     = ""
+	defaultTLSCert        = ""
+	defaultTLSKey         = ""
+	defaultTLSCA          = ""
+	defaultSRVRefresh     = 0
+	defaultMaxProcs       = 0
+	defaultNice           = 0
+	defaultMaxLoad        = 0
+	defaultMemoryBudget   = 0
+	defaultMaxBatchSize   = 0
+	defaultSendQueueSize  = 0
+	defaultMinPoll        = 0
+	defaultMaxPoll        = 0
+	defaultStratTimeout   = 0
+	defaultStrategies     = ""
+	defaultStrategyCache  = ""
+	defaultStatusAddress  = ""
+	defaultHeartbeat      = false
+	defaultClockSource    = ""
+	defaultShutdownReport = ""
+	defaultVirtualHosts   = 0
+	defaultVirtualChurn   = 0
+	defaultVirtualMode    = ""
+	defaultTraceEndpoint  = ""
+	defaultTraceRatio     = 1.0
+	defaultRecordFile     = ""
+	defaultReplayFile     = ""
+	defaultReplaySpeed    = 0
 )
 
 // Config holds the configuration settings for the application.
 // It contains the server address, signing key, intervals for polling and reporting metrics,
 // a rate limit for HTTP requests, and a flag for enabling or disabling pprof profiling.
 type Config struct {
-	ServerAddress  string `env:"ADDRESS"         json:"server_address,omitempty"`
-	SigningKey     string `env:"KEY"             json:"signing_key,omitempty"`
-	CryptoKey      string `env:"CRYPTO_KEY"      json:"crypto_key,omitempty"`
-	ConfigPath     string `env:"CONFIG"          json:"config_path,omitempty"`
-	PollInterval   int    `env:"POLL_INTERVAL"   json:"poll_interval,omitempty"`
-	ReportInterval int    `env:"REPORT_INTERVAL" json:"report_interval,omitempty"`
-	RateLimit      int    `env:"RATE_LIMIT"      json:"rate_limit,omitempty"`
-	PprofFlag      bool   `env:"PPROF_FLAG"      json:"pprof_flag,omitempty"`
+	ServerAddress   string   `env:"ADDRESS"                     json:"server_address,omitempty"`
+	SigningKey      string   `env:"KEY"                         json:"signing_key,omitempty"`
+	CryptoKey       string   `env:"CRYPTO_KEY"                  json:"crypto_key,omitempty"`
+	ConfigPath      string   `env:"CONFIG"                      json:"config_path,omitempty"`
+	KeyFingerprint  string   `env:"CRYPTO_KEY_FINGERPRINT"      json:"crypto_key_fingerprint,omitempty"`
+	NextSigningKey  string   `env:"NEXT_KEY"                    json:"next_signing_key,omitempty"`
+	NextKeyPin      string   `env:"NEXT_CRYPTO_KEY_FINGERPRINT" json:"next_crypto_key_fingerprint,omitempty"`
+	AgentID         string   `env:"AGENT_ID"                    json:"agent_id,omitempty"`
+	APIKey          string   `env:"API_KEY"                     json:"api_key,omitempty"`
+	CrashDumpDir    string   `env:"CRASH_DUMP_DIR"              json:"crash_dump_dir,omitempty"`
+	ServerSRV       string   `env:"SERVER_SRV"                  json:"server_srv,omitempty"`
+	ServerRegistry  string   `env:"SERVER_REGISTRY"             json:"server_registry,omitempty"`
+	PodInfoDir      string   `env:"PODINFO_DIR"                 json:"podinfo_dir,omitempty"`
+	TLSCert         string   `env:"CRYPTO_TLS_CERT"             json:"crypto_tls_cert,omitempty"`
+	TLSKey          string   `env:"CRYPTO_TLS_KEY"              json:"crypto_tls_key,omitempty"`
+	TLSCA           string   `env:"CRYPTO_TLS_CA"               json:"crypto_tls_ca,omitempty"`
+	Strategies      string   `env:"STRATEGIES"                  json:"strategies,omitempty"`
+	StrategyCache   string   `env:"STRATEGY_CACHE"              json:"strategy_cache,omitempty"`
+	StatusAddress   string   `env:"STATUS_ADDRESS"              json:"status_address,omitempty"`
+	ClockSource     string   `env:"CLOCK_DRIFT_SOURCE"          json:"clock_drift_source,omitempty"`
+	ShutdownReport  string   `env:"SHUTDOWN_REPORT_FILE"        json:"shutdown_report_file,omitempty"`
+	VirtualMode     string   `env:"VIRTUAL_HOST_MODE"           json:"virtual_host_mode,omitempty"`
+	TraceEndpoint   string   `env:"OTLP_ENDPOINT"               json:"otlp_endpoint,omitempty"`
+	RecordFile      string   `env:"RECORD_FILE"                 json:"record_file,omitempty"`
+	ReplayFile      string   `env:"REPLAY_FILE"                 json:"replay_file,omitempty"`
+	MetricRename    []string `env:"METRIC_RENAME"               json:"metric_rename,omitempty"`
+	MetricLabels    []string `env:"METRIC_LABELS"               json:"metric_labels,omitempty"`
+	MetricInclude   []string `env:"METRIC_INCLUDE"              json:"metric_include,omitempty"`
+	Collect         []string `env:"COLLECT_STRATEGIES"          json:"collect_strategies,omitempty"`
+	MetricExclude   []string `env:"METRIC_EXCLUDE"              json:"metric_exclude,omitempty"`
+	ScrapeTargets   []string `env:"SCRAPE_TARGETS"              json:"scrape_targets,omitempty"`
+	ScrapeSelect    []string `env:"SCRAPE_SELECT"               json:"scrape_select,omitempty"`
+	Processes       []string `env:"PROCESSES"                   json:"processes,omitempty"`
+	PollInterval    int      `env:"POLL_INTERVAL"               json:"poll_interval,omitempty"`
+	ReportInterval  int      `env:"REPORT_INTERVAL"             json:"report_interval,omitempty"`
+	RateLimit       int      `env:"RATE_LIMIT"                  json:"rate_limit,omitempty"`
+	MaxProcs        int      `env:"MAX_PROCS"                   json:"max_procs,omitempty"`
+	Nice            int      `env:"NICE"                        json:"nice,omitempty"`
+	MinPollInterval int      `env:"MIN_POLL_INTERVAL"           json:"min_poll_interval,omitempty"`
+	MaxPollInterval int      `env:"MAX_POLL_INTERVAL"           json:"max_poll_interval,omitempty"`
+	StrategyTimeout int      `env:"STRATEGY_TIMEOUT"            json:"strategy_timeout,omitempty"`
+	SRVRefresh      int      `env:"SRV_REFRESH"                 json:"srv_refresh,omitempty"`
+	MemoryBudget    int      `env:"MEMORY_BUDGET_MB"            json:"memory_budget_mb,omitempty"`
+	MaxBatchSize    int      `env:"MAX_BATCH_SIZE"              json:"max_batch_size,omitempty"`
+	SendQueueSize   int      `env:"SEND_QUEUE_SIZE"             json:"send_queue_size,omitempty"`
+	VirtualHosts    int      `env:"VIRTUAL_HOSTS"               json:"virtual_hosts,omitempty"`
+	MaxLoad         float64  `env:"MAX_LOAD"                    json:"max_load,omitempty"`
+	VirtualChurn    float64  `env:"VIRTUAL_HOST_CHURN"          json:"virtual_host_churn,omitempty"`
+	TraceRatio      float64  `env:"TRACE_SAMPLE_RATIO"          json:"trace_sample_ratio,omitempty"`
+	ReplaySpeed     float64  `env:"REPLAY_SPEED"                json:"replay_speed,omitempty"`
+	PprofFlag       bool     `env:"PPROF_FLAG"                  json:"pprof_flag,omitempty"`
+	KeyFetch        bool     `env:"CRYPTO_KEY_FETCH"            json:"crypto_key_fetch,omitempty"`
+	Heartbeat       bool     `env:"HEARTBEAT"                   json:"heartbeat,omitempty"`
+	ReplayDirect    bool     `env:"REPLAY_DIRECT"               json:"replay_direct,omitempty"`
+	ReplayRetime    bool     `env:"REPLAY_RETIME"               json:"replay_retime,omitempty"`
+}
+
+// defaultConfig returns the configuration used for the settings that are not given.
+func defaultConfig() Config {
+	return Config{
+		ServerAddress:   defaultServerAddress,
+		PollInterval:    defaultPollInterval,
+		ReportInterval:  defaultReportInterval,
+		SigningKey:      defaultSigningKey,
+		RateLimit:       defaultRateLimit,
+		PprofFlag:       defaultPprofFlag,
+		CryptoKey:       defaultCryptoKey,
+		ConfigPath:      defaultConfigPath,
+		KeyFetch:        defaultKeyFetch,
+		KeyFingerprint:  defaultKeyFingerprint,
+		NextSigningKey:  defaultNextSigningKey,
+		NextKeyPin:      defaultNextKeyPin,
+		AgentID:         defaultAgentID,
+		APIKey:          defaultAPIKey,
+		CrashDumpDir:    defaultCrashDumpDir,
+		ServerSRV:       defaultServerSRV,
+		ServerRegistry:  defaultServerRegistry,
+		PodInfoDir:      defaultPodInfoDir,
+		TLSCert:         defaultTLSCert,
+		TLSKey:          defaultTLSKey,
+		TLSCA:           defaultTLSCA,
+		SRVRefresh:      defaultSRVRefresh,
+		MaxProcs:        defaultMaxProcs,
+		Nice:            defaultNice,
+		MaxLoad:         defaultMaxLoad,
+		MemoryBudget:    defaultMemoryBudget,
+		MaxBatchSize:    defaultMaxBatchSize,
+		SendQueueSize:   defaultSendQueueSize,
+		MinPollInterval: defaultMinPoll,
+		MaxPollInterval: defaultMaxPoll,
+		StrategyTimeout: defaultStratTimeout,
+		Strategies:      defaultStrategies,
+		StrategyCache:   defaultStrategyCache,
+		StatusAddress:   defaultStatusAddress,
+		Heartbeat:       defaultHeartbeat,
+		ClockSource:     defaultClockSource,
+		ShutdownReport:  defaultShutdownReport,
+		VirtualHosts:    defaultVirtualHosts,
+		VirtualChurn:    defaultVirtualChurn,
+		VirtualMode:     defaultVirtualMode,
+		TraceEndpoint:   defaultTraceEndpoint,
+		TraceRatio:      defaultTraceRatio,
+		RecordFile:      defaultRecordFile,
+		ReplayFile:      defaultReplayFile,
+		ReplaySpeed:     defaultReplaySpeed,
+	}
 }
 
 // ParseConfig initializes a new Config instance with default values, then overrides these values
@@ -48,17 +187,7 @@ type Config struct {
 //   - *Config: A pointer to the populated Config structure.
 //   - error: An error if environment variable parsing fails; otherwise, nil.
 func ParseConfig() (*Config, error) {
-	// Default settings for the service configuration.
-	cfg := Config{
-		ServerAddress:  defaultServerAddress,
-		PollInterval:   defaultPollInterval,
-		ReportInterval: defaultReportInterval,
-		SigningKey:     defaultSigningKey,
-		RateLimit:      defaultRateLimit,
-		PprofFlag:      defaultPprofFlag,
-		CryptoKey:      defaultCryptoKey,
-		ConfigPath:     defaultConfigPath,
-	}
+	cfg := defaultConfig()
 
 	// Parse command-line arguments or set default settings if no arguments are provided.
 	parseFlagsOrSetDefault(&cfg)
@@ -74,9 +203,130 @@ func ParseConfig() (*Config, error) {
 		}
 	}
 
+	if err := netaddr.ValidateServer(cfg.ServerAddress); err != nil {
+		return nil, fmt.Errorf("invalid server address: %w", err)
+	}
+	if cfg.StatusAddress != defaultStatusAddress {
+		if err := netaddr.ValidateListen(cfg.StatusAddress); err != nil {
+			return nil, fmt.Errorf("invalid status address: %w", err)
+		}
+	}
+	if (cfg.TLSCert == defaultTLSCert) != (cfg.TLSKey == defaultTLSKey) {
+		return nil, errors.New("the TLS client certificate and key must be set together")
+	}
+	if cfg.ServerSRV != defaultServerSRV && cfg.ServerRegistry != defaultServerRegistry {
+		return nil, errors.New("only one of server SRV name and server registry can be set")
+	}
+	if cfg.MemoryBudget < 0 || cfg.MaxBatchSize < 0 || cfg.SendQueueSize < 0 {
+		return nil, errors.New("the memory budget, batch size and send queue size must not be negative")
+	}
+	if cfg.TraceRatio < 0 || cfg.TraceRatio > 1 {
+		return nil, fmt.Errorf("invalid trace sample ratio: %v is not between 0 and 1", cfg.TraceRatio)
+	}
+	if cfg.RecordFile != defaultRecordFile && cfg.ReplayFile != defaultReplayFile {
+		return nil, errors.New("a replaying agent collects nothing, so recording and replaying cannot be combined")
+	}
+	if cfg.ReplaySpeed < 0 {
+		return nil, fmt.Errorf("invalid replay speed: %v is negative", cfg.ReplaySpeed)
+	}
+
 	return &cfg, nil
 }
 
+// Audit describes every setting with its effective value and where it came from, with secrets redacted.
+// It must be called on the configuration returned by ParseConfig, which the command-line flags are bound to.
+//
+// Returns:
+//   - []configaudit.Entry: The settings in declaration order.
+func (c *Config) Audit() []configaudit.Entry {
+	return configaudit.Audit(c, defaultConfig(), flag.CommandLine, os.LookupEnv)
+}
+
+// MetricFilters holds the settings of the metric filtering and renaming rules, which can be reloaded.
+type MetricFilters struct {
+	Include []string // Include lists the patterns of metrics to keep.
+	Exclude []string // Exclude lists the patterns of metrics to drop.
+	Rename  []string // Rename lists the "old=new" rename pairs.
+}
+
+// ReloadMetricFilters reads the metric filtering and renaming settings again from the environment and
+// the configuration file, so they can be changed without restarting the agent. As at startup, the
+// environment takes precedence over the file.
+//
+// Returns:
+//   - MetricFilters: The settings read.
+//   - error: An error if the environment or the configuration file cannot be parsed.
+func (c *Config) ReloadMetricFilters() (MetricFilters, error) {
+	fresh := Config{ConfigPath: c.ConfigPath}
+	if err := env.Parse(&fresh); err != nil {
+		return MetricFilters{}, fmt.Errorf("failed to parse environment variables: %w", err)
+	}
+	if fresh.ConfigPath != defaultConfigPath {
+		if err := mergeConfigFile(&fresh); err != nil {
+			return MetricFilters{}, fmt.Errorf("failed to merge configuration file: %w", err)
+		}
+	}
+	return MetricFilters{Include: fresh.MetricInclude, Exclude: fresh.MetricExclude, Rename: fresh.MetricRename}, nil
+}
+
+// Tuning holds the settings the running agent applies without restarting.
+type Tuning struct {
+	ServerAddress  string // ServerAddress is the address of the server metrics are sent to.
+	SigningKey     string // SigningKey signs the requests.
+	PollInterval   int    // PollInterval is the collection interval in seconds.
+	ReportInterval int    // ReportInterval is the sending interval in seconds.
+	RateLimit      int    // RateLimit is the number of batches sent per report interval.
+}
+
+// ReloadTuning reads the poll and report intervals, the rate limit, the server address and the signing key
+// again from the environment and the configuration file, so they can be changed without restarting the agent.
+// As at startup, the environment takes precedence over the file, and so do the command-line flags, which
+// keep their values.
+//
+// Returns:
+//   - Tuning: The settings read.
+//   - error: An error if the environment or the configuration file cannot be parsed or a setting is invalid.
+func (c *Config) ReloadTuning() (Tuning, error) {
+	fresh := defaultConfig()
+	fresh.ConfigPath = c.ConfigPath
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "a":
+			fresh.ServerAddress = c.ServerAddress
+		case "k":
+			fresh.SigningKey = c.SigningKey
+		case "p":
+			fresh.PollInterval = c.PollInterval
+		case "r":
+			fresh.ReportInterval = c.ReportInterval
+		case "l":
+			fresh.RateLimit = c.RateLimit
+		}
+	})
+	if err := env.Parse(&fresh); err != nil {
+		return Tuning{}, fmt.Errorf("failed to parse environment variables: %w", err)
+	}
+	if fresh.ConfigPath != defaultConfigPath {
+		if err := mergeConfigFile(&fresh); err != nil {
+			return Tuning{}, fmt.Errorf("failed to merge configuration file: %w", err)
+		}
+	}
+
+	if err := netaddr.ValidateServer(fresh.ServerAddress); err != nil {
+		return Tuning{}, fmt.Errorf("invalid server address: %w", err)
+	}
+	if fresh.PollInterval <= 0 || fresh.ReportInterval <= 0 || fresh.RateLimit <= 0 {
+		return Tuning{}, errors.New("the poll interval, report interval and rate limit must be positive")
+	}
+	return Tuning{
+		ServerAddress:  fresh.ServerAddress,
+		SigningKey:     fresh.SigningKey,
+		PollInterval:   fresh.PollInterval,
+		ReportInterval: fresh.ReportInterval,
+		RateLimit:      fresh.RateLimit,
+	}, nil
+}
+
 func mergeConfigFile(cfg *Config) error {
 	data, err := os.ReadFile(cfg.ConfigPath)
 	if err != nil {
@@ -112,6 +362,147 @@ func mergeConfigFile(cfg *Config) error {
 	if !cfg.PprofFlag && tempCfg.PprofFlag {
 		cfg.PprofFlag = tempCfg.PprofFlag
 	}
+	if !cfg.KeyFetch && tempCfg.KeyFetch {
+		cfg.KeyFetch = tempCfg.KeyFetch
+	}
+	if cfg.KeyFingerprint == defaultKeyFingerprint && tempCfg.KeyFingerprint != defaultKeyFingerprint {
+		cfg.KeyFingerprint = tempCfg.KeyFingerprint
+	}
+	if cfg.NextSigningKey == defaultNextSigningKey && tempCfg.NextSigningKey != defaultNextSigningKey {
+		cfg.NextSigningKey = tempCfg.NextSigningKey
+	}
+	if cfg.NextKeyPin == defaultNextKeyPin && tempCfg.NextKeyPin != defaultNextKeyPin {
+		cfg.NextKeyPin = tempCfg.NextKeyPin
+	}
+	if cfg.AgentID == defaultAgentID && tempCfg.AgentID != defaultAgentID {
+		cfg.AgentID = tempCfg.AgentID
+	}
+	if cfg.APIKey == defaultAPIKey && tempCfg.APIKey != defaultAPIKey {
+		cfg.APIKey = tempCfg.APIKey
+	}
+	if cfg.CrashDumpDir == defaultCrashDumpDir && tempCfg.CrashDumpDir != defaultCrashDumpDir {
+		cfg.CrashDumpDir = tempCfg.CrashDumpDir
+	}
+	if cfg.ServerSRV == defaultServerSRV && tempCfg.ServerSRV != defaultServerSRV {
+		cfg.ServerSRV = tempCfg.ServerSRV
+	}
+	if cfg.ServerRegistry == defaultServerRegistry && tempCfg.ServerRegistry != defaultServerRegistry {
+		cfg.ServerRegistry = tempCfg.ServerRegistry
+	}
+	if cfg.TLSCert == defaultTLSCert && tempCfg.TLSCert != defaultTLSCert {
+		cfg.TLSCert = tempCfg.TLSCert
+	}
+	if cfg.TLSKey == defaultTLSKey && tempCfg.TLSKey != defaultTLSKey {
+		cfg.TLSKey = tempCfg.TLSKey
+	}
+	if cfg.TLSCA == defaultTLSCA && tempCfg.TLSCA != defaultTLSCA {
+		cfg.TLSCA = tempCfg.TLSCA
+	}
+	if cfg.PodInfoDir == defaultPodInfoDir && tempCfg.PodInfoDir != defaultPodInfoDir {
+		cfg.PodInfoDir = tempCfg.PodInfoDir
+	}
+	if cfg.SRVRefresh == defaultSRVRefresh && tempCfg.SRVRefresh != defaultSRVRefresh {
+		cfg.SRVRefresh = tempCfg.SRVRefresh
+	}
+	if cfg.MaxProcs == defaultMaxProcs && tempCfg.MaxProcs != defaultMaxProcs {
+		cfg.MaxProcs = tempCfg.MaxProcs
+	}
+	if cfg.Nice == defaultNice && tempCfg.Nice != defaultNice {
+		cfg.Nice = tempCfg.Nice
+	}
+	if cfg.MaxLoad == defaultMaxLoad && tempCfg.MaxLoad != defaultMaxLoad {
+		cfg.MaxLoad = tempCfg.MaxLoad
+	}
+	if cfg.MemoryBudget == defaultMemoryBudget && tempCfg.MemoryBudget != defaultMemoryBudget {
+		cfg.MemoryBudget = tempCfg.MemoryBudget
+	}
+	if cfg.MaxBatchSize == defaultMaxBatchSize && tempCfg.MaxBatchSize != defaultMaxBatchSize {
+		cfg.MaxBatchSize = tempCfg.MaxBatchSize
+	}
+	if cfg.SendQueueSize == defaultSendQueueSize && tempCfg.SendQueueSize != defaultSendQueueSize {
+		cfg.SendQueueSize = tempCfg.SendQueueSize
+	}
+	if cfg.MinPollInterval == defaultMinPoll && tempCfg.MinPollInterval != defaultMinPoll {
+		cfg.MinPollInterval = tempCfg.MinPollInterval
+	}
+	if cfg.MaxPollInterval == defaultMaxPoll && tempCfg.MaxPollInterval != defaultMaxPoll {
+		cfg.MaxPollInterval = tempCfg.MaxPollInterval
+	}
+	if cfg.StrategyTimeout == defaultStratTimeout && tempCfg.StrategyTimeout != defaultStratTimeout {
+		cfg.StrategyTimeout = tempCfg.StrategyTimeout
+	}
+	if cfg.Strategies == defaultStrategies && tempCfg.Strategies != defaultStrategies {
+		cfg.Strategies = tempCfg.Strategies
+	}
+	if cfg.StrategyCache == defaultStrategyCache && tempCfg.StrategyCache != defaultStrategyCache {
+		cfg.StrategyCache = tempCfg.StrategyCache
+	}
+	if cfg.StatusAddress == defaultStatusAddress && tempCfg.StatusAddress != defaultStatusAddress {
+		cfg.StatusAddress = tempCfg.StatusAddress
+	}
+	if cfg.ShutdownReport == defaultShutdownReport && tempCfg.ShutdownReport != defaultShutdownReport {
+		cfg.ShutdownReport = tempCfg.ShutdownReport
+	}
+	if cfg.VirtualHosts == defaultVirtualHosts && tempCfg.VirtualHosts != defaultVirtualHosts {
+		cfg.VirtualHosts = tempCfg.VirtualHosts
+	}
+	if cfg.VirtualChurn == defaultVirtualChurn && tempCfg.VirtualChurn != defaultVirtualChurn {
+		cfg.VirtualChurn = tempCfg.VirtualChurn
+	}
+	if cfg.VirtualMode == defaultVirtualMode && tempCfg.VirtualMode != defaultVirtualMode {
+		cfg.VirtualMode = tempCfg.VirtualMode
+	}
+	if cfg.TraceEndpoint == defaultTraceEndpoint && tempCfg.TraceEndpoint != defaultTraceEndpoint {
+		cfg.TraceEndpoint = tempCfg.TraceEndpoint
+	}
+	if cfg.TraceRatio == defaultTraceRatio && tempCfg.TraceRatio != 0 {
+		cfg.TraceRatio = tempCfg.TraceRatio
+	}
+	if cfg.RecordFile == defaultRecordFile && tempCfg.RecordFile != defaultRecordFile {
+		cfg.RecordFile = tempCfg.RecordFile
+	}
+	if cfg.ReplayFile == defaultReplayFile && tempCfg.ReplayFile != defaultReplayFile {
+		cfg.ReplayFile = tempCfg.ReplayFile
+	}
+	if cfg.ReplaySpeed == defaultReplaySpeed && tempCfg.ReplaySpeed != defaultReplaySpeed {
+		cfg.ReplaySpeed = tempCfg.ReplaySpeed
+	}
+	if !cfg.ReplayDirect && tempCfg.ReplayDirect {
+		cfg.ReplayDirect = tempCfg.ReplayDirect
+	}
+	if !cfg.ReplayRetime && tempCfg.ReplayRetime {
+		cfg.ReplayRetime = tempCfg.ReplayRetime
+	}
+	if !cfg.Heartbeat && tempCfg.Heartbeat {
+		cfg.Heartbeat = tempCfg.Heartbeat
+	}
+	if len(cfg.Collect) == 0 {
+		cfg.Collect = tempCfg.Collect
+	}
+	if len(cfg.MetricInclude) == 0 {
+		cfg.MetricInclude = tempCfg.MetricInclude
+	}
+	if len(cfg.MetricExclude) == 0 {
+		cfg.MetricExclude = tempCfg.MetricExclude
+	}
+	if len(cfg.MetricRename) == 0 {
+		cfg.MetricRename = tempCfg.MetricRename
+	}
+	if len(cfg.MetricLabels) == 0 {
+		cfg.MetricLabels = tempCfg.MetricLabels
+	}
+	if cfg.ClockSource == defaultClockSource && tempCfg.ClockSource != defaultClockSource {
+		cfg.ClockSource = tempCfg.ClockSource
+	}
+	if len(cfg.ScrapeTargets) == 0 {
+		cfg.ScrapeTargets = tempCfg.ScrapeTargets
+	}
+	if len(cfg.ScrapeSelect) == 0 {
+		cfg.ScrapeSelect = tempCfg.ScrapeSelect
+	}
+	if len(cfg.Processes) == 0 {
+		cfg.Processes = tempCfg.Processes
+	}
 
 	return nil
 }
@@ -125,11 +516,205 @@ func mergeConfigFile(cfg *Config) error {
 func parseFlagsOrSetDefault(cfg *Config) {
 	flag.IntVar(&cfg.PollInterval, "p", cfg.PollInterval, "Interval (in seconds) for collecting metrics.")
 	flag.IntVar(&cfg.ReportInterval, "r", cfg.ReportInterval, "Interval (in seconds) for sending metrics.")
-	flag.StringVar(&cfg.ServerAddress, "a", cfg.ServerAddress, "Address of the server to connect to.")
+	flag.StringVar(
+		&cfg.ServerAddress,
+		"a",
+		cfg.ServerAddress,
+		"Address of the server to connect to, e.g. \"localhost:8080\" or \"[::1]:8080\".",
+	)
 	flag.StringVar(&cfg.SigningKey, "k", cfg.SigningKey, "Signing key used for creating request signatures.")
 	flag.IntVar(&cfg.RateLimit, "l", cfg.RateLimit, "Maximum rate for sending HTTP requests per interval.")
 	flag.BoolVar(&cfg.PprofFlag, "pf", cfg.PprofFlag, "Enable or disable profiling with pprof.")
 	flag.StringVar(&cfg.CryptoKey, "crypto-key", cfg.CryptoKey, "Path to public key file.")
 	flag.StringVar(&cfg.ConfigPath, "c", cfg.ConfigPath, "Path to config file.")
+	flag.BoolVar(&cfg.KeyFetch, "crypto-key-fetch", cfg.KeyFetch, "Fetch the public key from the server at startup.")
+	flag.StringVar(
+		&cfg.KeyFingerprint,
+		"crypto-key-fingerprint",
+		cfg.KeyFingerprint,
+		"SHA-256 fingerprint the public key must match.",
+	)
+	flag.StringVar(
+		&cfg.NextSigningKey,
+		"next-key",
+		cfg.NextSigningKey,
+		"Signing key to switch to once the server advertises it.",
+	)
+	flag.StringVar(
+		&cfg.NextKeyPin,
+		"next-crypto-key-fingerprint",
+		cfg.NextKeyPin,
+		"SHA-256 fingerprint of the public key to switch to once the server advertises it.",
+	)
+	flag.StringVar(&cfg.AgentID, "agent-id", cfg.AgentID, "Agent identifier sent to the server; defaults to the hostname.")
+	flag.StringVar(&cfg.APIKey, "api-key", cfg.APIKey, "API key sent in the X-API-Key header to select the server quotas.")
+	flag.StringVar(&cfg.CrashDumpDir, "crash-dump-dir", cfg.CrashDumpDir, "Directory for crash dumps of recovered panics.")
+	flag.StringVar(
+		&cfg.ServerSRV,
+		"server-srv",
+		cfg.ServerSRV,
+		"DNS SRV name the server targets are resolved from, e.g. \"_metricol._tcp.example.com\"; overrides -a.",
+	)
+	flag.StringVar(
+		&cfg.ServerRegistry,
+		"server-registry",
+		cfg.ServerRegistry,
+		"Consul or etcd URL the server instances are looked up in, e.g. \"consul://localhost:8500/metricol\"; overrides -a.",
+	)
+	flag.StringVar(&cfg.TLSCert, "crypto-tls-cert", cfg.TLSCert, "Path to the PEM client certificate for mutual TLS.")
+	flag.StringVar(&cfg.TLSKey, "crypto-tls-key", cfg.TLSKey, "Path to the PEM private key of -crypto-tls-cert.")
+	flag.StringVar(
+		&cfg.TLSCA,
+		"crypto-tls-ca",
+		cfg.TLSCA,
+		"Path to the PEM bundle of CAs the server certificate is verified against; empty uses the system pool.",
+	)
+	flag.StringVar(
+		&cfg.PodInfoDir,
+		"podinfo-dir",
+		cfg.PodInfoDir,
+		"Mount path of a Kubernetes downward API volume whose \"labels\" file labels all metrics.",
+	)
+	flag.IntVar(
+		&cfg.SRVRefresh,
+		"srv-refresh",
+		cfg.SRVRefresh,
+		"Seconds between lookups of -server-srv or -server-registry; 0 uses 30.",
+	)
+	flag.IntVar(&cfg.MaxProcs, "max-procs", cfg.MaxProcs, "Max CPUs used by the agent; 0 uses all of them.")
+	flag.IntVar(&cfg.Nice, "nice", cfg.Nice, "Scheduling priority of the agent from -20 to 19; 0 keeps the current one.")
+	flag.Float64Var(
+		&cfg.MaxLoad,
+		"max-load",
+		cfg.MaxLoad,
+		"1-minute host load average above which collection cycles are skipped; 0 never skips.",
+	)
+	flag.IntVar(
+		&cfg.MemoryBudget,
+		"memory-budget",
+		cfg.MemoryBudget,
+		"Memory in MB the agent should fit in, deriving the batch and queue sizes; 0 sets no budget.",
+	)
+	flag.IntVar(
+		&cfg.MaxBatchSize,
+		"max-batch-size",
+		cfg.MaxBatchSize,
+		"Max metrics sent in one request; 0 derives it from -memory-budget or sends batches whole.",
+	)
+	flag.IntVar(
+		&cfg.SendQueueSize,
+		"send-queue-size",
+		cfg.SendQueueSize,
+		"Collected batches that may wait to be sent; 0 derives it from -memory-budget or the rate limit.",
+	)
+	flag.IntVar(
+		&cfg.MinPollInterval,
+		"min-poll-interval",
+		cfg.MinPollInterval,
+		"Shortest interval (in seconds) the adaptive polling may use; 0 means 1 second.",
+	)
+	flag.IntVar(
+		&cfg.MaxPollInterval,
+		"max-poll-interval",
+		cfg.MaxPollInterval,
+		"Longest interval (in seconds) the adaptive polling may use; 0 disables adaptive polling.",
+	)
+	flag.IntVar(
+		&cfg.StrategyTimeout,
+		"strategy-timeout",
+		cfg.StrategyTimeout,
+		"Max time (in seconds) a single collection strategy may run; 0 uses the poll interval.",
+	)
+	flag.StringVar(
+		&cfg.Strategies,
+		"strategies",
+		cfg.Strategies,
+		"Per-strategy poll intervals in seconds or \"off\", e.g. \"memstats=5,gopsutil=off\".",
+	)
+	flag.StringVar(
+		&cfg.StrategyCache,
+		"strategy-cache",
+		cfg.StrategyCache,
+		"Per-strategy result cache lifetimes in seconds, e.g. \"gopsutil=30\".",
+	)
+	flag.StringVar(
+		&cfg.StatusAddress,
+		"status-address",
+		cfg.StatusAddress,
+		"Address of the local endpoint serving the agent health at /status; empty disables it.",
+	)
+	flag.StringVar(
+		&cfg.ClockSource,
+		"clock-drift-source",
+		cfg.ClockSource,
+		"Server clock the local clock drift is measured against: \"time\" or \"date\"; empty disables it.",
+	)
+	flag.StringVar(
+		&cfg.ShutdownReport,
+		"shutdown-report",
+		cfg.ShutdownReport,
+		"Path of the JSON file the shutdown report is written to.",
+	)
+	flag.IntVar(
+		&cfg.VirtualHosts,
+		"virtual-hosts",
+		cfg.VirtualHosts,
+		"Number of simulated hosts every metric is reported for, for demos and scale tests; 0 simulates none.",
+	)
+	flag.Float64Var(
+		&cfg.VirtualChurn,
+		"virtual-host-churn",
+		cfg.VirtualChurn,
+		"Fraction of the simulated hosts replaced by new ones every minute, e.g. 0.1.",
+	)
+	flag.StringVar(
+		&cfg.VirtualMode,
+		"virtual-host-mode",
+		cfg.VirtualMode,
+		"How simulated hosts are told apart: \"label\" adds a host label, \"prefix\" prefixes the names.",
+	)
+	flag.StringVar(
+		&cfg.TraceEndpoint,
+		"otlp-endpoint",
+		cfg.TraceEndpoint,
+		"OTLP/HTTP collector URL traces are exported to, e.g. http://localhost:4318; empty disables exporting.",
+	)
+	flag.Float64Var(
+		&cfg.TraceRatio,
+		"trace-sample-ratio",
+		cfg.TraceRatio,
+		"Fraction of the sent batches traced, from 0 to 1.",
+	)
+	flag.StringVar(
+		&cfg.RecordFile,
+		"record",
+		cfg.RecordFile,
+		"Path of a file every collected batch is appended to, for replaying it later with -replay.",
+	)
+	flag.StringVar(
+		&cfg.ReplayFile,
+		"replay",
+		cfg.ReplayFile,
+		"Path of a recording to send to the server instead of collecting metrics; the agent exits when done.",
+	)
+	flag.Float64Var(
+		&cfg.ReplaySpeed,
+		"replay-speed",
+		cfg.ReplaySpeed,
+		"Pace of the replay relative to the recording, e.g. 1 for the original pace; 0 replays without waiting.",
+	)
+	flag.BoolVar(
+		&cfg.ReplayDirect,
+		"replay-direct",
+		cfg.ReplayDirect,
+		"Replay as plain JSON, without the compression, signing and encryption of the sender.",
+	)
+	flag.BoolVar(
+		&cfg.ReplayRetime,
+		"replay-retime",
+		cfg.ReplayRetime,
+		"Shift the replayed timestamps so the first batch appears collected now.",
+	)
+	flag.BoolVar(&cfg.Heartbeat, "heartbeat", cfg.Heartbeat, "Send the agent health to the server every report interval.")
 	flag.Parse()
 }
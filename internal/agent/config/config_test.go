@@ -3,8 +3,11 @@ package config
 import (
 	"flag"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/gdyunin/metricol.git/pkg/configaudit"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -22,36 +25,163 @@ func TestParseConfig(t *testing.T) {
 			envVars: map[string]string{},
 			args:    []string{},
 			expected: Config{
-				ServerAddress:  defaultServerAddress,
-				PollInterval:   defaultPollInterval,
-				ReportInterval: defaultReportInterval,
-				SigningKey:     defaultSigningKey,
-				RateLimit:      defaultRateLimit,
-				PprofFlag:      defaultPprofFlag,
-				CryptoKey:      defaultCryptoKey,
+				ServerAddress:   defaultServerAddress,
+				PollInterval:    defaultPollInterval,
+				ReportInterval:  defaultReportInterval,
+				SigningKey:      defaultSigningKey,
+				RateLimit:       defaultRateLimit,
+				PprofFlag:       defaultPprofFlag,
+				CryptoKey:       defaultCryptoKey,
+				KeyFetch:        defaultKeyFetch,
+				KeyFingerprint:  defaultKeyFingerprint,
+				NextSigningKey:  defaultNextSigningKey,
+				NextKeyPin:      defaultNextKeyPin,
+				AgentID:         defaultAgentID,
+				APIKey:          defaultAPIKey,
+				CrashDumpDir:    defaultCrashDumpDir,
+				ServerSRV:       defaultServerSRV,
+				ServerRegistry:  defaultServerRegistry,
+				PodInfoDir:      defaultPodInfoDir,
+				TLSCert:         defaultTLSCert,
+				TLSKey:          defaultTLSKey,
+				TLSCA:           defaultTLSCA,
+				SRVRefresh:      defaultSRVRefresh,
+				MaxProcs:        defaultMaxProcs,
+				Nice:            defaultNice,
+				MaxLoad:         defaultMaxLoad,
+				MemoryBudget:    defaultMemoryBudget,
+				MaxBatchSize:    defaultMaxBatchSize,
+				SendQueueSize:   defaultSendQueueSize,
+				MinPollInterval: defaultMinPoll,
+				MaxPollInterval: defaultMaxPoll,
+				StrategyTimeout: defaultStratTimeout,
+				Strategies:      defaultStrategies,
+				StrategyCache:   defaultStrategyCache,
+				StatusAddress:   defaultStatusAddress,
+				Heartbeat:       defaultHeartbeat,
+				ClockSource:     defaultClockSource,
+				ShutdownReport:  defaultShutdownReport,
+				VirtualHosts:    defaultVirtualHosts,
+				VirtualChurn:    defaultVirtualChurn,
+				VirtualMode:     defaultVirtualMode,
+				TraceEndpoint:   defaultTraceEndpoint,
+				TraceRatio:      defaultTraceRatio,
+				RecordFile:      defaultRecordFile,
+				ReplayFile:      defaultReplayFile,
+				ReplaySpeed:     defaultReplaySpeed,
 			},
 			expectError: false,
 		},
 		{
 			name: "Environment variables",
 			envVars: map[string]string{
-				"ADDRESS":         "envserver:9000",
-				"POLL_INTERVAL":   "5",
-				"REPORT_INTERVAL": "15",
-				"KEY":             "testpass",
-				"RATE_LIMIT":      "8",
-				"PPROF_FLAG":      "true",
-				"CRYPTO_KEY":      "env_example/path",
+				"ADDRESS":                     "envserver:9000",
+				"POLL_INTERVAL":               "5",
+				"REPORT_INTERVAL":             "15",
+				"KEY":                         "testpass",
+				"RATE_LIMIT":                  "8",
+				"PPROF_FLAG":                  "true",
+				"CRYPTO_KEY":                  "env_example/path",
+				"CRYPTO_KEY_FETCH":            "true",
+				"CRYPTO_KEY_FINGERPRINT":      "abcdef",
+				"NEXT_KEY":                    "envnextkey",
+				"NEXT_CRYPTO_KEY_FINGERPRINT": "123456",
+				"AGENT_ID":                    "envagent",
+				"API_KEY":                     "envapikey",
+				"CRASH_DUMP_DIR":              "/var/lib/metricol/crash",
+				"SERVER_SRV":                  "_metricol._tcp.example.com",
+				"SRV_REFRESH":                 "60",
+				"MAX_PROCS":                   "2",
+				"NICE":                        "10",
+				"MAX_LOAD":                    "4.5",
+				"MEMORY_BUDGET_MB":            "64",
+				"MAX_BATCH_SIZE":              "500",
+				"SEND_QUEUE_SIZE":             "4",
+				"MIN_POLL_INTERVAL":           "1",
+				"MAX_POLL_INTERVAL":           "30",
+				"STRATEGY_TIMEOUT":            "3",
+				"STRATEGIES":                  "memstats=5,gopsutil=off",
+				"STRATEGY_CACHE":              "gopsutil=30",
+				"METRIC_INCLUDE":              "Heap*,CPU*",
+				"COLLECT_STRATEGIES":          "memstats,pgstats",
+				"METRIC_EXCLUDE":              "HeapReleased",
+				"METRIC_RENAME":               "Alloc=go_alloc",
+				"METRIC_LABELS":               "region=eu",
+				"PODINFO_DIR":                 "/etc/podinfo",
+				"CRYPTO_TLS_CERT":             "/etc/metricol/agent.crt",
+				"CRYPTO_TLS_KEY":              "/etc/metricol/agent.key",
+				"CRYPTO_TLS_CA":               "/etc/metricol/ca.crt",
+				"SCRAPE_TARGETS":              "api=http://localhost:9100/metrics",
+				"SCRAPE_SELECT":               "http_*,go_goroutines",
+				"PROCESSES":                   "nginx,42",
+				"STATUS_ADDRESS":              "localhost:9100",
+				"HEARTBEAT":                   "true",
+				"CLOCK_DRIFT_SOURCE":          "date",
+				"SHUTDOWN_REPORT_FILE":        "/var/log/metricol/agent-shutdown.json",
+				"VIRTUAL_HOSTS":               "50",
+				"VIRTUAL_HOST_CHURN":          "0.1",
+				"VIRTUAL_HOST_MODE":           "prefix",
+				"OTLP_ENDPOINT":               "http://otel:4318",
+				"TRACE_SAMPLE_RATIO":          "0.25",
+				"REPLAY_FILE":                 "/tmp/recording.jsonl",
+				"REPLAY_SPEED":                "2",
+				"REPLAY_DIRECT":               "true",
+				"REPLAY_RETIME":               "true",
 			},
 			args: []string{},
 			expected: Config{
-				ServerAddress:  "envserver:9000",
-				PollInterval:   5,
-				ReportInterval: 15,
-				SigningKey:     "testpass",
-				RateLimit:      8,
-				PprofFlag:      true,
-				CryptoKey:      "env_example/path",
+				ServerAddress:   "envserver:9000",
+				PollInterval:    5,
+				ReportInterval:  15,
+				SigningKey:      "testpass",
+				RateLimit:       8,
+				PprofFlag:       true,
+				CryptoKey:       "env_example/path",
+				KeyFetch:        true,
+				KeyFingerprint:  "abcdef",
+				NextSigningKey:  "envnextkey",
+				NextKeyPin:      "123456",
+				AgentID:         "envagent",
+				APIKey:          "envapikey",
+				CrashDumpDir:    "/var/lib/metricol/crash",
+				ServerSRV:       "_metricol._tcp.example.com",
+				SRVRefresh:      60,
+				MaxProcs:        2,
+				Nice:            10,
+				MaxLoad:         4.5,
+				MemoryBudget:    64,
+				MaxBatchSize:    500,
+				SendQueueSize:   4,
+				MinPollInterval: 1,
+				MaxPollInterval: 30,
+				StrategyTimeout: 3,
+				Strategies:      "memstats=5,gopsutil=off",
+				StrategyCache:   "gopsutil=30",
+				MetricInclude:   []string{"Heap*", "CPU*"},
+				Collect:         []string{"memstats", "pgstats"},
+				MetricExclude:   []string{"HeapReleased"},
+				MetricRename:    []string{"Alloc=go_alloc"},
+				MetricLabels:    []string{"region=eu"},
+				PodInfoDir:      "/etc/podinfo",
+				TLSCert:         "/etc/metricol/agent.crt",
+				TLSKey:          "/etc/metricol/agent.key",
+				TLSCA:           "/etc/metricol/ca.crt",
+				ScrapeTargets:   []string{"api=http://localhost:9100/metrics"},
+				ScrapeSelect:    []string{"http_*", "go_goroutines"},
+				Processes:       []string{"nginx", "42"},
+				StatusAddress:   "localhost:9100",
+				Heartbeat:       true,
+				ClockSource:     "date",
+				ShutdownReport:  "/var/log/metricol/agent-shutdown.json",
+				VirtualHosts:    50,
+				VirtualChurn:    0.1,
+				VirtualMode:     "prefix",
+				TraceEndpoint:   "http://otel:4318",
+				TraceRatio:      0.25,
+				ReplayFile:      "/tmp/recording.jsonl",
+				ReplaySpeed:     2,
+				ReplayDirect:    true,
+				ReplayRetime:    true,
 			},
 			expectError: false,
 		},
@@ -66,6 +196,10 @@ func TestParseConfig(t *testing.T) {
 				"-l", "8",
 				"-pf",
 				"-crypto-key", "cmd_example/path",
+				"-crypto-key-fetch",
+				"-crypto-key-fingerprint", "fedcba",
+				"-next-key", "flagnextkey",
+				"-agent-id", "flagagent",
 			},
 			expected: Config{
 				ServerAddress:  "flagserver:8000",
@@ -75,6 +209,12 @@ func TestParseConfig(t *testing.T) {
 				RateLimit:      8,
 				PprofFlag:      true,
 				CryptoKey:      "cmd_example/path",
+				KeyFetch:       true,
+				KeyFingerprint: "fedcba",
+				NextSigningKey: "flagnextkey",
+				NextKeyPin:     defaultNextKeyPin,
+				TraceRatio:     defaultTraceRatio,
+				AgentID:        "flagagent",
 			},
 			expectError: false,
 		},
@@ -105,9 +245,61 @@ func TestParseConfig(t *testing.T) {
 				RateLimit:      8,
 				PprofFlag:      true,
 				CryptoKey:      "env_example/path",
+				TraceRatio:     defaultTraceRatio,
 			},
 			expectError: false,
 		},
+		{
+			name:        "Unbracketed IPv6 server address",
+			envVars:     map[string]string{"ADDRESS": "http://2001:db8::1:8080"},
+			args:        []string{},
+			expected:    Config{},
+			expectError: true,
+		},
+		{
+			name: "Both SRV name and registry",
+			envVars: map[string]string{
+				"SERVER_SRV":      "_metricol._tcp.example.com",
+				"SERVER_REGISTRY": "consul://localhost:8500",
+			},
+			args:        []string{},
+			expected:    Config{},
+			expectError: true,
+		},
+		{
+			name:        "TLS certificate without key",
+			envVars:     map[string]string{"CRYPTO_TLS_CERT": "/etc/metricol/agent.crt"},
+			args:        []string{},
+			expected:    Config{},
+			expectError: true,
+		},
+		{
+			name:        "Negative memory budget",
+			envVars:     map[string]string{"MEMORY_BUDGET_MB": "-64"},
+			args:        []string{},
+			expectError: true,
+		},
+		{
+			name:        "Negative trace sample ratio",
+			envVars:     map[string]string{"TRACE_SAMPLE_RATIO": "-0.5"},
+			args:        []string{},
+			expectError: true,
+		},
+		{
+			name: "Recording while replaying",
+			envVars: map[string]string{
+				"RECORD_FILE": "/tmp/new.jsonl",
+				"REPLAY_FILE": "/tmp/recording.jsonl",
+			},
+			args:        []string{},
+			expectError: true,
+		},
+		{
+			name:        "Negative replay speed",
+			envVars:     map[string]string{"REPLAY_SPEED": "-1"},
+			args:        []string{},
+			expectError: true,
+		},
 		{
 			name: "Invalid environment variable",
 			envVars: map[string]string{
@@ -147,3 +339,143 @@ func TestParseConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeConfigFile_MetricRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.json")
+	data := `{
+		"metric_include": ["Heap*"],
+		"collect_strategies": ["gopsutil"],
+		"metric_exclude": ["HeapReleased"],
+		"metric_rename": ["HeapAlloc=heap_alloc"],
+		"scrape_targets": ["api=http://localhost:9100/metrics"],
+		"scrape_select": ["http_*"],
+		"processes": ["envoy"]
+	}`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	tests := []struct {
+		cfg      Config
+		expected Config
+		name     string
+	}{
+		{
+			name: "rules taken from file",
+			cfg:  Config{ConfigPath: path},
+			expected: Config{
+				ConfigPath:    path,
+				MetricInclude: []string{"Heap*"},
+				Collect:       []string{"gopsutil"},
+				MetricExclude: []string{"HeapReleased"},
+				MetricRename:  []string{"HeapAlloc=heap_alloc"},
+				ScrapeTargets: []string{"api=http://localhost:9100/metrics"},
+				ScrapeSelect:  []string{"http_*"},
+				Processes:     []string{"envoy"},
+			},
+		},
+		{
+			name: "environment takes precedence",
+			cfg:  Config{ConfigPath: path, MetricInclude: []string{"CPU*"}},
+			expected: Config{
+				ConfigPath:    path,
+				MetricInclude: []string{"CPU*"},
+				Collect:       []string{"gopsutil"},
+				MetricExclude: []string{"HeapReleased"},
+				MetricRename:  []string{"HeapAlloc=heap_alloc"},
+				ScrapeTargets: []string{"api=http://localhost:9100/metrics"},
+				ScrapeSelect:  []string{"http_*"},
+				Processes:     []string{"envoy"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			require.NoError(t, mergeConfigFile(&cfg))
+			assert.Equal(t, tt.expected.MetricInclude, cfg.MetricInclude)
+			assert.Equal(t, tt.expected.Collect, cfg.Collect)
+			assert.Equal(t, tt.expected.MetricExclude, cfg.MetricExclude)
+			assert.Equal(t, tt.expected.MetricRename, cfg.MetricRename)
+			assert.Equal(t, tt.expected.ScrapeTargets, cfg.ScrapeTargets)
+			assert.Equal(t, tt.expected.ScrapeSelect, cfg.ScrapeSelect)
+			assert.Equal(t, tt.expected.Processes, cfg.Processes)
+		})
+	}
+}
+
+func TestConfig_ReloadMetricFilters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"metric_include": ["Heap*"]}`), 0o600))
+	cfg := Config{ConfigPath: path}
+
+	filters, err := cfg.ReloadMetricFilters()
+	require.NoError(t, err)
+	assert.Equal(t, MetricFilters{Include: []string{"Heap*"}}, filters)
+
+	data := `{"metric_include": ["/^Heap/"], "metric_exclude": ["HeapReleased"], "metric_rename": ["Alloc=go_alloc"]}`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	t.Setenv("METRIC_EXCLUDE", "HeapIdle")
+	filters, err = cfg.ReloadMetricFilters()
+	require.NoError(t, err)
+	assert.Equal(t, MetricFilters{
+		Include: []string{"/^Heap/"},
+		Exclude: []string{"HeapIdle"},
+		Rename:  []string{"Alloc=go_alloc"},
+	}, filters, "the environment takes precedence over the file")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{`), 0o600))
+	_, err = cfg.ReloadMetricFilters()
+	assert.Error(t, err)
+}
+
+func TestConfig_ReloadTuning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.json")
+	data := `{"poll_interval": 3, "report_interval": 20, "rate_limit": 3, "server_address": "metrics:8080"}`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	t.Setenv("RATE_LIMIT", "4")
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError) //nolint:reassign // for tests
+	os.Args = []string{"cmd", "-c=" + path, "-p=7"}                  //nolint:reassign // for tests
+	cfg, err := ParseConfig()
+	require.NoError(t, err)
+
+	data = `{"poll_interval": 5, "report_interval": 30, "rate_limit": 9, "server_address": "metrics:9090", ` +
+		`"signing_key": "rotated"}`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+	tuning, err := cfg.ReloadTuning()
+	require.NoError(t, err)
+	assert.Equal(t, Tuning{
+		ServerAddress:  "metrics:9090",
+		SigningKey:     "rotated",
+		PollInterval:   7,
+		ReportInterval: 30,
+		RateLimit:      4,
+	}, tuning, "the flags and the environment take precedence over the file")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"report_interval": -1, "server_address": "metrics:9090"}`), 0o600))
+	_, err = cfg.ReloadTuning()
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{`), 0o600))
+	_, err = cfg.ReloadTuning()
+	assert.Error(t, err)
+}
+
+func TestConfig_Audit(t *testing.T) {
+	t.Setenv("KEY", "envkey")
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError) //nolint:reassign // for tests
+	os.Args = []string{"cmd", "-p=7"}                                //nolint:reassign // for tests
+
+	cfg, err := ParseConfig()
+	require.NoError(t, err)
+
+	entries := make(map[string]configaudit.Entry)
+	for _, e := range cfg.Audit() {
+		entries[e.Name] = e
+	}
+	assert.Equal(t, configaudit.SourceEnv, entries["signing_key"].Source)
+	assert.Equal(t, configaudit.Redacted, entries["signing_key"].Value)
+	assert.Equal(t, configaudit.SourceFlag, entries["poll_interval"].Source)
+	assert.Equal(t, "p", entries["poll_interval"].Flag)
+	assert.Equal(t, 7, entries["poll_interval"].Value)
+	assert.Equal(t, configaudit.SourceDefault, entries["config_path"].Source)
+}
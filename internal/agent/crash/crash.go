@@ -0,0 +1,175 @@
+// Package crash recovers panics in agent goroutines and records them, so crashes in the field can be diagnosed:
+// every panic is logged, written to a crash dump file with the goroutine stack and a summary of the batch
+// being processed, and counted in the AgentPanics counter collected like any other metric.
+package crash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// StrategyName is the name of the strategy collecting the panic counter.
+	StrategyName = "crash"
+	// panicsMetric is the counter of recovered panics.
+	panicsMetric = "AgentPanics"
+	// dumpTimeLayout is the layout of the time in crash dump file names.
+	dumpTimeLayout = "20060102T150405.000000000"
+	// summaryNames is the number of metric names listed in a batch summary.
+	summaryNames = 10
+)
+
+// Reporter records recovered panics. A Reporter is safe for concurrent use.
+type Reporter struct {
+	logger  *zap.SugaredLogger
+	dir     string     // dir is the directory crash dumps are written to, empty to only log panics.
+	mu      sync.Mutex // mu protects total and pending.
+	total   int64      // total is the number of panics recorded since start.
+	pending int64      // pending is the number of panics not collected yet.
+}
+
+// NewReporter creates a Reporter.
+//
+// Parameters:
+//   - dir: The directory crash dumps are written to; empty disables the dumps.
+//   - logger: The logger reporting panics.
+//
+// Returns:
+//   - *Reporter: The reporter.
+func NewReporter(dir string, logger *zap.SugaredLogger) *Reporter {
+	return &Reporter{dir: dir, logger: logger}
+}
+
+// Recover records a panic of the calling goroutine and stops it from crashing the agent.
+// It must be deferred directly, e.g. defer reporter.Recover("sender", summary).
+//
+// Parameters:
+//   - worker: The name of the goroutine.
+//   - summary: The function describing the batch being processed; nil if there is none.
+func (r *Reporter) Recover(worker string, summary func() string) {
+	rec := recover()
+	if rec == nil {
+		return
+	}
+	var batch string
+	if summary != nil {
+		batch = summary()
+	}
+	r.Record(worker, rec, debug.Stack(), batch)
+}
+
+// Record counts a panic that was already recovered, logs it and writes its crash dump.
+//
+// Parameters:
+//   - worker: The name of the goroutine that panicked.
+//   - value: The recovered panic value.
+//   - stack: The stack of the goroutine at the moment of the panic.
+//   - batch: The summary of the batch being processed; empty if there is none.
+func (r *Reporter) Record(worker string, value any, stack []byte, batch string) {
+	r.mu.Lock()
+	r.total++
+	r.pending++
+	r.mu.Unlock()
+
+	if r.dir == "" {
+		r.logger.Errorf("Recovered panic in %s: %v\n%s", worker, value, stack)
+		return
+	}
+	path, err := r.writeDump(worker, value, stack, batch)
+	if err != nil {
+		r.logger.Errorf("Recovered panic in %s: %v; failed to write crash dump: %v\n%s", worker, value, err, stack)
+		return
+	}
+	r.logger.Errorf("Recovered panic in %s: %v; crash dump written to %s", worker, value, path)
+}
+
+// Total returns the number of panics recorded since start.
+//
+// Returns:
+//   - int64: The number of panics.
+func (r *Reporter) Total() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.total
+}
+
+// Name returns the configuration name of the strategy collecting the panic counter.
+//
+// Returns:
+//   - string: The strategy name.
+func (r *Reporter) Name() string {
+	return StrategyName
+}
+
+// Collect returns the AgentPanics counter with the number of panics recorded since the last call.
+//
+// Returns:
+//   - *entity.Metrics: The counter.
+//   - error: Always nil.
+func (r *Reporter) Collect() (*entity.Metrics, error) {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = 0
+	r.mu.Unlock()
+
+	return &entity.Metrics{{Name: panicsMetric, Type: entity.MetricTypeCounter, Value: pending}}, nil
+}
+
+// writeDump writes a crash dump file and returns its path.
+func (r *Reporter) writeDump(worker string, value any, stack []byte, batch string) (string, error) {
+	if err := os.MkdirAll(r.dir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create crash dump directory: %w", err)
+	}
+
+	now := time.Now()
+	var b strings.Builder
+	fmt.Fprintf(&b, "time: %s\n", now.Format(time.RFC3339Nano))
+	fmt.Fprintf(&b, "worker: %s\n", worker)
+	fmt.Fprintf(&b, "panic: %v\n", value)
+	if batch != "" {
+		fmt.Fprintf(&b, "last batch: %s\n", batch)
+	}
+	fmt.Fprintf(&b, "\n%s", stack)
+
+	path := filepath.Join(r.dir, fmt.Sprintf("crash-%s-%s.txt", worker, now.UTC().Format(dumpTimeLayout)))
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write crash dump: %w", err)
+	}
+	return path, nil
+}
+
+// BatchSummary describes a batch of metrics by its size and the first metric names.
+//
+// Parameters:
+//   - metrics: The batch; may be nil.
+//
+// Returns:
+//   - string: The summary, e.g. "3 metrics: Alloc, HeapAlloc, PollCount".
+func BatchSummary(metrics *entity.Metrics) string {
+	if metrics == nil {
+		return "none"
+	}
+	names := make([]string, 0, min(metrics.Length(), summaryNames))
+	for _, m := range *metrics {
+		if len(names) == summaryNames {
+			break
+		}
+		if m != nil {
+			names = append(names, m.Name)
+		}
+	}
+	summary := fmt.Sprintf("%d metrics: %s", metrics.Length(), strings.Join(names, ", "))
+	if metrics.Length() > summaryNames {
+		summary += ", ..."
+	}
+	return summary
+}
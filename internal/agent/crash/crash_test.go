@@ -0,0 +1,98 @@
+package crash
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func panicking(r *Reporter, batch *entity.Metrics) {
+	defer r.Recover("sender", func() string { return BatchSummary(batch) })
+	panic("boom")
+}
+
+func TestReporter_Recover(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "crash")
+	r := NewReporter(dir, zap.NewNop().Sugar())
+	batch := &entity.Metrics{{Name: "Alloc"}, {Name: "HeapAlloc"}}
+
+	require.NotPanics(t, func() { panicking(r, batch) })
+	assert.Equal(t, int64(1), r.Total())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.True(t, strings.HasPrefix(entries[0].Name(), "crash-sender-"))
+
+	dump, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	require.NoError(t, err)
+	assert.Contains(t, string(dump), "worker: sender\n")
+	assert.Contains(t, string(dump), "panic: boom\n")
+	assert.Contains(t, string(dump), "last batch: 2 metrics: Alloc, HeapAlloc\n")
+	assert.Contains(t, string(dump), "crash.panicking")
+}
+
+func TestReporter_RecoverWithoutPanic(t *testing.T) {
+	dir := t.TempDir()
+	r := NewReporter(dir, zap.NewNop().Sugar())
+
+	func() {
+		defer r.Recover("sender", nil)
+	}()
+
+	assert.Zero(t, r.Total())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestReporter_WithoutDumps(t *testing.T) {
+	r := NewReporter("", zap.NewNop().Sugar())
+	require.NotPanics(t, func() { panicking(r, nil) })
+	assert.Equal(t, int64(1), r.Total())
+}
+
+func TestReporter_Collect(t *testing.T) {
+	r := NewReporter("", zap.NewNop().Sugar())
+	r.Record("collector", "boom", nil, "")
+	r.Record("collector", "boom", nil, "")
+
+	metrics, err := r.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, &entity.Metrics{{Name: panicsMetric, Type: entity.MetricTypeCounter, Value: int64(2)}}, metrics)
+
+	metrics, err = r.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), (*metrics)[0].Value, "collected panics are not reported again")
+	assert.Equal(t, int64(2), r.Total())
+}
+
+func TestBatchSummary(t *testing.T) {
+	many := make(entity.Metrics, summaryNames+2)
+	for i := range many {
+		many[i] = &entity.Metric{Name: "m"}
+	}
+
+	tests := []struct {
+		metrics  *entity.Metrics
+		name     string
+		expected string
+	}{
+		{name: "Nil", metrics: nil, expected: "none"},
+		{name: "Empty", metrics: &entity.Metrics{}, expected: "0 metrics: "},
+		{name: "Few", metrics: &entity.Metrics{{Name: "a"}, {Name: "b"}}, expected: "2 metrics: a, b"},
+		{name: "Many", metrics: &many, expected: "12 metrics: m, m, m, m, m, m, m, m, m, m, ..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, BatchSummary(tt.metrics))
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package discovery
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	// schemeConsul selects the Consul registry.
+	schemeConsul = "consul"
+	// schemeEtcd selects the etcd registry.
+	schemeEtcd = "etcd"
+	// defaultServiceName is the service name looked up when the registry URL has no path.
+	defaultServiceName = "metricol"
+	// etcdKeyPrefix is the prefix of the etcd keys the server registers under, followed by "<service>/<id>".
+	etcdKeyPrefix = "/services/"
+	// registryTimeout limits every request to the registry.
+	registryTimeout = 5 * time.Second
+)
+
+// ErrUnsupportedRegistry is returned for a registry URL with an unknown scheme.
+var ErrUnsupportedRegistry = errors.New("unsupported registry, expected consul:// or etcd://")
+
+// consulEntry is an entry of the Consul health API response; only the fields locating the service are decoded.
+type consulEntry struct {
+	Node struct {
+		Address string `json:"Address"`
+	} `json:"Node"`
+	Service struct {
+		Address string `json:"Address"`
+		Port    int    `json:"Port"`
+	} `json:"Service"`
+}
+
+// etcdRange is the response of the etcd v3 JSON API to a range request, with base64-encoded keys and values.
+type etcdRange struct {
+	Kvs []struct {
+		Value string `json:"value"`
+	} `json:"kvs"`
+}
+
+// NewRegistryResolver creates a resolver for the server instances registered in Consul or etcd,
+// as the server does with its -registry setting. Only instances passing their Consul health checks are used;
+// etcd only holds instances that keep their lease alive. All instances are weighted equally.
+//
+// Parameters:
+//   - raw: The registry URL, e.g. "consul://localhost:8500/metricol" or "etcd://localhost:2379".
+//   - refresh: The period after which the instances are looked up again.
+//   - logger: The logger reporting failed lookups.
+//
+// Returns:
+//   - *Resolver: The resolver.
+//   - error: An error if the URL is invalid.
+func NewRegistryResolver(raw string, refresh time.Duration, logger *zap.SugaredLogger) (*Resolver, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry URL %q: %w", raw, err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("registry URL %q has no host", raw)
+	}
+	service := strings.Trim(u.Path, "/")
+	if service == "" {
+		service = defaultServiceName
+	}
+	client := resty.New().SetBaseURL("http://" + u.Host).SetTimeout(registryTimeout)
+
+	switch u.Scheme {
+	case schemeConsul:
+		return newResolver(raw, consulLookup(client, service), refresh, logger), nil
+	case schemeEtcd:
+		return newResolver(raw, etcdLookup(client, service), refresh, logger), nil
+	default:
+		return nil, fmt.Errorf("registry URL %q: %w", raw, ErrUnsupportedRegistry)
+	}
+}
+
+// consulLookup returns the lookup of the healthy instances of service in Consul.
+func consulLookup(client *resty.Client, service string) lookupFunc {
+	return func(ctx context.Context) ([]*net.SRV, error) {
+		var entries []consulEntry
+		req := client.R().SetQueryParam("passing", "true")
+		if err := get(ctx, req, "/v1/health/service/"+url.PathEscape(service), &entries); err != nil {
+			return nil, fmt.Errorf("consul lookup failed: %w", err)
+		}
+		records := make([]*net.SRV, 0, len(entries))
+		for _, e := range entries {
+			host := e.Service.Address
+			if host == "" {
+				host = e.Node.Address
+			}
+			port := uint16(e.Service.Port) //nolint:gosec // Consul ports are valid TCP ports.
+			records = append(records, &net.SRV{Target: host, Port: port, Weight: 1})
+		}
+		return records, nil
+	}
+}
+
+// etcdLookup returns the lookup of the instances of service registered in etcd.
+func etcdLookup(client *resty.Client, service string) lookupFunc {
+	prefix := etcdKeyPrefix + service + "/"
+	// The range end is the prefix with its last byte incremented, selecting every key with the prefix.
+	end := prefix[:len(prefix)-1] + string(prefix[len(prefix)-1]+1)
+	body := map[string]string{
+		"key":       base64.StdEncoding.EncodeToString([]byte(prefix)),
+		"range_end": base64.StdEncoding.EncodeToString([]byte(end)),
+	}
+
+	return func(ctx context.Context) ([]*net.SRV, error) {
+		var resp etcdRange
+		if err := post(ctx, client.R().SetBody(body), "/v3/kv/range", &resp); err != nil {
+			return nil, fmt.Errorf("etcd lookup failed: %w", err)
+		}
+		records := make([]*net.SRV, 0, len(resp.Kvs))
+		for _, kv := range resp.Kvs {
+			value, err := base64.StdEncoding.DecodeString(kv.Value)
+			if err != nil {
+				continue
+			}
+			host, rawPort, err := net.SplitHostPort(string(value))
+			if err != nil {
+				continue
+			}
+			port, err := strconv.ParseUint(rawPort, 10, 16)
+			if err != nil {
+				continue
+			}
+			records = append(records, &net.SRV{Target: host, Port: uint16(port), Weight: 1})
+		}
+		return records, nil
+	}
+}
+
+// get sends a GET request and decodes the JSON response into result.
+func get(ctx context.Context, req *resty.Request, path string, result any) error {
+	resp, err := req.SetContext(ctx).Get(path)
+	return decode(resp, err, result)
+}
+
+// post sends a POST request and decodes the JSON response into result.
+func post(ctx context.Context, req *resty.Request, path string, result any) error {
+	resp, err := req.SetContext(ctx).Post(path)
+	return decode(resp, err, result)
+}
+
+// decode checks the outcome of a registry request and decodes its JSON response into result.
+func decode(resp *resty.Response, err error, result any) error {
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("unexpected status %s: %s", resp.Status(), resp.String())
+	}
+	if err := json.Unmarshal(resp.Body(), result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
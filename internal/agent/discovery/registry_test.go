@@ -0,0 +1,100 @@
+package discovery
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNewRegistryResolver_InvalidURL(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{name: "no host", raw: "consul:///metricol"},
+		{name: "unsupported scheme", raw: "zookeeper://localhost:2181"},
+		{name: "malformed", raw: "consul://%zz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRegistryResolver(tt.raw, time.Minute, zap.NewNop().Sugar())
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestRegistryResolver_Consul(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/health/service/metrics", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("passing"))
+		_, _ = w.Write([]byte(`[
+			{"Node":{"Address":"10.0.0.9"},"Service":{"Address":"10.0.0.1","Port":8080}},
+			{"Node":{"Address":"10.0.0.2"},"Service":{"Address":"","Port":8081}}
+		]`))
+	}))
+	defer ts.Close()
+
+	raw := "consul://" + strings.TrimPrefix(ts.URL, "http://") + "/metrics"
+	r, err := NewRegistryResolver(raw, time.Minute, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	records, err := r.lookup(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []*net.SRV{
+		{Target: "10.0.0.1", Port: 8080, Weight: 1},
+		{Target: "10.0.0.2", Port: 8081, Weight: 1},
+	}, records)
+}
+
+func TestRegistryResolver_Etcd(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/kv/range", r.URL.Path)
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		key, _ := base64.StdEncoding.DecodeString(body["key"])
+		end, _ := base64.StdEncoding.DecodeString(body["range_end"])
+		assert.Equal(t, "/services/metricol/", string(key))
+		assert.Equal(t, "/services/metricol0", string(end))
+
+		encode := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+		_, _ = w.Write([]byte(`{"kvs":[{"value":"` + encode("10.0.0.1:8080") + `"},{"value":"` +
+			encode("not an address") + `"},{"value":"` + encode("[2001:db8::1]:8081") + `"}]}`))
+	}))
+	defer ts.Close()
+
+	raw := "etcd://" + strings.TrimPrefix(ts.URL, "http://")
+	r, err := NewRegistryResolver(raw, time.Minute, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	records, err := r.lookup(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []*net.SRV{
+		{Target: "10.0.0.1", Port: 8080, Weight: 1},
+		{Target: "2001:db8::1", Port: 8081, Weight: 1},
+	}, records)
+}
+
+func TestRegistryResolver_Unavailable(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "No cluster leader", http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	raw := "consul://" + strings.TrimPrefix(ts.URL, "http://")
+	r, err := NewRegistryResolver(raw, time.Minute, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	_, err = r.Resolve(context.Background())
+	require.Error(t, err)
+}
@@ -0,0 +1,167 @@
+// Package discovery resolves the address of the metrics server from DNS SRV records or a service registry,
+// Consul or etcd, so agents deployed behind service discovery do not need the server hosts hardcoded.
+// The targets are re-resolved periodically and every new connection picks one among the targets
+// of the lowest priority, weighted by their weight.
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// dialTimeout limits the time spent connecting to a resolved target.
+const dialTimeout = 10 * time.Second
+
+// ErrNoRecords is reported when the source resolves to no usable target.
+var ErrNoRecords = errors.New("no server targets")
+
+// lookupFunc looks up the server targets. Targets are described as SRV records whatever their source.
+type lookupFunc func(ctx context.Context) ([]*net.SRV, error)
+
+// Resolver resolves a server address from the targets of a discovery source. It is safe for concurrent use.
+type Resolver struct {
+	resolvedAt time.Time          // resolvedAt is the time of the last lookup attempt.
+	lookup     lookupFunc         // lookup looks up the targets.
+	logger     *zap.SugaredLogger // logger reports failed lookups.
+	name       string             // name describes the source, e.g. "_metricol._tcp.example.com".
+	records    []*net.SRV         // records are the targets of the last successful lookup.
+	mu         sync.Mutex         // mu protects records and resolvedAt.
+	refresh    time.Duration      // refresh is the period after which the targets are resolved again.
+}
+
+// newResolver creates a resolver looking targets up with lookup.
+func newResolver(name string, lookup lookupFunc, refresh time.Duration, logger *zap.SugaredLogger) *Resolver {
+	return &Resolver{
+		lookup:  lookup,
+		logger:  logger,
+		name:    name,
+		refresh: refresh,
+	}
+}
+
+// Name returns the description of the source the resolver resolves from.
+//
+// Returns:
+//   - string: The source, e.g. the SRV name.
+func (r *Resolver) Name() string {
+	return r.name
+}
+
+// Resolve picks the address of a server target. The targets are looked up again once they are older than
+// the refresh period; if the lookup fails, the previous targets are used until the next period.
+//
+// Parameters:
+//   - ctx: The context of the lookup.
+//
+// Returns:
+//   - string: The target address in the host:port form.
+//   - error: An error if the source has never resolved to a target.
+func (r *Resolver) Resolve(ctx context.Context) (string, error) {
+	records, err := r.current(ctx)
+	if err != nil {
+		return "", err
+	}
+	target := pick(records, rand.IntN)
+	return net.JoinHostPort(strings.TrimSuffix(target.Target, "."), strconv.Itoa(int(target.Port))), nil
+}
+
+// DialContext connects to a resolved target, ignoring the address it is given.
+// It is meant for the DialContext field of an http.Transport, so every new connection follows the targets.
+//
+// Parameters:
+//   - ctx: The context of the connection.
+//   - network: The network to connect over, e.g. "tcp".
+//
+// Returns:
+//   - net.Conn: The connection.
+//   - error: An error if no target could be resolved or connected to.
+func (r *Resolver) DialContext(ctx context.Context, network, _ string) (net.Conn, error) {
+	addr, err := r.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dialer := net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s resolved from %s: %w", addr, r.name, err)
+	}
+	return conn, nil
+}
+
+// current returns the targets, looking them up again if they are older than the refresh period.
+func (r *Resolver) current(ctx context.Context) ([]*net.SRV, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.records != nil && time.Since(r.resolvedAt) < r.refresh {
+		return r.records, nil
+	}
+	r.resolvedAt = time.Now()
+
+	records, err := r.lookup(ctx)
+	if err == nil {
+		records = usable(records)
+		if len(records) == 0 {
+			err = ErrNoRecords
+		}
+	}
+	if err != nil {
+		if r.records == nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", r.name, err)
+		}
+		r.logger.Warnf("Failed to re-resolve %s, keeping %d known targets: %v", r.name, len(r.records), err)
+		return r.records, nil
+	}
+	r.records = records
+	return records, nil
+}
+
+// usable drops the records telling that the service is not available, whose target is ".".
+func usable(records []*net.SRV) []*net.SRV {
+	kept := make([]*net.SRV, 0, len(records))
+	for _, rec := range records {
+		if rec != nil && rec.Target != "." && rec.Target != "" {
+			kept = append(kept, rec)
+		}
+	}
+	return kept
+}
+
+// pick selects a record as RFC 2782 describes: among the records of the lowest priority,
+// with a probability proportional to the weight. Records of weight zero are picked only if all are.
+// intN returns a random number in [0, n).
+func pick(records []*net.SRV, intN func(n int) int) *net.SRV {
+	best := records[0].Priority
+	for _, rec := range records[1:] {
+		best = min(best, rec.Priority)
+	}
+	candidates := make([]*net.SRV, 0, len(records))
+	total := 0
+	for _, rec := range records {
+		if rec.Priority == best {
+			candidates = append(candidates, rec)
+			total += int(rec.Weight)
+		}
+	}
+	if total == 0 {
+		return candidates[intN(len(candidates))]
+	}
+
+	n := intN(total)
+	for _, rec := range candidates {
+		n -= int(rec.Weight)
+		if n < 0 {
+			return rec
+		}
+	}
+	return candidates[len(candidates)-1]
+}
@@ -0,0 +1,107 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeLookup returns the queued answers in order, counting the lookups.
+type fakeLookup struct {
+	answers []fakeAnswer
+	calls   int
+}
+
+type fakeAnswer struct {
+	err     error
+	records []*net.SRV
+}
+
+func (f *fakeLookup) lookup(context.Context) ([]*net.SRV, error) {
+	answer := f.answers[min(f.calls, len(f.answers)-1)]
+	f.calls++
+	return answer.records, answer.err
+}
+
+func newTestResolver(refresh time.Duration, answers ...fakeAnswer) (*Resolver, *fakeLookup) {
+	fake := &fakeLookup{answers: answers}
+	return newResolver("_metricol._tcp.example.com", fake.lookup, refresh, zap.NewNop().Sugar()), fake
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	r, fake := newTestResolver(time.Hour, fakeAnswer{records: []*net.SRV{{Target: "metrics.example.com.", Port: 8080}}})
+
+	addr, err := r.Resolve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "metrics.example.com:8080", addr)
+
+	_, err = r.Resolve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, fake.calls, "records are cached for the refresh period")
+}
+
+func TestResolver_Refresh(t *testing.T) {
+	r, fake := newTestResolver(
+		0,
+		fakeAnswer{records: []*net.SRV{{Target: "old.example.com.", Port: 8080}}},
+		fakeAnswer{records: []*net.SRV{{Target: "new.example.com.", Port: 9090}}},
+		fakeAnswer{err: errors.New("timeout")},
+	)
+
+	addr, err := r.Resolve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "old.example.com:8080", addr)
+
+	addr, err = r.Resolve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "new.example.com:9090", addr)
+
+	addr, err = r.Resolve(context.Background())
+	require.NoError(t, err, "a failed re-resolution keeps the known targets")
+	assert.Equal(t, "new.example.com:9090", addr)
+	assert.Equal(t, 3, fake.calls)
+}
+
+func TestResolver_NoRecords(t *testing.T) {
+	r, _ := newTestResolver(time.Hour, fakeAnswer{records: []*net.SRV{{Target: ".", Port: 0}}})
+
+	_, err := r.Resolve(context.Background())
+	require.ErrorIs(t, err, ErrNoRecords)
+}
+
+func TestResolver_DialContext(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+	port := ln.Addr().(*net.TCPAddr).Port //nolint:forcetypeassert // a TCP listener
+
+	r, _ := newTestResolver(time.Hour, fakeAnswer{records: []*net.SRV{{Target: "127.0.0.1.", Port: uint16(port)}}})
+
+	conn, err := r.DialContext(context.Background(), "tcp", "ignored.example.com:80")
+	require.NoError(t, err)
+	assert.Equal(t, ln.Addr().String(), conn.RemoteAddr().String())
+	require.NoError(t, conn.Close())
+}
+
+func TestPick(t *testing.T) {
+	records := []*net.SRV{
+		{Target: "backup", Priority: 20, Weight: 100},
+		{Target: "light", Priority: 10, Weight: 1},
+		{Target: "heavy", Priority: 10, Weight: 3},
+	}
+
+	picked := map[string]int{}
+	for n := range 4 {
+		picked[pick(records, func(int) int { return n }).Target]++
+	}
+	assert.Equal(t, map[string]int{"light": 1, "heavy": 3}, picked)
+
+	zero := []*net.SRV{{Target: "a"}, {Target: "b"}}
+	assert.Equal(t, "b", pick(zero, func(n int) int { return n - 1 }).Target)
+}
@@ -0,0 +1,26 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// NewSRVResolver creates a resolver for the DNS SRV records of name. Targets are picked as RFC 2782 describes.
+//
+// Parameters:
+//   - name: The full SRV name, e.g. "_metricol._tcp.example.com".
+//   - refresh: The period after which the records are resolved again.
+//   - logger: The logger reporting failed lookups.
+//
+// Returns:
+//   - *Resolver: The resolver.
+func NewSRVResolver(name string, refresh time.Duration, logger *zap.SugaredLogger) *Resolver {
+	lookup := func(ctx context.Context) ([]*net.SRV, error) {
+		_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+		return records, err //nolint:wrapcheck // Resolver adds the name to the error.
+	}
+	return newResolver(name, lookup, refresh, logger)
+}
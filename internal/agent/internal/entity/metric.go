@@ -6,12 +6,16 @@ package entity
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
 const (
 	// MetricTypeCounter represents a counter metric type.
 	MetricTypeCounter = "counter"
 
+	// MetricTypeFloatCounter represents a counter metric type for fractional quantities, such as CPU seconds.
+	MetricTypeFloatCounter = "fcounter"
+
 	// MetricTypeGauge represents a gauge metric type.
 	MetricTypeGauge = "gauge"
 )
@@ -19,10 +23,11 @@ const (
 // Metric represents a single metric with its name, type, value, and a flag
 // indicating whether it is metadata.
 type Metric struct {
-	Value      any    // Value holds the metric value.
-	Name       string // Name is the identifier of the metric.
-	Type       string // Type specifies the metric type, e.g., counter or gauge.
-	IsMetadata bool   // IsMetadata indicates if the metric is metadata.
+	Timestamp  time.Time // Timestamp is the moment the metric was collected; zero if unknown.
+	Value      any       // Value holds the metric value.
+	Name       string    // Name is the identifier of the metric.
+	Type       string    // Type specifies the metric type, e.g., counter or gauge.
+	IsMetadata bool      // IsMetadata indicates if the metric is metadata.
 }
 
 // Metrics is a collection of pointers to Metric.
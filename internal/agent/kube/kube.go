@@ -0,0 +1,104 @@
+// Package kube derives metric labels from the Kubernetes downward API. The pod name, namespace and node
+// are read from environment variables the pod spec fills from field references, and the pod labels from
+// the "labels" file of a downward API volume. Outside Kubernetes none of them are set and no labels result.
+package kube
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// EnvPodName is the variable holding the pod name, filled from metadata.name.
+	EnvPodName = "POD_NAME"
+	// EnvPodNamespace is the variable holding the pod namespace, filled from metadata.namespace.
+	EnvPodNamespace = "POD_NAMESPACE"
+	// EnvNodeName is the variable holding the node name, filled from spec.nodeName.
+	EnvNodeName = "NODE_NAME"
+	// LabelsFile is the file of a downward API volume holding the pod labels, filled from metadata.labels.
+	LabelsFile = "labels"
+	// podLabelPrefix is prepended to the pod labels, so they cannot clash with the pod, namespace and node labels.
+	podLabelPrefix = "label_"
+)
+
+// invalidLabelChars matches the characters of Kubernetes label keys that Prometheus label names do not allow.
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// envLabels maps the downward API variables to the labels they are attached as.
+var envLabels = map[string]string{
+	EnvPodName:      "pod",
+	EnvPodNamespace: "namespace",
+	EnvNodeName:     "node",
+}
+
+// Labels returns the labels describing where the agent runs.
+//
+// Parameters:
+//   - lookupEnv: The environment lookup, usually os.LookupEnv.
+//   - podInfoDir: The mount path of the downward API volume; empty skips the pod labels.
+//
+// Returns:
+//   - map[string]string: The pod, namespace and node labels that are set, and the pod labels prefixed with
+//     "label_", e.g. "app.kubernetes.io/name" becomes "label_app_kubernetes_io_name".
+//   - error: An error if the labels file exists but cannot be read or parsed.
+func Labels(lookupEnv func(string) (string, bool), podInfoDir string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for env, label := range envLabels {
+		if value, ok := lookupEnv(env); ok && value != "" {
+			labels[label] = value
+		}
+	}
+	if podInfoDir == "" {
+		return labels, nil
+	}
+
+	podLabels, err := readLabelsFile(filepath.Join(podInfoDir, LabelsFile))
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range podLabels {
+		labels[podLabelPrefix+invalidLabelChars.ReplaceAllString(key, "_")] = value
+	}
+	return labels, nil
+}
+
+// readLabelsFile parses a downward API labels file of `key="value"` lines; a missing file yields no labels.
+func readLabelsFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pod labels: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	labels := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		key, quoted, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid pod label line %q", line)
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of pod label %q: %w", key, err)
+		}
+		labels[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pod labels: %w", err)
+	}
+	return labels, nil
+}
@@ -0,0 +1,56 @@
+package kube
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// env returns an environment lookup over vars.
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+func TestLabels(t *testing.T) {
+	dir := t.TempDir()
+	data := "app.kubernetes.io/name=\"api\"\ntier=\"backend\"\n\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, LabelsFile), []byte(data), 0o600))
+
+	labels, err := Labels(env(map[string]string{
+		EnvPodName:      "api-0",
+		EnvPodNamespace: "prod",
+		EnvNodeName:     "",
+	}), dir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"pod":                          "api-0",
+		"namespace":                    "prod",
+		"label_app_kubernetes_io_name": "api",
+		"label_tier":                   "backend",
+	}, labels)
+}
+
+func TestLabels_OutsideKubernetes(t *testing.T) {
+	labels, err := Labels(env(nil), t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, labels)
+
+	labels, err = Labels(env(nil), "")
+	require.NoError(t, err)
+	assert.Empty(t, labels)
+}
+
+func TestLabels_MalformedFile(t *testing.T) {
+	for _, data := range []string{"tier\n", "tier=backend\n"} {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, LabelsFile), []byte(data), 0o600))
+		_, err := Labels(env(nil), dir)
+		require.Error(t, err, data)
+	}
+}
@@ -0,0 +1,121 @@
+// Package lifecycle defines how the long-running components of the agent, such as the collector and the sender,
+// are started, stopped, restarted after failures and checked for health, and aggregates the health of several
+// components into one report.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+const (
+	// StatusOK reports that all components are healthy.
+	StatusOK = "ok"
+	// StatusDegraded reports that at least one component is unhealthy.
+	StatusDegraded = "degraded"
+)
+
+// ErrNotRunning is reported by components that have not been started or have already stopped.
+var ErrNotRunning = errors.New("component is not running")
+
+// Component is a long-running part of the agent.
+type Component interface {
+	// Start runs the component until the context is canceled or Stop is called. It blocks while the component runs.
+	Start(ctx context.Context)
+	// Stop asks a running component to stop; Start returns once it has.
+	Stop()
+	// Healthy returns nil if the component works as expected and the reason otherwise.
+	Healthy() error
+}
+
+// Runner tracks whether a component runs and lets it be stopped. The zero value is ready to use;
+// components embed it to implement Start and Stop.
+type Runner struct {
+	cancel  context.CancelFunc // cancel stops the current run.
+	mu      sync.Mutex         // mu protects cancel and running.
+	running bool               // running reports that a run is in progress.
+}
+
+// Run calls fn with a context that is canceled when ctx is canceled or Stop is called, and returns when fn does.
+//
+// Parameters:
+//   - ctx: The parent context of the run.
+//   - fn: The function running the component.
+func (r *Runner) Run(ctx context.Context, fn func(context.Context)) {
+	ctx, cancel := context.WithCancel(ctx)
+	r.mu.Lock()
+	r.cancel = cancel
+	r.running = true
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		r.running = false
+		r.mu.Unlock()
+		cancel()
+	}()
+
+	fn(ctx)
+}
+
+// Stop cancels the current run, if any.
+func (r *Runner) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		r.cancel()
+	}
+}
+
+// Running reports whether a run is in progress.
+//
+// Returns:
+//   - bool: True between the start and the end of Run.
+func (r *Runner) Running() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.running
+}
+
+// Health is the aggregated health of several components.
+type Health struct {
+	Components map[string]string `json:"components"` // Components maps component names to "ok" or the failure reason.
+	Status     string            `json:"status"`     // Status is StatusOK or StatusDegraded.
+}
+
+// Unhealthy returns the names of the unhealthy components in alphabetical order.
+//
+// Returns:
+//   - []string: The names of the unhealthy components.
+func (h Health) Unhealthy() []string {
+	var names []string
+	for name, state := range h.Components {
+		if state != StatusOK {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check asks every component for its health.
+//
+// Parameters:
+//   - components: The components keyed by name.
+//
+// Returns:
+//   - Health: The health of every component and the aggregated status.
+func Check(components map[string]Component) Health {
+	h := Health{Components: make(map[string]string, len(components)), Status: StatusOK}
+	for name, c := range components {
+		if err := c.Healthy(); err != nil {
+			h.Components[name] = err.Error()
+			h.Status = StatusDegraded
+			continue
+		}
+		h.Components[name] = StatusOK
+	}
+	return h
+}
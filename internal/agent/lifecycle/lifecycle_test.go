@@ -0,0 +1,96 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubComponent is a Component reporting a fixed health.
+type stubComponent struct {
+	err error
+	Runner
+}
+
+func (s *stubComponent) Start(ctx context.Context) {
+	s.Run(ctx, func(ctx context.Context) { <-ctx.Done() })
+}
+
+func (s *stubComponent) Healthy() error {
+	return s.err
+}
+
+func TestRunner(t *testing.T) {
+	c := &stubComponent{}
+	assert.False(t, c.Running())
+
+	done := make(chan struct{})
+	go func() {
+		c.Start(context.Background())
+		close(done)
+	}()
+	require.Eventually(t, c.Running, time.Second, time.Millisecond)
+
+	c.Stop()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Start did not return after Stop")
+	}
+	assert.False(t, c.Running())
+}
+
+func TestRunner_StopBeforeStart(t *testing.T) {
+	var r Runner
+	r.Stop()
+	assert.False(t, r.Running())
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		components    map[string]Component
+		expected      map[string]string
+		name          string
+		status        string
+		unhealthyList []string
+	}{
+		{
+			name:       "no components",
+			components: map[string]Component{},
+			expected:   map[string]string{},
+			status:     StatusOK,
+		},
+		{
+			name: "all healthy",
+			components: map[string]Component{
+				"collector": &stubComponent{},
+				"sender":    &stubComponent{},
+			},
+			expected: map[string]string{"collector": StatusOK, "sender": StatusOK},
+			status:   StatusOK,
+		},
+		{
+			name: "one unhealthy",
+			components: map[string]Component{
+				"collector": &stubComponent{},
+				"sender":    &stubComponent{err: errors.New("server unreachable")},
+			},
+			expected:      map[string]string{"collector": StatusOK, "sender": "server unreachable"},
+			status:        StatusDegraded,
+			unhealthyList: []string{"sender"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Check(tt.components)
+			assert.Equal(t, tt.status, h.Status)
+			assert.Equal(t, tt.expected, h.Components)
+			assert.Equal(t, tt.unhealthyList, h.Unhealthy())
+		})
+	}
+}
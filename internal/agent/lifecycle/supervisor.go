@@ -0,0 +1,174 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrUnexpectedExit is reported for a run that returned while its context was still live.
+var ErrUnexpectedExit = errors.New("component exited unexpectedly")
+
+// Supervisor runs a component and restarts it when a run ends unexpectedly, by panicking or by returning
+// before it was asked to stop. Restarts are delayed by a backoff that doubles after every failure up to
+// a maximum and is reset once a run lasts longer than that maximum.
+// Only panics in the goroutine running Start are recovered; components recover their own goroutines.
+type Supervisor struct {
+	component  Component          // component is the supervised component.
+	onPanic    PanicHandler       // onPanic is told about panics of the component; nil if not set.
+	logger     *zap.SugaredLogger // logger reports failures and restarts.
+	lastErr    error              // lastErr is the reason the last run ended, nil before the first failure.
+	name       string             // name identifies the component in logs.
+	life       Runner             // life tracks the run started with Start.
+	mu         sync.Mutex         // mu protects lastErr, restarts and waiting.
+	minBackoff time.Duration      // minBackoff is the delay before the first restart.
+	maxBackoff time.Duration      // maxBackoff caps the delay between restarts.
+	restarts   int                // restarts is the number of restarts so far.
+	waiting    bool               // waiting reports that a restart is pending.
+}
+
+// PanicHandler is told about a recovered panic of a supervised component.
+type PanicHandler func(name string, value any, stack []byte)
+
+// SupervisorOption configures optional Supervisor settings.
+type SupervisorOption func(*Supervisor)
+
+// WithPanicHandler passes recovered panics of the component with their stack to handler, e.g. to write crash dumps.
+//
+// Parameters:
+//   - handler: The panic handler.
+//
+// Returns:
+//   - SupervisorOption: An option applying the handler.
+func WithPanicHandler(handler PanicHandler) SupervisorOption {
+	return func(s *Supervisor) {
+		s.onPanic = handler
+	}
+}
+
+// NewSupervisor creates a supervisor for a component.
+//
+// Parameters:
+//   - name: The component name used in logs.
+//   - component: The component to supervise; it must support being started again after a run ends.
+//   - minBackoff: The delay before the first restart.
+//   - maxBackoff: The maximum delay between restarts.
+//   - logger: The logger reporting failures and restarts.
+//   - opts: Optional supervisor settings.
+//
+// Returns:
+//   - *Supervisor: The supervisor, which is itself a Component.
+func NewSupervisor(
+	name string,
+	component Component,
+	minBackoff time.Duration,
+	maxBackoff time.Duration,
+	logger *zap.SugaredLogger,
+	opts ...SupervisorOption,
+) *Supervisor {
+	s := &Supervisor{
+		component:  component,
+		logger:     logger,
+		name:       name,
+		minBackoff: minBackoff,
+		maxBackoff: max(minBackoff, maxBackoff),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Start runs the component and restarts it after unexpected exits until the context is canceled or Stop is called.
+//
+// Parameters:
+//   - ctx: The context of the run.
+func (s *Supervisor) Start(ctx context.Context) {
+	s.life.Run(ctx, s.supervise)
+}
+
+// Stop stops the supervisor and the component, which runs with a context of the supervisor run;
+// Start returns once both have stopped.
+func (s *Supervisor) Stop() {
+	s.life.Stop()
+}
+
+// Healthy reports the health of the component, or why it is not running while a restart is pending.
+//
+// Returns:
+//   - error: ErrNotRunning, an error wrapping the reason of the last exit, the component error or nil.
+func (s *Supervisor) Healthy() error {
+	if !s.life.Running() {
+		return ErrNotRunning
+	}
+	s.mu.Lock()
+	waiting, lastErr, restarts := s.waiting, s.lastErr, s.restarts
+	s.mu.Unlock()
+	if waiting {
+		return fmt.Errorf("restarting after failure %d: %w", restarts+1, lastErr)
+	}
+	return s.component.Healthy() //nolint:wrapcheck // The component reason is reported as is.
+}
+
+// Restarts returns the number of times the component was restarted.
+//
+// Returns:
+//   - int: The number of restarts.
+func (s *Supervisor) Restarts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.restarts
+}
+
+// supervise runs the component until ctx is canceled, waiting for the backoff between runs.
+func (s *Supervisor) supervise(ctx context.Context) {
+	backoff := s.minBackoff
+	for {
+		startedAt := time.Now()
+		err := s.runOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(startedAt) > s.maxBackoff {
+			backoff = s.minBackoff
+		}
+
+		s.mu.Lock()
+		s.lastErr = err
+		s.waiting = true
+		s.mu.Unlock()
+		s.logger.Errorf("Component %s failed, restarting in %s: %v", s.name, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		s.mu.Lock()
+		s.waiting = false
+		s.restarts++
+		s.mu.Unlock()
+		backoff = min(2*backoff, s.maxBackoff)
+	}
+}
+
+// runOnce runs the component once and returns why it ended.
+func (s *Supervisor) runOnce(ctx context.Context) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			if s.onPanic != nil {
+				s.onPanic(s.name, rec, debug.Stack())
+			}
+			err = fmt.Errorf("component panicked: %v", rec)
+		}
+	}()
+
+	s.component.Start(ctx)
+	return ErrUnexpectedExit
+}
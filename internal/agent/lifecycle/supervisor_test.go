@@ -0,0 +1,120 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// flakyComponent fails its first runs, by panicking or returning, and then runs until stopped.
+type flakyComponent struct {
+	life   Runner
+	runs   atomic.Int32
+	fails  int32
+	panics bool
+}
+
+func (f *flakyComponent) Start(ctx context.Context) {
+	f.life.Run(ctx, func(ctx context.Context) {
+		if f.runs.Add(1) <= f.fails {
+			if f.panics {
+				panic("boom")
+			}
+			return
+		}
+		<-ctx.Done()
+	})
+}
+
+func (f *flakyComponent) Stop() { f.life.Stop() }
+
+func (f *flakyComponent) Healthy() error {
+	if !f.life.Running() {
+		return ErrNotRunning
+	}
+	return nil
+}
+
+func TestSupervisor_Restarts(t *testing.T) {
+	tests := []struct {
+		name   string
+		panics bool
+	}{
+		{name: "Unexpected return"},
+		{name: "Panic", panics: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var panics atomic.Int32
+			c := &flakyComponent{fails: 2, panics: tt.panics}
+			s := NewSupervisor(
+				"flaky", c, time.Millisecond, 5*time.Millisecond, zap.NewNop().Sugar(),
+				WithPanicHandler(func(name string, value any, stack []byte) {
+					assert.Equal(t, "flaky", name)
+					assert.Equal(t, "boom", value)
+					assert.Contains(t, string(stack), "flakyComponent")
+					panics.Add(1)
+				}),
+			)
+			assert.ErrorIs(t, s.Healthy(), ErrNotRunning)
+
+			done := make(chan struct{})
+			go func() {
+				s.Start(context.Background())
+				close(done)
+			}()
+
+			require.Eventually(t, func() bool { return s.Healthy() == nil }, time.Second, time.Millisecond)
+			assert.Equal(t, 2, s.Restarts())
+			assert.Equal(t, int32(3), c.runs.Load())
+			if tt.panics {
+				assert.Equal(t, int32(2), panics.Load())
+			} else {
+				assert.Zero(t, panics.Load())
+			}
+
+			s.Stop()
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("Supervisor.Start did not return after Stop")
+			}
+			assert.ErrorIs(t, s.Healthy(), ErrNotRunning)
+			assert.Equal(t, int32(3), c.runs.Load())
+		})
+	}
+}
+
+func TestSupervisor_HealthWhileRestarting(t *testing.T) {
+	c := &flakyComponent{fails: 1}
+	s := NewSupervisor("flaky", c, time.Hour, time.Hour, zap.NewNop().Sugar())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Start(ctx)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		return errors.Is(s.Healthy(), ErrUnexpectedExit)
+	}, time.Second, time.Millisecond)
+	assert.ErrorContains(t, s.Healthy(), "restarting after failure 1")
+
+	// Canceling the context ends the backoff without another run.
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Supervisor.Start did not return after the context was canceled")
+	}
+	assert.Equal(t, int32(1), c.runs.Load())
+	assert.Equal(t, 0, s.Restarts())
+}
@@ -0,0 +1,80 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/agent/send/model"
+)
+
+// updatesEndpoint is the server route batches are posted to.
+const updatesEndpoint = "/updates"
+
+// DirectSender posts batches to a server as plain JSON, without the compression, signing and encryption
+// of the agent's sender. Replaying a recording with both tells whether a failure comes from the metrics
+// themselves or from how the sender encodes them.
+type DirectSender struct {
+	client *http.Client // client sends the requests.
+	url    string       // url is the URL of the updates endpoint.
+}
+
+// NewDirectSender creates a DirectSender.
+//
+// Parameters:
+//   - serverAddress: The address of the server, with or without a scheme.
+//   - client: The HTTP client; nil uses http.DefaultClient.
+//
+// Returns:
+//   - *DirectSender: The sender.
+func NewDirectSender(serverAddress string, client *http.Client) *DirectSender {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if !strings.Contains(serverAddress, "://") {
+		serverAddress = "http://" + serverAddress
+	}
+	return &DirectSender{client: client, url: strings.TrimSuffix(serverAddress, "/") + updatesEndpoint}
+}
+
+// SendBatch posts a batch to the server.
+//
+// Parameters:
+//   - ctx: The context of the request.
+//   - metrics: The batch.
+//
+// Returns:
+//   - error: An error if the batch cannot be encoded or sent, or the server does not accept it.
+func (s *DirectSender) SendBatch(ctx context.Context, metrics *entity.Metrics) error {
+	models, err := model.NewFromEntityMetrics(metrics)
+	if err != nil {
+		return fmt.Errorf("conversion of metrics to models failed: %w", err)
+	}
+	body, err := json.Marshal(models)
+	if err != nil {
+		return fmt.Errorf("serialization of metrics to JSON failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request execution failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		reply, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unsuccessful response from server: status code %s: %s",
+			resp.Status, strings.TrimSpace(string(reply)))
+	}
+	return nil
+}
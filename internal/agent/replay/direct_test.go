@@ -0,0 +1,54 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/agent/send/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectSender(t *testing.T) {
+	var received model.Metrics
+	var header http.Header
+	status := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/updates", r.URL.Path)
+		header = r.Header.Clone()
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("metric rejected\n"))
+	}))
+	defer ts.Close()
+
+	sender := NewDirectSender(ts.URL+"/", ts.Client())
+	batch := entity.Metrics{
+		{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(3)},
+		{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 2.5},
+	}
+	require.NoError(t, sender.SendBatch(context.Background(), &batch))
+	require.Len(t, received, 2)
+	assert.Equal(t, int64(3), *received[0].Delta)
+	assert.Equal(t, 2.5, *received[1].Value)
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Empty(t, header.Get("Content-Encoding"), "the body is sent as is")
+
+	status = http.StatusBadRequest
+	err := sender.SendBatch(context.Background(), &batch)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400 Bad Request: metric rejected")
+}
+
+func TestNewDirectSender_Scheme(t *testing.T) {
+	assert.Equal(t, "http://localhost:8080/updates", NewDirectSender("localhost:8080", nil).url)
+	assert.Equal(t, "https://metrics.example.com/updates", NewDirectSender("https://metrics.example.com", nil).url)
+}
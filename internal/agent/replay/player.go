@@ -0,0 +1,195 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/clock"
+)
+
+// maxLineSize bounds the size of a recorded batch, so a corrupt recording cannot exhaust the memory.
+const maxLineSize = 64 << 20
+
+// Sender sends replayed batches, e.g. the agent's StreamSender or a DirectSender.
+type Sender interface {
+	// SendBatch sends a batch of metrics.
+	SendBatch(ctx context.Context, metrics *entity.Metrics) error
+}
+
+// Player plays recordings back.
+type Player struct {
+	clock  clock.Clock // clock paces the replay; tests replace it.
+	speed  float64     // speed is the replay pace relative to the recording; 0 replays without waiting.
+	retime bool        // retime shifts the metric timestamps so the first batch appears collected now.
+}
+
+// PlayerOption configures optional Player settings.
+type PlayerOption func(*Player)
+
+// WithSpeed replays the batches at the pace they were recorded at, scaled by speed: 1 keeps the original
+// pace and 2 replays twice as fast. 0, the default, replays the batches one after another without waiting.
+//
+// Parameters:
+//   - speed: The pace relative to the recording.
+//
+// Returns:
+//   - PlayerOption: An option applying the pace.
+func WithSpeed(speed float64) PlayerOption {
+	return func(p *Player) {
+		p.speed = speed
+	}
+}
+
+// WithRetime shifts the timestamps of the replayed metrics by the time passed since the recording, so the
+// server stores replayed gauges rather than dropping them as older than the values it already has.
+//
+// Returns:
+//   - PlayerOption: An option enabling the shift.
+func WithRetime() PlayerOption {
+	return func(p *Player) {
+		p.retime = true
+	}
+}
+
+// NewPlayer creates a Player.
+//
+// Parameters:
+//   - opts: Optional settings.
+//
+// Returns:
+//   - *Player: The player.
+//   - error: An error if the speed is negative.
+func NewPlayer(opts ...PlayerOption) (*Player, error) {
+	p := &Player{clock: clock.Real()}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.speed < 0 {
+		return nil, fmt.Errorf("replay speed %g must not be negative", p.speed)
+	}
+	return p, nil
+}
+
+// Play sends the batches of a recording in order. It stops at the first batch that cannot be sent, so the
+// failing batch is the last one sent.
+//
+// Parameters:
+//   - ctx: The context of the replay.
+//   - recording: The recording.
+//   - sender: The sender of the batches.
+//
+// Returns:
+//   - int: The number of batches sent.
+//   - error: An error if the recording is invalid, a batch cannot be sent or the context is canceled.
+func (p *Player) Play(ctx context.Context, recording io.Reader, sender Sender) (int, error) {
+	scanner := bufio.NewScanner(recording)
+	scanner.Buffer(nil, maxLineSize)
+
+	var first time.Time
+	var shift time.Duration
+	start := p.clock.Now()
+	sent := 0
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		batch, err := decodeBatch(scanner.Bytes())
+		if err != nil {
+			return sent, fmt.Errorf("invalid batch on line %d: %w", line, err)
+		}
+		if first.IsZero() {
+			first = batch.RecordedAt
+			shift = start.Sub(first)
+		}
+		if err := p.wait(ctx, start, batch.RecordedAt.Sub(first)); err != nil {
+			return sent, err
+		}
+
+		metrics, err := batch.entities()
+		if err != nil {
+			return sent, fmt.Errorf("invalid batch on line %d: %w", line, err)
+		}
+		if p.retime {
+			for _, m := range metrics {
+				if !m.Timestamp.IsZero() {
+					m.Timestamp = m.Timestamp.Add(shift)
+				}
+			}
+		}
+		if err := sender.SendBatch(ctx, &metrics); err != nil {
+			return sent, fmt.Errorf("failed to send batch on line %d: %w", line, err)
+		}
+		sent++
+	}
+	if err := scanner.Err(); err != nil {
+		return sent, fmt.Errorf("failed to read recording: %w", err)
+	}
+	return sent, nil
+}
+
+// wait waits until a batch recorded offset after the first one is due.
+func (p *Player) wait(ctx context.Context, start time.Time, offset time.Duration) error {
+	if p.speed == 0 || offset <= 0 {
+		return nil
+	}
+	delay := time.Duration(float64(offset)/p.speed) - p.clock.Since(start)
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-p.clock.After(delay):
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("replay canceled: %w", ctx.Err())
+	}
+}
+
+// decodeBatch decodes a recorded batch, keeping the values as numbers so counters are restored exactly.
+func decodeBatch(line []byte) (*Batch, error) {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	var batch Batch
+	if err := decoder.Decode(&batch); err != nil {
+		return nil, fmt.Errorf("failed to decode batch: %w", err)
+	}
+	return &batch, nil
+}
+
+// entities converts the recorded metrics back to the metrics the collector produced.
+func (b *Batch) entities() (entity.Metrics, error) {
+	metrics := make(entity.Metrics, 0, len(b.Metrics))
+	for _, m := range b.Metrics {
+		number, ok := m.Value.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("metric %s has a non-numeric value %v", m.Name, m.Value)
+		}
+		var value any
+		var err error
+		switch m.Type {
+		case entity.MetricTypeCounter:
+			value, err = number.Int64()
+		case entity.MetricTypeGauge, entity.MetricTypeFloatCounter:
+			value, err = number.Float64()
+		default:
+			err = errors.New("unknown metric type " + m.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("metric %s: %w", m.Name, err)
+		}
+		metrics = append(metrics, &entity.Metric{
+			Timestamp:  m.Timestamp,
+			Value:      value,
+			Name:       m.Name,
+			Type:       m.Type,
+			IsMetadata: m.Metadata,
+		})
+	}
+	return metrics, nil
+}
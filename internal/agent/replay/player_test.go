@@ -0,0 +1,164 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubSender keeps the batches it is given and fails from the batch numbered failAt, counted from 1.
+type stubSender struct {
+	batches []entity.Metrics
+	failAt  int
+	mu      sync.Mutex
+}
+
+func (s *stubSender) SendBatch(_ context.Context, metrics *entity.Metrics) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failAt > 0 && len(s.batches)+1 >= s.failAt {
+		return errors.New("server is down")
+	}
+	s.batches = append(s.batches, *metrics)
+	return nil
+}
+
+func (s *stubSender) sent() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.batches)
+}
+
+const recording = `{"recorded_at":"2024-05-01T12:00:00Z","strategy":"memstats","metrics":[` +
+	`{"timestamp":"2024-05-01T11:59:59Z","value":9007199254740993,"name":"PollCount","type":"counter"},` +
+	`{"timestamp":"0001-01-01T00:00:00Z","value":1.5,"name":"Alloc","type":"gauge","metadata":true}]}` + "\n\n" +
+	`{"recorded_at":"2024-05-01T12:00:10Z","metrics":[` +
+	`{"timestamp":"2024-05-01T12:00:10Z","value":2,"name":"Alloc","type":"gauge"}]}` + "\n"
+
+func TestPlayer_Play(t *testing.T) {
+	player, err := NewPlayer()
+	require.NoError(t, err)
+	sender := &stubSender{}
+
+	sent, err := player.Play(context.Background(), strings.NewReader(recording), sender)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	require.Len(t, sender.batches, 2)
+
+	first := sender.batches[0]
+	require.Len(t, first, 2)
+	assert.Equal(t, &entity.Metric{
+		Timestamp: time.Date(2024, 5, 1, 11, 59, 59, 0, time.UTC),
+		Value:     int64(9007199254740993),
+		Name:      "PollCount",
+		Type:      entity.MetricTypeCounter,
+	}, first[0], "counters are restored exactly, beyond the precision of float64")
+	assert.Equal(t, &entity.Metric{Value: 1.5, Name: "Alloc", Type: entity.MetricTypeGauge, IsMetadata: true}, first[1])
+	assert.Equal(t, 2.0, sender.batches[1][0].Value)
+}
+
+func TestPlayer_Retime(t *testing.T) {
+	now := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	player, err := NewPlayer(WithRetime())
+	require.NoError(t, err)
+	player.clock = clock.NewFake(now)
+	sender := &stubSender{}
+
+	_, err = player.Play(context.Background(), strings.NewReader(recording), sender)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-time.Second), sender.batches[0][0].Timestamp, "the first batch appears recorded now")
+	assert.True(t, sender.batches[0][1].Timestamp.IsZero(), "unknown timestamps stay unknown")
+	assert.Equal(t, now.Add(10*time.Second), sender.batches[1][0].Timestamp)
+}
+
+func TestPlayer_Speed(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	player, err := NewPlayer(WithSpeed(2))
+	require.NoError(t, err)
+	player.clock = fake
+	sender := &stubSender{}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := player.Play(context.Background(), strings.NewReader(recording), sender)
+		done <- err
+	}()
+
+	// The second batch was recorded 10s after the first, so at double speed it is due after 5s.
+	fake.BlockUntil(1)
+	assert.Equal(t, 1, sender.sent())
+	fake.Advance(4 * time.Second)
+	assert.Equal(t, 1, sender.sent())
+	fake.Advance(time.Second)
+	require.NoError(t, <-done)
+	assert.Equal(t, 2, sender.sent())
+}
+
+func TestPlayer_Canceled(t *testing.T) {
+	player, err := NewPlayer(WithSpeed(1))
+	require.NoError(t, err)
+	player.clock = clock.NewFake(time.Now())
+	ctx, cancel := context.WithCancel(context.Background())
+	sender := &stubSender{}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := player.Play(ctx, strings.NewReader(recording), sender)
+		done <- err
+	}()
+	player.clock.(*clock.Fake).BlockUntil(1)
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+	assert.Equal(t, 1, sender.sent())
+}
+
+func TestPlayer_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		recording string
+		failAt    int
+		sent      int
+		expected  string
+	}{
+		{name: "Invalid JSON", recording: "{\n", expected: "line 1"},
+		{
+			name:      "Unknown type",
+			recording: `{"metrics":[{"value":1,"name":"x","type":"histogram"}]}`,
+			expected:  "unknown metric type histogram",
+		},
+		{
+			name:      "Fractional counter",
+			recording: `{"metrics":[{"value":1.5,"name":"x","type":"counter"}]}`,
+			expected:  "metric x",
+		},
+		{
+			name:      "Non-numeric value",
+			recording: `{"metrics":[{"value":"1","name":"x","type":"gauge"}]}`,
+			expected:  "non-numeric value",
+		},
+		{name: "Send failure", recording: recording, failAt: 2, sent: 1, expected: "failed to send batch on line 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			player, err := NewPlayer()
+			require.NoError(t, err)
+			sent, err := player.Play(context.Background(), strings.NewReader(tt.recording), &stubSender{failAt: tt.failAt})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expected)
+			assert.Equal(t, tt.sent, sent)
+		})
+	}
+}
+
+func TestNewPlayer_NegativeSpeed(t *testing.T) {
+	_, err := NewPlayer(WithSpeed(-1))
+	assert.Error(t, err)
+}
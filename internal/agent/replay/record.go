@@ -0,0 +1,121 @@
+// Package replay records the batches the agent collects to a file and plays them back later, through
+// the metrics sender or straight to a server. A recording captures batches before they are converted
+// to the wire format, so replaying one reproduces the serialization, compression and encryption of the
+// original run exactly, which makes bugs in them reproducible without the host that triggered them.
+//
+// A recording is a JSON Lines file with one batch per line.
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+)
+
+// ErrClosed is returned when a batch is recorded after the recorder was closed.
+var ErrClosed = errors.New("recorder is closed")
+
+// Batch is a recorded batch.
+type Batch struct {
+	RecordedAt time.Time `json:"recorded_at"`        // RecordedAt is when the batch left the collector.
+	Strategy   string    `json:"strategy,omitempty"` // Strategy names the strategy that collected the batch.
+	Metrics    []Metric  `json:"metrics"`            // Metrics are the metrics of the batch.
+}
+
+// Metric is a recorded metric. Its value is restored by type on replay: counters as int64, gauges as float64.
+type Metric struct {
+	Timestamp time.Time `json:"timestamp"`          // Timestamp is when the metric was collected; zero if unknown.
+	Value     any       `json:"value"`              // Value is the value of the metric.
+	Name      string    `json:"name"`               // Name is the name of the metric.
+	Type      string    `json:"type"`               // Type is the type of the metric.
+	Metadata  bool      `json:"metadata,omitempty"` // Metadata is true for metadata metrics.
+}
+
+// Recorder appends collected batches to a recording. It is safe for concurrent use, as batches of
+// several strategies are collected concurrently.
+type Recorder struct {
+	file *os.File         // file is the recording; nil once the recorder is closed.
+	now  func() time.Time // now returns the current time; tests replace it.
+	mu   sync.Mutex       // mu serializes the writes, so lines of concurrent batches do not interleave.
+}
+
+// NewRecorder opens a recording for appending, creating it if it does not exist.
+//
+// Parameters:
+//   - path: The path of the recording.
+//
+// Returns:
+//   - *Recorder: The recorder.
+//   - error: An error if the file cannot be opened.
+func NewRecorder(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording %s: %w", path, err)
+	}
+	return &Recorder{file: file, now: time.Now}, nil
+}
+
+// Record appends a batch to the recording. Every batch is written with a single write, so a recording
+// cut short by a crash loses at most its last line.
+//
+// Parameters:
+//   - strategy: The name of the strategy that collected the batch.
+//   - metrics: The batch.
+//
+// Returns:
+//   - error: ErrClosed, or an error if the batch cannot be encoded or written.
+func (r *Recorder) Record(strategy string, metrics *entity.Metrics) error {
+	batch := Batch{RecordedAt: r.now(), Strategy: strategy, Metrics: make([]Metric, 0, metrics.Length())}
+	if metrics != nil {
+		for _, m := range *metrics {
+			if m == nil {
+				continue
+			}
+			batch.Metrics = append(batch.Metrics, Metric{
+				Timestamp: m.Timestamp,
+				Value:     m.Value,
+				Name:      m.Name,
+				Type:      m.Type,
+				Metadata:  m.IsMetadata,
+			})
+		}
+	}
+	line, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to encode batch: %w", err)
+	}
+	line = append(line, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return ErrClosed
+	}
+	if _, err := r.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write batch: %w", err)
+	}
+	return nil
+}
+
+// Close closes the recording. Closing a closed recorder does nothing.
+//
+// Returns:
+//   - error: An error if the file cannot be closed.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to close recording: %w", err)
+	}
+	return nil
+}
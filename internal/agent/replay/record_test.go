@@ -0,0 +1,91 @@
+package replay
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	recordedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	collected := recordedAt.Add(-time.Second)
+
+	recorder, err := NewRecorder(path)
+	require.NoError(t, err)
+	recorder.now = func() time.Time { return recordedAt }
+	batch := entity.Metrics{
+		{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(5), Timestamp: collected},
+		nil,
+		{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.5, IsMetadata: true},
+	}
+	require.NoError(t, recorder.Record("memstats", &batch))
+	require.NoError(t, recorder.Close())
+	require.NoError(t, recorder.Close(), "closing twice does nothing")
+	require.ErrorIs(t, recorder.Record("memstats", &batch), ErrClosed)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"recorded_at": "2024-05-01T12:00:00Z",
+		"strategy": "memstats",
+		"metrics": [
+			{"timestamp": "2024-05-01T11:59:59Z", "value": 5, "name": "PollCount", "type": "counter"},
+			{"timestamp": "0001-01-01T00:00:00Z", "value": 1.5, "name": "Alloc", "type": "gauge", "metadata": true}
+		]
+	}`, string(data))
+	assert.True(t, strings.HasSuffix(string(data), "\n"))
+
+	// Reopening appends to the recording.
+	recorder, err = NewRecorder(path)
+	require.NoError(t, err)
+	require.NoError(t, recorder.Record("memstats", &batch))
+	require.NoError(t, recorder.Close())
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "\n"))
+}
+
+func TestRecorder_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.jsonl")
+	recorder, err := NewRecorder(path)
+	require.NoError(t, err)
+
+	const writers, batches = 8, 25
+	var wg sync.WaitGroup
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range batches {
+				batch := entity.Metrics{{Name: "m", Type: entity.MetricTypeGauge, Value: float64(i)}}
+				assert.NoError(t, recorder.Record("s", &batch))
+			}
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, recorder.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+	sender := &stubSender{}
+	player, err := NewPlayer()
+	require.NoError(t, err)
+	sent, err := player.Play(context.Background(), file, sender)
+	require.NoError(t, err, "lines of concurrent batches do not interleave")
+	assert.Equal(t, writers*batches, sent)
+}
+
+func TestNewRecorder_Error(t *testing.T) {
+	_, err := NewRecorder(filepath.Join(t.TempDir(), "missing", "recording.jsonl"))
+	assert.Error(t, err)
+}
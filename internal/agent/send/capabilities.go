@@ -0,0 +1,111 @@
+package send
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/send/model"
+	"github.com/gdyunin/metricol.git/pkg/x25519box"
+)
+
+const (
+	// Const capabilitiesEndpoint defines the API endpoint describing the options the server supports.
+	capabilitiesEndpoint = "/api/capabilities"
+	// Const capabilitiesTimeout bounds the capabilities request, so an old server does not delay the startup.
+	capabilitiesTimeout = 5 * time.Second
+
+	encodingGzip        = "gzip"
+	transportBatch      = "json-batch"
+	transportJSON       = "json"
+	signingHMACSHA256   = "hmac-sha256"
+	encryptionRSAAESGCM = "rsa-aes-gcm"
+)
+
+// FetchCapabilities asks the server which encodings, transports, signing algorithms and limits it supports.
+// It makes a single attempt: servers predating the endpoint answer with an error, and the agent keeps its defaults.
+//
+// Parameters:
+//   - ctx: The context controlling the request lifecycle.
+//   - serverAddress: The server address.
+//   - opts: Optional client settings, e.g. WithClientTLS.
+//
+// Returns:
+//   - *model.Capabilities: The capabilities of the server.
+//   - error: An error if the request fails or the server does not report its capabilities.
+func FetchCapabilities(ctx context.Context, serverAddress string, opts ...ClientOption) (*model.Capabilities, error) {
+	client := newClient(serverAddress, opts).SetTimeout(capabilitiesTimeout)
+
+	var caps model.Capabilities
+	resp, err := client.R().SetContext(ctx).SetResult(&caps).Get(capabilitiesEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("capabilities request failed: %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("capabilities request failed: unexpected status code %d", resp.StatusCode())
+	}
+	return &caps, nil
+}
+
+// WithCapabilities adapts the sender to the server capabilities: bodies are compressed only if the server
+// accepts gzip, and metrics are sent one by one if the server has no batch updates. Signing and encryption
+// are never turned off; a mismatch is only logged, since the server would reject or misread such requests.
+// Nil capabilities keep the defaults.
+//
+// Parameters:
+//   - caps: The capabilities reported by the server.
+//
+// Returns:
+//   - Option: An option applying the negotiated settings.
+func WithCapabilities(caps *model.Capabilities) Option {
+	return func(s *StreamSender) {
+		if caps == nil {
+			return
+		}
+		s.negotiate(caps)
+	}
+}
+
+// negotiate selects the best options supported by both the agent and the server.
+func (s *StreamSender) negotiate(caps *model.Capabilities) {
+	compression := slices.Contains(caps.Encodings, encodingGzip)
+	s.requestBuilder.SetCompression(compression)
+	if !compression {
+		s.httpClient.Header.Del("Accept-Encoding")
+	}
+
+	switch {
+	case slices.Contains(caps.Transports, transportBatch):
+		s.singleMetrics = false
+	case slices.Contains(caps.Transports, transportJSON):
+		s.singleMetrics = true
+	default:
+		s.logger.Warnf("Server reports no known transport in %v, keeping batch updates", caps.Transports)
+	}
+
+	signingKey, cryptoKey := s.keys.keys()
+	if signingKey != "" && !slices.Contains(caps.Signing, signingHMACSHA256) {
+		s.logger.Warnf("Server does not support %s signing, requests may be rejected", signingHMACSHA256)
+	}
+	if cryptoKey != "" {
+		scheme := encryptionRSAAESGCM
+		if x25519box.IsPublicKey(cryptoKey) {
+			scheme = x25519box.Scheme
+		}
+		if !slices.Contains(caps.Encryption, scheme) {
+			s.logger.Warnf("Server does not have %s encryption enabled, requests may be rejected", scheme)
+		}
+	}
+
+	s.logger.Infow(
+		"Negotiated options with server",
+		"compression", compression,
+		"batch", !s.singleMetrics,
+		"min_agent_version", caps.Limits.MinAgentVersion,
+		"metric_rate_limit", caps.Limits.MetricRateLimit,
+		"max_series", caps.Limits.MaxSeries,
+		"max_clock_skew_seconds", caps.Limits.MaxClockSkewSeconds,
+	)
+}
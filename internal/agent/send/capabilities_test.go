@@ -0,0 +1,148 @@
+package send
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/agent/send/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestFetchCapabilities(t *testing.T) {
+	expected := model.Capabilities{
+		Encodings:  []string{"gzip", "identity"},
+		Transports: []string{"json-batch", "json"},
+		Signing:    []string{"hmac-sha256"},
+		Encryption: []string{},
+		Limits:     model.CapabilityLimits{MetricRateLimit: 5, MinAgentVersion: "1.0.0"},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != capabilitiesEndpoint {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(expected)
+	}))
+	defer srv.Close()
+
+	caps, err := FetchCapabilities(context.Background(), srv.URL)
+	require.NoError(t, err)
+	assert.Equal(t, expected, *caps)
+}
+
+func TestFetchCapabilitiesUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	caps, err := FetchCapabilities(context.Background(), srv.URL)
+	assert.Error(t, err)
+	assert.Nil(t, caps)
+}
+
+func TestWithCapabilities(t *testing.T) {
+	tests := []struct {
+		caps             *model.Capabilities
+		name             string
+		expectedPaths    []string
+		expectedEncoding string
+	}{
+		{
+			name:             "no capabilities keeps defaults",
+			caps:             nil,
+			expectedPaths:    []string{updateBatchEndpoint},
+			expectedEncoding: "gzip",
+		},
+		{
+			name: "gzip and batch",
+			caps: &model.Capabilities{
+				Encodings:  []string{"gzip", "identity"},
+				Transports: []string{"json-batch", "json", "uri"},
+			},
+			expectedPaths:    []string{updateBatchEndpoint},
+			expectedEncoding: "gzip",
+		},
+		{
+			name: "identity and single metrics",
+			caps: &model.Capabilities{
+				Encodings:  []string{"identity"},
+				Transports: []string{"json", "uri"},
+			},
+			expectedPaths:    []string{updateEndpoint, updateEndpoint},
+			expectedEncoding: "",
+		},
+		{
+			name: "unknown transports keep batch",
+			caps: &model.Capabilities{
+				Encodings:  []string{"gzip"},
+				Transports: []string{"grpc"},
+			},
+			expectedPaths:    []string{updateBatchEndpoint},
+			expectedEncoding: "gzip",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu        sync.Mutex
+				paths     []string
+				encodings []string
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(io.Discard, r.Body)
+				mu.Lock()
+				paths = append(paths, r.URL.Path)
+				encodings = append(encodings, r.Header.Get("Content-Encoding"))
+				mu.Unlock()
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer srv.Close()
+
+			sender := NewStreamSender(
+				make(chan *entity.Metrics), time.Second, 1, srv.URL, "", "", zap.NewNop().Sugar(),
+				WithCapabilities(tt.caps),
+			)
+			metrics := &entity.Metrics{
+				{Name: "g", Type: entity.MetricTypeGauge, Value: 1.0},
+				{Name: "c", Type: entity.MetricTypeCounter, Value: int64(1)},
+			}
+			require.NoError(t, sender.SendBatch(context.Background(), metrics))
+
+			assert.Equal(t, tt.expectedPaths, paths)
+			for _, encoding := range encodings {
+				assert.Equal(t, tt.expectedEncoding, encoding)
+			}
+		})
+	}
+}
+
+func TestWithCapabilities_SingleMetricBody(t *testing.T) {
+	var got model.Metric
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	sender := NewStreamSender(
+		make(chan *entity.Metrics), time.Second, 1, srv.URL, "", "", zap.NewNop().Sugar(),
+		WithCapabilities(&model.Capabilities{Encodings: []string{"identity"}, Transports: []string{"json"}}),
+	)
+	metrics := &entity.Metrics{{Name: "g", Type: entity.MetricTypeGauge, Value: 2.5}}
+	require.NoError(t, sender.SendBatch(context.Background(), metrics))
+
+	assert.Equal(t, "g", got.ID)
+	assert.Equal(t, entity.MetricTypeGauge, got.MType)
+	require.NotNil(t, got.Value)
+	assert.InDelta(t, 2.5, *got.Value, 0)
+}
@@ -0,0 +1,113 @@
+package send
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	// Const timeEndpoint defines the API endpoint serving the server clock.
+	timeEndpoint = "/api/time"
+	// Const clockProbeTimeout bounds a single clock measurement.
+	clockProbeTimeout = 5 * time.Second
+)
+
+// Server clock sources the local clock can be compared with.
+const (
+	// ClockSourceTime reads the server clock from the /api/time endpoint, with sub-second precision.
+	ClockSourceTime = "time"
+	// ClockSourceDate reads the server clock from the Date header, which every server sends with one-second
+	// precision.
+	ClockSourceDate = "date"
+)
+
+// ServerClock measures the offset of the local clock against the server clock. Like NTP, it assumes
+// the server read its clock halfway through the round trip.
+type ServerClock struct {
+	client *resty.Client    // client sends the measurement requests.
+	now    func() time.Time // now reads the local clock.
+	source string           // source is ClockSourceTime or ClockSourceDate.
+}
+
+// NewServerClock creates a ServerClock reading the server clock from the given source.
+//
+// Parameters:
+//   - serverAddress: The server address.
+//   - source: ClockSourceTime or ClockSourceDate.
+//   - opts: Optional client settings, e.g. WithClientTLS.
+//
+// Returns:
+//   - *ServerClock: A pointer to the created ServerClock.
+//   - error: An error if the source is unknown.
+func NewServerClock(serverAddress string, source string, opts ...ClientOption) (*ServerClock, error) {
+	if source != ClockSourceTime && source != ClockSourceDate {
+		return nil, fmt.Errorf("unknown clock source %q, use %q or %q", source, ClockSourceTime, ClockSourceDate)
+	}
+	return &ServerClock{
+		client: newClient(serverAddress, opts).SetTimeout(clockProbeTimeout),
+		now:    time.Now,
+		source: source,
+	}, nil
+}
+
+// Measure compares the local clock with the server clock once.
+//
+// Parameters:
+//   - ctx: The context controlling the request lifecycle.
+//
+// Returns:
+//   - time.Duration: The offset of the local clock, positive when it is ahead of the server.
+//   - time.Duration: The round trip of the request, which bounds the measurement error.
+//   - error: An error if the server clock cannot be read.
+func (c *ServerClock) Measure(ctx context.Context) (time.Duration, time.Duration, error) {
+	sent := c.now()
+	server, err := c.read(ctx)
+	received := c.now()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	roundTrip := received.Sub(sent)
+	midpoint := sent.Add(roundTrip / 2)
+	return midpoint.Sub(server), roundTrip, nil
+}
+
+// read requests the server clock from the configured source.
+func (c *ServerClock) read(ctx context.Context) (time.Time, error) {
+	if c.source == ClockSourceDate {
+		return c.readDate(ctx)
+	}
+
+	var body struct {
+		UnixNano int64 `json:"unix_nano"`
+	}
+	resp, err := c.client.R().SetContext(ctx).SetResult(&body).Get(timeEndpoint)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to request server time: %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return time.Time{}, fmt.Errorf(
+			"unexpected status code %d from %s, servers without it need the %q clock source",
+			resp.StatusCode(), timeEndpoint, ClockSourceDate,
+		)
+	}
+	return time.Unix(0, body.UnixNano), nil
+}
+
+// readDate reads the Date header of a HEAD request. Any response carries it, so the status is ignored.
+// The header is truncated to the second, so half a second is added to center the error.
+func (c *ServerClock) readDate(ctx context.Context) (time.Time, error) {
+	resp, err := c.client.R().SetContext(ctx).Head(timeEndpoint)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to request server date: %w", err)
+	}
+	date, err := http.ParseTime(resp.Header().Get("Date"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid Date header: %w", err)
+	}
+	return date.Add(time.Second / 2), nil
+}
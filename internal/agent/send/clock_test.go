@@ -0,0 +1,88 @@
+package send
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock returns the given times one after another.
+func fakeClock(times ...time.Time) func() time.Time {
+	return func() time.Time {
+		t := times[0]
+		times = times[1:]
+		return t
+	}
+}
+
+func TestServerClock_Measure(t *testing.T) {
+	serverTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		source    string
+		handler   http.HandlerFunc
+		offset    time.Duration
+		expectErr bool
+	}{
+		{
+			name:   "Time endpoint",
+			source: ClockSourceTime,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, timeEndpoint, r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"unix_nano":` + strconv.FormatInt(serverTime.UnixNano(), 10) + `}`))
+			},
+			offset: 11 * time.Second,
+		},
+		{
+			name:   "Date header",
+			source: ClockSourceDate,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodHead, r.Method)
+				w.Header().Set("Date", serverTime.Format(http.TimeFormat))
+				w.WriteHeader(http.StatusNotFound)
+			},
+			offset: 11*time.Second - time.Second/2,
+		},
+		{
+			name:   "Server without time endpoint",
+			source: ClockSourceTime,
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(tt.handler)
+			defer ts.Close()
+
+			clock, err := NewServerClock(ts.URL, tt.source)
+			require.NoError(t, err)
+			clock.now = fakeClock(serverTime.Add(10*time.Second), serverTime.Add(12*time.Second))
+
+			offset, roundTrip, err := clock.Measure(context.Background())
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.offset, offset)
+			assert.Equal(t, 2*time.Second, roundTrip)
+		})
+	}
+}
+
+func TestNewServerClock_UnknownSource(t *testing.T) {
+	_, err := NewServerClock("localhost:8080", "ntp")
+	assert.Error(t, err)
+}
@@ -57,5 +57,6 @@ func (c *Compressor) Compress(data []byte) ([]byte, error) {
 		return nil, fmt.Errorf("compression error: unable to close gzip writer: %w", err)
 	}
 
-	return c.buf.Bytes(), nil
+	// The buffer is reused by the next call, possibly while the returned data is still being sent.
+	return bytes.Clone(c.buf.Bytes()), nil
 }
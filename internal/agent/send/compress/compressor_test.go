@@ -76,6 +76,22 @@ func TestCompressor_Compress(t *testing.T) {
 	}
 }
 
+func TestCompressor_CompressKeepsEarlierResults(t *testing.T) {
+	compressor := NewCompressor()
+	first, err := compressor.Compress(bytes.Repeat([]byte("first"), 100))
+	require.NoError(t, err)
+	_, err = compressor.Compress(bytes.Repeat([]byte("second"), 100))
+	require.NoError(t, err)
+
+	// The first result may still be being sent while the next batch is compressed.
+	reader, err := gzip.NewReader(bytes.NewReader(first))
+	require.NoError(t, err)
+	decompressedData, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte("first"), 100), decompressedData)
+	assert.NoError(t, reader.Close())
+}
+
 func BenchmarkCompressor_Compress(b *testing.B) {
 	compressor := NewCompressor()
 	inputBytes := bytes.Repeat([]byte("a"), 10000)
@@ -1,4 +1,4 @@
 // Package send provides functionality for building and sending HTTP requests,
-// including support for gzip compression and request signing.
+// including support for gzip compression, request signing and verification of signed responses.
 // It utilizes the resty HTTP client library for constructing and executing requests.
 package send
@@ -0,0 +1,70 @@
+package send
+
+import "github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+
+const (
+	// Const errorWindowSize is the number of latest sends the error rate is computed over.
+	errorWindowSize = 20
+	// Const minWindowSends is the number of sends in the window needed before the sender may switch modes.
+	minWindowSends = 5
+	// Const degradeErrorRate is the error rate at which the sender switches to degraded mode.
+	degradeErrorRate = 0.5
+	// Const recoverErrorRate is the error rate at or below which a degraded sender switches back to normal mode.
+	recoverErrorRate = 0.1
+	// Const degradedIntervalFactor multiplies the send interval in degraded mode.
+	degradedIntervalFactor = 4
+	// Const degradedBatchSize is the maximum number of metrics sent in one request in degraded mode.
+	degradedBatchSize = 50
+)
+
+// errorBudget tracks the error rate of the latest sends and decides whether the sender is degraded.
+// The window is cleared on every switch, so each mode is judged only by the sends made in it.
+// The zero value is ready to use and starts in normal mode.
+type errorBudget struct {
+	window   [errorWindowSize]bool // window holds the outcomes of the latest sends, true for failures.
+	next     int                   // next is the window slot the next outcome is written to.
+	sends    int                   // sends is the number of outcomes in the window.
+	failures int                   // failures is the number of failed sends in the window.
+	rate     float64               // rate is the error rate that caused the last switch.
+	degraded bool                  // degraded reports that the sender runs in degraded mode.
+}
+
+// record adds the outcome of a send and switches modes once the error rate crosses a threshold.
+// It returns whether the mode changed.
+func (b *errorBudget) record(failed bool) bool {
+	if b.sends == errorWindowSize {
+		if b.window[b.next] {
+			b.failures--
+		}
+	} else {
+		b.sends++
+	}
+	b.window[b.next] = failed
+	b.next = (b.next + 1) % errorWindowSize
+	if failed {
+		b.failures++
+	}
+
+	if b.sends < minWindowSends {
+		return false
+	}
+	rate := float64(b.failures) / float64(b.sends)
+	if (!b.degraded && rate >= degradeErrorRate) || (b.degraded && rate <= recoverErrorRate) {
+		*b = errorBudget{degraded: !b.degraded, rate: rate}
+		return true
+	}
+	return false
+}
+
+// splitBatch splits metrics into batches of at most size metrics.
+func splitBatch(metrics *entity.Metrics, size int) []*entity.Metrics {
+	if metrics.Length() <= size {
+		return []*entity.Metrics{metrics}
+	}
+	batches := make([]*entity.Metrics, 0, (metrics.Length()+size-1)/size)
+	for start := 0; start < metrics.Length(); start += size {
+		batch := (*metrics)[start:min(start+size, metrics.Length())]
+		batches = append(batches, &batch)
+	}
+	return batches
+}
@@ -0,0 +1,83 @@
+package send
+
+import (
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorBudget_Record(t *testing.T) {
+	var b errorBudget
+
+	// Too few sends to judge the error rate.
+	for range minWindowSends - 1 {
+		assert.False(t, b.record(true))
+	}
+	assert.False(t, b.degraded)
+
+	assert.True(t, b.record(true))
+	assert.True(t, b.degraded)
+	assert.InDelta(t, 1.0, b.rate, 1e-9)
+	assert.Zero(t, b.sends, "the window is cleared on a switch")
+
+	// One failure in the degraded window keeps the rate above the recovery threshold.
+	assert.False(t, b.record(true))
+	for range minWindowSends - 1 {
+		assert.False(t, b.record(false))
+	}
+	assert.True(t, b.degraded)
+	for range minWindowSends - 1 {
+		assert.False(t, b.record(false))
+	}
+	assert.True(t, b.record(false), "one failure in ten sends is at the recovery threshold")
+	assert.False(t, b.degraded)
+	assert.InDelta(t, recoverErrorRate, b.rate, 1e-9)
+}
+
+func TestErrorBudget_SlidingWindow(t *testing.T) {
+	var b errorBudget
+
+	// A full window of successful sends.
+	for range errorWindowSize {
+		b.record(false)
+	}
+	assert.Equal(t, errorWindowSize, b.sends)
+	assert.Zero(t, b.failures)
+
+	// Old outcomes leave the window as new ones arrive, until half of the window has failed.
+	for i := range errorWindowSize/2 - 1 {
+		assert.False(t, b.record(true), "failure %d", i+1)
+	}
+	assert.Equal(t, errorWindowSize/2-1, b.failures)
+	assert.True(t, b.record(true))
+	assert.True(t, b.degraded)
+}
+
+func TestSplitBatch(t *testing.T) {
+	metrics := entity.Metrics{
+		{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"},
+	}
+
+	tests := []struct {
+		name     string
+		expected []int
+		size     int
+	}{
+		{name: "Fits", size: 5, expected: []int{5}},
+		{name: "Even", size: 1, expected: []int{1, 1, 1, 1, 1}},
+		{name: "Remainder", size: 2, expected: []int{2, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batches := splitBatch(&metrics, tt.size)
+			lengths := make([]int, 0, len(batches))
+			for _, batch := range batches {
+				lengths = append(lengths, batch.Length())
+			}
+			assert.Equal(t, tt.expected, lengths)
+			assert.Equal(t, "e", (*batches[len(batches)-1])[batches[len(batches)-1].Length()-1].Name)
+		})
+	}
+}
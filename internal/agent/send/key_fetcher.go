@@ -0,0 +1,85 @@
+package send
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gdyunin/metricol.git/internal/agent/send/model"
+	"github.com/gdyunin/metricol.git/pkg/pubkey"
+	"github.com/gdyunin/metricol.git/pkg/retry"
+
+	"go.uber.org/zap"
+)
+
+// Const publicKeyEndpoint defines the API endpoint serving the server's encryption key.
+const publicKeyEndpoint = "/crypto/public-key"
+
+// ErrFingerprintMismatch is returned when a public key does not match the pinned fingerprint.
+var ErrFingerprintMismatch = errors.New("public key fingerprint mismatch")
+
+// FetchPublicKey downloads the server's RSA or X25519 public key and verifies it against the pinned fingerprint.
+// The fingerprint is always computed locally from the received key rather than taken from the response.
+// An empty pinned fingerprint trusts the key on first use.
+//
+// Parameters:
+//   - ctx: The context controlling the request lifecycle.
+//   - serverAddress: The server address.
+//   - pinnedFingerprint: The expected hex encoded SHA-256 fingerprint; empty to skip verification.
+//   - logger: Logger for retry attempts.
+//   - opts: Optional client settings, e.g. WithClientTLS.
+//
+// Returns:
+//   - string: The public key in PEM format.
+//   - string: The fingerprint of the received key.
+//   - error: An error if the key cannot be fetched or does not match the pinned fingerprint.
+func FetchPublicKey(
+	ctx context.Context,
+	serverAddress string,
+	pinnedFingerprint string,
+	logger *zap.SugaredLogger,
+	opts ...ClientOption,
+) (string, string, error) {
+	client := newClient(serverAddress, opts)
+
+	var body model.PublicKey
+	err := retry.WithRetry(ctx, logger, "fetch server public key", attemptsDefaultCount, func() error {
+		resp, err := client.R().SetContext(ctx).SetResult(&body).Get(publicKeyEndpoint)
+		if err != nil {
+			return fmt.Errorf("request failed: %w", err)
+		}
+		if resp.StatusCode() != http.StatusOK {
+			return fmt.Errorf("unexpected status code %d", resp.StatusCode())
+		}
+		return nil
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to fetch public key: %w", err)
+	}
+
+	return VerifyPublicKey(body.PublicKey, pinnedFingerprint)
+}
+
+// VerifyPublicKey computes the fingerprint of a public key and compares it with the pinned one.
+//
+// Parameters:
+//   - publicKeyPEM: The public key in PEM format.
+//   - pinnedFingerprint: The expected hex encoded SHA-256 fingerprint; empty to skip verification.
+//
+// Returns:
+//   - string: The public key in PEM format.
+//   - string: The computed fingerprint.
+//   - error: ErrFingerprintMismatch if the fingerprints differ, or an error if the key is invalid.
+func VerifyPublicKey(publicKeyPEM string, pinnedFingerprint string) (string, string, error) {
+	fingerprint, err := pubkey.Fingerprint(publicKeyPEM)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid public key: %w", err)
+	}
+
+	pinned := normalizeFingerprint(pinnedFingerprint)
+	if pinned != "" && pinned != fingerprint {
+		return "", "", fmt.Errorf("%w: expected %s, got %s", ErrFingerprintMismatch, pinned, fingerprint)
+	}
+	return publicKeyPEM, fingerprint, nil
+}
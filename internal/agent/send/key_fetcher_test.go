@@ -0,0 +1,90 @@
+package send
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/send/model"
+	"github.com/gdyunin/metricol.git/pkg/pubkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestFetchPublicKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	pubPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	fingerprint, err := pubkey.Fingerprint(pubPEM)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != publicKeyEndpoint {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.PublicKey{PublicKey: pubPEM, Fingerprint: fingerprint})
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name        string
+		pinned      string
+		expectError error
+	}{
+		{name: "Pinned fingerprint matches", pinned: fingerprint},
+		{name: "Pinned fingerprint in upper case with colons", pinned: colonize(strings.ToUpper(fingerprint))},
+		{name: "Trust on first use", pinned: ""},
+		{name: "Pinned fingerprint mismatch", pinned: strings.Repeat("0", 64), expectError: ErrFingerprintMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotKey, gotFingerprint, err := FetchPublicKey(
+				context.Background(),
+				strings.TrimPrefix(srv.URL, "http://"),
+				tt.pinned,
+				zap.NewNop().Sugar(),
+			)
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, pubPEM, gotKey)
+			assert.Equal(t, fingerprint, gotFingerprint)
+		})
+	}
+}
+
+func TestFetchPublicKeyUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, _, err := FetchPublicKey(ctx, srv.URL, "", zap.NewNop().Sugar())
+	assert.Error(t, err)
+}
+
+// colonize inserts a colon between every pair of hex digits.
+func colonize(hex string) string {
+	pairs := make([]string, 0, len(hex)/2)
+	for i := 0; i+1 < len(hex); i += 2 {
+		pairs = append(pairs, hex[i:i+2])
+	}
+	return strings.Join(pairs, ":")
+}
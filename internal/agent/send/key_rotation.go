@@ -0,0 +1,199 @@
+package send
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gdyunin/metricol.git/internal/agent/send/model"
+	"github.com/gdyunin/metricol.git/pkg/pubkey"
+	"github.com/gdyunin/metricol.git/pkg/sign"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	// Const keysEndpoint defines the API endpoint describing the keys in effect on the server.
+	keysEndpoint = "/crypto/keys"
+	// Const headerSigningKeyID is the response header carrying the current signing key identifier.
+	headerSigningKeyID = "X-Signing-Key-ID"
+	// Const headerNextSigningKeyID is the response header carrying the next signing key identifier.
+	headerNextSigningKeyID = "X-Signing-Key-Next-ID"
+	// Const headerCryptoKeyID is the response header carrying the current encryption key fingerprint.
+	headerCryptoKeyID = "X-Crypto-Key-ID"
+	// Const headerNextCryptoKeyID is the response header carrying the next encryption key fingerprint.
+	headerNextCryptoKeyID = "X-Crypto-Key-Next-ID"
+)
+
+// keyRotator holds the keys used for outgoing requests and switches them when the server
+// advertises a rotation.
+//
+// The signing key is switched to the preconfigured next key once the server advertises its identifier.
+// The encryption key is switched to the advertised next key after it is fetched and its fingerprint
+// verified; an unknown key is only accepted if it matches the pinned next fingerprint or pinning is off.
+type keyRotator struct {
+	client         *resty.Client // client fetches the server's public keys.
+	logger         *zap.SugaredLogger
+	server         string // server is the base URL keys are fetched from; empty for the base URL of client.
+	signingKey     string // signingKey is used for signing the request payload.
+	nextSigningKey string // nextSigningKey replaces signingKey once the server advertises it.
+	cryptoKey      string // cryptoKey is the public key used for payload encryption.
+	cryptoKeyID    string // cryptoKeyID is the fingerprint of cryptoKey.
+	nextCryptoPin  string // nextCryptoPin is the expected fingerprint of the next encryption key.
+	rejectedID     string // rejectedID is the last advertised encryption key refused for not being pinned.
+	mu             sync.RWMutex
+	fetching       atomic.Bool // fetching guards against concurrent key fetches.
+	trustUnpinned  bool        // trustUnpinned allows switching to an encryption key without a pinned fingerprint.
+}
+
+// newKeyRotator creates a keyRotator using the given keys.
+//
+// Parameters:
+//   - client: The client used to fetch the server's public keys.
+//   - signingKey: The key used for signing requests.
+//   - cryptoKey: The public key used for payload encryption; empty if encryption is disabled.
+//   - logger: Logger for key switches.
+//
+// Returns:
+//   - *keyRotator: A pointer to the created keyRotator.
+func newKeyRotator(client *resty.Client, signingKey, cryptoKey string, logger *zap.SugaredLogger) *keyRotator {
+	r := &keyRotator{
+		client:     client,
+		logger:     logger,
+		signingKey: signingKey,
+		cryptoKey:  cryptoKey,
+	}
+	if cryptoKey != "" {
+		// An invalid key leaves the ID empty, so any advertised key is treated as a new one.
+		r.cryptoKeyID, _ = pubkey.Fingerprint(cryptoKey)
+	}
+	return r
+}
+
+// keys returns the signing and encryption keys to use for the next request.
+func (r *keyRotator) keys() (string, string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.signingKey, r.cryptoKey
+}
+
+// setServer changes the server the keys are fetched from.
+func (r *keyRotator) setServer(serverAddress string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.server = serverAddress
+}
+
+// setSigningKey replaces the signing key in use.
+func (r *keyRotator) setSigningKey(signingKey string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.signingKey = signingKey
+}
+
+// observe inspects the key advertisement in a server response and switches keys if needed.
+//
+// Parameters:
+//   - ctx: The context for fetching a new encryption key.
+//   - header: The response headers.
+func (r *keyRotator) observe(ctx context.Context, header http.Header) {
+	r.observeSigning(header.Get(headerSigningKeyID), header.Get(headerNextSigningKeyID))
+	r.observeCrypto(ctx, header.Get(headerCryptoKeyID), header.Get(headerNextCryptoKeyID))
+}
+
+// observeSigning switches to the next signing key once the server advertises it.
+func (r *keyRotator) observeSigning(currentID, nextID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nextSigningKey == "" || r.nextSigningKey == r.signingKey {
+		return
+	}
+	id := sign.KeyID(r.nextSigningKey)
+	if currentID != id && nextID != id {
+		return
+	}
+	r.signingKey = r.nextSigningKey
+	r.logger.Infof("Switched to next signing key %s", id)
+}
+
+// observeCrypto fetches and switches to the encryption key advertised by the server if it differs from ours.
+func (r *keyRotator) observeCrypto(ctx context.Context, currentID, nextID string) {
+	r.mu.RLock()
+	ownID, enabled := r.cryptoKeyID, r.cryptoKey != ""
+	r.mu.RUnlock()
+	if !enabled {
+		return
+	}
+
+	candidate := nextID
+	if candidate == "" || candidate == ownID {
+		candidate = currentID
+	}
+	if candidate == "" || candidate == ownID {
+		return
+	}
+	if !r.trustUnpinned && candidate != normalizeFingerprint(r.nextCryptoPin) {
+		r.mu.Lock()
+		if r.rejectedID != candidate {
+			r.rejectedID = candidate
+			r.logger.Warnf("Server advertises crypto key %s which is not pinned, keep using %s", candidate, ownID)
+		}
+		r.mu.Unlock()
+		return
+	}
+
+	if !r.fetching.CompareAndSwap(false, true) {
+		return
+	}
+	defer r.fetching.Store(false)
+
+	key, err := r.fetchKey(ctx, candidate)
+	if err != nil {
+		r.logger.Errorf("Failed to fetch crypto key %s: %v", candidate, err)
+		return
+	}
+
+	r.mu.Lock()
+	r.cryptoKey, r.cryptoKeyID = key, candidate
+	r.mu.Unlock()
+	r.logger.Infof("Switched to crypto key %s", candidate)
+}
+
+// fetchKey downloads the server's public keys and returns the one with the given fingerprint.
+// The fingerprint is computed locally rather than taken from the response.
+func (r *keyRotator) fetchKey(ctx context.Context, id string) (string, error) {
+	r.mu.RLock()
+	url := r.server + keysEndpoint
+	r.mu.RUnlock()
+
+	var body model.Keys
+	resp, err := r.client.R().SetContext(ctx).SetResult(&body).Get(url)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode())
+	}
+
+	for _, k := range body.PublicKeys {
+		if k.ID != id {
+			continue
+		}
+		key, _, err := VerifyPublicKey(k.PublicKey, id)
+		if err != nil {
+			return "", fmt.Errorf("key verification failed: %w", err)
+		}
+		return key, nil
+	}
+	return "", fmt.Errorf("key %s is not offered by the server", id)
+}
+
+// normalizeFingerprint lowercases a fingerprint and strips colon separators.
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))
+}
@@ -0,0 +1,145 @@
+package send
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/agent/send/model"
+	"github.com/gdyunin/metricol.git/pkg/pubkey"
+	"github.com/gdyunin/metricol.git/pkg/sign"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newPublicKey generates an RSA public key in PEM format together with its fingerprint.
+func newPublicKey(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	pubPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	fingerprint, err := pubkey.Fingerprint(pubPEM)
+	require.NoError(t, err)
+	return pubPEM, fingerprint
+}
+
+func TestKeyRotator_ObserveSigning(t *testing.T) {
+	tests := []struct {
+		header   map[string]string
+		name     string
+		next     string
+		expected string
+	}{
+		{
+			name:     "Next key advertised",
+			next:     "new",
+			header:   map[string]string{headerSigningKeyID: sign.KeyID("old"), headerNextSigningKeyID: sign.KeyID("new")},
+			expected: "new",
+		},
+		{
+			name:     "Next key already current on server",
+			next:     "new",
+			header:   map[string]string{headerSigningKeyID: sign.KeyID("new")},
+			expected: "new",
+		},
+		{
+			name:     "Unknown key advertised",
+			next:     "new",
+			header:   map[string]string{headerSigningKeyID: sign.KeyID("old"), headerNextSigningKeyID: sign.KeyID("other")},
+			expected: "old",
+		},
+		{
+			name:     "No next key configured",
+			next:     "",
+			header:   map[string]string{headerNextSigningKeyID: sign.KeyID("new")},
+			expected: "old",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newKeyRotator(resty.New(), "old", "", zap.NewNop().Sugar())
+			r.nextSigningKey = tt.next
+
+			header := http.Header{}
+			for k, v := range tt.header {
+				header.Set(k, v)
+			}
+			r.observe(context.Background(), header)
+
+			signingKey, _ := r.keys()
+			assert.Equal(t, tt.expected, signingKey)
+		})
+	}
+}
+
+func TestKeyRotator_ObserveCrypto(t *testing.T) {
+	currentPEM, currentID := newPublicKey(t)
+	nextPEM, nextID := newPublicKey(t)
+	otherPEM, _ := newPublicKey(t)
+
+	tests := []struct {
+		name          string
+		offeredPEM    string
+		pin           string
+		expected      string
+		trustUnpinned bool
+	}{
+		{name: "Pinned next key", offeredPEM: nextPEM, pin: nextID, expected: nextPEM},
+		{name: "Unpinned key trusted", offeredPEM: nextPEM, trustUnpinned: true, expected: nextPEM},
+		{name: "Unpinned key refused", offeredPEM: nextPEM, expected: currentPEM},
+		{name: "Offered key does not match its ID", offeredPEM: otherPEM, pin: nextID, expected: currentPEM},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != keysEndpoint {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(model.Keys{PublicKeys: []model.RotationKey{
+					{ID: currentID, PublicKey: currentPEM, Status: "current"},
+					{ID: nextID, PublicKey: tt.offeredPEM, Status: "next"},
+				}})
+			}))
+			defer srv.Close()
+
+			r := newKeyRotator(resty.New().SetBaseURL(srv.URL), "", currentPEM, zap.NewNop().Sugar())
+			r.nextCryptoPin = tt.pin
+			r.trustUnpinned = tt.trustUnpinned
+
+			header := http.Header{}
+			header.Set(headerCryptoKeyID, currentID)
+			header.Set(headerNextCryptoKeyID, nextID)
+			r.observe(context.Background(), header)
+
+			_, cryptoKey := r.keys()
+			assert.Equal(t, tt.expected, cryptoKey)
+		})
+	}
+}
+
+func TestKeyRotator_EncryptionDisabled(t *testing.T) {
+	_, nextID := newPublicKey(t)
+	r := newKeyRotator(resty.New(), "", "", zap.NewNop().Sugar())
+	r.trustUnpinned = true
+
+	header := http.Header{}
+	header.Set(headerNextCryptoKeyID, nextID)
+	r.observe(context.Background(), header)
+
+	_, cryptoKey := r.keys()
+	assert.Empty(t, cryptoKey)
+}
@@ -6,17 +6,19 @@ package model
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
 )
 
 // Metric represents a single metric including its type, unique identifier, and value.
-// For counter metrics, Delta is used; for gauge metrics, Value is used.
+// For counter metrics, Delta is used; for gauge and float counter metrics, Value is used.
 type Metric struct {
-	Delta *int64   `json:"delta,omitempty"`            // Delta holds the counter value for counter metrics.
-	Value *float64 `json:"value,omitempty"`            // Value holds the gauge value for gauge metrics.
-	ID    string   `json:"id"              uri:"id"`   // ID is the unique identifier of the metric.
-	MType string   `json:"type"            uri:"type"` // MType indicates the type of the metric.
+	Delta     *int64     `json:"delta,omitempty"`            // Delta holds the counter value for counter metrics.
+	Value     *float64   `json:"value,omitempty"`            // Value holds the value of gauge and float counter metrics.
+	Timestamp *time.Time `json:"timestamp,omitempty"`        // Timestamp is the moment the metric was collected.
+	ID        string     `json:"id"              uri:"id"`   // ID is the unique identifier of the metric.
+	MType     string     `json:"type"            uri:"type"` // MType indicates the type of the metric.
 }
 
 // NewFromEntityMetric converts an entity.Metric to a model.Metric.
@@ -38,6 +40,10 @@ func NewFromEntityMetric(entityMetric *entity.Metric) (*Metric, error) {
 		ID:    entityMetric.Name,
 		MType: entityMetric.Type,
 	}
+	if !entityMetric.Timestamp.IsZero() {
+		timestamp := entityMetric.Timestamp
+		metric.Timestamp = &timestamp
+	}
 
 	switch entityMetric.Type {
 	case entity.MetricTypeCounter:
@@ -60,6 +66,16 @@ func NewFromEntityMetric(entityMetric *entity.Metric) (*Metric, error) {
 				entityMetric.Value,
 			)
 		}
+	case entity.MetricTypeFloatCounter:
+		if v, ok := entityMetric.Value.(float64); ok {
+			metric.Value = &v
+		} else {
+			return nil, fmt.Errorf(
+				"unexpected value type for float counter metric '%s': got %T, expected float64",
+				entityMetric.Name,
+				entityMetric.Value,
+			)
+		}
 	default:
 		return nil, fmt.Errorf(
 			"unsupported metric type '%s' for metric '%s'",
@@ -100,3 +116,38 @@ func NewFromEntityMetrics(entityMetrics *entity.Metrics) (*Metrics, error) {
 
 	return &metrics, nil
 }
+
+// PublicKey represents the server response carrying its encryption key.
+type PublicKey struct {
+	PublicKey   string `json:"public_key"`  // PublicKey is the RSA or X25519 public key in PEM format.
+	Fingerprint string `json:"fingerprint"` // Fingerprint is the fingerprint reported by the server.
+}
+
+// RotationKey represents a public encryption key offered by the server during key rotation.
+type RotationKey struct {
+	ID        string `json:"id"`         // ID is the fingerprint reported by the server.
+	PublicKey string `json:"public_key"` // PublicKey is the RSA or X25519 public key in PEM format.
+	Status    string `json:"status"`     // Status is "current" or "next".
+}
+
+// Keys represents the server response describing the keys in effect.
+type Keys struct {
+	PublicKeys []RotationKey `json:"public_keys"` // PublicKeys are the encryption keys offered by the server.
+}
+
+// Capabilities represents the server response describing the options it supports.
+type Capabilities struct {
+	Encodings  []string         `json:"encodings"`  // Encodings are the supported body encodings.
+	Transports []string         `json:"transports"` // Transports are the supported ways of sending metrics.
+	Signing    []string         `json:"signing"`    // Signing are the supported signing algorithms.
+	Encryption []string         `json:"encryption"` // Encryption are the enabled encryption schemes.
+	Limits     CapabilityLimits `json:"limits"`     // Limits are the limits applied to metric updates.
+}
+
+// CapabilityLimits represents the limits the server applies to metric updates; zero values mean no limit.
+type CapabilityLimits struct {
+	MinAgentVersion     string  `json:"min_agent_version"`      // MinAgentVersion is the oldest accepted agent.
+	MetricRateLimit     int     `json:"metric_rate_limit"`      // MetricRateLimit is the updates/s per metric.
+	MaxSeries           int     `json:"max_series"`             // MaxSeries is the number of distinct metrics.
+	MaxClockSkewSeconds float64 `json:"max_clock_skew_seconds"` // MaxClockSkewSeconds bounds agent clock skew.
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"testing"
+	"time"
 
 	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
 	"github.com/stretchr/testify/assert"
@@ -24,6 +25,31 @@ func TestNewFromEntityMetric(t *testing.T) {
 			input:    &entity.Metric{Name: "test_gauge", Type: "gauge", Value: float64(3.14)},
 			expected: &Metric{ID: "test_gauge", MType: "gauge", Value: float64Ptr(3.14)},
 		},
+		{
+			name: "Timestamped gauge metric",
+			input: &entity.Metric{
+				Name:      "test_gauge",
+				Type:      "gauge",
+				Value:     float64(3.14),
+				Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+			},
+			expected: &Metric{
+				ID:        "test_gauge",
+				MType:     "gauge",
+				Value:     float64Ptr(3.14),
+				Timestamp: timePtr(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)),
+			},
+		},
+		{
+			name:     "Valid float counter metric",
+			input:    &entity.Metric{Name: "cpu_seconds", Type: "fcounter", Value: float64(0.25)},
+			expected: &Metric{ID: "cpu_seconds", MType: "fcounter", Value: float64Ptr(0.25)},
+		},
+		{
+			name:        "Invalid float counter metric type",
+			input:       &entity.Metric{Name: "cpu_seconds", Type: "fcounter", Value: int64(1)},
+			expectError: true,
+		},
 		{
 			name:        "Invalid counter metric type",
 			input:       &entity.Metric{Name: "invalid_counter", Type: "counter", Value: "string"},
@@ -65,3 +91,7 @@ func int64Ptr(i int64) *int64 {
 func float64Ptr(f float64) *float64 {
 	return &f
 }
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
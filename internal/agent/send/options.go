@@ -0,0 +1,141 @@
+package send
+
+import (
+	"context"
+	"net"
+
+	"github.com/gdyunin/metricol.git/internal/agent/crash"
+	"github.com/gdyunin/metricol.git/internal/agent/lifecycle"
+	"github.com/gdyunin/metricol.git/pkg/buildinfo"
+)
+
+// Option configures optional StreamSender settings.
+type Option func(*StreamSender)
+
+// WithKeyRotation enables switching keys when the server advertises a rotation.
+//
+// Parameters:
+//   - nextSigningKey: The signing key to switch to once the server advertises it; empty to keep the current key.
+//   - nextCryptoFingerprint: The pinned fingerprint of the next encryption key; empty if none is pinned.
+//   - trustUnpinned: Whether an advertised encryption key may be used without a pinned fingerprint.
+//
+// Returns:
+//   - Option: An option applying the rotation settings.
+func WithKeyRotation(nextSigningKey, nextCryptoFingerprint string, trustUnpinned bool) Option {
+	return func(s *StreamSender) {
+		s.keys.nextSigningKey = nextSigningKey
+		s.keys.nextCryptoPin = nextCryptoFingerprint
+		s.keys.trustUnpinned = trustUnpinned
+	}
+}
+
+// WithAgentID identifies the agent to the server, which reports clock skew per agent.
+// An empty identifier leaves the server to identify the agent by its address.
+//
+// Parameters:
+//   - id: The agent identifier.
+//
+// Returns:
+//   - Option: An option applying the identifier.
+func WithAgentID(id string) Option {
+	return func(s *StreamSender) {
+		if id != "" {
+			s.httpClient.SetHeader(headerAgentID, id)
+		}
+	}
+}
+
+// WithAPIKey sends the API key in the headers of every request, so the server applies the quotas of that key.
+// An empty key leaves the agent subject to the quotas shared by all other clients.
+//
+// Parameters:
+//   - key: The API key.
+//
+// Returns:
+//   - Option: An option applying the API key.
+func WithAPIKey(key string) Option {
+	return func(s *StreamSender) {
+		if key != "" {
+			s.httpClient.SetHeader(headerAPIKey, key)
+		}
+	}
+}
+
+// WithCrashReporter records panics of the sending goroutines with reporter, which writes crash dumps
+// and counts the panics. By default panics are only logged.
+//
+// Parameters:
+//   - reporter: The crash reporter.
+//
+// Returns:
+//   - Option: An option applying the reporter.
+func WithCrashReporter(reporter *crash.Reporter) Option {
+	return func(s *StreamSender) {
+		if reporter != nil {
+			s.crash = reporter
+		}
+	}
+}
+
+// WithBuildInfo sends the agent build info in the headers of every batch, so the server can check
+// whether the agent is compatible with it.
+//
+// Parameters:
+//   - info: The agent build info.
+//
+// Returns:
+//   - Option: An option applying the build headers.
+func WithBuildInfo(info buildinfo.Info) Option {
+	return func(s *StreamSender) {
+		s.httpClient.SetHeaders(info.AgentHeaders())
+	}
+}
+
+// WithHeartbeat sends the agent health to the server every interval, as the AgentHealthy gauge (1 or 0)
+// and the AgentUnhealthyComponents gauge, even when there are no metrics to send.
+//
+// Parameters:
+//   - health: The function reporting the agent health.
+//
+// Returns:
+//   - Option: An option enabling the heartbeat.
+func WithHeartbeat(health func() lifecycle.Health) Option {
+	return func(s *StreamSender) {
+		s.heartbeat = health
+	}
+}
+
+// WithMaxBatchSize caps the number of metrics sent in one request; larger batches are split,
+// bounding the memory taken by request bodies. A non-positive size leaves batches whole.
+//
+// Parameters:
+//   - size: The maximum number of metrics in a request.
+//
+// Returns:
+//   - Option: An option applying the cap.
+func WithMaxBatchSize(size int) Option {
+	return func(s *StreamSender) {
+		s.maxBatchSize = max(size, 0)
+	}
+}
+
+// WithDialer opens the connections to the server with dial instead of resolving the server address,
+// e.g. to follow the targets discovered from DNS SRV records.
+//
+// Parameters:
+//   - dial: The function opening connections, as the DialContext field of an http.Transport.
+//
+// Returns:
+//   - Option: An option applying the dialer.
+func WithDialer(dial func(ctx context.Context, network, addr string) (net.Conn, error)) Option {
+	return func(s *StreamSender) {
+		if dial == nil {
+			return
+		}
+		for _, client := range s.clients() {
+			if transport, err := client.Transport(); err == nil {
+				transport.DialContext = dial
+			}
+		}
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/gdyunin/metricol.git/internal/agent/send/compress"
 	"github.com/gdyunin/metricol.git/pkg/sign"
+	"github.com/gdyunin/metricol.git/pkg/x25519box"
 	"github.com/go-resty/resty/v2"
 )
 
@@ -22,6 +23,7 @@ import (
 type RequestBuilder struct {
 	httpClient *resty.Client        // httpClient is the HTTP client used for sending requests.
 	compressor *compress.Compressor // compressor provides gzip compression for request bodies.
+	identity   bool                 // identity sends bodies uncompressed, for servers not accepting gzip.
 }
 
 // NewRequestBuilder initializes and returns a new RequestBuilder instance.
@@ -55,7 +57,15 @@ func (b *RequestBuilder) Build(method string, endpoint string, body []byte) *res
 	return req
 }
 
-// BuildWithParams creates an HTTP request with a gzip-compressed body.
+// SetCompression enables or disables the gzip compression of request bodies. It is enabled by default.
+//
+// Parameters:
+//   - enabled: Whether request bodies are compressed.
+func (b *RequestBuilder) SetCompression(enabled bool) {
+	b.identity = !enabled
+}
+
+// BuildWithParams creates an HTTP request with a gzip-compressed body, unless compression is disabled.
 // It compresses the provided body data and, if a signing key is provided,
 // computes and encodes a signature that is added as a header.
 //
@@ -64,6 +74,7 @@ func (b *RequestBuilder) Build(method string, endpoint string, body []byte) *res
 //   - endpoint: The URL endpoint for the request.
 //   - body: The body content to be compressed and included in the request.
 //   - signingKey: A key used for signing the request payload; if empty, no signature is added.
+//   - publicKeyPEM: An RSA or X25519 public key the payload is encrypted for; if empty, it is sent in plain.
 //
 // Returns:
 //   - *resty.Request: The constructed HTTP request with a gzip-compressed body.
@@ -87,30 +98,42 @@ func (b *RequestBuilder) BuildWithParams(
 	// Using hybrid crypto method for correct work with large body.
 	// [ДЛЯ РЕВЬЮ] Использую тут гибридное шифрование, т.к. тело большое и не шифруется "маленькими" (4096) rsa.
 	// Поэтому в качестве выхода из ситуации подобрал такой подход. Будет работать даже если мы откажемся от сжатия.
-	var e string
+	// X25519 public keys select the faster X25519/ChaCha20-Poly1305 scheme instead.
+	var e, scheme string
 	if publicKeyPEM != "" {
-		encryptedBody, encryptedKey, err := encryptWithPublicKeyHybrid(body, publicKeyPEM)
+		encrypt, name := encryptWithPublicKeyHybrid, encryptionRSAAESGCM
+		if x25519box.IsPublicKey(publicKeyPEM) {
+			encrypt, name = x25519box.Seal, x25519box.Scheme
+		}
+		encryptedBody, encryptedKey, err := encrypt(body, publicKeyPEM)
 		if err != nil {
 			return nil, fmt.Errorf("encryption failed for request body: %w", err)
 		}
 
 		body = encryptedBody
 		e = base64.StdEncoding.EncodeToString(encryptedKey)
+		scheme = name
 	}
 
-	body, err := b.compressor.Compress(body)
-	if err != nil {
-		return nil, fmt.Errorf("gzip compression failed for request body: %w", err)
+	if !b.identity {
+		compressed, err := b.compressor.Compress(body)
+		if err != nil {
+			return nil, fmt.Errorf("gzip compression failed for request body: %w", err)
+		}
+		body = compressed
 	}
 
 	req := b.Build(method, endpoint, body)
-	req.SetHeader("Content-Encoding", "gzip")
+	if !b.identity {
+		req.SetHeader("Content-Encoding", "gzip")
+	}
 
 	if s != "" {
 		req.SetHeader("HashSHA256", s)
 	}
 	if e != "" {
 		req.SetHeader("X-Encrypted-Key", e)
+		req.SetHeader("X-Encryption-Scheme", scheme)
 	}
 
 	return req, nil
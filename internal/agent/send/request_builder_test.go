@@ -6,8 +6,10 @@ import (
 
 	"github.com/gdyunin/metricol.git/internal/agent/send/compress"
 	"github.com/gdyunin/metricol.git/pkg/sign"
+	"github.com/gdyunin/metricol.git/pkg/x25519box"
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBuild(t *testing.T) {
@@ -92,3 +94,36 @@ func TestBuildWithGzip(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildWithoutCompression(t *testing.T) {
+	builder := NewRequestBuilder(resty.New())
+	builder.SetCompression(false)
+
+	body := []byte(`{"key": "value"}`)
+	req, err := builder.BuildWithParams("POST", "https://example.com/data", body, "secretKey", "")
+	assert.NoError(t, err)
+	assert.Equal(t, body, req.Body)
+	assert.Empty(t, req.Header.Get("Content-Encoding"))
+	assert.Equal(t, base64.StdEncoding.EncodeToString(sign.MakeSign(body, "secretKey")), req.Header.Get("HashSHA256"))
+}
+
+func TestBuildWithX25519Encryption(t *testing.T) {
+	privateKey, publicKey, err := x25519box.GenerateKey()
+	require.NoError(t, err)
+
+	builder := NewRequestBuilder(resty.New())
+	builder.SetCompression(false)
+
+	body := []byte(`{"key": "value"}`)
+	req, err := builder.BuildWithParams("POST", "https://example.com/data", body, "", publicKey)
+	require.NoError(t, err)
+	assert.Equal(t, x25519box.Scheme, req.Header.Get("X-Encryption-Scheme"))
+
+	ephemeral, err := base64.StdEncoding.DecodeString(req.Header.Get("X-Encrypted-Key"))
+	require.NoError(t, err)
+	sealed, ok := req.Body.([]byte)
+	require.True(t, ok)
+	opened, err := x25519box.Open(sealed, ephemeral, privateKey)
+	require.NoError(t, err)
+	assert.Equal(t, body, opened)
+}
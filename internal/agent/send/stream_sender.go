@@ -9,11 +9,16 @@ import (
 	"sync"
 	"time"
 
+	"github.com/gdyunin/metricol.git/internal/agent/crash"
 	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/agent/lifecycle"
 	"github.com/gdyunin/metricol.git/internal/agent/send/model"
 	"github.com/gdyunin/metricol.git/pkg/retry"
+	"github.com/gdyunin/metricol.git/pkg/tracing"
 
 	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 	"go.uber.org/zap"
 )
 
@@ -22,10 +27,22 @@ type contextKey string
 const (
 	// Const updateBatchEndpoint defines the API endpoint for updating a batch of metrics.
 	updateBatchEndpoint = "/updates"
+	// Const updateEndpoint defines the API endpoint for updating a single metric.
+	updateEndpoint = "/update"
 	// Const attemptsDefaultCount defines the default number of attempts for retry calls.
 	attemptsDefaultCount = 4
 	// Const retryCalcContextKey is the key used to store the retry calculator in the request context.
 	retryCalcContextKey contextKey = "retryCalculator"
+	// Const headerAgentID carries the agent identifier.
+	headerAgentID = "X-Agent-ID"
+	// Const headerAPIKey carries the API key selecting the server quotas.
+	headerAPIKey = "X-API-Key"
+	// Const headerAgentTime carries the agent clock at the moment a request attempt is sent.
+	headerAgentTime = "X-Agent-Time"
+	// Const heartbeatHealthyMetric is the heartbeat gauge set to 1 while the agent is healthy and 0 otherwise.
+	heartbeatHealthyMetric = "AgentHealthy"
+	// Const heartbeatUnhealthyMetric is the heartbeat gauge holding the number of unhealthy agent components.
+	heartbeatUnhealthyMetric = "AgentUnhealthyComponents"
 )
 
 // StreamSender provides functionality for sending batches of metrics to a remote server.
@@ -35,11 +52,20 @@ type StreamSender struct {
 	httpClient     *resty.Client   // httpClient is the client used to send HTTP requests.
 	requestBuilder *RequestBuilder // requestBuilder constructs HTTP requests with optional gzip compression.
 	logger         *zap.SugaredLogger
-	streamFrom     chan *entity.Metrics // streamFrom is the channel from which metrics batches are received.
-	signingKey     string               // signingKey is used for signing the request payload.
-	cryptoKey      string
-	interval       time.Duration // interval defines the period between send attempts.
-	maxPoolSize    int           // maxPoolSize limits the number of concurrent sending goroutines.
+	streamFrom     chan *entity.Metrics    // streamFrom is the channel from which metrics batches are received.
+	keys           *keyRotator             // keys provides the signing and encryption keys and follows their rotation.
+	crash          *crash.Reporter         // crash records panics of the sending goroutines.
+	heartbeat      func() lifecycle.Health // heartbeat provides the agent health sent every interval; nil disables it.
+	lastErr        error                   // lastErr is the error of the last failed send.
+	retune         chan struct{}           // retune wakes the send loop when the interval is changed.
+	serverAddress  string                  // serverAddress is the base URL of the server requests are sent to.
+	budget         errorBudget             // budget tracks the error rate and switches the degraded mode.
+	life           lifecycle.Runner        // life tracks the run started with Start.
+	mu             sync.Mutex              // mu protects budget, lastErr, serverAddress, interval and maxPoolSize.
+	interval       time.Duration           // interval defines the period between send attempts.
+	maxPoolSize    int                     // maxPoolSize limits the number of concurrent sending goroutines.
+	maxBatchSize   int                     // maxBatchSize caps the metrics sent in one request, 0 for no cap.
+	singleMetrics  bool                    // singleMetrics sends metrics one by one, for servers without batch updates.
 }
 
 // NewStreamSender creates and initializes a new StreamSender instance.
@@ -50,7 +76,9 @@ type StreamSender struct {
 //   - maxPoolSize: The maximum number of concurrent sending operations.
 //   - serverAddress: The base URL of the server to which metrics will be sent.
 //   - signingKey: A key used for signing requests.
+//   - cryptoKey: A public key used for payload encryption; empty if encryption is disabled.
 //   - logger: A logger for recording messages and errors.
+//   - opts: Optional sender settings.
 //
 // Returns:
 //   - *StreamSender: A pointer to the initialized StreamSender.
@@ -62,11 +90,9 @@ func NewStreamSender(
 	signingKey string,
 	cryptoKey string,
 	logger *zap.SugaredLogger,
+	opts ...Option,
 ) *StreamSender {
-	// Ensure the server address has the proper HTTP scheme.
-	if !strings.HasPrefix(serverAddress, "http://") && !strings.HasPrefix(serverAddress, "https://") {
-		serverAddress = "http://" + strings.TrimPrefix(serverAddress, "/")
-	}
+	serverAddress = withScheme(serverAddress)
 
 	httpClient := resty.New().
 		SetHeader("Content-Type", "application/json").
@@ -94,30 +120,47 @@ func NewStreamSender(
 
 			return retryCalculator.Next(), nil
 		}).
-		SetLogger(logger.Named("http_client"))
+		SetLogger(logger.Named("http_client")).
+		OnBeforeRequest(stampAgentTime)
 
 	requestBuilder := NewRequestBuilder(httpClient)
 
-	logger.Infof("Initialized StreamSender with server address: %s", serverAddress)
-	return &StreamSender{
+	keys := newKeyRotator(
+		resty.New().SetBaseURL(serverAddress),
+		signingKey,
+		cryptoKey,
+		logger.Named("key_rotator"),
+	)
+
+	sender := &StreamSender{
 		httpClient:     httpClient,
 		requestBuilder: requestBuilder,
 		logger:         logger,
-		signingKey:     signingKey,
-		cryptoKey:      cryptoKey,
+		keys:           keys,
 		streamFrom:     streamFrom,
+		serverAddress:  serverAddress,
 		interval:       interval,
 		maxPoolSize:    maxPoolSize,
+		crash:          crash.NewReporter("", logger),
+		retune:         make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(sender)
 	}
+
+	logger.Infof("Initialized StreamSender with server address: %s", serverAddress)
+	return sender
 }
 
 // StartStreaming begins the process of periodically sending metrics batches to the server.
 // It uses a ticker to trigger send operations and stops when the provided context is canceled.
+// In degraded mode the ticker runs degradedIntervalFactor times slower.
 //
 // Parameters:
 //   - ctx: The context to control cancellation of the streaming operation.
 func (s *StreamSender) StartStreaming(ctx context.Context) {
-	ticker := time.NewTicker(s.interval)
+	interval := s.sendInterval()
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
@@ -125,18 +168,120 @@ func (s *StreamSender) StartStreaming(ctx context.Context) {
 		case <-ctx.Done():
 			s.logger.Info("Context canceled: stopping stream")
 			return
+		case <-s.retune:
+			interval = s.sendInterval()
+			ticker.Reset(interval)
 		case <-ticker.C:
 			s.sendWithPool(ctx)
+			if next := s.sendInterval(); next != interval {
+				interval = next
+				ticker.Reset(interval)
+			}
 		}
 	}
 }
 
+// SetInterval changes the period between sends of a running or stopped sender; the next send happens
+// one new interval from now. A non-positive interval is ignored.
+//
+// Parameters:
+//   - interval: The new period between sends.
+func (s *StreamSender) SetInterval(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	s.mu.Lock()
+	s.interval = interval
+	s.mu.Unlock()
+	select {
+	case s.retune <- struct{}{}:
+	default:
+	}
+}
+
+// SetMaxPoolSize changes the number of batches sent concurrently, starting with the next send.
+// The batches already queued are kept. A non-positive size is ignored.
+//
+// Parameters:
+//   - maxPoolSize: The new maximum number of concurrent sending operations.
+func (s *StreamSender) SetMaxPoolSize(maxPoolSize int) {
+	if maxPoolSize <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxPoolSize = maxPoolSize
+}
+
+// SetServerAddress changes the server the following requests are sent to, including the key fetches
+// of the key rotation. Requests already in flight complete against the previous server.
+//
+// Parameters:
+//   - serverAddress: The base URL of the server, with or without a scheme.
+func (s *StreamSender) SetServerAddress(serverAddress string) {
+	serverAddress = withScheme(serverAddress)
+	s.mu.Lock()
+	s.serverAddress = serverAddress
+	s.mu.Unlock()
+	s.keys.setServer(serverAddress)
+}
+
+// SetSigningKey changes the key the following requests are signed with. A next signing key configured
+// for the key rotation still replaces it once the server advertises the rotation.
+//
+// Parameters:
+//   - signingKey: The new signing key; empty to stop signing.
+func (s *StreamSender) SetSigningKey(signingKey string) {
+	s.keys.setSigningKey(signingKey)
+}
+
+// Start runs the sender until the context is canceled or Stop is called.
+//
+// Parameters:
+//   - ctx: The context of the run.
+func (s *StreamSender) Start(ctx context.Context) {
+	s.life.Run(ctx, s.StartStreaming)
+}
+
+// Stop stops a sender started with Start.
+func (s *StreamSender) Stop() {
+	s.life.Stop()
+}
+
+// Healthy reports whether the sender runs and reaches the server, that is whether it is not in degraded mode.
+//
+// Returns:
+//   - error: lifecycle.ErrNotRunning, an error wrapping the last send error, or nil.
+func (s *StreamSender) Healthy() error {
+	if !s.life.Running() {
+		return lifecycle.ErrNotRunning
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.budget.degraded {
+		return fmt.Errorf("degraded mode after %.0f%% of sends failed: %w", 100*s.budget.rate, s.lastErr)
+	}
+	return nil
+}
+
 // sendWithPool retrieves metric batches from the streamFrom channel and sends them concurrently.
-// It launches up to maxPoolSize goroutines to handle sending in parallel.
+// It launches up to maxPoolSize goroutines to handle sending in parallel, plus one for the heartbeat if enabled.
 func (s *StreamSender) sendWithPool(ctx context.Context) {
 	var wg sync.WaitGroup
 
-	for range s.maxPoolSize {
+	if s.heartbeat != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer s.crash.Recover("heartbeat", nil)
+			s.sendHeartbeat(ctx)
+		}()
+	}
+
+	s.mu.Lock()
+	poolSize := s.maxPoolSize
+	s.mu.Unlock()
+	for range poolSize {
 		select {
 		case <-ctx.Done():
 			s.logger.Info("Context canceled: cancel send")
@@ -145,7 +290,16 @@ func (s *StreamSender) sendWithPool(ctx context.Context) {
 			wg.Add(1)
 			go func() {
 				defer wg.Done()
-				metrics, ok := <-s.streamFrom
+				var metrics *entity.Metrics
+				defer s.crash.Recover("sender", func() string { return crash.BatchSummary(metrics) })
+
+				// Waiting for a batch must not outlive the sender; the agent counts the batches left as unsent.
+				var ok bool
+				select {
+				case metrics, ok = <-s.streamFrom:
+				case <-ctx.Done():
+					return
+				}
 				if !ok {
 					s.logger.Info("StreamFrom channel was closed, stop sending")
 					return
@@ -169,6 +323,8 @@ func (s *StreamSender) sendWithPool(ctx context.Context) {
 
 // SendBatch sends a batch of metrics to the server using gzip compression and retry logic.
 // It first converts the metrics from the entity format to the model format, then prepares and sends the request.
+// Batches above the configured maximum batch size are sent in several requests, and in degraded mode
+// in requests of at most degradedBatchSize metrics, stopping at the first failure.
 //
 // Parameters:
 //   - ctx: The context for the HTTP request.
@@ -177,18 +333,117 @@ func (s *StreamSender) sendWithPool(ctx context.Context) {
 // Returns:
 //   - error: An error if the sending process fails; otherwise, nil.
 func (s *StreamSender) SendBatch(ctx context.Context, metrics *entity.Metrics) error {
+	batchSize := s.maxBatchSize
+	if s.degraded() && (batchSize == 0 || batchSize > degradedBatchSize) {
+		batchSize = degradedBatchSize
+	}
+	batches := []*entity.Metrics{metrics}
+	if batchSize > 0 {
+		batches = splitBatch(metrics, batchSize)
+	}
+	for _, batch := range batches {
+		if err := s.sendOne(ctx, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendOne sends a batch of metrics in a single request, or one request per metric for servers
+// without batch updates, and records the outcome in the error budget.
+func (s *StreamSender) sendOne(ctx context.Context, metrics *entity.Metrics) (err error) {
+	ctx, span := startSendSpan(ctx, metrics)
+	defer func() { tracing.End(span, err) }()
+
 	modelsMetric, err := model.NewFromEntityMetrics(metrics)
 	if err != nil {
 		return fmt.Errorf("conversion of metrics to models failed: %w", err)
 	}
 
-	if err = s.prepareAndSend(ctx, modelsMetric, updateBatchEndpoint); err != nil {
+	if s.singleMetrics {
+		err = s.sendEach(ctx, *modelsMetric)
+	} else {
+		err = s.prepareAndSend(ctx, modelsMetric, updateBatchEndpoint)
+	}
+	s.recordResult(err)
+	if err != nil {
 		return fmt.Errorf("error during preparation or sending of batch request: %w", err)
 	}
 
 	return nil
 }
 
+// sendEach sends metrics one request per metric, stopping at the first failure.
+func (s *StreamSender) sendEach(ctx context.Context, metrics model.Metrics) error {
+	for _, m := range metrics {
+		if err := s.prepareAndSend(ctx, m, updateEndpoint); err != nil {
+			return fmt.Errorf("sending metric '%s' failed: %w", m.ID, err)
+		}
+	}
+	return nil
+}
+
+// sendHeartbeat sends the agent health as gauges, so the server sees the agent and its state
+// even while no metrics are collected.
+func (s *StreamSender) sendHeartbeat(ctx context.Context) {
+	health := s.heartbeat()
+	healthy := 0.0
+	if health.Status == lifecycle.StatusOK {
+		healthy = 1
+	}
+
+	now := time.Now()
+	batch := entity.Metrics{
+		{Name: heartbeatHealthyMetric, Type: entity.MetricTypeGauge, Value: healthy, Timestamp: now},
+		{
+			Name:      heartbeatUnhealthyMetric,
+			Type:      entity.MetricTypeGauge,
+			Value:     float64(len(health.Unhealthy())),
+			Timestamp: now,
+		},
+	}
+	if err := s.SendBatch(ctx, &batch); err != nil {
+		s.logger.Errorf("Failed to send heartbeat: %v", err)
+	}
+}
+
+// recordResult adds the outcome of a send to the error budget and reports switches of the degraded mode.
+func (s *StreamSender) recordResult(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.lastErr = err
+	}
+	if !s.budget.record(err != nil) {
+		return
+	}
+	if s.budget.degraded {
+		s.logger.Warnf(
+			"Entering degraded mode: %.0f%% of sends failed, sending every %s in batches of at most %d metrics",
+			100*s.budget.rate, s.interval*degradedIntervalFactor, degradedBatchSize,
+		)
+		return
+	}
+	s.logger.Infof("Leaving degraded mode: %.0f%% of sends failed, sending every %s", 100*s.budget.rate, s.interval)
+}
+
+// degraded reports whether the sender runs in degraded mode.
+func (s *StreamSender) degraded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.budget.degraded
+}
+
+// sendInterval returns the period between sends in the current mode.
+func (s *StreamSender) sendInterval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.budget.degraded {
+		return s.interval * degradedIntervalFactor
+	}
+	return s.interval
+}
+
 // prepareAndSend prepares the HTTP request with the provided payload and sends it to the specified endpoint.
 // It serializes the payload to JSON, compresses it, and then executes the request.
 //
@@ -205,8 +460,14 @@ func (s *StreamSender) prepareAndSend(ctx context.Context, v any, endpoint strin
 		return fmt.Errorf("request preparation failed: %w", err)
 	}
 	req.SetContext(ctx)
+	// The server continues the trace of the batch from the W3C traceparent header.
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
-	if _, err = s.doRequest(req); err != nil {
+	resp, err := s.doRequest(req)
+	if resp != nil && resp.RawResponse != nil {
+		s.keys.observe(ctx, resp.Header())
+	}
+	if err != nil {
 		return fmt.Errorf("request execution failed: %w", err)
 	}
 
@@ -230,7 +491,14 @@ func (s *StreamSender) prepareRequest(v any, endpoint string) (*resty.Request, e
 		return nil, fmt.Errorf("serialization of metrics to JSON failed: %w", err)
 	}
 
-	req, err := s.requestBuilder.BuildWithParams(http.MethodPost, endpoint, data, s.signingKey, s.cryptoKey)
+	s.mu.Lock()
+	serverAddress := s.serverAddress
+	s.mu.Unlock()
+	// The request carries the absolute URL, so the server address can change while other requests are built.
+	signingKey, cryptoKey := s.keys.keys()
+	req, err := s.requestBuilder.BuildWithParams(
+		http.MethodPost, serverAddress+endpoint, data, signingKey, cryptoKey,
+	)
 	if err != nil {
 		return nil, fmt.Errorf("request with params build failed: %w", err)
 	}
@@ -261,3 +529,29 @@ func (s *StreamSender) doRequest(r *resty.Request) (resp *resty.Response, err er
 
 	return
 }
+
+// withScheme ensures the server address has the proper HTTP scheme.
+//
+// Parameters:
+//   - serverAddress: The server address, with or without a scheme.
+//
+// Returns:
+//   - string: The address prefixed with "http://" unless it already has a scheme.
+func withScheme(serverAddress string) string {
+	if !strings.HasPrefix(serverAddress, "http://") && !strings.HasPrefix(serverAddress, "https://") {
+		return "http://" + strings.TrimPrefix(serverAddress, "/")
+	}
+	return serverAddress
+}
+
+// clients returns the HTTP clients the sender talks to the server with.
+func (s *StreamSender) clients() []*resty.Client {
+	return []*resty.Client{s.httpClient, s.keys.client}
+}
+
+// stampAgentTime sets the agent clock on every request attempt, so the server can measure clock skew
+// without counting the time spent in retries.
+func stampAgentTime(_ *resty.Client, r *resty.Request) error {
+	r.SetHeader(headerAgentTime, time.Now().UTC().Format(time.RFC3339Nano))
+	return nil
+}
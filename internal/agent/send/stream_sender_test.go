@@ -1,16 +1,29 @@
 package send
 
 import (
+	"compress/gzip"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/agent/lifecycle"
+	"github.com/gdyunin/metricol.git/internal/agent/send/model"
+	"github.com/gdyunin/metricol.git/pkg/buildinfo"
+	"github.com/gdyunin/metricol.git/pkg/sign"
 	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -127,3 +140,330 @@ func TestStreamSender_SendBatch(t *testing.T) {
 		})
 	}
 }
+
+func TestStreamSender_AgentHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		agentID  string
+		expected string
+	}{
+		{name: "Agent ID configured", agentID: "host-1", expected: "host-1"},
+		{name: "No agent ID", agentID: "", expected: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotID, gotTime string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotID = r.Header.Get(headerAgentID)
+				gotTime = r.Header.Get(headerAgentTime)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer ts.Close()
+
+			sender := NewStreamSender(
+				make(chan *entity.Metrics), time.Second, 1, ts.URL, "", "", zap.NewNop().Sugar(),
+				WithAgentID(tc.agentID),
+			)
+
+			metrics := &entity.Metrics{{Name: "m", Type: entity.MetricTypeGauge, Value: 1.0}}
+			before := time.Now().Add(-time.Second)
+			if err := sender.SendBatch(context.Background(), metrics); err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+
+			if gotID != tc.expected {
+				t.Errorf("expected agent ID %q, got %q", tc.expected, gotID)
+			}
+			sentAt, err := time.Parse(time.RFC3339Nano, gotTime)
+			if err != nil {
+				t.Fatalf("invalid agent time %q: %v", gotTime, err)
+			}
+			if sentAt.Before(before) || sentAt.After(time.Now().Add(time.Second)) {
+				t.Errorf("agent time %v is not close to now", sentAt)
+			}
+		})
+	}
+}
+
+func TestStreamSender_APIKeyHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		expected string
+	}{
+		{name: "API key configured", key: "team-a", expected: "team-a"},
+		{name: "No API key", key: "", expected: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Get(headerAPIKey)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer ts.Close()
+
+			sender := NewStreamSender(
+				make(chan *entity.Metrics), time.Second, 1, ts.URL, "", "", zap.NewNop().Sugar(),
+				WithAPIKey(tc.key),
+			)
+			metrics := &entity.Metrics{{Name: "m", Type: entity.MetricTypeGauge, Value: 1.0}}
+			require.NoError(t, sender.SendBatch(context.Background(), metrics))
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestStreamSender_Dialer(t *testing.T) {
+	var served atomic.Bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served.Store(true)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	var dialed []string
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		var d net.Dialer
+		return d.DialContext(ctx, network, ts.Listener.Addr().String())
+	}
+
+	sender := NewStreamSender(
+		make(chan *entity.Metrics), time.Second, 1, "metrics.invalid:8080", "", "", zap.NewNop().Sugar(),
+		WithDialer(dial),
+	)
+	metrics := &entity.Metrics{{Name: "m", Type: entity.MetricTypeGauge, Value: 1.0}}
+	require.NoError(t, sender.SendBatch(context.Background(), metrics))
+	assert.True(t, served.Load())
+	assert.Equal(t, []string{"metrics.invalid:8080"}, dialed)
+}
+
+func TestStreamSender_BuildInfoHeaders(t *testing.T) {
+	var got http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	sender := NewStreamSender(
+		make(chan *entity.Metrics), time.Second, 1, ts.URL, "", "", zap.NewNop().Sugar(),
+		WithBuildInfo(buildinfo.New("1.2.0", "2024-05-01", "abc123")),
+	)
+	metrics := &entity.Metrics{{Name: "m", Type: entity.MetricTypeGauge, Value: 1.0}}
+	require.NoError(t, sender.SendBatch(context.Background(), metrics))
+
+	info, ok := buildinfo.FromAgentHeaders(got)
+	require.True(t, ok)
+	assert.Equal(t, buildinfo.Info{Version: "1.2.0", Date: "2024-05-01", Commit: "abc123"}, info)
+}
+
+func TestStreamSender_Healthy(t *testing.T) {
+	var failing atomic.Bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	sender := NewStreamSender(make(chan *entity.Metrics), time.Hour, 1, ts.URL, "", "", zap.NewNop().Sugar())
+	assert.ErrorIs(t, sender.Healthy(), lifecycle.ErrNotRunning)
+
+	done := make(chan struct{})
+	go func() {
+		sender.Start(context.Background())
+		close(done)
+	}()
+	require.Eventually(t, func() bool { return sender.Healthy() == nil }, time.Second, time.Millisecond)
+
+	metrics := &entity.Metrics{{Name: "m", Type: entity.MetricTypeGauge, Value: 1.0}}
+	failing.Store(true)
+	for range minWindowSends - 1 {
+		require.Error(t, sender.SendBatch(context.Background(), metrics))
+	}
+	assert.NoError(t, sender.Healthy(), "a few failures are tolerated")
+
+	require.Error(t, sender.SendBatch(context.Background(), metrics))
+	assert.ErrorContains(t, sender.Healthy(), "degraded mode after 100% of sends failed")
+	assert.Equal(t, time.Hour*degradedIntervalFactor, sender.sendInterval())
+
+	failing.Store(false)
+	for range minWindowSends {
+		require.NoError(t, sender.SendBatch(context.Background(), metrics))
+	}
+	assert.NoError(t, sender.Healthy(), "successful sends recover from degraded mode")
+	assert.Equal(t, time.Hour, sender.sendInterval())
+
+	sender.Stop()
+	<-done
+	assert.ErrorIs(t, sender.Healthy(), lifecycle.ErrNotRunning)
+}
+
+func TestStreamSender_Heartbeat(t *testing.T) {
+	received := make(chan []model.Metric, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var batch []model.Metric
+		if err = json.NewDecoder(gz).Decode(&batch); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		select {
+		case received <- batch:
+		default:
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	health := func() lifecycle.Health {
+		return lifecycle.Health{
+			Components: map[string]string{"collector": lifecycle.StatusOK, "sender": "server unreachable"},
+			Status:     lifecycle.StatusDegraded,
+		}
+	}
+	// A closed queue makes every tick send only the heartbeat.
+	queue := make(chan *entity.Metrics)
+	close(queue)
+	sender := NewStreamSender(queue, 10*time.Millisecond, 1, ts.URL, "", "", zap.NewNop().Sugar(), WithHeartbeat(health))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sender.Start(ctx)
+
+	select {
+	case batch := <-received:
+		values := make(map[string]float64, len(batch))
+		for _, m := range batch {
+			require.NotNil(t, m.Value)
+			values[m.ID] = *m.Value
+		}
+		assert.Equal(t, map[string]float64{heartbeatHealthyMetric: 0, heartbeatUnhealthyMetric: 1}, values)
+	case <-time.After(time.Second):
+		t.Fatal("no heartbeat received")
+	}
+}
+
+func TestStreamSender_RecordResult(t *testing.T) {
+	sender := &StreamSender{logger: zap.NewNop().Sugar()}
+	sendErr := errors.New("send failed")
+
+	sender.recordResult(sendErr)
+	sender.recordResult(sendErr)
+	assert.Equal(t, 2, sender.budget.failures)
+	assert.Equal(t, sendErr, sender.lastErr)
+
+	sender.recordResult(nil)
+	assert.Equal(t, 3, sender.budget.sends)
+	assert.Equal(t, sendErr, sender.lastErr, "the last failure is kept for health reports")
+}
+
+func TestStreamSender_DegradedBatches(t *testing.T) {
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	sender := NewStreamSender(make(chan *entity.Metrics), time.Hour, 1, ts.URL, "", "", zap.NewNop().Sugar())
+	metrics := make(entity.Metrics, 2*degradedBatchSize+1)
+	for i := range metrics {
+		metrics[i] = &entity.Metric{Name: fmt.Sprintf("m%d", i), Type: entity.MetricTypeGauge, Value: 1.0}
+	}
+
+	require.NoError(t, sender.SendBatch(context.Background(), &metrics))
+	assert.Equal(t, int32(1), requests.Load())
+
+	sender.budget.degraded = true
+	requests.Store(0)
+	require.NoError(t, sender.SendBatch(context.Background(), &metrics))
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestStreamSender_MaxBatchSize(t *testing.T) {
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	sender := NewStreamSender(
+		make(chan *entity.Metrics), time.Hour, 1, ts.URL, "", "", zap.NewNop().Sugar(), WithMaxBatchSize(10),
+	)
+	metrics := make(entity.Metrics, 25)
+	for i := range metrics {
+		metrics[i] = &entity.Metric{Name: fmt.Sprintf("m%d", i), Type: entity.MetricTypeGauge, Value: 1.0}
+	}
+
+	require.NoError(t, sender.SendBatch(context.Background(), &metrics))
+	assert.Equal(t, int32(3), requests.Load())
+
+	// A cap below the degraded batch size still applies in degraded mode.
+	sender.budget.degraded = true
+	requests.Store(0)
+	require.NoError(t, sender.SendBatch(context.Background(), &metrics))
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestStreamSender_Reconfigure(t *testing.T) {
+	type request struct {
+		server string
+		valid  bool
+	}
+	requests := make(chan request, 10)
+	handler := func(server string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			gz, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			body, err := io.ReadAll(gz)
+			require.NoError(t, err)
+			signature := base64.StdEncoding.EncodeToString(sign.MakeSign(body, "new"))
+			requests <- request{server: server, valid: r.Header.Get("HashSHA256") == signature}
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+	previous := httptest.NewServer(handler("previous"))
+	defer previous.Close()
+	current := httptest.NewServer(handler("current"))
+	defer current.Close()
+
+	queue := make(chan *entity.Metrics, 2)
+	for range 2 {
+		queue <- &entity.Metrics{{Name: "m", Type: entity.MetricTypeGauge, Value: 1.0}}
+	}
+	sender := NewStreamSender(queue, time.Hour, 1, previous.URL, "old", "", zap.NewNop().Sugar())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sender.Start(ctx)
+	}()
+
+	sender.SetServerAddress(current.URL)
+	sender.SetSigningKey("new")
+	sender.SetMaxPoolSize(2)
+	sender.SetInterval(10 * time.Millisecond)
+	for range 2 {
+		select {
+		case r := <-requests:
+			assert.Equal(t, request{server: "current", valid: true}, r)
+		case <-time.After(time.Second):
+			t.Fatal("expected the queued batches to be sent with the new settings")
+		}
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,95 @@
+package send
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// ClientOption configures the HTTP client of a one-off request to the server, such as FetchCapabilities.
+type ClientOption func(*resty.Client)
+
+// LoadTLSConfig builds the TLS settings for servers requiring mutual TLS or signed by a private CA.
+//
+// Parameters:
+//   - certFile: The PEM client certificate presented to the server; empty to present none.
+//   - keyFile: The PEM private key of the client certificate; required with certFile.
+//   - caFile: The PEM bundle of CAs the server certificate is verified against; empty to use the system pool.
+//
+// Returns:
+//   - *tls.Config: The TLS settings.
+//   - error: An error if a file cannot be read or holds no usable certificate.
+func LoadTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	if (certFile == "") != (keyFile == "") {
+		return nil, errors.New("the client certificate and key must be set together")
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		bundle, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// WithTLS makes the sender connect to the server with the given TLS settings, e.g. a client certificate
+// for mutual TLS. The server address must use the https scheme. Nil settings keep the defaults.
+//
+// Parameters:
+//   - cfg: The TLS settings, usually built by LoadTLSConfig.
+//
+// Returns:
+//   - Option: An option applying the TLS settings.
+func WithTLS(cfg *tls.Config) Option {
+	return func(s *StreamSender) {
+		if cfg == nil {
+			return
+		}
+		for _, client := range s.clients() {
+			client.SetTLSClientConfig(cfg)
+		}
+	}
+}
+
+// WithClientTLS makes a one-off request connect to the server with the given TLS settings.
+// Nil settings keep the defaults.
+//
+// Parameters:
+//   - cfg: The TLS settings, usually built by LoadTLSConfig.
+//
+// Returns:
+//   - ClientOption: An option applying the TLS settings.
+func WithClientTLS(cfg *tls.Config) ClientOption {
+	return func(client *resty.Client) {
+		if cfg != nil {
+			client.SetTLSClientConfig(cfg)
+		}
+	}
+}
+
+// newClient creates the client of a one-off request to the server.
+func newClient(serverAddress string, opts []ClientOption) *resty.Client {
+	client := resty.New().SetBaseURL(withScheme(serverAddress))
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
+}
@@ -0,0 +1,188 @@
+package send
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// testCA issues certificates for the TLS tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key signed by the CA.
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// writeFile writes data to a file in dir and returns its path.
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+// newMTLSServer starts a server accepting only clients with a certificate issued by ca.
+func newMTLSServer(t *testing.T, ca *testCA) *httptest.Server {
+	t.Helper()
+	certPEM, keyPEM := ca.issue(t, "server", x509.ExtKeyUsageServerAuth)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == capabilitiesEndpoint {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"encodings":["gzip"]}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	ts.TLS = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+	}
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, "agent", x509.ExtKeyUsageClientAuth)
+	certFile := writeFile(t, dir, "agent.crt", certPEM)
+	keyFile := writeFile(t, dir, "agent.key", keyPEM)
+	caFile := writeFile(t, dir, "ca.crt", ca.pem)
+
+	cfg, err := LoadTLSConfig(certFile, keyFile, caFile)
+	require.NoError(t, err)
+	assert.Len(t, cfg.Certificates, 1)
+	assert.NotNil(t, cfg.RootCAs)
+
+	cfg, err = LoadTLSConfig("", "", caFile)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Certificates)
+
+	tests := []struct {
+		name, cert, key, ca string
+	}{
+		{name: "certificate without key", cert: certFile},
+		{name: "key without certificate", key: keyFile},
+		{name: "missing certificate", cert: filepath.Join(dir, "missing.crt"), key: keyFile},
+		{name: "missing CA bundle", ca: filepath.Join(dir, "missing.crt")},
+		{name: "CA bundle without certificates", ca: keyFile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadTLSConfig(tt.cert, tt.key, tt.ca)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestStreamSender_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	ts := newMTLSServer(t, ca)
+	certPEM, keyPEM := ca.issue(t, "agent", x509.ExtKeyUsageClientAuth)
+	cfg, err := LoadTLSConfig(
+		writeFile(t, dir, "agent.crt", certPEM),
+		writeFile(t, dir, "agent.key", keyPEM),
+		writeFile(t, dir, "ca.crt", ca.pem),
+	)
+	require.NoError(t, err)
+	metrics := &entity.Metrics{{Name: "m", Type: entity.MetricTypeGauge, Value: 1.0}}
+
+	sender := NewStreamSender(make(chan *entity.Metrics), time.Second, 1, ts.URL, "", "", zap.NewNop().Sugar(),
+		WithTLS(cfg), WithDialer((&net.Dialer{}).DialContext))
+	require.NoError(t, sender.SendBatch(context.Background(), metrics))
+
+	caOnly, err := LoadTLSConfig("", "", writeFile(t, dir, "ca-only.crt", ca.pem))
+	require.NoError(t, err)
+	sender = NewStreamSender(make(chan *entity.Metrics), time.Second, 1, ts.URL, "", "", zap.NewNop().Sugar(),
+		WithTLS(caOnly))
+	require.Error(t, sender.SendBatch(context.Background(), metrics), "the server requires a client certificate")
+}
+
+func TestFetchCapabilities_ClientTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	ts := newMTLSServer(t, ca)
+	certPEM, keyPEM := ca.issue(t, "agent", x509.ExtKeyUsageClientAuth)
+	cfg, err := LoadTLSConfig(
+		writeFile(t, dir, "agent.crt", certPEM),
+		writeFile(t, dir, "agent.key", keyPEM),
+		writeFile(t, dir, "ca.crt", ca.pem),
+	)
+	require.NoError(t, err)
+
+	caps, err := FetchCapabilities(context.Background(), ts.URL, WithClientTLS(cfg))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gzip"}, caps.Encodings)
+
+	_, err = FetchCapabilities(context.Background(), ts.URL)
+	require.Error(t, err, "the server certificate is not trusted without the CA bundle")
+}
@@ -0,0 +1,66 @@
+package send
+
+import (
+	"context"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// Const tracerName names the tracer of the send spans.
+	tracerName = "github.com/gdyunin/metricol.git/internal/agent/send"
+	// Const sendSpanName names the span of a batch from its collection until the server stored it.
+	sendSpanName = "metrics.send"
+)
+
+// attrBatchSize is the span attribute holding the number of metrics in a batch.
+var attrBatchSize = attribute.Key("metricol.batch.size")
+
+// startSendSpan starts the span of a batch. The span starts when the oldest metric of the batch was
+// collected, so a trace shows the time a batch waited in the agent next to the time the server took
+// to store it.
+//
+// Parameters:
+//   - ctx: The context of the send.
+//   - metrics: The batch.
+//
+// Returns:
+//   - context.Context: The context carrying the span, propagated to the server in the request headers.
+//   - trace.Span: The started span.
+func startSendSpan(ctx context.Context, metrics *entity.Metrics) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrBatchSize.Int(metrics.Length())),
+	}
+	if metrics != nil {
+		if collected := oldestTimestamp(*metrics); !collected.IsZero() {
+			opts = append(opts, trace.WithTimestamp(collected))
+		}
+	}
+	return otel.Tracer(tracerName).Start(ctx, sendSpanName, opts...)
+}
+
+// oldestTimestamp returns the collection time of the oldest metric, or the zero time if no metric has one.
+//
+// Parameters:
+//   - metrics: The batch.
+//
+// Returns:
+//   - time.Time: The oldest timestamp.
+func oldestTimestamp(metrics entity.Metrics) time.Time {
+	var oldest time.Time
+	for _, m := range metrics {
+		if m == nil || m.Timestamp.IsZero() {
+			continue
+		}
+		if oldest.IsZero() || m.Timestamp.Before(oldest) {
+			oldest = m.Timestamp
+		}
+	}
+	return oldest
+}
@@ -0,0 +1,87 @@
+package send
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+func TestStreamSender_TraceContext(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	var received trace.SpanContext
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagation.TraceContext{}.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		received = trace.SpanContextFromContext(ctx)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	collected := time.Now().Add(-5 * time.Second)
+	sender := NewStreamSender(make(chan *entity.Metrics), time.Second, 1, ts.URL, "", "", zap.NewNop().Sugar())
+	metrics := &entity.Metrics{
+		{Name: "a", Type: entity.MetricTypeGauge, Value: 1.0, Timestamp: collected.Add(time.Second)},
+		{Name: "b", Type: entity.MetricTypeGauge, Value: 2.0, Timestamp: collected},
+	}
+	require.NoError(t, sender.SendBatch(context.Background(), metrics))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, sendSpanName, span.Name())
+	assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+	assert.True(t, span.StartTime().Equal(collected), "the span starts when the oldest metric was collected")
+	assert.Contains(t, span.Attributes(), attrBatchSize.Int(2))
+	assert.Equal(t, span.SpanContext().TraceID(), received.TraceID(), "the server continues the batch trace")
+	assert.Equal(t, span.SpanContext().SpanID(), received.SpanID())
+	assert.True(t, received.IsSampled())
+}
+
+func TestOldestTimestamp(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		metrics  entity.Metrics
+		expected time.Time
+	}{
+		{name: "Empty batch", metrics: entity.Metrics{}, expected: time.Time{}},
+		{
+			name:     "Without timestamps",
+			metrics:  entity.Metrics{{Name: "a"}, nil},
+			expected: time.Time{},
+		},
+		{
+			name: "Oldest of several",
+			metrics: entity.Metrics{
+				{Name: "a", Timestamp: now},
+				{Name: "b"},
+				{Name: "c", Timestamp: now.Add(-time.Minute)},
+			},
+			expected: now.Add(-time.Minute),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.expected.Equal(oldestTimestamp(tt.metrics)))
+		})
+	}
+}
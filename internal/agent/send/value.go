@@ -0,0 +1,82 @@
+package send
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gdyunin/metricol.git/internal/agent/send/model"
+	"github.com/gdyunin/metricol.git/pkg/sign"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	// Const valueEndpoint defines the API endpoint returning the stored value of a metric.
+	valueEndpoint = "/value"
+	// Const headerSign carries the HMAC-SHA256 signature of a body.
+	headerSign = "HashSHA256"
+)
+
+// ErrResponseSignature is returned when a server response is not signed with the agent's signing key.
+var ErrResponseSignature = errors.New("invalid response signature")
+
+// Value requests the value the server stores for a metric. If a signing key is set, the response must carry
+// a valid signature of its body, so a value altered on the way or served by an impostor is rejected.
+//
+// Parameters:
+//   - ctx: The context for the HTTP request.
+//   - metricType: The type of the metric, gauge or counter.
+//   - name: The name of the metric.
+//
+// Returns:
+//   - *model.Metric: The stored metric.
+//   - error: ErrResponseSignature if the response signature is missing or invalid,
+//     or an error if the request fails.
+func (s *StreamSender) Value(ctx context.Context, metricType, name string) (*model.Metric, error) {
+	req, err := s.prepareRequest(model.Metric{ID: name, MType: metricType}, valueEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("request preparation failed: %w", err)
+	}
+	req.SetContext(ctx)
+
+	resp, err := s.doRequest(req)
+	if err != nil {
+		return nil, fmt.Errorf("request execution failed: %w", err)
+	}
+
+	signingKey, _ := s.keys.keys()
+	if err = verifyResponse(resp, signingKey); err != nil {
+		return nil, err
+	}
+
+	var metric model.Metric
+	if err = json.Unmarshal(resp.Body(), &metric); err != nil {
+		return nil, fmt.Errorf("failed to decode metric: %w", err)
+	}
+	return &metric, nil
+}
+
+// verifyResponse checks the signature of a response body against the signing key. The server sends it
+// in the HashSHA256 header, or in a trailer of the same name for streamed responses. An empty key skips
+// the check.
+func verifyResponse(resp *resty.Response, signingKey string) error {
+	if signingKey == "" {
+		return nil
+	}
+
+	value := resp.Header().Get(headerSign)
+	if value == "" && resp.RawResponse != nil {
+		value = resp.RawResponse.Trailer.Get(headerSign)
+	}
+	if value == "" {
+		return fmt.Errorf("%w: response is not signed", ErrResponseSignature)
+	}
+	signature, err := hex.DecodeString(value)
+	if err != nil || !sign.Verify(resp.Body(), signature, signingKey) {
+		return fmt.Errorf("%w: signature does not match the body", ErrResponseSignature)
+	}
+	return nil
+}
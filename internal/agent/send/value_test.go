@@ -0,0 +1,87 @@
+package send
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/sign"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestStreamSender_Value(t *testing.T) {
+	const body = `{"value":1.5,"id":"Alloc","type":"gauge"}`
+	validSign := hex.EncodeToString(sign.MakeSign([]byte(body), "secret"))
+
+	tests := []struct {
+		name      string
+		agentKey  string
+		header    string
+		trailer   string
+		expectErr bool
+	}{
+		{name: "Signed response", agentKey: "secret", header: validSign},
+		{name: "Signed in trailer", agentKey: "secret", trailer: validSign},
+		{
+			name:      "Tampered response",
+			agentKey:  "secret",
+			header:    hex.EncodeToString(sign.MakeSign([]byte("{}"), "secret")),
+			expectErr: true,
+		},
+		{name: "Signed with another key", agentKey: "another", header: validSign, expectErr: true},
+		{name: "Unsigned response", agentKey: "secret", expectErr: true},
+		{name: "Malformed signature", agentKey: "secret", header: "not-hex", expectErr: true},
+		{name: "No signing key", agentKey: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, valueEndpoint, r.URL.Path)
+				if tt.trailer != "" {
+					w.Header().Set("Trailer", headerSign)
+				}
+				if tt.header != "" {
+					w.Header().Set(headerSign, tt.header)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(body))
+				if tt.trailer != "" {
+					w.Header().Set(headerSign, tt.trailer)
+				}
+			}))
+			defer ts.Close()
+
+			sender := NewStreamSender(
+				make(chan *entity.Metrics), time.Second, 1, ts.URL, tt.agentKey, "", zap.NewNop().Sugar(),
+			)
+			metric, err := sender.Value(context.Background(), entity.MetricTypeGauge, "Alloc")
+			if tt.expectErr {
+				assert.ErrorIs(t, err, ErrResponseSignature)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Alloc", metric.ID)
+			require.NotNil(t, metric.Value)
+			assert.InEpsilon(t, 1.5, *metric.Value, 1e-9)
+		})
+	}
+}
+
+func TestStreamSender_ValueNotFound(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	sender := NewStreamSender(make(chan *entity.Metrics), time.Second, 1, ts.URL, "", "", zap.NewNop().Sugar())
+	_, err := sender.Value(context.Background(), entity.MetricTypeGauge, "Missing")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrResponseSignature)
+}
@@ -0,0 +1,18 @@
+//go:build !nogopsutil
+
+package throttle
+
+import (
+	"fmt"
+
+	"github.com/shirou/gopsutil/v4/load"
+)
+
+// hostLoad returns the 1-minute load average of the host.
+func hostLoad() (float64, error) {
+	avg, err := load.Avg()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read load average: %w", err)
+	}
+	return avg.Load1, nil
+}
@@ -0,0 +1,10 @@
+//go:build nogopsutil
+
+package throttle
+
+import "errors"
+
+// hostLoad is not supported in builds without gopsutil.
+func hostLoad() (float64, error) {
+	return 0, errors.New("reading the host load is not supported in builds with the nogopsutil tag")
+}
@@ -0,0 +1,87 @@
+package throttle
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+)
+
+const (
+	// Const bytesPerMB converts megabytes to bytes.
+	bytesPerMB = 1 << 20
+	// Const MinMemoryBudgetMB is the smallest memory budget the agent can run in.
+	MinMemoryBudgetMB = 32
+	// Const baselineMemoryMB is the memory the agent needs regardless of its limits: the runtime,
+	// the HTTP clients and the collection strategies.
+	baselineMemoryMB = 24
+	// Const bytesPerSentMetric estimates the memory a metric takes while it is sent: its model,
+	// its JSON encoding, the compressed and encrypted bodies and the copy kept for retries.
+	bytesPerSentMetric = 2048
+	// Const bytesPerQueuedMetric estimates the memory a collected metric takes while it waits to be sent.
+	bytesPerQueuedMetric = 256
+	// Const minBatchSize keeps batches large enough for the request overhead not to dominate.
+	minBatchSize = 50
+	// Const maxBatchSize caps batches, as larger ones gain nothing on small devices.
+	maxBatchSize = 5000
+	// Const maxQueueSize caps the send queue derived from large budgets.
+	maxQueueSize = 1000
+	// Const softLimitPercent is the share of the budget set as the Go runtime soft memory limit,
+	// leaving room for memory the runtime does not manage.
+	softLimitPercent = 90
+)
+
+// MemoryLimits are the agent limits derived from a memory budget.
+type MemoryLimits struct {
+	SoftLimit int64 // SoftLimit is the Go runtime soft memory limit in bytes.
+	BatchSize int   // BatchSize is the maximum number of metrics sent in one request.
+	QueueSize int   // QueueSize is the number of collected batches that may wait to be sent.
+}
+
+// DeriveMemoryLimits splits a memory budget between the batches being sent and the batches waiting
+// in the send queue, so the agent runs predictably on devices with 64–128 MB of memory.
+// A quarter of the memory left after the baseline goes to each.
+//
+// Parameters:
+//   - budgetMB: The memory budget in megabytes.
+//   - senders: The number of batches sent concurrently.
+//
+// Returns:
+//   - MemoryLimits: The derived limits.
+//   - error: An error if the budget is below MinMemoryBudgetMB.
+func DeriveMemoryLimits(budgetMB, senders int) (MemoryLimits, error) {
+	if budgetMB < MinMemoryBudgetMB {
+		return MemoryLimits{}, fmt.Errorf("memory budget of %d MB is below the minimum of %d MB", budgetMB, MinMemoryBudgetMB)
+	}
+	senders = max(senders, 1)
+
+	share := int64(budgetMB-baselineMemoryMB) * bytesPerMB / 4 //nolint:mnd // A quarter each, see above.
+	batchSize := clamp(int(share/int64(senders*bytesPerSentMetric)), minBatchSize, maxBatchSize)
+	queueSize := clamp(int(share/int64(batchSize*bytesPerQueuedMetric)), 1, maxQueueSize)
+
+	return MemoryLimits{
+		SoftLimit: int64(budgetMB) * bytesPerMB * softLimitPercent / 100, //nolint:mnd // Percent.
+		BatchSize: batchSize,
+		QueueSize: queueSize,
+	}, nil
+}
+
+// ApplyMemoryLimit sets the Go runtime soft memory limit, making the garbage collector work harder
+// as the agent approaches it. A limit set with the GOMEMLIMIT environment variable takes precedence.
+//
+// Parameters:
+//   - limits: The limits derived from the memory budget.
+//
+// Returns:
+//   - bool: False if GOMEMLIMIT is set and the limit was left unchanged.
+func ApplyMemoryLimit(limits MemoryLimits) bool {
+	if _, set := os.LookupEnv("GOMEMLIMIT"); set {
+		return false
+	}
+	debug.SetMemoryLimit(limits.SoftLimit)
+	return true
+}
+
+// clamp limits v to the range [lo, hi].
+func clamp(v, lo, hi int) int {
+	return min(max(v, lo), hi)
+}
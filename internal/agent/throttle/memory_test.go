@@ -0,0 +1,71 @@
+package throttle
+
+import (
+	"os"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveMemoryLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		budgetMB    int
+		senders     int
+		expected    MemoryLimits
+		expectError bool
+	}{
+		{
+			name:     "Smallest budget",
+			budgetMB: MinMemoryBudgetMB,
+			senders:  1,
+			expected: MemoryLimits{SoftLimit: 30198988, BatchSize: 1024, QueueSize: 8},
+		},
+		{
+			name:     "64 MB device caps the batch size",
+			budgetMB: 64,
+			senders:  1,
+			expected: MemoryLimits{SoftLimit: 60397977, BatchSize: maxBatchSize, QueueSize: 8},
+		},
+		{
+			name:     "Concurrent senders share the budget",
+			budgetMB: 128,
+			senders:  4,
+			expected: MemoryLimits{SoftLimit: 120795955, BatchSize: 3328, QueueSize: 32},
+		},
+		{
+			name:     "No senders counts as one",
+			budgetMB: 64,
+			senders:  0,
+			expected: MemoryLimits{SoftLimit: 60397977, BatchSize: maxBatchSize, QueueSize: 8},
+		},
+		{name: "Budget below the minimum", budgetMB: 16, senders: 1, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limits, err := DeriveMemoryLimits(tt.budgetMB, tt.senders)
+			if tt.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, limits)
+		})
+	}
+}
+
+func TestApplyMemoryLimit(t *testing.T) {
+	previous := debug.SetMemoryLimit(-1)
+	defer debug.SetMemoryLimit(previous)
+
+	t.Setenv("GOMEMLIMIT", "")
+	assert.False(t, ApplyMemoryLimit(MemoryLimits{SoftLimit: 64 << 20}))
+	assert.Equal(t, previous, debug.SetMemoryLimit(-1))
+
+	require.NoError(t, os.Unsetenv("GOMEMLIMIT"))
+	assert.True(t, ApplyMemoryLimit(MemoryLimits{SoftLimit: 64 << 20}))
+	assert.Equal(t, int64(64<<20), debug.SetMemoryLimit(-1))
+}
@@ -0,0 +1,32 @@
+package throttle
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// setNice changes the priority of every thread of the process. On Linux the priority is a per-thread
+// attribute, so setting it for the process ID alone would leave the other runtime threads untouched;
+// threads started later inherit the priority from the thread creating them.
+func setNice(nice int) error {
+	tasks, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		if err = syscall.Setpriority(syscall.PRIO_PROCESS, 0, nice); err != nil {
+			return fmt.Errorf("process: %w", err)
+		}
+		return nil
+	}
+
+	for _, task := range tasks {
+		tid, convErr := strconv.Atoi(task.Name())
+		if convErr != nil {
+			continue
+		}
+		if err = syscall.Setpriority(syscall.PRIO_PROCESS, tid, nice); err != nil {
+			return fmt.Errorf("thread %d: %w", tid, err)
+		}
+	}
+	return nil
+}
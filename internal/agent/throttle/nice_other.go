@@ -0,0 +1,10 @@
+//go:build !unix
+
+package throttle
+
+import "errors"
+
+// setNice is not supported outside Unix systems.
+func setNice(int) error {
+	return errors.New("changing the process priority is not supported on this platform")
+}
@@ -0,0 +1,16 @@
+//go:build unix && !linux
+
+package throttle
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// setNice changes the priority of the process.
+func setNice(nice int) error {
+	if err := syscall.Setpriority(syscall.PRIO_PROCESS, 0, nice); err != nil {
+		return fmt.Errorf("process: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// Package throttle keeps the agent unobtrusive on busy machines. It caps the CPU the agent process
+// may use, skips collection cycles while the host is overloaded and fits the agent into a memory budget
+// on small devices.
+package throttle
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// ApplyProcessLimits caps the CPU usage of the agent process.
+//
+// Parameters:
+//   - maxProcs: The maximum number of OS threads executing Go code simultaneously; non-positive keeps the default.
+//   - nice: The scheduling priority of the process, from -20 (highest) to 19 (lowest); zero keeps the current one.
+//
+// Returns:
+//   - error: An error if the priority cannot be changed, e.g. raising it without privileges.
+func ApplyProcessLimits(maxProcs, nice int) error {
+	if maxProcs > 0 {
+		runtime.GOMAXPROCS(maxProcs)
+	}
+	if nice != 0 {
+		if err := setNice(nice); err != nil {
+			return fmt.Errorf("failed to set nice level %d: %w", nice, err)
+		}
+	}
+	return nil
+}
+
+// LoadGuard decides whether a collection cycle may run based on the host load average.
+type LoadGuard struct {
+	logger  *zap.SugaredLogger      // logger reports skipped cycles.
+	loadFn  func() (float64, error) // loadFn returns the 1-minute load average of the host.
+	skipped atomic.Int64            // skipped counts cycles skipped because of a high load.
+	maxLoad float64                 // maxLoad is the load average above which cycles are skipped.
+}
+
+// NewLoadGuard creates a LoadGuard skipping cycles while the 1-minute load average exceeds maxLoad.
+// A non-positive maxLoad never skips a cycle.
+//
+// Parameters:
+//   - maxLoad: The load average threshold.
+//   - logger: Logger reporting skipped cycles.
+//
+// Returns:
+//   - *LoadGuard: The guard.
+func NewLoadGuard(maxLoad float64, logger *zap.SugaredLogger) *LoadGuard {
+	return &LoadGuard{
+		logger:  logger,
+		loadFn:  hostLoad,
+		maxLoad: maxLoad,
+	}
+}
+
+// Allow reports whether a collection cycle may run now.
+// If the load average cannot be read, the cycle runs, so the agent keeps working on unsupported platforms.
+//
+// Returns:
+//   - bool: False if the host load exceeds the threshold.
+func (g *LoadGuard) Allow() bool {
+	if g.maxLoad <= 0 {
+		return true
+	}
+
+	current, err := g.loadFn()
+	if err != nil {
+		g.logger.Warnf("Failed to read host load, collecting anyway: %v", err)
+		return true
+	}
+	if current <= g.maxLoad {
+		return true
+	}
+
+	g.skipped.Add(1)
+	g.logger.Infof("Host load %.2f exceeds %.2f, skipping collection cycle", current, g.maxLoad)
+	return false
+}
+
+// Skipped returns the number of collection cycles skipped because of a high load.
+//
+// Returns:
+//   - int64: The number of skipped cycles.
+func (g *LoadGuard) Skipped() int64 {
+	return g.skipped.Load()
+}
@@ -0,0 +1,48 @@
+package throttle
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestApplyProcessLimits(t *testing.T) {
+	previous := runtime.GOMAXPROCS(0)
+	defer runtime.GOMAXPROCS(previous)
+
+	assert.NoError(t, ApplyProcessLimits(1, 0))
+	assert.Equal(t, 1, runtime.GOMAXPROCS(0))
+
+	assert.NoError(t, ApplyProcessLimits(0, 0), "zero limits must leave the process untouched")
+	assert.Equal(t, 1, runtime.GOMAXPROCS(0))
+}
+
+func TestLoadGuard_Allow(t *testing.T) {
+	tests := []struct {
+		loadErr     error
+		name        string
+		maxLoad     float64
+		load        float64
+		expected    bool
+		wantSkipped int64
+	}{
+		{name: "Disabled", maxLoad: 0, load: 100, expected: true},
+		{name: "Below threshold", maxLoad: 2, load: 1.5, expected: true},
+		{name: "At threshold", maxLoad: 2, load: 2, expected: true},
+		{name: "Above threshold", maxLoad: 2, load: 2.5, expected: false, wantSkipped: 1},
+		{name: "Load unavailable", maxLoad: 2, loadErr: errors.New("not supported"), expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewLoadGuard(tt.maxLoad, zap.NewNop().Sugar())
+			g.loadFn = func() (float64, error) { return tt.load, tt.loadErr }
+
+			assert.Equal(t, tt.expected, g.Allow())
+			assert.Equal(t, tt.wantSkipped, g.Skipped())
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Package replay loads captured HTTP traffic and sends it again to a server, keeping the original pacing.
+// It reads the records served by the server's /debug/requests endpoint as well as HAR-like files
+// exported from browsers and proxies.
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// Entry is a single captured request.
+type Entry struct {
+	Time   time.Time   // Time is when the request was originally sent.
+	Header http.Header // Header holds the original request headers.
+	Method string      // Method is the request method.
+	Path   string      // Path is the request path with the query.
+	Body   []byte      // Body is the decoded request body.
+}
+
+// recordedRequest is a request as served by /debug/requests.
+type recordedRequest struct {
+	Time           time.Time   `json:"time"`
+	RequestHeaders http.Header `json:"request_headers"`
+	Method         string      `json:"method"`
+	Path           string      `json:"path"`
+	RequestBody    string      `json:"request_body"`
+}
+
+// harFile is the subset of the HAR format needed to replay requests.
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			StartedDateTime time.Time `json:"startedDateTime"`
+			Request         struct {
+				PostData *struct {
+					Text string `json:"text"`
+				} `json:"postData"`
+				Method  string `json:"method"`
+				URL     string `json:"url"`
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+			} `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// Load reads captured requests and returns them ordered by the time they were sent.
+// The input is either a JSON array of records as served by /debug/requests or a HAR file.
+//
+// Parameters:
+//   - r: The reader providing the captured traffic.
+//
+// Returns:
+//   - []Entry: The captured requests, oldest first.
+//   - error: An error if the input cannot be read or has an unknown format.
+func Load(r io.Reader) ([]Entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read captured traffic: %w", err)
+	}
+
+	var entries []Entry
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		entries, err = loadRecords(trimmed)
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		entries, err = loadHAR(trimmed)
+	default:
+		err = errors.New("expected a JSON array of recorded requests or a HAR object")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries, nil
+}
+
+// loadRecords parses records served by /debug/requests.
+func loadRecords(data []byte) ([]Entry, error) {
+	var records []recordedRequest
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse recorded requests: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(records))
+	for _, r := range records {
+		entries = append(entries, Entry{
+			Time:   r.Time,
+			Header: r.RequestHeaders,
+			Method: r.Method,
+			Path:   r.Path,
+			Body:   []byte(r.RequestBody),
+		})
+	}
+	return entries, nil
+}
+
+// loadHAR parses the requests of a HAR file. Absolute URLs are reduced to their path and query,
+// so the requests can be sent to another server.
+func loadHAR(data []byte) ([]Entry, error) {
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("failed to parse HAR file: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(har.Log.Entries))
+	for i, e := range har.Log.Entries {
+		u, err := url.Parse(e.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d has an invalid URL: %w", i, err)
+		}
+
+		header := make(http.Header, len(e.Request.Headers))
+		for _, h := range e.Request.Headers {
+			header.Add(h.Name, h.Value)
+		}
+		var body []byte
+		if e.Request.PostData != nil {
+			body = []byte(e.Request.PostData.Text)
+		}
+
+		entries = append(entries, Entry{
+			Time:   e.StartedDateTime,
+			Header: header,
+			Method: e.Request.Method,
+			Path:   u.RequestURI(),
+			Body:   body,
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,76 @@
+package replay
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	first := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		input       string
+		expected    []Entry
+		expectError bool
+	}{
+		{
+			name: "Recorded requests",
+			input: `[
+				{"time":"2025-01-01T12:00:02Z","method":"POST","path":"/updates",
+				 "request_headers":{"Content-Type":["application/json"]},"request_body":"[]"},
+				{"time":"2025-01-01T12:00:00Z","method":"POST","path":"/update","request_body":"{}"}
+			]`,
+			expected: []Entry{
+				{Time: first, Method: "POST", Path: "/update", Body: []byte("{}")},
+				{
+					Time:   first.Add(2 * time.Second),
+					Method: "POST",
+					Path:   "/updates",
+					Header: map[string][]string{"Content-Type": {"application/json"}},
+					Body:   []byte("[]"),
+				},
+			},
+		},
+		{
+			name: "HAR file",
+			input: `{"log":{"entries":[
+				{"startedDateTime":"2025-01-01T12:00:00Z","request":{
+					"method":"POST","url":"http://prod:8080/update?x=1",
+					"headers":[{"name":"Content-Type","value":"application/json"}],
+					"postData":{"text":"{}"}}},
+				{"startedDateTime":"2025-01-01T12:00:01Z","request":{
+					"method":"GET","url":"http://prod:8080/ping","headers":[]}}
+			]}}`,
+			expected: []Entry{
+				{
+					Time:   first,
+					Method: "POST",
+					Path:   "/update?x=1",
+					Header: map[string][]string{"Content-Type": {"application/json"}},
+					Body:   []byte("{}"),
+				},
+				{Time: first.Add(time.Second), Method: "GET", Path: "/ping", Header: map[string][]string{}},
+			},
+		},
+		{name: "Unknown format", input: "method=POST", expectError: true},
+		{name: "Malformed records", input: `[{"time":1}]`, expectError: true},
+		{name: "Malformed HAR URL", input: `{"log":{"entries":[{"request":{"url":"%zz"}}]}}`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := Load(strings.NewReader(tt.input))
+			if tt.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, entries)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gdyunin/metricol.git/pkg/sign"
+)
+
+// droppedHeaders lists the captured headers that are not sent again: recorded bodies are already decoded,
+// connection details belong to the new request and credentials were redacted when recording.
+var droppedHeaders = []string{
+	"Accept-Encoding",
+	"Authorization",
+	"Content-Encoding",
+	"Content-Length",
+	"HashSHA256",
+	"Host",
+	"X-API-Key",
+	"X-Debug-Record",
+	"X-Encrypted-Key",
+	"X-Encryption-Scheme",
+	"X-Request-Id",
+}
+
+// Result describes the outcome of a replayed request.
+type Result struct {
+	Err     error         // Err is set if the request could not be sent.
+	Entry   Entry         // Entry is the replayed request.
+	Status  int           // Status is the response status code, 0 if the request failed.
+	Elapsed time.Duration // Elapsed is the time until the response was received.
+}
+
+// Replayer sends captured requests to a target server.
+type Replayer struct {
+	client  *http.Client // client sends the requests.
+	target  string       // target is the base URL of the server, e.g. http://localhost:8080.
+	signKey string       // signKey re-signs request bodies if set.
+	speed   float64      // speed scales the pacing; 2 replays twice as fast, 0 sends without pauses.
+}
+
+// NewReplayer creates a Replayer.
+//
+// Parameters:
+//   - client: The HTTP client sending the requests.
+//   - target: The base URL of the target server.
+//   - speed: The pacing factor; 1 keeps the original timing, 2 halves the pauses, 0 sends without pauses.
+//   - signKey: The key used to sign request bodies with HMAC-SHA256; empty sends them unsigned.
+//
+// Returns:
+//   - *Replayer: A pointer to the created Replayer.
+func NewReplayer(client *http.Client, target string, speed float64, signKey string) *Replayer {
+	return &Replayer{
+		client:  client,
+		target:  strings.TrimRight(target, "/"),
+		signKey: signKey,
+		speed:   speed,
+	}
+}
+
+// Run sends the entries in order, keeping the original pauses between them scaled by the speed.
+// A failing request does not stop the replay; its error is passed to report.
+//
+// Parameters:
+//   - ctx: The context stopping the replay when canceled.
+//   - entries: The requests to send, oldest first.
+//   - report: The function receiving the outcome of every request.
+//
+// Returns:
+//   - error: The context error if the replay was canceled before all requests were sent.
+func (r *Replayer) Run(ctx context.Context, entries []Entry, report func(Result)) error {
+	start := time.Now()
+	for _, entry := range entries {
+		if err := r.wait(ctx, start, entry.Time.Sub(entries[0].Time)); err != nil {
+			return err
+		}
+		report(r.send(ctx, entry))
+	}
+	return nil
+}
+
+// wait sleeps until the scaled offset of an entry has passed since the replay started.
+func (r *Replayer) wait(ctx context.Context, start time.Time, offset time.Duration) error {
+	var delay time.Duration
+	if r.speed > 0 {
+		delay = time.Duration(float64(offset)/r.speed) - time.Since(start)
+	}
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("replay canceled: %w", err)
+	}
+	return nil
+}
+
+// send sends a single entry to the target server.
+func (r *Replayer) send(ctx context.Context, entry Entry) Result {
+	result := Result{Entry: entry}
+
+	req, err := http.NewRequestWithContext(ctx, entry.Method, r.target+entry.Path, bytes.NewReader(entry.Body))
+	if err != nil {
+		result.Err = fmt.Errorf("failed to build request: %w", err)
+		return result
+	}
+	req.Header = entry.Header.Clone()
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	for _, h := range droppedHeaders {
+		req.Header.Del(h)
+	}
+	if r.signKey != "" && len(entry.Body) > 0 {
+		req.Header.Set("HashSHA256", base64.StdEncoding.EncodeToString(sign.MakeSign(entry.Body, r.signKey)))
+	}
+
+	start := time.Now()
+	resp, err := r.client.Do(req)
+	result.Elapsed = time.Since(start)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to send request: %w", err)
+		return result
+	}
+	_ = resp.Body.Close()
+
+	result.Status = resp.StatusCode
+	return result
+}
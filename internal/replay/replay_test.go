@@ -0,0 +1,131 @@
+package replay
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/pkg/sign"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type receivedRequest struct {
+	header http.Header
+	method string
+	path   string
+	body   string
+}
+
+func newTargetServer(t *testing.T) (*httptest.Server, func() []receivedRequest) {
+	t.Helper()
+
+	var (
+		mu       sync.Mutex
+		received []receivedRequest
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		mu.Lock()
+		received = append(received, receivedRequest{
+			header: r.Header.Clone(),
+			method: r.Method,
+			path:   r.URL.RequestURI(),
+			body:   string(body),
+		})
+		mu.Unlock()
+
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []receivedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return received
+	}
+}
+
+func TestReplayer_Run(t *testing.T) {
+	server, received := newTargetServer(t)
+
+	start := time.Now()
+	entries := []Entry{
+		{
+			Time:   start,
+			Method: http.MethodPost,
+			Path:   "/update?x=1",
+			Header: http.Header{
+				"Content-Type":     {"application/json"},
+				"Content-Encoding": {"gzip"},
+				"Authorization":    {"[redacted]"},
+			},
+			Body: []byte(`{"id":"x"}`),
+		},
+		{Time: start.Add(100 * time.Millisecond), Method: http.MethodGet, Path: "/missing"},
+	}
+
+	results := make([]Result, 0)
+	replayer := NewReplayer(server.Client(), server.URL+"/", 2, "secret")
+	began := time.Now()
+	err := replayer.Run(context.Background(), entries, func(r Result) { results = append(results, r) })
+	require.NoError(t, err)
+
+	// The 100ms pause is replayed at double speed.
+	assert.GreaterOrEqual(t, time.Since(began), 50*time.Millisecond)
+
+	require.Len(t, results, 2)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, http.StatusOK, results[0].Status)
+	assert.Equal(t, http.StatusNotFound, results[1].Status)
+
+	requests := received()
+	require.Len(t, requests, 2)
+	assert.Equal(t, http.MethodPost, requests[0].method)
+	assert.Equal(t, "/update?x=1", requests[0].path)
+	assert.JSONEq(t, `{"id":"x"}`, requests[0].body)
+	assert.Equal(t, "application/json", requests[0].header.Get("Content-Type"))
+	assert.Empty(t, requests[0].header.Get("Content-Encoding"))
+	assert.Empty(t, requests[0].header.Get("Authorization"))
+	assert.Equal(t,
+		base64.StdEncoding.EncodeToString(sign.MakeSign([]byte(`{"id":"x"}`), "secret")),
+		requests[0].header.Get("HashSHA256"),
+	)
+	assert.Empty(t, requests[1].header.Get("HashSHA256"))
+}
+
+func TestReplayer_RunCanceled(t *testing.T) {
+	server, received := newTargetServer(t)
+
+	start := time.Now()
+	entries := []Entry{
+		{Time: start, Method: http.MethodGet, Path: "/ping"},
+		{Time: start.Add(time.Hour), Method: http.MethodGet, Path: "/ping"},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := NewReplayer(server.Client(), server.URL, 1, "").Run(ctx, entries, func(Result) {})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Len(t, received(), 1)
+}
+
+func TestReplayer_RunUnreachable(t *testing.T) {
+	entries := []Entry{{Time: time.Now(), Method: http.MethodGet, Path: "/ping"}}
+
+	var result Result
+	replayer := NewReplayer(&http.Client{Timeout: time.Second}, "http://127.0.0.1:1", 0, "")
+	require.NoError(t, replayer.Run(context.Background(), entries, func(r Result) { result = r }))
+	assert.Error(t, result.Err)
+	assert.Zero(t, result.Status)
+}
@@ -0,0 +1,241 @@
+// Package alerting evaluates the provisioned alert rules in the background and notifies chat integrations,
+// Slack incoming webhooks and Telegram bots, when a rule starts or stops firing. Messages are rendered from
+// a text template and rate limited per integration, so a flapping metric cannot flood a channel.
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/internal/ratelimit"
+	"github.com/gdyunin/metricol.git/internal/server/provisioning"
+	"github.com/gdyunin/metricol.git/pkg/clock"
+	"go.uber.org/zap"
+)
+
+// Notification states.
+const (
+	// StateFiring is the state of a rule whose metric started to satisfy the rule.
+	StateFiring = "firing"
+	// StateResolved is the state of a rule whose metric stopped satisfying the rule.
+	StateResolved = "resolved"
+)
+
+const (
+	// DefaultRateLimit is how many notifications a notifier sends per minute by default.
+	DefaultRateLimit = 10
+	// readTimeout bounds reading the metrics of an evaluation.
+	readTimeout = time.Minute
+	// requestTimeout bounds every request to a chat integration.
+	requestTimeout = 10 * time.Second
+)
+
+// MetricsReader reads the stored metrics.
+type MetricsReader interface {
+	All(ctx context.Context) (*entity.Metrics, error)
+}
+
+// AlertRules holds the provisioning file with the alert rules.
+type AlertRules interface {
+	Current() *provisioning.File
+}
+
+// Notifier delivers notifications to a chat integration.
+type Notifier interface {
+	// Name identifies the integration in logs and rate limits, e.g. "slack".
+	Name() string
+	// Notify delivers a rendered message.
+	Notify(ctx context.Context, text string) error
+}
+
+// Notification describes a change of the state of an alert rule.
+type Notification struct {
+	At    time.Time              // At is the time of the evaluation that noticed the change.
+	State string                 // State is StateFiring or StateResolved.
+	Rule  provisioning.AlertRule // Rule is the alert rule.
+	Value float64                // Value is the value of the watched metric.
+}
+
+// Option configures optional Watcher settings.
+type Option func(*Watcher)
+
+// WithTemplate renders the notifications with tmpl instead of the default template.
+//
+// Parameters:
+//   - tmpl: The message template; nil keeps the default.
+//
+// Returns:
+//   - Option: An option applying the template.
+func WithTemplate(tmpl *Template) Option {
+	return func(w *Watcher) {
+		if tmpl != nil {
+			w.template = tmpl
+		}
+	}
+}
+
+// WithRateLimit sets how many notifications every notifier sends per minute; the notifications above
+// the limit are dropped. A non-positive limit keeps DefaultRateLimit.
+//
+// Parameters:
+//   - perMinute: The number of notifications per minute, also the number that may be sent at once.
+//
+// Returns:
+//   - Option: An option applying the rate limit.
+func WithRateLimit(perMinute int) Option {
+	return func(w *Watcher) {
+		if perMinute > 0 {
+			w.limiter = ratelimit.NewLimiter(float64(perMinute)/time.Minute.Seconds(), perMinute)
+		}
+	}
+}
+
+// Watcher evaluates the alert rules periodically and notifies when a rule starts or stops firing.
+// A rule whose metric is not stored keeps its state, so a metric missing for a while resolves nothing.
+// States are not persisted, so the rules firing at the first evaluation after a restart are notified again.
+type Watcher struct {
+	metrics   MetricsReader      // metrics reads the stored metrics.
+	rules     AlertRules         // rules holds the alert rules.
+	clock     clock.Clock        // clock drives the evaluations.
+	logger    *zap.SugaredLogger // logger is used for logging the notifications.
+	template  *Template          // template renders the notifications.
+	limiter   *ratelimit.Limiter // limiter caps the notifications per notifier.
+	firing    map[string]bool    // firing marks the rules firing at the previous evaluation by name.
+	notifiers []Notifier         // notifiers deliver the notifications.
+	interval  time.Duration      // interval is the period between evaluations.
+}
+
+// NewWatcher creates a Watcher.
+//
+// Parameters:
+//   - interval: The period between evaluations of the alert rules.
+//   - metrics: The source of the stored metrics.
+//   - rules: The source of the alert rules.
+//   - notifiers: The chat integrations notified of state changes.
+//   - logger: Logger for alerting operations.
+//   - opts: Optional watcher settings.
+//
+// Returns:
+//   - *Watcher: A pointer to the created Watcher.
+func NewWatcher(
+	interval time.Duration,
+	metrics MetricsReader,
+	rules AlertRules,
+	notifiers []Notifier,
+	logger *zap.SugaredLogger,
+	opts ...Option,
+) *Watcher {
+	w := &Watcher{
+		interval:  interval,
+		metrics:   metrics,
+		rules:     rules,
+		notifiers: notifiers,
+		logger:    logger,
+		clock:     clock.Real(),
+		template:  defaultTemplate,
+		firing:    make(map[string]bool),
+	}
+	WithRateLimit(DefaultRateLimit)(w)
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Start evaluates the alert rules every interval until the context is canceled. An evaluation that cannot
+// read the metrics is logged and skipped.
+//
+// Parameters:
+//   - ctx: The context controlling the watcher lifecycle.
+func (w *Watcher) Start(ctx context.Context) {
+	names := make([]string, 0, len(w.notifiers))
+	for _, n := range w.notifiers {
+		names = append(names, n.Name())
+	}
+	w.logger.Infof("Evaluating alert rules every %s, notifying %s", w.interval, strings.Join(names, ", "))
+
+	ticker := w.clock.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("Context canceled: stopping alert evaluation")
+			return
+		case <-ticker.C():
+			if err := w.evaluate(ctx); err != nil {
+				w.logger.Errorf("Failed to evaluate alert rules: %v", err)
+			}
+		}
+	}
+}
+
+// evaluate compares the alert rules with the stored metrics and notifies the changes of their states.
+func (w *Watcher) evaluate(ctx context.Context) error {
+	metrics, err := w.readMetrics(ctx)
+	if err != nil {
+		return err
+	}
+	values := make(map[string]float64, metrics.Length())
+	for _, m := range *metrics {
+		if v, ok := m.Float64(); ok {
+			values[m.Type+"/"+m.Name] = v
+		}
+	}
+
+	now := w.clock.Now()
+	rules := w.rules.Current().AlertRules
+	firing := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		v, ok := values[r.Type+"/"+r.Metric]
+		if !ok {
+			firing[r.Name] = w.firing[r.Name]
+			continue
+		}
+		fires := r.Fires(v)
+		firing[r.Name] = fires
+		switch {
+		case fires && !w.firing[r.Name]:
+			w.notify(ctx, Notification{At: now, State: StateFiring, Rule: r, Value: v})
+		case !fires && w.firing[r.Name]:
+			w.notify(ctx, Notification{At: now, State: StateResolved, Rule: r, Value: v})
+		}
+	}
+	w.firing = firing
+	return nil
+}
+
+// notify renders the notification and delivers it with every notifier within its rate limit.
+// Failed deliveries are logged and not retried.
+func (w *Watcher) notify(ctx context.Context, n Notification) {
+	text, err := w.template.Render(n)
+	if err != nil {
+		w.logger.Errorf("Failed to render notification of alert %s: %v", n.Rule.Name, err)
+		return
+	}
+	for _, notifier := range w.notifiers {
+		if ok, _ := w.limiter.Allow(notifier.Name()); !ok {
+			w.logger.Warnf("Rate limit reached: dropping %s notification of alert %s", notifier.Name(), n.Rule.Name)
+			continue
+		}
+		if err := notifier.Notify(ctx, text); err != nil {
+			w.logger.Errorf("Failed to notify %s of alert %s: %v", notifier.Name(), n.Rule.Name, err)
+			continue
+		}
+		w.logger.Infof("Notified %s of alert %s %s", notifier.Name(), n.Rule.Name, n.State)
+	}
+}
+
+// readMetrics reads the stored metrics within readTimeout.
+func (w *Watcher) readMetrics(ctx context.Context) (*entity.Metrics, error) {
+	readCtx, cancel := w.clock.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	metrics, err := w.metrics.All(readCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metrics: %w", err)
+	}
+	return metrics, nil
+}
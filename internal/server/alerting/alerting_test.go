@@ -0,0 +1,156 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/provisioning"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/gdyunin/metricol.git/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// staticRules serves a fixed provisioning file.
+type staticRules struct {
+	file *provisioning.File
+}
+
+func (s staticRules) Current() *provisioning.File {
+	return s.file
+}
+
+// recordingNotifier records the delivered messages.
+type recordingNotifier struct {
+	err      error
+	name     string
+	messages chan string
+}
+
+func (r *recordingNotifier) Name() string {
+	return r.name
+}
+
+func (r *recordingNotifier) Notify(_ context.Context, text string) error {
+	r.messages <- text
+	return r.err
+}
+
+func newRecordingNotifier(name string) *recordingNotifier {
+	return &recordingNotifier{name: name, messages: make(chan string, 10)}
+}
+
+// received drains the messages delivered so far.
+func (r *recordingNotifier) received() []string {
+	var got []string
+	for {
+		select {
+		case text := <-r.messages:
+			got = append(got, text)
+		default:
+			return got
+		}
+	}
+}
+
+func newTestRepo(t *testing.T) *repository.InMemoryRepository {
+	t.Helper()
+	return repository.NewInMemoryRepository(zap.NewNop().Sugar())
+}
+
+func updateGauge(t *testing.T, repo *repository.InMemoryRepository, name string, value float64) {
+	t.Helper()
+	metric := &entity.Metric{Name: name, Type: entity.MetricTypeGauge, Value: value}
+	require.NoError(t, repo.Update(context.Background(), metric))
+}
+
+var heapRules = staticRules{file: &provisioning.File{AlertRules: []provisioning.AlertRule{{
+	Name:      "high-heap",
+	Metric:    "HeapAlloc",
+	Type:      entity.MetricTypeGauge,
+	Op:        provisioning.OpGreater,
+	Severity:  "critical",
+	Threshold: 100,
+}}}}
+
+func TestWatcher_Evaluate(t *testing.T) {
+	repo := newTestRepo(t)
+	notifier := newRecordingNotifier("chat")
+	tmpl, err := ParseTemplate(`{{.State}} {{.Rule.Name}} {{.Value}}`)
+	require.NoError(t, err)
+	w := NewWatcher(time.Second, repo, heapRules, []Notifier{notifier}, zap.NewNop().Sugar(), WithTemplate(tmpl))
+	ctx := context.Background()
+
+	require.NoError(t, w.evaluate(ctx))
+	assert.Empty(t, notifier.received(), "a rule without data does not fire")
+
+	updateGauge(t, repo, "HeapAlloc", 150)
+	require.NoError(t, w.evaluate(ctx))
+	assert.Equal(t, []string{"firing high-heap 150"}, notifier.received())
+
+	updateGauge(t, repo, "HeapAlloc", 200)
+	require.NoError(t, w.evaluate(ctx))
+	assert.Empty(t, notifier.received(), "a firing rule is notified once")
+
+	updateGauge(t, repo, "HeapAlloc", 50)
+	require.NoError(t, w.evaluate(ctx))
+	assert.Equal(t, []string{"resolved high-heap 50"}, notifier.received())
+}
+
+func TestWatcher_EvaluateFailedNotifier(t *testing.T) {
+	repo := newTestRepo(t)
+	failing := newRecordingNotifier("failing")
+	failing.err = errors.New("unavailable")
+	working := newRecordingNotifier("working")
+	w := NewWatcher(time.Second, repo, heapRules, []Notifier{failing, working}, zap.NewNop().Sugar())
+
+	updateGauge(t, repo, "HeapAlloc", 150)
+	require.NoError(t, w.evaluate(context.Background()))
+	assert.Len(t, failing.received(), 1)
+	require.Len(t, working.received(), 1, "a failed notifier does not stop the others")
+}
+
+func TestWatcher_RateLimit(t *testing.T) {
+	repo := newTestRepo(t)
+	notifier := newRecordingNotifier("chat")
+	w := NewWatcher(time.Second, repo, heapRules, []Notifier{notifier}, zap.NewNop().Sugar(), WithRateLimit(1))
+	ctx := context.Background()
+
+	updateGauge(t, repo, "HeapAlloc", 150)
+	require.NoError(t, w.evaluate(ctx))
+	updateGauge(t, repo, "HeapAlloc", 50)
+	require.NoError(t, w.evaluate(ctx))
+
+	got := notifier.received()
+	require.Len(t, got, 1, "the resolved notification is above the limit")
+	assert.Contains(t, got[0], "[FIRING] high-heap")
+}
+
+func TestWatcher_Start(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo := newTestRepo(t)
+	updateGauge(t, repo, "HeapAlloc", 150)
+	notifier := newRecordingNotifier("chat")
+	fake := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	w := NewWatcher(time.Minute, repo, heapRules, []Notifier{notifier}, zap.NewNop().Sugar())
+	w.clock = fake
+
+	done := make(chan struct{})
+	go func() {
+		w.Start(ctx)
+		close(done)
+	}()
+
+	fake.BlockUntil(1)
+	fake.Advance(time.Minute)
+	assert.Contains(t, <-notifier.messages, "at 2025-01-01 12:01:00 UTC")
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,78 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// slackMessage is the body of a Slack incoming webhook request.
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// SlackNotifier posts notifications to a Slack incoming webhook.
+type SlackNotifier struct {
+	client  *resty.Client // client posts the messages.
+	webhook string        // webhook is the URL of the incoming webhook, which embeds its credentials.
+}
+
+// NewSlackNotifier creates a SlackNotifier.
+//
+// Parameters:
+//   - webhook: The URL of the incoming webhook, e.g. "https://hooks.slack.com/services/T000/B000/XXXX".
+//
+// Returns:
+//   - *SlackNotifier: The notifier.
+//   - error: An error if the webhook is not an HTTP or HTTPS URL.
+func NewSlackNotifier(webhook string) (*SlackNotifier, error) {
+	u, err := url.Parse(webhook)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("the Slack webhook must be an http or https URL")
+	}
+	return &SlackNotifier{
+		client:  resty.New().SetTimeout(requestTimeout),
+		webhook: webhook,
+	}, nil
+}
+
+// Name returns "slack".
+//
+// Returns:
+//   - string: The integration name.
+func (s *SlackNotifier) Name() string {
+	return "slack"
+}
+
+// Notify posts a message to the webhook.
+//
+// Parameters:
+//   - ctx: The context of the request.
+//   - text: The message.
+//
+// Returns:
+//   - error: An error if the webhook cannot be reached or rejects the message.
+func (s *SlackNotifier) Notify(ctx context.Context, text string) error {
+	resp, err := s.client.R().SetContext(ctx).SetBody(slackMessage{Text: text}).Post(s.webhook)
+	if err != nil {
+		return fmt.Errorf("request to Slack failed: %w", withoutURL(err))
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("slack responded with status %s: %s", resp.Status(), resp.String())
+	}
+	return nil
+}
+
+// withoutURL strips the request URL from a transport error, since webhook and bot URLs embed credentials
+// that must not end up in the logs.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSlackNotifier(t *testing.T) {
+	for _, webhook := range []string{"", "hooks.slack.com/services/T/B/X", "ftp://hooks.slack.com/services/T/B/X"} {
+		_, err := NewSlackNotifier(webhook)
+		assert.Error(t, err, webhook)
+	}
+	s, err := NewSlackNotifier("https://hooks.slack.com/services/T/B/X")
+	require.NoError(t, err)
+	assert.Equal(t, "slack", s.Name())
+}
+
+func TestSlackNotifier_Notify(t *testing.T) {
+	var got slackMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/services/T/B/X", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		if got.Text == "" {
+			http.Error(w, "no_text", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	s, err := NewSlackNotifier(ts.URL + "/services/T/B/X")
+	require.NoError(t, err)
+	require.NoError(t, s.Notify(context.Background(), "[FIRING] high-heap"))
+	assert.Equal(t, "[FIRING] high-heap", got.Text)
+
+	err = s.Notify(context.Background(), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no_text")
+}
+
+func TestSlackNotifier_NotifyUnreachable(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	webhook := ts.URL + "/services/T/B/secret"
+	ts.Close()
+
+	s, err := NewSlackNotifier(webhook)
+	require.NoError(t, err)
+	err = s.Notify(context.Background(), "text")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret", "the webhook credentials are not logged")
+}
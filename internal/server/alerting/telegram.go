@@ -0,0 +1,90 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// telegramAPI is the base URL of the Telegram Bot API.
+const telegramAPI = "https://api.telegram.org"
+
+// telegramMessage is the body of a Telegram sendMessage request.
+type telegramMessage struct {
+	ChatID             string `json:"chat_id"`
+	Text               string `json:"text"`
+	DisableWebPreviews bool   `json:"disable_web_page_preview"`
+}
+
+// telegramResponse is the envelope of Telegram Bot API responses.
+type telegramResponse struct {
+	Description string `json:"description"`
+	OK          bool   `json:"ok"`
+}
+
+// TelegramNotifier sends notifications to a Telegram chat through a bot.
+type TelegramNotifier struct {
+	client *resty.Client // client calls the Bot API.
+	api    string        // api is the base URL of the Bot API.
+	token  string        // token authenticates the bot.
+	chatID string        // chatID is the chat messages are sent to.
+}
+
+// NewTelegramNotifier creates a TelegramNotifier.
+//
+// Parameters:
+//   - token: The bot token issued by BotFather.
+//   - chatID: The chat identifier, or the @username of a public channel, the bot sends messages to.
+//
+// Returns:
+//   - *TelegramNotifier: The notifier.
+//   - error: An error if the token or the chat is missing.
+func NewTelegramNotifier(token, chatID string) (*TelegramNotifier, error) {
+	if token == "" || chatID == "" {
+		return nil, errors.New("the Telegram bot token and chat must be set together")
+	}
+	return &TelegramNotifier{
+		client: resty.New().SetTimeout(requestTimeout),
+		api:    telegramAPI,
+		token:  token,
+		chatID: chatID,
+	}, nil
+}
+
+// Name returns "telegram".
+//
+// Returns:
+//   - string: The integration name.
+func (t *TelegramNotifier) Name() string {
+	return "telegram"
+}
+
+// Notify sends a message to the chat.
+//
+// Parameters:
+//   - ctx: The context of the request.
+//   - text: The message.
+//
+// Returns:
+//   - error: An error if the Bot API cannot be reached or rejects the message.
+func (t *TelegramNotifier) Notify(ctx context.Context, text string) error {
+	resp, err := t.client.R().
+		SetContext(ctx).
+		SetBody(telegramMessage{ChatID: t.chatID, Text: text, DisableWebPreviews: true}).
+		Post(t.api + "/bot" + t.token + "/sendMessage")
+	if err != nil {
+		return fmt.Errorf("request to Telegram failed: %w", withoutURL(err))
+	}
+
+	var result telegramResponse
+	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+		return fmt.Errorf("telegram responded with status %s and an unreadable body: %w", resp.Status(), err)
+	}
+	if !result.OK {
+		return fmt.Errorf("telegram rejected the message with status %s: %s", resp.Status(), result.Description)
+	}
+	return nil
+}
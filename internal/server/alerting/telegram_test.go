@@ -0,0 +1,61 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTelegramNotifier(t *testing.T) {
+	_, err := NewTelegramNotifier("123:token", "")
+	assert.Error(t, err)
+	_, err = NewTelegramNotifier("", "@alerts")
+	assert.Error(t, err)
+
+	n, err := NewTelegramNotifier("123:token", "@alerts")
+	require.NoError(t, err)
+	assert.Equal(t, "telegram", n.Name())
+}
+
+func TestTelegramNotifier_Notify(t *testing.T) {
+	var got telegramMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/bot123:token/sendMessage", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		if got.ChatID != "-100500" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"ok": false, "description": "Bad Request: chat not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok": true, "result": {}}`))
+	}))
+	defer ts.Close()
+
+	n, err := NewTelegramNotifier("123:token", "-100500")
+	require.NoError(t, err)
+	n.api = ts.URL
+	require.NoError(t, n.Notify(context.Background(), "[FIRING] high-heap"))
+	assert.Equal(t, telegramMessage{ChatID: "-100500", Text: "[FIRING] high-heap", DisableWebPreviews: true}, got)
+
+	n.chatID = "@unknown"
+	err = n.Notify(context.Background(), "[FIRING] high-heap")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "chat not found")
+}
+
+func TestTelegramNotifier_NotifyUnreachable(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+
+	n, err := NewTelegramNotifier("123:secret", "-100500")
+	require.NoError(t, err)
+	n.api = ts.URL
+	err = n.Notify(context.Background(), "text")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret", "the bot token is not logged")
+}
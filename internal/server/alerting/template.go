@@ -0,0 +1,91 @@
+package alerting
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/provisioning"
+)
+
+// DefaultTemplate is the message template used when none is configured. Templates are executed with
+// a Notification and may format times with the stamp function.
+const DefaultTemplate = `{{if eq .State "firing"}}[FIRING]{{else}}[RESOLVED]{{end}} {{.Rule.Name}} ({{.Rule.Severity}})
+{{.Rule.Type}}/{{.Rule.Metric}} = {{printf "%g" .Value}}, rule {{.Rule.Op}} {{.Rule.Threshold}}
+{{- if .Rule.Summary}}
+{{.Rule.Summary}}{{end}}
+at {{stamp .At}}`
+
+// notificationTimeLayout formats the times in a notification.
+const notificationTimeLayout = "2006-01-02 15:04:05 MST"
+
+// defaultTemplate renders the notifications when no template is configured.
+var defaultTemplate = mustParseTemplate(DefaultTemplate)
+
+// sampleNotification checks that a template can render a notification when it is parsed.
+var sampleNotification = Notification{
+	At:    time.Unix(0, 0).UTC(),
+	State: StateFiring,
+	Rule: provisioning.AlertRule{
+		Name:      "sample",
+		Metric:    "Sample",
+		Type:      "gauge",
+		Op:        provisioning.OpGreater,
+		Severity:  "warning",
+		Threshold: 1,
+	},
+	Value: 2,
+}
+
+// Template renders notifications as chat messages.
+type Template struct {
+	tmpl *template.Template // tmpl is the parsed text template.
+}
+
+// ParseTemplate parses a message template, a text/template executed with a Notification.
+//
+// Parameters:
+//   - text: The template.
+//
+// Returns:
+//   - *Template: The parsed template.
+//   - error: An error if the template is malformed or cannot render a notification, e.g. for an unknown field.
+func ParseTemplate(text string) (*Template, error) {
+	tmpl, err := template.New("notification").Funcs(template.FuncMap{
+		"stamp": func(t time.Time) string { return t.Format(notificationTimeLayout) },
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	t := &Template{tmpl: tmpl}
+	if _, err := t.Render(sampleNotification); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Render renders a notification.
+//
+// Parameters:
+//   - n: The notification.
+//
+// Returns:
+//   - string: The message.
+//   - error: An error if the template fails.
+func (t *Template) Render(n Notification) (string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, n); err != nil {
+		return "", fmt.Errorf("failed to render notification: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// mustParseTemplate parses a built-in template and panics if it is invalid.
+func mustParseTemplate(text string) *Template {
+	t, err := ParseTemplate(text)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
@@ -0,0 +1,65 @@
+package alerting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/provisioning"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplate_Render(t *testing.T) {
+	n := Notification{
+		At:    time.Date(2025, time.January, 15, 10, 30, 0, 0, time.UTC),
+		State: StateFiring,
+		Rule: provisioning.AlertRule{
+			Name:      "high-heap",
+			Metric:    "HeapAlloc",
+			Type:      "gauge",
+			Op:        provisioning.OpGreater,
+			Severity:  "critical",
+			Summary:   "Heap is above 1 GB",
+			Threshold: 1e9,
+		},
+		Value: 1.5e9,
+	}
+
+	text, err := defaultTemplate.Render(n)
+	require.NoError(t, err)
+	assert.Equal(t, "[FIRING] high-heap (critical)\n"+
+		"gauge/HeapAlloc = 1.5e+09, rule > 1e+09\n"+
+		"Heap is above 1 GB\n"+
+		"at 2025-01-15 10:30:00 UTC", text)
+
+	n.State, n.Rule.Summary = StateResolved, ""
+	text, err = defaultTemplate.Render(n)
+	require.NoError(t, err)
+	assert.Equal(t, "[RESOLVED] high-heap (critical)\n"+
+		"gauge/HeapAlloc = 1.5e+09, rule > 1e+09\n"+
+		"at 2025-01-15 10:30:00 UTC", text)
+
+	custom, err := ParseTemplate(`{{.Rule.Name}} is {{.State}}`)
+	require.NoError(t, err)
+	text, err = custom.Render(n)
+	require.NoError(t, err)
+	assert.Equal(t, "high-heap is resolved", text)
+}
+
+func TestParseTemplate_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{name: "malformed", text: `{{.Rule.Name`},
+		{name: "unknown field", text: `{{.Rule.Owner}}`},
+		{name: "unknown function", text: `{{upper .State}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTemplate(tt.text)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Package audit records the mutations of the stored metrics, who made them and from where, to a pluggable sink,
+// so what changed can be reconstructed after the fact.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Operations recorded in the audit log.
+const (
+	OpUpdate      = "update"
+	OpUpdateBatch = "update_batch"
+	OpDelete      = "delete"
+	OpUndelete    = "undelete"
+)
+
+// Metric identifies a metric touched by an audited operation.
+type Metric struct {
+	Value any    `json:"value,omitempty"` // Value is the written value, empty for deletions.
+	Type  string `json:"type"`            // Type is the metric type.
+	Name  string `json:"name"`            // Name is the metric name.
+}
+
+// Request describes the request an operation was made by.
+type Request struct {
+	Agent     string `json:"agent,omitempty"`      // Agent is the agent ID sent in the X-Agent-ID header.
+	KeyID     string `json:"key_id,omitempty"`     // KeyID is the fingerprint of the API key, never the key itself.
+	User      string `json:"user,omitempty"`       // User is the basic auth user.
+	SourceIP  string `json:"source_ip,omitempty"`  // SourceIP is the client IP address.
+	RequestID string `json:"request_id,omitempty"` // RequestID is the ID of the request.
+}
+
+// Entry is one record of the audit log.
+type Entry struct {
+	Time      time.Time `json:"time"`      // Time is when the operation completed.
+	Operation string    `json:"operation"` // Operation is one of the Op constants.
+	Request             // Request describes who made the operation; empty for operations without a request.
+	Metrics   []Metric  `json:"metrics"` // Metrics are the metrics the operation touched.
+}
+
+// Sink stores audit entries.
+type Sink interface {
+	// Write stores an entry.
+	Write(ctx context.Context, entry Entry) error
+	// Close releases the sink.
+	Close() error
+}
+
+// requestContextKey is the context key of the request description.
+type requestContextKey struct{}
+
+// WithRequest returns a context carrying the description of the request, recorded with the operations made
+// within the context.
+//
+// Parameters:
+//   - ctx: The context of the request.
+//   - req: The description of the request.
+//
+// Returns:
+//   - context.Context: The context carrying the description.
+func WithRequest(ctx context.Context, req Request) context.Context {
+	return context.WithValue(ctx, requestContextKey{}, req)
+}
+
+// RequestFrom returns the description of the request carried by the context.
+//
+// Parameters:
+//   - ctx: The context of the operation.
+//
+// Returns:
+//   - Request: The description, empty if the context carries none.
+func RequestFrom(ctx context.Context) Request {
+	req, _ := ctx.Value(requestContextKey{}).(Request)
+	return req
+}
+
+// Open opens the sink of a target: a PostgreSQL DSN ("postgres://" or "postgresql://") stores the entries
+// in the audit_log table of the database, anything else is the path of a JSON Lines file, optionally
+// prefixed with "file://".
+//
+// Parameters:
+//   - ctx: The context bounding the connection to the database.
+//   - target: The target of the audit log.
+//
+// Returns:
+//   - Sink: The opened sink.
+//   - error: An error if the file or the database cannot be opened.
+func Open(ctx context.Context, target string) (Sink, error) {
+	lower := strings.ToLower(target)
+	if strings.HasPrefix(lower, "postgres://") || strings.HasPrefix(lower, "postgresql://") {
+		return OpenPSQLSink(ctx, target)
+	}
+	path := strings.TrimPrefix(target, "file://")
+	if path == "" {
+		return nil, fmt.Errorf("audit log target %q has no path", target)
+	}
+	return NewFileSink(path)
+}
@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestContext(t *testing.T) {
+	assert.Equal(t, Request{}, RequestFrom(context.Background()))
+
+	req := Request{Agent: "host-1", RequestID: "req-1"}
+	assert.Equal(t, req, RequestFrom(WithRequest(context.Background(), req)))
+}
+
+func TestOpen_File(t *testing.T) {
+	tests := []struct {
+		name   string
+		target func(path string) string
+	}{
+		{name: "Plain path", target: func(path string) string { return path }},
+		{name: "File URL", target: func(path string) string { return "file://" + path }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.jsonl")
+			sink, err := Open(context.Background(), tt.target(path))
+			require.NoError(t, err)
+			require.NoError(t, sink.Write(context.Background(), Entry{Operation: OpUpdate}))
+			require.NoError(t, sink.Close())
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Contains(t, string(data), `"operation":"update"`)
+		})
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{name: "Empty file URL", target: "file://"},
+		{name: "Invalid PostgreSQL DSN", target: "postgres://user@host:notaport/db"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Open(context.Background(), tt.target)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// ErrClosed is returned by sinks written to after they were closed.
+var ErrClosed = errors.New("audit sink is closed")
+
+// FileSink appends the entries to a JSON Lines file, one entry per line.
+type FileSink struct {
+	file *os.File   // file is the audit log, nil once closed.
+	mu   sync.Mutex // mu serializes the writes, so entries never interleave.
+}
+
+// NewFileSink opens the audit log file for appending, creating it if needed.
+// The file is readable by its owner only, since the entries name the clients of the server.
+//
+// Parameters:
+//   - path: The path of the file.
+//
+// Returns:
+//   - *FileSink: A pointer to the created sink.
+//   - error: An error if the file cannot be opened.
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &FileSink{file: file}, nil
+}
+
+// Write appends an entry as a single line, written in a single write so a crash cannot leave half of it.
+//
+// Parameters:
+//   - entry: The entry to append.
+//
+// Returns:
+//   - error: An error if the sink is closed or the write fails.
+func (s *FileSink) Write(_ context.Context, entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return ErrClosed
+	}
+	if _, err := s.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// Close closes the file. Closing a closed sink does nothing.
+//
+// Returns:
+//   - error: An error if the file cannot be closed.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to close audit log: %w", err)
+	}
+	return nil
+}
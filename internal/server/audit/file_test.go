@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSink_Write(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+
+	entry := Entry{
+		Time:      time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Operation: OpUpdateBatch,
+		Request:   Request{Agent: "host-1", KeyID: "abcd", SourceIP: "10.0.0.7", RequestID: "req-1"},
+		Metrics:   []Metric{{Type: "counter", Name: "PollCount", Value: 5}, {Type: "gauge", Name: "Alloc"}},
+	}
+	require.NoError(t, sink.Write(context.Background(), entry))
+	require.NoError(t, sink.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"time": "2025-01-02T03:04:05Z",
+		"operation": "update_batch",
+		"agent": "host-1",
+		"key_id": "abcd",
+		"source_ip": "10.0.0.7",
+		"request_id": "req-1",
+		"metrics": [{"type": "counter", "name": "PollCount", "value": 5}, {"type": "gauge", "name": "Alloc"}]
+	}`, string(data))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestFileSink_AppendsConcurrently(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, sink.Write(context.Background(), Entry{Operation: OpUpdate}))
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, sink.Close())
+
+	// Reopening appends after the existing entries.
+	sink, err = NewFileSink(path)
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(context.Background(), Entry{Operation: OpDelete}))
+	require.NoError(t, sink.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		lines++
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, writers+1, lines)
+}
+
+func TestFileSink_Closed(t *testing.T) {
+	sink, err := NewFileSink(filepath.Join(t.TempDir(), "audit.jsonl"))
+	require.NoError(t, err)
+	require.NoError(t, sink.Close())
+	require.NoError(t, sink.Close())
+
+	assert.ErrorIs(t, sink.Write(context.Background(), Entry{}), ErrClosed)
+}
+
+func TestNewFileSink_Error(t *testing.T) {
+	_, err := NewFileSink(filepath.Join(t.TempDir(), "missing", "audit.jsonl"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,121 @@
+//go:build !nopostgres
+
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+const (
+	// Const createTableQuery creates the audit table. The sink creates it itself, since the audit log may live
+	// in a database other than the metrics.
+	createTableQuery = `
+		CREATE TABLE IF NOT EXISTS audit_log (
+			id         BIGSERIAL PRIMARY KEY,
+			at         TIMESTAMPTZ NOT NULL,
+			operation  TEXT NOT NULL,
+			agent      TEXT NOT NULL DEFAULT '',
+			key_id     TEXT NOT NULL DEFAULT '',
+			username   TEXT NOT NULL DEFAULT '',
+			source_ip  TEXT NOT NULL DEFAULT '',
+			request_id TEXT NOT NULL DEFAULT '',
+			metrics    JSONB NOT NULL
+		);
+	`
+	// Const insertQuery stores an entry.
+	insertQuery = `
+		INSERT INTO audit_log (at, operation, agent, key_id, username, source_ip, request_id, metrics)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+	`
+)
+
+// PSQLSink stores the entries in the audit_log table of a PostgreSQL database.
+type PSQLSink struct {
+	db *sql.DB // db is the database holding the audit table.
+}
+
+// OpenPSQLSink connects to the database and creates the audit table if it does not exist.
+//
+// Parameters:
+//   - ctx: The context bounding the connection.
+//   - dsn: The connection string of the database.
+//
+// Returns:
+//   - Sink: The created sink.
+//   - error: An error if the DSN is invalid or the table cannot be created.
+func OpenPSQLSink(ctx context.Context, dsn string) (Sink, error) {
+	cfg, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse audit database connection string: %w", err)
+	}
+	db := stdlib.OpenDB(*cfg)
+	sink, err := NewPSQLSink(ctx, db)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return sink, nil
+}
+
+// NewPSQLSink creates a sink on an open database and creates the audit table if it does not exist.
+// The sink takes over the database and closes it on Close.
+//
+// Parameters:
+//   - ctx: The context bounding the table creation.
+//   - db: The database holding the audit table.
+//
+// Returns:
+//   - *PSQLSink: A pointer to the created sink.
+//   - error: An error if the table cannot be created.
+func NewPSQLSink(ctx context.Context, db *sql.DB) (*PSQLSink, error) {
+	if _, err := db.ExecContext(ctx, createTableQuery); err != nil {
+		return nil, fmt.Errorf("failed to create audit table: %w", err)
+	}
+	return &PSQLSink{db: db}, nil
+}
+
+// Write inserts an entry into the audit table.
+//
+// Parameters:
+//   - ctx: The context of the operation.
+//   - entry: The entry to insert.
+//
+// Returns:
+//   - error: An error if the insert fails.
+func (s *PSQLSink) Write(ctx context.Context, entry Entry) error {
+	metrics, err := json.Marshal(entry.Metrics)
+	if err != nil {
+		return fmt.Errorf("failed to encode audited metrics: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, insertQuery,
+		entry.Time,
+		entry.Operation,
+		entry.Agent,
+		entry.KeyID,
+		entry.User,
+		entry.SourceIP,
+		entry.RequestID,
+		metrics,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database.
+//
+// Returns:
+//   - error: An error if the database cannot be closed.
+func (s *PSQLSink) Close() error {
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("failed to close audit database: %w", err)
+	}
+	return nil
+}
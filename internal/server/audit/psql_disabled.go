@@ -0,0 +1,20 @@
+//go:build nopostgres
+
+package audit
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrPostgresDisabled is returned for PostgreSQL audit targets by binaries built with the nopostgres tag.
+var ErrPostgresDisabled = errors.New("PostgreSQL support is not compiled in, rebuild without the nopostgres tag")
+
+// OpenPSQLSink always fails in builds without PostgreSQL support.
+//
+// Returns:
+//   - Sink: Always nil.
+//   - error: ErrPostgresDisabled.
+func OpenPSQLSink(context.Context, string) (Sink, error) {
+	return nil, ErrPostgresDisabled
+}
@@ -0,0 +1,67 @@
+//go:build !nopostgres
+
+package audit
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPSQLSink_Write(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS audit_log")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	sink, err := NewPSQLSink(context.Background(), db)
+	require.NoError(t, err)
+
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO audit_log")).
+		WithArgs(at, OpUpdate, "host-1", "abcd", "admin", "10.0.0.7", "req-1",
+			[]byte(`[{"value":1.5,"type":"gauge","name":"Alloc"}]`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	err = sink.Write(context.Background(), Entry{
+		Time:      at,
+		Operation: OpUpdate,
+		Request:   Request{Agent: "host-1", KeyID: "abcd", User: "admin", SourceIP: "10.0.0.7", RequestID: "req-1"},
+		Metrics:   []Metric{{Type: "gauge", Name: "Alloc", Value: 1.5}},
+	})
+	require.NoError(t, err)
+
+	mock.ExpectClose()
+	require.NoError(t, sink.Close())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPSQLSink_Errors(t *testing.T) {
+	t.Run("Table creation fails", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer func() { _ = db.Close() }()
+
+		mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE")).WillReturnError(errors.New("permission denied"))
+		_, err = NewPSQLSink(context.Background(), db)
+		assert.Error(t, err)
+	})
+
+	t.Run("Insert fails", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer func() { _ = db.Close() }()
+
+		mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE")).WillReturnResult(sqlmock.NewResult(0, 0))
+		sink, err := NewPSQLSink(context.Background(), db)
+		require.NoError(t, err)
+
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO audit_log")).WillReturnError(errors.New("connection lost"))
+		assert.Error(t, sink.Write(context.Background(), Entry{Operation: OpDelete}))
+	})
+}
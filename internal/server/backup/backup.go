@@ -0,0 +1,304 @@
+// Package backup seals metric dumps exported by /admin/snapshot into an envelope that /admin/import opens
+// again. The envelope can be signed with a shared HMAC key, so a dump altered on its way between environments
+// is refused, and encrypted for the public key of the importing server, so only that server can read it.
+//
+// Encryption uses the schemes agents use for payloads: the X25519 scheme of package x25519box, in the style
+// of age, or an RSA hybrid of an OAEP wrapped AES-256-GCM key, depending on the type of the recipient key.
+// The signature covers the ciphertext, so a dump is verified before it is decrypted.
+//
+// Sealed backups can be kept in a Dir, so /api/diff can compare them, e.g. before and after a deployment.
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/gdyunin/metricol.git/pkg/pubkey"
+	"github.com/gdyunin/metricol.git/pkg/sign"
+	"github.com/gdyunin/metricol.git/pkg/x25519box"
+)
+
+const (
+	// Const Format identifies a backup envelope.
+	Format = "metricol-backup"
+	// Const SchemeRSA is the name of the RSA hybrid encryption scheme.
+	SchemeRSA = "rsa-oaep-aes256gcm"
+	// Const SchemeX25519 is the name of the X25519 encryption scheme.
+	SchemeX25519 = x25519box.Scheme
+
+	// Const envelopeVersion is the version of the envelope format written by this build.
+	envelopeVersion = 1
+	// Const aesKeySize is the size in bytes of the AES key wrapped by the RSA hybrid scheme.
+	aesKeySize = 32
+	// Const rsaPrivateKeyPEMType is the PEM block type of PKCS#1 RSA private keys.
+	rsaPrivateKeyPEMType = "RSA PRIVATE KEY"
+	// Const publicKeyPEMType is the PEM block type of PKIX public keys.
+	publicKeyPEMType = "PUBLIC KEY"
+)
+
+var (
+	// ErrInvalidBackup is returned when the data is not a backup envelope this build can read.
+	ErrInvalidBackup = errors.New("invalid backup")
+	// ErrSignature is returned when the signature of a backup is missing, invalid or cannot be checked.
+	ErrSignature = errors.New("backup signature verification failed")
+	// ErrDecrypt is returned when an encrypted backup cannot be decrypted with any of the server keys.
+	ErrDecrypt = errors.New("backup cannot be decrypted")
+)
+
+// Envelope is the exported form of a dump.
+type Envelope struct {
+	Format       string `json:"format"`                   // Format is always Format.
+	Version      int    `json:"version"`                  // Version is the envelope format version.
+	Encryption   string `json:"encryption,omitempty"`     // Encryption is the encryption scheme, empty if plain.
+	Recipient    string `json:"recipient,omitempty"`      // Recipient is the fingerprint of the recipient key.
+	Key          []byte `json:"key,omitempty"`            // Key is the wrapped AES key or the ephemeral X25519 key.
+	Payload      []byte `json:"payload"`                  // Payload is the dump, encrypted if Encryption is set.
+	SigningKeyID string `json:"signing_key_id,omitempty"` // SigningKeyID identifies the key of the signature.
+	Signature    string `json:"signature,omitempty"`      // Signature is the hex encoded HMAC-SHA256 of the envelope.
+}
+
+// Codec seals and opens backup envelopes. The zero value neither signs nor encrypts, and opens only
+// unsigned envelopes.
+type Codec struct {
+	signingKey   string // signingKey signs exported envelopes and verifies imported ones, empty to disable.
+	recipientPEM string // recipientPEM is the public key exports are encrypted for, empty to disable.
+	recipientID  string // recipientID is the fingerprint of recipientPEM.
+}
+
+// New creates a Codec.
+//
+// Parameters:
+//   - signingKey: The HMAC key shared by the environments exchanging backups; empty to disable signing.
+//   - recipientPEM: The RSA or X25519 public key of the importing server in PEM format; empty to export
+//     plain dumps.
+//
+// Returns:
+//   - *Codec: The codec.
+//   - error: An error if the recipient key cannot be parsed.
+func New(signingKey, recipientPEM string) (*Codec, error) {
+	c := &Codec{signingKey: signingKey, recipientPEM: recipientPEM}
+	if recipientPEM == "" {
+		return c, nil
+	}
+	if _, err := scheme(recipientPEM); err != nil {
+		return nil, err
+	}
+	id, err := pubkey.Fingerprint(recipientPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup recipient: %w", err)
+	}
+	c.recipientID = id
+	return c, nil
+}
+
+// Seal wraps a dump into an envelope, encrypting and signing it as configured.
+//
+// Parameters:
+//   - dump: The dump to export.
+//
+// Returns:
+//   - []byte: The envelope in JSON format.
+//   - error: An error if the dump cannot be encrypted.
+func (c *Codec) Seal(dump []byte) ([]byte, error) {
+	env := Envelope{Format: Format, Version: envelopeVersion, Payload: dump}
+	if c.recipientPEM != "" {
+		encryption, _ := scheme(c.recipientPEM)
+		payload, key, err := encrypt(encryption, dump, c.recipientPEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt backup: %w", err)
+		}
+		env.Encryption, env.Recipient, env.Key, env.Payload = encryption, c.recipientID, key, payload
+	}
+	if c.signingKey != "" {
+		env.SigningKeyID = sign.KeyID(c.signingKey)
+		env.Signature = hex.EncodeToString(sign.MakeSign(signedData(env), c.signingKey))
+	}
+
+	data, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize backup: %w", err)
+	}
+	return data, nil
+}
+
+// Open verifies an envelope and returns the dump it holds. If a signing key is configured, only envelopes
+// signed with it are accepted; otherwise only unsigned ones are, as a signature that cannot be checked
+// proves nothing.
+//
+// Parameters:
+//   - data: The envelope in JSON format.
+//   - privateKeys: The RSA and X25519 private keys in PEM format an encrypted dump may be sealed for.
+//
+// Returns:
+//   - []byte: The dump.
+//   - error: ErrInvalidBackup, ErrSignature or ErrDecrypt if the envelope cannot be opened.
+func (c *Codec) Open(data []byte, privateKeys []string) ([]byte, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBackup, err)
+	}
+	if env.Format != Format {
+		return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidBackup, env.Format)
+	}
+	if env.Version <= 0 || env.Version > envelopeVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidBackup, env.Version)
+	}
+
+	if err := c.verify(env); err != nil {
+		return nil, err
+	}
+	if env.Encryption == "" {
+		return env.Payload, nil
+	}
+	for _, key := range privateKeys {
+		if dump, err := decrypt(env.Encryption, env.Payload, env.Key, key); err == nil {
+			return dump, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no server key matches recipient %q", ErrDecrypt, env.Recipient)
+}
+
+// verify checks the signature of an envelope against the configured signing key.
+func (c *Codec) verify(env Envelope) error {
+	switch {
+	case c.signingKey == "" && env.Signature == "":
+		return nil
+	case c.signingKey == "":
+		return fmt.Errorf("%w: backup is signed but no signing key is configured", ErrSignature)
+	case env.Signature == "":
+		return fmt.Errorf("%w: backup is not signed", ErrSignature)
+	}
+
+	signature, err := hex.DecodeString(env.Signature)
+	if err != nil || !sign.Verify(signedData(env), signature, c.signingKey) {
+		return fmt.Errorf("%w: signature does not match key %q", ErrSignature, sign.KeyID(c.signingKey))
+	}
+	return nil
+}
+
+// signedData serializes the envelope without its signature, as the data the signature is computed over.
+func signedData(env Envelope) []byte {
+	env.Signature = ""
+	data, _ := json.Marshal(env)
+	return data
+}
+
+// scheme returns the encryption scheme for a recipient public key.
+func scheme(publicKeyPEM string) (string, error) {
+	if x25519box.IsPublicKey(publicKeyPEM) {
+		return SchemeX25519, nil
+	}
+	if _, err := parseRSAPublicKey(publicKeyPEM); err != nil {
+		return "", fmt.Errorf("invalid backup recipient: %w", err)
+	}
+	return SchemeRSA, nil
+}
+
+// encrypt seals a dump for a recipient public key.
+//
+// Returns:
+//   - []byte: The ciphertext.
+//   - []byte: The key material the recipient needs to decrypt it.
+//   - error: An error if encryption fails.
+func encrypt(encryption string, dump []byte, publicKeyPEM string) ([]byte, []byte, error) {
+	if encryption == SchemeX25519 {
+		return x25519box.Seal(dump, publicKeyPEM) //nolint:wrapcheck // the error is wrapped by the caller.
+	}
+
+	publicKey, err := parseRSAPublicKey(publicKeyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	aesKey := make([]byte, aesKeySize)
+	if _, err = rand.Read(aesKey); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate AES key: %w", err)
+	}
+	wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, aesKey, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encrypt AES key: %w", err)
+	}
+	aead, err := newGCM(aesKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(dump)+aead.Overhead())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, dump, nil), wrapped, nil
+}
+
+// decrypt opens a dump sealed for the public key of privateKeyPEM.
+func decrypt(encryption string, payload, key []byte, privateKeyPEM string) ([]byte, error) {
+	switch encryption {
+	case SchemeX25519:
+		return x25519box.Open(payload, key, privateKeyPEM) //nolint:wrapcheck // the error is not returned.
+	case SchemeRSA:
+	default:
+		return nil, fmt.Errorf("unknown encryption scheme %q", encryption)
+	}
+
+	block, _ := pem.Decode([]byte(privateKeyPEM))
+	if block == nil || block.Type != rsaPrivateKeyPEMType {
+		return nil, errors.New("invalid private key PEM format")
+	}
+	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	aesKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, privateKey, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt AES key: %w", err)
+	}
+	aead, err := newGCM(aesKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) < aead.NonceSize() {
+		return nil, errors.New("encrypted data too short")
+	}
+	dump, err := aead.Open(nil, payload[:aead.NonceSize()], payload[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %w", err)
+	}
+	return dump, nil
+}
+
+// parseRSAPublicKey parses a PEM encoded PKIX RSA public key.
+func parseRSAPublicKey(publicKeyPEM string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil || block.Type != publicKeyPEMType {
+		return nil, errors.New("invalid public key PEM format")
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	publicKey, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is neither an RSA nor an X25519 key")
+	}
+	return publicKey, nil
+}
+
+// newGCM creates the AES-GCM cipher of the RSA hybrid scheme.
+func newGCM(aesKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES-GCM: %w", err)
+	}
+	return aead, nil
+}
@@ -0,0 +1,130 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/pkg/pubkey"
+	"github.com/gdyunin/metricol.git/pkg/x25519box"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dump is the payload sealed by the tests.
+var dump = []byte(`{"taken_at":"2024-05-01T12:00:00Z","metrics":[{"id":"Alloc","type":"gauge","value":1.5}]}`)
+
+// rsaKeyPair generates an RSA key pair in PEM format.
+func rsaKeyPair(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	private := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	public, err := pubkey.FromPrivateKeyPEM(private)
+	require.NoError(t, err)
+	return private, public
+}
+
+func TestSealOpen(t *testing.T) {
+	rsaPrivate, rsaPublic := rsaKeyPair(t)
+	x25519Private, x25519Public, err := x25519box.GenerateKey()
+	require.NoError(t, err)
+	otherPrivate, _ := rsaKeyPair(t)
+
+	tests := []struct {
+		name       string
+		signingKey string
+		recipient  string
+		encryption string
+	}{
+		{name: "Plain"},
+		{name: "Signed", signingKey: "secret"},
+		{name: "RSA", recipient: rsaPublic, encryption: SchemeRSA},
+		{name: "X25519", recipient: x25519Public, encryption: SchemeX25519},
+		{name: "Signed and encrypted", signingKey: "secret", recipient: x25519Public, encryption: SchemeX25519},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, err := New(tt.signingKey, tt.recipient)
+			require.NoError(t, err)
+			data, err := codec.Seal(dump)
+			require.NoError(t, err)
+
+			var env Envelope
+			require.NoError(t, json.Unmarshal(data, &env))
+			assert.Equal(t, tt.encryption, env.Encryption)
+			assert.Equal(t, tt.signingKey != "", env.Signature != "")
+			if tt.encryption != "" {
+				assert.False(t, bytes.Contains(env.Payload, []byte("Alloc")), "payload is not encrypted")
+			}
+
+			opened, err := codec.Open(data, []string{otherPrivate, rsaPrivate, x25519Private})
+			require.NoError(t, err)
+			assert.Equal(t, dump, opened)
+		})
+	}
+}
+
+func TestOpen_Rejects(t *testing.T) {
+	_, rsaPublic := rsaKeyPair(t)
+	otherPrivate, _ := rsaKeyPair(t)
+
+	signed, err := New("secret", "")
+	require.NoError(t, err)
+	signedData, err := signed.Seal(dump)
+	require.NoError(t, err)
+	plain := &Codec{}
+	plainData, err := plain.Seal(dump)
+	require.NoError(t, err)
+	encrypted, err := New("", rsaPublic)
+	require.NoError(t, err)
+	encryptedData, err := encrypted.Seal(dump)
+	require.NoError(t, err)
+
+	tampered := bytes.Replace(signedData, []byte(`"payload":"`), []byte(`"payload":"AAAA`), 1)
+	otherKey, err := New("other", "")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		codec   *Codec
+		data    []byte
+		keys    []string
+		wantErr error
+	}{
+		{name: "Not JSON", codec: plain, data: []byte("dump"), wantErr: ErrInvalidBackup},
+		{name: "Unknown format", codec: plain, data: []byte(`{"format":"tar","version":1}`), wantErr: ErrInvalidBackup},
+		{
+			name:    "Newer version",
+			codec:   plain,
+			data:    []byte(`{"format":"metricol-backup","version":2}`),
+			wantErr: ErrInvalidBackup,
+		},
+		{name: "Tampered payload", codec: signed, data: tampered, wantErr: ErrSignature},
+		{name: "Other signing key", codec: otherKey, data: signedData, wantErr: ErrSignature},
+		{name: "Unsigned", codec: signed, data: plainData, wantErr: ErrSignature},
+		{name: "Signed without key", codec: plain, data: signedData, wantErr: ErrSignature},
+		{name: "No matching key", codec: plain, data: encryptedData, keys: []string{otherPrivate}, wantErr: ErrDecrypt},
+		{name: "No keys", codec: plain, data: encryptedData, wantErr: ErrDecrypt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.codec.Open(tt.data, tt.keys)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestNew_InvalidRecipient(t *testing.T) {
+	private, _ := rsaKeyPair(t)
+	for _, recipient := range []string{"not a key", private} {
+		_, err := New("", recipient)
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,110 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// Const dirPerm is the permission of the backup directory.
+	dirPerm = 0o750
+	// Const filePerm is the permission of the backup files, which may hold unencrypted dumps.
+	filePerm = 0o600
+)
+
+var (
+	// ErrNotFound is returned when a backup is not in the directory.
+	ErrNotFound = errors.New("backup not found")
+	// ErrInvalidName is returned when a backup name is not a plain file name.
+	ErrInvalidName = errors.New("invalid backup name")
+)
+
+// Dir keeps sealed backups as files in a directory, so they can be compared with each other later.
+type Dir struct {
+	path string // path is the directory holding the backups.
+}
+
+// NewDir creates a Dir, creating the directory if it does not exist.
+//
+// Parameters:
+//   - path: The directory holding the backups.
+//
+// Returns:
+//   - *Dir: The backup directory.
+//   - error: An error if the directory cannot be created.
+func NewDir(path string) (*Dir, error) {
+	if err := os.MkdirAll(path, dirPerm); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	return &Dir{path: path}, nil
+}
+
+// Save writes a sealed backup under a name, replacing a backup of the same name. The backup is written
+// to a temporary file first, so a failed write never leaves a truncated backup behind.
+//
+// Parameters:
+//   - name: The file name of the backup.
+//   - data: The sealed backup.
+//
+// Returns:
+//   - error: ErrInvalidName if the name is not a plain file name, or an error if the file cannot be written.
+func (d *Dir) Save(name string, data []byte) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(d.path, "."+name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary backup file: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if err = errors.Join(err, tmp.Chmod(filePerm), tmp.Close()); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write temporary backup file: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), filepath.Join(d.path, name)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to store backup: %w", err)
+	}
+	return nil
+}
+
+// Load reads the sealed backup stored under a name.
+//
+// Parameters:
+//   - name: The file name of the backup.
+//
+// Returns:
+//   - []byte: The sealed backup.
+//   - error: ErrInvalidName if the name is not a plain file name, ErrNotFound if there is no such backup,
+//     or an error if the file cannot be read.
+func (d *Dir) Load(name string) ([]byte, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(d.path, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// validateName checks that a backup name cannot address a file outside the directory.
+//
+// Parameters:
+//   - name: The file name of the backup.
+//
+// Returns:
+//   - error: ErrInvalidName if the name is empty, hidden or contains a path separator.
+func validateName(name string) error {
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return nil
+}
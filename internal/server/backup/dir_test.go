@@ -0,0 +1,44 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDir_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backups")
+	dir, err := NewDir(path)
+	require.NoError(t, err)
+
+	require.NoError(t, dir.Save("before.json", dump))
+	require.NoError(t, dir.Save("after.json", []byte("{}")))
+	require.NoError(t, dir.Save("after.json", dump))
+
+	loaded, err := dir.Load("after.json")
+	require.NoError(t, err)
+	assert.Equal(t, dump, loaded, "a backup of the same name is replaced")
+
+	entries, err := os.ReadDir(path)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2, "no temporary files are left behind")
+
+	_, err = dir.Load("missing.json")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestDir_InvalidName(t *testing.T) {
+	dir, err := NewDir(t.TempDir())
+	require.NoError(t, err)
+
+	for _, name := range []string{"", "../etc/passwd", "nested/backup.json", `..\backup.json`, ".hidden"} {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, dir.Save(name, dump), ErrInvalidName)
+			_, err := dir.Load(name)
+			assert.ErrorIs(t, err, ErrInvalidName)
+		})
+	}
+}
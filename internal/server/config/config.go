@@ -4,9 +4,28 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/alerting"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/internal/quota"
+	"github.com/gdyunin/metricol.git/internal/server/internal/retention"
+	"github.com/gdyunin/metricol.git/internal/server/internal/stream"
+	"github.com/gdyunin/metricol.git/internal/server/registration"
+	"github.com/gdyunin/metricol.git/internal/server/report"
+	"github.com/gdyunin/metricol.git/pkg/buildinfo"
+	"github.com/gdyunin/metricol.git/pkg/configaudit"
+	"github.com/gdyunin/metricol.git/pkg/convert"
+	"github.com/gdyunin/metricol.git/pkg/netaddr"
 
 	"github.com/caarlos0/env/v6"
 )
@@ -17,11 +36,88 @@ const (
 	defaultStoreInterval   = 300
 	defaultFileStoragePath = ""
 	defaultRestoreFlag     = true
+	defaultAutoMigrate     = true
+	defaultLazyConnect     = false
 	defaultDatabaseDSN     = ""
+	defaultStorageDSN      = ""
+	defaultReplicaDSN      = ""
 	defaultSigningKey      = ""
 	defaultPprofFlag       = false
 	defaultCryptoKey       = ""
 	defaultConfigPath      = ""
+	defaultTombstoneTTL    = 3600
+	defaultMetricRate      = 0
+	defaultMaxSeries       = 0
+	defaultPrefixLimits    = ""
+	defaultStreamBuffer    = 64
+	defaultStreamPolicy    = "drop-oldest"
+	defaultNextSigningKey  = ""
+	defaultNextCryptoKey   = ""
+	defaultRotationGrace   = 3600
+	defaultMaxClockSkew    = 0
+	defaultSampleRetention = 3600
+	defaultWriteBufferMs   = 0
+	defaultWriteBufferSize = 1000
+	defaultMaxFileSize     = 0
+	defaultFsyncPolicy     = "never"
+	defaultFsyncInterval   = 1
+	defaultMetricAllow     = ""
+	defaultMetricDeny      = ""
+	defaultRejectFiltered  = false
+	defaultAdminToken      = ""
+	defaultAdminUser       = ""
+	defaultAdminPassword   = ""
+	defaultRecordRequests  = false
+	defaultRecordBuffer    = 100
+	defaultFaultDelayMs    = 0
+	defaultFaultErrorRate  = 0.0
+	defaultTraceEndpoint   = ""
+	defaultTraceRatio      = 1.0
+	defaultAuditDSN        = ""
+	defaultBackupKey       = ""
+	defaultBackupRecipient = ""
+	defaultBackupDir       = ""
+	defaultMinAgentVersion = ""
+	defaultFederationName  = "local"
+	defaultFederationPeers = ""
+	defaultProvisioning    = ""
+	defaultGaugeSmoothing  = ""
+	defaultAPIQuotas       = ""
+	defaultAdminAddress    = ""
+	defaultRegistry        = ""
+	defaultAdvertise       = ""
+	defaultTrustedSubnet   = ""
+	defaultShutdownReport  = ""
+	defaultClientRate      = 0.0
+	defaultClientBurst     = 10
+	defaultClientRateBy    = RateLimitByIP
+	defaultDBMaxOpen       = 0
+	defaultDBMaxIdle       = 2
+	defaultDBLifetime      = 0
+	defaultDBStmtCache     = 512
+	defaultRetentionTypes  = ""
+	defaultRetentionNames  = ""
+	defaultRetentionSweep  = 60
+	defaultReportSchedule  = ""
+	defaultReportSMTP      = ""
+	defaultReportUser      = ""
+	defaultReportPassword  = ""
+	defaultReportFrom      = ""
+	defaultReportTo        = ""
+	defaultAlertInterval   = 30
+	defaultAlertRateLimit  = alerting.DefaultRateLimit
+	defaultAlertSlack      = ""
+	defaultAlertTgToken    = ""
+	defaultAlertTgChat     = ""
+	defaultAlertTemplate   = ""
+)
+
+const (
+	// Const RateLimitByIP applies the client rate limit per client IP address.
+	RateLimitByIP = "ip"
+	// Const RateLimitByAPIKey applies the client rate limit per API key, falling back to the IP address
+	// for requests without one.
+	RateLimitByAPIKey = "api-key"
 )
 
 // Config holds the configuration for the server, including its address,
@@ -29,37 +125,182 @@ const (
 // The configuration values can be provided via environment variables, command-line flags,
 // or default settings defined in the package.
 type Config struct {
-	ServerAddress   string `env:"ADDRESS"           json:"server_address,omitempty"`
-	FileStoragePath string `env:"FILE_STORAGE_PATH" json:"file_storage_path,omitempty"`
-	DatabaseDSN     string `env:"DATABASE_DSN"      json:"database_dsn,omitempty"`
-	SigningKey      string `env:"KEY"               json:"signing_key,omitempty"`
-	CryptoKey       string `env:"CRYPTO_KEY"        json:"crypto_key,omitempty"`
-	ConfigPath      string `env:"CONFIG"            json:"config_path,omitempty"`
-	StoreInterval   int    `env:"STORE_INTERVAL"    json:"store_interval,omitempty"`
-	Restore         bool   `env:"RESTORE"           json:"restore,omitempty"`
-	PprofFlag       bool   `env:"PPROF_SERVER_FLAG" json:"pprof_flag,omitempty"`
+	ServerAddress   string  `env:"ADDRESS"                   json:"server_address,omitempty"`
+	FileStoragePath string  `env:"FILE_STORAGE_PATH"         json:"file_storage_path,omitempty"`
+	DatabaseDSN     string  `env:"DATABASE_DSN"              json:"database_dsn,omitempty"`
+	StorageDSN      string  `env:"STORAGE_DSN"               json:"storage_dsn,omitempty"`
+	ReplicaDSN      string  `env:"DATABASE_REPLICA_DSN"      json:"database_replica_dsn,omitempty"`
+	SigningKey      string  `env:"KEY"                       json:"signing_key,omitempty"`
+	CryptoKey       string  `env:"CRYPTO_KEY"                json:"crypto_key,omitempty"`
+	ConfigPath      string  `env:"CONFIG"                    json:"config_path,omitempty"`
+	PrefixLimits    string  `env:"CARDINALITY_PREFIX_LIMITS" json:"cardinality_prefix_limits,omitempty"`
+	StreamPolicy    string  `env:"STREAM_DROP_POLICY"        json:"stream_drop_policy,omitempty"`
+	NextSigningKey  string  `env:"NEXT_KEY"                  json:"next_signing_key,omitempty"`
+	NextCryptoKey   string  `env:"NEXT_CRYPTO_KEY"           json:"next_crypto_key,omitempty"`
+	MetricAllow     string  `env:"METRIC_ALLOW"              json:"metric_allow,omitempty"`
+	MetricDeny      string  `env:"METRIC_DENY"               json:"metric_deny,omitempty"`
+	AdminToken      string  `env:"ADMIN_TOKEN"               json:"admin_token,omitempty"`
+	AdminUser       string  `env:"ADMIN_USER"                json:"admin_user,omitempty"`
+	AdminPassword   string  `env:"ADMIN_PASSWORD"            json:"admin_password,omitempty"`
+	MinAgentVersion string  `env:"MIN_AGENT_VERSION"         json:"min_agent_version,omitempty"`
+	FederationName  string  `env:"FEDERATION_NAME"           json:"federation_name,omitempty"`
+	FederationPeers string  `env:"FEDERATION_PEERS"          json:"federation_peers,omitempty"`
+	Provisioning    string  `env:"PROVISIONING_FILE"         json:"provisioning_file,omitempty"`
+	GaugeSmoothing  string  `env:"GAUGE_SMOOTHING"           json:"gauge_smoothing,omitempty"`
+	APIQuotas       string  `env:"API_QUOTAS"                json:"api_quotas,omitempty"`
+	AdminAddress    string  `env:"ADMIN_ADDRESS"             json:"admin_address,omitempty"`
+	Registry        string  `env:"REGISTRY"                  json:"registry,omitempty"`
+	Advertise       string  `env:"ADVERTISE_ADDRESS"         json:"advertise_address,omitempty"`
+	ClientRateBy    string  `env:"CLIENT_RATE_LIMIT_BY"      json:"client_rate_limit_by,omitempty"`
+	TrustedSubnet   string  `env:"TRUSTED_SUBNET"            json:"trusted_subnet,omitempty"`
+	ShutdownReport  string  `env:"SHUTDOWN_REPORT_FILE"      json:"shutdown_report_file,omitempty"`
+	TraceEndpoint   string  `env:"OTLP_ENDPOINT"             json:"otlp_endpoint,omitempty"`
+	AuditDSN        string  `env:"AUDIT_LOG_DSN"             json:"audit_log_dsn,omitempty"`
+	BackupKey       string  `env:"BACKUP_SIGNING_KEY"        json:"backup_signing_key,omitempty"`
+	BackupRecipient string  `env:"BACKUP_RECIPIENT"          json:"backup_recipient,omitempty"`
+	BackupDir       string  `env:"BACKUP_DIR"                json:"backup_dir,omitempty"`
+	RetentionTypes  string  `env:"RETENTION_TYPE_TTL"        json:"retention_type_ttl,omitempty"`
+	RetentionNames  string  `env:"RETENTION_NAME_TTL"        json:"retention_name_ttl,omitempty"`
+	ReportSchedule  string  `env:"REPORT_SCHEDULE"           json:"report_schedule,omitempty"`
+	ReportSMTP      string  `env:"REPORT_SMTP_ADDRESS"       json:"report_smtp_address,omitempty"`
+	ReportUser      string  `env:"REPORT_SMTP_USER"          json:"report_smtp_user,omitempty"`
+	ReportPassword  string  `env:"REPORT_SMTP_PASSWORD"      json:"report_smtp_password,omitempty"`
+	ReportFrom      string  `env:"REPORT_FROM"               json:"report_from,omitempty"`
+	ReportTo        string  `env:"REPORT_TO"                 json:"report_to,omitempty"`
+	AlertSlack      string  `env:"ALERT_SLACK_WEBHOOK"       json:"alert_slack_webhook,omitempty"`
+	AlertTgToken    string  `env:"ALERT_TELEGRAM_TOKEN"      json:"alert_telegram_token,omitempty"`
+	AlertTgChat     string  `env:"ALERT_TELEGRAM_CHAT"       json:"alert_telegram_chat,omitempty"`
+	AlertTemplate   string  `env:"ALERT_TEMPLATE_FILE"       json:"alert_template_file,omitempty"`
+	StoreInterval   int     `env:"STORE_INTERVAL"            json:"store_interval,omitempty"`
+	TombstoneTTL    int     `env:"TOMBSTONE_TTL"             json:"tombstone_ttl,omitempty"`
+	MetricRate      int     `env:"METRIC_RATE_LIMIT"         json:"metric_rate_limit,omitempty"`
+	MaxSeries       int     `env:"CARDINALITY_LIMIT"         json:"cardinality_limit,omitempty"`
+	StreamBuffer    int     `env:"STREAM_BUFFER_SIZE"        json:"stream_buffer_size,omitempty"`
+	RotationGrace   int     `env:"KEY_ROTATION_GRACE"        json:"key_rotation_grace,omitempty"`
+	FsyncPolicy     string  `env:"FSYNC_POLICY"              json:"fsync_policy,omitempty"`
+	FsyncInterval   int     `env:"FSYNC_INTERVAL"            json:"fsync_interval,omitempty"`
+	MaxFileSize     int     `env:"FILE_STORAGE_MAX_SIZE"     json:"file_storage_max_size,omitempty"`
+	WriteBufferMs   int     `env:"WRITE_BUFFER_INTERVAL_MS"  json:"write_buffer_interval_ms,omitempty"`
+	WriteBufferSize int     `env:"WRITE_BUFFER_SIZE"         json:"write_buffer_size,omitempty"`
+	MaxClockSkew    int     `env:"MAX_CLOCK_SKEW"            json:"max_clock_skew,omitempty"`
+	SampleRetention int     `env:"HISTORY_RETENTION"         json:"history_retention,omitempty"`
+	FaultDelayMs    int     `env:"FAULT_DELAY_MS"            json:"fault_delay_ms,omitempty"`
+	RecordBuffer    int     `env:"DEBUG_RECORD_BUFFER"       json:"debug_record_buffer,omitempty"`
+	ClientBurst     int     `env:"CLIENT_RATE_BURST"         json:"client_rate_burst,omitempty"`
+	DBMaxOpenConns  int     `env:"DATABASE_MAX_OPEN_CONNS"   json:"database_max_open_conns,omitempty"`
+	DBMaxIdleConns  int     `env:"DATABASE_MAX_IDLE_CONNS"   json:"database_max_idle_conns,omitempty"`
+	DBConnLifetime  int     `env:"DATABASE_CONN_LIFETIME"    json:"database_conn_lifetime,omitempty"`
+	DBStmtCache     int     `env:"DATABASE_STATEMENT_CACHE"  json:"database_statement_cache,omitempty"`
+	RetentionSweep  int     `env:"RETENTION_SWEEP_INTERVAL"  json:"retention_sweep_interval,omitempty"`
+	AlertInterval   int     `env:"ALERT_INTERVAL"            json:"alert_interval,omitempty"`
+	AlertRateLimit  int     `env:"ALERT_RATE_LIMIT"          json:"alert_rate_limit,omitempty"`
+	FaultErrorRate  float64 `env:"FAULT_ERROR_RATE"          json:"fault_error_rate,omitempty"`
+	ClientRate      float64 `env:"CLIENT_RATE_LIMIT"         json:"client_rate_limit,omitempty"`
+	TraceRatio      float64 `env:"TRACE_SAMPLE_RATIO"        json:"trace_sample_ratio,omitempty"`
+	Restore         bool    `env:"RESTORE"                   json:"restore,omitempty"`
+	PprofFlag       bool    `env:"PPROF_SERVER_FLAG"         json:"pprof_flag,omitempty"`
+	AutoMigrate     bool    `env:"AUTO_MIGRATE"              json:"auto_migrate"`
+	LazyConnect     bool    `env:"DATABASE_LAZY_CONNECT"     json:"database_lazy_connect,omitempty"`
+	RejectFiltered  bool    `env:"REJECT_FILTERED_METRICS"   json:"reject_filtered_metrics,omitempty"`
+	RecordRequests  bool    `env:"DEBUG_RECORD_REQUESTS"     json:"debug_record_requests,omitempty"`
+	MigrateUp       bool    `env:"MIGRATE_UP"                json:"-"`
+	MigrateDown     bool    `env:"MIGRATE_DOWN"              json:"-"`
+	MigrateStatus   bool    `env:"MIGRATE_STATUS"            json:"-"`
 }
 
-// ParseConfig initializes the Config with default values, overrides them with command-line flags if provided,
-// and then allows environment variables to set or override the configuration.
-// It returns a pointer to the Config structure or an error if environment parsing fails.
-//
-// Returns:
-//   - *Config: A pointer to the populated Config structure.
-//   - error: An error if parsing of environment variables fails.
-func ParseConfig() (*Config, error) {
-	// Default settings for the server configuration.
-	cfg := Config{
+// defaultConfig returns the configuration used for the settings that are not given.
+func defaultConfig() Config {
+	return Config{
 		ServerAddress:   defaultServerAddress,
 		StoreInterval:   defaultStoreInterval,
 		FileStoragePath: defaultFileStoragePath,
 		Restore:         defaultRestoreFlag,
+		AutoMigrate:     defaultAutoMigrate,
+		LazyConnect:     defaultLazyConnect,
 		DatabaseDSN:     defaultDatabaseDSN,
+		StorageDSN:      defaultStorageDSN,
+		ReplicaDSN:      defaultReplicaDSN,
 		SigningKey:      defaultSigningKey,
 		PprofFlag:       defaultPprofFlag,
 		CryptoKey:       defaultCryptoKey,
 		ConfigPath:      defaultConfigPath,
+		TombstoneTTL:    defaultTombstoneTTL,
+		MetricRate:      defaultMetricRate,
+		MaxSeries:       defaultMaxSeries,
+		PrefixLimits:    defaultPrefixLimits,
+		StreamBuffer:    defaultStreamBuffer,
+		StreamPolicy:    defaultStreamPolicy,
+		NextSigningKey:  defaultNextSigningKey,
+		NextCryptoKey:   defaultNextCryptoKey,
+		RotationGrace:   defaultRotationGrace,
+		MaxClockSkew:    defaultMaxClockSkew,
+		SampleRetention: defaultSampleRetention,
+		WriteBufferMs:   defaultWriteBufferMs,
+		WriteBufferSize: defaultWriteBufferSize,
+		MaxFileSize:     defaultMaxFileSize,
+		FsyncPolicy:     defaultFsyncPolicy,
+		FsyncInterval:   defaultFsyncInterval,
+		MetricAllow:     defaultMetricAllow,
+		MetricDeny:      defaultMetricDeny,
+		RejectFiltered:  defaultRejectFiltered,
+		AdminToken:      defaultAdminToken,
+		AdminUser:       defaultAdminUser,
+		AdminPassword:   defaultAdminPassword,
+		RecordRequests:  defaultRecordRequests,
+		RecordBuffer:    defaultRecordBuffer,
+		FaultDelayMs:    defaultFaultDelayMs,
+		FaultErrorRate:  defaultFaultErrorRate,
+		TraceEndpoint:   defaultTraceEndpoint,
+		TraceRatio:      defaultTraceRatio,
+		AuditDSN:        defaultAuditDSN,
+		BackupKey:       defaultBackupKey,
+		BackupRecipient: defaultBackupRecipient,
+		BackupDir:       defaultBackupDir,
+		MinAgentVersion: defaultMinAgentVersion,
+		FederationName:  defaultFederationName,
+		FederationPeers: defaultFederationPeers,
+		Provisioning:    defaultProvisioning,
+		GaugeSmoothing:  defaultGaugeSmoothing,
+		APIQuotas:       defaultAPIQuotas,
+		AdminAddress:    defaultAdminAddress,
+		Registry:        defaultRegistry,
+		Advertise:       defaultAdvertise,
+		TrustedSubnet:   defaultTrustedSubnet,
+		ShutdownReport:  defaultShutdownReport,
+		ClientRate:      defaultClientRate,
+		ClientBurst:     defaultClientBurst,
+		ClientRateBy:    defaultClientRateBy,
+		DBMaxOpenConns:  defaultDBMaxOpen,
+		DBMaxIdleConns:  defaultDBMaxIdle,
+		DBConnLifetime:  defaultDBLifetime,
+		DBStmtCache:     defaultDBStmtCache,
+		RetentionTypes:  defaultRetentionTypes,
+		RetentionNames:  defaultRetentionNames,
+		ReportSchedule:  defaultReportSchedule,
+		ReportSMTP:      defaultReportSMTP,
+		ReportUser:      defaultReportUser,
+		ReportPassword:  defaultReportPassword,
+		ReportFrom:      defaultReportFrom,
+		ReportTo:        defaultReportTo,
+		RetentionSweep:  defaultRetentionSweep,
+		AlertSlack:      defaultAlertSlack,
+		AlertTgToken:    defaultAlertTgToken,
+		AlertTgChat:     defaultAlertTgChat,
+		AlertTemplate:   defaultAlertTemplate,
+		AlertInterval:   defaultAlertInterval,
+		AlertRateLimit:  defaultAlertRateLimit,
 	}
+}
+
+// ParseConfig initializes the Config with default values, overrides them with command-line flags if provided,
+// and then allows environment variables to set or override the configuration.
+// It returns a pointer to the Config structure or an error if environment parsing fails.
+//
+// Returns:
+//   - *Config: A pointer to the populated Config structure.
+//   - error: An error if parsing of environment variables fails.
+func ParseConfig() (*Config, error) {
+	cfg := defaultConfig()
 
 	// Populate the configuration from command-line flags.
 	parseFlagsOrSetDefault(&cfg)
@@ -75,16 +316,478 @@ func ParseConfig() (*Config, error) {
 		}
 	}
 
+	if err := netaddr.ValidateListen(cfg.ServerAddress); err != nil {
+		return nil, fmt.Errorf("invalid server address: %w", err)
+	}
+	if cfg.AdminAddress != defaultAdminAddress {
+		if err := netaddr.ValidateListen(cfg.AdminAddress); err != nil {
+			return nil, fmt.Errorf("invalid admin address: %w", err)
+		}
+		if cfg.AdminToken == defaultAdminToken && cfg.AdminUser == "" && !netaddr.IsLoopback(cfg.AdminAddress) {
+			return nil, errors.New("invalid admin address: a non-loopback admin listener requires admin credentials")
+		}
+	}
+	if cfg.Registry != defaultRegistry {
+		if _, err := registration.ParseURL(cfg.Registry); err != nil {
+			return nil, fmt.Errorf("invalid registry: %w", err)
+		}
+	}
+	if cfg.Advertise != defaultAdvertise {
+		if err := netaddr.ValidateListen(cfg.Advertise); err != nil {
+			return nil, fmt.Errorf("invalid advertise address: %w", err)
+		}
+	}
+	if _, err := cfg.Storage(); err != nil {
+		return nil, fmt.Errorf("invalid storage: %w", err)
+	}
+	if _, err := cfg.CardinalityPrefixLimits(); err != nil {
+		return nil, fmt.Errorf("invalid cardinality prefix limits: %w", err)
+	}
+	if _, _, err := cfg.MetricNameFilter(); err != nil {
+		return nil, fmt.Errorf("invalid metric name filter: %w", err)
+	}
+	if _, err := cfg.FederationPeerURLs(); err != nil {
+		return nil, fmt.Errorf("invalid federation peers: %w", err)
+	}
+	if _, err := cfg.QuotaLimits(); err != nil {
+		return nil, fmt.Errorf("invalid API quotas: %w", err)
+	}
+	if _, err := cfg.RetentionPolicy(); err != nil {
+		return nil, fmt.Errorf("invalid retention policy: %w", err)
+	}
+	if _, _, err := cfg.Reports(); err != nil {
+		return nil, fmt.Errorf("invalid reports: %w", err)
+	}
+	if err := cfg.validateAlerting(); err != nil {
+		return nil, fmt.Errorf("invalid alerting: %w", err)
+	}
+	if (cfg.AdminUser == "") != (cfg.AdminPassword == "") {
+		return nil, errors.New("invalid admin credentials: the admin user and password must be set together")
+	}
+	if cfg.FaultErrorRate < 0 || cfg.FaultErrorRate > 1 {
+		return nil, fmt.Errorf("invalid fault error rate: %v is not between 0 and 1", cfg.FaultErrorRate)
+	}
+	if cfg.TraceRatio < 0 || cfg.TraceRatio > 1 {
+		return nil, fmt.Errorf("invalid trace sample ratio: %v is not between 0 and 1", cfg.TraceRatio)
+	}
+	if _, err := cfg.TrustedNet(); err != nil {
+		return nil, fmt.Errorf("invalid trusted subnet: %w", err)
+	}
+	if err := cfg.validateClientRateLimit(); err != nil {
+		return nil, fmt.Errorf("invalid client rate limit: %w", err)
+	}
+	if cfg.MinAgentVersion != defaultMinAgentVersion {
+		if err := buildinfo.ValidateVersion(cfg.MinAgentVersion); err != nil {
+			return nil, fmt.Errorf("invalid minimum agent version: %w", err)
+		}
+	}
+	if _, err := stream.ParseDropPolicy(cfg.StreamPolicy); err != nil {
+		return nil, fmt.Errorf("invalid stream drop policy: %w", err)
+	}
+	if err := cfg.validateMigrationCommand(); err != nil {
+		return nil, fmt.Errorf("invalid migration command: %w", err)
+	}
+
 	return &cfg, nil
 }
 
+// Audit describes every setting with its effective value and where it came from, with secrets redacted.
+// It must be called on the configuration returned by ParseConfig, which the command-line flags are bound to.
+//
+// Returns:
+//   - []configaudit.Entry: The settings in declaration order.
+func (c *Config) Audit() []configaudit.Entry {
+	return configaudit.Audit(c, defaultConfig(), flag.CommandLine, os.LookupEnv)
+}
+
+// Reports parses the report settings: ReportSchedule, when report emails are sent, and the SMTP server,
+// the sender and ReportTo, a comma-separated list of recipients, they are sent with.
+//
+// Returns:
+//   - *report.Schedule: The schedule, nil if no reports are sent.
+//   - *report.Mailer: The mailer sending the reports, nil if no reports are sent.
+//   - error: An error if the schedule is malformed or the mail settings are incomplete.
+func (c *Config) Reports() (*report.Schedule, *report.Mailer, error) {
+	if c.ReportSchedule == defaultReportSchedule {
+		return nil, nil, nil
+	}
+	schedule, err := report.ParseSchedule(c.ReportSchedule)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse schedule: %w", err)
+	}
+	var to []string
+	for _, address := range strings.Split(c.ReportTo, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			to = append(to, address)
+		}
+	}
+	mailer, err := report.NewMailer(c.ReportSMTP, c.ReportUser, c.ReportPassword, c.ReportFrom, to)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to configure mail: %w", err)
+	}
+	return schedule, mailer, nil
+}
+
+// Notifiers creates the chat integrations notified when an alert rule starts or stops firing:
+// a Slack incoming webhook if AlertSlack is set and a Telegram bot if AlertTgToken and AlertTgChat are.
+//
+// Returns:
+//   - []alerting.Notifier: The notifiers, empty if no integration is configured.
+//   - error: An error if an integration is misconfigured.
+func (c *Config) Notifiers() ([]alerting.Notifier, error) {
+	var notifiers []alerting.Notifier
+	if c.AlertSlack != defaultAlertSlack {
+		slack, err := alerting.NewSlackNotifier(c.AlertSlack)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Slack notifier: %w", err)
+		}
+		notifiers = append(notifiers, slack)
+	}
+	if c.AlertTgToken != defaultAlertTgToken || c.AlertTgChat != defaultAlertTgChat {
+		telegram, err := alerting.NewTelegramNotifier(c.AlertTgToken, c.AlertTgChat)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Telegram notifier: %w", err)
+		}
+		notifiers = append(notifiers, telegram)
+	}
+	return notifiers, nil
+}
+
+// NotificationTemplate reads and parses AlertTemplate, the file with the template of the notifications.
+//
+// Returns:
+//   - *alerting.Template: The template, nil if the default template is used.
+//   - error: An error if the file cannot be read or the template is invalid.
+func (c *Config) NotificationTemplate() (*alerting.Template, error) {
+	if c.AlertTemplate == defaultAlertTemplate {
+		return nil, nil //nolint:nilnil // Without a file the default template is used.
+	}
+	text, err := os.ReadFile(c.AlertTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template file: %w", err)
+	}
+	tmpl, err := alerting.ParseTemplate(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("invalid template file %s: %w", c.AlertTemplate, err)
+	}
+	return tmpl, nil
+}
+
+// validateAlerting checks that the notifiers and their template are valid, that they have alert rules
+// to watch and that the evaluation interval and the rate limit are positive.
+func (c *Config) validateAlerting() error {
+	notifiers, err := c.Notifiers()
+	if err != nil {
+		return err
+	}
+	if _, err := c.NotificationTemplate(); err != nil {
+		return err
+	}
+	if len(notifiers) > 0 && c.Provisioning == defaultProvisioning {
+		return errors.New("notifiers need alert rules from a provisioning file")
+	}
+	if c.AlertInterval < 1 {
+		return fmt.Errorf("interval %d must be positive", c.AlertInterval)
+	}
+	if c.AlertRateLimit < 1 {
+		return fmt.Errorf("rate limit %d must be positive", c.AlertRateLimit)
+	}
+	return nil
+}
+
+// TrustedNet parses TrustedSubnet, the CIDR clients must send requests from.
+//
+// Returns:
+//   - *net.IPNet: The trusted subnet, nil if requests are accepted from anywhere.
+//   - error: An error if the subnet is not in CIDR notation.
+func (c *Config) TrustedNet() (*net.IPNet, error) {
+	if c.TrustedSubnet == defaultTrustedSubnet {
+		return nil, nil //nolint:nilnil // Without a subnet every client is trusted.
+	}
+	_, subnet, err := net.ParseCIDR(strings.TrimSpace(c.TrustedSubnet))
+	if err != nil {
+		return nil, fmt.Errorf("expected CIDR notation such as 10.0.0.0/8: %w", err)
+	}
+	return subnet, nil
+}
+
+// validateClientRateLimit checks that the client rate limit is not negative, its burst is positive and
+// clients are told apart by a supported attribute.
+func (c *Config) validateClientRateLimit() error {
+	if c.ClientRate < 0 {
+		return fmt.Errorf("rate %v must not be negative", c.ClientRate)
+	}
+	if c.ClientBurst < 1 {
+		return fmt.Errorf("burst %d must be positive", c.ClientBurst)
+	}
+	if c.ClientRateBy != RateLimitByIP && c.ClientRateBy != RateLimitByAPIKey {
+		return fmt.Errorf("clients must be limited by %q or %q, got %q", RateLimitByIP, RateLimitByAPIKey, c.ClientRateBy)
+	}
+	return nil
+}
+
+// MigrationCommand reports whether one of the one-shot migration commands was requested.
+// The server then manages the database schema and exits instead of serving metrics.
+//
+// Returns:
+//   - bool: True if -migrate-up, -migrate-down or -migrate-status is set.
+func (c *Config) MigrationCommand() bool {
+	return c.MigrateUp || c.MigrateDown || c.MigrateStatus
+}
+
+// validateMigrationCommand checks that at most one migration command is set and that it has a database.
+func (c *Config) validateMigrationCommand() error {
+	requested := 0
+	for _, set := range []bool{c.MigrateUp, c.MigrateDown, c.MigrateStatus} {
+		if set {
+			requested++
+		}
+	}
+	if requested > 1 {
+		return errors.New("only one of -migrate-up, -migrate-down and -migrate-status can be set")
+	}
+	if requested == 0 {
+		return nil
+	}
+	if storage, err := c.Storage(); err != nil || storage.Kind != StoragePostgres {
+		return errors.New("migration commands require a PostgreSQL storage")
+	}
+	return nil
+}
+
+// CardinalityPrefixLimits parses PrefixLimits, a comma-separated list of "prefix=limit" pairs.
+//
+// Returns:
+//   - map[string]int: The limit for every configured metric name prefix.
+//   - error: An error if a pair is malformed or a limit is not a positive integer.
+func (c *Config) CardinalityPrefixLimits() (map[string]int, error) {
+	limits := make(map[string]int)
+	if strings.TrimSpace(c.PrefixLimits) == "" {
+		return limits, nil
+	}
+
+	for _, pair := range strings.Split(c.PrefixLimits, ",") {
+		prefix, rawLimit, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || prefix == "" {
+			return nil, fmt.Errorf("expected prefix=limit, got %q", pair)
+		}
+		limit, err := strconv.Atoi(rawLimit)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("limit for prefix %q must be a positive integer, got %q", prefix, rawLimit)
+		}
+		limits[prefix] = limit
+	}
+	return limits, nil
+}
+
+// FederationPeerURLs parses FederationPeers, a comma-separated list of "name=url" pairs.
+// An address without a scheme is taken as plain HTTP.
+//
+// Returns:
+//   - map[string]string: The base URL of every peer keyed by its name.
+//   - error: An error if a pair is malformed, a name repeats or names this server, or a URL is invalid.
+func (c *Config) FederationPeerURLs() (map[string]string, error) {
+	peers := make(map[string]string)
+	if strings.TrimSpace(c.FederationPeers) == "" {
+		return peers, nil
+	}
+
+	for _, pair := range strings.Split(c.FederationPeers, ",") {
+		name, rawURL, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || name == "" || rawURL == "" {
+			return nil, fmt.Errorf("expected name=url, got %q", pair)
+		}
+		if _, exists := peers[name]; exists || name == c.FederationName {
+			return nil, fmt.Errorf("peer name %q is not unique", name)
+		}
+		if !strings.Contains(rawURL, "://") {
+			rawURL = "http://" + rawURL
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("peer %q has an invalid URL %q", name, rawURL)
+		}
+		peers[name] = strings.TrimSuffix(rawURL, "/")
+	}
+	return peers, nil
+}
+
+// MetricNameFilter parses MetricAllow and MetricDeny, comma-separated lists of metric name glob patterns.
+//
+// Returns:
+//   - []string: The patterns of accepted metric names.
+//   - []string: The patterns of refused metric names.
+//   - error: An error if a pattern is malformed.
+func (c *Config) MetricNameFilter() ([]string, []string, error) {
+	allow, err := splitNamePatterns(c.MetricAllow)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid allow list: %w", err)
+	}
+	deny, err := splitNamePatterns(c.MetricDeny)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid deny list: %w", err)
+	}
+	return allow, deny, nil
+}
+
+// GaugeSmoothingAlphas parses GaugeSmoothing, a comma-separated list of "pattern=alpha" pairs, where pattern
+// is a glob matching gauge names and alpha is the weight of new samples in their moving average.
+//
+// Returns:
+//   - map[string]float64: The alpha of every configured pattern.
+//   - error: An error if a pair is malformed, a pattern is invalid or an alpha is not in (0, 1].
+func (c *Config) GaugeSmoothingAlphas() (map[string]float64, error) {
+	alphas := make(map[string]float64)
+	if strings.TrimSpace(c.GaugeSmoothing) == "" {
+		return alphas, nil
+	}
+
+	for _, pair := range strings.Split(c.GaugeSmoothing, ",") {
+		pattern, rawAlpha, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("expected pattern=alpha, got %q", pair)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+		alpha, err := strconv.ParseFloat(rawAlpha, 64)
+		if err != nil || alpha <= 0 || alpha > 1 {
+			return nil, fmt.Errorf("alpha for pattern %q must be in (0, 1], got %q", pattern, rawAlpha)
+		}
+		alphas[pattern] = alpha
+	}
+	return alphas, nil
+}
+
+// RetentionPolicy parses RetentionTypes and RetentionNames, comma-separated lists of "type=seconds" and
+// "pattern=seconds" pairs, where pattern is a glob matching metric names, into the time-to-live of metrics.
+//
+// Returns:
+//   - *retention.Policy: The retention policy, empty if no time-to-live is configured.
+//   - error: An error if a pair is malformed, a type is unknown, a pattern is invalid or a time-to-live
+//     is not a positive number of seconds.
+func (c *Config) RetentionPolicy() (*retention.Policy, error) {
+	types, err := parseTTLs(c.RetentionTypes)
+	if err != nil {
+		return nil, fmt.Errorf("type time-to-live: %w", err)
+	}
+	for metricType := range types {
+		switch metricType {
+		case entity.MetricTypeGauge, entity.MetricTypeCounter, entity.MetricTypeFloatCounter, entity.MetricTypeInfo:
+		default:
+			return nil, fmt.Errorf("type time-to-live: unknown metric type %q", metricType)
+		}
+	}
+	names, err := parseTTLs(c.RetentionNames)
+	if err != nil {
+		return nil, fmt.Errorf("name time-to-live: %w", err)
+	}
+	policy, err := retention.NewPolicy(types, names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create retention policy: %w", err)
+	}
+	return policy, nil
+}
+
+// parseTTLs parses a comma-separated list of "key=seconds" pairs.
+//
+// Parameters:
+//   - raw: The list of pairs.
+//
+// Returns:
+//   - map[string]time.Duration: The time-to-live of every key.
+//   - error: An error if a pair is malformed or the seconds are not a positive integer.
+func parseTTLs(raw string) (map[string]time.Duration, error) {
+	ttls := make(map[string]time.Duration)
+	if strings.TrimSpace(raw) == "" {
+		return ttls, nil
+	}
+
+	for _, pair := range strings.Split(raw, ",") {
+		key, rawSeconds, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("expected key=seconds, got %q", pair)
+		}
+		seconds, err := strconv.Atoi(rawSeconds)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("seconds for %q must be a positive integer, got %q", key, rawSeconds)
+		}
+		ttls[key] = convert.IntegerToSeconds(seconds)
+	}
+	return ttls, nil
+}
+
+// QuotaLimits parses APIQuotas, a semicolon-separated list of "key:quota=limit,..." entries, where key is
+// an API key or "*" for any other key and quota is one of metrics, updates or batch. Omitted quotas are unlimited.
+//
+// Returns:
+//   - map[string]quota.Limits: The quotas of every configured API key.
+//   - error: An error if an entry is malformed, a key repeats, a quota is unknown or a limit is not positive.
+func (c *Config) QuotaLimits() (map[string]quota.Limits, error) {
+	quotas := make(map[string]quota.Limits)
+	if strings.TrimSpace(c.APIQuotas) == "" {
+		return quotas, nil
+	}
+
+	for _, entry := range strings.Split(c.APIQuotas, ";") {
+		key, rawLimits, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || key == "" || rawLimits == "" {
+			return nil, fmt.Errorf("expected key:quota=limit, got %q", entry)
+		}
+		if _, exists := quotas[key]; exists {
+			return nil, fmt.Errorf("key %q is configured more than once", quota.KeyID(key))
+		}
+
+		var limits quota.Limits
+		for _, pair := range strings.Split(rawLimits, ",") {
+			name, rawLimit, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				return nil, fmt.Errorf("expected quota=limit, got %q", pair)
+			}
+			limit, err := strconv.Atoi(rawLimit)
+			if err != nil || limit <= 0 {
+				return nil, fmt.Errorf("limit for quota %q must be a positive integer, got %q", name, rawLimit)
+			}
+			switch name {
+			case quota.QuotaMetrics:
+				limits.Metrics = limit
+			case quota.QuotaUpdates:
+				limits.UpdatesPerMinute = limit
+			case quota.QuotaBatch:
+				limits.BatchSize = limit
+			default:
+				return nil, fmt.Errorf("unknown quota %q", name)
+			}
+		}
+		quotas[key] = limits
+	}
+	return quotas, nil
+}
+
+// splitNamePatterns splits a comma-separated list of glob patterns and checks that every pattern is well-formed.
+func splitNamePatterns(raw string) ([]string, error) {
+	patterns := make([]string, 0)
+	for _, pattern := range strings.Split(raw, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
 func mergeConfigFile(cfg *Config) error {
 	data, err := os.ReadFile(cfg.ConfigPath)
 	if err != nil {
 		return fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	tempCfg := Config{}
+	// AutoMigrate defaults to true, so a file that does not mention it must not disable it.
+	tempCfg := Config{AutoMigrate: defaultAutoMigrate}
 	if err := json.Unmarshal(data, &tempCfg); err != nil {
 		return fmt.Errorf("cannot parse JSON config %q: %w", cfg.ConfigPath, err)
 	}
@@ -99,9 +802,96 @@ func mergeConfigFile(cfg *Config) error {
 	if cfg.FileStoragePath == defaultFileStoragePath && tempCfg.FileStoragePath != defaultFileStoragePath {
 		cfg.FileStoragePath = tempCfg.FileStoragePath
 	}
+	if cfg.FederationName == defaultFederationName && tempCfg.FederationName != "" {
+		cfg.FederationName = tempCfg.FederationName
+	}
+	if cfg.FederationPeers == defaultFederationPeers && tempCfg.FederationPeers != defaultFederationPeers {
+		cfg.FederationPeers = tempCfg.FederationPeers
+	}
+	if cfg.Provisioning == defaultProvisioning && tempCfg.Provisioning != defaultProvisioning {
+		cfg.Provisioning = tempCfg.Provisioning
+	}
+	if cfg.GaugeSmoothing == defaultGaugeSmoothing && tempCfg.GaugeSmoothing != defaultGaugeSmoothing {
+		cfg.GaugeSmoothing = tempCfg.GaugeSmoothing
+	}
+	if cfg.APIQuotas == defaultAPIQuotas && tempCfg.APIQuotas != defaultAPIQuotas {
+		cfg.APIQuotas = tempCfg.APIQuotas
+	}
+	if cfg.AdminAddress == defaultAdminAddress && tempCfg.AdminAddress != defaultAdminAddress {
+		cfg.AdminAddress = tempCfg.AdminAddress
+	}
+	if cfg.Registry == defaultRegistry && tempCfg.Registry != defaultRegistry {
+		cfg.Registry = tempCfg.Registry
+	}
+	if cfg.Advertise == defaultAdvertise && tempCfg.Advertise != defaultAdvertise {
+		cfg.Advertise = tempCfg.Advertise
+	}
 	if cfg.DatabaseDSN == defaultDatabaseDSN && tempCfg.DatabaseDSN != defaultDatabaseDSN {
 		cfg.DatabaseDSN = tempCfg.DatabaseDSN
 	}
+	if cfg.StorageDSN == defaultStorageDSN && tempCfg.StorageDSN != defaultStorageDSN {
+		cfg.StorageDSN = tempCfg.StorageDSN
+	}
+	if cfg.ReplicaDSN == defaultReplicaDSN && tempCfg.ReplicaDSN != defaultReplicaDSN {
+		cfg.ReplicaDSN = tempCfg.ReplicaDSN
+	}
+	if cfg.DBMaxOpenConns == defaultDBMaxOpen && tempCfg.DBMaxOpenConns != defaultDBMaxOpen {
+		cfg.DBMaxOpenConns = tempCfg.DBMaxOpenConns
+	}
+	if cfg.DBMaxIdleConns == defaultDBMaxIdle && tempCfg.DBMaxIdleConns != 0 {
+		cfg.DBMaxIdleConns = tempCfg.DBMaxIdleConns
+	}
+	if cfg.DBConnLifetime == defaultDBLifetime && tempCfg.DBConnLifetime != defaultDBLifetime {
+		cfg.DBConnLifetime = tempCfg.DBConnLifetime
+	}
+	if cfg.DBStmtCache == defaultDBStmtCache && tempCfg.DBStmtCache != 0 {
+		cfg.DBStmtCache = tempCfg.DBStmtCache
+	}
+	if cfg.RetentionTypes == defaultRetentionTypes && tempCfg.RetentionTypes != defaultRetentionTypes {
+		cfg.RetentionTypes = tempCfg.RetentionTypes
+	}
+	if cfg.RetentionNames == defaultRetentionNames && tempCfg.RetentionNames != defaultRetentionNames {
+		cfg.RetentionNames = tempCfg.RetentionNames
+	}
+	if cfg.ReportSchedule == defaultReportSchedule && tempCfg.ReportSchedule != defaultReportSchedule {
+		cfg.ReportSchedule = tempCfg.ReportSchedule
+	}
+	if cfg.ReportSMTP == defaultReportSMTP && tempCfg.ReportSMTP != defaultReportSMTP {
+		cfg.ReportSMTP = tempCfg.ReportSMTP
+	}
+	if cfg.ReportUser == defaultReportUser && tempCfg.ReportUser != defaultReportUser {
+		cfg.ReportUser = tempCfg.ReportUser
+	}
+	if cfg.ReportPassword == defaultReportPassword && tempCfg.ReportPassword != defaultReportPassword {
+		cfg.ReportPassword = tempCfg.ReportPassword
+	}
+	if cfg.ReportFrom == defaultReportFrom && tempCfg.ReportFrom != defaultReportFrom {
+		cfg.ReportFrom = tempCfg.ReportFrom
+	}
+	if cfg.ReportTo == defaultReportTo && tempCfg.ReportTo != defaultReportTo {
+		cfg.ReportTo = tempCfg.ReportTo
+	}
+	if cfg.RetentionSweep == defaultRetentionSweep && tempCfg.RetentionSweep != 0 {
+		cfg.RetentionSweep = tempCfg.RetentionSweep
+	}
+	if cfg.AlertSlack == defaultAlertSlack && tempCfg.AlertSlack != defaultAlertSlack {
+		cfg.AlertSlack = tempCfg.AlertSlack
+	}
+	if cfg.AlertTgToken == defaultAlertTgToken && tempCfg.AlertTgToken != defaultAlertTgToken {
+		cfg.AlertTgToken = tempCfg.AlertTgToken
+	}
+	if cfg.AlertTgChat == defaultAlertTgChat && tempCfg.AlertTgChat != defaultAlertTgChat {
+		cfg.AlertTgChat = tempCfg.AlertTgChat
+	}
+	if cfg.AlertTemplate == defaultAlertTemplate && tempCfg.AlertTemplate != defaultAlertTemplate {
+		cfg.AlertTemplate = tempCfg.AlertTemplate
+	}
+	if cfg.AlertInterval == defaultAlertInterval && tempCfg.AlertInterval != 0 {
+		cfg.AlertInterval = tempCfg.AlertInterval
+	}
+	if cfg.AlertRateLimit == defaultAlertRateLimit && tempCfg.AlertRateLimit != 0 {
+		cfg.AlertRateLimit = tempCfg.AlertRateLimit
+	}
 	if cfg.SigningKey == defaultSigningKey && tempCfg.SigningKey != defaultSigningKey {
 		cfg.SigningKey = tempCfg.SigningKey
 	}
@@ -111,9 +901,129 @@ func mergeConfigFile(cfg *Config) error {
 	if cfg.StoreInterval == defaultStoreInterval && tempCfg.StoreInterval != defaultStoreInterval {
 		cfg.StoreInterval = tempCfg.StoreInterval
 	}
+	if cfg.TombstoneTTL == defaultTombstoneTTL && tempCfg.TombstoneTTL != 0 {
+		cfg.TombstoneTTL = tempCfg.TombstoneTTL
+	}
+	if cfg.MetricRate == defaultMetricRate && tempCfg.MetricRate != defaultMetricRate {
+		cfg.MetricRate = tempCfg.MetricRate
+	}
+	if cfg.MaxSeries == defaultMaxSeries && tempCfg.MaxSeries != defaultMaxSeries {
+		cfg.MaxSeries = tempCfg.MaxSeries
+	}
+	if cfg.PrefixLimits == defaultPrefixLimits && tempCfg.PrefixLimits != defaultPrefixLimits {
+		cfg.PrefixLimits = tempCfg.PrefixLimits
+	}
+	if cfg.StreamBuffer == defaultStreamBuffer && tempCfg.StreamBuffer != 0 {
+		cfg.StreamBuffer = tempCfg.StreamBuffer
+	}
+	if cfg.StreamPolicy == defaultStreamPolicy && tempCfg.StreamPolicy != "" {
+		cfg.StreamPolicy = tempCfg.StreamPolicy
+	}
+	if cfg.NextSigningKey == defaultNextSigningKey && tempCfg.NextSigningKey != defaultNextSigningKey {
+		cfg.NextSigningKey = tempCfg.NextSigningKey
+	}
+	if cfg.NextCryptoKey == defaultNextCryptoKey && tempCfg.NextCryptoKey != defaultNextCryptoKey {
+		cfg.NextCryptoKey = tempCfg.NextCryptoKey
+	}
+	if cfg.RotationGrace == defaultRotationGrace && tempCfg.RotationGrace != 0 {
+		cfg.RotationGrace = tempCfg.RotationGrace
+	}
+	if cfg.MaxClockSkew == defaultMaxClockSkew && tempCfg.MaxClockSkew != defaultMaxClockSkew {
+		cfg.MaxClockSkew = tempCfg.MaxClockSkew
+	}
+	if cfg.SampleRetention == defaultSampleRetention && tempCfg.SampleRetention != defaultSampleRetention {
+		cfg.SampleRetention = tempCfg.SampleRetention
+	}
+	if cfg.WriteBufferMs == defaultWriteBufferMs && tempCfg.WriteBufferMs != defaultWriteBufferMs {
+		cfg.WriteBufferMs = tempCfg.WriteBufferMs
+	}
+	if cfg.WriteBufferSize == defaultWriteBufferSize && tempCfg.WriteBufferSize != 0 {
+		cfg.WriteBufferSize = tempCfg.WriteBufferSize
+	}
+	if cfg.MaxFileSize == defaultMaxFileSize && tempCfg.MaxFileSize != defaultMaxFileSize {
+		cfg.MaxFileSize = tempCfg.MaxFileSize
+	}
+	if cfg.FsyncPolicy == defaultFsyncPolicy && tempCfg.FsyncPolicy != "" {
+		cfg.FsyncPolicy = tempCfg.FsyncPolicy
+	}
+	if cfg.MetricAllow == defaultMetricAllow && tempCfg.MetricAllow != defaultMetricAllow {
+		cfg.MetricAllow = tempCfg.MetricAllow
+	}
+	if cfg.MetricDeny == defaultMetricDeny && tempCfg.MetricDeny != defaultMetricDeny {
+		cfg.MetricDeny = tempCfg.MetricDeny
+	}
+	if cfg.AdminToken == defaultAdminToken && tempCfg.AdminToken != defaultAdminToken {
+		cfg.AdminToken = tempCfg.AdminToken
+	}
+	if cfg.AdminUser == defaultAdminUser && tempCfg.AdminUser != defaultAdminUser {
+		cfg.AdminUser = tempCfg.AdminUser
+	}
+	if cfg.AdminPassword == defaultAdminPassword && tempCfg.AdminPassword != defaultAdminPassword {
+		cfg.AdminPassword = tempCfg.AdminPassword
+	}
+	if !cfg.RecordRequests && tempCfg.RecordRequests {
+		cfg.RecordRequests = tempCfg.RecordRequests
+	}
+	if cfg.RecordBuffer == defaultRecordBuffer && tempCfg.RecordBuffer != 0 {
+		cfg.RecordBuffer = tempCfg.RecordBuffer
+	}
+	if cfg.MinAgentVersion == defaultMinAgentVersion && tempCfg.MinAgentVersion != defaultMinAgentVersion {
+		cfg.MinAgentVersion = tempCfg.MinAgentVersion
+	}
+	if cfg.FaultDelayMs == defaultFaultDelayMs && tempCfg.FaultDelayMs != defaultFaultDelayMs {
+		cfg.FaultDelayMs = tempCfg.FaultDelayMs
+	}
+	if cfg.FaultErrorRate == defaultFaultErrorRate && tempCfg.FaultErrorRate != defaultFaultErrorRate {
+		cfg.FaultErrorRate = tempCfg.FaultErrorRate
+	}
+	if cfg.TraceEndpoint == defaultTraceEndpoint && tempCfg.TraceEndpoint != defaultTraceEndpoint {
+		cfg.TraceEndpoint = tempCfg.TraceEndpoint
+	}
+	if cfg.TraceRatio == defaultTraceRatio && tempCfg.TraceRatio != 0 {
+		cfg.TraceRatio = tempCfg.TraceRatio
+	}
+	if cfg.AuditDSN == defaultAuditDSN && tempCfg.AuditDSN != defaultAuditDSN {
+		cfg.AuditDSN = tempCfg.AuditDSN
+	}
+	if cfg.BackupKey == defaultBackupKey && tempCfg.BackupKey != defaultBackupKey {
+		cfg.BackupKey = tempCfg.BackupKey
+	}
+	if cfg.BackupRecipient == defaultBackupRecipient && tempCfg.BackupRecipient != defaultBackupRecipient {
+		cfg.BackupRecipient = tempCfg.BackupRecipient
+	}
+	if cfg.BackupDir == defaultBackupDir && tempCfg.BackupDir != defaultBackupDir {
+		cfg.BackupDir = tempCfg.BackupDir
+	}
+	if cfg.TrustedSubnet == defaultTrustedSubnet && tempCfg.TrustedSubnet != defaultTrustedSubnet {
+		cfg.TrustedSubnet = tempCfg.TrustedSubnet
+	}
+	if cfg.ShutdownReport == defaultShutdownReport && tempCfg.ShutdownReport != defaultShutdownReport {
+		cfg.ShutdownReport = tempCfg.ShutdownReport
+	}
+	if cfg.ClientRate == defaultClientRate && tempCfg.ClientRate != defaultClientRate {
+		cfg.ClientRate = tempCfg.ClientRate
+	}
+	if cfg.ClientBurst == defaultClientBurst && tempCfg.ClientBurst != 0 {
+		cfg.ClientBurst = tempCfg.ClientBurst
+	}
+	if cfg.ClientRateBy == defaultClientRateBy && tempCfg.ClientRateBy != "" {
+		cfg.ClientRateBy = tempCfg.ClientRateBy
+	}
+	if !cfg.RejectFiltered && tempCfg.RejectFiltered {
+		cfg.RejectFiltered = tempCfg.RejectFiltered
+	}
+	if cfg.FsyncInterval == defaultFsyncInterval && tempCfg.FsyncInterval != 0 {
+		cfg.FsyncInterval = tempCfg.FsyncInterval
+	}
 	if cfg.Restore && !tempCfg.Restore {
 		cfg.Restore = tempCfg.Restore
 	}
+	if cfg.AutoMigrate == defaultAutoMigrate && tempCfg.AutoMigrate != defaultAutoMigrate {
+		cfg.AutoMigrate = tempCfg.AutoMigrate
+	}
+	if !cfg.LazyConnect && tempCfg.LazyConnect {
+		cfg.LazyConnect = tempCfg.LazyConnect
+	}
 	if !cfg.PprofFlag && tempCfg.PprofFlag {
 		cfg.PprofFlag = tempCfg.PprofFlag
 	}
@@ -125,7 +1035,12 @@ func mergeConfigFile(cfg *Config) error {
 // retaining default values if flags are not provided.
 // This function updates the provided Config pointer with flag values.
 func parseFlagsOrSetDefault(cfg *Config) {
-	flag.StringVar(&cfg.ServerAddress, "a", cfg.ServerAddress, "Address of the server")
+	flag.StringVar(
+		&cfg.ServerAddress,
+		"a",
+		cfg.ServerAddress,
+		"Address of the server, e.g. \"localhost:8080\" or \"[::]:8080\" for IPv4 and IPv6",
+	)
 	flag.IntVar(
 		&cfg.StoreInterval,
 		"i",
@@ -135,9 +1050,348 @@ func parseFlagsOrSetDefault(cfg *Config) {
 	flag.StringVar(&cfg.FileStoragePath, "f", cfg.FileStoragePath, "File storage path")
 	flag.BoolVar(&cfg.Restore, "r", cfg.Restore, "Indicates whether restore is needed")
 	flag.StringVar(&cfg.DatabaseDSN, "d", cfg.DatabaseDSN, "Database DSN")
+	flag.StringVar(
+		&cfg.StorageDSN,
+		"storage-dsn",
+		cfg.StorageDSN,
+		"Storage backend: memory://, file:///path/to/dir or postgres://...; replaces -d and -f",
+	)
+	flag.StringVar(&cfg.ReplicaDSN, "replica-dsn", cfg.ReplicaDSN, "Read-only replica DSN used for reads")
+	flag.IntVar(
+		&cfg.DBMaxOpenConns,
+		"db-max-open-conns",
+		cfg.DBMaxOpenConns,
+		"Max open connections of each database pool, if = 0 unlimited",
+	)
+	flag.IntVar(
+		&cfg.DBMaxIdleConns,
+		"db-max-idle-conns",
+		cfg.DBMaxIdleConns,
+		"Idle connections kept by each database pool",
+	)
+	flag.IntVar(
+		&cfg.DBConnLifetime,
+		"db-conn-lifetime",
+		cfg.DBConnLifetime,
+		"Time in sec a database connection is reused before it is closed, if = 0 forever",
+	)
+	flag.IntVar(
+		&cfg.DBStmtCache,
+		"db-statement-cache",
+		cfg.DBStmtCache,
+		"Statements cached per database connection (0 disables the cache)",
+	)
+	flag.StringVar(
+		&cfg.RetentionTypes,
+		"retention-type-ttl",
+		cfg.RetentionTypes,
+		"Comma-separated type=seconds time-to-live of metrics since their last update, e.g. \"gauge=86400\"",
+	)
+	flag.StringVar(
+		&cfg.RetentionNames,
+		"retention-name-ttl",
+		cfg.RetentionNames,
+		"Comma-separated pattern=seconds time-to-live of matching metrics, overriding the type, e.g. \"job_*=300\"",
+	)
+	flag.StringVar(
+		&cfg.ReportSchedule,
+		"report-schedule",
+		cfg.ReportSchedule,
+		"Cron-like schedule of the report emails, e.g. \"0 8 * * 1-5\" or \"@daily\"; empty sends no reports",
+	)
+	flag.StringVar(&cfg.ReportSMTP, "report-smtp", cfg.ReportSMTP, "host:port of the SMTP server sending reports")
+	flag.StringVar(&cfg.ReportUser, "report-smtp-user", cfg.ReportUser, "User authenticating to the SMTP server")
+	flag.StringVar(
+		&cfg.ReportPassword,
+		"report-smtp-password",
+		cfg.ReportPassword,
+		"Password authenticating to the SMTP server",
+	)
+	flag.StringVar(&cfg.ReportFrom, "report-from", cfg.ReportFrom, "Sender address of the report emails")
+	flag.StringVar(&cfg.ReportTo, "report-to", cfg.ReportTo, "Comma-separated recipient addresses of the report emails")
+	flag.IntVar(
+		&cfg.RetentionSweep,
+		"retention-sweep-interval",
+		cfg.RetentionSweep,
+		"Interval in sec between deletions of expired metrics (0 disables expiry)",
+	)
+	flag.StringVar(&cfg.AlertSlack, "alert-slack-webhook", cfg.AlertSlack, "Slack incoming webhook URL notified of alerts")
+	flag.StringVar(&cfg.AlertTgToken, "alert-telegram-token", cfg.AlertTgToken, "Telegram bot token notifying of alerts")
+	flag.StringVar(&cfg.AlertTgChat, "alert-telegram-chat", cfg.AlertTgChat, "Telegram chat the bot notifies of alerts")
+	flag.StringVar(
+		&cfg.AlertTemplate,
+		"alert-template",
+		cfg.AlertTemplate,
+		"Path to the text/template file of the alert notifications",
+	)
+	flag.IntVar(&cfg.AlertInterval, "alert-interval", cfg.AlertInterval, "Interval in sec between evaluations of alerts")
+	flag.IntVar(
+		&cfg.AlertRateLimit,
+		"alert-rate-limit",
+		cfg.AlertRateLimit,
+		"Max alert notifications per minute sent by each notifier",
+	)
 	flag.StringVar(&cfg.SigningKey, "k", cfg.SigningKey, "Signing key for checking request signatures.")
 	flag.BoolVar(&cfg.PprofFlag, "pf", cfg.PprofFlag, "Enable or disable profiling with pprof")
 	flag.StringVar(&cfg.CryptoKey, "crypto-key", cfg.CryptoKey, "Path to private key file.")
 	flag.StringVar(&cfg.ConfigPath, "c", cfg.ConfigPath, "Path to config file.")
+	flag.StringVar(&cfg.TrustedSubnet, "t", cfg.TrustedSubnet, "CIDR of the clients allowed to send requests")
+	flag.StringVar(
+		&cfg.ShutdownReport,
+		"shutdown-report",
+		cfg.ShutdownReport,
+		"Path of the JSON file the shutdown report is written to",
+	)
+	flag.IntVar(
+		&cfg.TombstoneTTL,
+		"tombstone-ttl",
+		cfg.TombstoneTTL,
+		"Time in sec during which a deleted metric can be restored",
+	)
+	flag.IntVar(
+		&cfg.MetricRate,
+		"metric-rate-limit",
+		cfg.MetricRate,
+		"Max updates per second accepted for a single metric, if = 0 unlimited",
+	)
+	flag.IntVar(&cfg.MaxSeries, "cardinality-limit", cfg.MaxSeries, "Max number of distinct metrics, if = 0 unlimited")
+	flag.StringVar(
+		&cfg.PrefixLimits,
+		"cardinality-prefix-limits",
+		cfg.PrefixLimits,
+		"Max number of distinct metrics per name prefix, e.g. \"Random=10,Custom=100\"",
+	)
+	flag.IntVar(&cfg.StreamBuffer, "stream-buffer", cfg.StreamBuffer, "Updates buffered per live stream subscriber")
+	flag.StringVar(
+		&cfg.StreamPolicy,
+		"stream-drop-policy",
+		cfg.StreamPolicy,
+		"Policy for slow live stream subscribers: drop-oldest, drop-newest or disconnect",
+	)
+	flag.StringVar(&cfg.NextSigningKey, "next-key", cfg.NextSigningKey, "Signing key that replaces -k after rotation.")
+	flag.StringVar(
+		&cfg.NextCryptoKey,
+		"next-crypto-key",
+		cfg.NextCryptoKey,
+		"Path to private key file that replaces -crypto-key after rotation.",
+	)
+	flag.IntVar(
+		&cfg.RotationGrace,
+		"key-rotation-grace",
+		cfg.RotationGrace,
+		"Time in sec during which both current and next keys are accepted",
+	)
+	flag.IntVar(
+		&cfg.MaxClockSkew,
+		"max-clock-skew",
+		cfg.MaxClockSkew,
+		"Max agent clock skew in sec before updates are rejected (0 only reports skew)",
+	)
+	flag.IntVar(
+		&cfg.SampleRetention,
+		"history-retention",
+		cfg.SampleRetention,
+		"Time in sec counter samples are kept for /api/rate (0 disables the history)",
+	)
+	flag.IntVar(
+		&cfg.WriteBufferMs,
+		"write-buffer-interval-ms",
+		cfg.WriteBufferMs,
+		"Interval in ms between flushes of buffered metric updates (0 disables buffering)",
+	)
+	flag.IntVar(
+		&cfg.WriteBufferSize,
+		"write-buffer-size",
+		cfg.WriteBufferSize,
+		"Number of buffered metrics that forces an immediate flush",
+	)
+	flag.IntVar(
+		&cfg.MaxFileSize,
+		"file-storage-max-size",
+		cfg.MaxFileSize,
+		"Max write-ahead log size in bytes before it is compacted into the storage file (0 uses 4 MiB)",
+	)
+	flag.StringVar(
+		&cfg.FsyncPolicy,
+		"fsync-policy",
+		cfg.FsyncPolicy,
+		"When the storage file is synced to disk: always, interval or never",
+	)
+	flag.IntVar(
+		&cfg.FsyncInterval,
+		"fsync-interval",
+		cfg.FsyncInterval,
+		"Interval in sec between syncs for -fsync-policy=interval",
+	)
+	flag.BoolVar(
+		&cfg.AutoMigrate,
+		"auto-migrate",
+		cfg.AutoMigrate,
+		"Apply pending database migrations on startup; if disabled, pending migrations stop the server",
+	)
+	flag.BoolVar(
+		&cfg.LazyConnect,
+		"lazy-connect",
+		cfg.LazyConnect,
+		"Start even if the database is unreachable and keep connecting in the background",
+	)
+	flag.StringVar(
+		&cfg.MetricAllow,
+		"metric-allow",
+		cfg.MetricAllow,
+		"Comma-separated glob patterns of accepted metric names; empty accepts all names",
+	)
+	flag.StringVar(&cfg.MetricDeny, "metric-deny", cfg.MetricDeny, "Comma-separated glob patterns of refused metric names")
+	flag.BoolVar(
+		&cfg.RejectFiltered,
+		"reject-filtered",
+		cfg.RejectFiltered,
+		"Reject updates containing refused metric names with 403 instead of silently dropping them",
+	)
+	flag.StringVar(
+		&cfg.AdminToken,
+		"admin-token",
+		cfg.AdminToken,
+		"Bearer token required by /admin and /debug routes, distinct from the agent signing key",
+	)
+	flag.StringVar(&cfg.AdminUser, "admin-user", cfg.AdminUser, "Basic auth user for /admin and /debug routes")
+	flag.StringVar(
+		&cfg.AdminPassword,
+		"admin-password",
+		cfg.AdminPassword,
+		"Basic auth password for /admin and /debug routes",
+	)
+	flag.StringVar(
+		&cfg.FederationName,
+		"federation-name",
+		cfg.FederationName,
+		"Source name labeling the metrics of this server in /api/metrics",
+	)
+	flag.StringVar(
+		&cfg.FederationPeers,
+		"federation-peers",
+		cfg.FederationPeers,
+		"Comma-separated name=url peers whose metrics /api/metrics aggregates, e.g. \"eu=http://eu:8080\"",
+	)
+	flag.StringVar(
+		&cfg.Provisioning,
+		"provisioning-file",
+		cfg.Provisioning,
+		"Path to the YAML file provisioning dashboards, alert rules and allowed metrics",
+	)
+	flag.StringVar(
+		&cfg.GaugeSmoothing,
+		"gauge-smoothing",
+		cfg.GaugeSmoothing,
+		"Comma-separated pattern=alpha gauges stored with an EWMA, e.g. \"RandomValue=0.2,CPU*=0.5\"",
+	)
+	flag.StringVar(
+		&cfg.APIQuotas,
+		"api-quotas",
+		cfg.APIQuotas,
+		"Quotas per X-API-Key, e.g. \"key1:metrics=500,updates=6000,batch=1000;*:updates=600\"",
+	)
+	flag.StringVar(
+		&cfg.AdminAddress,
+		"admin-address",
+		cfg.AdminAddress,
+		"Separate address for the /admin and /debug routes, e.g. \"localhost:8081\"; empty serves them on -a",
+	)
+	flag.StringVar(
+		&cfg.Registry,
+		"registry",
+		cfg.Registry,
+		"Service registry to register in, e.g. \"consul://localhost:8500/metricol\" or \"etcd://localhost:2379\"",
+	)
+	flag.StringVar(
+		&cfg.Advertise,
+		"advertise-address",
+		cfg.Advertise,
+		"Address agents reach the server at, registered in -registry; empty derives it from -a and the hostname",
+	)
+	flag.StringVar(
+		&cfg.MinAgentVersion,
+		"min-agent-version",
+		cfg.MinAgentVersion,
+		"Oldest agent version accepted on update routes; older agents get 426 Upgrade Required",
+	)
+	flag.BoolVar(
+		&cfg.RecordRequests,
+		"debug-record",
+		cfg.RecordRequests,
+		"Record requests sent with the X-Debug-Record header, viewable at /debug/requests",
+	)
+	flag.IntVar(&cfg.RecordBuffer, "debug-record-buffer", cfg.RecordBuffer, "Number of recorded requests kept")
+	flag.IntVar(
+		&cfg.FaultDelayMs,
+		"fault-delay-ms",
+		cfg.FaultDelayMs,
+		"Latency in ms injected into every storage operation, for tests and staging only",
+	)
+	flag.Float64Var(
+		&cfg.FaultErrorRate,
+		"fault-error-rate",
+		cfg.FaultErrorRate,
+		"Share of storage operations failing with an injected error (0 to 1), for tests and staging only",
+	)
+	flag.StringVar(
+		&cfg.TraceEndpoint,
+		"otlp-endpoint",
+		cfg.TraceEndpoint,
+		"OTLP/HTTP collector URL traces are exported to, e.g. http://localhost:4318; empty disables exporting",
+	)
+	flag.Float64Var(
+		&cfg.TraceRatio,
+		"trace-sample-ratio",
+		cfg.TraceRatio,
+		"Share of new traces sampled (0 to 1); traces started by agents follow the agent's decision",
+	)
+	flag.StringVar(
+		&cfg.AuditDSN,
+		"audit-log-dsn",
+		cfg.AuditDSN,
+		"Audit log of metric mutations: a JSON Lines file path (file:///path or a plain path) or a PostgreSQL DSN; "+
+			"empty disables auditing",
+	)
+	flag.StringVar(
+		&cfg.BackupKey,
+		"backup-signing-key",
+		cfg.BackupKey,
+		"Key signing /admin/snapshot backups and verifying /admin/import ones; empty exports and accepts unsigned backups",
+	)
+	flag.StringVar(
+		&cfg.BackupRecipient,
+		"backup-recipient",
+		cfg.BackupRecipient,
+		"Path to the RSA or X25519 public key of the server /admin/snapshot backups are encrypted for; "+
+			"empty exports plain backups",
+	)
+	flag.StringVar(
+		&cfg.BackupDir,
+		"backup-dir",
+		cfg.BackupDir,
+		"Directory keeping /admin/snapshot backups for /api/diff to compare; empty keeps none",
+	)
+	flag.Float64Var(
+		&cfg.ClientRate,
+		"client-rate-limit",
+		cfg.ClientRate,
+		"Update requests per second allowed to every client on /update and /updates, 0 disables the limit",
+	)
+	flag.IntVar(
+		&cfg.ClientBurst,
+		"client-rate-burst",
+		cfg.ClientBurst,
+		"Update requests a client may send at once above -client-rate-limit",
+	)
+	flag.StringVar(
+		&cfg.ClientRateBy,
+		"client-rate-limit-by",
+		cfg.ClientRateBy,
+		"What tells clients apart for -client-rate-limit: ip or api-key",
+	)
+	flag.BoolVar(&cfg.MigrateUp, "migrate-up", cfg.MigrateUp, "Apply pending database migrations and exit")
+	flag.BoolVar(&cfg.MigrateDown, "migrate-down", cfg.MigrateDown, "Roll back the last database migration and exit")
+	flag.BoolVar(&cfg.MigrateStatus, "migrate-status", cfg.MigrateStatus, "Print the database migration status and exit")
 	flag.Parse()
 }
@@ -3,7 +3,13 @@ package config
 import (
 	"flag"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/internal/quota"
+	"github.com/gdyunin/metricol.git/pkg/configaudit"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -25,36 +31,219 @@ func TestParseConfig(t *testing.T) {
 				ServerAddress:   defaultServerAddress,
 				FileStoragePath: defaultFileStoragePath,
 				DatabaseDSN:     defaultDatabaseDSN,
+				StorageDSN:      defaultStorageDSN,
+				ReplicaDSN:      defaultReplicaDSN,
 				SigningKey:      defaultSigningKey,
 				StoreInterval:   defaultStoreInterval,
 				Restore:         defaultRestoreFlag,
+				AutoMigrate:     defaultAutoMigrate,
+				LazyConnect:     defaultLazyConnect,
 				PprofFlag:       defaultPprofFlag,
 				CryptoKey:       defaultCryptoKey,
+				TombstoneTTL:    defaultTombstoneTTL,
+				MetricRate:      defaultMetricRate,
+				MaxSeries:       defaultMaxSeries,
+				PrefixLimits:    defaultPrefixLimits,
+				StreamBuffer:    defaultStreamBuffer,
+				StreamPolicy:    defaultStreamPolicy,
+				NextSigningKey:  defaultNextSigningKey,
+				NextCryptoKey:   defaultNextCryptoKey,
+				RotationGrace:   defaultRotationGrace,
+				MaxClockSkew:    defaultMaxClockSkew,
+				SampleRetention: defaultSampleRetention,
+				WriteBufferMs:   defaultWriteBufferMs,
+				WriteBufferSize: defaultWriteBufferSize,
+				MaxFileSize:     defaultMaxFileSize,
+				FsyncPolicy:     defaultFsyncPolicy,
+				FsyncInterval:   defaultFsyncInterval,
+				MetricAllow:     defaultMetricAllow,
+				MetricDeny:      defaultMetricDeny,
+				RejectFiltered:  defaultRejectFiltered,
+				AdminToken:      defaultAdminToken,
+				AdminUser:       defaultAdminUser,
+				AdminPassword:   defaultAdminPassword,
+				RecordRequests:  defaultRecordRequests,
+				RecordBuffer:    defaultRecordBuffer,
+				FaultDelayMs:    defaultFaultDelayMs,
+				FaultErrorRate:  defaultFaultErrorRate,
+				TraceEndpoint:   defaultTraceEndpoint,
+				TraceRatio:      defaultTraceRatio,
+				MinAgentVersion: defaultMinAgentVersion,
+				FederationName:  defaultFederationName,
+				FederationPeers: defaultFederationPeers,
+				Provisioning:    defaultProvisioning,
+				GaugeSmoothing:  defaultGaugeSmoothing,
+				APIQuotas:       defaultAPIQuotas,
+				AdminAddress:    defaultAdminAddress,
+				Registry:        defaultRegistry,
+				Advertise:       defaultAdvertise,
+				TrustedSubnet:   defaultTrustedSubnet,
+				ShutdownReport:  defaultShutdownReport,
+				ClientRate:      defaultClientRate,
+				ClientBurst:     defaultClientBurst,
+				ClientRateBy:    defaultClientRateBy,
+				DBMaxOpenConns:  defaultDBMaxOpen,
+				DBMaxIdleConns:  defaultDBMaxIdle,
+				DBConnLifetime:  defaultDBLifetime,
+				DBStmtCache:     defaultDBStmtCache,
+				RetentionTypes:  defaultRetentionTypes,
+				RetentionNames:  defaultRetentionNames,
+				RetentionSweep:  defaultRetentionSweep,
+				AlertInterval:   defaultAlertInterval,
+				AlertRateLimit:  defaultAlertRateLimit,
 			},
 			expectError: false,
 		},
 		{
 			name: "Environment variables",
 			envVars: map[string]string{
-				"ADDRESS":           "envserver:9000",
-				"FILE_STORAGE_PATH": "envfilestoragepath",
-				"DATABASE_DSN":      "envdatabasedsn",
-				"KEY":               "envkey",
-				"STORE_INTERVAL":    "300",
-				"RESTORE":           "true",
-				"PPROF_SERVER_FLAG": "true",
-				"CRYPTO_KEY":        "env_example/path",
+				"ADDRESS":                  "envserver:9000",
+				"FILE_STORAGE_PATH":        "envfilestoragepath",
+				"DATABASE_DSN":             "envdatabasedsn",
+				"DATABASE_REPLICA_DSN":     "envreplicadsn",
+				"KEY":                      "envkey",
+				"STORE_INTERVAL":           "300",
+				"RESTORE":                  "true",
+				"PPROF_SERVER_FLAG":        "true",
+				"CRYPTO_KEY":               "env_example/path",
+				"METRIC_RATE_LIMIT":        "5",
+				"NEXT_KEY":                 "envnextkey",
+				"KEY_ROTATION_GRACE":       "60",
+				"MAX_CLOCK_SKEW":           "30",
+				"HISTORY_RETENTION":        "600",
+				"WRITE_BUFFER_INTERVAL_MS": "250",
+				"WRITE_BUFFER_SIZE":        "500",
+				"FILE_STORAGE_MAX_SIZE":    "4096",
+				"FSYNC_POLICY":             "always",
+				"FSYNC_INTERVAL":           "5",
+				"AUTO_MIGRATE":             "false",
+				"DATABASE_LAZY_CONNECT":    "true",
+				"METRIC_ALLOW":             "Heap*,Alloc",
+				"METRIC_DENY":              "HeapReleased",
+				"REJECT_FILTERED_METRICS":  "true",
+				"ADMIN_TOKEN":              "envadmintoken",
+				"ADMIN_USER":               "envadmin",
+				"ADMIN_PASSWORD":           "envadminpassword",
+				"DEBUG_RECORD_REQUESTS":    "true",
+				"DEBUG_RECORD_BUFFER":      "20",
+				"FAULT_DELAY_MS":           "15",
+				"FAULT_ERROR_RATE":         "0.25",
+				"OTLP_ENDPOINT":            "http://otel:4318",
+				"TRACE_SAMPLE_RATIO":       "0.5",
+				"AUDIT_LOG_DSN":            "/var/log/metricol/audit.jsonl",
+				"BACKUP_SIGNING_KEY":       "backup-secret",
+				"BACKUP_RECIPIENT":         "/etc/metricol/backup.pub",
+				"BACKUP_DIR":               "/var/lib/metricol/backups",
+				"REPORT_SCHEDULE":          "@daily",
+				"REPORT_SMTP_ADDRESS":      "smtp.example.com:587",
+				"REPORT_SMTP_USER":         "reports",
+				"REPORT_SMTP_PASSWORD":     "smtp-secret",
+				"REPORT_FROM":              "metricol@example.com",
+				"REPORT_TO":                "ops@example.com",
+				"FEDERATION_NAME":          "eu",
+				"FEDERATION_PEERS":         "us=http://us:8080",
+				"PROVISIONING_FILE":        "/etc/metricol/provisioning.yaml",
+				"GAUGE_SMOOTHING":          "RandomValue=0.2",
+				"API_QUOTAS":               "*:batch=100",
+				"ADMIN_ADDRESS":            "localhost:8081",
+				"REGISTRY":                 "consul://consul:8500/metricol",
+				"ADVERTISE_ADDRESS":        "10.0.0.1:8080",
+				"CLIENT_RATE_LIMIT":        "2.5",
+				"SHUTDOWN_REPORT_FILE":     "/var/log/metricol/shutdown.json",
+				"TRUSTED_SUBNET":           "10.0.0.0/8",
+				"CLIENT_RATE_BURST":        "20",
+				"CLIENT_RATE_LIMIT_BY":     "api-key",
+				"DATABASE_MAX_OPEN_CONNS":  "20",
+				"DATABASE_MAX_IDLE_CONNS":  "10",
+				"DATABASE_CONN_LIFETIME":   "1800",
+				"DATABASE_STATEMENT_CACHE": "0",
+				"RETENTION_TYPE_TTL":       "gauge=86400",
+				"RETENTION_NAME_TTL":       "job_*=300",
+				"RETENTION_SWEEP_INTERVAL": "30",
+				"ALERT_SLACK_WEBHOOK":      "https://hooks.slack.com/services/T/B/X",
+				"ALERT_TELEGRAM_TOKEN":     "123:telegram-token",
+				"ALERT_TELEGRAM_CHAT":      "-100500",
+				"ALERT_INTERVAL":           "60",
+				"ALERT_RATE_LIMIT":         "5",
+				"MIN_AGENT_VERSION":        "1.2.0",
 			},
 			args: []string{},
 			expected: Config{
 				ServerAddress:   "envserver:9000",
 				FileStoragePath: "envfilestoragepath",
 				DatabaseDSN:     "envdatabasedsn",
+				ReplicaDSN:      "envreplicadsn",
 				SigningKey:      "envkey",
 				StoreInterval:   300,
 				Restore:         true,
 				PprofFlag:       true,
 				CryptoKey:       "env_example/path",
+				TombstoneTTL:    defaultTombstoneTTL,
+				MetricRate:      5,
+				MaxSeries:       defaultMaxSeries,
+				PrefixLimits:    defaultPrefixLimits,
+				StreamBuffer:    defaultStreamBuffer,
+				StreamPolicy:    defaultStreamPolicy,
+				NextSigningKey:  "envnextkey",
+				NextCryptoKey:   defaultNextCryptoKey,
+				RotationGrace:   60,
+				MaxClockSkew:    30,
+				SampleRetention: 600,
+				WriteBufferMs:   250,
+				WriteBufferSize: 500,
+				MaxFileSize:     4096,
+				FsyncPolicy:     "always",
+				FsyncInterval:   5,
+				AutoMigrate:     false,
+				LazyConnect:     true,
+				MetricAllow:     "Heap*,Alloc",
+				MetricDeny:      "HeapReleased",
+				RejectFiltered:  true,
+				AdminToken:      "envadmintoken",
+				AdminUser:       "envadmin",
+				AdminPassword:   "envadminpassword",
+				RecordRequests:  true,
+				RecordBuffer:    20,
+				FaultDelayMs:    15,
+				FaultErrorRate:  0.25,
+				TraceEndpoint:   "http://otel:4318",
+				TraceRatio:      0.5,
+				AuditDSN:        "/var/log/metricol/audit.jsonl",
+				BackupKey:       "backup-secret",
+				BackupRecipient: "/etc/metricol/backup.pub",
+				BackupDir:       "/var/lib/metricol/backups",
+				ReportSchedule:  "@daily",
+				ReportSMTP:      "smtp.example.com:587",
+				ReportUser:      "reports",
+				ReportPassword:  "smtp-secret",
+				ReportFrom:      "metricol@example.com",
+				ReportTo:        "ops@example.com",
+				MinAgentVersion: "1.2.0",
+				FederationName:  "eu",
+				FederationPeers: "us=http://us:8080",
+				Provisioning:    "/etc/metricol/provisioning.yaml",
+				GaugeSmoothing:  "RandomValue=0.2",
+				APIQuotas:       "*:batch=100",
+				AdminAddress:    "localhost:8081",
+				Registry:        "consul://consul:8500/metricol",
+				Advertise:       "10.0.0.1:8080",
+				TrustedSubnet:   "10.0.0.0/8",
+				ShutdownReport:  "/var/log/metricol/shutdown.json",
+				ClientRate:      2.5,
+				ClientBurst:     20,
+				ClientRateBy:    RateLimitByAPIKey,
+				DBMaxOpenConns:  20,
+				DBMaxIdleConns:  10,
+				DBConnLifetime:  1800,
+				DBStmtCache:     0,
+				RetentionTypes:  "gauge=86400",
+				RetentionNames:  "job_*=300",
+				RetentionSweep:  30,
+				AlertSlack:      "https://hooks.slack.com/services/T/B/X",
+				AlertTgToken:    "123:telegram-token",
+				AlertTgChat:     "-100500",
+				AlertInterval:   60,
+				AlertRateLimit:  5,
 			},
 			expectError: false,
 		},
@@ -69,16 +258,77 @@ func TestParseConfig(t *testing.T) {
 				"-i", "500",
 				"-r", "-pf",
 				"-crypto-key", "cmd_example/path",
+				"-metric-rate-limit", "10",
+				"-cardinality-limit", "100",
+				"-cardinality-prefix-limits", "Random=10",
+				"-stream-drop-policy", "disconnect",
+				"-next-crypto-key", "cmd_example/next",
+				"-migrate-status",
 			},
 			expected: Config{
 				ServerAddress:   "flagserver:8000",
 				FileStoragePath: "flagfilestoragepath",
 				DatabaseDSN:     "flagdatabasedsn",
+				ReplicaDSN:      defaultReplicaDSN,
 				SigningKey:      "flagkey",
 				StoreInterval:   500,
 				Restore:         true,
+				AutoMigrate:     defaultAutoMigrate,
+				LazyConnect:     defaultLazyConnect,
 				PprofFlag:       true,
 				CryptoKey:       "cmd_example/path",
+				TombstoneTTL:    defaultTombstoneTTL,
+				MetricRate:      10,
+				MaxSeries:       100,
+				PrefixLimits:    "Random=10",
+				StreamBuffer:    defaultStreamBuffer,
+				StreamPolicy:    "disconnect",
+				NextSigningKey:  defaultNextSigningKey,
+				NextCryptoKey:   "cmd_example/next",
+				RotationGrace:   defaultRotationGrace,
+				MaxClockSkew:    defaultMaxClockSkew,
+				SampleRetention: defaultSampleRetention,
+				WriteBufferMs:   defaultWriteBufferMs,
+				WriteBufferSize: defaultWriteBufferSize,
+				MaxFileSize:     defaultMaxFileSize,
+				FsyncPolicy:     defaultFsyncPolicy,
+				FsyncInterval:   defaultFsyncInterval,
+				MetricAllow:     defaultMetricAllow,
+				MetricDeny:      defaultMetricDeny,
+				RejectFiltered:  defaultRejectFiltered,
+				AdminToken:      defaultAdminToken,
+				AdminUser:       defaultAdminUser,
+				AdminPassword:   defaultAdminPassword,
+				RecordRequests:  defaultRecordRequests,
+				RecordBuffer:    defaultRecordBuffer,
+				FaultDelayMs:    defaultFaultDelayMs,
+				FaultErrorRate:  defaultFaultErrorRate,
+				TraceEndpoint:   defaultTraceEndpoint,
+				TraceRatio:      defaultTraceRatio,
+				MinAgentVersion: defaultMinAgentVersion,
+				FederationName:  defaultFederationName,
+				FederationPeers: defaultFederationPeers,
+				Provisioning:    defaultProvisioning,
+				GaugeSmoothing:  defaultGaugeSmoothing,
+				APIQuotas:       defaultAPIQuotas,
+				AdminAddress:    defaultAdminAddress,
+				Registry:        defaultRegistry,
+				Advertise:       defaultAdvertise,
+				TrustedSubnet:   defaultTrustedSubnet,
+				ShutdownReport:  defaultShutdownReport,
+				ClientRate:      defaultClientRate,
+				ClientBurst:     defaultClientBurst,
+				ClientRateBy:    defaultClientRateBy,
+				DBMaxOpenConns:  defaultDBMaxOpen,
+				DBMaxIdleConns:  defaultDBMaxIdle,
+				DBConnLifetime:  defaultDBLifetime,
+				DBStmtCache:     defaultDBStmtCache,
+				RetentionTypes:  defaultRetentionTypes,
+				RetentionNames:  defaultRetentionNames,
+				RetentionSweep:  defaultRetentionSweep,
+				AlertInterval:   defaultAlertInterval,
+				AlertRateLimit:  defaultAlertRateLimit,
+				MigrateStatus:   true,
 			},
 			expectError: false,
 		},
@@ -108,14 +358,204 @@ func TestParseConfig(t *testing.T) {
 				ServerAddress:   "envserver:9000",
 				FileStoragePath: "envfilestoragepath",
 				DatabaseDSN:     "envdatabasedsn",
+				ReplicaDSN:      defaultReplicaDSN,
 				SigningKey:      "envkey",
 				StoreInterval:   300,
 				Restore:         true,
+				AutoMigrate:     defaultAutoMigrate,
+				LazyConnect:     defaultLazyConnect,
 				PprofFlag:       true,
 				CryptoKey:       "env_example/path",
+				TombstoneTTL:    defaultTombstoneTTL,
+				MetricRate:      defaultMetricRate,
+				MaxSeries:       defaultMaxSeries,
+				PrefixLimits:    defaultPrefixLimits,
+				StreamBuffer:    defaultStreamBuffer,
+				StreamPolicy:    defaultStreamPolicy,
+				NextSigningKey:  defaultNextSigningKey,
+				NextCryptoKey:   defaultNextCryptoKey,
+				RotationGrace:   defaultRotationGrace,
+				MaxClockSkew:    defaultMaxClockSkew,
+				SampleRetention: defaultSampleRetention,
+				WriteBufferMs:   defaultWriteBufferMs,
+				WriteBufferSize: defaultWriteBufferSize,
+				MaxFileSize:     defaultMaxFileSize,
+				FsyncPolicy:     defaultFsyncPolicy,
+				FsyncInterval:   defaultFsyncInterval,
+				MetricAllow:     defaultMetricAllow,
+				MetricDeny:      defaultMetricDeny,
+				RejectFiltered:  defaultRejectFiltered,
+				AdminToken:      defaultAdminToken,
+				AdminUser:       defaultAdminUser,
+				AdminPassword:   defaultAdminPassword,
+				RecordRequests:  defaultRecordRequests,
+				RecordBuffer:    defaultRecordBuffer,
+				FaultDelayMs:    defaultFaultDelayMs,
+				FaultErrorRate:  defaultFaultErrorRate,
+				TraceEndpoint:   defaultTraceEndpoint,
+				TraceRatio:      defaultTraceRatio,
+				MinAgentVersion: defaultMinAgentVersion,
+				FederationName:  defaultFederationName,
+				FederationPeers: defaultFederationPeers,
+				Provisioning:    defaultProvisioning,
+				GaugeSmoothing:  defaultGaugeSmoothing,
+				APIQuotas:       defaultAPIQuotas,
+				AdminAddress:    defaultAdminAddress,
+				Registry:        defaultRegistry,
+				Advertise:       defaultAdvertise,
+				TrustedSubnet:   defaultTrustedSubnet,
+				ShutdownReport:  defaultShutdownReport,
+				ClientRate:      defaultClientRate,
+				ClientBurst:     defaultClientBurst,
+				ClientRateBy:    defaultClientRateBy,
+				DBMaxOpenConns:  defaultDBMaxOpen,
+				DBMaxIdleConns:  defaultDBMaxIdle,
+				DBConnLifetime:  defaultDBLifetime,
+				DBStmtCache:     defaultDBStmtCache,
+				RetentionTypes:  defaultRetentionTypes,
+				RetentionNames:  defaultRetentionNames,
+				RetentionSweep:  defaultRetentionSweep,
+				AlertInterval:   defaultAlertInterval,
+				AlertRateLimit:  defaultAlertRateLimit,
 			},
 			expectError: false,
 		},
+		{
+			name:        "Public admin listener without credentials",
+			envVars:     map[string]string{"ADMIN_ADDRESS": ":8081"},
+			expected:    Config{},
+			expectError: true,
+		},
+		{
+			name:        "Notifiers without alert rules",
+			envVars:     map[string]string{"ALERT_SLACK_WEBHOOK": "https://hooks.slack.com/services/T/B/X"},
+			expected:    Config{},
+			expectError: true,
+		},
+		{
+			name:        "Non-positive alert interval",
+			envVars:     map[string]string{"ALERT_INTERVAL": "0"},
+			expected:    Config{},
+			expectError: true,
+		},
+		{
+			name: "Invalid stream drop policy",
+			envVars: map[string]string{
+				"STREAM_DROP_POLICY": "block",
+			},
+			args:        []string{},
+			expected:    Config{},
+			expectError: true,
+		},
+		{
+			name:        "Admin user without password",
+			envVars:     map[string]string{"ADMIN_USER": "envadmin"},
+			args:        []string{},
+			expected:    Config{},
+			expectError: true,
+		},
+		{
+			name:        "Fault error rate above one",
+			envVars:     map[string]string{"FAULT_ERROR_RATE": "1.5"},
+			args:        []string{},
+			expected:    Config{},
+			expectError: true,
+		},
+		{
+			name:        "Trace sample ratio above one",
+			envVars:     map[string]string{"TRACE_SAMPLE_RATIO": "1.5"},
+			args:        []string{},
+			expected:    Config{},
+			expectError: true,
+		},
+		{
+			name:        "Invalid trusted subnet",
+			envVars:     map[string]string{"TRUSTED_SUBNET": "10.0.0.1"},
+			args:        []string{},
+			expectError: true,
+		},
+		{
+			name:        "Negative client rate limit",
+			envVars:     map[string]string{"CLIENT_RATE_LIMIT": "-1"},
+			args:        []string{},
+			expectError: true,
+		},
+		{
+			name:        "Unsupported client rate limit key",
+			envVars:     map[string]string{"CLIENT_RATE_LIMIT_BY": "user"},
+			args:        []string{},
+			expectError: true,
+		},
+		{
+			name:        "Invalid minimum agent version",
+			envVars:     map[string]string{"MIN_AGENT_VERSION": "latest"},
+			args:        []string{},
+			expected:    Config{},
+			expectError: true,
+		},
+		{
+			name:        "Unbracketed IPv6 server address",
+			envVars:     map[string]string{"ADDRESS": "::1:8080"},
+			args:        []string{},
+			expected:    Config{},
+			expectError: true,
+		},
+		{
+			name:        "Unsupported registry",
+			envVars:     map[string]string{"REGISTRY": "zookeeper://localhost:2181"},
+			args:        []string{},
+			expected:    Config{},
+			expectError: true,
+		},
+		{
+			name:        "Invalid admin address",
+			envVars:     map[string]string{"ADMIN_ADDRESS": "localhost"},
+			args:        []string{},
+			expected:    Config{},
+			expectError: true,
+		},
+		{
+			name:        "Invalid federation peers",
+			envVars:     map[string]string{"FEDERATION_PEERS": "us"},
+			args:        []string{},
+			expected:    Config{},
+			expectError: true,
+		},
+		{
+			name:        "Storage DSN with database DSN",
+			envVars:     map[string]string{"STORAGE_DSN": "memory://", "DATABASE_DSN": "envdatabasedsn"},
+			args:        []string{},
+			expected:    Config{},
+			expectError: true,
+		},
+		{
+			name:        "Invalid storage DSN",
+			envVars:     map[string]string{"STORAGE_DSN": "redis://localhost"},
+			args:        []string{},
+			expected:    Config{},
+			expectError: true,
+		},
+		{
+			name:        "Migration command with file storage DSN",
+			envVars:     map[string]string{"STORAGE_DSN": "file:///tmp/metrics"},
+			args:        []string{"-migrate-up"},
+			expected:    Config{},
+			expectError: true,
+		},
+		{
+			name:        "Several migration commands",
+			envVars:     map[string]string{"DATABASE_DSN": "envdatabasedsn"},
+			args:        []string{"-migrate-up", "-migrate-down"},
+			expected:    Config{},
+			expectError: true,
+		},
+		{
+			name:        "Migration command without database",
+			envVars:     map[string]string{},
+			args:        []string{"-migrate-status"},
+			expected:    Config{},
+			expectError: true,
+		},
 		{
 			name: "Invalid environment variable",
 			envVars: map[string]string{
@@ -155,3 +595,361 @@ func TestParseConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestCardinalityPrefixLimits(t *testing.T) {
+	tests := []struct {
+		expected    map[string]int
+		name        string
+		raw         string
+		expectError bool
+	}{
+		{name: "Empty", raw: "", expected: map[string]int{}},
+		{name: "Single", raw: "Random=10", expected: map[string]int{"Random": 10}},
+		{name: "Multiple", raw: "Random=10, Custom_=5", expected: map[string]int{"Random": 10, "Custom_": 5}},
+		{name: "Missing limit", raw: "Random", expectError: true},
+		{name: "Empty prefix", raw: "=10", expectError: true},
+		{name: "Non-positive limit", raw: "Random=0", expectError: true},
+		{name: "Non-numeric limit", raw: "Random=ten", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{PrefixLimits: tt.raw}
+			limits, err := cfg.CardinalityPrefixLimits()
+			if tt.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, limits)
+		})
+	}
+}
+
+func TestGaugeSmoothingAlphas(t *testing.T) {
+	tests := []struct {
+		expected    map[string]float64
+		name        string
+		raw         string
+		expectError bool
+	}{
+		{name: "Empty", raw: "", expected: map[string]float64{}},
+		{name: "Single", raw: "RandomValue=0.2", expected: map[string]float64{"RandomValue": 0.2}},
+		{name: "Multiple", raw: "RandomValue=0.2, CPU*=1", expected: map[string]float64{"RandomValue": 0.2, "CPU*": 1}},
+		{name: "Missing alpha", raw: "RandomValue", expectError: true},
+		{name: "Empty pattern", raw: "=0.2", expectError: true},
+		{name: "Malformed pattern", raw: "[=0.2", expectError: true},
+		{name: "Zero alpha", raw: "RandomValue=0", expectError: true},
+		{name: "Alpha above one", raw: "RandomValue=1.5", expectError: true},
+		{name: "Non-numeric alpha", raw: "RandomValue=high", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{GaugeSmoothing: tt.raw}
+			alphas, err := cfg.GaugeSmoothingAlphas()
+			if tt.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, alphas)
+		})
+	}
+}
+
+func TestRetentionPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		types       string
+		names       string
+		expectError bool
+	}{
+		{name: "Empty"},
+		{name: "Types and names", types: "gauge=86400, info=3600", names: "job_*=300"},
+		{name: "Unknown type", types: "histogram=60", expectError: true},
+		{name: "Missing seconds", types: "gauge", expectError: true},
+		{name: "Zero seconds", names: "job_*=0", expectError: true},
+		{name: "Non-numeric seconds", names: "job_*=1h", expectError: true},
+		{name: "Malformed pattern", names: "job_[=300", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{RetentionTypes: tt.types, RetentionNames: tt.names}
+			policy, err := cfg.RetentionPolicy()
+			if tt.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.types == "" && tt.names == "", policy.Empty())
+		})
+	}
+
+	cfg := Config{RetentionTypes: "gauge=86400", RetentionNames: "job_*=300"}
+	policy, err := cfg.RetentionPolicy()
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, policy.TTL(entity.MetricTypeGauge, "job_import"))
+	assert.Equal(t, 24*time.Hour, policy.TTL(entity.MetricTypeGauge, "Alloc"))
+}
+
+func TestReports(t *testing.T) {
+	tests := []struct {
+		cfg         Config
+		name        string
+		expectError bool
+	}{
+		{name: "Disabled"},
+		{
+			name: "Complete",
+			cfg: Config{
+				ReportSchedule: "0 8 * * 1-5",
+				ReportSMTP:     "smtp.example.com:25",
+				ReportFrom:     "metricol@example.com",
+				ReportTo:       "ops@example.com, dev@example.com",
+			},
+		},
+		{name: "Malformed schedule", cfg: Config{ReportSchedule: "0 8 * *"}, expectError: true},
+		{
+			name:        "Missing recipients",
+			cfg:         Config{ReportSchedule: "@daily", ReportSMTP: "smtp.example.com:25", ReportFrom: "a@example.com"},
+			expectError: true,
+		},
+		{name: "Missing SMTP server", cfg: Config{ReportSchedule: "@daily"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, mailer, err := tt.cfg.Reports()
+			if tt.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.cfg.ReportSchedule == "", schedule == nil)
+			assert.Equal(t, tt.cfg.ReportSchedule == "", mailer == nil)
+		})
+	}
+}
+
+func TestNotifiers(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         Config
+		expected    []string
+		expectError bool
+	}{
+		{name: "None"},
+		{name: "Slack", cfg: Config{AlertSlack: "https://hooks.slack.com/services/T/B/X"}, expected: []string{"slack"}},
+		{
+			name: "Slack and Telegram",
+			cfg: Config{
+				AlertSlack:   "https://hooks.slack.com/services/T/B/X",
+				AlertTgToken: "123:token",
+				AlertTgChat:  "@alerts",
+			},
+			expected: []string{"slack", "telegram"},
+		},
+		{name: "Malformed Slack webhook", cfg: Config{AlertSlack: "hooks.slack.com"}, expectError: true},
+		{name: "Telegram without chat", cfg: Config{AlertTgToken: "123:token"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notifiers, err := tt.cfg.Notifiers()
+			if tt.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			var names []string
+			for _, n := range notifiers {
+				names = append(names, n.Name())
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+}
+
+func TestNotificationTemplate(t *testing.T) {
+	cfg := Config{}
+	tmpl, err := cfg.NotificationTemplate()
+	require.NoError(t, err)
+	assert.Nil(t, tmpl)
+
+	dir := t.TempDir()
+	cfg.AlertTemplate = filepath.Join(dir, "alert.tmpl")
+	require.NoError(t, os.WriteFile(cfg.AlertTemplate, []byte(`{{.Rule.Name}} is {{.State}}`), 0o600))
+	tmpl, err = cfg.NotificationTemplate()
+	require.NoError(t, err)
+	assert.NotNil(t, tmpl)
+
+	require.NoError(t, os.WriteFile(cfg.AlertTemplate, []byte(`{{.Rule.Owner}}`), 0o600))
+	_, err = cfg.NotificationTemplate()
+	require.Error(t, err)
+
+	cfg.AlertTemplate = filepath.Join(dir, "missing.tmpl")
+	_, err = cfg.NotificationTemplate()
+	require.Error(t, err)
+}
+
+func TestQuotaLimits(t *testing.T) {
+	tests := []struct {
+		expected    map[string]quota.Limits
+		name        string
+		raw         string
+		expectError bool
+	}{
+		{name: "Empty", raw: "", expected: map[string]quota.Limits{}},
+		{
+			name: "Multiple keys",
+			raw:  "team-a:metrics=500,updates=6000,batch=1000; *:updates=600",
+			expected: map[string]quota.Limits{
+				"team-a": {Metrics: 500, UpdatesPerMinute: 6000, BatchSize: 1000},
+				"*":      {UpdatesPerMinute: 600},
+			},
+		},
+		{name: "Missing limits", raw: "team-a", expectError: true},
+		{name: "Empty key", raw: ":batch=10", expectError: true},
+		{name: "Repeated key", raw: "team-a:batch=10;team-a:batch=20", expectError: true},
+		{name: "Unknown quota", raw: "team-a:series=10", expectError: true},
+		{name: "Missing limit", raw: "team-a:batch", expectError: true},
+		{name: "Zero limit", raw: "team-a:batch=0", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{APIQuotas: tt.raw}
+			quotas, err := cfg.QuotaLimits()
+			if tt.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, quotas)
+		})
+	}
+}
+
+func TestMetricNameFilter(t *testing.T) {
+	tests := []struct {
+		name          string
+		allow         string
+		deny          string
+		expectedAllow []string
+		expectedDeny  []string
+		expectError   bool
+	}{
+		{name: "Empty", expectedAllow: []string{}, expectedDeny: []string{}},
+		{
+			name:          "Patterns",
+			allow:         "Heap*, Alloc,",
+			deny:          "HeapReleased",
+			expectedAllow: []string{"Heap*", "Alloc"},
+			expectedDeny:  []string{"HeapReleased"},
+		},
+		{name: "Malformed allow pattern", allow: "Heap[", expectError: true},
+		{name: "Malformed deny pattern", deny: "[-]", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{MetricAllow: tt.allow, MetricDeny: tt.deny}
+			allow, deny, err := cfg.MetricNameFilter()
+			if tt.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedAllow, allow)
+			assert.Equal(t, tt.expectedDeny, deny)
+		})
+	}
+}
+
+func TestFederationPeerURLs(t *testing.T) {
+	tests := []struct {
+		expected    map[string]string
+		name        string
+		raw         string
+		expectError bool
+	}{
+		{name: "Empty", raw: "", expected: map[string]string{}},
+		{
+			name:     "Multiple",
+			raw:      "eu=https://eu.example.com/, us=us:8080",
+			expected: map[string]string{"eu": "https://eu.example.com", "us": "http://us:8080"},
+		},
+		{name: "Missing URL", raw: "eu", expectError: true},
+		{name: "Empty name", raw: "=http://eu:8080", expectError: true},
+		{name: "Repeated name", raw: "eu=eu1:8080,eu=eu2:8080", expectError: true},
+		{name: "Own name", raw: "local=http://eu:8080", expectError: true},
+		{name: "Unsupported scheme", raw: "eu=ftp://eu:8080", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{FederationName: defaultFederationName, FederationPeers: tt.raw}
+			peers, err := cfg.FederationPeerURLs()
+			if tt.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, peers)
+		})
+	}
+}
+
+func TestConfig_Audit(t *testing.T) {
+	t.Setenv("KEY", "envkey")
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError) //nolint:reassign // for tests
+	os.Args = []string{"cmd", "-r=false"}                            //nolint:reassign // for tests
+
+	cfg, err := ParseConfig()
+	require.NoError(t, err)
+
+	entries := make(map[string]configaudit.Entry)
+	for _, e := range cfg.Audit() {
+		entries[e.Name] = e
+	}
+	assert.Equal(t, configaudit.SourceEnv, entries["signing_key"].Source)
+	assert.Equal(t, configaudit.Redacted, entries["signing_key"].Value)
+	assert.Equal(t, configaudit.SourceFlag, entries["restore"].Source)
+	assert.Equal(t, "r", entries["restore"].Flag)
+	assert.Equal(t, false, entries["restore"].Value)
+	assert.Equal(t, configaudit.SourceDefault, entries["config_path"].Source)
+}
+
+func TestTrustedNet(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		expected    string
+		expectError bool
+	}{
+		{name: "Empty", raw: ""},
+		{name: "IPv4", raw: "192.168.1.0/24", expected: "192.168.1.0/24"},
+		{name: "Host bits are masked", raw: " 10.1.2.3/8 ", expected: "10.0.0.0/8"},
+		{name: "IPv6", raw: "2001:db8::/32", expected: "2001:db8::/32"},
+		{name: "Address without mask", raw: "10.0.0.1", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{TrustedSubnet: tt.raw}
+			subnet, err := cfg.TrustedNet()
+			if tt.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tt.expected == "" {
+				assert.Nil(t, subnet)
+				return
+			}
+			assert.Equal(t, tt.expected, subnet.String())
+		})
+	}
+}
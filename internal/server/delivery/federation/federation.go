@@ -0,0 +1,115 @@
+// Package federation reads metrics from remote peer servers, so one server can present a rollup
+// of several regions at /api/metrics.
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+)
+
+const (
+	// MetricsPath is the path peers serve their metrics at.
+	MetricsPath = "/api/metrics"
+	// ScopeParam is the query parameter limiting /api/metrics to the metrics of the queried server.
+	// Peers are always queried with it, so servers federating each other do not loop.
+	ScopeParam = "scope"
+	// ScopeLocal is the ScopeParam value limiting /api/metrics to local metrics.
+	ScopeLocal = "local"
+	// DefaultTimeout bounds the request to every peer.
+	DefaultTimeout = 5 * time.Second
+)
+
+// Result holds the metrics read from one peer.
+type Result struct {
+	Err     error                 // Err is the reason the metrics could not be read, nil on success.
+	Peer    string                // Peer is the name of the peer.
+	Metrics []model.SourcedMetric // Metrics are the metrics of the peer, labeled with its name.
+}
+
+// Client reads metrics from the configured peers.
+type Client struct {
+	httpClient *http.Client      // httpClient sends the requests to peers.
+	peers      map[string]string // peers maps peer names to their base URLs.
+	timeout    time.Duration     // timeout bounds the request to every peer.
+}
+
+// New creates a Client for the given peers.
+//
+// Parameters:
+//   - peers: The base URLs of the peers keyed by name, used as the source label of their metrics.
+//   - timeout: The time allowed for every peer to respond.
+//
+// Returns:
+//   - *Client: A pointer to the created Client.
+func New(peers map[string]string, timeout time.Duration) *Client {
+	return &Client{httpClient: &http.Client{}, peers: peers, timeout: timeout}
+}
+
+// Fetch reads the local metrics of every peer concurrently. A failing peer does not fail the others.
+//
+// Parameters:
+//   - ctx: The context of the request.
+//
+// Returns:
+//   - []Result: The result for every peer, sorted by peer name.
+func (c *Client) Fetch(ctx context.Context) []Result {
+	results := make([]Result, 0, len(c.peers))
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for name, baseURL := range c.peers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			metrics, err := c.fetchPeer(ctx, name, baseURL)
+			mu.Lock()
+			results = append(results, Result{Peer: name, Metrics: metrics, Err: err})
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Peer < results[j].Peer })
+	return results
+}
+
+// fetchPeer reads the local metrics of a peer and labels them with its name.
+func (c *Client) fetchPeer(ctx context.Context, name, baseURL string) ([]model.SourcedMetric, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	endpoint := baseURL + MetricsPath + "?" + url.Values{ScopeParam: {ScopeLocal}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var body model.FederatedMetrics
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode metrics: %w", err)
+	}
+	for i := range body.Metrics {
+		body.Metrics[i].Source = name
+	}
+	return body.Metrics, nil
+}
@@ -0,0 +1,61 @@
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Fetch(t *testing.T) {
+	value := 1.5
+	var gotScope string
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != MetricsPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		gotScope = r.URL.Query().Get(ScopeParam)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(model.FederatedMetrics{
+			Sources: map[string]string{"local": "ok"},
+			Metrics: []model.SourcedMetric{
+				{Metric: model.Metric{ID: "g", MType: "gauge", Value: &value}, Source: "local"},
+			},
+		})
+	}))
+	defer healthy.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer slow.Close()
+
+	client := New(map[string]string{"eu": healthy.URL, "us": failing.URL, "ap": slow.URL}, 100*time.Millisecond)
+	results := client.Fetch(context.Background())
+	require.Len(t, results, 3)
+
+	assert.Equal(t, "ap", results[0].Peer)
+	assert.Error(t, results[0].Err)
+
+	assert.Equal(t, "eu", results[1].Peer)
+	require.NoError(t, results[1].Err)
+	assert.Equal(t, ScopeLocal, gotScope)
+	require.Len(t, results[1].Metrics, 1)
+	assert.Equal(t, "eu", results[1].Metrics[0].Source)
+	assert.Equal(t, "g", results[1].Metrics[0].ID)
+
+	assert.Equal(t, "us", results[2].Peer)
+	assert.ErrorContains(t, results[2].Err, "503")
+}
@@ -0,0 +1,158 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/backup"
+	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// Const backupOperationTimeout bounds exporting and importing all metrics.
+	backupOperationTimeout = 30 * time.Second
+	// Const maxBackupSize is the largest backup /admin/import accepts, in bytes.
+	maxBackupSize = 64 << 20
+)
+
+// MetricsExporter defines the interface for reading all stored metrics.
+type MetricsExporter interface {
+	PullAll(ctx context.Context) (*entity.Metrics, error)
+}
+
+// MetricsImporter defines the interface for storing a batch of metrics.
+type MetricsImporter interface {
+	PushMetrics(ctx context.Context, metrics *entity.Metrics) (*entity.Metrics, error)
+}
+
+// dump is the content of a backup.
+type dump struct {
+	TakenAt time.Time     `json:"taken_at"` // TakenAt is the time the metrics were read.
+	Metrics model.Metrics `json:"metrics"`  // Metrics are the stored metrics.
+}
+
+// Snapshot handles requests to export all stored metrics as a backup, signed and encrypted as the codec is
+// configured. The backup is served as an attachment to be imported with /admin/import and, with a backup
+// directory, also kept there under the same file name, so /api/diff can compare it later.
+//
+// Parameters:
+//   - exporter: An implementation of MetricsExporter to read the metrics.
+//   - codec: The codec sealing the backup.
+//   - dir: The directory keeping exported backups; nil to keep none.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the backup envelope in JSON format.
+func Snapshot(exporter MetricsExporter, codec *backup.Codec, dir *backup.Dir) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), backupOperationTimeout)
+		defer cancel()
+
+		metrics, err := exporter.PullAll(ctx)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+		takenAt := time.Now().UTC()
+		body, err := json.Marshal(dump{TakenAt: takenAt, Metrics: *model.FromEntityMetrics(metrics)})
+		if err != nil {
+			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+		sealed, err := codec.Seal(body)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+
+		filename := fmt.Sprintf("metricol-backup-%s.json", takenAt.Format("20060102T150405Z"))
+		if dir != nil {
+			if err = dir.Save(filename, sealed); err != nil {
+				return c.String(http.StatusInternalServerError, "Failed to store the backup.")
+			}
+		}
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+		return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, sealed)
+	}
+}
+
+// Import handles requests to restore metrics from a backup exported by /admin/snapshot. The backup is
+// verified before anything is stored, so a tampered or foreign backup is refused as a whole.
+//
+// Parameters:
+//   - importer: An implementation of MetricsImporter to store the metrics.
+//   - codec: The codec opening the backup.
+//   - privateKeys: Returns the private keys an encrypted backup may be sealed for.
+//
+// Returns:
+//   - An echo.HandlerFunc that stores the metrics and responds with their number in JSON format.
+func Import(importer MetricsImporter, codec *backup.Codec, privateKeys func() []string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		data, err := io.ReadAll(io.LimitReader(c.Request().Body, maxBackupSize+1))
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Failed to read the backup.")
+		}
+		if len(data) > maxBackupSize {
+			return c.String(http.StatusRequestEntityTooLarge, "Backup is too large.")
+		}
+
+		d, err := openDump(codec, data, privateKeys())
+		if err != nil {
+			return c.String(openError(err))
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request().Context(), backupOperationTimeout)
+		defer cancel()
+
+		stored, err := importer.PushMetrics(ctx, d.Metrics.ToEntityMetrics())
+		if err != nil {
+			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+		return c.JSON(http.StatusOK, map[string]any{"imported": stored.Length(), "taken_at": d.TakenAt})
+	}
+}
+
+// openDump opens a backup envelope and decodes the dump inside it.
+//
+// Parameters:
+//   - codec: The codec opening the backup.
+//   - data: The backup envelope.
+//   - privateKeys: The private keys an encrypted backup may be sealed for.
+//
+// Returns:
+//   - *dump: The dump.
+//   - error: backup.ErrSignature or backup.ErrDecrypt if the backup cannot be opened, or backup.ErrInvalidBackup
+//     if it is malformed.
+func openDump(codec *backup.Codec, data []byte, privateKeys []string) (*dump, error) {
+	body, err := codec.Open(data, privateKeys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup: %w", err)
+	}
+	var d dump
+	if err = json.Unmarshal(body, &d); err != nil {
+		return nil, fmt.Errorf("%w: %w", backup.ErrInvalidBackup, err)
+	}
+	return &d, nil
+}
+
+// openError maps an error of openDump to the HTTP status and message answering it.
+//
+// Parameters:
+//   - err: The error returned by openDump.
+//
+// Returns:
+//   - int: The HTTP status.
+//   - string: The message.
+func openError(err error) (int, string) {
+	switch {
+	case errors.Is(err, backup.ErrSignature):
+		return http.StatusForbidden, "Backup signature verification failed."
+	case errors.Is(err, backup.ErrDecrypt):
+		return http.StatusUnprocessableEntity, "Backup is not encrypted for this server."
+	default:
+		return http.StatusBadRequest, "Invalid backup."
+	}
+}
@@ -0,0 +1,153 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/backup"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/x25519box"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubStore implements MetricsExporter and MetricsImporter for testing.
+type stubStore struct {
+	err     error
+	metrics entity.Metrics
+}
+
+func (s *stubStore) PullAll(_ context.Context) (*entity.Metrics, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &s.metrics, nil
+}
+
+func (s *stubStore) PushMetrics(_ context.Context, metrics *entity.Metrics) (*entity.Metrics, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.metrics = append(s.metrics, *metrics...)
+	return metrics, nil
+}
+
+// exportBackup serves /admin/snapshot from the store and returns the response.
+func exportBackup(t *testing.T, store *stubStore, codec *backup.Codec) *httptest.ResponseRecorder {
+	t.Helper()
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/admin/snapshot", http.NoBody), rec)
+	require.NoError(t, Snapshot(store, codec, nil)(c))
+	return rec
+}
+
+// importBackup posts data to /admin/import and returns the response.
+func importBackup(
+	t *testing.T,
+	store *stubStore,
+	codec *backup.Codec,
+	keys []string,
+	data []byte,
+) *httptest.ResponseRecorder {
+	t.Helper()
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodPost, "/admin/import", bytes.NewReader(data)), rec)
+	require.NoError(t, Import(store, codec, func() []string { return keys })(c))
+	return rec
+}
+
+func TestSnapshotImport(t *testing.T) {
+	private, public, err := x25519box.GenerateKey()
+	require.NoError(t, err)
+	codec, err := backup.New("secret", public)
+	require.NoError(t, err)
+
+	source := &stubStore{metrics: entity.Metrics{
+		{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.5},
+		{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(7)},
+	}}
+	exported := exportBackup(t, source, codec)
+	require.Equal(t, http.StatusOK, exported.Code)
+	assert.Contains(t, exported.Header().Get(echo.HeaderContentDisposition), "attachment; filename=\"metricol-backup-")
+	assert.NotContains(t, exported.Body.String(), "Alloc")
+
+	target := &stubStore{}
+	imported := importBackup(t, target, codec, []string{private}, exported.Body.Bytes())
+	require.Equal(t, http.StatusOK, imported.Code)
+	assert.Contains(t, imported.Body.String(), `"imported":2`)
+	assert.Equal(t, source.metrics, target.metrics)
+}
+
+func TestImport_Rejects(t *testing.T) {
+	private, public, err := x25519box.GenerateKey()
+	require.NoError(t, err)
+	_, otherPublic, err := x25519box.GenerateKey()
+	require.NoError(t, err)
+
+	signed, err := backup.New("secret", "")
+	require.NoError(t, err)
+	foreign, err := backup.New("secret", otherPublic)
+	require.NoError(t, err)
+	encrypted, err := backup.New("", public)
+	require.NoError(t, err)
+	source := &stubStore{metrics: entity.Metrics{{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.5}}}
+
+	tests := []struct {
+		codec          *backup.Codec
+		store          *stubStore
+		name           string
+		data           []byte
+		expectedStatus int
+	}{
+		{
+			name:           "Unsigned backup",
+			codec:          signed,
+			store:          &stubStore{},
+			data:           exportBackup(t, source, &backup.Codec{}).Body.Bytes(),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Encrypted for another server",
+			codec:          signed,
+			store:          &stubStore{},
+			data:           exportBackup(t, source, foreign).Body.Bytes(),
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Not a backup",
+			codec:          &backup.Codec{},
+			store:          &stubStore{},
+			data:           []byte(`[{"id":"Alloc","type":"gauge","value":1.5}]`),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Storage error",
+			codec:          encrypted,
+			store:          &stubStore{err: errors.New("storage is down")},
+			data:           exportBackup(t, source, encrypted).Body.Bytes(),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := importBackup(t, tt.store, tt.codec, []string{private}, tt.data)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.store.err == nil {
+				assert.Empty(t, tt.store.metrics)
+			}
+		})
+	}
+}
+
+func TestSnapshot_StorageError(t *testing.T) {
+	rec := exportBackup(t, &stubStore{err: errors.New("storage is down")}, &backup.Codec{})
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
@@ -0,0 +1,165 @@
+package admin
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/backup"
+	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/labstack/echo/v4"
+)
+
+// Const currentSnapshot names the stored metrics in a diff against a backup.
+const currentSnapshot = "current"
+
+// snapshotRef identifies a compared snapshot.
+type snapshotRef struct {
+	TakenAt time.Time `json:"taken_at"` // TakenAt is the time the metrics were read.
+	Name    string    `json:"name"`     // Name is the file name of the backup, or currentSnapshot.
+}
+
+// metricChange is a metric whose value differs between the snapshots.
+type metricChange struct {
+	From *model.Metric `json:"from"` // From is the metric in the older snapshot.
+	To   *model.Metric `json:"to"`   // To is the metric in the newer snapshot.
+}
+
+// metricsDiff describes how the metrics changed from one snapshot to another.
+type metricsDiff struct {
+	From    snapshotRef    `json:"from"`    // From is the older snapshot.
+	To      snapshotRef    `json:"to"`      // To is the newer snapshot.
+	Added   model.Metrics  `json:"added"`   // Added are the metrics only in the newer snapshot.
+	Removed model.Metrics  `json:"removed"` // Removed are the metrics only in the older snapshot.
+	Changed []metricChange `json:"changed"` // Changed are the metrics whose value differs.
+}
+
+// Diff handles requests comparing two backups kept in the backup directory, named by the fromSnapshot and
+// toSnapshot query parameters, e.g. before and after a deployment or a migration. Without toSnapshot the backup
+// is compared with the stored metrics. Backups are verified and decrypted as /admin/import does.
+//
+// Parameters:
+//   - exporter: An implementation of MetricsExporter to read the stored metrics.
+//   - codec: The codec opening the backups.
+//   - dir: The directory keeping the backups.
+//   - privateKeys: Returns the private keys an encrypted backup may be sealed for.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the added, removed and changed metrics in JSON format.
+func Diff(
+	exporter MetricsExporter,
+	codec *backup.Codec,
+	dir *backup.Dir,
+	privateKeys func() []string,
+) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		fromName, toName := c.QueryParam("fromSnapshot"), c.QueryParam("toSnapshot")
+		if fromName == "" {
+			return c.String(http.StatusBadRequest, "The fromSnapshot parameter is required.")
+		}
+
+		from, status, msg := loadSnapshot(dir, codec, fromName, privateKeys())
+		if status != http.StatusOK {
+			return c.String(status, msg)
+		}
+
+		var to *dump
+		if toName == "" {
+			ctx, cancel := context.WithTimeout(c.Request().Context(), backupOperationTimeout)
+			defer cancel()
+
+			metrics, err := exporter.PullAll(ctx)
+			if err != nil {
+				return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			}
+			toName = currentSnapshot
+			to = &dump{TakenAt: time.Now().UTC(), Metrics: *model.FromEntityMetrics(metrics)}
+		} else if to, status, msg = loadSnapshot(dir, codec, toName, privateKeys()); status != http.StatusOK {
+			return c.String(status, msg)
+		}
+
+		diff := diffMetrics(from.Metrics.ToEntityMetrics(), to.Metrics.ToEntityMetrics())
+		diff.From = snapshotRef{Name: fromName, TakenAt: from.TakenAt}
+		diff.To = snapshotRef{Name: toName, TakenAt: to.TakenAt}
+		return c.JSON(http.StatusOK, diff)
+	}
+}
+
+// loadSnapshot reads and opens a backup from the backup directory.
+//
+// Parameters:
+//   - dir: The directory keeping the backups.
+//   - codec: The codec opening the backup.
+//   - name: The file name of the backup.
+//   - privateKeys: The private keys an encrypted backup may be sealed for.
+//
+// Returns:
+//   - *dump: The dump of the backup.
+//   - int: http.StatusOK, or the HTTP status answering the failure.
+//   - string: The message answering the failure.
+func loadSnapshot(dir *backup.Dir, codec *backup.Codec, name string, privateKeys []string) (*dump, int, string) {
+	data, err := dir.Load(name)
+	switch {
+	case errors.Is(err, backup.ErrInvalidName):
+		return nil, http.StatusBadRequest, "Invalid snapshot name."
+	case errors.Is(err, backup.ErrNotFound):
+		return nil, http.StatusNotFound, "Snapshot " + name + " not found."
+	case err != nil:
+		return nil, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)
+	}
+
+	d, err := openDump(codec, data, privateKeys)
+	if err != nil {
+		status, msg := openError(err)
+		return nil, status, msg
+	}
+	return d, http.StatusOK, ""
+}
+
+// diffMetrics compares two sets of metrics. Metrics are matched by type and name, and every list is
+// ordered by type and name.
+//
+// Parameters:
+//   - from: The older metrics.
+//   - to: The newer metrics.
+//
+// Returns:
+//   - metricsDiff: The added, removed and changed metrics.
+func diffMetrics(from, to *entity.Metrics) metricsDiff {
+	key := func(m *entity.Metric) string { return m.Type + "|" + m.Name }
+	older := make(map[string]*entity.Metric, from.Length())
+	for _, m := range *from {
+		older[key(m)] = m
+	}
+
+	diff := metricsDiff{Added: model.Metrics{}, Removed: model.Metrics{}, Changed: []metricChange{}}
+	for _, m := range *to {
+		previous, ok := older[key(m)]
+		if !ok {
+			diff.Added = append(diff.Added, model.FromEntityMetric(m))
+			continue
+		}
+		delete(older, key(m))
+		if previous.Value != m.Value {
+			diff.Changed = append(diff.Changed, metricChange{
+				From: model.FromEntityMetric(previous),
+				To:   model.FromEntityMetric(m),
+			})
+		}
+	}
+	for _, m := range older {
+		diff.Removed = append(diff.Removed, model.FromEntityMetric(m))
+	}
+
+	byIdentity := func(a, b *model.Metric) int {
+		return cmp.Or(cmp.Compare(a.MType, b.MType), cmp.Compare(a.ID, b.ID))
+	}
+	slices.SortFunc(diff.Added, byIdentity)
+	slices.SortFunc(diff.Removed, byIdentity)
+	slices.SortFunc(diff.Changed, func(a, b metricChange) int { return byIdentity(a.To, b.To) })
+	return diff
+}
@@ -0,0 +1,140 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/backup"
+	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storeBackup seals the metrics with the codec and keeps the backup in the directory.
+func storeBackup(t *testing.T, dir *backup.Dir, codec *backup.Codec, name string, metrics entity.Metrics) {
+	t.Helper()
+	body, err := json.Marshal(dump{TakenAt: time.Now().UTC(), Metrics: *model.FromEntityMetrics(&metrics)})
+	require.NoError(t, err)
+	sealed, err := codec.Seal(body)
+	require.NoError(t, err)
+	require.NoError(t, dir.Save(name, sealed))
+}
+
+// requestDiff serves /api/diff with the query and returns the response.
+func requestDiff(
+	t *testing.T,
+	store *stubStore,
+	codec *backup.Codec,
+	dir *backup.Dir,
+	query string,
+) *httptest.ResponseRecorder {
+	t.Helper()
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/api/diff?"+query, http.NoBody), rec)
+	require.NoError(t, Diff(store, codec, dir, func() []string { return nil })(c))
+	return rec
+}
+
+func TestDiff(t *testing.T) {
+	dir, err := backup.NewDir(t.TempDir())
+	require.NoError(t, err)
+	codec, err := backup.New("secret", "")
+	require.NoError(t, err)
+
+	storeBackup(t, dir, codec, "before.json", entity.Metrics{
+		{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.5},
+		{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(7)},
+		{Name: "Legacy", Type: entity.MetricTypeGauge, Value: 3.0},
+	})
+	storeBackup(t, dir, codec, "after.json", entity.Metrics{
+		{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(9)},
+		{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.5},
+		{Name: "Version", Type: entity.MetricTypeInfo, Value: "1.2.0"},
+	})
+
+	rec := requestDiff(t, &stubStore{}, codec, dir, "fromSnapshot=before.json&toSnapshot=after.json")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var diff metricsDiff
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &diff))
+	assert.Equal(t, "before.json", diff.From.Name)
+	assert.Equal(t, "after.json", diff.To.Name)
+	require.Len(t, diff.Added, 1)
+	assert.Equal(t, "Version", diff.Added[0].ID)
+	require.Len(t, diff.Removed, 1)
+	assert.Equal(t, "Legacy", diff.Removed[0].ID)
+	require.Len(t, diff.Changed, 1)
+	assert.Equal(t, int64(7), *diff.Changed[0].From.Delta)
+	assert.Equal(t, int64(9), *diff.Changed[0].To.Delta)
+}
+
+func TestDiff_Current(t *testing.T) {
+	dir, err := backup.NewDir(t.TempDir())
+	require.NoError(t, err)
+	codec := &backup.Codec{}
+	metrics := entity.Metrics{
+		{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.5},
+		{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(7)},
+	}
+	storeBackup(t, dir, codec, "before.json", metrics)
+
+	rec := requestDiff(t, &stubStore{metrics: metrics}, codec, dir, "fromSnapshot=before.json")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"added":[],"removed":[],"changed":[]`, "unchanged metrics are not reported")
+	assert.Contains(t, rec.Body.String(), `"name":"current"`)
+
+	rec = requestDiff(t, &stubStore{err: errors.New("storage is down")}, codec, dir, "fromSnapshot=before.json")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestDiff_Rejects(t *testing.T) {
+	dir, err := backup.NewDir(t.TempDir())
+	require.NoError(t, err)
+	unsigned := &backup.Codec{}
+	signed, err := backup.New("secret", "")
+	require.NoError(t, err)
+	storeBackup(t, dir, unsigned, "unsigned.json", entity.Metrics{})
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+	}{
+		{name: "Missing from", query: "toSnapshot=unsigned.json", expectedStatus: http.StatusBadRequest},
+		{name: "Path traversal", query: "fromSnapshot=../secret.json", expectedStatus: http.StatusBadRequest},
+		{name: "Unknown snapshot", query: "fromSnapshot=missing.json", expectedStatus: http.StatusNotFound},
+		{name: "Unsigned snapshot", query: "fromSnapshot=unsigned.json", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedStatus, requestDiff(t, &stubStore{}, signed, dir, tt.query).Code)
+		})
+	}
+}
+
+func TestSnapshot_KeepsBackup(t *testing.T) {
+	dir, err := backup.NewDir(t.TempDir())
+	require.NoError(t, err)
+	store := &stubStore{metrics: entity.Metrics{{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.5}}}
+
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/admin/snapshot", http.NoBody), rec)
+	require.NoError(t, Snapshot(store, &backup.Codec{}, dir)(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	_, params, err := mime.ParseMediaType(rec.Header().Get(echo.HeaderContentDisposition))
+	require.NoError(t, err)
+	kept, err := dir.Load(params["filename"])
+	require.NoError(t, err)
+	assert.Equal(t, rec.Body.Bytes(), kept)
+}
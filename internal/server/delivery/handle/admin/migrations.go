@@ -0,0 +1,33 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// MigrationReporter defines the interface for reporting the database schema version.
+type MigrationReporter interface {
+	MigrationStatus() (*repository.MigrationStatus, error)
+}
+
+// Migrations handles requests for the database schema version.
+// It responds with the current version and the applied and pending migrations.
+//
+// Parameters:
+//   - reporter: An implementation of MigrationReporter to read the schema version.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the migration status in JSON format.
+func Migrations(reporter MigrationReporter) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		status, err := reporter.MigrationStatus()
+		if err != nil {
+			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		return c.JSON(http.StatusOK, status)
+	}
+}
@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockMigrationReporter implements MigrationReporter for testing.
+type mockMigrationReporter struct {
+	status *repository.MigrationStatus
+	err    error
+}
+
+func (m *mockMigrationReporter) MigrationStatus() (*repository.MigrationStatus, error) {
+	return m.status, m.err
+}
+
+func TestMigrations(t *testing.T) {
+	tests := []struct {
+		reporter       MigrationReporter
+		name           string
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name: "Status reported",
+			reporter: &mockMigrationReporter{status: &repository.MigrationStatus{
+				Applied: []uint{1, 2},
+				Pending: []uint{3},
+				Version: 2,
+			}},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"applied":[1,2],"pending":[3],"version":2,"dirty":false}`,
+		},
+		{
+			name:           "Repository failure",
+			reporter:       &mockMigrationReporter{err: errors.New("db down")},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   http.StatusText(http.StatusInternalServerError),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/admin/migrations", http.NoBody)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			err := Migrations(tt.reporter)(c)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gdyunin/metricol.git/internal/server/provisioning"
+	"github.com/labstack/echo/v4"
+)
+
+// ProvisioningReloader defines the interface for reading and reloading the provisioning file.
+type ProvisioningReloader interface {
+	State() provisioning.State
+	Reload() (provisioning.State, error)
+}
+
+// Provisioning handles requests for the provisioning file in effect.
+//
+// Parameters:
+//   - reloader: An implementation of ProvisioningReloader holding the file.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the loaded file, its path and load time in JSON format.
+func Provisioning(reloader ProvisioningReloader) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, reloader.State())
+	}
+}
+
+// ReloadProvisioning handles requests to reload the provisioning file. A file that cannot be loaded
+// is reported with 422 Unprocessable Entity and leaves the previous one in effect.
+//
+// Parameters:
+//   - reloader: An implementation of ProvisioningReloader holding the file.
+//
+// Returns:
+//   - An echo.HandlerFunc that reloads the file and responds with the new state in JSON format.
+func ReloadProvisioning(reloader ProvisioningReloader) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		state, err := reloader.Reload()
+		if err != nil {
+			return c.String(
+				http.StatusUnprocessableEntity,
+				"Provisioning file rejected, the previous one stays in effect: "+err.Error(),
+			)
+		}
+		return c.JSON(http.StatusOK, state)
+	}
+}
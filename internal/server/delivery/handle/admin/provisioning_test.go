@@ -0,0 +1,89 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/provisioning"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockProvisioningReloader implements ProvisioningReloader for testing.
+type mockProvisioningReloader struct {
+	err   error
+	state provisioning.State
+}
+
+func (m *mockProvisioningReloader) State() provisioning.State {
+	return m.state
+}
+
+func (m *mockProvisioningReloader) Reload() (provisioning.State, error) {
+	if m.err != nil {
+		return provisioning.State{}, m.err
+	}
+	return m.state, nil
+}
+
+func TestProvisioning(t *testing.T) {
+	state := provisioning.State{
+		LoadedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		File:     &provisioning.File{Dashboards: []provisioning.Dashboard{}, AlertRules: []provisioning.AlertRule{}},
+		Path:     "provisioning.yaml",
+	}
+	stateJSON := `{"loaded_at":"2024-01-02T03:04:05Z","file":{"dashboards":[],"alert_rules":[]},` +
+		`"path":"provisioning.yaml"}`
+
+	tests := []struct {
+		handler        func(ProvisioningReloader) echo.HandlerFunc
+		reloader       ProvisioningReloader
+		name           string
+		method         string
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name:           "State",
+			handler:        Provisioning,
+			method:         http.MethodGet,
+			reloader:       &mockProvisioningReloader{state: state},
+			expectedStatus: http.StatusOK,
+			expectedBody:   stateJSON,
+		},
+		{
+			name:           "Reloaded",
+			handler:        ReloadProvisioning,
+			method:         http.MethodPost,
+			reloader:       &mockProvisioningReloader{state: state},
+			expectedStatus: http.StatusOK,
+			expectedBody:   stateJSON,
+		},
+		{
+			name:           "Reload rejected",
+			handler:        ReloadProvisioning,
+			method:         http.MethodPost,
+			reloader:       &mockProvisioningReloader{err: errors.New("unknown key")},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "Provisioning file rejected, the previous one stays in effect: unknown key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(tt.method, "/admin/provisioning", http.NoBody)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			err := tt.handler(tt.reloader)(c)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// Package admin provides HTTP handlers for administrative operations on the metrics storage,
+// such as restoring metrics that were deleted by mistake.
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+	"github.com/gdyunin/metricol.git/internal/server/internal/controller"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/labstack/echo/v4"
+)
+
+const adminOperationTimeout = 5 * time.Second
+
+// MetricsUndeleter defines the interface for restoring soft-deleted metrics.
+type MetricsUndeleter interface {
+	Undelete(ctx context.Context, metricType string, name string) (*entity.Metric, error)
+}
+
+// Undelete handles requests to restore a soft-deleted metric.
+// The metric is identified either by URI parameters (/admin/undelete/:type/:id)
+// or by a JSON payload with "id" and "type" fields.
+//
+// Parameters:
+//   - undeleter: An implementation of MetricsUndeleter to restore metrics.
+//
+// Returns:
+//   - An echo.HandlerFunc that restores the metric and responds with it in JSON format.
+func Undelete(undeleter MetricsUndeleter) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		m := model.Metric{}
+		if err := c.Bind(&m); err != nil || m.ID == "" || m.MType == "" {
+			return c.String(http.StatusBadRequest, "Metric type and id are required.")
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request().Context(), adminOperationTimeout)
+		defer cancel()
+
+		restored, err := undeleter.Undelete(ctx, m.MType, m.ID)
+		if err != nil {
+			if errors.Is(err, controller.ErrNotFoundInRepository) {
+				return c.String(http.StatusNotFound, "Deleted metric not found or already purged.")
+			}
+			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		return c.JSON(http.StatusOK, model.FromEntityMetric(restored))
+	}
+}
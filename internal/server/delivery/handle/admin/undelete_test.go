@@ -0,0 +1,100 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/controller"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockUndeleter implements MetricsUndeleter for testing.
+type mockUndeleter struct {
+	err error
+}
+
+func (m *mockUndeleter) Undelete(_ context.Context, metricType, name string) (*entity.Metric, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &entity.Metric{Name: name, Type: metricType, Value: 42.5}, nil
+}
+
+func TestUndelete(t *testing.T) {
+	tests := []struct {
+		undeleter      MetricsUndeleter
+		name           string
+		body           string
+		paramType      string
+		paramID        string
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name:           "Restore by URI",
+			undeleter:      &mockUndeleter{},
+			paramType:      "gauge",
+			paramID:        "temp",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"value":42.5,"id":"temp","type":"gauge"}`,
+		},
+		{
+			name:           "Restore by JSON",
+			undeleter:      &mockUndeleter{},
+			body:           `{"id":"temp","type":"gauge"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"value":42.5,"id":"temp","type":"gauge"}`,
+		},
+		{
+			name:           "Missing identifiers",
+			undeleter:      &mockUndeleter{},
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Metric type and id are required.",
+		},
+		{
+			name:           "Nothing to restore",
+			undeleter:      &mockUndeleter{err: fmt.Errorf("wrap: %w", controller.ErrNotFoundInRepository)},
+			paramType:      "gauge",
+			paramID:        "temp",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "Deleted metric not found or already purged.",
+		},
+		{
+			name:           "Repository failure",
+			undeleter:      &mockUndeleter{err: errors.New("db down")},
+			paramType:      "gauge",
+			paramID:        "temp",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   http.StatusText(http.StatusInternalServerError),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/admin/undelete", strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if tt.paramType != "" {
+				c.SetParamNames("type", "id")
+				c.SetParamValues(tt.paramType, tt.paramID)
+			}
+
+			err := Undelete(tt.undeleter)(c)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/quota"
+	"github.com/labstack/echo/v4"
+)
+
+// UsageReporter defines the interface for reporting the use of API key quotas.
+type UsageReporter interface {
+	Usage() []quota.Usage
+}
+
+// Usage handles requests for the quota usage of every configured API key.
+// Keys are identified by a prefix of their hash, so the report can be shared without leaking them.
+//
+// Parameters:
+//   - reporter: An implementation of UsageReporter to read the usage.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the usage in JSON format.
+func Usage(reporter UsageReporter) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, reporter.Usage())
+	}
+}
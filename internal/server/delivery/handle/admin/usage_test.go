@@ -0,0 +1,41 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/quota"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubUsageReporter implements UsageReporter for testing.
+type stubUsageReporter struct {
+	usage []quota.Usage
+}
+
+func (s *stubUsageReporter) Usage() []quota.Usage {
+	return s.usage
+}
+
+func TestUsage(t *testing.T) {
+	reporter := &stubUsageReporter{usage: []quota.Usage{{
+		KeyID:             "3f2a9c0b7d1e",
+		Limits:            quota.Limits{Metrics: 100, UpdatesPerMinute: 600, BatchSize: 50},
+		Metrics:           42,
+		UpdatesThisMinute: 120,
+		Updates:           9000,
+		Rejected:          3,
+	}}}
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/admin/usage", http.NoBody)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	assert.NoError(t, Usage(reporter)(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"key_id":"3f2a9c0b7d1e","limits":{"metrics":100,"updates_per_minute":600,"batch_size":50},`+
+		`"metrics":42,"updates_this_minute":120,"updates":9000,"rejected":3}]`, rec.Body.String())
+}
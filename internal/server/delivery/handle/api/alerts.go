@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gdyunin/metricol.git/internal/server/provisioning"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Alert states reported by /api/alerts.
+const (
+	// AlertFiring is the state of a rule whose metric satisfies the rule.
+	AlertFiring = "firing"
+	// AlertOK is the state of a rule whose metric does not satisfy the rule.
+	AlertOK = "ok"
+	// AlertNoData is the state of a rule whose metric is not stored.
+	AlertNoData = "no_data"
+)
+
+// AlertStatus is a provisioned alert rule evaluated against the current value of its metric.
+type AlertStatus struct {
+	Value *float64 `json:"value,omitempty"` // Value is the current value of the metric, nil without data.
+	State string   `json:"state"`           // State is firing, ok or no_data.
+	provisioning.AlertRule
+}
+
+// Alerts handles requests for the state of the provisioned alert rules. Rules are evaluated on every
+// request against the stored values, so the states always reflect the latest updates.
+//
+// Parameters:
+//   - puller: An implementation of the PullerAll interface for reading the watched metrics.
+//   - provisioned: The source of the provisioning file in effect.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the alert states in JSON format.
+func Alerts(puller PullerAll, provisioned Provisioned) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		metrics, err := pullAll(c.Request().Context(), puller)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+
+		values := make(map[string]float64, metrics.Length())
+		for _, m := range *metrics {
+			if v, ok := m.Float64(); ok {
+				values[m.Type+"/"+m.Name] = v
+			}
+		}
+
+		rules := provisioned.Current().AlertRules
+		statuses := make([]AlertStatus, 0, len(rules))
+		for _, r := range rules {
+			status := AlertStatus{AlertRule: r, State: AlertNoData}
+			if v, ok := values[r.Type+"/"+r.Metric]; ok {
+				status.Value = &v
+				status.State = AlertOK
+				if r.Fires(v) {
+					status.State = AlertFiring
+				}
+			}
+			statuses = append(statuses, status)
+		}
+		return c.JSON(http.StatusOK, statuses)
+	}
+}
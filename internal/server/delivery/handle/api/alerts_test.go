@@ -0,0 +1,69 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/provisioning"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlerts(t *testing.T) {
+	provisioned := &staticProvisioned{file: &provisioning.File{AlertRules: []provisioning.AlertRule{
+		{Name: "heap_high", Metric: "HeapAlloc", Type: "gauge", Op: ">", Threshold: 1, Severity: "warning"},
+		{Name: "polls", Metric: "PollCount", Type: "counter", Op: "<", Threshold: 2, Severity: "critical"},
+		{Name: "missing", Metric: "Missing", Type: "gauge", Op: "==", Severity: "warning"},
+	}}}
+
+	tests := []struct {
+		puller         PullerAll
+		name           string
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name: "Rules evaluated",
+			puller: &mockPuller{metrics: &entity.Metrics{
+				{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 1.5},
+				{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(3)},
+				{Name: "Missing", Type: entity.MetricTypeCounter, Value: int64(0)},
+			}},
+			expectedStatus: http.StatusOK,
+			expectedBody: `[{"value":1.5,"state":"firing","name":"heap_high","metric":"HeapAlloc","type":"gauge",` +
+				`"op":">","severity":"warning","threshold":1},` +
+				`{"value":3,"state":"ok","name":"polls","metric":"PollCount","type":"counter",` +
+				`"op":"<","severity":"critical","threshold":2},` +
+				`{"state":"no_data","name":"missing","metric":"Missing","type":"gauge",` +
+				`"op":"==","severity":"warning","threshold":0}]`,
+		},
+		{
+			name:           "Storage failure",
+			puller:         &mockPuller{err: errors.New("db down")},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   http.StatusText(http.StatusInternalServerError),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/alerts", http.NoBody)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			err := Alerts(tt.puller, provisioned)(c)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+				return
+			}
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
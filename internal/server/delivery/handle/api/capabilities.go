@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// EncodingGzip is the gzip content encoding of request and response bodies.
+	EncodingGzip = "gzip"
+	// EncodingIdentity means bodies are sent as they are.
+	EncodingIdentity = "identity"
+
+	// TransportBatch sends a JSON array of metrics to POST /updates.
+	TransportBatch = "json-batch"
+	// TransportJSON sends a single JSON metric to POST /update.
+	TransportJSON = "json"
+	// TransportURI sends a single metric in the path of POST /update/:type/:id/:value.
+	TransportURI = "uri"
+
+	// SigningHMACSHA256 signs bodies with HMAC-SHA256 in the HashSHA256 header.
+	SigningHMACSHA256 = "hmac-sha256"
+
+	// EncryptionRSAAESGCM encrypts bodies with AES-GCM under a key encrypted with the server's RSA public key.
+	EncryptionRSAAESGCM = "rsa-aes-gcm"
+	// EncryptionX25519ChaCha20 encrypts bodies with ChaCha20-Poly1305 under a key agreed with the server's
+	// X25519 public key.
+	EncryptionX25519ChaCha20 = "x25519-chacha20poly1305"
+)
+
+// Limits describes the limits the server applies to metric updates. Zero values mean no limit.
+type Limits struct {
+	MinAgentVersion     string  `json:"min_agent_version,omitempty"`      // MinAgentVersion is the oldest accepted agent.
+	MetricRateLimit     int     `json:"metric_rate_limit,omitempty"`      // MetricRateLimit is the updates/s per metric.
+	MaxSeries           int     `json:"max_series,omitempty"`             // MaxSeries is the number of distinct metrics.
+	MaxClockSkewSeconds float64 `json:"max_clock_skew_seconds,omitempty"` // MaxClockSkewSeconds bounds agent clock skew.
+}
+
+// ServerCapabilities lists the options the server supports, so clients can pick the best ones they support too.
+type ServerCapabilities struct {
+	Encodings  []string `json:"encodings"`  // Encodings are the supported body encodings.
+	Transports []string `json:"transports"` // Transports are the supported ways of sending metrics.
+	Signing    []string `json:"signing"`    // Signing are the supported signing algorithms.
+	Encryption []string `json:"encryption"` // Encryption are the enabled encryption schemes; empty if disabled.
+	Limits     Limits   `json:"limits"`     // Limits are the limits applied to metric updates.
+}
+
+// Capabilities handles requests for the capabilities of the server. Agents query it at startup
+// to choose the encoding, transport and security options.
+//
+// Parameters:
+//   - capabilities: The function describing the current capabilities of the server.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the capabilities in JSON format.
+func Capabilities(capabilities func() ServerCapabilities) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, capabilities())
+	}
+}
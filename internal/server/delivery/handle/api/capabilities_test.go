@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapabilities(t *testing.T) {
+	caps := ServerCapabilities{
+		Encodings:  []string{EncodingGzip, EncodingIdentity},
+		Transports: []string{TransportBatch, TransportJSON, TransportURI},
+		Signing:    []string{SigningHMACSHA256},
+		Encryption: []string{},
+		Limits:     Limits{MetricRateLimit: 10, MinAgentVersion: "1.2.0"},
+	}
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/capabilities", http.NoBody)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	assert.NoError(t, Capabilities(func() ServerCapabilities { return caps })(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"encodings":["gzip","identity"],
+		"transports":["json-batch","json","uri"],
+		"signing":["hmac-sha256"],
+		"encryption":[],
+		"limits":{"metric_rate_limit":10,"min_agent_version":"1.2.0"}
+	}`, rec.Body.String())
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Const defaultCardinalityPrefixes is the number of prefixes listed when a cardinality request does not set n.
+const defaultCardinalityPrefixes = 50
+
+// PrefixCount is the number of distinct metrics sharing a name prefix.
+type PrefixCount struct {
+	Prefix string `json:"prefix"` // Prefix is the shared name prefix.
+	Count  int    `json:"count"`  // Count is the number of metrics with the prefix.
+}
+
+// Cardinality is the number of distinct metrics, in total and broken down by type and name prefix.
+type Cardinality struct {
+	ByType   map[string]int `json:"by_type"`   // ByType maps a metric type to the number of its metrics.
+	ByPrefix []PrefixCount  `json:"by_prefix"` // ByPrefix lists the most numerous prefixes first.
+	Total    int            `json:"total"`     // Total is the number of distinct metrics.
+}
+
+// CardinalityCounts handles requests for the number of stored metrics by type and name prefix, to find
+// which families of metrics dominate a busy server. The prefix of a name is the part before its first
+// "_", "." or ":" separator, or the name without trailing digits if it has none, so that numbered series
+// such as CPUutilization1 and CPUutilization2 are counted together. The n query parameter sets how many
+// prefixes are listed, 50 by default.
+//
+// Parameters:
+//   - puller: An implementation of the PullerAll interface for reading the metrics.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the counts in JSON format, or 400 for an invalid n.
+func CardinalityCounts(puller PullerAll) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		n, err := parseLimit(c.QueryParam("n"), defaultCardinalityPrefixes, maxTopN)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "N must be a positive integer.")
+		}
+
+		metrics, err := pullAll(c.Request().Context(), puller)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+
+		response := Cardinality{ByType: make(map[string]int), Total: len(*metrics)}
+		prefixes := make(map[string]int)
+		for _, m := range *metrics {
+			response.ByType[m.Type]++
+			prefixes[namePrefix(m.Name)]++
+		}
+
+		response.ByPrefix = make([]PrefixCount, 0, len(prefixes))
+		for prefix, count := range prefixes {
+			response.ByPrefix = append(response.ByPrefix, PrefixCount{Prefix: prefix, Count: count})
+		}
+		sort.Slice(response.ByPrefix, func(i, j int) bool {
+			a, b := response.ByPrefix[i], response.ByPrefix[j]
+			if a.Count != b.Count {
+				return a.Count > b.Count
+			}
+			return a.Prefix < b.Prefix
+		})
+		if len(response.ByPrefix) > n {
+			response.ByPrefix = response.ByPrefix[:n]
+		}
+		return c.JSON(http.StatusOK, response)
+	}
+}
+
+// namePrefix returns the family a metric name belongs to.
+func namePrefix(name string) string {
+	if i := strings.IndexAny(name, "_.:"); i > 0 {
+		return name[:i]
+	}
+	if trimmed := strings.TrimRight(name, "0123456789"); trimmed != "" {
+		return trimmed
+	}
+	return name
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCardinalityCounts(t *testing.T) {
+	puller := &mockPuller{metrics: &entity.Metrics{
+		{Name: "CPUutilization1", Type: entity.MetricTypeGauge, Value: 1.0},
+		{Name: "CPUutilization2", Type: entity.MetricTypeGauge, Value: 2.0},
+		{Name: "metricol_series", Type: entity.MetricTypeGauge, Value: 5.0},
+		{Name: "metricol_requests", Type: entity.MetricTypeCounter, Value: int64(7)},
+		{Name: "metricol_requests", Type: entity.MetricTypeGauge, Value: 7.0},
+		{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(3)},
+	}}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name:           "All prefixes",
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"total":6,"by_type":{"counter":2,"gauge":4},"by_prefix":[` +
+				`{"prefix":"metricol","count":3},{"prefix":"CPUutilization","count":2},{"prefix":"PollCount","count":1}]}`,
+		},
+		{
+			name:           "Limited prefixes",
+			query:          "?n=1",
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"total":6,"by_type":{"counter":2,"gauge":4},"by_prefix":[` +
+				`{"prefix":"metricol","count":3}]}`,
+		},
+		{
+			name:           "Invalid n",
+			query:          "?n=many",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/cardinality"+tt.query, http.NoBody)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			assert.NoError(t, CardinalityCounts(puller)(c))
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestNamePrefix(t *testing.T) {
+	tests := map[string]string{
+		"metricol_series":  "metricol",
+		"http.requests":    "http",
+		"CPUutilization12": "CPUutilization",
+		"Alloc":            "Alloc",
+		"42":               "42",
+		"_private":         "_private",
+	}
+	for name, expected := range tests {
+		assert.Equal(t, expected, namePrefix(name), name)
+	}
+}
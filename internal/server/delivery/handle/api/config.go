@@ -0,0 +1,21 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gdyunin/metricol.git/pkg/configaudit"
+	"github.com/labstack/echo/v4"
+)
+
+// Config handles requests for the server settings and where each of them came from, with secrets redacted.
+//
+// Parameters:
+//   - entries: The settings reported by the configuration audit.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the settings in JSON format.
+func Config(entries []configaudit.Entry) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, entries)
+	}
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/pkg/configaudit"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/config", http.NoBody)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	entries := []configaudit.Entry{
+		{Name: "server_address", Env: "ADDRESS", Flag: "a", Value: "localhost:8080", Source: configaudit.SourceDefault},
+		{Name: "signing_key", Env: "KEY", Flag: "k", Value: configaudit.Redacted, Source: configaudit.SourceEnv},
+	}
+	assert.NoError(t, Config(entries)(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[
+		{"name":"server_address","env":"ADDRESS","flag":"a","value":"localhost:8080","source":"default"},
+		{"name":"signing_key","env":"KEY","flag":"k","value":"[REDACTED]","source":"env"}
+	]`, rec.Body.String())
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"sort"
+
+	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/provisioning"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Provisioned defines an interface for reading the provisioning file in effect.
+type Provisioned interface {
+	// Current returns the content of the provisioning file in effect.
+	Current() *provisioning.File
+}
+
+// DashboardView is a provisioned dashboard with the current values of its metrics.
+type DashboardView struct {
+	Name   string      `json:"name"`   // Name identifies the dashboard.
+	Title  string      `json:"title"`  // Title is the human-readable dashboard title.
+	Panels []PanelView `json:"panels"` // Panels are the panels of the dashboard.
+}
+
+// PanelView is a dashboard panel with the metrics matching its patterns.
+type PanelView struct {
+	Title   string         `json:"title"`   // Title is the human-readable panel title.
+	Metrics []model.Metric `json:"metrics"` // Metrics are the stored metrics matching the panel patterns.
+}
+
+// Dashboards handles requests for all provisioned dashboards.
+//
+// Parameters:
+//   - puller: An implementation of the PullerAll interface for reading the metrics shown on panels.
+//   - provisioned: The source of the provisioning file in effect.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the dashboards in JSON format.
+func Dashboards(puller PullerAll, provisioned Provisioned) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		metrics, err := pullAll(c.Request().Context(), puller)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+
+		dashboards := provisioned.Current().Dashboards
+		views := make([]DashboardView, 0, len(dashboards))
+		for _, d := range dashboards {
+			views = append(views, dashboardView(d, metrics))
+		}
+		return c.JSON(http.StatusOK, views)
+	}
+}
+
+// Dashboard handles requests for a single provisioned dashboard identified by the :name path parameter.
+//
+// Parameters:
+//   - puller: An implementation of the PullerAll interface for reading the metrics shown on panels.
+//   - provisioned: The source of the provisioning file in effect.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the dashboard in JSON format, or 404 if it is not provisioned.
+func Dashboard(puller PullerAll, provisioned Provisioned) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		name := c.Param("name")
+		for _, d := range provisioned.Current().Dashboards {
+			if d.Name != name {
+				continue
+			}
+			metrics, err := pullAll(c.Request().Context(), puller)
+			if err != nil {
+				return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			}
+			return c.JSON(http.StatusOK, dashboardView(d, metrics))
+		}
+		return c.String(http.StatusNotFound, "Dashboard not found.")
+	}
+}
+
+// dashboardView fills the panels of a dashboard with the matching metrics.
+func dashboardView(d provisioning.Dashboard, metrics *entity.Metrics) DashboardView {
+	view := DashboardView{Name: d.Name, Title: d.Title, Panels: make([]PanelView, 0, len(d.Panels))}
+	for _, p := range d.Panels {
+		panel := PanelView{Title: p.Title, Metrics: make([]model.Metric, 0)}
+		for _, m := range *metrics {
+			if matchAny(p.Metrics, m.Name) {
+				panel.Metrics = append(panel.Metrics, *model.FromEntityMetric(m))
+			}
+		}
+		view.Panels = append(view.Panels, panel)
+	}
+	return view
+}
+
+// pullAll reads all local metrics, sorted by name and type, within the pullAllTimeout.
+func pullAll(ctx context.Context, puller PullerAll) (*entity.Metrics, error) {
+	ctx, cancel := context.WithTimeout(ctx, pullAllTimeout)
+	defer cancel()
+
+	metrics, err := puller.PullAll(ctx)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // handlers only report that reading failed.
+	}
+	if metrics == nil {
+		metrics = &entity.Metrics{}
+	}
+	sort.Slice(*metrics, func(i, j int) bool {
+		a, b := (*metrics)[i], (*metrics)[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Type < b.Type
+	})
+	return metrics, nil
+}
+
+// matchAny reports whether name matches any of the glob patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
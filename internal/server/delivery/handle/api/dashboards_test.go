@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/provisioning"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// staticProvisioned implements Provisioned for testing.
+type staticProvisioned struct {
+	file *provisioning.File
+}
+
+func (p *staticProvisioned) Current() *provisioning.File {
+	return p.file
+}
+
+func TestDashboards(t *testing.T) {
+	provisioned := &staticProvisioned{file: &provisioning.File{Dashboards: []provisioning.Dashboard{
+		{
+			Name:  "memory",
+			Title: "Memory",
+			Panels: []provisioning.Panel{
+				{Title: "Heap", Metrics: []string{"Heap*"}},
+				{Title: "Polls", Metrics: []string{"PollCount"}},
+			},
+		},
+		{Name: "empty", Title: "Empty"},
+	}}}
+	puller := &mockPuller{metrics: &entity.Metrics{
+		{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(3)},
+		{Name: "HeapInuse", Type: entity.MetricTypeGauge, Value: 2.0},
+		{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 1.5},
+		{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.0},
+	}}
+	memoryJSON := `{"name":"memory","title":"Memory","panels":[` +
+		`{"title":"Heap","metrics":[{"value":1.5,"id":"HeapAlloc","type":"gauge"},` +
+		`{"value":2,"id":"HeapInuse","type":"gauge"}]},` +
+		`{"title":"Polls","metrics":[{"delta":3,"id":"PollCount","type":"counter"}]}]}`
+
+	tests := []struct {
+		handler        func(PullerAll, Provisioned) echo.HandlerFunc
+		puller         PullerAll
+		name           string
+		param          string
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name:           "All dashboards",
+			handler:        Dashboards,
+			puller:         puller,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[` + memoryJSON + `,{"name":"empty","title":"Empty","panels":[]}]`,
+		},
+		{
+			name:           "Single dashboard",
+			handler:        Dashboard,
+			puller:         puller,
+			param:          "memory",
+			expectedStatus: http.StatusOK,
+			expectedBody:   memoryJSON,
+		},
+		{
+			name:           "Unknown dashboard",
+			handler:        Dashboard,
+			puller:         puller,
+			param:          "cpu",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "Dashboard not found.",
+		},
+		{
+			name:           "Storage failure",
+			handler:        Dashboards,
+			puller:         &mockPuller{err: errors.New("db down")},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   http.StatusText(http.StatusInternalServerError),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/dashboards", http.NoBody)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			if tt.param != "" {
+				c.SetParamNames("name")
+				c.SetParamValues(tt.param)
+			}
+
+			err := tt.handler(tt.puller, provisioned)(c)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
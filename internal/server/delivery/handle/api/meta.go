@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MetaEditor defines an interface for reading and replacing the annotations of metrics.
+type MetaEditor interface {
+	// Meta retrieves the annotations of a metric, or repository.ErrNotFoundInRepo if it has none.
+	Meta(ctx context.Context, metricType string, metricName string) (*entity.Meta, error)
+	// SetMeta replaces the annotations of a stored metric, or returns repository.ErrNotFoundInRepo
+	// if the metric is not stored.
+	SetMeta(ctx context.Context, metricType string, metricName string, meta *entity.Meta) error
+}
+
+// MetaPatch changes some annotations of a metric. Omitted fields keep their value and empty strings
+// clear them.
+type MetaPatch struct {
+	Owner   *string `json:"owner"`   // Owner is the team responsible for the metric.
+	Runbook *string `json:"runbook"` // Runbook is an http or https link to the instructions for the metric.
+	Notes   *string `json:"notes"`   // Notes is any other information about the metric.
+}
+
+// Meta handles requests for the annotations of the metric identified by the :type and :id path parameters.
+//
+// Parameters:
+//   - store: The store holding the annotations.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the annotations in JSON format, or 404 if there are none.
+func Meta(store MetaEditor) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		meta, err := store.Meta(c.Request().Context(), c.Param("type"), c.Param("id"))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFoundInRepo) {
+				return c.String(http.StatusNotFound, "The metric has no annotations.")
+			}
+			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+		return c.JSON(http.StatusOK, meta)
+	}
+}
+
+// PatchMeta handles requests changing the annotations of the metric identified by the :type and :id
+// path parameters. The body is a MetaPatch; fields it omits keep their current value.
+//
+// Parameters:
+//   - store: The store holding the annotations.
+//   - now: The source of the time stamped on changed annotations.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the resulting annotations in JSON format, 400 for an invalid
+//     body or runbook link, or 404 if the metric is not stored.
+func PatchMeta(store MetaEditor, now func() time.Time) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var patch MetaPatch
+		if err := c.Bind(&patch); err != nil {
+			return c.String(http.StatusBadRequest, "Invalid annotations.")
+		}
+		if patch.Runbook != nil && *patch.Runbook != "" && !isWebLink(*patch.Runbook) {
+			return c.String(http.StatusBadRequest, "Runbook must be an http or https link.")
+		}
+
+		ctx := c.Request().Context()
+		metricType, name := c.Param("type"), c.Param("id")
+		meta, err := store.Meta(ctx, metricType, name)
+		if err != nil {
+			if !errors.Is(err, repository.ErrNotFoundInRepo) {
+				return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			}
+			meta = &entity.Meta{}
+		}
+
+		if patch.Owner != nil {
+			meta.Owner = *patch.Owner
+		}
+		if patch.Runbook != nil {
+			meta.Runbook = *patch.Runbook
+		}
+		if patch.Notes != nil {
+			meta.Notes = *patch.Notes
+		}
+		meta.UpdatedAt = now().UTC()
+
+		if err = store.SetMeta(ctx, metricType, name, meta); err != nil {
+			if errors.Is(err, repository.ErrNotFoundInRepo) {
+				return c.String(http.StatusNotFound, "Metric not found.")
+			}
+			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+		return c.JSON(http.StatusOK, meta)
+	}
+}
+
+// isWebLink reports whether link is an absolute http or https URL.
+func isWebLink(link string) bool {
+	u, err := url.Parse(link)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
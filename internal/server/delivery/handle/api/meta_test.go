@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockMetaEditor implements MetaEditor for testing.
+type mockMetaEditor struct {
+	meta   map[string]entity.Meta
+	stored map[string]bool
+	err    error
+}
+
+func (m *mockMetaEditor) Meta(_ context.Context, metricType, name string) (*entity.Meta, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	meta, ok := m.meta[metricType+"/"+name]
+	if !ok {
+		return nil, repository.ErrNotFoundInRepo
+	}
+	return &meta, nil
+}
+
+func (m *mockMetaEditor) SetMeta(_ context.Context, metricType, name string, meta *entity.Meta) error {
+	if !m.stored[metricType+"/"+name] {
+		return repository.ErrNotFoundInRepo
+	}
+	m.meta[metricType+"/"+name] = *meta
+	return nil
+}
+
+func newMockMetaEditor() *mockMetaEditor {
+	return &mockMetaEditor{
+		meta: map[string]entity.Meta{
+			"gauge/HeapAlloc": {Owner: "platform", Notes: "heap", UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		stored: map[string]bool{"gauge/HeapAlloc": true, "counter/PollCount": true},
+	}
+}
+
+func TestMeta(t *testing.T) {
+	tests := []struct {
+		store          MetaEditor
+		name           string
+		metric         string
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name:           "Annotated metric",
+			store:          newMockMetaEditor(),
+			metric:         "HeapAlloc",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"updated_at":"2024-01-01T00:00:00Z","owner":"platform","notes":"heap"}`,
+		},
+		{
+			name:           "No annotations",
+			store:          newMockMetaEditor(),
+			metric:         "Alloc",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Storage error",
+			store:          &mockMetaEditor{err: errors.New("storage error")},
+			metric:         "HeapAlloc",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/metrics/gauge/"+tt.metric+"/meta", http.NoBody)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("type", "id")
+			c.SetParamValues("gauge", tt.metric)
+
+			assert.NoError(t, Meta(tt.store)(c))
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestPatchMeta(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		metricType     string
+		metric         string
+		body           string
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name:           "Merge into existing annotations",
+			metricType:     "gauge",
+			metric:         "HeapAlloc",
+			body:           `{"runbook":"https://wiki.example.com/heap","notes":""}`,
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"updated_at":"2024-05-01T12:00:00Z","owner":"platform",` +
+				`"runbook":"https://wiki.example.com/heap"}`,
+		},
+		{
+			name:           "First annotations",
+			metricType:     "counter",
+			metric:         "PollCount",
+			body:           `{"owner":"agents"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"updated_at":"2024-05-01T12:00:00Z","owner":"agents"}`,
+		},
+		{
+			name:           "Metric not stored",
+			metricType:     "gauge",
+			metric:         "Alloc",
+			body:           `{"owner":"platform"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Runbook is not a link",
+			metricType:     "gauge",
+			metric:         "HeapAlloc",
+			body:           `{"runbook":"javascript:alert(1)"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid JSON",
+			metricType:     "gauge",
+			metric:         "HeapAlloc",
+			body:           `{"owner":`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(
+				http.MethodPatch,
+				"/api/metrics/"+tt.metricType+"/"+tt.metric+"/meta",
+				strings.NewReader(tt.body),
+			)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("type", "id")
+			c.SetParamValues(tt.metricType, tt.metric)
+
+			assert.NoError(t, PatchMeta(newMockMetaEditor(), func() time.Time { return now })(c))
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
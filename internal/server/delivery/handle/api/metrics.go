@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/delivery/federation"
+	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// pullAllTimeout bounds reading the local metrics.
+	pullAllTimeout = 5 * time.Second
+	// sourceOK is the state of a source whose metrics are listed.
+	sourceOK = "ok"
+)
+
+// PullerAll defines an interface for retrieving all metrics.
+type PullerAll interface {
+	// PullAll retrieves all metrics from the repository or other storage.
+	PullAll(context.Context) (*entity.Metrics, error)
+}
+
+// PeerFetcher defines an interface for reading the metrics of federation peers.
+type PeerFetcher interface {
+	// Fetch reads the metrics of every peer.
+	Fetch(ctx context.Context) []federation.Result
+}
+
+// Metrics handles requests for all metrics, labeled with the server holding them. With federation peers,
+// their metrics are listed too unless the scope=local query parameter is set; a peer that cannot be read
+// is reported in the sources instead of failing the request.
+//
+// Parameters:
+//   - puller: An implementation of the PullerAll interface for fetching the local metrics.
+//   - source: The name labeling the local metrics.
+//   - peers: The fetcher of the federation peers; nil if federation is disabled.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the metrics in JSON format.
+func Metrics(puller PullerAll, source string, peers PeerFetcher) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), pullAllTimeout)
+		defer cancel()
+
+		local, err := puller.PullAll(ctx)
+		if err != nil || local == nil {
+			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+
+		response := model.FederatedMetrics{
+			Sources: map[string]string{source: sourceOK},
+			Metrics: make([]model.SourcedMetric, 0, local.Length()),
+		}
+		for _, m := range *model.FromEntityMetrics(local) {
+			response.Metrics = append(response.Metrics, model.SourcedMetric{Metric: *m, Source: source})
+		}
+
+		if peers == nil || c.QueryParam(federation.ScopeParam) == federation.ScopeLocal {
+			return c.JSON(http.StatusOK, response)
+		}
+
+		for _, result := range peers.Fetch(ctx) {
+			if result.Err != nil {
+				response.Sources[result.Peer] = result.Err.Error()
+				continue
+			}
+			response.Sources[result.Peer] = sourceOK
+			response.Metrics = append(response.Metrics, result.Metrics...)
+		}
+		return c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/delivery/federation"
+	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPuller struct {
+	metrics *entity.Metrics
+	err     error
+}
+
+func (m *mockPuller) PullAll(context.Context) (*entity.Metrics, error) {
+	return m.metrics, m.err
+}
+
+type mockPeers struct {
+	results []federation.Result
+}
+
+func (m *mockPeers) Fetch(context.Context) []federation.Result {
+	return m.results
+}
+
+func TestMetrics(t *testing.T) {
+	local := &mockPuller{metrics: &entity.Metrics{
+		{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(3)},
+	}}
+	value := 2.5
+	peers := &mockPeers{results: []federation.Result{
+		{
+			Peer: "eu",
+			Metrics: []model.SourcedMetric{
+				{Metric: model.Metric{ID: "Alloc", MType: "gauge", Value: &value}, Source: "eu"},
+			},
+		},
+		{Peer: "us", Err: errors.New("request failed")},
+	}}
+
+	tests := []struct {
+		puller         PullerAll
+		peers          PeerFetcher
+		name           string
+		query          string
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name:           "Local only",
+			puller:         local,
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"sources":{"main":"ok"},` +
+				`"metrics":[{"id":"PollCount","type":"counter","delta":3,"source":"main"}]}`,
+		},
+		{
+			name:           "Federated",
+			puller:         local,
+			peers:          peers,
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"sources":{"main":"ok","eu":"ok","us":"request failed"},"metrics":[` +
+				`{"id":"PollCount","type":"counter","delta":3,"source":"main"},` +
+				`{"id":"Alloc","type":"gauge","value":2.5,"source":"eu"}]}`,
+		},
+		{
+			name:           "Local scope skips peers",
+			puller:         local,
+			peers:          peers,
+			query:          "?scope=local",
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"sources":{"main":"ok"},` +
+				`"metrics":[{"id":"PollCount","type":"counter","delta":3,"source":"main"}]}`,
+		},
+		{
+			name:           "Local storage error",
+			puller:         &mockPuller{err: errors.New("storage down")},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/metrics"+tt.query, http.NoBody)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			assert.NoError(t, Metrics(tt.puller, "main", tt.peers)(c))
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/history"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Const defaultRateWindow is the window used when a rate request does not set one.
+const defaultRateWindow = 5 * time.Minute
+
+// CounterHistory defines an interface for reading recent counter samples.
+type CounterHistory interface {
+	// Range returns the samples of the named counter recorded at or after from, oldest first.
+	Range(name string, from time.Time) []history.Sample
+	// Retention returns how long samples are kept.
+	Retention() time.Duration
+}
+
+// CounterRate is the per-second rate of a counter over a window.
+type CounterRate struct {
+	Name          string    `json:"name"`           // Name identifies the counter.
+	WindowSeconds float64   `json:"window_seconds"` // WindowSeconds is the requested window length.
+	Rate          float64   `json:"rate"`           // Rate is the average increase per second between the samples.
+	Increase      float64   `json:"increase"`       // Increase is the total increase between the samples, resets included.
+	Resets        int       `json:"resets"`         // Resets is the number of counter resets detected in the window.
+	Samples       int       `json:"samples"`        // Samples is the number of samples the rate is computed from.
+	From          time.Time `json:"from"`           // From is the time of the first sample.
+	To            time.Time `json:"to"`             // To is the time of the last sample.
+}
+
+// Rate handles requests for the per-second rate of the counter identified by the :name path parameter.
+// The window query parameter is a Go duration such as 30s or 5m, 5m by default, and cannot exceed
+// the history retention. A value lower than the previous sample is treated as a counter reset.
+//
+// Parameters:
+//   - h: The history of counter samples.
+//   - now: The source of the current time.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the rate in JSON format, 400 for an invalid window,
+//     404 if the counter has no samples in the window, or 422 if it has fewer than two.
+func Rate(h CounterHistory, now func() time.Time) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		window := defaultRateWindow
+		if raw := c.QueryParam("window"); raw != "" {
+			parsed, err := time.ParseDuration(raw)
+			if err != nil || parsed <= 0 {
+				return c.String(http.StatusBadRequest, "Window must be a positive duration.")
+			}
+			window = parsed
+		}
+		if window > h.Retention() {
+			return c.String(http.StatusBadRequest, "Window exceeds the history retention of "+h.Retention().String()+".")
+		}
+
+		name := c.Param("name")
+		samples := h.Range(name, now().Add(-window))
+		if len(samples) == 0 {
+			return c.String(http.StatusNotFound, "No samples of the counter in the window.")
+		}
+		rate, ok := history.ComputeRate(samples)
+		if !ok {
+			return c.String(http.StatusUnprocessableEntity, "At least two samples are required to compute a rate.")
+		}
+
+		return c.JSON(http.StatusOK, CounterRate{
+			Name:          name,
+			WindowSeconds: window.Seconds(),
+			Rate:          rate.PerSec,
+			Increase:      rate.Increase,
+			Resets:        rate.Resets,
+			Samples:       len(samples),
+			From:          rate.From,
+			To:            rate.To,
+		})
+	}
+}
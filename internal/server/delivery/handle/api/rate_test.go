@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/history"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockCounterHistory struct {
+	samples   []history.Sample
+	retention time.Duration
+}
+
+func (m *mockCounterHistory) Range(_ string, from time.Time) []history.Sample {
+	var out []history.Sample
+	for _, s := range m.samples {
+		if !s.Time.Before(from) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func (m *mockCounterHistory) Retention() time.Duration {
+	return m.retention
+}
+
+func TestRate(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	h := &mockCounterHistory{
+		samples: []history.Sample{
+			{Time: now.Add(-10 * time.Minute), Value: 1},
+			{Time: now.Add(-30 * time.Second), Value: 10},
+			{Time: now.Add(-20 * time.Second), Value: 30},
+			{Time: now.Add(-10 * time.Second), Value: 5},
+			{Time: now, Value: 25},
+		},
+		retention: time.Hour,
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Rate with reset",
+			query:        "?window=1m",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"name":"PollCount","window_seconds":60,"rate":1.5,"increase":45,"resets":1,"samples":4,` +
+				`"from":"2024-05-01T11:59:30Z","to":"2024-05-01T12:00:00Z"}`,
+		},
+		{
+			name:         "Default window",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"name":"PollCount","window_seconds":300,"rate":1.5,"increase":45,"resets":1,"samples":4,` +
+				`"from":"2024-05-01T11:59:30Z","to":"2024-05-01T12:00:00Z"}`,
+		},
+		{
+			name:         "Single sample",
+			query:        "?window=5s",
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Invalid window",
+			query:        "?window=soon",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Window beyond retention",
+			query:        "?window=2h",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/rate/PollCount"+tt.query, http.NoBody)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("name")
+			c.SetParamValues("PollCount")
+
+			assert.NoError(t, Rate(h, func() time.Time { return now })(c))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+			}
+		})
+	}
+
+	t.Run("No samples", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/api/rate/missing", http.NoBody)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		assert.NoError(t, Rate(&mockCounterHistory{retention: time.Hour}, time.Now)(c))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ServerTime is the server clock at the moment a request was handled.
+type ServerTime struct {
+	Time     time.Time `json:"time"`      // Time is the server time in RFC 3339 format with nanoseconds.
+	UnixNano int64     `json:"unix_nano"` // UnixNano is the server time in nanoseconds since the Unix epoch.
+}
+
+// Time handles requests for the server clock, which agents compare with their own clocks to measure drift.
+// Unlike the Date header, the response carries sub-second precision.
+//
+// Parameters:
+//   - now: The source of the current time.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the current server time in JSON format.
+func Time(now func() time.Time) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		t := now().UTC()
+		return c.JSON(http.StatusOK, ServerTime{Time: t, UnixNano: t.UnixNano()})
+	}
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTime(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/time", http.NoBody)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	now := time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.FixedZone("UTC+3", 3*60*60))
+	assert.NoError(t, Time(func() time.Time { return now })(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(
+		t,
+		`{"time":"2024-05-01T09:30:00.123456789Z","unix_nano":1714555800123456789}`,
+		rec.Body.String(),
+	)
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// Const TopByValue orders /api/top by the numeric value of counters and gauges, largest first.
+	TopByValue = "value"
+	// Const TopByRecent orders /api/top by the time of the last update, most recent first.
+	TopByRecent = "recent"
+	// Const defaultTopN is the number of metrics listed when a top request does not set n.
+	defaultTopN = 20
+	// Const maxTopN bounds the number of metrics a single top request can list.
+	maxTopN = 1000
+)
+
+// Top handles requests for the largest or most recently updated metrics. The by query parameter selects
+// the order, "value" by default or "recent"; n sets how many metrics are listed, 20 by default, and type
+// restricts the listing to a single metric type. Info metrics have no numeric value and are never listed
+// by value.
+//
+// Parameters:
+//   - puller: An implementation of the PullerAll interface for reading the metrics.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the metrics in JSON format, or 400 for invalid parameters.
+func Top(puller PullerAll) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		by := c.QueryParam("by")
+		if by == "" {
+			by = TopByValue
+		}
+		if by != TopByValue && by != TopByRecent {
+			return c.String(http.StatusBadRequest, "Order must be \"value\" or \"recent\".")
+		}
+		n, err := parseLimit(c.QueryParam("n"), defaultTopN, maxTopN)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "N must be a positive integer.")
+		}
+		metricType := c.QueryParam("type")
+
+		metrics, err := pullAll(c.Request().Context(), puller)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+
+		selected := make(entity.Metrics, 0, len(*metrics))
+		values := make(map[*entity.Metric]float64, len(*metrics))
+		for _, m := range *metrics {
+			if metricType != "" && m.Type != metricType {
+				continue
+			}
+			if by == TopByValue {
+				v, ok := m.Float64()
+				if !ok {
+					continue
+				}
+				values[m] = v
+			}
+			selected = append(selected, m)
+		}
+
+		// The metrics are sorted by name already, so ties keep a stable order.
+		sort.SliceStable(selected, func(i, j int) bool {
+			if by == TopByRecent {
+				return selected[i].Timestamp.After(selected[j].Timestamp)
+			}
+			return values[selected[i]] > values[selected[j]]
+		})
+		if len(selected) > n {
+			selected = selected[:n]
+		}
+		return c.JSON(http.StatusOK, model.FromEntityMetrics(&selected))
+	}
+}
+
+// parseLimit parses a positive count query parameter, capped at maxLimit.
+// An empty value yields defaultLimit.
+func parseLimit(raw string, defaultLimit, maxLimit int) (int, error) {
+	if raw == "" {
+		return defaultLimit, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, err //nolint:wrapcheck // handlers only report that the parameter is invalid.
+	}
+	if n <= 0 {
+		return 0, strconv.ErrRange
+	}
+	return min(n, maxLimit), nil
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTop(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	puller := &mockPuller{metrics: &entity.Metrics{
+		{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(300), Timestamp: now.Add(-time.Minute)},
+		{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 1024.5, Timestamp: now.Add(-time.Hour)},
+		{Name: "RandomValue", Type: entity.MetricTypeGauge, Value: 0.5, Timestamp: now},
+		{Name: "Version", Type: entity.MetricTypeInfo, Value: "1.2.3", Timestamp: now.Add(time.Second)},
+	}}
+
+	tests := []struct {
+		puller         PullerAll
+		name           string
+		query          string
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name:           "By value",
+			puller:         puller,
+			expectedStatus: http.StatusOK,
+			expectedBody: `[{"value":1024.5,"timestamp":"2024-05-01T11:00:00Z","id":"HeapAlloc","type":"gauge"},` +
+				`{"delta":300,"timestamp":"2024-05-01T11:59:00Z","id":"PollCount","type":"counter"},` +
+				`{"value":0.5,"timestamp":"2024-05-01T12:00:00Z","id":"RandomValue","type":"gauge"}]`,
+		},
+		{
+			name:           "By value limited to gauges",
+			puller:         puller,
+			query:          "?type=gauge&n=1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"value":1024.5,"timestamp":"2024-05-01T11:00:00Z","id":"HeapAlloc","type":"gauge"}]`,
+		},
+		{
+			name:           "By recent",
+			puller:         puller,
+			query:          "?by=recent&n=2",
+			expectedStatus: http.StatusOK,
+			expectedBody: `[{"info":"1.2.3","timestamp":"2024-05-01T12:00:01Z","id":"Version","type":"info"},` +
+				`{"value":0.5,"timestamp":"2024-05-01T12:00:00Z","id":"RandomValue","type":"gauge"}]`,
+		},
+		{
+			name:           "Unknown order",
+			puller:         puller,
+			query:          "?by=size",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid n",
+			puller:         puller,
+			query:          "?n=0",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Repository error",
+			puller:         &mockPuller{err: errors.New("repository error")},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/top"+tt.query, http.NoBody)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			assert.NoError(t, Top(tt.puller)(c))
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
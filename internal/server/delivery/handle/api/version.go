@@ -0,0 +1,23 @@
+// Package api provides HTTP handlers for the /api routes, which describe the server and its data
+// to clients and tools rather than storing or reading single metrics.
+package api
+
+import (
+	"net/http"
+
+	"github.com/gdyunin/metricol.git/pkg/buildinfo"
+	"github.com/labstack/echo/v4"
+)
+
+// Version handles requests for the build info of the server.
+//
+// Parameters:
+//   - info: The build info of the server.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the build info in JSON format.
+func Version(info buildinfo.Info) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, info)
+	}
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/pkg/buildinfo"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersion(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/version", http.NoBody)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	assert.NoError(t, Version(buildinfo.New("1.2.0", "2024-05-01", "abc123"))(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get(echo.HeaderContentType), echo.MIMEApplicationJSON)
+	assert.JSONEq(
+		t,
+		`{"version":"1.2.0","date":"2024-05-01","commit":"abc123","go_version":"`+runtime.Version()+`"}`,
+		rec.Body.String(),
+	)
+}
@@ -0,0 +1,29 @@
+// Package debug provides HTTP handlers exposing internal server state for troubleshooting and tuning.
+package debug
+
+import (
+	"net/http"
+
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/labstack/echo/v4"
+)
+
+// PoolStatsReporter defines the interface for reporting database connection pool statistics.
+type PoolStatsReporter interface {
+	PoolStats() map[string]repository.PoolStats
+}
+
+// DBStats handles requests for the database connection pool statistics.
+// The response maps every pool name, e.g. "primary" or "replica", to its statistics.
+//
+// Parameters:
+//   - reporter: An implementation of PoolStatsReporter to read the statistics.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the pool statistics in JSON format.
+func DBStats(reporter PoolStatsReporter) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		return c.JSON(http.StatusOK, reporter.PoolStats())
+	}
+}
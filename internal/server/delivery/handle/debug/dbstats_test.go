@@ -0,0 +1,41 @@
+package debug
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockPoolStatsReporter implements PoolStatsReporter for testing.
+type mockPoolStatsReporter struct {
+	stats map[string]repository.PoolStats
+}
+
+func (m *mockPoolStatsReporter) PoolStats() map[string]repository.PoolStats {
+	return m.stats
+}
+
+func TestDBStats(t *testing.T) {
+	reporter := &mockPoolStatsReporter{stats: map[string]repository.PoolStats{
+		"primary": {MaxOpenConnections: 10, OpenConnections: 3, InUse: 1, Idle: 2, WaitCount: 5, WaitDurationSeconds: 1.5},
+	}}
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/debug/dbstats", http.NoBody)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	assert.NoError(t, DBStats(reporter)(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, echo.MIMEApplicationJSON, rec.Header().Get(echo.HeaderContentType))
+	assert.JSONEq(t, `{"primary":{
+		"max_open_connections":10,"open_connections":3,"in_use":1,"idle":2,
+		"wait_count":5,"wait_duration_seconds":1.5,
+		"max_idle_closed":0,"max_idle_time_closed":0,"max_lifetime_closed":0
+	}}`, strings.TrimSpace(rec.Body.String()))
+}
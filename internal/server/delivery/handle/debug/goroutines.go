@@ -0,0 +1,29 @@
+package debug
+
+import (
+	"net/http"
+
+	"github.com/gdyunin/metricol.git/pkg/goroutines"
+	"github.com/labstack/echo/v4"
+)
+
+// GoroutineSampler defines the interface for sampling the running goroutines.
+type GoroutineSampler interface {
+	Sample() goroutines.Sample
+}
+
+// Goroutines handles requests for the running goroutines, grouped by their function and by the function
+// that started them. Every request takes a new sample and diffs it against the one taken by the previous
+// request, so the groups that keep growing between two requests come first.
+//
+// Parameters:
+//   - sampler: An implementation of GoroutineSampler to take the samples.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the sample in JSON format.
+func Goroutines(sampler GoroutineSampler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		return c.JSON(http.StatusOK, sampler.Sample())
+	}
+}
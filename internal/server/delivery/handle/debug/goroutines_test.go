@@ -0,0 +1,39 @@
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/pkg/clock"
+	"github.com/gdyunin/metricol.git/pkg/goroutines"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoroutines(t *testing.T) {
+	handler := Goroutines(goroutines.NewSampler(clock.Real()))
+	e := echo.New()
+
+	get := func() goroutines.Sample {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/debug/goroutines", http.NoBody), rec)
+		require.NoError(t, handler(c))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, echo.MIMEApplicationJSON, rec.Header().Get(echo.HeaderContentType))
+
+		var sample goroutines.Sample
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sample))
+		return sample
+	}
+
+	first := get()
+	assert.Nil(t, first.Since)
+	assert.Positive(t, first.Total)
+	assert.NotEmpty(t, first.Groups)
+
+	second := get()
+	assert.NotNil(t, second.Since, "the second sample is diffed against the first")
+}
@@ -0,0 +1,48 @@
+package debug
+
+import (
+	"net/http"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/reqrecord"
+	"github.com/labstack/echo/v4"
+)
+
+// RequestLister defines the interface for reading recorded requests.
+type RequestLister interface {
+	List() []reqrecord.Record
+	Find(id string) (reqrecord.Record, bool)
+}
+
+// Requests handles requests for the recorded requests and responses, most recent first.
+//
+// Parameters:
+//   - lister: An implementation of RequestLister to read the records.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the records in JSON format.
+func Requests(lister RequestLister) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		return c.JSON(http.StatusOK, lister.List())
+	}
+}
+
+// Request handles requests for a single recorded request, looked up by the request ID in the path.
+// It responds with 404 Not Found if no record has the ID.
+//
+// Parameters:
+//   - lister: An implementation of RequestLister to read the records.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the record in JSON format.
+func Request(lister RequestLister) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		record, ok := lister.Find(c.Param("id"))
+		if !ok {
+			return c.String(http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		return c.JSON(http.StatusOK, record)
+	}
+}
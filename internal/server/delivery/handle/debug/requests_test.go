@@ -0,0 +1,67 @@
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/reqrecord"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequests(t *testing.T) {
+	buffer := reqrecord.NewBuffer(10)
+	buffer.Add(reqrecord.Record{ID: "first", Method: http.MethodPost, Path: "/update", Status: http.StatusOK})
+	buffer.Add(reqrecord.Record{ID: "second", Method: http.MethodPost, Path: "/updates", Status: http.StatusBadRequest})
+
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/debug/requests", http.NoBody), rec)
+
+	require.NoError(t, Requests(buffer)(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var records []reqrecord.Record
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &records))
+	require.Len(t, records, 2)
+	assert.Equal(t, "second", records[0].ID)
+	assert.Equal(t, "first", records[1].ID)
+}
+
+func TestRequest(t *testing.T) {
+	buffer := reqrecord.NewBuffer(10)
+	buffer.Add(reqrecord.Record{ID: "known", Method: http.MethodPost, Path: "/update", RequestBody: "{}"})
+
+	tests := []struct {
+		name         string
+		id           string
+		expectedCode int
+	}{
+		{name: "Known request", id: "known", expectedCode: http.StatusOK},
+		{name: "Unknown request", id: "unknown", expectedCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/debug/requests/"+tt.id, http.NoBody), rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+
+			require.NoError(t, Request(buffer)(c))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var record reqrecord.Record
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &record))
+			assert.Equal(t, "known", record.ID)
+			assert.Equal(t, "{}", record.RequestBody)
+		})
+	}
+}
@@ -4,19 +4,40 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/gdyunin/metricol.git/pkg/clock"
 
 	"github.com/labstack/echo/v4"
 )
 
-const pullAllTimeout = 5 * time.Second
+const (
+	pullAllTimeout = 5 * time.Second
+	// Const defaultPerPage is the number of metrics on a page of the main page unless requested otherwise.
+	defaultPerPage = 100
+	// Const maxPerPage caps the number of metrics on a page of the main page.
+	maxPerPage = 1000
+	// Const pageParam is the query parameter selecting the page, starting at 1.
+	pageParam = "page"
+	// Const perPageParam is the query parameter setting the number of metrics per page.
+	perPageParam = "per_page"
+	// Const sortParam is the query parameter setting the order of the metrics.
+	sortParam = "sort"
+)
+
+// clk provides the request timeouts; tests replace it with a fake clock.
+var clk = clock.Real()
 
 // tr represents a table row with a metric name and value.
 type tr struct {
 	Name  string // Name of the metric.
+	Type  string // Type of the metric, used to link the row to the detail page.
 	Value string // Value of the metric as a string.
+	Info  bool   // Info marks rows of info metrics, which are shown apart from measurements.
 }
 
 // PullerAll defines an interface for retrieving all metrics.
@@ -25,42 +46,146 @@ type PullerAll interface {
 	PullAll(context.Context) (*entity.Metrics, error)
 }
 
-// MainPage returns an HTTP handler function that renders the main page with metrics.
+// PullerPage defines an interface for retrieving a page of metrics.
+type PullerPage interface {
+	// PullPage retrieves one page of metrics in a stable order.
+	PullPage(context.Context, repository.PageQuery) (*repository.Page, error)
+}
+
+// mainPage is the data rendered into the main page.
+type mainPage struct {
+	Rows    []*tr  // Rows are the metrics of the page.
+	Sort    string // Sort is the order of the metrics, repository.SortByName or repository.SortByType.
+	Prev    string // Prev links to the previous page, empty on the first one.
+	Next    string // Next links to the next page, empty on the last one.
+	ByName  string // ByName links to the first page sorted by name.
+	ByType  string // ByType links to the first page sorted by type.
+	Page    int    // Page is the number of the page, starting at 1.
+	Pages   int    // Pages is the number of pages, at least 1.
+	PerPage int    // PerPage is the number of metrics per page.
+	Total   int    // Total is the number of metrics across all pages.
+}
+
+// MainPage returns an HTTP handler function that renders one page of the metrics on the main page.
+// The page is selected with the query parameters page (starting at 1), per_page (up to maxPerPage)
+// and sort ("name" or "type"), so the page stays usable with hundreds of thousands of metrics.
+// Invalid parameters are answered with 400 Bad Request.
 //
 // Parameters:
-//   - puller: An implementation of the PullerAll interface for fetching all metrics.
+//   - puller: An implementation of the PullerPage interface for fetching a page of metrics.
 //
 // Returns:
 //   - An echo.HandlerFunc that handles HTTP requests for the main page.
-func MainPage(puller PullerAll) echo.HandlerFunc {
+func MainPage(puller PullerPage) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		// Attempt to fetch all metrics.
-		// If an error occurs or the result is nil, respond with 500 Internal Server Error.
+		page, perPage, sortBy, err := parsePageParams(c)
+		if err != nil {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
 
-		ctx, cancel := context.WithTimeout(c.Request().Context(), pullAllTimeout)
+		ctx, cancel := clk.WithTimeout(c.Request().Context(), pullAllTimeout)
 		defer cancel()
 
-		allMetrics, err := puller.PullAll(ctx)
-		if err != nil || allMetrics == nil {
+		// If an error occurs or the result is nil, respond with 500 Internal Server Error.
+		metrics, err := puller.PullPage(ctx, repository.PageQuery{
+			SortBy: sortBy,
+			Offset: (page - 1) * perPage,
+			Limit:  perPage,
+		})
+		if err != nil || metrics == nil {
 			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
 		}
 
-		// Initialize a slice to store table rows, pre-allocated to the number of metrics for efficiency.
-		table := make([]*tr, 0, allMetrics.Length())
-
-		// Iterate through all metrics to transform them into table rows.
-		for _, metric := range *allMetrics {
-			// Extract the name and value of the metric. Use fmt.Sprint to safely convert the value to a string.
-			name := metric.Name
-			value := fmt.Sprint(metric.Value)
+		data := mainPage{
+			Rows:    tableRows(&metrics.Metrics),
+			Sort:    sortBy,
+			ByName:  pageURL(1, perPage, repository.SortByName),
+			ByType:  pageURL(1, perPage, repository.SortByType),
+			Page:    page,
+			Pages:   max((metrics.Total+perPage-1)/perPage, 1),
+			PerPage: perPage,
+			Total:   metrics.Total,
+		}
+		if page > 1 {
+			data.Prev = pageURL(min(page-1, data.Pages), perPage, sortBy)
+		}
+		if page < data.Pages {
+			data.Next = pageURL(page+1, perPage, sortBy)
+		}
+		return c.Render(http.StatusOK, "main_page.html", data)
+	}
+}
 
-			// Append a new row to the table with the metric's name and value.
-			table = append(table, &tr{
-				Name:  name,
-				Value: value,
-			})
+// parsePageParams reads the page, the page size and the order from the query parameters.
+//
+// Parameters:
+//   - c: The request context.
+//
+// Returns:
+//   - int: The page number, starting at 1.
+//   - int: The number of metrics per page.
+//   - string: The order of the metrics.
+//   - error: An error describing the first invalid parameter.
+func parsePageParams(c echo.Context) (int, int, string, error) {
+	page, perPage, sortBy := 1, defaultPerPage, repository.SortByName
+	if raw := c.QueryParam(pageParam); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return 0, 0, "", fmt.Errorf("%s must be a positive integer", pageParam)
+		}
+		page = n
+	}
+	if raw := c.QueryParam(perPageParam); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxPerPage {
+			return 0, 0, "", fmt.Errorf("%s must be an integer from 1 to %d", perPageParam, maxPerPage)
 		}
+		perPage = n
+	}
+	if raw := c.QueryParam(sortParam); raw != "" {
+		if raw != repository.SortByName && raw != repository.SortByType {
+			return 0, 0, "", fmt.Errorf("%s must be %q or %q", sortParam, repository.SortByName, repository.SortByType)
+		}
+		sortBy = raw
+	}
+	return page, perPage, sortBy, nil
+}
+
+// pageURL builds the link to a page of the main page.
+//
+// Parameters:
+//   - page: The page number.
+//   - perPage: The number of metrics per page.
+//   - sortBy: The order of the metrics.
+//
+// Returns:
+//   - string: The link.
+func pageURL(page, perPage int, sortBy string) string {
+	query := url.Values{}
+	query.Set(pageParam, strconv.Itoa(page))
+	query.Set(perPageParam, strconv.Itoa(perPage))
+	query.Set(sortParam, sortBy)
+	return "/?" + query.Encode()
+}
+
+// tableRows transforms metrics into table rows.
+func tableRows(metrics *entity.Metrics) []*tr {
+	// Initialize a slice to store table rows, pre-allocated to the number of metrics for efficiency.
+	table := make([]*tr, 0, metrics.Length())
+
+	// Iterate through all metrics to transform them into table rows.
+	for _, metric := range *metrics {
+		// Extract the name and value of the metric. Use fmt.Sprint to safely convert the value to a string.
+		name := metric.Name
+		value := fmt.Sprint(metric.Value)
 
-		return c.Render(http.StatusOK, "main_page.html", table)
+		// Append a new row to the table with the metric's name and value.
+		table = append(table, &tr{
+			Name:  name,
+			Type:  metric.Type,
+			Value: value,
+			Info:  metric.Type == entity.MetricTypeInfo,
+		})
 	}
+	return table
 }
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -39,7 +40,7 @@ type MockPullerAll struct {
 func (m *MockPullerAll) PullAll(ctx context.Context) (*entity.Metrics, error) {
 	if m.Delay > 0 {
 		select {
-		case <-time.After(m.Delay):
+		case <-clk.After(m.Delay):
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		}
@@ -51,9 +52,18 @@ func (m *MockPullerAll) PullAll(ctx context.Context) (*entity.Metrics, error) {
 	return m.Metrics, nil
 }
 
+// PullPage implements the PullerPage interface by cutting the page out of PullAll.
+func (m *MockPullerAll) PullPage(ctx context.Context, query repository.PageQuery) (*repository.Page, error) {
+	metrics, err := m.PullAll(ctx)
+	if err != nil || metrics == nil {
+		return nil, err
+	}
+	return repository.PageOf(*metrics, query), nil
+}
+
 func TestMainPage(t *testing.T) {
 	tests := []struct {
-		puller         PullerAll
+		puller         PullerPage
 		name           string
 		expectedStatus int
 		expectedRows   int
@@ -71,6 +81,18 @@ func TestMainPage(t *testing.T) {
 			checkTemplate:  true,
 			expectedRows:   2,
 		},
+		{
+			name: "Success with info metric",
+			puller: &MockPullerAll{
+				Metrics: &entity.Metrics{
+					&entity.Metric{Name: "metric1", Type: entity.MetricTypeGauge, Value: 20.5},
+					&entity.Metric{Name: "version", Type: entity.MetricTypeInfo, Value: "v1.2.3"},
+				},
+			},
+			expectedStatus: http.StatusOK,
+			checkTemplate:  true,
+			expectedRows:   2,
+		},
 		{
 			name: "Success with one metric",
 			puller: &MockPullerAll{
@@ -138,6 +160,7 @@ func TestMainPage(t *testing.T) {
 			c := e.NewContext(req, rec)
 
 			// Execute handler.
+			advanceClock(t, tt.puller.(*MockPullerAll).Delay, pullAllTimeout)
 			handler := MainPage(tt.puller)
 			err := handler(c)
 
@@ -149,8 +172,9 @@ func TestMainPage(t *testing.T) {
 			if tt.checkTemplate {
 				assert.True(t, templateCalled, "Template should have been rendered")
 				if templateData != nil {
-					tableRows, ok := templateData.([]*tr)
-					require.True(t, ok, "Template data should be []*tr")
+					page, ok := templateData.(mainPage)
+					require.True(t, ok, "Template data should be mainPage")
+					tableRows := page.Rows
 					assert.Len(t, tableRows, tt.expectedRows)
 
 					// If we have metrics to check, verify they were passed correctly.
@@ -159,6 +183,7 @@ func TestMainPage(t *testing.T) {
 						for i, metric := range *metrics {
 							if i < len(tableRows) {
 								assert.Equal(t, metric.Name, tableRows[i].Name)
+								assert.Equal(t, metric.Type == entity.MetricTypeInfo, tableRows[i].Info)
 							}
 						}
 					}
@@ -171,6 +196,88 @@ func TestMainPage(t *testing.T) {
 	}
 }
 
+func TestMainPage_Pagination(t *testing.T) {
+	puller := &MockPullerAll{Metrics: &entity.Metrics{
+		&entity.Metric{Name: "c", Type: entity.MetricTypeGauge, Value: 3.0},
+		&entity.Metric{Name: "a", Type: entity.MetricTypeGauge, Value: 1.0},
+		&entity.Metric{Name: "d", Type: entity.MetricTypeCounter, Value: int64(4)},
+		&entity.Metric{Name: "b", Type: entity.MetricTypeGauge, Value: 2.0},
+		&entity.Metric{Name: "e", Type: entity.MetricTypeGauge, Value: 5.0},
+	}}
+	tests := []struct {
+		name     string
+		target   string
+		wantRows []string
+		want     mainPage
+	}{
+		{
+			name:     "First page by name",
+			target:   "/?per_page=2",
+			wantRows: []string{"a", "b"},
+			want:     mainPage{Sort: "name", Page: 1, Pages: 3, PerPage: 2, Total: 5, Next: "/?page=2&per_page=2&sort=name"},
+		},
+		{
+			name:     "Middle page by type",
+			target:   "/?page=2&per_page=2&sort=type",
+			wantRows: []string{"b", "c"},
+			want: mainPage{
+				Sort: "type", Page: 2, Pages: 3, PerPage: 2, Total: 5,
+				Prev: "/?page=1&per_page=2&sort=type", Next: "/?page=3&per_page=2&sort=type",
+			},
+		},
+		{
+			name:     "Beyond the last page",
+			target:   "/?page=9&per_page=2",
+			wantRows: []string{},
+			want:     mainPage{Sort: "name", Page: 9, Pages: 3, PerPage: 2, Total: 5, Prev: "/?page=3&per_page=2&sort=name"},
+		},
+		{
+			name:     "Default page size",
+			target:   "/",
+			wantRows: []string{"a", "b", "c", "d", "e"},
+			want:     mainPage{Sort: "name", Page: 1, Pages: 1, PerPage: defaultPerPage, Total: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			var got mainPage
+			e.Renderer = &MockTemplate{RenderFunc: func(_ io.Writer, _ string, data interface{}, _ echo.Context) error {
+				got, _ = data.(mainPage)
+				return nil
+			}}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, tt.target, http.NoBody), rec)
+
+			require.NoError(t, MainPage(puller)(c))
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			names := make([]string, 0, len(got.Rows))
+			for _, row := range got.Rows {
+				names = append(names, row.Name)
+			}
+			assert.Equal(t, tt.wantRows, names)
+			got.Rows = nil
+			tt.want.ByName = "/?page=1&per_page=" + fmt.Sprint(tt.want.PerPage) + "&sort=name"
+			tt.want.ByType = "/?page=1&per_page=" + fmt.Sprint(tt.want.PerPage) + "&sort=type"
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMainPage_InvalidParams(t *testing.T) {
+	for _, target := range []string{"/?page=0", "/?page=x", "/?per_page=0", "/?per_page=1001", "/?sort=value"} {
+		t.Run(target, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, target, http.NoBody), rec)
+
+			require.NoError(t, MainPage(&MockPullerAll{Metrics: &entity.Metrics{}})(c))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
 // ExampleMainPage demonstrates how to use the MainPage handler.
 // It sets up a dummy puller that returns two metrics, creates an Echo instance with a mock renderer,
 // invokes the MainPage handler, and prints the rendered output.
@@ -199,10 +306,10 @@ func ExampleMainPage() {
 		RenderFunc: func(w io.Writer, tmplName string, data interface{}, _ echo.Context) error {
 			// Write the template name.
 			_, _ = fmt.Fprintf(w, "Template: %s\n", tmplName)
-			// Assert that data is a slice of table rows.
-			rows, ok := data.([]*tr)
+			// Assert that data is a page of table rows.
+			page, ok := data.(mainPage)
 			if ok {
-				for _, row := range rows {
+				for _, row := range page.Rows {
 					_, _ = fmt.Fprintf(w, "%s: %s\n", row.Name, row.Value)
 				}
 			}
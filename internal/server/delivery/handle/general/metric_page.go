@@ -0,0 +1,75 @@
+package general
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/controller"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Const pullTimeout bounds reading the metric shown on a detail page.
+const pullTimeout = 2 * time.Second
+
+// Puller defines an interface for retrieving a single metric.
+type Puller interface {
+	// Pull retrieves a metric by type and name.
+	Pull(ctx context.Context, metricType string, name string) (*entity.Metric, error)
+}
+
+// MetaReader defines an interface for reading the annotations of a metric.
+type MetaReader interface {
+	// Meta retrieves the annotations of a metric, or repository.ErrNotFoundInRepo if it has none.
+	Meta(ctx context.Context, metricType string, metricName string) (*entity.Meta, error)
+}
+
+// metricPage is the data rendered on the detail page of a metric.
+type metricPage struct {
+	Meta  *entity.Meta // Meta holds the annotations of the metric, nil if it has none.
+	Name  string       // Name of the metric.
+	Type  string       // Type of the metric.
+	Value string       // Value of the metric as a string.
+}
+
+// MetricPage returns an HTTP handler function that renders the detail page of the metric identified
+// by the :type and :id path parameters, with its annotations.
+//
+// Parameters:
+//   - puller: An implementation of the Puller interface for fetching the metric.
+//   - meta: The source of the annotations; nil if the storage keeps none.
+//
+// Returns:
+//   - An echo.HandlerFunc that handles HTTP requests for the detail page.
+func MetricPage(puller Puller, meta MetaReader) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, cancel := clk.WithTimeout(c.Request().Context(), pullTimeout)
+		defer cancel()
+
+		metricType, name := c.Param("type"), c.Param("id")
+		metric, err := puller.Pull(ctx, metricType, name)
+		if err != nil {
+			if errors.Is(err, controller.ErrNotFoundInRepository) {
+				return c.String(http.StatusNotFound, "Metric not found.")
+			}
+			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+
+		page := metricPage{Name: metric.Name, Type: metric.Type, Value: fmt.Sprint(metric.Value)}
+		if meta != nil {
+			annotations, err := meta.Meta(ctx, metricType, name)
+			switch {
+			case err == nil:
+				page.Meta = annotations
+			case !errors.Is(err, repository.ErrNotFoundInRepo):
+				return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			}
+		}
+		return c.Render(http.StatusOK, "metric_page.html", page)
+	}
+}
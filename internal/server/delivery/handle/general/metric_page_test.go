@@ -0,0 +1,121 @@
+package general
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/delivery/render"
+	"github.com/gdyunin/metricol.git/internal/server/internal/controller"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// metricPageTemplate is the template rendered by MetricPage.
+const metricPageTemplate = "../../../../../web/templates/metric_page.html"
+
+// mockPuller implements the Puller interface for testing.
+type mockPuller struct {
+	metric *entity.Metric
+	err    error
+}
+
+func (m *mockPuller) Pull(context.Context, string, string) (*entity.Metric, error) {
+	return m.metric, m.err
+}
+
+// mockMetaReader implements the MetaReader interface for testing.
+type mockMetaReader struct {
+	meta *entity.Meta
+	err  error
+}
+
+func (m *mockMetaReader) Meta(context.Context, string, string) (*entity.Meta, error) {
+	return m.meta, m.err
+}
+
+func TestMetricPage(t *testing.T) {
+	heap := &mockPuller{metric: &entity.Metric{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 1.5}}
+	annotated := &mockMetaReader{meta: &entity.Meta{
+		Owner:     "platform",
+		Runbook:   "https://wiki.example.com/heap",
+		UpdatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}}
+
+	tests := []struct {
+		puller         Puller
+		meta           MetaReader
+		name           string
+		contains       []string
+		absent         []string
+		expectedStatus int
+	}{
+		{
+			name:           "With annotations",
+			puller:         heap,
+			meta:           annotated,
+			expectedStatus: http.StatusOK,
+			contains: []string{
+				"<h1>HeapAlloc</h1>",
+				"<td>1.5</td>",
+				"<td>platform</td>",
+				`<a href="https://wiki.example.com/heap">`,
+				"2024-05-01 12:00:00 UTC",
+			},
+			absent: []string{"Заметки"},
+		},
+		{
+			name:           "Without annotations",
+			puller:         heap,
+			meta:           &mockMetaReader{err: repository.ErrNotFoundInRepo},
+			expectedStatus: http.StatusOK,
+			contains:       []string{"<td>1.5</td>"},
+			absent:         []string{"Владелец", "Изменено"},
+		},
+		{
+			name:           "Storage without annotations",
+			puller:         heap,
+			expectedStatus: http.StatusOK,
+			contains:       []string{"<td>gauge</td>"},
+		},
+		{
+			name:           "Metric not found",
+			puller:         &mockPuller{err: controller.ErrNotFoundInRepository},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Annotations error",
+			puller:         heap,
+			meta:           &mockMetaReader{err: errors.New("storage error")},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Renderer = render.NewRenderer(template.Must(template.ParseFiles(metricPageTemplate)))
+			req := httptest.NewRequest(http.MethodGet, "/metric/gauge/HeapAlloc", http.NoBody)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("type", "id")
+			c.SetParamValues("gauge", "HeapAlloc")
+
+			require.NoError(t, MetricPage(tt.puller, tt.meta)(c))
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			for _, s := range tt.contains {
+				assert.Contains(t, rec.Body.String(), s)
+			}
+			for _, s := range tt.absent {
+				assert.NotContains(t, rec.Body.String(), s)
+			}
+		})
+	}
+}
@@ -37,7 +37,7 @@ type ConnectChecker interface {
 //   - A 500 Internal Server Error status if the connection check fails.
 func Ping(checker ConnectChecker) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		ctx, cancel := context.WithTimeout(context.Background(), connectionCheckTimeout)
+		ctx, cancel := clk.WithTimeout(context.Background(), connectionCheckTimeout)
 		defer cancel()
 
 		if err := checker.CheckConnection(ctx); err != nil {
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gdyunin/metricol.git/pkg/clock"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 )
@@ -23,7 +24,7 @@ type MockConnectChecker struct {
 func (m *MockConnectChecker) CheckConnection(ctx context.Context) error {
 	if m.Delay > 0 {
 		select {
-		case <-time.After(m.Delay):
+		case <-clk.After(m.Delay):
 		case <-ctx.Done():
 			return ctx.Err()
 		}
@@ -35,6 +36,23 @@ func (m *MockConnectChecker) CheckConnection(ctx context.Context) error {
 	return nil
 }
 
+// advanceClock replaces the handler clock with a fake one and, if a mock delays, advances it once
+// the handler and the mock both wait: by the delay if it is shorter than the timeout, by the timeout otherwise.
+func advanceClock(t *testing.T, delay, timeout time.Duration) {
+	t.Helper()
+
+	fake := clock.NewFake(time.Now())
+	clk = fake
+	t.Cleanup(func() { clk = clock.Real() })
+
+	if delay > 0 {
+		go func() {
+			fake.BlockUntil(2)
+			fake.Advance(min(delay, timeout))
+		}()
+	}
+}
+
 func TestPing(t *testing.T) {
 	tests := []struct {
 		checker        ConnectChecker
@@ -75,6 +93,7 @@ func TestPing(t *testing.T) {
 			rec := httptest.NewRecorder()
 			c := e.NewContext(req, rec)
 
+			advanceClock(t, tt.checker.(*MockConnectChecker).Delay, connectionCheckTimeout)
 			handler := Ping(tt.checker)
 			_ = handler(c)
 
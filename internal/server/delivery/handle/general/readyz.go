@@ -0,0 +1,33 @@
+package general
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ReadinessReporter defines an interface for reporting whether a component can serve requests.
+//
+// Unlike ConnectChecker, it reports state tracked in the background and never blocks,
+// so it is safe to call on every readiness probe.
+type ReadinessReporter interface {
+	Ready() bool
+}
+
+// Readyz returns an HTTP handler function for readiness probes.
+// Components that do not implement ReadinessReporter are always ready, so a nil reporter is allowed.
+//
+// Parameters:
+//   - reporter: The component whose readiness is reported; may be nil.
+//
+// Returns:
+//   - An echo.HandlerFunc that sends "ready" with a 200 OK status if the component is ready.
+//   - A 503 Service Unavailable status if it is not.
+func Readyz(reporter ReadinessReporter) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if reporter != nil && !reporter.Ready() {
+			return c.String(http.StatusServiceUnavailable, "not ready")
+		}
+		return c.String(http.StatusOK, "ready")
+	}
+}
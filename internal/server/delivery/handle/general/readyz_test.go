@@ -0,0 +1,62 @@
+package general
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockReadinessReporter implements the ReadinessReporter interface for testing.
+type mockReadinessReporter struct {
+	ready bool
+}
+
+// Ready implements the ReadinessReporter interface.
+func (m *mockReadinessReporter) Ready() bool {
+	return m.ready
+}
+
+func TestReadyz(t *testing.T) {
+	tests := []struct {
+		reporter       ReadinessReporter
+		name           string
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name:           "Ready",
+			reporter:       &mockReadinessReporter{ready: true},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "ready",
+		},
+		{
+			name:           "Not ready",
+			reporter:       &mockReadinessReporter{ready: false},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   "not ready",
+		},
+		{
+			name:           "Without reporter",
+			reporter:       nil,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "ready",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/readyz", http.NoBody)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			err := Readyz(tt.reporter)(c)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.expectedBody, rec.Body.String())
+		})
+	}
+}
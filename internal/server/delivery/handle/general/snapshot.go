@@ -0,0 +1,57 @@
+package general
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// snapshotTimeLayout is the layout of the snapshot timestamp, also used in the suggested file name.
+const snapshotTimeLayout = "2006-01-02T15-04-05Z"
+
+// snapshotPage is the data rendered into the static snapshot.
+type snapshotPage struct {
+	TakenAt time.Time // TakenAt is the moment the metrics were read, in UTC.
+	Rows    []*tr     // Rows are the metrics sorted by type and name.
+}
+
+// Snapshot returns an HTTP handler function that renders the current metrics as a self-contained
+// static page. The page has its styles inline and no links to the server, so it can be saved and
+// attached to incident reports; it is marked with the moment the metrics were read.
+//
+// Parameters:
+//   - puller: An implementation of the PullerAll interface for fetching all metrics.
+//
+// Returns:
+//   - An echo.HandlerFunc that handles HTTP requests for the snapshot.
+func Snapshot(puller PullerAll) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, cancel := clk.WithTimeout(c.Request().Context(), pullAllTimeout)
+		defer cancel()
+
+		allMetrics, err := puller.PullAll(ctx)
+		if err != nil || allMetrics == nil {
+			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+
+		// A snapshot is compared with others, so rows keep a stable order.
+		rows := tableRows(allMetrics)
+		sort.Slice(rows, func(i, j int) bool {
+			if rows[i].Type != rows[j].Type {
+				return rows[i].Type < rows[j].Type
+			}
+			return rows[i].Name < rows[j].Name
+		})
+		page := snapshotPage{TakenAt: clk.Now().UTC(), Rows: rows}
+
+		c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+		c.Response().Header().Set(
+			echo.HeaderContentDisposition,
+			fmt.Sprintf(`inline; filename="metricol-snapshot-%s.html"`, page.TakenAt.Format(snapshotTimeLayout)),
+		)
+		return c.Render(http.StatusOK, "snapshot.html", page)
+	}
+}
@@ -0,0 +1,104 @@
+package general
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/delivery/render"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/clock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// snapshotTemplate is the template rendered by Snapshot.
+const snapshotTemplate = "../../../../../web/templates/snapshot.html"
+
+func TestSnapshot(t *testing.T) {
+	clk = clock.NewFake(time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC))
+	t.Cleanup(func() { clk = clock.Real() })
+
+	tests := []struct {
+		puller         PullerAll
+		name           string
+		contains       []string
+		expectedStatus int
+	}{
+		{
+			name: "Metrics",
+			puller: &MockPullerAll{Metrics: &entity.Metrics{
+				&entity.Metric{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(7)},
+				&entity.Metric{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 1.5},
+				&entity.Metric{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 2.5},
+			}},
+			expectedStatus: http.StatusOK,
+			contains: []string{
+				"2024-05-01 12:30:00 UTC",
+				"метрик: 3",
+				"<style>",
+			},
+		},
+		{
+			name:           "No metrics",
+			puller:         &MockPullerAll{Metrics: &entity.Metrics{}},
+			expectedStatus: http.StatusOK,
+			contains:       []string{"метрик: 0"},
+		},
+		{
+			name:           "Storage error",
+			puller:         &MockPullerAll{ShouldFail: true},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Renderer = render.NewRenderer(template.Must(template.ParseFiles(snapshotTemplate)))
+			req := httptest.NewRequest(http.MethodGet, "/snapshot.html", http.NoBody)
+			rec := httptest.NewRecorder()
+
+			require.NoError(t, Snapshot(tt.puller)(e.NewContext(req, rec)))
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			body := rec.Body.String()
+			for _, s := range tt.contains {
+				assert.Contains(t, body, s)
+			}
+			assert.NotContains(t, body, "<link")
+			assert.NotContains(t, body, "<a href")
+			assert.Equal(t, "no-store", rec.Header().Get(echo.HeaderCacheControl))
+			assert.Equal(t,
+				`inline; filename="metricol-snapshot-2024-05-01T12-30-00Z.html"`,
+				rec.Header().Get(echo.HeaderContentDisposition),
+			)
+		})
+	}
+}
+
+func TestSnapshot_Order(t *testing.T) {
+	e := echo.New()
+	e.Renderer = render.NewRenderer(template.Must(template.ParseFiles(snapshotTemplate)))
+	puller := &MockPullerAll{Metrics: &entity.Metrics{
+		&entity.Metric{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 1.5},
+		&entity.Metric{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(7)},
+		&entity.Metric{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 2.5},
+	}}
+	rec := httptest.NewRecorder()
+
+	require.NoError(t, Snapshot(puller)(e.NewContext(httptest.NewRequest(http.MethodGet, "/", http.NoBody), rec)))
+
+	body := rec.Body.String()
+	poll, alloc, heap := strings.Index(body, "PollCount"), strings.Index(body, ">Alloc<"), strings.Index(body, "HeapAlloc")
+	require.Positive(t, poll)
+	assert.Less(t, poll, alloc)
+	assert.Less(t, alloc, heap)
+}
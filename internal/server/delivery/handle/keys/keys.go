@@ -0,0 +1,33 @@
+package keys
+
+import (
+	"net/http"
+
+	"github.com/gdyunin/metricol.git/internal/server/keyring"
+
+	"github.com/labstack/echo/v4"
+)
+
+// KeysResponse is the body returned by the keys endpoint.
+type KeysResponse struct {
+	keyring.Advertisement
+	PublicKeys []keyring.PublicKey `json:"public_keys"` // PublicKeys are the encryption keys agents may use.
+}
+
+// Keys serves the state of a key rotation: the identifiers of the current and next signing and
+// encryption keys, and the public encryption keys themselves. Agents fetch it when the server
+// advertises a key they do not know yet.
+//
+// Parameters:
+//   - source: The source of the server's keys.
+//
+// Returns:
+//   - An echo.HandlerFunc responding with the KeysResponse in JSON.
+func Keys(source KeySource) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, KeysResponse{
+			Advertisement: source.Advertisement(),
+			PublicKeys:    source.PublicKeys(),
+		})
+	}
+}
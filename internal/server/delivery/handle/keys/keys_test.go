@@ -0,0 +1,56 @@
+package keys
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/keyring"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		source *MockKeySource
+	}{
+		{
+			name: "Rotation in progress",
+			source: &MockKeySource{
+				Keys: []keyring.PublicKey{
+					{ID: "aa", PublicKey: "current-pem", Status: keyring.StatusCurrent},
+					{ID: "bb", PublicKey: "next-pem", Status: keyring.StatusNext},
+				},
+				Adv: keyring.Advertisement{
+					SigningKeyID:     "s1",
+					NextSigningKeyID: "s2",
+					CryptoKeyID:      "aa",
+					NextCryptoKeyID:  "bb",
+				},
+			},
+		},
+		{
+			name:   "No keys configured",
+			source: &MockKeySource{Keys: []keyring.PublicKey{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/crypto/keys", http.NoBody)
+			rec := httptest.NewRecorder()
+
+			require.NoError(t, Keys(tt.source)(e.NewContext(req, rec)))
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			var resp KeysResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tt.source.Adv, resp.Advertisement)
+			assert.Equal(t, tt.source.Keys, resp.PublicKeys)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+// Package keys provides handlers distributing the server's encryption keys to agents.
+package keys
+
+import (
+	"net/http"
+
+	"github.com/gdyunin/metricol.git/internal/server/keyring"
+
+	"github.com/labstack/echo/v4"
+)
+
+// msgEncryptionDisabled is returned when the server has no encryption key.
+const msgEncryptionDisabled = "Payload encryption is disabled on this server."
+
+// KeySource defines the interface for obtaining the keys offered to agents.
+type KeySource interface {
+	// PublicKeys returns the current public encryption key followed by the next one, if any.
+	PublicKeys() []keyring.PublicKey
+	// Advertisement returns the identifiers of the current and next keys.
+	Advertisement() keyring.Advertisement
+}
+
+// PublicKeyResponse is the body returned by the public key endpoint.
+type PublicKeyResponse struct {
+	PublicKey   string `json:"public_key"`  // PublicKey is the RSA or X25519 public key in PEM format.
+	Fingerprint string `json:"fingerprint"` // Fingerprint is the hex encoded SHA-256 digest of the DER encoded key.
+}
+
+// PublicKey serves the public part of the server's current RSA or X25519 key so agents can encrypt payloads without
+// the key being distributed to every host by hand. Agents should verify the returned key against
+// a fingerprint obtained out of band before trusting it.
+//
+// Parameters:
+//   - source: The source of the server's keys.
+//
+// Returns:
+//   - An echo.HandlerFunc responding with the PublicKeyResponse in JSON,
+//     or 404 if encryption is disabled.
+func PublicKey(source KeySource) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		for _, key := range source.PublicKeys() {
+			if key.Status == keyring.StatusCurrent {
+				return c.JSON(http.StatusOK, PublicKeyResponse{PublicKey: key.PublicKey, Fingerprint: key.ID})
+			}
+		}
+		return c.String(http.StatusNotFound, msgEncryptionDisabled)
+	}
+}
@@ -0,0 +1,70 @@
+package keys
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/keyring"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockKeySource is a static KeySource for tests.
+type MockKeySource struct {
+	Keys []keyring.PublicKey
+	Adv  keyring.Advertisement
+}
+
+func (m *MockKeySource) PublicKeys() []keyring.PublicKey {
+	return m.Keys
+}
+
+func (m *MockKeySource) Advertisement() keyring.Advertisement {
+	return m.Adv
+}
+
+func TestPublicKey(t *testing.T) {
+	current := keyring.PublicKey{ID: "aa", PublicKey: "current-pem", Status: keyring.StatusCurrent}
+	next := keyring.PublicKey{ID: "bb", PublicKey: "next-pem", Status: keyring.StatusNext}
+
+	tests := []struct {
+		name           string
+		expected       PublicKeyResponse
+		keys           []keyring.PublicKey
+		expectedStatus int
+	}{
+		{
+			name:           "Encryption enabled",
+			keys:           []keyring.PublicKey{current},
+			expectedStatus: http.StatusOK,
+			expected:       PublicKeyResponse{PublicKey: "current-pem", Fingerprint: "aa"},
+		},
+		{
+			name:           "Rotation in progress serves current key",
+			keys:           []keyring.PublicKey{current, next},
+			expectedStatus: http.StatusOK,
+			expected:       PublicKeyResponse{PublicKey: "current-pem", Fingerprint: "aa"},
+		},
+		{name: "Encryption disabled", keys: nil, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/crypto/public-key", http.NoBody)
+			rec := httptest.NewRecorder()
+
+			require.NoError(t, PublicKey(&MockKeySource{Keys: tt.keys})(e.NewContext(req, rec)))
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var resp PublicKeyResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, tt.expected, resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Package live provides handlers streaming metric updates to clients as Server-Sent Events.
+package live
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+	"github.com/gdyunin/metricol.git/internal/server/internal/stream"
+
+	"github.com/labstack/echo/v4"
+)
+
+// keepAliveInterval is the period of comment frames keeping idle connections open through proxies.
+const keepAliveInterval = 15 * time.Second
+
+// MIMEEventStream is the content type of Server-Sent Events responses.
+const MIMEEventStream = "text/event-stream"
+
+// StreamHub defines the interface for subscribing to metric updates.
+type StreamHub interface {
+	Subscribe(filter stream.Filter) *stream.Subscriber
+	Unsubscribe(*stream.Subscriber)
+}
+
+// Stream streams metric updates as Server-Sent Events until the client disconnects.
+// Every update is sent as a "metric" event carrying the JSON model of the metric. If the client
+// falls too far behind, the hub may end the subscription; the handler then sends a "disconnect" event.
+//
+// The updates are filtered on the server by the query parameters: name and type select the metrics,
+// each repeatable or a comma-separated list, and delta skips updates whose value changed by less than
+// the delta since the last one sent for the metric.
+//
+// Parameters:
+//   - hub: An implementation of StreamHub providing the updates.
+//
+// Returns:
+//   - An echo.HandlerFunc serving the event stream, or responding 400 if delta is not a non-negative number.
+func Stream(hub StreamHub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		filter, ok := parseFilter(c)
+		if !ok {
+			return c.String(http.StatusBadRequest, "Delta must be a non-negative number.")
+		}
+
+		sub := hub.Subscribe(filter)
+		defer hub.Unsubscribe(sub)
+
+		resp := c.Response()
+		resp.Header().Set(echo.HeaderContentType, MIMEEventStream)
+		resp.Header().Set(echo.HeaderCacheControl, "no-cache")
+		resp.Header().Set(echo.HeaderConnection, "keep-alive")
+		resp.WriteHeader(http.StatusOK)
+		resp.Flush()
+
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-c.Request().Context().Done():
+				return nil
+			case <-sub.Done():
+				return writeEvent(resp, "disconnect", []byte(`"subscriber too slow"`))
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(resp, ": keep-alive\n\n"); err != nil {
+					return nil
+				}
+				resp.Flush()
+			case m := <-sub.Events():
+				data, err := json.Marshal(model.FromEntityMetric(m))
+				if err != nil {
+					continue
+				}
+				if err := writeEvent(resp, "metric", data); err != nil {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// parseFilter reads the stream filter from the query parameters.
+//
+// Parameters:
+//   - c: The request context.
+//
+// Returns:
+//   - stream.Filter: The requested filter.
+//   - bool: False if delta is not a non-negative number.
+func parseFilter(c echo.Context) (stream.Filter, bool) {
+	params := c.QueryParams()
+	filter := stream.Filter{
+		Names: splitList(params["name"]),
+		Types: splitList(params["type"]),
+	}
+	if raw := c.QueryParam("delta"); raw != "" {
+		delta, err := strconv.ParseFloat(raw, 64)
+		if err != nil || delta < 0 || math.IsNaN(delta) {
+			return stream.Filter{}, false
+		}
+		filter.MinDelta = delta
+	}
+	return filter, true
+}
+
+// splitList flattens repeated and comma-separated query values, skipping empty items.
+//
+// Parameters:
+//   - values: The values of a query parameter.
+//
+// Returns:
+//   - []string: The listed items, nil if there are none.
+func splitList(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// writeEvent writes a single Server-Sent Event and flushes it to the client.
+//
+// Parameters:
+//   - resp: The response to write to.
+//   - event: The event name.
+//   - data: The event payload.
+//
+// Returns:
+//   - An error if writing fails.
+func writeEvent(resp *echo.Response, event string, data []byte) error {
+	if _, err := fmt.Fprintf(resp, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return fmt.Errorf("failed to write %s event: %w", event, err)
+	}
+	resp.Flush()
+	return nil
+}
@@ -0,0 +1,148 @@
+package live
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/internal/stream"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// notifyingHub wraps a stream.Hub and reports created subscriptions.
+type notifyingHub struct {
+	*stream.Hub
+	subscribed chan *stream.Subscriber
+}
+
+// Subscribe implements the StreamHub interface.
+func (h *notifyingHub) Subscribe(filter stream.Filter) *stream.Subscriber {
+	sub := h.Hub.Subscribe(filter)
+	h.subscribed <- sub
+	return sub
+}
+
+// readEvent reads lines until a blank line and returns the event name and data.
+func readEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+	var event, data string
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			if event != "" {
+				return event, data
+			}
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	hub := &notifyingHub{Hub: stream.NewHub(4, stream.DropOldest), subscribed: make(chan *stream.Subscriber, 1)}
+	e := echo.New()
+	e.GET("/stream", Stream(hub))
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/stream") //nolint:noctx // test request
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, MIMEEventStream, resp.Header.Get(echo.HeaderContentType))
+
+	sub := <-hub.subscribed
+	hub.Publish(entity.Metrics{{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.5}})
+
+	reader := bufio.NewReader(resp.Body)
+	event, data := readEvent(t, reader)
+	assert.Equal(t, "metric", event)
+	assert.JSONEq(t, `{"id":"Alloc","type":"gauge","value":1.5}`, data)
+
+	hub.Unsubscribe(sub)
+	event, _ = readEvent(t, reader)
+	assert.Equal(t, "disconnect", event)
+}
+
+func TestStream_Filter(t *testing.T) {
+	hub := &notifyingHub{Hub: stream.NewHub(8, stream.DropOldest), subscribed: make(chan *stream.Subscriber, 1)}
+	e := echo.New()
+	e.GET("/stream", Stream(hub))
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/stream?name=Alloc,Sys&type=gauge&delta=1") //nolint:noctx // test request
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	sub := <-hub.subscribed
+	defer hub.Unsubscribe(sub)
+	hub.Publish(entity.Metrics{
+		{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.0},
+		{Name: "HeapInuse", Type: entity.MetricTypeGauge, Value: 1.0},
+		{Name: "Alloc", Type: entity.MetricTypeCounter, Value: int64(1)},
+		{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.5},
+		{Name: "Sys", Type: entity.MetricTypeGauge, Value: 2.0},
+	})
+
+	reader := bufio.NewReader(resp.Body)
+	_, data := readEvent(t, reader)
+	assert.JSONEq(t, `{"id":"Alloc","type":"gauge","value":1}`, data)
+	_, data = readEvent(t, reader)
+	assert.JSONEq(t, `{"id":"Sys","type":"gauge","value":2}`, data)
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected stream.Filter
+		ok       bool
+	}{
+		{name: "No filter", query: "", ok: true},
+		{
+			name:     "Repeated and comma-separated names",
+			query:    "name=Alloc&name=Sys,%20HeapInuse,&type=gauge",
+			expected: stream.Filter{Names: []string{"Alloc", "Sys", "HeapInuse"}, Types: []string{"gauge"}},
+			ok:       true,
+		},
+		{name: "Delta", query: "delta=0.5", expected: stream.Filter{MinDelta: 0.5}, ok: true},
+		{name: "Negative delta", query: "delta=-1"},
+		{name: "Invalid delta", query: "delta=big"},
+		{name: "NaN delta", query: "delta=NaN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/stream?"+tt.query, http.NoBody)
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			filter, ok := parseFilter(c)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, filter)
+		})
+	}
+}
+
+func TestStream_InvalidDelta(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/stream?delta=-1", http.NoBody)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	hub := stream.NewHub(1, stream.DropOldest)
+	require.NoError(t, Stream(hub)(c))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,56 @@
+// Package pusherr maps the errors of pushing metrics to HTTP responses, so every endpoint accepting
+// metric updates answers the same failure with the same status and headers.
+package pusherr
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/controller"
+	"github.com/gdyunin/metricol.git/internal/server/internal/quota"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// rateLimitRetryAfter is the Retry-After value, in seconds, sent with 429 responses.
+	rateLimitRetryAfter = "1"
+	// quotaRetryAfter is the Retry-After value, in seconds, sent with 429 responses caused by a quota.
+	quotaRetryAfter = "60"
+)
+
+// Respond writes the HTTP response matching an error returned by pushing metrics. Errors that match
+// no known failure are answered with 500.
+//
+// Parameters:
+//   - c: The Echo context of the request.
+//   - err: The error returned by the push.
+//
+// Returns:
+//   - error: An error if writing the response fails.
+func Respond(c echo.Context, err error) error {
+	var (
+		cardinalityErr *controller.CardinalityError
+		quotaErr       *quota.Error
+	)
+	switch {
+	case errors.Is(err, controller.ErrRateLimited):
+		c.Response().Header().Set(echo.HeaderRetryAfter, rateLimitRetryAfter)
+		return c.String(http.StatusTooManyRequests, "Metric update rate limit exceeded.")
+	case errors.As(err, &quotaErr) && errors.Is(err, quota.ErrBatchTooLarge):
+		return c.String(http.StatusRequestEntityTooLarge, quotaErr.Error())
+	case errors.As(err, &quotaErr):
+		c.Response().Header().Set(echo.HeaderRetryAfter, quotaRetryAfter)
+		return c.String(http.StatusTooManyRequests, quotaErr.Error())
+	case errors.Is(err, controller.ErrOutOfOrder):
+		return c.String(http.StatusConflict, "Metric sample is older than the stored one.")
+	case errors.As(err, &cardinalityErr):
+		return c.String(http.StatusUnprocessableEntity, cardinalityErr.Error())
+	case errors.Is(err, controller.ErrMetricNotAllowed):
+		return c.String(http.StatusForbidden, "Metric name is not allowed.")
+	case errors.Is(err, controller.ErrInvalidMetric):
+		return c.String(http.StatusBadRequest, "Invalid parameters provided in the request.")
+	default:
+		return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+	}
+}
@@ -0,0 +1,78 @@
+package pusherr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/controller"
+	"github.com/gdyunin/metricol.git/internal/server/internal/quota"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRespond(t *testing.T) {
+	tests := []struct {
+		err                error
+		name               string
+		expectedRetryAfter string
+		expectedCode       int
+	}{
+		{
+			name:               "Rate limited",
+			err:                fmt.Errorf("%w: type=gauge, name=Alloc", controller.ErrRateLimited),
+			expectedCode:       http.StatusTooManyRequests,
+			expectedRetryAfter: rateLimitRetryAfter,
+		},
+		{
+			name:               "Updates quota",
+			err:                fmt.Errorf("rejected: %w", &quota.Error{KeyID: "ci", Quota: quota.QuotaUpdates, Limit: 10}),
+			expectedCode:       http.StatusTooManyRequests,
+			expectedRetryAfter: quotaRetryAfter,
+		},
+		{
+			name:         "Batch quota",
+			err:          fmt.Errorf("rejected: %w", &quota.Error{KeyID: "ci", Quota: quota.QuotaBatch, Limit: 10}),
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:         "Out of order",
+			err:          fmt.Errorf("push failed: %w", controller.ErrOutOfOrder),
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "Cardinality limit",
+			err:          fmt.Errorf("rejected: %w", &controller.CardinalityError{Metric: "Alloc", Limit: 1}),
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Name not allowed",
+			err:          fmt.Errorf("rejected: %w", controller.ErrMetricNotAllowed),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "Invalid metric",
+			err:          fmt.Errorf("push failed: %w: info metric value must be a string", controller.ErrInvalidMetric),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unknown error",
+			err:          errors.New("storage unavailable"),
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/updates/", http.NoBody), rec)
+
+			require.NoError(t, Respond(c, tt.err))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, tt.expectedRetryAfter, rec.Header().Get(echo.HeaderRetryAfter))
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package pushgateway
+
+import (
+	"maps"
+	"sort"
+	"sync"
+)
+
+// Groups remembers which series every push group holds, so a push can replace the series of its group
+// like Pushgateway does. The membership is kept in memory: after a restart, series pushed before it
+// are no longer replaced or deleted with their group.
+type Groups struct {
+	series map[string]map[string]string // series maps group keys to their series names and metric names.
+	mu     sync.Mutex                   // mu protects series.
+}
+
+// NewGroups creates an empty Groups registry.
+//
+// Returns:
+//   - *Groups: A pointer to the created registry.
+func NewGroups() *Groups {
+	return &Groups{series: make(map[string]map[string]string)}
+}
+
+// Replace sets the series of a group, as a PUT push does.
+//
+// Parameters:
+//   - group: The group key.
+//   - pushed: The pushed series names mapped to their metric names.
+//
+// Returns:
+//   - []string: The series of the group that were not pushed again and must be deleted.
+func (g *Groups) Replace(group string, pushed map[string]string) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	stale := stale(g.series[group], pushed, func(string) bool { return true })
+	g.series[group] = maps.Clone(pushed)
+	return stale
+}
+
+// Merge adds series to a group, replacing only the series of the pushed metric names, as a POST push does.
+//
+// Parameters:
+//   - group: The group key.
+//   - pushed: The pushed series names mapped to their metric names.
+//
+// Returns:
+//   - []string: The series of the pushed metric names that were not pushed again and must be deleted.
+func (g *Groups) Merge(group string, pushed map[string]string) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	names := make(map[string]bool, len(pushed))
+	for _, name := range pushed {
+		names[name] = true
+	}
+
+	current := g.series[group]
+	stale := stale(current, pushed, func(name string) bool { return names[name] })
+	if current == nil {
+		current = make(map[string]string, len(pushed))
+		g.series[group] = current
+	}
+	for _, series := range stale {
+		delete(current, series)
+	}
+	for series, name := range pushed {
+		current[series] = name
+	}
+	return stale
+}
+
+// Remove forgets a group, as a DELETE request does.
+//
+// Parameters:
+//   - group: The group key.
+//
+// Returns:
+//   - []string: The series of the group, which must be deleted.
+func (g *Groups) Remove(group string) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	removed := stale(g.series[group], nil, func(string) bool { return true })
+	delete(g.series, group)
+	return removed
+}
+
+// stale returns, in sorted order, the current series not pushed again whose metric name is affected.
+func stale(current, pushed map[string]string, affected func(name string) bool) []string {
+	var series []string
+	for s, name := range current {
+		if _, ok := pushed[s]; !ok && affected(name) {
+			series = append(series, s)
+		}
+	}
+	sort.Strings(series)
+	return series
+}
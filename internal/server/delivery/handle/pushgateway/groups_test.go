@@ -0,0 +1,36 @@
+package pushgateway
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroups(t *testing.T) {
+	g := NewGroups()
+	const group = `{job="batch"}`
+
+	assert.Empty(t, g.Replace(group, map[string]string{
+		`a{job="batch"}`:           "a",
+		`b{job="batch",le="1"}`:    "b",
+		`b{job="batch",le="+Inf"}`: "b",
+	}))
+
+	// POST replaces the series of the pushed names only.
+	assert.Equal(t, []string{`b{job="batch",le="1"}`}, g.Merge(group, map[string]string{
+		`b{job="batch",le="+Inf"}`: "b",
+		`c{job="batch"}`:           "c",
+	}))
+
+	// PUT replaces the whole group.
+	assert.Equal(t, []string{`a{job="batch"}`, `b{job="batch",le="+Inf"}`}, g.Replace(group, map[string]string{
+		`c{job="batch"}`: "c",
+	}))
+
+	// Other groups are not affected.
+	assert.Empty(t, g.Merge(`{job="other"}`, map[string]string{`a{job="other"}`: "a"}))
+
+	assert.Equal(t, []string{`c{job="batch"}`}, g.Remove(group))
+	assert.Empty(t, g.Remove(group))
+	assert.Equal(t, []string{`a{job="other"}`}, g.Remove(`{job="other"}`))
+}
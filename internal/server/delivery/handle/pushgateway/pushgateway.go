@@ -0,0 +1,197 @@
+// Package pushgateway implements the push API of the Prometheus Pushgateway, so jobs using Pushgateway
+// clients can push to this server unchanged. Pushes go to /metrics/job/<job>{/<label>/<value>}; the job and
+// path labels form the grouping key, which is added to the labels of every pushed sample. Every sample is
+// stored as a gauge named after its series, e.g. `requests_total{instance="a",job="batch"}`, holding the
+// last pushed value like Pushgateway does. Only the text exposition format is supported.
+package pushgateway
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/delivery/handle/pusherr"
+	"github.com/gdyunin/metricol.git/internal/server/internal/controller"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/clock"
+	"github.com/gdyunin/metricol.git/pkg/promtext"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// PathPrefix is the path prefix of the push API.
+	PathPrefix = "/metrics/"
+	// pushTimeout bounds storing a push.
+	pushTimeout = 5 * time.Second
+	// jobLabel is the label every grouping key starts with.
+	jobLabel = "job"
+	// base64Suffix marks a label whose value in the path is URL-safe base64 encoded.
+	base64Suffix = "@base64"
+	// pushTimeMetric is the gauge holding the time of the last successful push of a group.
+	pushTimeMetric = "push_time_seconds"
+	// protobufContentType is the content type of the protobuf exposition format, which is not supported.
+	protobufContentType = "application/vnd.google.protobuf"
+)
+
+// errTimestamp rejects samples with timestamps, which Pushgateway does not accept either.
+var errTimestamp = errors.New("pushed metrics must not have timestamps")
+
+// clk provides the request timeouts and push times; tests replace it with a fake clock.
+var clk = clock.Real()
+
+// MetricsPusher defines the interface for storing and deleting pushed metrics.
+type MetricsPusher interface {
+	PushMetrics(context.Context, *entity.Metrics) (*entity.Metrics, error)
+	Delete(ctx context.Context, metricType string, name string) error
+}
+
+// Push handles PUT and POST pushes. A PUT replaces all metrics of the group; a POST replaces only the
+// metrics with the pushed names.
+//
+// Parameters:
+//   - pusher: An implementation of MetricsPusher storing the metrics.
+//   - groups: The registry of the series every group holds.
+//   - replaceGroup: Whether the push replaces the whole group (PUT) or only the pushed metric names (POST).
+//
+// Returns:
+//   - An echo.HandlerFunc handling pushes.
+func Push(pusher MetricsPusher, groups *Groups, replaceGroup bool) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		grouping, err := parseGroupingKey(c.Request().URL.Path)
+		if err != nil {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+		if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), protobufContentType) {
+			return c.String(
+				http.StatusUnsupportedMediaType,
+				"The protobuf format is not supported, push in the text format (text/plain; version=0.0.4).",
+			)
+		}
+
+		samples, err := promtext.Parse(c.Request().Body)
+		if err != nil {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+		for _, s := range samples {
+			if s.HasTimestamp {
+				return c.String(http.StatusBadRequest, fmt.Sprintf("metric %q: %v", s.Name, errTimestamp))
+			}
+		}
+
+		now := clk.Now()
+		pushed := make(map[string]string, len(samples)+1)
+		metrics := make(entity.Metrics, 0, len(samples)+1)
+		add := func(name string, labels map[string]string, value float64) {
+			series := promtext.SeriesName(name, labels)
+			if _, ok := pushed[series]; !ok {
+				metrics = append(metrics, &entity.Metric{Name: series, Type: entity.MetricTypeGauge, Value: value})
+			}
+			pushed[series] = name
+		}
+		for _, s := range samples {
+			if !promtext.IsFinite(s.Value) {
+				continue
+			}
+			for k, v := range grouping {
+				s.Labels[k] = v
+			}
+			add(s.Name, s.Labels, s.Value)
+		}
+		add(pushTimeMetric, grouping, float64(now.UnixNano())/float64(time.Second))
+
+		ctx, cancel := clk.WithTimeout(c.Request().Context(), pushTimeout)
+		defer cancel()
+
+		if _, err = pusher.PushMetrics(ctx, &metrics); err != nil {
+			return pusherr.Respond(c, err)
+		}
+
+		group := promtext.SeriesName("", grouping)
+		var stale []string
+		if replaceGroup {
+			stale = groups.Replace(group, pushed)
+		} else {
+			stale = groups.Merge(group, pushed)
+		}
+		if err = deleteSeries(ctx, pusher, stale); err != nil {
+			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+		return c.NoContent(http.StatusOK)
+	}
+}
+
+// Delete handles DELETE requests, removing all metrics of the group.
+//
+// Parameters:
+//   - pusher: An implementation of MetricsPusher deleting the metrics.
+//   - groups: The registry of the series every group holds.
+//
+// Returns:
+//   - An echo.HandlerFunc handling group deletions.
+func Delete(pusher MetricsPusher, groups *Groups) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		grouping, err := parseGroupingKey(c.Request().URL.Path)
+		if err != nil {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+
+		ctx, cancel := clk.WithTimeout(c.Request().Context(), pushTimeout)
+		defer cancel()
+
+		if err = deleteSeries(ctx, pusher, groups.Remove(promtext.SeriesName("", grouping))); err != nil {
+			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+		return c.NoContent(http.StatusAccepted)
+	}
+}
+
+// parseGroupingKey parses the grouping key from a push path: /metrics/job/<job>{/<label>/<value>}.
+// A label name ending in @base64 carries a URL-safe base64 encoded value, which allows values with slashes.
+func parseGroupingKey(path string) (map[string]string, error) {
+	parts := strings.Split(strings.TrimPrefix(path, PathPrefix), "/")
+	if len(parts)%2 != 0 {
+		return nil, errors.New("the grouping key must consist of label name and value pairs")
+	}
+	if name, _ := strings.CutSuffix(parts[0], base64Suffix); name != jobLabel {
+		return nil, errors.New("the grouping key must start with the job label")
+	}
+
+	grouping := make(map[string]string, len(parts)/2)
+	for i := 0; i < len(parts); i += 2 {
+		name, value := parts[i], parts[i+1]
+		if encoded, ok := strings.CutSuffix(name, base64Suffix); ok {
+			decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+			if err != nil {
+				return nil, fmt.Errorf("label %q: invalid base64 value", encoded)
+			}
+			name, value = encoded, string(decoded)
+		}
+		if !promtext.ValidLabelName(name) {
+			return nil, fmt.Errorf("invalid label name %q", name)
+		}
+		if _, exists := grouping[name]; exists {
+			return nil, fmt.Errorf("label %q is repeated", name)
+		}
+		grouping[name] = value
+	}
+	if grouping[jobLabel] == "" {
+		return nil, errors.New("the job label must not be empty")
+	}
+	return grouping, nil
+}
+
+// deleteSeries deletes the gauges of series that left their group. Series already gone are skipped.
+func deleteSeries(ctx context.Context, pusher MetricsPusher, series []string) error {
+	for _, name := range series {
+		err := pusher.Delete(ctx, entity.MetricTypeGauge, name)
+		if err != nil && !errors.Is(err, controller.ErrNotFoundInRepository) {
+			return fmt.Errorf("failed to delete series %q: %w", name, err)
+		}
+	}
+	return nil
+}
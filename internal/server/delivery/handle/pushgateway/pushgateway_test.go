@@ -0,0 +1,159 @@
+package pushgateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/controller"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/clock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockPusher struct {
+	err     error
+	stored  map[string]any
+	deleted []string
+}
+
+func (m *mockPusher) PushMetrics(_ context.Context, metrics *entity.Metrics) (*entity.Metrics, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for _, metric := range *metrics {
+		m.stored[metric.Name] = metric.Value
+	}
+	return metrics, nil
+}
+
+func (m *mockPusher) Delete(_ context.Context, _ string, name string) error {
+	if _, ok := m.stored[name]; !ok {
+		return controller.ErrNotFoundInRepository
+	}
+	delete(m.stored, name)
+	m.deleted = append(m.deleted, name)
+	return nil
+}
+
+// newTestServer routes the push API like the server does.
+func newTestServer(t *testing.T, pusher MetricsPusher) *echo.Echo {
+	t.Helper()
+	fake := clock.NewFake(time.Unix(1700000000, 0))
+	old := clk
+	clk = fake
+	t.Cleanup(func() { clk = old })
+
+	e := echo.New()
+	groups := NewGroups()
+	e.PUT("/metrics/*", Push(pusher, groups, true))
+	e.POST("/metrics/*", Push(pusher, groups, false))
+	e.DELETE("/metrics/*", Delete(pusher, groups))
+	return e
+}
+
+func do(e *echo.Echo, method, path, body, contentType string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set(echo.HeaderContentType, contentType)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPushAndDelete(t *testing.T) {
+	pusher := &mockPusher{stored: map[string]any{}}
+	e := newTestServer(t, pusher)
+
+	// The instance value "a/b" is base64 encoded in the path.
+	rec := do(e, http.MethodPut, "/metrics/job/batch/instance@base64/YS9i",
+		"# TYPE jobs_total counter\njobs_total 42\nduration_seconds{stage=\"load\",job=\"ignored\"} 1.5\nbroken NaN\n", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, map[string]any{
+		`jobs_total{instance="a/b",job="batch"}`:                    42.0,
+		`duration_seconds{instance="a/b",job="batch",stage="load"}`: 1.5,
+		`push_time_seconds{instance="a/b",job="batch"}`:             1700000000.0,
+	}, pusher.stored)
+
+	// POST replaces the series of the pushed names only.
+	rec = do(e, http.MethodPost, "/metrics/job/batch/instance@base64/YS9i", "duration_seconds{stage=\"save\"} 2\n", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{`duration_seconds{instance="a/b",job="batch",stage="load"}`}, pusher.deleted)
+	assert.Contains(t, pusher.stored, `jobs_total{instance="a/b",job="batch"}`)
+
+	// PUT replaces the whole group.
+	pusher.deleted = nil
+	rec = do(e, http.MethodPut, "/metrics/job/batch/instance@base64/YS9i", "jobs_total 43\n", "text/plain; version=0.0.4")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{`duration_seconds{instance="a/b",job="batch",stage="save"}`}, pusher.deleted)
+
+	// DELETE removes the group.
+	pusher.deleted = nil
+	rec = do(e, http.MethodDelete, "/metrics/job/batch/instance@base64/YS9i", "", "")
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Empty(t, pusher.stored)
+	assert.Len(t, pusher.deleted, 2)
+}
+
+func TestPush_Errors(t *testing.T) {
+	tests := []struct {
+		pusherErr      error
+		name           string
+		path           string
+		body           string
+		contentType    string
+		retryAfter     string
+		expectedStatus int
+	}{
+		{name: "Missing label value", path: "/metrics/job/batch/instance", body: "up 1", expectedStatus: 400},
+		{name: "Empty job", path: "/metrics/job@base64/=", body: "up 1", expectedStatus: 400},
+		{name: "Invalid base64", path: "/metrics/job@base64/!!", body: "up 1", expectedStatus: 400},
+		{name: "Invalid label name", path: "/metrics/job/batch/1a/x", body: "up 1", expectedStatus: 400},
+		{name: "Repeated label", path: "/metrics/job/batch/job/other", body: "up 1", expectedStatus: 400},
+		{name: "Malformed body", path: "/metrics/job/batch", body: "up one", expectedStatus: 400},
+		{
+			name:           "Protobuf body",
+			path:           "/metrics/job/batch",
+			body:           "\x00",
+			contentType:    "application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=delimited",
+			expectedStatus: 415,
+		},
+		{
+			name:           "Rate limited",
+			path:           "/metrics/job/batch",
+			body:           "up 1",
+			pusherErr:      controller.ErrRateLimited,
+			retryAfter:     "1",
+			expectedStatus: 429,
+		},
+		{
+			name:           "Out of order",
+			path:           "/metrics/job/batch",
+			body:           "up 1",
+			pusherErr:      controller.ErrOutOfOrder,
+			expectedStatus: 409,
+		},
+		{
+			name:           "Name not allowed",
+			path:           "/metrics/job/batch",
+			body:           "up 1",
+			pusherErr:      controller.ErrMetricNotAllowed,
+			expectedStatus: 403,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestServer(t, &mockPusher{stored: map[string]any{}, err: tt.pusherErr})
+			rec := do(e, http.MethodPut, tt.path, tt.body, tt.contentType)
+			assert.Equal(t, tt.expectedStatus, rec.Code, rec.Body.String())
+			assert.Equal(t, tt.retryAfter, rec.Header().Get(echo.HeaderRetryAfter))
+		})
+	}
+}
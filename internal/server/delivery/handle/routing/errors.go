@@ -0,0 +1,159 @@
+// Package routing answers requests that match no route, or match a route with another method,
+// with structured JSON errors instead of the generic Echo error bodies.
+package routing
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxHintDistance is the largest number of edited characters for which a route is suggested as a hint.
+const maxHintDistance = 3
+
+// errorResponse is the JSON body returned for unknown routes and unsupported methods.
+type errorResponse struct {
+	Error          string   `json:"error"`                     // Error describes the failure.
+	Method         string   `json:"method"`                    // Method is the method of the request.
+	Path           string   `json:"path"`                      // Path is the path of the request.
+	Hint           string   `json:"hint,omitempty"`            // Hint is the closest registered route, if any.
+	AllowedMethods []string `json:"allowed_methods,omitempty"` // AllowedMethods lists the methods the path supports.
+}
+
+// ErrorHandler creates an Echo error handler reporting unknown routes and unsupported methods as JSON.
+// A 404 response carries the closest registered route as a hint, a 405 response the methods the path supports.
+// Any other error is passed to the default Echo error handler.
+//
+// Parameters:
+//   - e: The Echo instance whose routes are used for hints.
+//
+// Returns:
+//   - echo.HTTPErrorHandler: The error handler.
+func ErrorHandler(e *echo.Echo) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+
+		req := c.Request()
+		resp := errorResponse{Method: req.Method, Path: req.URL.Path}
+		var status int
+		switch {
+		case errors.Is(err, echo.ErrNotFound):
+			status = http.StatusNotFound
+			resp.Error = "route not found"
+			resp.Hint = closestRoute(e.Routes(), req.URL.Path)
+		case errors.Is(err, echo.ErrMethodNotAllowed):
+			status = http.StatusMethodNotAllowed
+			resp.Error = "method not allowed"
+			resp.AllowedMethods = allowedMethods(c.Response().Header().Get(echo.HeaderAllow))
+		default:
+			e.DefaultHTTPErrorHandler(err, c)
+			return
+		}
+
+		if writeErr := c.JSON(status, resp); writeErr != nil {
+			e.Logger.Error(writeErr)
+		}
+	}
+}
+
+// allowedMethods splits the value of an Allow header into a sorted list of methods.
+//
+// Parameters:
+//   - header: The Allow header value, e.g. "OPTIONS, POST".
+//
+// Returns:
+//   - []string: The allowed methods.
+func allowedMethods(header string) []string {
+	methods := make([]string, 0)
+	for _, method := range strings.Split(header, ",") {
+		if method = strings.TrimSpace(method); method != "" {
+			methods = append(methods, method)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// closestRoute finds the registered route closest to a path that matched none.
+// Routes are compared segment by segment: parameters match any segment and static segments
+// add their edit distance to the request segment.
+//
+// Parameters:
+//   - routes: The registered routes.
+//   - path: The request path.
+//
+// Returns:
+//   - string: The closest route as "METHOD /path", or an empty string if no route is close enough.
+func closestRoute(routes []*echo.Route, path string) string {
+	requested := splitPath(path)
+
+	best, bestDistance := "", maxHintDistance+1
+	for _, route := range routes {
+		if route.Method == echo.RouteNotFound || strings.Contains(route.Path, "*") {
+			continue
+		}
+		distance, ok := routeDistance(splitPath(route.Path), requested)
+		if !ok || distance == 0 {
+			continue
+		}
+		candidate := route.Method + " " + route.Path
+		if distance < bestDistance || (distance == bestDistance && candidate < best) {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// routeDistance computes the edit distance between a route pattern and a request path.
+//
+// Parameters:
+//   - pattern: The segments of the route pattern.
+//   - requested: The segments of the request path.
+//
+// Returns:
+//   - int: The number of edited characters.
+//   - bool: False if the route has a different number of segments.
+func routeDistance(pattern, requested []string) (int, bool) {
+	if len(pattern) != len(requested) {
+		return 0, false
+	}
+	distance := 0
+	for i, segment := range pattern {
+		if strings.HasPrefix(segment, ":") {
+			continue
+		}
+		distance += editDistance(segment, requested[i])
+	}
+	return distance, true
+}
+
+// splitPath splits a path into its non-empty segments.
+func splitPath(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+}
+
+// editDistance computes the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+	return prev[len(b)]
+}
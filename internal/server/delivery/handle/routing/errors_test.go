@@ -0,0 +1,130 @@
+package routing
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEcho() *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = ErrorHandler(e)
+
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.POST("/update", ok)
+	e.POST("/update/:type/:id/:value", ok)
+	e.GET("/value/:type/:id", ok)
+	e.GET("/ping", ok)
+	e.GET("/fail", func(echo.Context) error {
+		return echo.NewHTTPError(http.StatusBadRequest, "bad request")
+	})
+	return e
+}
+
+func TestErrorHandler(t *testing.T) {
+	tests := []struct {
+		expected     *errorResponse
+		name         string
+		method       string
+		path         string
+		expectedCode int
+	}{
+		{
+			name:         "Unknown route with hint",
+			method:       http.MethodPost,
+			path:         "/updte/gauge/x/1",
+			expectedCode: http.StatusNotFound,
+			expected: &errorResponse{
+				Error:  "route not found",
+				Method: http.MethodPost,
+				Path:   "/updte/gauge/x/1",
+				Hint:   "POST /update/:type/:id/:value",
+			},
+		},
+		{
+			name:         "Unknown route without hint",
+			method:       http.MethodGet,
+			path:         "/completely/different/path",
+			expectedCode: http.StatusNotFound,
+			expected: &errorResponse{
+				Error:  "route not found",
+				Method: http.MethodGet,
+				Path:   "/completely/different/path",
+			},
+		},
+		{
+			name:         "Method not allowed",
+			method:       http.MethodGet,
+			path:         "/update",
+			expectedCode: http.StatusMethodNotAllowed,
+			expected: &errorResponse{
+				Error:          "method not allowed",
+				Method:         http.MethodGet,
+				Path:           "/update",
+				AllowedMethods: []string{http.MethodOptions, http.MethodPost},
+			},
+		},
+		{
+			name:         "Other errors use the default handler",
+			method:       http.MethodGet,
+			path:         "/fail",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEcho()
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, http.NoBody))
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			if tt.expected == nil {
+				assert.JSONEq(t, `{"message":"bad request"}`, rec.Body.String())
+				return
+			}
+			var resp errorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, *tt.expected, resp)
+		})
+	}
+}
+
+func TestClosestRoute(t *testing.T) {
+	routes := []*echo.Route{
+		{Method: http.MethodPost, Path: "/updates"},
+		{Method: http.MethodPost, Path: "/update"},
+		{Method: http.MethodGet, Path: "/value/:type/:id"},
+		{Method: echo.RouteNotFound, Path: "/admin/*"},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{name: "Typo in static segment", path: "/updatess", expected: "POST /updates"},
+		{name: "Parameters match any segment", path: "/valeu/gauge/x", expected: "GET /value/:type/:id"},
+		{name: "Ties resolved by name", path: "/updat", expected: "POST /update"},
+		{name: "Different segment count", path: "/value/gauge", expected: ""},
+		{name: "Too far", path: "/metrics", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, closestRoute(routes, tt.path))
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("update", "update"))
+	assert.Equal(t, 1, editDistance("update", "updte"))
+	assert.Equal(t, 2, editDistance("value", "valeu"))
+	assert.Equal(t, 6, editDistance("", "update"))
+}
@@ -2,18 +2,24 @@ package update
 
 import (
 	"context"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/gdyunin/metricol.git/internal/server/delivery/handle/pusherr"
 	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
 	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/clock"
 
 	"github.com/labstack/echo/v4"
 )
 
 const metricUpdateTimeout = 5 * time.Second
 
+// clk provides the request timeouts; tests replace it with a fake clock.
+var clk = clock.Real()
+
 // MetricsUpdater defines the interface for pushing metric updates.
 type MetricsUpdater interface {
 	PushMetric(context.Context, *entity.Metric) (*entity.Metric, error)
@@ -33,12 +39,12 @@ func FromJSON(updater MetricsUpdater) echo.HandlerFunc {
 			return c.String(http.StatusBadRequest, "Invalid JSON payload provided.")
 		}
 
-		ctx, cancel := context.WithTimeout(c.Request().Context(), metricUpdateTimeout)
+		ctx, cancel := clk.WithTimeout(c.Request().Context(), metricUpdateTimeout)
 		defer cancel()
 
 		updated, err := updater.PushMetric(ctx, m.ToEntityMetric())
 		if err != nil {
-			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return pusherr.Respond(c, err)
 		}
 
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -65,12 +71,12 @@ func FromURI(updater MetricsUpdater) echo.HandlerFunc {
 			return c.String(err.(*echo.HTTPError).Code, err.Error()) //nolint
 		}
 
-		ctx, cancel := context.WithTimeout(c.Request().Context(), metricUpdateTimeout)
+		ctx, cancel := clk.WithTimeout(c.Request().Context(), metricUpdateTimeout)
 		defer cancel()
 
 		_, err := updater.PushMetric(ctx, m.ToEntityMetric())
 		if err != nil {
-			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return pusherr.Respond(c, err)
 		}
 
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlain)
@@ -104,9 +110,36 @@ func validateMetricValue(m *model.Metric, valueStr string) error {
 			return echo.NewHTTPError(http.StatusBadRequest, "Provided gauge value is invalid.")
 		}
 		m.Value = &value
+	case entity.MetricTypeFloatCounter:
+		value, err := strconv.ParseFloat(valueStr, 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) || value < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "Provided float counter value is invalid.")
+		}
+		m.Value = &value
+	case entity.MetricTypeInfo:
+		m.Info = parseInfoValue(valueStr)
 	default:
 		return echo.NewHTTPError(http.StatusBadRequest, "Unsupported metric type.")
 	}
 
 	return nil
 }
+
+// parseInfoValue converts an info value taken from the URI.
+// The exact strings "true" and "false" become booleans; anything else is kept as a string.
+//
+// Parameters:
+//   - valueStr: The value string extracted from URI parameters.
+//
+// Returns:
+//   - any: A bool or a string.
+func parseInfoValue(valueStr string) any {
+	switch valueStr {
+	case "true":
+		return true
+	case "false":
+		return false
+	default:
+		return valueStr
+	}
+}
@@ -13,7 +13,9 @@ import (
 	"time"
 
 	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+	"github.com/gdyunin/metricol.git/internal/server/internal/controller"
 	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/clock"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -22,18 +24,20 @@ import (
 // MockMetricsUpdater implements the MetricsUpdater interface for testing.
 type MockMetricsUpdater struct {
 	ReturnedMetric *entity.Metric
-	Delay          time.Duration
+	Err            error
 	ShouldFail     bool
+	Block          bool // Block makes PushMetric wait until the context ends.
 }
 
 // PushMetric implements the MetricsUpdater interface.
 func (m *MockMetricsUpdater) PushMetric(ctx context.Context, metric *entity.Metric) (*entity.Metric, error) {
-	if m.Delay > 0 {
-		select {
-		case <-time.After(m.Delay):
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		}
+	if m.Block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	if m.Err != nil {
+		return nil, m.Err
 	}
 
 	if m.ShouldFail {
@@ -46,6 +50,23 @@ func (m *MockMetricsUpdater) PushMetric(ctx context.Context, metric *entity.Metr
 	return metric, nil
 }
 
+// expireTimeout replaces the handler clock with a fake one and, if the updater blocks,
+// advances it past metricUpdateTimeout once the handler waits for the update.
+func expireTimeout(t *testing.T, updater MetricsUpdater) {
+	t.Helper()
+
+	fake := clock.NewFake(time.Now())
+	clk = fake
+	t.Cleanup(func() { clk = clock.Real() })
+
+	if mock, ok := updater.(*MockMetricsUpdater); ok && mock.Block {
+		go func() {
+			fake.BlockUntil(1)
+			fake.Advance(metricUpdateTimeout)
+		}()
+	}
+}
+
 func TestFromJSON(t *testing.T) {
 	tests := []struct {
 		updater        MetricsUpdater
@@ -81,6 +102,19 @@ func TestFromJSON(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			checkJSON:      true,
 		},
+		{
+			name: "Valid info metric",
+			updater: &MockMetricsUpdater{
+				ReturnedMetric: &entity.Metric{
+					Name:  "version",
+					Type:  entity.MetricTypeInfo,
+					Value: "v1.2.3",
+				},
+			},
+			requestBody:    `{"id":"version","type":"info","info":"v1.2.3"}`,
+			expectedStatus: http.StatusOK,
+			checkJSON:      true,
+		},
 		{
 			name:           "Invalid JSON payload",
 			updater:        &MockMetricsUpdater{},
@@ -97,10 +131,28 @@ func TestFromJSON(t *testing.T) {
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   http.StatusText(http.StatusInternalServerError),
 		},
+		{
+			name: "PushMetric rate limited",
+			updater: &MockMetricsUpdater{
+				Err: fmt.Errorf("push: %w", controller.ErrRateLimited),
+			},
+			requestBody:    `{"id":"test_limited","type":"counter","delta":42}`,
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody:   "Metric update rate limit exceeded.",
+		},
+		{
+			name: "PushMetric out of order",
+			updater: &MockMetricsUpdater{
+				Err: fmt.Errorf("push: %w", controller.ErrOutOfOrder),
+			},
+			requestBody:    `{"id":"test_gauge","type":"gauge","value":1,"timestamp":"2024-01-01T00:00:00Z"}`,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   "Metric sample is older than the stored one.",
+		},
 		{
 			name: "Timeout",
 			updater: &MockMetricsUpdater{
-				Delay: 6 * time.Second, // Longer than metricUpdateTimeout (5s).
+				Block: true,
 			},
 			requestBody:    `{"id":"test_timeout","type":"counter","delta":42}`,
 			expectedStatus: http.StatusInternalServerError,
@@ -116,6 +168,7 @@ func TestFromJSON(t *testing.T) {
 			rec := httptest.NewRecorder()
 			c := e.NewContext(req, rec)
 
+			expireTimeout(t, tt.updater)
 			handler := FromJSON(tt.updater)
 			err := handler(c)
 
@@ -176,6 +229,16 @@ func TestFromURI(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedBody:   "Metric update successful.",
 		},
+		{
+			name:    "Valid info metric",
+			updater: &MockMetricsUpdater{},
+			setupContext: func(c echo.Context) {
+				c.SetParamNames("type", "id", "value")
+				c.SetParamValues("info", "version", "v1.2.3")
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "Metric update successful.",
+		},
 		{
 			name:    "Missing value parameter",
 			updater: &MockMetricsUpdater{},
@@ -228,10 +291,22 @@ func TestFromURI(t *testing.T) {
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   http.StatusText(http.StatusInternalServerError),
 		},
+		{
+			name: "PushMetric rate limited",
+			updater: &MockMetricsUpdater{
+				Err: fmt.Errorf("push: %w", controller.ErrRateLimited),
+			},
+			setupContext: func(c echo.Context) {
+				c.SetParamNames("type", "id", "value")
+				c.SetParamValues("counter", "test_limited", "42")
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody:   "Metric update rate limit exceeded.",
+		},
 		{
 			name: "Timeout",
 			updater: &MockMetricsUpdater{
-				Delay: 6 * time.Second, // Longer than metricUpdateTimeout (5s).
+				Block: true,
 			},
 			setupContext: func(c echo.Context) {
 				c.SetParamNames("type", "id", "value")
@@ -253,6 +328,7 @@ func TestFromURI(t *testing.T) {
 				tt.setupContext(c)
 			}
 
+			expireTimeout(t, tt.updater)
 			handler := FromURI(tt.updater)
 			err := handler(c)
 
@@ -320,6 +396,55 @@ func TestValidateMetricValue(t *testing.T) {
 			valueStr:   "42.5",
 			shouldPass: true,
 		},
+		{
+			name: "Valid string info value",
+			metric: &model.Metric{
+				ID:    "version",
+				MType: entity.MetricTypeInfo,
+			},
+			valueStr:   "v1.2.3",
+			shouldPass: true,
+		},
+		{
+			name: "Valid boolean info value",
+			metric: &model.Metric{
+				ID:    "feature",
+				MType: entity.MetricTypeInfo,
+			},
+			valueStr:   "true",
+			shouldPass: true,
+		},
+		{
+			name: "Valid float counter value",
+			metric: &model.Metric{
+				ID:    "cpu_seconds",
+				MType: entity.MetricTypeFloatCounter,
+			},
+			valueStr:   "0.25",
+			shouldPass: true,
+		},
+		{
+			name: "Negative float counter value",
+			metric: &model.Metric{
+				ID:    "cpu_seconds",
+				MType: entity.MetricTypeFloatCounter,
+			},
+			valueStr:   "-0.25",
+			shouldPass: false,
+			errorCode:  http.StatusBadRequest,
+			errorMsg:   "Provided float counter value is invalid.",
+		},
+		{
+			name: "Infinite float counter value",
+			metric: &model.Metric{
+				ID:    "cpu_seconds",
+				MType: entity.MetricTypeFloatCounter,
+			},
+			valueStr:   "+Inf",
+			shouldPass: false,
+			errorCode:  http.StatusBadRequest,
+			errorMsg:   "Provided float counter value is invalid.",
+		},
 		{
 			name: "Empty value",
 			metric: &model.Metric{
@@ -378,10 +503,12 @@ func TestValidateMetricValue(t *testing.T) {
 					require.NotNil(t, tt.metric.Delta)
 					expectedDelta, _ := strconv.ParseInt(tt.valueStr, 10, 64)
 					assert.Equal(t, expectedDelta, *tt.metric.Delta)
-				case entity.MetricTypeGauge:
+				case entity.MetricTypeGauge, entity.MetricTypeFloatCounter:
 					require.NotNil(t, tt.metric.Value)
 					expectedValue, _ := strconv.ParseFloat(tt.valueStr, 64)
 					assert.Equal(t, expectedValue, *tt.metric.Value)
+				case entity.MetricTypeInfo:
+					assert.Equal(t, parseInfoValue(tt.valueStr), tt.metric.Info)
 				}
 			} else {
 				require.Error(t, err)
@@ -461,3 +588,22 @@ func ExampleFromURI() {
 	// Output:
 	// Metric update successful.
 }
+
+func TestParseInfoValue(t *testing.T) {
+	tests := []struct {
+		expected any
+		name     string
+		valueStr string
+	}{
+		{name: "String", valueStr: "v1.2.3", expected: "v1.2.3"},
+		{name: "True", valueStr: "true", expected: true},
+		{name: "False", valueStr: "false", expected: false},
+		{name: "Not an exact boolean", valueStr: "1", expected: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseInfoValue(tt.valueStr))
+		})
+	}
+}
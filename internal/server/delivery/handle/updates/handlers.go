@@ -5,13 +5,18 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/gdyunin/metricol.git/internal/server/delivery/handle/pusherr"
 	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
 	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/clock"
 	"github.com/labstack/echo/v4"
 )
 
 const metricUpdateTimeout = 5 * time.Second
 
+// clk provides the request timeouts; tests replace it with a fake clock.
+var clk = clock.Real()
+
 // MetricsUpdater defines the interface for pushing metric updates.
 type MetricsUpdater interface {
 	PushMetrics(context.Context, *entity.Metrics) (*entity.Metrics, error)
@@ -40,12 +45,12 @@ func FromJSON(updater MetricsUpdater) echo.HandlerFunc {
 			metrics = append(metrics, m.ToEntityMetric())
 		}
 
-		ctx, cancel := context.WithTimeout(c.Request().Context(), metricUpdateTimeout)
+		ctx, cancel := clk.WithTimeout(c.Request().Context(), metricUpdateTimeout)
 		defer cancel()
 
 		updatedMetrics, err := updater.PushMetrics(ctx, &metrics)
 		if err != nil {
-			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return pusherr.Respond(c, err)
 		}
 
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -55,6 +60,7 @@ func FromJSON(updater MetricsUpdater) echo.HandlerFunc {
 
 // isValidMetric validates the structure of a metric.
 // A valid metric must have a non-empty ID and type, and at least one non-nil value field.
+// Info metrics carry their value in the Info field.
 //
 // Parameters:
 //   - m: A pointer to a model.Metric object to be validated.
@@ -62,5 +68,6 @@ func FromJSON(updater MetricsUpdater) echo.HandlerFunc {
 // Returns:
 //   - A boolean indicating whether the metric is valid.
 func isValidMetric(m *model.Metric) bool {
-	return m.ID != "" && m.MType != "" && (m.Delta != nil || m.Value != nil)
+	hasValue := m.Delta != nil || m.Value != nil || (m.MType == entity.MetricTypeInfo && m.Info != nil)
+	return m.ID != "" && m.MType != "" && hasValue
 }
@@ -9,9 +9,13 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+	"github.com/gdyunin/metricol.git/internal/server/internal/controller"
 	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/internal/quota"
+	"github.com/gdyunin/metricol.git/pkg/clock"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -90,6 +94,29 @@ func TestFromJSON(t *testing.T) {
 			expectedBody:   `[{"id":"test_gauge","type":"gauge","value":3.14}]`,
 			validateJSON:   true,
 		},
+		{
+			name:        "Valid info metric",
+			requestBody: `[{"id":"version","type":"info","info":"v1.2.3"}]`,
+			mockSetup: func(m *MockMetricsUpdater) {
+				expectedMetrics := &entity.Metrics{
+					{
+						Name:  "version",
+						Type:  entity.MetricTypeInfo,
+						Value: "v1.2.3",
+					},
+				}
+				m.On("PushMetrics", mock.Anything, mock.MatchedBy(func(metrics *entity.Metrics) bool {
+					if len(*metrics) != 1 {
+						return false
+					}
+					metric := (*metrics)[0]
+					return metric.Name == "version" && metric.Type == entity.MetricTypeInfo && metric.Value == "v1.2.3"
+				})).Return(expectedMetrics, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"id":"version","type":"info","info":"v1.2.3"}]`,
+			validateJSON:   true,
+		},
 		{
 			name:        "Multiple metrics",
 			requestBody: `[{"id":"counter1","type":"counter","delta":1},{"id":"gauge1","type":"gauge","value":2.5}]`,
@@ -154,6 +181,65 @@ func TestFromJSON(t *testing.T) {
 			expectedBody:   http.StatusText(http.StatusInternalServerError),
 			validateJSON:   false,
 		},
+		{
+			name:        "PushMetrics rate limited",
+			requestBody: `[{"id":"test_counter","type":"counter","delta":5}]`,
+			mockSetup: func(m *MockMetricsUpdater) {
+				m.On("PushMetrics", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("push: %w", controller.ErrRateLimited))
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody:   "Metric update rate limit exceeded.",
+			validateJSON:   false,
+		},
+		{
+			name:        "PushMetrics cardinality limit",
+			requestBody: `[{"id":"test_counter","type":"counter","delta":5}]`,
+			mockSetup: func(m *MockMetricsUpdater) {
+				m.On("PushMetrics", mock.Anything, mock.Anything).Return(nil, fmt.Errorf(
+					"push: %w", &controller.CardinalityError{Metric: "counter/test_counter", Limit: 1},
+				))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody: "metric cardinality limit exceeded: at most 1 distinct metrics allowed, " +
+				"new metric counter/test_counter rejected",
+			validateJSON: false,
+		},
+		{
+			name:        "PushMetrics batch size quota",
+			requestBody: `[{"id":"test_counter","type":"counter","delta":5}]`,
+			mockSetup: func(m *MockMetricsUpdater) {
+				m.On("PushMetrics", mock.Anything, mock.Anything).Return(nil, fmt.Errorf(
+					"push: %w", &quota.Error{KeyID: "*", Quota: quota.QuotaBatch, Limit: 0},
+				))
+			},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedBody:   "batch size quota exceeded: key * allows at most 0 metrics per request",
+			validateJSON:   false,
+		},
+		{
+			name:        "PushMetrics updates quota",
+			requestBody: `[{"id":"test_counter","type":"counter","delta":5}]`,
+			mockSetup: func(m *MockMetricsUpdater) {
+				m.On("PushMetrics", mock.Anything, mock.Anything).Return(nil, fmt.Errorf(
+					"push: %w", &quota.Error{KeyID: "*", Quota: quota.QuotaUpdates, Limit: 100},
+				))
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody:   "quota exceeded: key * allows at most 100 metric updates per minute",
+			validateJSON:   false,
+		},
+		{
+			name:        "PushMetrics metric name not allowed",
+			requestBody: `[{"id":"test_counter","type":"counter","delta":5}]`,
+			mockSetup: func(m *MockMetricsUpdater) {
+				m.On("PushMetrics", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("push: %w", controller.ErrMetricNotAllowed))
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   "Metric name is not allowed.",
+			validateJSON:   false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -237,6 +323,24 @@ func TestIsValidMetric(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			name: "Valid info metric",
+			metric: &model.Metric{
+				ID:    "version",
+				MType: entity.MetricTypeInfo,
+				Info:  "v1.2.3",
+			},
+			expected: true,
+		},
+		{
+			name: "Info value on another type",
+			metric: &model.Metric{
+				ID:    "version",
+				MType: entity.MetricTypeGauge,
+				Info:  "v1.2.3",
+			},
+			expected: false,
+		},
 		{
 			name: "Empty ID",
 			metric: &model.Metric{
@@ -312,3 +416,31 @@ func ExampleFromJSON() {
 	// Output:
 	// [{"delta":5,"id":"test_counter","type":"counter"}]
 }
+
+func TestFromJSON_Timeout(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	clk = fake
+	t.Cleanup(func() { clk = clock.Real() })
+
+	updater := new(MockMetricsUpdater)
+	updater.On("PushMetrics", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			ctx, _ := args.Get(0).(context.Context)
+			<-ctx.Done()
+		}).
+		Return(nil, context.DeadlineExceeded)
+
+	go func() {
+		fake.BlockUntil(1)
+		fake.Advance(metricUpdateTimeout)
+	}()
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/updates", strings.NewReader(`[{"id":"x","type":"gauge","value":1}]`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	assert.NoError(t, FromJSON(updater)(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	updater.AssertExpectations(t)
+}
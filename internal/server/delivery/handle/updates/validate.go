@@ -0,0 +1,91 @@
+package updates
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+	"github.com/gdyunin/metricol.git/internal/server/internal/controller"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/labstack/echo/v4"
+)
+
+// MetricsValidator defines the interface for checking metric updates without storing them.
+type MetricsValidator interface {
+	ValidateMetrics(context.Context, *entity.Metrics) (*controller.ValidationResult, error)
+}
+
+// validationResponse is the JSON body returned by Validate.
+type validationResponse struct {
+	Error    string   `json:"error,omitempty"`   // Error explains why the batch would be rejected.
+	Dropped  []string `json:"dropped,omitempty"` // Dropped lists metrics the server would silently drop.
+	Stale    []string `json:"stale,omitempty"`   // Stale lists metrics older than the stored ones.
+	New      []string `json:"new,omitempty"`     // New lists metrics that do not exist yet.
+	Accepted int      `json:"accepted"`          // Accepted is the number of metrics that would be stored.
+	Valid    bool     `json:"valid"`             // Valid reports whether the batch would be accepted.
+}
+
+// Validate handles dry-run requests for batch metric updates. The body is checked exactly like a
+// POST /updates request, but nothing is stored, so payloads can be tested against a production server.
+// It responds with 200 OK and a report if the batch would be accepted, and otherwise with the status
+// the update would fail with and the reason.
+//
+// Parameters:
+//   - validator: An implementation of the MetricsValidator interface used to check the metrics.
+//
+// Returns:
+//   - An echo.HandlerFunc to handle the HTTP request and response cycle.
+func Validate(validator MetricsValidator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		models := model.Metrics{}
+		if err := c.Bind(&models); err != nil {
+			return c.JSON(http.StatusBadRequest, validationResponse{Error: "request body is not a JSON array of metrics"})
+		}
+
+		metrics := make(entity.Metrics, 0, len(models))
+		for _, m := range models {
+			if !isValidMetric(m) {
+				return c.JSON(http.StatusBadRequest, validationResponse{
+					Error: "metric must have an id, a type and a value: " + m.ID,
+				})
+			}
+			metrics = append(metrics, m.ToEntityMetric())
+		}
+
+		ctx, cancel := clk.WithTimeout(c.Request().Context(), metricUpdateTimeout)
+		defer cancel()
+
+		result, err := validator.ValidateMetrics(ctx, &metrics)
+		if err != nil {
+			status := validationErrorStatus(err)
+			if status == http.StatusInternalServerError {
+				return c.JSON(status, validationResponse{Error: http.StatusText(status)})
+			}
+			return c.JSON(status, validationResponse{Error: err.Error()})
+		}
+
+		return c.JSON(http.StatusOK, validationResponse{
+			Dropped:  result.Dropped,
+			Stale:    result.Stale,
+			New:      result.New,
+			Accepted: result.Accepted,
+			Valid:    true,
+		})
+	}
+}
+
+// validationErrorStatus returns the status a POST /updates request would fail with.
+func validationErrorStatus(err error) int {
+	var cardinalityErr *controller.CardinalityError
+	switch {
+	case errors.Is(err, controller.ErrInvalidMetric):
+		return http.StatusBadRequest
+	case errors.Is(err, controller.ErrMetricNotAllowed):
+		return http.StatusForbidden
+	case errors.As(err, &cardinalityErr):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,138 @@
+package updates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/controller"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockMetricsValidator is a mock implementation of the MetricsValidator interface.
+type MockMetricsValidator struct {
+	mock.Mock
+}
+
+// ValidateMetrics implements the MetricsValidator interface.
+func (m *MockMetricsValidator) ValidateMetrics(
+	ctx context.Context,
+	metrics *entity.Metrics,
+) (*controller.ValidationResult, error) {
+	args := m.Called(ctx, metrics)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*controller.ValidationResult), args.Error(1)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		mockSetup      func(*MockMetricsValidator)
+		name           string
+		requestBody    string
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name:        "Valid batch",
+			requestBody: `[{"id":"load","type":"gauge","value":0.5},{"id":"hits","type":"counter","delta":1}]`,
+			mockSetup: func(m *MockMetricsValidator) {
+				m.On("ValidateMetrics", mock.Anything, mock.MatchedBy(func(metrics *entity.Metrics) bool {
+					return metrics.Length() == 2
+				})).Return(&controller.ValidationResult{
+					Dropped:  []string{},
+					Stale:    []string{},
+					New:      []string{"counter/hits"},
+					Accepted: 2,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"new":["counter/hits"],"accepted":2,"valid":true}`,
+		},
+		{
+			name:        "Info metric",
+			requestBody: `[{"id":"version","type":"info","info":true}]`,
+			mockSetup: func(m *MockMetricsValidator) {
+				m.On("ValidateMetrics", mock.Anything, mock.MatchedBy(func(metrics *entity.Metrics) bool {
+					return metrics.Length() == 1 && (*metrics)[0].Value == true
+				})).Return(&controller.ValidationResult{
+					Dropped:  []string{},
+					Stale:    []string{},
+					New:      []string{"info/version"},
+					Accepted: 1,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"new":["info/version"],"accepted":1,"valid":true}`,
+		},
+		{
+			name:           "Malformed body",
+			requestBody:    `{"id":`,
+			mockSetup:      func(*MockMetricsValidator) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"request body is not a JSON array of metrics","accepted":0,"valid":false}`,
+		},
+		{
+			name:           "Metric without value",
+			requestBody:    `[{"id":"load","type":"gauge"}]`,
+			mockSetup:      func(*MockMetricsValidator) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"error":"metric must have an id, a type and a value: load","accepted":0,"valid":false}`,
+		},
+		{
+			name:        "Name not allowed",
+			requestBody: `[{"id":"load","type":"gauge","value":0.5}]`,
+			mockSetup: func(m *MockMetricsValidator) {
+				m.On("ValidateMetrics", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("rejected: %w", controller.ErrMetricNotAllowed))
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"error":"rejected: metric name is not allowed","accepted":0,"valid":false}`,
+		},
+		{
+			name:        "Cardinality limit",
+			requestBody: `[{"id":"load","type":"gauge","value":0.5}]`,
+			mockSetup: func(m *MockMetricsValidator) {
+				m.On("ValidateMetrics", mock.Anything, mock.Anything).
+					Return(nil, &controller.CardinalityError{Metric: "gauge/load", Limit: 1})
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody: `{"error":"metric cardinality limit exceeded: at most 1 distinct metrics allowed, ` +
+				`new metric gauge/load rejected","accepted":0,"valid":false}`,
+		},
+		{
+			name:        "Repository failure",
+			requestBody: `[{"id":"load","type":"gauge","value":0.5}]`,
+			mockSetup: func(m *MockMetricsValidator) {
+				m.On("ValidateMetrics", mock.Anything, mock.Anything).Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":"Internal Server Error","accepted":0,"valid":false}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := new(MockMetricsValidator)
+			tt.mockSetup(validator)
+
+			req := httptest.NewRequest(http.MethodPost, "/updates/validate", strings.NewReader(tt.requestBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+
+			require.NoError(t, Validate(validator)(echo.New().NewContext(req, rec)))
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+			validator.AssertExpectations(t)
+		})
+	}
+}
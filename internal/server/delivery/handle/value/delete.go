@@ -0,0 +1,93 @@
+package value
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+	"github.com/gdyunin/metricol.git/internal/server/internal/controller"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MetricsDeleter defines the interface for deleting metrics.
+type MetricsDeleter interface {
+	Delete(ctx context.Context, metricType string, name string) error
+}
+
+// DeleteFromJSON handles HTTP requests to delete a metric identified by a JSON payload
+// with "id" and "type" fields. The metric is soft-deleted and can be restored with /admin/undelete
+// until its tombstone is purged.
+//
+// Parameters:
+//   - deleter: An implementation of MetricsDeleter to delete metrics.
+//
+// Returns:
+//   - An echo.HandlerFunc that deletes the metric and responds with its identity in JSON format.
+func DeleteFromJSON(deleter MetricsDeleter) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		m := model.Metric{}
+		if err := c.Bind(&m); err != nil || m.ID == "" || m.MType == "" {
+			return c.String(http.StatusBadRequest, "Metric type and id are required.")
+		}
+
+		ctx, cancel := clk.WithTimeout(c.Request().Context(), metricUpdateTimeout)
+		defer cancel()
+
+		if err := deleteMetric(ctx, deleter, m); err != nil {
+			return c.String(err.(*echo.HTTPError).Code, err.Error()) //nolint
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		return c.JSON(http.StatusOK, model.Metric{ID: m.ID, MType: m.MType})
+	}
+}
+
+// DeleteFromURI handles HTTP requests to delete a metric identified by URI parameters (/value/:type/:id).
+// The metric is soft-deleted and can be restored with /admin/undelete until its tombstone is purged.
+//
+// Parameters:
+//   - deleter: An implementation of MetricsDeleter to delete metrics.
+//
+// Returns:
+//   - An echo.HandlerFunc that deletes the metric and responds with 200 OK.
+func DeleteFromURI(deleter MetricsDeleter) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		m := model.Metric{}
+		if err := c.Bind(&m); err != nil || m.ID == "" || m.MType == "" {
+			return c.String(http.StatusBadRequest, "Metric type and id are required.")
+		}
+
+		ctx, cancel := clk.WithTimeout(c.Request().Context(), metricUpdateTimeout)
+		defer cancel()
+
+		if err := deleteMetric(ctx, deleter, m); err != nil {
+			return c.String(err.(*echo.HTTPError).Code, err.Error()) //nolint
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlain)
+		return c.String(http.StatusOK, http.StatusText(http.StatusOK))
+	}
+}
+
+// deleteMetric deletes a metric using the provided deleter.
+//
+// Parameters:
+//   - deleter: The MetricsDeleter instance used to delete the metric.
+//   - m: The Metric model containing the type and ID of the metric.
+//
+// Returns:
+//   - An error response if the metric is not found or if an error occurs during deletion.
+func deleteMetric(ctx context.Context, deleter MetricsDeleter, m model.Metric) error {
+	if err := deleter.Delete(ctx, m.MType, m.ID); err != nil {
+		if errors.Is(err, controller.ErrNotFoundInRepository) {
+			return echo.NewHTTPError(http.StatusNotFound, "Metric not found in the repository.")
+		}
+		return echo.NewHTTPError(
+			http.StatusInternalServerError,
+			http.StatusText(http.StatusInternalServerError),
+		)
+	}
+	return nil
+}
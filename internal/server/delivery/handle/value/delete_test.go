@@ -0,0 +1,136 @@
+package value
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/controller"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockMetricsDeleter is a mock implementation of the MetricsDeleter interface.
+type MockMetricsDeleter struct {
+	mock.Mock
+}
+
+// Delete implements the MetricsDeleter interface.
+func (m *MockMetricsDeleter) Delete(ctx context.Context, metricType string, name string) error {
+	return m.Called(ctx, metricType, name).Error(0)
+}
+
+func TestDeleteFromJSON(t *testing.T) {
+	tests := []struct {
+		mockSetup      func(*MockMetricsDeleter)
+		name           string
+		requestBody    string
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name:        "Deleted",
+			requestBody: `{"id":"stale","type":"gauge"}`,
+			mockSetup: func(m *MockMetricsDeleter) {
+				m.On("Delete", mock.Anything, "gauge", "stale").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":"stale","type":"gauge"}`,
+		},
+		{
+			name:           "Missing id",
+			requestBody:    `{"type":"gauge"}`,
+			mockSetup:      func(*MockMetricsDeleter) {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Metric type and id are required.",
+		},
+		{
+			name:        "Metric not found",
+			requestBody: `{"id":"non_existent","type":"counter"}`,
+			mockSetup: func(m *MockMetricsDeleter) {
+				m.On("Delete", mock.Anything, "counter", "non_existent").Return(controller.ErrNotFoundInRepository)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "Metric not found in the repository.",
+		},
+		{
+			name:        "Repository error",
+			requestBody: `{"id":"error_metric","type":"counter"}`,
+			mockSetup: func(m *MockMetricsDeleter) {
+				m.On("Delete", mock.Anything, "counter", "error_metric").Return(errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   http.StatusText(http.StatusInternalServerError),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			deleter := new(MockMetricsDeleter)
+			tt.mockSetup(deleter)
+
+			req := httptest.NewRequest(http.MethodDelete, "/delete", strings.NewReader(tt.requestBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+
+			require.NoError(t, DeleteFromJSON(deleter)(e.NewContext(req, rec)))
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.expectedBody, normalizeErrorResponse(rec.Body.String()))
+			deleter.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteFromURI(t *testing.T) {
+	tests := []struct {
+		mockSetup      func(*MockMetricsDeleter)
+		name           string
+		metricID       string
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name:     "Deleted",
+			metricID: "stale",
+			mockSetup: func(m *MockMetricsDeleter) {
+				m.On("Delete", mock.Anything, "gauge", "stale").Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   http.StatusText(http.StatusOK),
+		},
+		{
+			name:     "Metric not found",
+			metricID: "non_existent",
+			mockSetup: func(m *MockMetricsDeleter) {
+				m.On("Delete", mock.Anything, "gauge", "non_existent").Return(controller.ErrNotFoundInRepository)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "Metric not found in the repository.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			deleter := new(MockMetricsDeleter)
+			tt.mockSetup(deleter)
+
+			req := httptest.NewRequest(http.MethodDelete, "/value/gauge/"+tt.metricID, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("type", "id")
+			c.SetParamValues("gauge", tt.metricID)
+
+			require.NoError(t, DeleteFromURI(deleter)(c))
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.expectedBody, normalizeErrorResponse(rec.Body.String()))
+			deleter.AssertExpectations(t)
+		})
+	}
+}
@@ -10,12 +10,16 @@ import (
 	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
 	"github.com/gdyunin/metricol.git/internal/server/internal/controller"
 	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/clock"
 
 	"github.com/labstack/echo/v4"
 )
 
 const metricUpdateTimeout = 5 * time.Second
 
+// clk provides the request timeouts; tests replace it with a fake clock.
+var clk = clock.Real()
+
 // MetricsPuller defines the interface for retrieving metrics.
 type MetricsPuller interface {
 	Pull(ctx context.Context, metricType string, name string) (*entity.Metric, error)
@@ -35,7 +39,7 @@ func FromJSON(puller MetricsPuller) echo.HandlerFunc {
 			return c.String(http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
 		}
 
-		ctx, cancel := context.WithTimeout(c.Request().Context(), metricUpdateTimeout)
+		ctx, cancel := clk.WithTimeout(c.Request().Context(), metricUpdateTimeout)
 		defer cancel()
 
 		metric, err := pullMetric(ctx, puller, m)
@@ -62,7 +66,7 @@ func FromURI(puller MetricsPuller) echo.HandlerFunc {
 			return c.String(http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
 		}
 
-		ctx, cancel := context.WithTimeout(c.Request().Context(), metricUpdateTimeout)
+		ctx, cancel := clk.WithTimeout(c.Request().Context(), metricUpdateTimeout)
 		defer cancel()
 
 		metric, err := pullMetric(ctx, puller, m)
@@ -0,0 +1,53 @@
+package value
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+	"github.com/gdyunin/metricol.git/internal/server/internal/controller"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MetricsResetter defines the interface for resetting metrics.
+type MetricsResetter interface {
+	Reset(ctx context.Context, metricType string, name string) (*entity.Metric, error)
+}
+
+// ResetFromURI handles HTTP requests to reset a metric identified by URI parameters (/reset/:type/:id).
+// Counters and float counters are zeroed; metrics of other types are soft-deleted like with DeleteFromURI.
+//
+// Parameters:
+//   - resetter: An implementation of MetricsResetter to reset metrics.
+//
+// Returns:
+//   - An echo.HandlerFunc that resets the metric and responds with the zeroed counter, or the identity
+//     of the deleted metric, in JSON format.
+func ResetFromURI(resetter MetricsResetter) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		m := model.Metric{}
+		if err := c.Bind(&m); err != nil || m.ID == "" || m.MType == "" {
+			return c.String(http.StatusBadRequest, "Metric type and id are required.")
+		}
+
+		ctx, cancel := clk.WithTimeout(c.Request().Context(), metricUpdateTimeout)
+		defer cancel()
+
+		reset, err := resetter.Reset(ctx, m.MType, m.ID)
+		if err != nil {
+			if errors.Is(err, controller.ErrNotFoundInRepository) {
+				return c.String(http.StatusNotFound, "Metric not found in the repository.")
+			}
+			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if reset == nil {
+			return c.JSON(http.StatusOK, model.Metric{ID: m.ID, MType: m.MType})
+		}
+		return c.JSON(http.StatusOK, model.FromEntityMetric(reset))
+	}
+}
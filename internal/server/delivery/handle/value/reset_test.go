@@ -0,0 +1,102 @@
+package value
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/controller"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockMetricsResetter is a mock implementation of the MetricsResetter interface.
+type MockMetricsResetter struct {
+	mock.Mock
+}
+
+// Reset implements the MetricsResetter interface.
+func (m *MockMetricsResetter) Reset(ctx context.Context, metricType string, name string) (*entity.Metric, error) {
+	args := m.Called(ctx, metricType, name)
+	if metric := args.Get(0); metric != nil {
+		return metric.(*entity.Metric), args.Error(1) //nolint:forcetypeassert // for tests
+	}
+	return nil, args.Error(1)
+}
+
+func TestResetFromURI(t *testing.T) {
+	tests := []struct {
+		mockSetup      func(*MockMetricsResetter)
+		name           string
+		metricType     string
+		metricID       string
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name:       "Counter zeroed",
+			metricType: "counter",
+			metricID:   "PollCount",
+			mockSetup: func(m *MockMetricsResetter) {
+				m.On("Reset", mock.Anything, "counter", "PollCount").
+					Return(&entity.Metric{Name: "PollCount", Type: "counter", Value: int64(0)}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"delta":0,"id":"PollCount","type":"counter"}`,
+		},
+		{
+			name:       "Gauge deleted",
+			metricType: "gauge",
+			metricID:   "Alloc",
+			mockSetup: func(m *MockMetricsResetter) {
+				m.On("Reset", mock.Anything, "gauge", "Alloc").Return(nil, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"id":"Alloc","type":"gauge"}`,
+		},
+		{
+			name:       "Metric not found",
+			metricType: "counter",
+			metricID:   "non_existent",
+			mockSetup: func(m *MockMetricsResetter) {
+				m.On("Reset", mock.Anything, "counter", "non_existent").Return(nil, controller.ErrNotFoundInRepository)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "Metric not found in the repository.",
+		},
+		{
+			name:       "Repository error",
+			metricType: "counter",
+			metricID:   "error_metric",
+			mockSetup: func(m *MockMetricsResetter) {
+				m.On("Reset", mock.Anything, "counter", "error_metric").Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   http.StatusText(http.StatusInternalServerError),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			resetter := new(MockMetricsResetter)
+			tt.mockSetup(resetter)
+
+			req := httptest.NewRequest(http.MethodPost, "/reset/"+tt.metricType+"/"+tt.metricID, http.NoBody)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("type", "id")
+			c.SetParamValues(tt.metricType, tt.metricID)
+
+			require.NoError(t, ResetFromURI(resetter)(c))
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.expectedBody, normalizeErrorResponse(rec.Body.String()))
+			resetter.AssertExpectations(t)
+		})
+	}
+}
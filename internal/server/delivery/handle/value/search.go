@@ -0,0 +1,53 @@
+package value
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MetricsQuerier defines the interface for retrieving the metrics matching a filter.
+type MetricsQuerier interface {
+	Query(ctx context.Context, filter repository.MetricFilter) (*entity.Metrics, error)
+}
+
+// Search handles HTTP requests listing the metrics that match the query parameters: type selects a metric
+// type, prefix the start of the names and regexp an expression the names must match, in RE2 syntax.
+// Parameters that are left out match every metric.
+//
+// Parameters:
+//   - querier: An implementation of MetricsQuerier to retrieve the matching metrics.
+//
+// Returns:
+//   - An echo.HandlerFunc that responds with the matching metrics in JSON format, sorted by name and type,
+//     or 400 if regexp is not a valid expression.
+func Search(querier MetricsQuerier) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		filter := repository.MetricFilter{
+			Type:       c.QueryParam("type"),
+			NamePrefix: c.QueryParam("prefix"),
+		}
+		if expr := c.QueryParam("regexp"); expr != "" {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return c.String(http.StatusBadRequest, "Regexp must be a valid regular expression.")
+			}
+			filter.Name = re
+		}
+
+		ctx, cancel := clk.WithTimeout(c.Request().Context(), metricUpdateTimeout)
+		defer cancel()
+
+		metrics, err := querier.Query(ctx, filter)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+		return c.JSON(http.StatusOK, model.FromEntityMetrics(metrics))
+	}
+}
@@ -0,0 +1,100 @@
+package value
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// failingQuerier fails every query.
+type failingQuerier struct{}
+
+func (failingQuerier) Query(context.Context, repository.MetricFilter) (*entity.Metrics, error) {
+	return nil, errors.New("storage unavailable")
+}
+
+func TestSearch(t *testing.T) {
+	repo := repository.NewInMemoryRepository(zap.NewNop().Sugar())
+	require.NoError(t, repo.UpdateBatch(context.Background(), &entity.Metrics{
+		{Name: "heap_alloc", Type: entity.MetricTypeGauge, Value: 1.5},
+		{Name: "heap_sys", Type: entity.MetricTypeGauge, Value: 2.5},
+		{Name: "poll_count", Type: entity.MetricTypeCounter, Value: int64(3)},
+		{Name: "heap_objects", Type: entity.MetricTypeCounter, Value: int64(4)},
+	}))
+
+	tests := []struct {
+		querier        MetricsQuerier
+		name           string
+		target         string
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name:           "All metrics",
+			querier:        repo,
+			target:         "/values",
+			expectedStatus: http.StatusOK,
+			expectedBody: `[{"value":1.5,"id":"heap_alloc","type":"gauge"},{"delta":4,"id":"heap_objects","type":"counter"},` +
+				`{"value":2.5,"id":"heap_sys","type":"gauge"},{"delta":3,"id":"poll_count","type":"counter"}]`,
+		},
+		{
+			name:           "Type and prefix",
+			querier:        repo,
+			target:         "/values?type=gauge&prefix=heap_",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"value":1.5,"id":"heap_alloc","type":"gauge"},{"value":2.5,"id":"heap_sys","type":"gauge"}]`,
+		},
+		{
+			name:           "Regexp",
+			querier:        repo,
+			target:         "/values?regexp=_(count|objects)$",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[{"delta":4,"id":"heap_objects","type":"counter"},{"delta":3,"id":"poll_count","type":"counter"}]`,
+		},
+		{
+			name:           "No match",
+			querier:        repo,
+			target:         "/values?prefix=cpu",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[]`,
+		},
+		{
+			name:           "Invalid regexp",
+			querier:        repo,
+			target:         "/values?regexp=(",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Regexp must be a valid regular expression.",
+		},
+		{
+			name:           "Query failure",
+			querier:        failingQuerier{},
+			target:         "/values",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   http.StatusText(http.StatusInternalServerError),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, tt.target, http.NoBody), rec)
+
+			require.NoError(t, Search(tt.querier)(c))
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+				return
+			}
+			assert.Equal(t, tt.expectedBody, rec.Body.String())
+		})
+	}
+}
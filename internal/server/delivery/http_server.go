@@ -7,21 +7,47 @@ import (
 	"context"
 	"errors"
 	"html/template"
+	"net"
 	"net/http"
 	"path"
+	"slices"
 	"time"
 
+	"github.com/gdyunin/metricol.git/internal/server/backup"
+	"github.com/gdyunin/metricol.git/internal/server/delivery/handle/admin"
+	"github.com/gdyunin/metricol.git/internal/server/delivery/handle/api"
+	"github.com/gdyunin/metricol.git/internal/server/delivery/handle/debug"
 	"github.com/gdyunin/metricol.git/internal/server/delivery/handle/general"
+	"github.com/gdyunin/metricol.git/internal/server/delivery/handle/keys"
+	"github.com/gdyunin/metricol.git/internal/server/delivery/handle/live"
+	"github.com/gdyunin/metricol.git/internal/server/delivery/handle/pushgateway"
+	"github.com/gdyunin/metricol.git/internal/server/delivery/handle/routing"
 	"github.com/gdyunin/metricol.git/internal/server/delivery/handle/update"
 	"github.com/gdyunin/metricol.git/internal/server/delivery/handle/updates"
 	"github.com/gdyunin/metricol.git/internal/server/delivery/handle/value"
 	custMiddleware "github.com/gdyunin/metricol.git/internal/server/delivery/middleware"
 	"github.com/gdyunin/metricol.git/internal/server/delivery/render"
+	"github.com/gdyunin/metricol.git/internal/server/internal/clockskew"
 	"github.com/gdyunin/metricol.git/internal/server/internal/controller"
+	"github.com/gdyunin/metricol.git/internal/server/internal/history"
+	"github.com/gdyunin/metricol.git/internal/server/internal/quota"
+	"github.com/gdyunin/metricol.git/internal/server/internal/ratelimit"
+	"github.com/gdyunin/metricol.git/internal/server/internal/reqrecord"
+	"github.com/gdyunin/metricol.git/internal/server/internal/routestats"
+	"github.com/gdyunin/metricol.git/internal/server/internal/stream"
+	"github.com/gdyunin/metricol.git/internal/server/keyring"
+	"github.com/gdyunin/metricol.git/internal/server/provisioning"
 	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/gdyunin/metricol.git/pkg/buildinfo"
+	"github.com/gdyunin/metricol.git/pkg/clock"
+	"github.com/gdyunin/metricol.git/pkg/configaudit"
+	"github.com/gdyunin/metricol.git/pkg/goroutines"
+	"github.com/gdyunin/metricol.git/pkg/netaddr"
+	"github.com/gdyunin/metricol.git/pkg/x25519box"
 
 	"github.com/labstack/echo/v4"
 	echoMiddleware "github.com/labstack/echo/v4/middleware"
+	"go.opentelemetry.io/otel"
 	"go.uber.org/zap"
 )
 
@@ -30,19 +56,53 @@ const (
 	defaultTemplatesPath = "web/templates/"
 	// Const gracefulShutdownTimeout is the time duration to wait for ongoing tasks to complete during shutdown.
 	gracefulShutdownTimeout = 5 * time.Second
+	// Const defaultStreamBuffer is the number of updates buffered per live stream subscriber by default.
+	defaultStreamBuffer = 64
+	// Const defaultSourceName labels the local metrics listed by /api/metrics by default.
+	defaultSourceName = "local"
 )
 
 // EchoServer defines the HTTP server powered by the Echo framework.
 // It encapsulates the Echo instance, logger, metric controller, address,
-// template path, and signing key.
+// template path, and keyring.
 type EchoServer struct {
-	echo        *echo.Echo                // echo is the Echo instance used to serve HTTP requests.
-	logger      *zap.SugaredLogger        // logger is used for structured logging.
-	metricsCtrl *controller.MetricService // metricsCtrl handles metric operations.
-	addr        string                    // addr is the server address to listen on.
-	tmplPath    string                    // tmplPath is the directory path to the HTML templates.
-	signingKey  string                    // signingKey is used for request signing and authentication.
-	cryptoKey   string
+	echo            *echo.Echo                      // echo is the Echo instance used to serve HTTP requests.
+	logger          *zap.SugaredLogger              // logger is used for structured logging.
+	metricsCtrl     *controller.MetricService       // metricsCtrl handles metric operations.
+	addr            string                          // addr is the server address to listen on.
+	adminEcho       *echo.Echo                      // adminEcho serves /admin and /debug apart, nil to serve them on addr.
+	adminAddr       string                          // adminAddr is the address adminEcho listens on.
+	tmplPath        string                          // tmplPath is the directory path to the HTML templates.
+	keys            *keyring.Keyring                // keys holds the signing and encryption keys in effect.
+	serviceOpts     []controller.Option             // serviceOpts are applied when the metric controller is created.
+	hub             *stream.Hub                     // hub fans out stored updates to live stream subscribers.
+	skew            *clockskew.Tracker              // skew records agent clock skew on metric updates.
+	history         *history.Store                  // history keeps recent counter samples for /api/rate, nil if disabled.
+	quotas          *quota.Tracker                  // quotas enforces per-key quotas on updates, nil if disabled.
+	expiry          bool                            // expiry accepts the X-Expires-At header on updates.
+	clientLimiter   *ratelimit.Limiter              // clientLimiter caps the update requests per client, nil if disabled.
+	limitByAPIKey   bool                            // limitByAPIKey tells clients apart by API key rather than IP.
+	trustedNet      *net.IPNet                      // trustedNet is the subnet clients must send from, nil for any.
+	routeStats      *routestats.Recorder            // routeStats records request durations and statuses per route.
+	adminCreds      custMiddleware.AdminCredentials // adminCreds protects the /admin and /debug routes.
+	recordings      *reqrecord.Buffer               // recordings keeps requests recorded for debugging, nil if disabled.
+	auditRequests   bool                            // auditRequests describes requests for the audit log.
+	migrations      admin.MigrationReporter         // migrations reports the schema version, nil if the storage has none.
+	readiness       general.ReadinessReporter       // readiness reports whether the storage is ready, nil if always ready.
+	poolStats       debug.PoolStatsReporter         // poolStats reports the storage connection pools, nil if it has none.
+	sampler         *goroutines.Sampler             // sampler diffs the running goroutines for /debug/goroutines.
+	meta            api.MetaEditor                  // meta stores metric annotations, nil if the storage keeps none.
+	buildInfo       buildinfo.Info                  // buildInfo describes the server build.
+	configAudit     []configaudit.Entry             // configAudit lists the settings for /api/config, nil if disabled.
+	minAgentVersion string                          // minAgentVersion is the oldest agent version accepted, empty for any.
+	limits          api.Limits                      // limits collects the update limits advertised by /api/capabilities.
+	sourceName      string                          // sourceName labels the local metrics listed by /api/metrics.
+	peers           api.PeerFetcher                 // peers reads the metrics of federation peers, nil if disabled.
+	provisioner     *provisioning.Provisioner       // provisioner holds the provisioning file, nil if disabled.
+	backup          *backup.Codec                   // backup seals exported backups and opens imported ones.
+	backupDir       *backup.Dir                     // backupDir keeps exported backups for /api/diff, nil if disabled.
+	nameAllow       []string                        // nameAllow are the allow patterns used if provisioning sets none.
+	nameDeny        []string                        // nameDeny are the deny patterns used if provisioning sets none.
 }
 
 // NewEchoServer creates and configures a new EchoServer instance.
@@ -51,27 +111,65 @@ type EchoServer struct {
 //
 // Parameters:
 //   - serverAddress: The address on which the server will listen.
-//   - signingKey: The key used for signing requests.
+//   - keys: The signing and encryption keys, including any being rotated in.
 //   - repo: The repository instance used for metric storage.
 //   - logger: The logger instance for structured logging.
+//   - opts: Optional server settings.
 //
 // Returns:
 //   - *EchoServer: A pointer to the configured EchoServer instance.
 func NewEchoServer(
 	serverAddress string,
-	signingKey string,
-	cryptoKey string,
+	keys *keyring.Keyring,
 	repo repository.Repository,
 	logger *zap.SugaredLogger,
+	opts ...Option,
 ) *EchoServer {
 	echoServer := EchoServer{
-		echo:        echo.New(),
-		logger:      logger,
-		addr:        serverAddress,
-		signingKey:  signingKey,
-		cryptoKey:   cryptoKey,
-		tmplPath:    defaultTemplatesPath,
-		metricsCtrl: controller.NewMetricService(repo),
+		echo:       echo.New(),
+		logger:     logger,
+		addr:       serverAddress,
+		keys:       keys,
+		tmplPath:   defaultTemplatesPath,
+		routeStats: routestats.NewRecorder(),
+		buildInfo:  buildinfo.New("", "", ""),
+		sourceName: defaultSourceName,
+		sampler:    goroutines.NewSampler(clock.Real()),
+		backup:     &backup.Codec{},
+	}
+	for _, opt := range opts {
+		opt(&echoServer)
+	}
+	if echoServer.hub == nil {
+		echoServer.hub = stream.NewHub(defaultStreamBuffer, stream.DropOldest)
+	}
+	if echoServer.skew == nil {
+		echoServer.skew = clockskew.NewTracker(0)
+	}
+	echoServer.serviceOpts = append(
+		echoServer.serviceOpts,
+		controller.WithStreamHub(echoServer.hub),
+		controller.WithClockSkewTracker(echoServer.skew),
+		controller.WithRouteStats(echoServer.routeStats),
+		controller.WithBuildInfo(echoServer.buildInfo),
+	)
+	echoServer.metricsCtrl = controller.NewMetricService(repo, echoServer.serviceOpts...)
+	if echoServer.provisioner != nil {
+		echoServer.provisioner.Subscribe(echoServer.applyProvisioning)
+	}
+	// Optional capabilities belong to the storage itself, not to decorators wrapping it.
+	base := repository.Base(repo)
+	if reporter, ok := base.(admin.MigrationReporter); ok {
+		echoServer.migrations = reporter
+	}
+	if reporter, ok := base.(general.ReadinessReporter); ok {
+		echoServer.readiness = reporter
+	}
+	if reporter, ok := base.(debug.PoolStatsReporter); ok {
+		echoServer.poolStats = reporter
+	}
+	if store, ok := base.(api.MetaEditor); ok {
+		echoServer.meta = store
 	}
 
 	// Hide Echo's startup banner and port output.
@@ -82,24 +180,57 @@ func NewEchoServer(
 }
 
 // Start runs the Echo server and initiates graceful shutdown when the provided context is canceled.
-// It starts a separate goroutine to handle shutdown signals.
+// It starts a separate goroutine to handle shutdown signals and returns once the listeners are shut down
+// and the buffered metric updates are flushed.
 //
 // Parameters:
 //   - ctx: The context used to manage the server lifecycle and signal shutdown.
 func (s *EchoServer) Start(ctx context.Context) {
-	go s.handleShutdown(ctx)
+	shutdownDone := make(chan struct{})
+	go func() {
+		s.handleShutdown(ctx)
+		close(shutdownDone)
+	}()
+	go s.metricsCtrl.Start(ctx)
+
+	if s.adminEcho != nil {
+		go s.startAdmin()
+	}
 
 	s.logger.Infof("Server is starting on %s", s.addr)
 	if err := s.echo.Start(s.addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		s.logger.Fatalf("Server start failed: %v", err)
-	} else {
-		s.logger.Info("Server exited cleanly")
+	}
+	// Buffered updates are only stored once the shutdown flushed them.
+	<-shutdownDone
+	s.logger.Info("Server exited cleanly")
+}
+
+// Ready reports whether the server can serve requests, as the /readyz probe does.
+//
+// Returns:
+//   - bool: True if the storage is ready or does not report its readiness.
+func (s *EchoServer) Ready() bool {
+	return s.readiness == nil || s.readiness.Ready()
+}
+
+// startAdmin runs the listener serving the admin and debug routes. Without admin credentials the routes
+// are unprotected, so the listener is only started on a loopback address.
+func (s *EchoServer) startAdmin() {
+	if !s.adminCreds.Enabled() && !netaddr.IsLoopback(s.adminAddr) {
+		s.logger.Errorf("Admin listener is not started: %s is not a loopback address and no admin credentials "+
+			"are configured", s.adminAddr)
+		return
+	}
+	s.logger.Infof("Admin listener is starting on %s", s.adminAddr)
+	if err := s.adminEcho.Start(s.adminAddr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.logger.Fatalf("Admin listener start failed: %v", err)
 	}
 }
 
 // handleShutdown listens for a shutdown signal from the provided context.
 // Upon receiving the signal, it initiates a graceful shutdown of the Echo server
-// within a predefined timeout period.
+// within a predefined timeout period and then flushes buffered metric updates.
 //
 // Parameters:
 //   - ctx: The context to monitor for shutdown signals.
@@ -116,6 +247,18 @@ func (s *EchoServer) handleShutdown(ctx context.Context) {
 	} else {
 		s.logger.Info("Server shutdown gracefully")
 	}
+	if s.adminEcho != nil {
+		if err := s.adminEcho.Shutdown(shutdownCtx); err != nil {
+			s.logger.Warnf("Failed to shutdown admin listener gracefully: %v", err)
+		}
+	}
+
+	// The parent context is already canceled, so the final flush gets its own deadline.
+	flushCtx, cancelFlush := context.WithTimeout(context.WithoutCancel(ctx), gracefulShutdownTimeout)
+	defer cancelFlush()
+	if err := s.metricsCtrl.Flush(flushCtx); err != nil {
+		s.logger.Errorf("Failed to flush buffered metrics: %v", err)
+	}
 }
 
 // build configures the EchoServer by executing a series of setup steps.
@@ -131,6 +274,7 @@ func (s *EchoServer) build() *EchoServer {
 		s.setupGeneralMiddlewares,
 		s.setupRenderers,
 		s.setupRouters,
+		s.setupErrorHandler,
 	}
 
 	for _, stepFunc := range buildSteps {
@@ -143,38 +287,131 @@ func (s *EchoServer) build() *EchoServer {
 
 // setupPreMiddlewares configures the pre-middlewares for the Echo server.
 // Pre-middlewares are executed before the router is matched.
-// This setup includes assigning a unique request ID and removing trailing slashes.
+// This setup includes assigning a unique request ID, collapsing duplicate slashes and removing trailing slashes.
 func (s *EchoServer) setupPreMiddlewares() {
 	s.logger.Info("Setting up pre-middlewares")
-	s.echo.Pre(
-		echoMiddleware.RequestID(),
-		echoMiddleware.RemoveTrailingSlash(),
-	)
+	for _, e := range s.instances() {
+		e.Pre(
+			echoMiddleware.RequestID(),
+			custMiddleware.CollapseSlashes(),
+			echoMiddleware.RemoveTrailingSlash(),
+		)
+	}
 }
 
 // setupGeneralMiddlewares configures the general middlewares for the Echo server.
-// These middlewares handle logging, decompression, authentication, signing, and gzip compression.
+// These middlewares handle tracing, route statistics, logging, decompression, key advertisement, authentication,
+// decryption, gzip compression, response signing and, if enabled, request recording and auditing.
 func (s *EchoServer) setupGeneralMiddlewares() {
 	s.logger.Info("Setting up general middlewares")
 	requestLogger := s.logger.Named("request")
 
 	s.echo.Use(
+		// Tracing runs first, so the request span covers every other middleware.
+		custMiddleware.Trace(otel.GetTracerProvider()),
+		custMiddleware.RouteMetrics(s.routeStats),
 		custMiddleware.Log(requestLogger),
+		// Untrusted clients are rejected before their bodies are decoded or their signatures checked.
+		custMiddleware.TrustedSubnet(s.trustedNet),
 		echoMiddleware.Decompress(),
-		custMiddleware.Auth(s.signingKey),
-		custMiddleware.Sign(s.signingKey),
-		custMiddleware.Crypto(s.cryptoKey, requestLogger.Named("crypto")),
+		custMiddleware.AdvertiseKeys(s.keys.Advertisement),
+		custMiddleware.AuthWithKeys(s.keys.SigningKeys),
+		custMiddleware.CryptoWithKeys(s.keys.CryptoKeys, requestLogger.Named("crypto")),
 		custMiddleware.Gzip(requestLogger.Named("gzip_writer")),
+		// Signing runs inside compression, so signatures cover the bodies clients read after decompressing.
+		custMiddleware.SignWithKey(s.keys.SigningKey),
+	)
+	if s.recordings != nil {
+		// Recording runs last, so it sees decoded request bodies and uncompressed responses.
+		s.echo.Use(custMiddleware.Record(s.recordings))
+	}
+	if s.auditRequests {
+		s.echo.Use(custMiddleware.Audit())
+	}
+
+	// The admin listener serves operators rather than agents, so it skips key handling and recording.
+	if s.adminEcho != nil {
+		s.adminEcho.Use(
+			custMiddleware.Trace(otel.GetTracerProvider()),
+			custMiddleware.RouteMetrics(s.routeStats),
+			custMiddleware.Log(requestLogger.Named("admin")),
+			custMiddleware.TrustedSubnet(s.trustedNet),
+			echoMiddleware.Decompress(),
+			custMiddleware.Gzip(requestLogger.Named("gzip_writer")),
+		)
+		if s.auditRequests {
+			s.adminEcho.Use(custMiddleware.Audit())
+		}
+	}
+}
+
+// setupErrorHandler replaces the default error handler so unknown routes and unsupported methods
+// are answered with JSON errors carrying route hints and the allowed methods.
+func (s *EchoServer) setupErrorHandler() {
+	s.logger.Info("Setting up error handler")
+	for _, e := range s.instances() {
+		e.HTTPErrorHandler = routing.ErrorHandler(e)
+	}
+}
+
+// instances returns the Echo instances of the server: the public one and, if configured, the admin one.
+func (s *EchoServer) instances() []*echo.Echo {
+	if s.adminEcho == nil {
+		return []*echo.Echo{s.echo}
+	}
+	return []*echo.Echo{s.echo, s.adminEcho}
+}
+
+// capabilities describes what the server supports to clients negotiating options through /api/capabilities.
+func (s *EchoServer) capabilities() api.ServerCapabilities {
+	limits := s.limits
+	limits.MinAgentVersion = s.minAgentVersion
+	limits.MaxClockSkewSeconds = s.skew.MaxSkew().Seconds()
+
+	encryption := []string{}
+	for _, key := range s.keys.CryptoKeys() {
+		scheme := api.EncryptionRSAAESGCM
+		if x25519box.IsPrivateKey(key) {
+			scheme = api.EncryptionX25519ChaCha20
+		}
+		if !slices.Contains(encryption, scheme) {
+			encryption = append(encryption, scheme)
+		}
+	}
+
+	return api.ServerCapabilities{
+		Encodings:  []string{api.EncodingGzip, api.EncodingIdentity},
+		Transports: []string{api.TransportBatch, api.TransportJSON, api.TransportURI},
+		Signing:    []string{api.SigningHMACSHA256},
+		Encryption: encryption,
+		Limits:     limits,
+	}
+}
+
+// applyProvisioning puts the allowed metrics of a loaded provisioning file in effect, falling back to the
+// configured name filter patterns if the file sets none.
+func (s *EchoServer) applyProvisioning(f *provisioning.File) {
+	allow, deny, source := s.nameAllow, s.nameDeny, "configuration"
+	if f.AllowedMetrics != nil {
+		allow, deny, source = f.AllowedMetrics.Allow, f.AllowedMetrics.Deny, "provisioning file"
+	}
+	s.metricsCtrl.SetNameFilter(allow, deny)
+	s.logger.Infof(
+		"Provisioning applied: %d dashboards, %d alert rules, allowed metrics from the %s",
+		len(f.Dashboards),
+		len(f.AlertRules),
+		source,
 	)
 }
 
 // setupRenderers sets up the HTML template renderer for the Echo server.
-// It parses all HTML templates in the specified template path and assigns the renderer to Echo.
+// The HTML templates in the specified template path are parsed on the first page render.
 func (s *EchoServer) setupRenderers() {
 	s.logger.Info("Setting up template renderers")
-	s.echo.Renderer = render.NewRenderer(
-		template.Must(template.ParseGlob(path.Join(s.tmplPath, "*.html"))),
-	)
+	pattern := path.Join(s.tmplPath, "*.html")
+	s.echo.Renderer = render.NewLazyRenderer(func() (*template.Template, error) {
+		return template.ParseGlob(pattern) //nolint:wrapcheck // The renderer wraps the error.
+	})
 }
 
 // setupRouters configures the HTTP routes for the Echo server.
@@ -183,21 +420,139 @@ func (s *EchoServer) setupRenderers() {
 func (s *EchoServer) setupRouters() {
 	s.logger.Info("Setting up routes")
 
+	// Agent version and clock skew are checked on every update route, after the client rate limit
+	// so rejected requests cost as little as possible.
+	var agentChecks []echo.MiddlewareFunc
+	if s.clientLimiter != nil {
+		agentChecks = append(agentChecks, custMiddleware.RateLimit(s.clientLimiter, s.limitByAPIKey))
+	}
+	agentChecks = append(
+		agentChecks,
+		custMiddleware.MinAgentVersion(s.minAgentVersion),
+		custMiddleware.ClockSkew(s.skew),
+	)
+	// The API key selects the quotas applied to pushed metrics.
+	var pushChecks []echo.MiddlewareFunc
+	if s.quotas != nil {
+		pushChecks = append(pushChecks, custMiddleware.APIKey())
+	}
+	// The expiry header sets when pushed metrics are deleted.
+	if s.expiry {
+		pushChecks = append(pushChecks, custMiddleware.ExpiresAt())
+	}
+	agentChecks = append(agentChecks, pushChecks...)
+
 	// Route group for single metric updates.
-	updateGroup := s.echo.Group("/update")
+	updateGroup := s.echo.Group("/update", agentChecks...)
 	updateGroup.POST("", update.FromJSON(s.metricsCtrl))
 	updateGroup.POST("/:type/:id/:value", update.FromURI(s.metricsCtrl))
 
 	// Route group for batch metric updates.
-	updatesGroup := s.echo.Group("/updates")
+	updatesGroup := s.echo.Group("/updates", agentChecks...)
 	updatesGroup.POST("", updates.FromJSON(s.metricsCtrl))
+	updatesGroup.POST("/validate", updates.Validate(s.metricsCtrl))
 
 	// Route group for metric value retrieval.
 	valueGroup := s.echo.Group("/value")
 	valueGroup.POST("", value.FromJSON(s.metricsCtrl))
 	valueGroup.GET("/:type/:id", value.FromURI(s.metricsCtrl))
+	s.echo.GET("/values", value.Search(s.metricsCtrl))
+
+	// Administrative and troubleshooting routes require the admin credentials.
+	if !s.adminCreds.Enabled() {
+		s.logger.Warn(
+			"No admin credentials are configured, /admin, /debug, /api/config, /api/diff, delete and reset routes " +
+				"are unprotected",
+		)
+	}
+	adminAuth := custMiddleware.AdminAuth(s.adminCreds)
+
+	// Deleting metrics is destructive, so like undeleting it requires the admin credentials.
+	valueGroup.DELETE("/:type/:id", value.DeleteFromURI(s.metricsCtrl), adminAuth)
+	s.echo.DELETE("/delete", value.DeleteFromJSON(s.metricsCtrl), adminAuth)
+	// Resetting zeroes counters and deletes other metrics, so it requires the admin credentials as well.
+	s.echo.POST("/reset/:type/:id", value.ResetFromURI(s.metricsCtrl), adminAuth)
+
+	// Administrative and troubleshooting routes are served by the admin listener if there is one.
+	mgmt := s.echo
+	if s.adminEcho != nil {
+		mgmt = s.adminEcho
+	}
+
+	// Route group for administrative operations.
+	adminGroup := mgmt.Group("/admin", adminAuth)
+	adminGroup.POST("/undelete", admin.Undelete(s.metricsCtrl))
+	adminGroup.POST("/undelete/:type/:id", admin.Undelete(s.metricsCtrl))
+	adminGroup.GET("/snapshot", admin.Snapshot(s.metricsCtrl, s.backup, s.backupDir))
+	adminGroup.POST("/import", admin.Import(s.metricsCtrl, s.backup, s.keys.CryptoKeys))
+	if s.migrations != nil {
+		adminGroup.GET("/migrations", admin.Migrations(s.migrations))
+	}
+	if s.quotas != nil {
+		adminGroup.GET("/usage", admin.Usage(s.quotas))
+	}
+	if s.provisioner != nil {
+		adminGroup.GET("/provisioning", admin.Provisioning(s.provisioner))
+		adminGroup.POST("/provisioning/reload", admin.ReloadProvisioning(s.provisioner))
+	}
+
+	// Route group for troubleshooting endpoints.
+	debugGroup := mgmt.Group("/debug", adminAuth)
+	debugGroup.GET("/goroutines", debug.Goroutines(s.sampler))
+	if s.poolStats != nil {
+		debugGroup.GET("/dbstats", debug.DBStats(s.poolStats))
+	}
+	if s.recordings != nil {
+		debugGroup.GET("/requests", debug.Requests(s.recordings))
+		debugGroup.GET("/requests/:id", debug.Request(s.recordings))
+	}
+
+	// Route group for encryption key distribution.
+	cryptoGroup := s.echo.Group("/crypto")
+	cryptoGroup.GET("/public-key", keys.PublicKey(s.keys))
+	cryptoGroup.GET("/keys", keys.Keys(s.keys))
+
+	// Route group describing the server to clients and tools.
+	apiGroup := s.echo.Group("/api")
+	apiGroup.GET("/version", api.Version(s.buildInfo))
+	apiGroup.GET("/capabilities", api.Capabilities(s.capabilities))
+	apiGroup.GET("/time", api.Time(time.Now))
+	apiGroup.GET("/metrics", api.Metrics(s.metricsCtrl, s.sourceName, s.peers))
+	apiGroup.GET("/top", api.Top(s.metricsCtrl))
+	if s.meta != nil {
+		apiGroup.GET("/metrics/:type/:id/meta", api.Meta(s.meta))
+		apiGroup.PATCH("/metrics/:type/:id/meta", api.PatchMeta(s.meta, time.Now), adminAuth)
+	}
+	apiGroup.GET("/cardinality", api.CardinalityCounts(s.metricsCtrl))
+	if s.configAudit != nil {
+		apiGroup.GET("/config", api.Config(s.configAudit), adminAuth)
+	}
+	if s.backupDir != nil {
+		apiGroup.GET("/diff", admin.Diff(s.metricsCtrl, s.backup, s.backupDir, s.keys.CryptoKeys), adminAuth)
+	}
+	if s.history != nil {
+		apiGroup.GET("/rate/:name", api.Rate(s.history, time.Now))
+	}
+	if s.provisioner != nil {
+		apiGroup.GET("/dashboards", api.Dashboards(s.metricsCtrl, s.provisioner))
+		apiGroup.GET("/dashboards/:name", api.Dashboard(s.metricsCtrl, s.provisioner))
+		apiGroup.GET("/alerts", api.Alerts(s.metricsCtrl, s.provisioner))
+	}
+
+	// Route group for the Prometheus Pushgateway push API.
+	pushGroup := s.echo.Group("/metrics", pushChecks...)
+	pushGroups := pushgateway.NewGroups()
+	pushGroup.PUT("/*", pushgateway.Push(s.metricsCtrl, pushGroups, true))
+	pushGroup.POST("/*", pushgateway.Push(s.metricsCtrl, pushGroups, false))
+	pushGroup.DELETE("/*", pushgateway.Delete(s.metricsCtrl, pushGroups))
+
+	// Live stream of metric updates.
+	s.echo.GET("/stream", live.Stream(s.hub))
 
-	// Routes for main page and health check.
+	// Routes for main page and its snapshot, metric pages, health check and readiness probe.
 	s.echo.GET("/", general.MainPage(s.metricsCtrl))
+	s.echo.GET("/snapshot.html", general.Snapshot(s.metricsCtrl))
+	s.echo.GET("/metric/:type/:id", general.MetricPage(s.metricsCtrl, s.meta))
 	s.echo.GET("/ping", general.Ping(s.metricsCtrl))
+	s.echo.GET("/readyz", general.Readyz(s.readiness))
 }
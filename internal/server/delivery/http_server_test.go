@@ -0,0 +1,78 @@
+package delivery
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/keyring"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestEchoServer_StartFlushesWriteBuffer(t *testing.T) {
+	keys, err := keyring.New("", "", "", "", 0)
+	require.NoError(t, err)
+	repo := repository.NewInMemoryRepository(zap.NewNop().Sugar())
+	server := NewEchoServer("127.0.0.1:0", keys, repo, zap.NewNop().Sugar(), WithWriteBuffer(time.Hour, 1000))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		server.Start(ctx)
+		close(done)
+	}()
+
+	for _, path := range []string{"/update/gauge/Alloc/1.5", "/update/counter/PollCount/3"} {
+		rec := httptest.NewRecorder()
+		server.echo.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, http.NoBody))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	}
+	all, err := repo.All(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, all.Length(), "the updates wait in the write buffer")
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * gracefulShutdownTimeout):
+		t.Fatal("Start did not return after the context was canceled")
+	}
+
+	gauge, err := repo.Find(context.Background(), entity.MetricTypeGauge, "Alloc")
+	require.NoError(t, err, "buffered updates are stored before Start returns")
+	assert.Equal(t, 1.5, gauge.Value)
+	counter, err := repo.Find(context.Background(), entity.MetricTypeCounter, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), counter.Value)
+}
+
+func TestEchoServer_AdminListenerTrustedSubnet(t *testing.T) {
+	keys, err := keyring.New("", "", "", "", 0)
+	require.NoError(t, err)
+	_, subnet, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+	server := NewEchoServer(
+		"127.0.0.1:0",
+		keys,
+		repository.NewInMemoryRepository(zap.NewNop().Sugar()),
+		zap.NewNop().Sugar(),
+		WithAdminAddress("127.0.0.1:9091"),
+		WithTrustedSubnet(subnet),
+	)
+
+	for ip, expected := range map[string]int{"10.1.2.3": http.StatusOK, "192.168.1.1": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodGet, "/debug/goroutines", http.NoBody)
+		req.Header.Set(echo.HeaderXRealIP, ip)
+		rec := httptest.NewRecorder()
+		server.adminEcho.ServeHTTP(rec, req)
+		assert.Equal(t, expected, rec.Code, ip)
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AdminCredentials holds the credentials accepted by the AdminAuth middleware.
+// They are independent of the signing key used by agents to authenticate metric updates.
+type AdminCredentials struct {
+	Token    string // Token is accepted as "Authorization: Bearer <token>"; empty disables token access.
+	User     string // User is the basic auth user; empty disables basic auth.
+	Password string // Password is the basic auth password.
+}
+
+// Enabled reports whether any admin credential is configured.
+//
+// Returns:
+//   - bool: True if a token or a basic auth user is set.
+func (c AdminCredentials) Enabled() bool {
+	return c.Token != "" || c.User != ""
+}
+
+// AdminAuth creates a middleware protecting administrative and troubleshooting routes.
+// A request is let through if it carries the configured bearer token or basic auth credentials,
+// otherwise it is answered with 401 Unauthorized. If no credential is configured,
+// every request is let through.
+//
+// Parameters:
+//   - creds: The accepted admin credentials.
+//
+// Returns:
+//   - echo.MiddlewareFunc: The configured middleware function.
+func AdminAuth(creds AdminCredentials) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !creds.Enabled() || creds.authorized(c.Request()) {
+				return next(c)
+			}
+
+			if creds.User != "" {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="metricol admin"`)
+			} else {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="metricol admin"`)
+			}
+			return c.String(http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		}
+	}
+}
+
+// authorized checks the Authorization header of the request against the credentials.
+//
+// Parameters:
+//   - req: The request to check.
+//
+// Returns:
+//   - bool: True if the request carries a valid token or valid basic auth credentials.
+func (c AdminCredentials) authorized(req *http.Request) bool {
+	if c.Token != "" {
+		header := req.Header.Get(echo.HeaderAuthorization)
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok && secureEqual(token, c.Token) {
+			return true
+		}
+	}
+	if c.User != "" {
+		user, password, ok := req.BasicAuth()
+		// Both values are compared so the response time does not reveal which one is wrong.
+		userOK := secureEqual(user, c.User)
+		passwordOK := secureEqual(password, c.Password)
+		if ok && userOK && passwordOK {
+			return true
+		}
+	}
+	return false
+}
+
+// secureEqual compares two secrets in constant time.
+func secureEqual(got, want string) bool {
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminAuth(t *testing.T) {
+	tests := []struct {
+		setup        func(req *http.Request)
+		name         string
+		expectedAuth string
+		creds        AdminCredentials
+		expectedCode int
+	}{
+		{
+			name:         "No credentials configured",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Valid token",
+			creds:        AdminCredentials{Token: "secret"},
+			setup:        func(req *http.Request) { req.Header.Set(echo.HeaderAuthorization, "Bearer secret") },
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Invalid token",
+			creds:        AdminCredentials{Token: "secret"},
+			setup:        func(req *http.Request) { req.Header.Set(echo.HeaderAuthorization, "Bearer wrong") },
+			expectedCode: http.StatusUnauthorized,
+			expectedAuth: `Bearer realm="metricol admin"`,
+		},
+		{
+			name:         "Missing token",
+			creds:        AdminCredentials{Token: "secret"},
+			expectedCode: http.StatusUnauthorized,
+			expectedAuth: `Bearer realm="metricol admin"`,
+		},
+		{
+			name:         "Valid basic auth",
+			creds:        AdminCredentials{User: "admin", Password: "pass"},
+			setup:        func(req *http.Request) { req.SetBasicAuth("admin", "pass") },
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Invalid basic auth password",
+			creds:        AdminCredentials{User: "admin", Password: "pass"},
+			setup:        func(req *http.Request) { req.SetBasicAuth("admin", "wrong") },
+			expectedCode: http.StatusUnauthorized,
+			expectedAuth: `Basic realm="metricol admin"`,
+		},
+		{
+			name:         "Basic auth when only a token is accepted",
+			creds:        AdminCredentials{Token: "secret"},
+			setup:        func(req *http.Request) { req.SetBasicAuth("admin", "secret") },
+			expectedCode: http.StatusUnauthorized,
+			expectedAuth: `Bearer realm="metricol admin"`,
+		},
+		{
+			name:         "Token when both are accepted",
+			creds:        AdminCredentials{Token: "secret", User: "admin", Password: "pass"},
+			setup:        func(req *http.Request) { req.Header.Set(echo.HeaderAuthorization, "Bearer secret") },
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/admin/migrations", http.NoBody)
+			if tt.setup != nil {
+				tt.setup(req)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			handler := AdminAuth(tt.creds)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			assert.NoError(t, handler(c))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, tt.expectedAuth, rec.Header().Get(echo.HeaderWWWAuthenticate))
+		})
+	}
+}
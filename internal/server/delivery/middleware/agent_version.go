@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gdyunin/metricol.git/pkg/buildinfo"
+	"github.com/labstack/echo/v4"
+)
+
+// MinAgentVersion creates a middleware rejecting requests from agents older than minVersion, as reported
+// by the X-Agent-Version header, with 426 Upgrade Required. Requests without the header and agents whose
+// version cannot be parsed, such as development builds, pass through unchanged. An empty minVersion
+// disables the check.
+//
+// Parameters:
+//   - minVersion: The oldest supported agent version.
+//
+// Returns:
+//   - echo.MiddlewareFunc: The configured middleware function.
+func MinAgentVersion(minVersion string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if minVersion == "" {
+			return next
+		}
+		return func(c echo.Context) error {
+			version := c.Request().Header.Get(buildinfo.HeaderAgentVersion)
+			if version == "" {
+				return next(c)
+			}
+			if cmp, err := buildinfo.CompareVersions(version, minVersion); err != nil || cmp >= 0 {
+				return next(c)
+			}
+			return c.String(
+				http.StatusUpgradeRequired,
+				fmt.Sprintf(
+					"Agent version %s is no longer supported, the minimum is %s. Upgrade the agent to %s or later.",
+					version,
+					minVersion,
+					minVersion,
+				),
+			)
+		}
+	}
+}
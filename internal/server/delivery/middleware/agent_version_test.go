@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/pkg/buildinfo"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinAgentVersion(t *testing.T) {
+	tests := []struct {
+		name         string
+		minVersion   string
+		agentVersion string
+		expectedBody string
+		expectedCode int
+	}{
+		{name: "check disabled", minVersion: "", agentVersion: "0.1.0", expectedCode: http.StatusOK},
+		{name: "no version header", minVersion: "1.2.0", expectedCode: http.StatusOK},
+		{name: "same version", minVersion: "1.2.0", agentVersion: "v1.2.0", expectedCode: http.StatusOK},
+		{name: "newer version", minVersion: "1.2.0", agentVersion: "1.3.0", expectedCode: http.StatusOK},
+		{name: "development build", minVersion: "1.2.0", agentVersion: buildinfo.Unknown, expectedCode: http.StatusOK},
+		{
+			name:         "older version",
+			minVersion:   "1.2.0",
+			agentVersion: "1.1.9",
+			expectedCode: http.StatusUpgradeRequired,
+			expectedBody: "Agent version 1.1.9 is no longer supported, the minimum is 1.2.0. " +
+				"Upgrade the agent to 1.2.0 or later.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/updates", http.NoBody)
+			if tt.agentVersion != "" {
+				req.Header.Set(buildinfo.HeaderAgentVersion, tt.agentVersion)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			called := false
+			handler := MinAgentVersion(tt.minVersion)(func(c echo.Context) error {
+				called = true
+				return c.NoContent(http.StatusOK)
+			})
+
+			require.NoError(t, handler(c))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, tt.expectedCode == http.StatusOK, called)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
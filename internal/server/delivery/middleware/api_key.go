@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"github.com/gdyunin/metricol.git/internal/server/internal/quota"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Const HeaderAPIKey carries the API key whose quotas apply to the request.
+const HeaderAPIKey = "X-API-Key"
+
+// APIKey creates a middleware passing the API key from the X-API-Key header to the metric controller
+// through the request context, so the quotas of the key apply to the metrics of the request.
+// Requests without the header carry an empty key.
+//
+// Returns:
+//   - echo.MiddlewareFunc: The configured middleware function.
+func APIKey() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if key := c.Request().Header.Get(HeaderAPIKey); key != "" {
+				req := c.Request()
+				c.SetRequest(req.WithContext(quota.WithKey(req.Context(), key)))
+			}
+			return next(c)
+		}
+	}
+}
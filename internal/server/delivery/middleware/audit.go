@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/gdyunin/metricol.git/internal/server/audit"
+
+	"github.com/labstack/echo/v4"
+)
+
+// keyIDLength is the number of hex digits of the API key fingerprint recorded in the audit log.
+const keyIDLength = 16
+
+// Audit creates a middleware describing the request for the audit log: the agent ID, a fingerprint of the
+// API key, the basic auth user, the client IP and the request ID. The description travels in the request
+// context to the audited repository, which records it with the mutations the request makes.
+//
+// Returns:
+//   - echo.MiddlewareFunc: The configured middleware function.
+func Audit() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			described := audit.Request{
+				Agent:     req.Header.Get(HeaderAgentID),
+				KeyID:     keyID(req.Header.Get(HeaderAPIKey)),
+				SourceIP:  c.RealIP(),
+				RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+			}
+			if described.RequestID == "" {
+				described.RequestID = req.Header.Get(echo.HeaderXRequestID)
+			}
+			if user, _, ok := req.BasicAuth(); ok {
+				described.User = user
+			}
+			c.SetRequest(req.WithContext(audit.WithRequest(req.Context(), described)))
+			return next(c)
+		}
+	}
+}
+
+// keyID fingerprints an API key, so the audit log tells keys apart without storing them.
+//
+// Parameters:
+//   - key: The API key.
+//
+// Returns:
+//   - string: The fingerprint, empty for an empty key.
+func keyID(key string) string {
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:keyIDLength]
+}
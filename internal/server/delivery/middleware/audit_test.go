@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/audit"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *testing.T) {
+	tests := []struct {
+		headers  map[string]string
+		user     string
+		expected audit.Request
+		name     string
+	}{
+		{
+			name: "Agent with API key",
+			headers: map[string]string{
+				HeaderAgentID:         "host-1",
+				HeaderAPIKey:          "team-a",
+				echo.HeaderXRealIP:    "10.0.0.7",
+				echo.HeaderXRequestID: "req-1",
+			},
+			expected: audit.Request{
+				Agent:     "host-1",
+				KeyID:     keyID("team-a"),
+				SourceIP:  "10.0.0.7",
+				RequestID: "req-1",
+			},
+		},
+		{
+			name:     "Basic auth user",
+			headers:  map[string]string{echo.HeaderXRealIP: "10.0.0.8"},
+			user:     "admin",
+			expected: audit.Request{User: "admin", SourceIP: "10.0.0.8"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/updates", http.NoBody)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, "secret")
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			var got audit.Request
+			handler := Audit()(func(c echo.Context) error {
+				got = audit.RequestFrom(c.Request().Context())
+				return c.NoContent(http.StatusOK)
+			})
+			require.NoError(t, handler(c))
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestAudit_ResponseRequestID(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/updates", http.NoBody)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Response().Header().Set(echo.HeaderXRequestID, "generated")
+
+	var got audit.Request
+	handler := Audit()(func(c echo.Context) error {
+		got = audit.RequestFrom(c.Request().Context())
+		return nil
+	})
+	require.NoError(t, handler(c))
+	assert.Equal(t, "generated", got.RequestID)
+}
+
+func TestKeyID(t *testing.T) {
+	assert.Empty(t, keyID(""))
+	assert.Len(t, keyID("team-a"), keyIDLength)
+	assert.Equal(t, keyID("team-a"), keyID("team-a"))
+	assert.NotEqual(t, keyID("team-a"), keyID("team-b"))
+	assert.NotContains(t, keyID("team-a"), "team-a")
+}
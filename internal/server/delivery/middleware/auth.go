@@ -24,9 +24,23 @@ import (
 // Returns:
 //   - echo.MiddlewareFunc: The configured middleware function.
 func Auth(key string) echo.MiddlewareFunc {
+	return AuthWithKeys(func() []string { return nonEmptyKeys(key) })
+}
+
+// AuthWithKeys works like Auth but accepts a signature made with any of the keys returned by keys,
+// which allows the signing key to be rotated without rejecting agents that have not switched yet.
+// The keys are requested for every request. If no keys are returned, verification is skipped.
+//
+// Parameters:
+//   - keys: A function returning the currently accepted secret keys.
+//
+// Returns:
+//   - echo.MiddlewareFunc: The configured middleware function.
+func AuthWithKeys(keys func() []string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) (err error) {
-			if key == "" {
+			accepted := keys()
+			if len(accepted) == 0 {
 				return next(c)
 			}
 
@@ -43,15 +57,30 @@ func Auth(key string) echo.MiddlewareFunc {
 				)
 			}
 
-			if !checkSign(rawBody, sign, key) {
-				return c.String(http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			for _, key := range accepted {
+				if checkSign(rawBody, sign, key) {
+					return next(c)
+				}
 			}
-
-			return next(c)
+			return c.String(http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
 		}
 	}
 }
 
+// nonEmptyKeys returns a list holding the key, or an empty list if the key is empty.
+//
+// Parameters:
+//   - key: The key to wrap.
+//
+// Returns:
+//   - []string: The wrapped key.
+func nonEmptyKeys(key string) []string {
+	if key == "" {
+		return nil
+	}
+	return []string{key}
+}
+
 // getRawBody retrieves the raw body from an http.Request.
 //
 // Parameters:
@@ -14,6 +14,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // errorReadCloser simulates a reader that always returns an error.
@@ -143,6 +144,33 @@ func TestAuthMiddleware(t *testing.T) {
 	}
 }
 
+func TestAuthWithKeys(t *testing.T) {
+	cases := []struct {
+		name           string
+		signKey        string
+		expectedStatus int
+	}{
+		{name: "Signed with current key", signKey: "current", expectedStatus: http.StatusOK},
+		{name: "Signed with next key", signKey: "next", expectedStatus: http.StatusOK},
+		{name: "Signed with retired key", signKey: "retired", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello"))
+			req.Header.Set("HashSHA256", computeValidSign([]byte("hello"), tc.signKey))
+			rec := httptest.NewRecorder()
+
+			handler := AuthWithKeys(func() []string { return []string{"current", "next"} })(func(c echo.Context) error {
+				return c.String(http.StatusOK, "next")
+			})
+			require.NoError(t, handler(e.NewContext(req, rec)))
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
+
 // TestCheckSign tests the checkSign helper function.
 func TestCheckSign(t *testing.T) {
 	cases := []struct {
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// Const HeaderAgentID carries the identifier of the agent sending the request.
+	HeaderAgentID = "X-Agent-ID"
+	// Const HeaderAgentTime carries the agent clock at the moment the request was sent, in RFC 3339 format.
+	HeaderAgentTime = "X-Agent-Time"
+)
+
+// SkewObserver records agent clock skew and decides whether it is acceptable.
+type SkewObserver interface {
+	// Observe records the skew of an agent and reports false if it exceeds the accepted bound.
+	Observe(agent string, agentTime, serverTime time.Time) (time.Duration, bool)
+	// MaxSkew returns the largest accepted absolute skew.
+	MaxSkew() time.Duration
+}
+
+// ClockSkew creates a middleware comparing the agent clock sent in the X-Agent-Time header with the server clock.
+// The agent is identified by the X-Agent-ID header, falling back to the client IP address.
+// Requests without the header pass through unchanged; requests whose skew is rejected by the observer
+// are answered with 400 Bad Request.
+//
+// Parameters:
+//   - observer: The observer recording the skew.
+//
+// Returns:
+//   - echo.MiddlewareFunc: The configured middleware function.
+func ClockSkew(observer SkewObserver) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			receivedAt := time.Now()
+
+			raw := c.Request().Header.Get(HeaderAgentTime)
+			if raw == "" {
+				return next(c)
+			}
+			agentTime, err := time.Parse(time.RFC3339Nano, raw)
+			if err != nil {
+				return c.String(http.StatusBadRequest, fmt.Sprintf("Invalid %s header.", HeaderAgentTime))
+			}
+
+			agent := c.Request().Header.Get(HeaderAgentID)
+			if agent == "" {
+				agent = c.RealIP()
+			}
+
+			skew, ok := observer.Observe(agent, agentTime, receivedAt)
+			if !ok {
+				return c.String(
+					http.StatusBadRequest,
+					fmt.Sprintf("Clock skew of %s exceeds the allowed %s.", skew.Round(time.Millisecond), observer.MaxSkew()),
+				)
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubSkewObserver struct {
+	agent   string
+	maxSkew time.Duration
+	calls   int
+}
+
+func (o *stubSkewObserver) Observe(agent string, agentTime, serverTime time.Time) (time.Duration, bool) {
+	o.calls++
+	o.agent = agent
+	skew := agentTime.Sub(serverTime)
+	return skew, o.maxSkew == 0 || (skew <= o.maxSkew && skew >= -o.maxSkew)
+}
+
+func (o *stubSkewObserver) MaxSkew() time.Duration {
+	return o.maxSkew
+}
+
+func TestClockSkew(t *testing.T) {
+	tests := []struct {
+		headers       map[string]string
+		name          string
+		expectedAgent string
+		maxSkew       time.Duration
+		expectedCode  int
+		expectedCalls int
+	}{
+		{
+			name:         "No agent time",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Invalid agent time",
+			headers:      map[string]string{HeaderAgentTime: "yesterday"},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "Skew within bound",
+			headers: map[string]string{
+				HeaderAgentID:   "host-1",
+				HeaderAgentTime: time.Now().Add(time.Second).Format(time.RFC3339Nano),
+			},
+			maxSkew:       time.Minute,
+			expectedCode:  http.StatusOK,
+			expectedCalls: 1,
+			expectedAgent: "host-1",
+		},
+		{
+			name: "Skew beyond bound",
+			headers: map[string]string{
+				HeaderAgentID:   "host-1",
+				HeaderAgentTime: time.Now().Add(-time.Hour).Format(time.RFC3339Nano),
+			},
+			maxSkew:       time.Minute,
+			expectedCode:  http.StatusBadRequest,
+			expectedCalls: 1,
+			expectedAgent: "host-1",
+		},
+		{
+			name:          "Agent falls back to client address",
+			headers:       map[string]string{HeaderAgentTime: time.Now().Format(time.RFC3339Nano)},
+			expectedCode:  http.StatusOK,
+			expectedCalls: 1,
+			expectedAgent: "192.0.2.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/updates", http.NoBody)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+
+			observer := &stubSkewObserver{maxSkew: tt.maxSkew}
+			handler := ClockSkew(observer)(func(c echo.Context) error {
+				return c.String(http.StatusOK, "ok")
+			})
+			require.NoError(t, handler(e.NewContext(req, rec)))
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, tt.expectedCalls, observer.calls)
+			assert.Equal(t, tt.expectedAgent, observer.agent)
+		})
+	}
+}
@@ -13,25 +13,91 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
+	"github.com/gdyunin/metricol.git/pkg/x25519box"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 )
 
+const (
+	// Const HeaderEncryptedKey carries the encrypted session key of RSA hybrid payloads,
+	// or the ephemeral public key of X25519 payloads.
+	HeaderEncryptedKey = "X-Encrypted-Key"
+	// Const HeaderEncryptionScheme names the scheme a payload is encrypted with.
+	// Without it RSA hybrid is assumed, as sent by agents predating the header.
+	HeaderEncryptionScheme = "X-Encryption-Scheme"
+	// Const EncryptionRSAAESGCM is the scheme encrypting payloads with AES-GCM under an RSA encrypted key.
+	EncryptionRSAAESGCM = "rsa-aes-gcm"
+)
+
 // [ДЛЯ РЕВЬЮ] Этот волшебный 🩼 -- плата за экономию на переделывании `internal/server/delivery/http_server.go`...
 var cryptoIgnoredPath = map[string]bool{
-	"/":     true,
-	"/ping": true,
+	"/":       true,
+	"/ping":   true,
+	"/readyz": true,
+	"/stream": true,
+}
+
+// cryptoIgnoredPrefixes lists route prefixes whose requests never carry encrypted payloads.
+var cryptoIgnoredPrefixes = []string{
+	"/admin/",
+	"/api/",
+	"/crypto/",
+	"/debug/",
+	"/metrics/",
+}
+
+// isCryptoIgnored reports whether the request path is exempt from payload decryption.
+func isCryptoIgnored(path string) bool {
+	if cryptoIgnoredPath[path] {
+		return true
+	}
+	for _, prefix := range cryptoIgnoredPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
 }
 
+// Crypto creates a middleware decrypting request payloads encrypted with the server's public key.
+//
+// Parameters:
+//   - cryptoKey: The RSA or X25519 private key in PEM format; empty disables decryption.
+//   - logger: Logger for decryption failures.
+//
+// Returns:
+//   - echo.MiddlewareFunc: The configured middleware function.
 func Crypto(cryptoKey string, logger *zap.SugaredLogger) echo.MiddlewareFunc {
+	return CryptoWithKeys(func() []string { return nonEmptyKeys(cryptoKey) }, logger)
+}
+
+// CryptoWithKeys works like Crypto but tries every key returned by keys,
+// so payloads encrypted with either the current or the next key are accepted during rotation.
+// The scheme is taken from the X-Encryption-Scheme header: rsa-aes-gcm, the default, or x25519-chacha20poly1305.
+// Requests naming an unknown scheme are answered with 400 Bad Request.
+//
+// Parameters:
+//   - keys: A function returning the currently accepted RSA and X25519 private keys in PEM format.
+//   - logger: Logger for decryption failures.
+//
+// Returns:
+//   - echo.MiddlewareFunc: The configured middleware function.
+func CryptoWithKeys(keys func() []string, logger *zap.SugaredLogger) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) (err error) {
-			if cryptoKey == "" || cryptoIgnoredPath[c.Request().URL.Path] {
+			accepted := keys()
+			if len(accepted) == 0 || isCryptoIgnored(c.Request().URL.Path) {
 				return next(c)
 			}
 
-			encryptedKeyB64 := c.Request().Header.Get("X-Encrypted-Key")
+			decrypt, ok := decrypters[c.Request().Header.Get(HeaderEncryptionScheme)]
+			if !ok {
+				return c.String(http.StatusBadRequest, "Unsupported encryption scheme.")
+			}
+
+			encryptedKeyB64 := c.Request().Header.Get(HeaderEncryptedKey)
 			encryptedKey, err := base64.StdEncoding.DecodeString(encryptedKeyB64)
 			if err != nil {
 				logger.Errorf("failed to decode base64 encrypted key: %v", err)
@@ -50,7 +116,12 @@ func Crypto(cryptoKey string, logger *zap.SugaredLogger) echo.MiddlewareFunc {
 				)
 			}
 
-			decryptedBody, err := decryptWithPrivateKeyHybrid(encryptedBody, encryptedKey, cryptoKey)
+			var decryptedBody []byte
+			for _, key := range accepted {
+				if decryptedBody, err = decrypt(encryptedBody, encryptedKey, key); err == nil {
+					break
+				}
+			}
 			if err != nil {
 				return c.String(http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
 			}
@@ -62,6 +133,13 @@ func Crypto(cryptoKey string, logger *zap.SugaredLogger) echo.MiddlewareFunc {
 	}
 }
 
+// decrypters maps the supported encryption schemes to the functions decrypting their payloads.
+var decrypters = map[string]func(encryptedData, encryptedKey []byte, privateKeyPEM string) ([]byte, error){
+	"":                  decryptWithPrivateKeyHybrid,
+	EncryptionRSAAESGCM: decryptWithPrivateKeyHybrid,
+	x25519box.Scheme:    x25519box.Open,
+}
+
 func decryptWithPrivateKeyHybrid(
 	encryptedData []byte,
 	encryptedKey []byte,
@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/pkg/x25519box"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// encryptHybrid encrypts data the way agents do: AES-GCM for the payload and RSA for the AES key.
+func encryptHybrid(t *testing.T, data []byte, key *rsa.PrivateKey) ([]byte, string) {
+	t.Helper()
+	aesKey := make([]byte, 32)
+	_, err := rand.Read(aesKey)
+	require.NoError(t, err)
+
+	block, err := aes.NewCipher(aesKey)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	require.NoError(t, err)
+
+	encryptedKey, err := rsa.EncryptPKCS1v15(rand.Reader, &key.PublicKey, aesKey)
+	require.NoError(t, err)
+	return gcm.Seal(nonce, nonce, data, nil), base64.StdEncoding.EncodeToString(encryptedKey)
+}
+
+func generatePrivateKey(t *testing.T) (*rsa.PrivateKey, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+}
+
+func TestCryptoWithKeys_X25519(t *testing.T) {
+	_, rsaPEM := generatePrivateKey(t)
+	privatePEM, publicPEM, err := x25519box.GenerateKey()
+	require.NoError(t, err)
+	_, otherPublicPEM, err := x25519box.GenerateKey()
+	require.NoError(t, err)
+	body := []byte(`[{"id":"a","type":"gauge","value":1}]`)
+
+	tests := []struct {
+		name           string
+		scheme         string
+		publicPEM      string
+		expectedStatus int
+	}{
+		{name: "Encrypted with server key", scheme: x25519box.Scheme, publicPEM: publicPEM, expectedStatus: http.StatusOK},
+		{
+			name:           "Encrypted with other key",
+			scheme:         x25519box.Scheme,
+			publicPEM:      otherPublicPEM,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{name: "Scheme header missing", publicPEM: publicPEM, expectedStatus: http.StatusBadRequest},
+		{name: "Unknown scheme", scheme: "rot13", publicPEM: publicPEM, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealed, ephemeral, err := x25519box.Seal(body, tt.publicPEM)
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/updates", bytes.NewReader(sealed))
+			req.Header.Set(HeaderEncryptedKey, base64.StdEncoding.EncodeToString(ephemeral))
+			if tt.scheme != "" {
+				req.Header.Set(HeaderEncryptionScheme, tt.scheme)
+			}
+			rec := httptest.NewRecorder()
+
+			mw := CryptoWithKeys(func() []string { return []string{rsaPEM, privatePEM} }, zap.NewNop().Sugar())
+			handler := mw(func(c echo.Context) error {
+				got, err := io.ReadAll(c.Request().Body)
+				require.NoError(t, err)
+				assert.Equal(t, body, got)
+				return c.NoContent(http.StatusOK)
+			})
+			require.NoError(t, handler(echo.New().NewContext(req, rec)))
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestCryptoWithKeys(t *testing.T) {
+	currentKey, currentPEM := generatePrivateKey(t)
+	nextKey, nextPEM := generatePrivateKey(t)
+	retiredKey, _ := generatePrivateKey(t)
+
+	tests := []struct {
+		key            *rsa.PrivateKey
+		name           string
+		scheme         string
+		path           string
+		expectedStatus int
+	}{
+		{name: "Encrypted with current key", key: currentKey, path: "/updates", expectedStatus: http.StatusOK},
+		{name: "Encrypted with next key", key: nextKey, path: "/updates", expectedStatus: http.StatusOK},
+		{name: "Encrypted with retired key", key: retiredKey, path: "/updates", expectedStatus: http.StatusBadRequest},
+		{
+			name:           "Explicit scheme",
+			key:            currentKey,
+			scheme:         EncryptionRSAAESGCM,
+			path:           "/updates",
+			expectedStatus: http.StatusOK,
+		},
+		{name: "Ignored path", path: "/crypto/keys", expectedStatus: http.StatusOK},
+		{name: "Ignored API path", path: "/api/capabilities", expectedStatus: http.StatusOK},
+		{name: "Ignored push path", path: "/metrics/job/batch", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(`[{"id":"a","type":"gauge","value":1}]`)
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewReader(body))
+			if tt.key != nil {
+				encrypted, encryptedKey := encryptHybrid(t, body, tt.key)
+				req = httptest.NewRequest(http.MethodPost, tt.path, bytes.NewReader(encrypted))
+				req.Header.Set(HeaderEncryptedKey, encryptedKey)
+				if tt.scheme != "" {
+					req.Header.Set(HeaderEncryptionScheme, tt.scheme)
+				}
+			}
+			rec := httptest.NewRecorder()
+
+			mw := CryptoWithKeys(func() []string { return []string{currentPEM, nextPEM} }, zap.NewNop().Sugar())
+			handler := mw(func(c echo.Context) error {
+				got, err := io.ReadAll(c.Request().Body)
+				require.NoError(t, err)
+				assert.Equal(t, body, got)
+				return c.NoContent(http.StatusOK)
+			})
+			require.NoError(t, handler(echo.New().NewContext(req, rec)))
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/retention"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Const HeaderExpiresAt carries the moment the metrics of an update request expire, as an RFC 3339 time
+// or Unix seconds.
+const HeaderExpiresAt = "X-Expires-At"
+
+// ExpiresAt creates a middleware passing the expiry from the X-Expires-At header to the metric controller
+// through the request context, so the metrics of the request are deleted once it has passed.
+// Requests without the header pass through unchanged; requests with a malformed one are answered
+// with 400 Bad Request.
+//
+// Returns:
+//   - echo.MiddlewareFunc: The configured middleware function.
+func ExpiresAt() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			raw := c.Request().Header.Get(HeaderExpiresAt)
+			if raw == "" {
+				return next(c)
+			}
+			expiresAt, err := retention.ParseExpiresAt(raw)
+			if err != nil {
+				return c.String(http.StatusBadRequest, fmt.Sprintf("Invalid %s header.", HeaderExpiresAt))
+			}
+			req := c.Request()
+			c.SetRequest(req.WithContext(retention.WithExpiresAt(req.Context(), expiresAt)))
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/retention"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpiresAt(t *testing.T) {
+	tests := []struct {
+		expected time.Time
+		name     string
+		header   string
+		status   int
+	}{
+		{name: "RFC 3339", header: "2024-05-01T12:00:00Z", expected: time.Unix(1714564800, 0), status: http.StatusOK},
+		{name: "Unix seconds", header: "1714564800", expected: time.Unix(1714564800, 0), status: http.StatusOK},
+		{name: "Without header", status: http.StatusOK},
+		{name: "Malformed", header: "tomorrow", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/updates", http.NoBody)
+			if tt.header != "" {
+				req.Header.Set(HeaderExpiresAt, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			var got time.Time
+			handler := ExpiresAt()(func(c echo.Context) error {
+				got = retention.ExpiresAtFrom(c.Request().Context())
+				return c.NoContent(http.StatusOK)
+			})
+			require.NoError(t, handler(c))
+			assert.Equal(t, tt.status, rec.Code)
+			assert.True(t, tt.expected.Equal(got))
+		})
+	}
+}
@@ -97,3 +97,11 @@ func (w *gzipWriter) Write(data []byte) (int, error) {
 	}
 	return n, nil
 }
+
+// Unwrap returns the underlying ResponseWriter, allowing http.ResponseController to flush it.
+//
+// Returns:
+//   - http.ResponseWriter: The wrapped writer.
+func (w *gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"github.com/gdyunin/metricol.git/internal/server/keyring"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AdvertiseKeys creates a middleware announcing the current and next key identifiers in response headers,
+// so agents learn about a key rotation from their regular traffic without polling.
+//
+// Parameters:
+//   - advertise: A function returning the identifiers to announce.
+//
+// Returns:
+//   - echo.MiddlewareFunc: The configured middleware function.
+func AdvertiseKeys(advertise func() keyring.Advertisement) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			adv := advertise()
+			headers := map[string]string{
+				keyring.HeaderSigningKeyID:     adv.SigningKeyID,
+				keyring.HeaderNextSigningKeyID: adv.NextSigningKeyID,
+				keyring.HeaderCryptoKeyID:      adv.CryptoKeyID,
+				keyring.HeaderNextCryptoKeyID:  adv.NextCryptoKeyID,
+			}
+			for name, value := range headers {
+				if value != "" {
+					c.Response().Header().Set(name, value)
+				}
+			}
+			return next(c)
+		}
+	}
+}
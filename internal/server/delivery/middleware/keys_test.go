@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/keyring"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdvertiseKeys(t *testing.T) {
+	tests := []struct {
+		expected map[string]string
+		name     string
+		adv      keyring.Advertisement
+	}{
+		{name: "Nothing to advertise", expected: map[string]string{}},
+		{
+			name: "Rotation in progress",
+			adv: keyring.Advertisement{
+				SigningKeyID:     "s1",
+				NextSigningKeyID: "s2",
+				CryptoKeyID:      "c1",
+				NextCryptoKeyID:  "c2",
+			},
+			expected: map[string]string{
+				keyring.HeaderSigningKeyID:     "s1",
+				keyring.HeaderNextSigningKeyID: "s2",
+				keyring.HeaderCryptoKeyID:      "c1",
+				keyring.HeaderNextCryptoKeyID:  "c2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			rec := httptest.NewRecorder()
+
+			handler := AdvertiseKeys(func() keyring.Advertisement { return tt.adv })(func(c echo.Context) error {
+				return c.String(http.StatusOK, "ok")
+			})
+			require.NoError(t, handler(e.NewContext(req, rec)))
+
+			for _, name := range []string{
+				keyring.HeaderSigningKeyID,
+				keyring.HeaderNextSigningKeyID,
+				keyring.HeaderCryptoKeyID,
+				keyring.HeaderNextCryptoKeyID,
+			} {
+				assert.Equal(t, tt.expected[name], rec.Header().Get(name), name)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/quota"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ClientLimiter decides whether a client may send another request.
+type ClientLimiter interface {
+	// Allow takes a token from the bucket of the client and reports how long to wait if there is none.
+	Allow(client string) (bool, time.Duration)
+}
+
+// RateLimit creates a middleware enforcing a request rate per client. Clients are told apart by their IP
+// address or, with byAPIKey, by the API key in the X-API-Key header, falling back to the IP address for
+// requests without one. Requests over the limit are answered with 429 Too Many Requests and a Retry-After
+// header saying when the client gets a new token.
+//
+// Parameters:
+//   - limiter: The limiter holding the token bucket of every client.
+//   - byAPIKey: Whether clients are told apart by their API key.
+//
+// Returns:
+//   - echo.MiddlewareFunc: The configured middleware function.
+func RateLimit(limiter ClientLimiter, byAPIKey bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			client := "ip:" + c.RealIP()
+			if key := c.Request().Header.Get(HeaderAPIKey); byAPIKey && key != "" {
+				// The key is hashed so the limiter does not keep it in memory.
+				client = "key:" + quota.KeyID(key)
+			}
+
+			ok, delay := limiter.Allow(client)
+			if !ok {
+				retryAfter := max(1, int(math.Ceil(delay.Seconds())))
+				c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(retryAfter))
+				return c.String(
+					http.StatusTooManyRequests,
+					fmt.Sprintf("Rate limit exceeded, retry in %d s.", retryAfter),
+				)
+			}
+			return next(c)
+		}
+	}
+}
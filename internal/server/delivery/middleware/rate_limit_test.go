@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/quota"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubClientLimiter struct {
+	delays map[string]time.Duration
+	client string
+}
+
+func (l *stubClientLimiter) Allow(client string) (bool, time.Duration) {
+	l.client = client
+	delay := l.delays[client]
+	return delay == 0, delay
+}
+
+func TestRateLimit(t *testing.T) {
+	tests := []struct {
+		delays             map[string]time.Duration
+		name               string
+		apiKey             string
+		expectedClient     string
+		expectedRetryAfter string
+		expectedCode       int
+		byAPIKey           bool
+	}{
+		{
+			name:           "Allowed by IP",
+			expectedClient: "ip:192.0.2.1",
+			expectedCode:   http.StatusOK,
+		},
+		{
+			name:               "Rejected by IP",
+			apiKey:             "team-a",
+			delays:             map[string]time.Duration{"ip:192.0.2.1": 1500 * time.Millisecond},
+			expectedClient:     "ip:192.0.2.1",
+			expectedCode:       http.StatusTooManyRequests,
+			expectedRetryAfter: "2",
+		},
+		{
+			name:               "Rejected by API key",
+			apiKey:             "team-a",
+			byAPIKey:           true,
+			delays:             map[string]time.Duration{"key:" + quota.KeyID("team-a"): time.Millisecond},
+			expectedClient:     "key:" + quota.KeyID("team-a"),
+			expectedCode:       http.StatusTooManyRequests,
+			expectedRetryAfter: "1",
+		},
+		{
+			name:           "API key missing falls back to IP",
+			byAPIKey:       true,
+			expectedClient: "ip:192.0.2.1",
+			expectedCode:   http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &stubClientLimiter{delays: tt.delays}
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/updates", http.NoBody)
+			req.RemoteAddr = "192.0.2.1:40000"
+			if tt.apiKey != "" {
+				req.Header.Set(HeaderAPIKey, tt.apiKey)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			handler := RateLimit(limiter, tt.byAPIKey)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+			require.NoError(t, handler(c))
+
+			assert.Equal(t, tt.expectedClient, limiter.client)
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, tt.expectedRetryAfter, rec.Header().Get(echo.HeaderRetryAfter))
+		})
+	}
+}
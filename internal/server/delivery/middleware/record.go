@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/reqrecord"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// HeaderDebugRecord asks the server to record the request and its response for debugging.
+	HeaderDebugRecord = "X-Debug-Record"
+	// maxRecordedBody is the number of body bytes kept per recorded request and response.
+	maxRecordedBody = 64 << 10
+	// redactedValue replaces the values of headers carrying credentials.
+	redactedValue = "[redacted]"
+)
+
+// RequestRecorder stores recorded requests.
+type RequestRecorder interface {
+	// Add stores a recorded request together with its response.
+	Add(record reqrecord.Record)
+}
+
+// Record creates a middleware capturing the full request and response of requests carrying the
+// X-Debug-Record header. It must run after decompression and decryption, so the recorded bodies are readable.
+// Credentials in the Authorization and X-API-Key headers are redacted, and bodies are cut at 64 KiB.
+//
+// Parameters:
+//   - recorder: The recorder storing the captured requests.
+//
+// Returns:
+//   - echo.MiddlewareFunc: The configured middleware function.
+func Record(recorder RequestRecorder) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.Header.Get(HeaderDebugRecord) == "" {
+				return next(c)
+			}
+
+			start := time.Now()
+			rawBody, err := getRawBody(req)
+			if err != nil {
+				return c.String(
+					http.StatusInternalServerError,
+					http.StatusText(http.StatusInternalServerError),
+				)
+			}
+
+			w := &recordingWriter{ResponseWriter: c.Response().Writer, body: &bytes.Buffer{}}
+			c.Response().Writer = w
+
+			// The error is rendered here, so the recorded response is the one the client receives.
+			if err = next(c); err != nil {
+				c.Error(err)
+			}
+
+			requestBody, requestTruncated := truncateBody(rawBody)
+			id := c.Response().Header().Get(echo.HeaderXRequestID)
+			if id == "" {
+				id = req.Header.Get(echo.HeaderXRequestID)
+			}
+			recorder.Add(reqrecord.Record{
+				Time:            start,
+				RequestHeaders:  redactHeaders(req.Header),
+				ResponseHeaders: redactHeaders(c.Response().Header()),
+				ID:              id,
+				Method:          req.Method,
+				Path:            req.URL.RequestURI(),
+				RequestBody:     string(requestBody),
+				ResponseBody:    w.body.String(),
+				DurationSeconds: time.Since(start).Seconds(),
+				Status:          c.Response().Status,
+				Truncated:       requestTruncated || w.truncated,
+			})
+			return nil
+		}
+	}
+}
+
+// recordingWriter wraps an http.ResponseWriter to keep a copy of the response body.
+type recordingWriter struct {
+	http.ResponseWriter
+	body      *bytes.Buffer // body holds the first maxRecordedBody bytes of the response.
+	truncated bool          // truncated reports that the response was longer than the kept copy.
+}
+
+// Write copies data to the body buffer and writes it to the underlying ResponseWriter.
+//
+// Parameters:
+//   - data: The data to write.
+//
+// Returns:
+//   - int: The number of bytes written.
+//   - error: An error if the write fails.
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	if room := maxRecordedBody - w.body.Len(); room < len(data) {
+		w.body.Write(data[:max(room, 0)])
+		w.truncated = true
+	} else {
+		w.body.Write(data)
+	}
+
+	i, err := w.ResponseWriter.Write(data)
+	if err != nil {
+		err = fmt.Errorf("error writing data in recording writer: %w", err)
+	}
+	return i, err
+}
+
+// Unwrap returns the underlying ResponseWriter, allowing http.ResponseController to flush it.
+//
+// Returns:
+//   - http.ResponseWriter: The wrapped writer.
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// truncateBody cuts a body to maxRecordedBody bytes.
+func truncateBody(body []byte) ([]byte, bool) {
+	if len(body) > maxRecordedBody {
+		return body[:maxRecordedBody], true
+	}
+	return body, false
+}
+
+// redactHeaders copies headers, replacing the values of headers carrying credentials.
+func redactHeaders(h http.Header) http.Header {
+	clone := h.Clone()
+	for _, name := range []string{echo.HeaderAuthorization, HeaderAPIKey} {
+		if clone.Get(name) != "" {
+			clone.Set(name, redactedValue)
+		}
+	}
+	return clone
+}
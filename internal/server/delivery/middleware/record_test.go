@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/reqrecord"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubRequestRecorder struct {
+	records []reqrecord.Record
+}
+
+func (r *stubRequestRecorder) Add(record reqrecord.Record) {
+	r.records = append(r.records, record)
+}
+
+func TestRecord(t *testing.T) {
+	tests := []struct {
+		handler        echo.HandlerFunc
+		name           string
+		body           string
+		expectedBody   string
+		expectedStatus int
+		record         bool
+		truncated      bool
+	}{
+		{
+			name:   "Not requested",
+			body:   `{"id":"x"}`,
+			record: false,
+			handler: func(c echo.Context) error {
+				return c.String(http.StatusOK, "ok")
+			},
+		},
+		{
+			name:   "Recorded",
+			body:   `{"id":"x"}`,
+			record: true,
+			handler: func(c echo.Context) error {
+				return c.String(http.StatusOK, "ok")
+			},
+			expectedBody:   "ok",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "Recorded error",
+			body:   `{"id":"x"}`,
+			record: true,
+			handler: func(echo.Context) error {
+				return echo.NewHTTPError(http.StatusBadRequest, "bad")
+			},
+			expectedBody:   "{\"message\":\"bad\"}\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Truncated",
+			body:   strings.Repeat("a", maxRecordedBody+1),
+			record: true,
+			handler: func(c echo.Context) error {
+				return c.String(http.StatusOK, "ok")
+			},
+			expectedBody:   "ok",
+			expectedStatus: http.StatusOK,
+			truncated:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/update?x=1", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderAuthorization, "Bearer secret")
+			req.Header.Set(HeaderAPIKey, "team-a")
+			req.Header.Set(echo.HeaderXRequestID, "req-1")
+			if tt.record {
+				req.Header.Set(HeaderDebugRecord, "1")
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			recorder := &stubRequestRecorder{}
+			var handlerBody string
+			handler := Record(recorder)(func(c echo.Context) error {
+				raw, err := getRawBody(c.Request())
+				require.NoError(t, err)
+				handlerBody = string(raw)
+				return tt.handler(c)
+			})
+
+			err := handler(c)
+			assert.Equal(t, tt.body, handlerBody)
+			if !tt.record {
+				assert.NoError(t, err)
+				assert.Empty(t, recorder.records)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, recorder.records, 1)
+			record := recorder.records[0]
+			assert.Equal(t, "req-1", record.ID)
+			assert.Equal(t, http.MethodPost, record.Method)
+			assert.Equal(t, "/update?x=1", record.Path)
+			assert.Equal(t, tt.expectedStatus, record.Status)
+			assert.Equal(t, tt.expectedBody, record.ResponseBody)
+			assert.Equal(t, tt.truncated, record.Truncated)
+			assert.Equal(t, redactedValue, record.RequestHeaders.Get(echo.HeaderAuthorization))
+			assert.Equal(t, "Bearer secret", req.Header.Get(echo.HeaderAuthorization))
+			assert.Equal(t, redactedValue, record.RequestHeaders.Get(HeaderAPIKey))
+			if !tt.truncated {
+				assert.Equal(t, tt.body, record.RequestBody)
+			} else {
+				assert.Len(t, record.RequestBody, maxRecordedBody)
+			}
+		})
+	}
+}
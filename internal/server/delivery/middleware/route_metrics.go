@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RouteObserver records the outcome of handled requests.
+type RouteObserver interface {
+	// Observe records a request by method, matched route pattern, response status and handling time.
+	Observe(method, route string, status int, elapsed time.Duration)
+}
+
+// RouteMetrics creates a middleware reporting the duration and response status of every request,
+// keyed by the route pattern the request matched rather than its path, so metric names stay bounded.
+// Requests that matched no route are reported with an empty route.
+//
+// Parameters:
+//   - observer: The observer recording the requests.
+//
+// Returns:
+//   - echo.MiddlewareFunc: The configured middleware function.
+func RouteMetrics(observer RouteObserver) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+
+			observer.Observe(c.Request().Method, c.Path(), responseStatus(c, err), time.Since(start))
+			return err
+		}
+	}
+}
+
+// responseStatus returns the status code a request is answered with.
+//
+// Parameters:
+//   - c: The Echo context of the handled request.
+//   - err: The error the handler chain returned.
+//
+// Returns:
+//   - int: The response status code.
+func responseStatus(c echo.Context, err error) int {
+	if err == nil || c.Response().Committed {
+		return c.Response().Status
+	}
+	// The error is turned into a response by the Echo error handler after the middleware returns.
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	return http.StatusInternalServerError
+}
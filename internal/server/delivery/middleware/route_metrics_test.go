@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type routeObservation struct {
+	method string
+	route  string
+	status int
+}
+
+type stubRouteObserver struct {
+	observed []routeObservation
+}
+
+func (o *stubRouteObserver) Observe(method, route string, status int, _ time.Duration) {
+	o.observed = append(o.observed, routeObservation{method: method, route: route, status: status})
+}
+
+func TestRouteMetrics(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		expected routeObservation
+	}{
+		{
+			name:     "Matched route",
+			method:   http.MethodPost,
+			path:     "/update/gauge/x/1",
+			expected: routeObservation{method: http.MethodPost, route: "/update/:type/:id/:value", status: http.StatusOK},
+		},
+		{
+			name:     "Handler error",
+			method:   http.MethodGet,
+			path:     "/fail",
+			expected: routeObservation{method: http.MethodGet, route: "/fail", status: http.StatusBadRequest},
+		},
+		{
+			name:     "Plain error",
+			method:   http.MethodGet,
+			path:     "/broken",
+			expected: routeObservation{method: http.MethodGet, route: "/broken", status: http.StatusInternalServerError},
+		},
+		{
+			name:     "Unmatched route",
+			method:   http.MethodGet,
+			path:     "/unknown/path",
+			expected: routeObservation{method: http.MethodGet, route: "", status: http.StatusNotFound},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			observer := &stubRouteObserver{}
+			e := echo.New()
+			e.Use(RouteMetrics(observer))
+			e.POST("/update/:type/:id/:value", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+			e.GET("/fail", func(echo.Context) error {
+				return echo.NewHTTPError(http.StatusBadRequest, "bad")
+			})
+			e.GET("/broken", func(echo.Context) error {
+				return assert.AnError
+			})
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, http.NoBody))
+
+			require.Len(t, observer.observed, 1)
+			assert.Equal(t, tt.expected, observer.observed[0])
+			assert.Equal(t, tt.expected.status, rec.Code)
+		})
+	}
+}
@@ -4,15 +4,24 @@ import (
 	"bytes"
 	"encoding/hex"
 	"fmt"
+	"hash"
 	"net/http"
+	"strings"
 
 	"github.com/gdyunin/metricol.git/pkg/sign"
 	"github.com/labstack/echo/v4"
 )
 
+// Const headerSign carries the HMAC-SHA256 signature of a response body, hex encoded.
+const headerSign = "HashSHA256"
+
 // Sign creates an Echo middleware that signs the HTTP response body using HMAC-SHA256.
-// The middleware wraps the response writer so that after the response body is written,
-// a "HashSHA256" header is added containing the signature computed using the provided key.
+// Responses are buffered until the handler returns, so the "HashSHA256" header carries the signature
+// of the complete body whatever way it was written: JSON, plain text, templated HTML or error pages.
+// Streaming responses, that is Server-Sent Events and responses flushed by the handler, are passed through
+// unbuffered and get the signature as an HTTP trailer once they end.
+//
+// The middleware must run inside Gzip, so the signature covers the uncompressed body clients read.
 //
 // Parameters:
 //   - key: The secret key used to sign the response body.
@@ -20,43 +29,59 @@ import (
 // Returns:
 //   - echo.MiddlewareFunc: The middleware function that applies the signing logic.
 func Sign(key string) echo.MiddlewareFunc {
+	return SignWithKey(func() string { return key })
+}
+
+// SignWithKey works like Sign but requests the key for every response, so responses follow key rotation.
+//
+// Parameters:
+//   - currentKey: A function returning the key used to sign the response body.
+//
+// Returns:
+//   - echo.MiddlewareFunc: The middleware function that applies the signing logic.
+func SignWithKey(currentKey func() string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) (err error) {
+			key := currentKey()
 			if key == "" {
 				return next(c)
 			}
 
+			resp := c.Response()
 			w := &signerWriter{
-				ResponseWriter: c.Response().Writer,
-				key:            key,
+				ResponseWriter: resp.Writer,
+				mac:            sign.New(key),
 				body:           &bytes.Buffer{},
 			}
-			c.Response().Writer = w
+			resp.Writer = w
+			// Gzip replaces the writer when the header is written; the signer stays in front of it.
+			resp.Before(func() {
+				if resp.Writer != w {
+					w.ResponseWriter = resp.Writer
+					resp.Writer = w
+				}
+			})
 
-			err = next(c)
-			if err != nil {
+			if err = next(c); err != nil {
 				c.Error(err)
 			}
-
-			// Ensure WriteHeader is called after the response body is populated.
-			if w.body.Len() > 0 {
-				w.WriteHeader(http.StatusOK)
-			}
-
-			return nil
+			return w.finish()
 		}
 	}
 }
 
-// signerWriter wraps an http.ResponseWriter to capture the response body
-// and to add a signature header upon writing the header.
+// signerWriter wraps an http.ResponseWriter to sign the response body. It buffers the response and writes it
+// with the signature header when finished, or passes it through and signs it in a trailer once it streams.
 type signerWriter struct {
 	http.ResponseWriter
-	body *bytes.Buffer // body buffers the response data.
-	key  string        // key is used to compute the HMAC signature.
+	mac       hash.Hash     // mac computes the signature of the body written so far.
+	body      *bytes.Buffer // body buffers the response until it is finished or starts streaming.
+	status    int           // status is the status code set by the handler; zero until it is set.
+	streaming bool          // streaming reports that the response is passed through and signed in a trailer.
 }
 
-// Write writes data to the buffer and to the underlying ResponseWriter.
+// Write adds data to the signature and buffers it, or writes it to the underlying ResponseWriter
+// once the response streams.
 //
 // Parameters:
 //   - data: The data to write.
@@ -65,7 +90,13 @@ type signerWriter struct {
 //   - int: The number of bytes written.
 //   - error: An error if the write fails.
 func (w *signerWriter) Write(data []byte) (int, error) {
-	w.body.Write(data)
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.mac.Write(data)
+	if !w.streaming {
+		return w.body.Write(data) //nolint:wrapcheck // writes to a bytes.Buffer do not fail.
+	}
 	i, err := w.ResponseWriter.Write(data)
 	if err != nil {
 		err = fmt.Errorf("error writing data in signer writer: %w", err)
@@ -73,14 +104,77 @@ func (w *signerWriter) Write(data []byte) (int, error) {
 	return i, err
 }
 
-// WriteHeader sets the "HashSHA256" header with the computed signature and writes the HTTP header.
+// WriteHeader records the status code. Event streams never end, so they start streaming at once;
+// other responses keep the header until the body is complete.
 //
 // Parameters:
 //   - statusCode: The HTTP status code to write.
 func (w *signerWriter) WriteHeader(statusCode int) {
-	w.Header().Set(
-		"HashSHA256",
-		hex.EncodeToString(sign.MakeSign(w.body.Bytes(), w.key)),
-	)
-	w.ResponseWriter.WriteHeader(statusCode)
+	if w.status != 0 {
+		return
+	}
+	w.status = statusCode
+	if isEventStream(w.Header()) {
+		w.stream()
+	}
+}
+
+// Flush starts streaming the response and flushes the underlying ResponseWriter.
+func (w *signerWriter) Flush() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.stream()
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter, allowing http.ResponseController to reach it.
+//
+// Returns:
+//   - http.ResponseWriter: The wrapped writer.
+func (w *signerWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// stream announces the signature trailer, writes the header and the buffered body, and passes
+// later writes through.
+func (w *signerWriter) stream() {
+	if w.streaming {
+		return
+	}
+	w.streaming = true
+	w.Header().Add("Trailer", headerSign)
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.body.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.body.Bytes())
+		w.body.Reset()
+	}
+}
+
+// finish sets the signature, as a trailer on streamed responses and as a header otherwise,
+// and writes the buffered response.
+func (w *signerWriter) finish() error {
+	signature := hex.EncodeToString(w.mac.Sum(nil))
+	if w.streaming {
+		w.Header().Set(headerSign, signature)
+		return nil
+	}
+	if w.status == 0 {
+		return nil
+	}
+
+	w.Header().Set(headerSign, signature)
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.body.Len() == 0 {
+		return nil
+	}
+	if _, err := w.ResponseWriter.Write(w.body.Bytes()); err != nil {
+		return fmt.Errorf("error writing signed response: %w", err)
+	}
+	return nil
+}
+
+// isEventStream reports whether the response is a Server-Sent Events stream.
+func isEventStream(h http.Header) bool {
+	return strings.HasPrefix(h.Get(echo.HeaderContentType), "text/event-stream")
 }
@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"compress/gzip"
 	"encoding/hex"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -9,14 +11,18 @@ import (
 	"github.com/gdyunin/metricol.git/pkg/sign"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 )
 
 func TestSignMiddleware(t *testing.T) {
 	tests := []struct {
-		name           string
-		key            string
-		responseBody   string
-		expectedHeader string
+		name            string
+		key             string
+		responseBody    string
+		expectedHeader  string
+		expectedTrailer string
+		eventStream     bool
 	}{
 		{
 			name:           "No key provided",
@@ -30,6 +36,13 @@ func TestSignMiddleware(t *testing.T) {
 			responseBody:   "test body",
 			expectedHeader: hex.EncodeToString(sign.MakeSign([]byte("test body"), "secret")),
 		},
+		{
+			name:            "Event stream is signed in a trailer",
+			key:             "secret",
+			responseBody:    "event: metric\ndata: {}\n\n",
+			expectedTrailer: hex.EncodeToString(sign.MakeSign([]byte("event: metric\ndata: {}\n\n"), "secret")),
+			eventStream:     true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -44,6 +57,13 @@ func TestSignMiddleware(t *testing.T) {
 
 			// Handler to be wrapped
 			handler := func(c echo.Context) error {
+				if tt.eventStream {
+					c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
+					c.Response().WriteHeader(http.StatusOK)
+					_, err := c.Response().Write([]byte(tt.responseBody))
+					c.Response().Flush()
+					return err
+				}
 				return c.String(http.StatusOK, tt.responseBody)
 			}
 
@@ -51,17 +71,120 @@ func TestSignMiddleware(t *testing.T) {
 			_ = mw(handler)(c)
 
 			// Assertions
-			if tt.expectedHeader != "" {
-				assert.Equal(
-					t,
-					tt.expectedHeader,
-					rec.Header().Get("HashSHA256"),
-					"Expected and actual hash do not match",
-				)
-			} else {
-				assert.Empty(t, rec.Header().Get("HashSHA256"))
-			}
+			result := rec.Result()
+			defer func() { _ = result.Body.Close() }()
+			assert.Equal(t, tt.expectedHeader, result.Header.Get("HashSHA256"), "Expected and actual hash do not match")
+			assert.Equal(t, tt.expectedTrailer, result.Trailer.Get("HashSHA256"))
 			assert.Equal(t, tt.responseBody, rec.Body.String())
 		})
 	}
 }
+
+func TestSignMiddleware_Responses(t *testing.T) {
+	tests := []struct {
+		handler        echo.HandlerFunc
+		name           string
+		expectedBody   string
+		expectedStatus int
+	}{
+		{
+			name: "Body written in parts",
+			handler: func(c echo.Context) error {
+				c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+				c.Response().WriteHeader(http.StatusOK)
+				_, _ = c.Response().Write([]byte("<html>"))
+				_, err := c.Response().Write([]byte("</html>"))
+				return err
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "<html></html>",
+		},
+		{
+			name: "Status code is kept",
+			handler: func(c echo.Context) error {
+				return c.String(http.StatusNotFound, "Metric not found.")
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "Metric not found.",
+		},
+		{
+			name: "Error rendered by the error handler",
+			handler: func(_ echo.Context) error {
+				return echo.NewHTTPError(http.StatusBadRequest, "bad")
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "{\"message\":\"bad\"}\n",
+		},
+		{
+			name: "Empty body",
+			handler: func(c echo.Context) error {
+				return c.NoContent(http.StatusNoContent)
+			},
+			expectedStatus: http.StatusNoContent,
+			expectedBody:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", http.NoBody), rec)
+
+			require.NoError(t, Sign("secret")(tt.handler)(c))
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.expectedBody, rec.Body.String())
+			assert.Equal(
+				t,
+				hex.EncodeToString(sign.MakeSign([]byte(tt.expectedBody), "secret")),
+				rec.Result().Header.Get("HashSHA256"),
+			)
+		})
+	}
+}
+
+func TestSignMiddleware_FlushStreams(t *testing.T) {
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", http.NoBody), rec)
+
+	handler := func(c echo.Context) error {
+		_, _ = c.Response().Write([]byte("first "))
+		c.Response().Flush()
+		assert.Equal(t, "first ", rec.Body.String(), "flushed data must reach the client")
+		_, err := c.Response().Write([]byte("second"))
+		return err
+	}
+	require.NoError(t, Sign("secret")(handler)(c))
+
+	result := rec.Result()
+	defer func() { _ = result.Body.Close() }()
+	assert.True(t, rec.Flushed)
+	assert.Empty(t, result.Header.Get("HashSHA256"))
+	assert.Equal(
+		t,
+		hex.EncodeToString(sign.MakeSign([]byte("first second"), "secret")),
+		result.Trailer.Get("HashSHA256"),
+	)
+}
+
+func TestSignMiddleware_InsideGzip(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	handler := func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{"id": "Alloc"})
+	}
+	require.NoError(t, Gzip(zap.NewNop().Sugar())(Sign("secret")(handler))(c))
+
+	assert.Equal(t, "gzip", rec.Header().Get(echo.HeaderContentEncoding))
+	gr, err := gzip.NewReader(rec.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(gr)
+	require.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(sign.MakeSign(body, "secret")), rec.Header().Get("HashSHA256"))
+}
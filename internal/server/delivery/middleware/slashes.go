@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CollapseSlashes creates a pre-routing middleware replacing runs of slashes in the request path with
+// a single slash, so "//update///gauge/x/1" is routed like "/update/gauge/x/1".
+//
+// Returns:
+//   - echo.MiddlewareFunc: The configured middleware function.
+func CollapseSlashes() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if strings.Contains(req.URL.Path, "//") {
+				req.URL.Path = collapseSlashes(req.URL.Path)
+				if req.URL.RawPath != "" {
+					req.URL.RawPath = collapseSlashes(req.URL.RawPath)
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// collapseSlashes replaces every run of slashes in a path with a single slash.
+func collapseSlashes(path string) string {
+	var b strings.Builder
+	b.Grow(len(path))
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' && i > 0 && path[i-1] == '/' {
+			continue
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
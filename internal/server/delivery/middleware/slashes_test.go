@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollapseSlashes(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		expectedPath string
+	}{
+		{name: "Clean path", path: "/update/gauge/x/1", expectedPath: "/update/gauge/x/1"},
+		{name: "Leading slashes", path: "//update/gauge/x/1", expectedPath: "/update/gauge/x/1"},
+		{name: "Inner slashes", path: "/update///gauge//x/1", expectedPath: "/update/gauge/x/1"},
+		{name: "Root", path: "///", expectedPath: "/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", http.NoBody)
+			req.URL.Path = tt.path
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			var routed string
+			handler := CollapseSlashes()(func(c echo.Context) error {
+				routed = c.Request().URL.Path
+				return nil
+			})
+
+			assert.NoError(t, handler(c))
+			assert.Equal(t, tt.expectedPath, routed)
+		})
+	}
+}
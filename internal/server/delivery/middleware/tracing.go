@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName names the tracer of the request spans.
+const tracerName = "github.com/gdyunin/metricol.git/internal/server/delivery/middleware"
+
+// Trace creates a middleware starting a server span for every request. The span continues the trace of
+// the client if the request carries a W3C traceparent header, and is named after the matched route
+// pattern rather than the path, so span names stay bounded. Responses with a 5xx status mark the span
+// as failed.
+//
+// Parameters:
+//   - provider: The tracer provider the spans are started with.
+//
+// Returns:
+//   - echo.MiddlewareFunc: The configured middleware function.
+func Trace(provider trace.TracerProvider) echo.MiddlewareFunc {
+	tracer := provider.Tracer(tracerName)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+			name := req.Method
+			if c.Path() != "" {
+				name += " " + c.Path()
+			}
+			ctx, span := tracer.Start(ctx, name,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(req.Method),
+					semconv.HTTPRoute(c.Path()),
+					semconv.URLPath(req.URL.Path),
+				),
+			)
+			defer span.End()
+			c.SetRequest(req.WithContext(ctx))
+
+			err := next(c)
+			status := responseStatus(c, err)
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= http.StatusInternalServerError {
+				if err != nil {
+					span.RecordError(err)
+				}
+				span.SetStatus(codes.Error, fmt.Sprintf("response status %d", status))
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTrace(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	var handlerSpan trace.SpanContext
+	e := echo.New()
+	e.Use(Trace(provider))
+	e.POST("/update/:type/:id/:value", func(c echo.Context) error {
+		handlerSpan = trace.SpanContextFromContext(c.Request().Context())
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET("/broken", func(echo.Context) error { return errors.New("storage is down") })
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest(http.MethodPost, "/update/gauge/x/1", http.NoBody)
+	req.Header.Set("traceparent", parent)
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/broken", http.NoBody))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	update := spans[0]
+	assert.Equal(t, "POST /update/:type/:id/:value", update.Name())
+	assert.Equal(t, trace.SpanKindServer, update.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", update.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", update.Parent().SpanID().String())
+	assert.True(t, update.Parent().IsRemote())
+	assert.Equal(t, update.SpanContext(), handlerSpan, "handlers see the request span in the request context")
+	assert.Contains(t, update.Attributes(), semconv.HTTPResponseStatusCode(http.StatusOK))
+	assert.Equal(t, codes.Unset, update.Status().Code)
+
+	broken := spans[1]
+	assert.Equal(t, "GET /broken", broken.Name())
+	assert.False(t, broken.Parent().IsValid(), "requests without traceparent start a new trace")
+	assert.Contains(t, broken.Attributes(), semconv.HTTPResponseStatusCode(http.StatusInternalServerError))
+	assert.Equal(t, codes.Error, broken.Status().Code)
+	require.Len(t, broken.Events(), 1)
+	assert.Equal(t, "exception", broken.Events()[0].Name)
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TrustedSubnet creates a middleware accepting requests only from clients in the trusted subnet.
+// The client address is taken from the X-Real-IP header, falling back to the remote address of the
+// connection; requests from other addresses, or whose address cannot be parsed, are answered with
+// 403 Forbidden. A nil subnet accepts every request.
+//
+// Parameters:
+//   - subnet: The trusted subnet.
+//
+// Returns:
+//   - echo.MiddlewareFunc: The configured middleware function.
+func TrustedSubnet(subnet *net.IPNet) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if subnet == nil {
+				return next(c)
+			}
+
+			if ip := clientIP(c.Request()); ip == nil || !subnet.Contains(ip) {
+				return c.String(http.StatusForbidden, "Client address is outside the trusted subnet.")
+			}
+			return next(c)
+		}
+	}
+}
+
+// clientIP returns the client address from the X-Real-IP header or, without it, from the remote address.
+func clientIP(req *http.Request) net.IP {
+	if raw := req.Header.Get(echo.HeaderXRealIP); raw != "" {
+		return net.ParseIP(raw)
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustedSubnet(t *testing.T) {
+	_, subnet, err := net.ParseCIDR("10.0.0.0/8")
+	require.NoError(t, err)
+
+	tests := []struct {
+		subnet       *net.IPNet
+		name         string
+		realIP       string
+		remoteAddr   string
+		expectedCode int
+	}{
+		{
+			name:         "No subnet",
+			remoteAddr:   "192.0.2.1:40000",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Real IP inside",
+			subnet:       subnet,
+			realIP:       "10.1.2.3",
+			remoteAddr:   "192.0.2.1:40000",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Real IP outside",
+			subnet:       subnet,
+			realIP:       "192.0.2.1",
+			remoteAddr:   "10.1.2.3:40000",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "Remote address inside",
+			subnet:       subnet,
+			remoteAddr:   "10.1.2.3:40000",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Remote address outside",
+			subnet:       subnet,
+			remoteAddr:   "192.0.2.1:40000",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "Malformed real IP",
+			subnet:       subnet,
+			realIP:       "agent-1",
+			remoteAddr:   "10.1.2.3:40000",
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/updates", http.NoBody)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				req.Header.Set(echo.HeaderXRealIP, tt.realIP)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			handler := TrustedSubnet(tt.subnet)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+			require.NoError(t, handler(c))
+			assert.Equal(t, tt.expectedCode, rec.Code)
+		})
+	}
+}
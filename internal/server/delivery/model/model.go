@@ -1,35 +1,45 @@
 // Package model defines the data structures and conversion functions used to map
 // between the internal entity representation of a metric and the model representation
-// used for JSON serialization and deserialization. This package supports both counter
-// and gauge metric types.
+// used for JSON serialization and deserialization. This package supports counter, float counter,
+// gauge and info metric types.
 package model
 
 import (
+	"time"
+
 	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
 	"github.com/gdyunin/metricol.git/pkg/convert"
 )
 
 // Metric represents the structure used for JSON serialization and deserialization of metrics.
-// It includes optional fields for Counter and Gauge metrics. For counter metrics, the Delta field
-// is used, whereas for gauge metrics, the Value field is used.
+// It includes optional fields for Counter, Gauge and Info metrics. For counter metrics, the Delta field
+// is used, for gauge and float counter metrics the Value field is used, and for info metrics the Info field
+// is used.
 // The ID field corresponds to the unique identifier of the metric, and MType indicates the metric type.
 type Metric struct {
 	// Delta holds the integer value for counter metrics.
 	// It is optional and is only used when MType is "counter".
 	Delta *int64 `json:"delta,omitempty"`
-	// Value holds the floating-point value for gauge metrics.
-	// It is optional and is only used when MType is "gauge".
+	// Value holds the floating-point value for gauge and float counter metrics.
+	// It is optional and is only used when MType is "gauge" or "fcounter".
 	Value *float64 `json:"value,omitempty"`
+	// Info holds the string or boolean value for info metrics.
+	// It is optional and is only used when MType is "info".
+	Info any `json:"info,omitempty"`
+	// Timestamp is the moment the value was observed.
+	// It is optional; the server uses the time of receipt when it is omitted.
+	Timestamp *time.Time `json:"timestamp,omitempty"`
 	// ID is the unique identifier for the metric.
 	ID string `json:"id"              param:"id"`
-	// MType represents the type of the metric, such as "counter" or "gauge".
+	// MType represents the type of the metric, such as "counter", "fcounter", "gauge" or "info".
 	MType string `json:"type"            param:"type"`
 }
 
 // ToEntityMetric converts a Metric model to an entity.Metric.
 // It maps the ID and MType fields directly and assigns the appropriate value based on the metric type.
-// If MType is "counter" and Delta is non-nil, Delta is used; if MType is "gauge" and Value is non-nil,
-// Value is used; otherwise, the Value field of the resulting entity.Metric is set to nil.
+// If MType is "counter" and Delta is non-nil, Delta is used; if MType is "gauge" or "fcounter" and Value
+// is non-nil, Value is used; if MType is "info", Info is used as is; otherwise, the Value field of
+// the resulting entity.Metric is set to nil.
 //
 // Returns:
 //   - A pointer to an entity.Metric with values mapped from the Metric model.
@@ -38,12 +48,17 @@ func (m *Metric) ToEntityMetric() *entity.Metric {
 		Name: m.ID,
 		Type: m.MType,
 	}
+	if m.Timestamp != nil {
+		metric.Timestamp = *m.Timestamp
+	}
 
 	switch {
 	case m.MType == entity.MetricTypeCounter && m.Delta != nil:
 		metric.Value = *m.Delta
-	case m.MType == entity.MetricTypeGauge && m.Value != nil:
+	case (m.MType == entity.MetricTypeGauge || m.MType == entity.MetricTypeFloatCounter) && m.Value != nil:
 		metric.Value = *m.Value
+	case m.MType == entity.MetricTypeInfo && m.Info != nil:
+		metric.Value = m.Info
 	default:
 		metric.Value = nil
 	}
@@ -54,7 +69,8 @@ func (m *Metric) ToEntityMetric() *entity.Metric {
 // FromEntityMetric converts an entity.Metric to a Metric model.
 // It maps the Name and Type fields to ID and MType respectively, and converts the Value field
 // based on the metric type: for "counter", it converts the value to an integer (Delta),
-// and for "gauge", it assigns the value to Value.
+// for "gauge", it assigns the value to Value, for "fcounter", it converts the value to a float (Value),
+// and for "info", it assigns a string or boolean value to Info.
 // If the input entity.Metric is nil, the function returns nil.
 //
 // Parameters:
@@ -71,6 +87,10 @@ func FromEntityMetric(em *entity.Metric) *Metric {
 		ID:    em.Name,
 		MType: em.Type,
 	}
+	if !em.Timestamp.IsZero() {
+		timestamp := em.Timestamp
+		metric.Timestamp = &timestamp
+	}
 
 	switch em.Type {
 	case entity.MetricTypeCounter:
@@ -81,6 +101,14 @@ func FromEntityMetric(em *entity.Metric) *Metric {
 		if value, ok := em.Value.(float64); ok {
 			metric.Value = &value
 		}
+	case entity.MetricTypeFloatCounter:
+		if value, err := convert.AnyToFloat64(em.Value); err == nil {
+			metric.Value = &value
+		}
+	case entity.MetricTypeInfo:
+		if entity.IsInfoValue(em.Value) {
+			metric.Info = em.Value
+		}
 	}
 
 	return &metric
@@ -135,3 +163,19 @@ func FromEntityMetrics(em *entity.Metrics) *Metrics {
 
 	return &models
 }
+
+// SourcedMetric is a metric labeled with the server it was read from, as listed by /api/metrics.
+type SourcedMetric struct {
+	Metric
+	// Source is the name of the server holding the metric.
+	Source string `json:"source"`
+}
+
+// FederatedMetrics is the response of /api/metrics: the metrics of this server and its federation peers,
+// and the state of every source.
+type FederatedMetrics struct {
+	// Sources maps the name of every queried server to "ok" or the reason its metrics are missing.
+	Sources map[string]string `json:"sources"`
+	// Metrics are the metrics of all sources, labeled with their source.
+	Metrics []SourcedMetric `json:"metrics"`
+}
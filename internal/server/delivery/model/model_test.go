@@ -2,6 +2,7 @@ package model
 
 import (
 	"testing"
+	"time"
 
 	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
 	"github.com/stretchr/testify/assert"
@@ -23,6 +24,31 @@ func TestToEntityMetric(t *testing.T) {
 			input:    &Metric{ID: "test_gauge", MType: "gauge", Value: float64Ptr(3.14)},
 			expected: &entity.Metric{Name: "test_gauge", Type: "gauge", Value: float64(3.14)},
 		},
+		{
+			name:     "Convert float counter metric",
+			input:    &Metric{ID: "cpu_seconds", MType: "fcounter", Value: float64Ptr(0.25)},
+			expected: &entity.Metric{Name: "cpu_seconds", Type: "fcounter", Value: float64(0.25)},
+		},
+		{
+			name:     "Convert string info metric",
+			input:    &Metric{ID: "test_info", MType: "info", Info: "v1.2.3"},
+			expected: &entity.Metric{Name: "test_info", Type: "info", Value: "v1.2.3"},
+		},
+		{
+			name:     "Convert boolean info metric",
+			input:    &Metric{ID: "test_flag", MType: "info", Info: false},
+			expected: &entity.Metric{Name: "test_flag", Type: "info", Value: false},
+		},
+		{
+			name:  "Convert timestamped metric",
+			input: &Metric{ID: "test_gauge", MType: "gauge", Value: float64Ptr(1), Timestamp: timePtr(testTimestamp)},
+			expected: &entity.Metric{
+				Name:      "test_gauge",
+				Type:      "gauge",
+				Value:     float64(1),
+				Timestamp: testTimestamp,
+			},
+		},
 		{
 			name:     "Invalid metric type",
 			input:    &Metric{ID: "test_invalid", MType: "invalid"},
@@ -54,6 +80,31 @@ func TestFromEntityMetric(t *testing.T) {
 			input:    &entity.Metric{Name: "test_gauge", Type: "gauge", Value: float64(2.71)},
 			expected: &Metric{ID: "test_gauge", MType: "gauge", Value: float64Ptr(2.71)},
 		},
+		{
+			name:     "Convert entity float counter metric",
+			input:    &entity.Metric{Name: "cpu_seconds", Type: "fcounter", Value: float64(1.75)},
+			expected: &Metric{ID: "cpu_seconds", MType: "fcounter", Value: float64Ptr(1.75)},
+		},
+		{
+			name:     "Convert entity info metric",
+			input:    &entity.Metric{Name: "test_info", Type: "info", Value: "v1.2.3"},
+			expected: &Metric{ID: "test_info", MType: "info", Info: "v1.2.3"},
+		},
+		{
+			name:     "Convert entity info metric with unsupported value",
+			input:    &entity.Metric{Name: "test_info", Type: "info", Value: 1.5},
+			expected: &Metric{ID: "test_info", MType: "info"},
+		},
+		{
+			name: "Convert timestamped entity metric",
+			input: &entity.Metric{
+				Name:      "test_gauge",
+				Type:      "gauge",
+				Value:     float64(1),
+				Timestamp: testTimestamp,
+			},
+			expected: &Metric{ID: "test_gauge", MType: "gauge", Value: float64Ptr(1), Timestamp: timePtr(testTimestamp)},
+		},
 		{
 			name:     "Invalid entity metric type",
 			input:    &entity.Metric{Name: "test_invalid", Type: "invalid"},
@@ -69,6 +120,12 @@ func TestFromEntityMetric(t *testing.T) {
 	}
 }
 
+var testTimestamp = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
 func int64Ptr(i int64) *int64 {
 	return &i
 }
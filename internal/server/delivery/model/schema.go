@@ -0,0 +1,49 @@
+package model
+
+import (
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/schema"
+)
+
+//go:generate go run ../../../../cmd/schemagen -format jsonschema -o ../../../../api/metric.schema.json
+//go:generate go run ../../../../cmd/schemagen -format proto -o ../../../../api/metric.proto
+//go:generate go run ../../../../cmd/schemagen -format go -o ../../../../api/client/metric.go
+
+// metricAnnotations document the fields of Metric for the generated schemas. Every field needs an entry;
+// the protobuf field numbers are published and must never be reused.
+var metricAnnotations = map[string]schema.Annotation{
+	"delta": {Number: 1, Doc: "The integer value of counter metrics."},
+	"value": {Number: 2, Doc: "The floating-point value of gauge and float counter metrics."},
+	"info": {
+		Number: 3,
+		Doc:    "The string or boolean value of info metrics.",
+		Kinds:  []schema.Kind{schema.KindString, schema.KindBoolean},
+	},
+	"timestamp": {
+		Number: 4,
+		Doc:    "The moment the value was observed; the server uses the time of receipt if it is left out.",
+	},
+	"id": {Number: 5, Doc: "The name of the metric."},
+	"type": {
+		Number: 6,
+		Doc:    "The type of the metric, which selects the field holding the value.",
+		Enum: []string{
+			entity.MetricTypeCounter,
+			entity.MetricTypeFloatCounter,
+			entity.MetricTypeGauge,
+			entity.MetricTypeInfo,
+		},
+	},
+}
+
+// MetricSchema describes the JSON encoding of Metric, which agents and third-party clients send to and
+// receive from the server.
+//
+// Returns:
+//   - *schema.Model: The description of Metric.
+//   - error: An error if a field of Metric is not annotated.
+func MetricSchema() (*schema.Model, error) {
+	//nolint:wrapcheck // The description is built from the package's own annotations.
+	return schema.FromStruct("Metric", "A metric as reported to and returned by the server.",
+		Metric{}, metricAnnotations)
+}
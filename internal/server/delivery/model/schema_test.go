@@ -0,0 +1,53 @@
+package model
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/api/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apiDir holds the generated schemas and client structs.
+var apiDir = filepath.Join("..", "..", "..", "..", "api")
+
+// TestMetricSchema_UpToDate fails when Metric changed without regenerating the published artifacts.
+func TestMetricSchema_UpToDate(t *testing.T) {
+	m, err := MetricSchema()
+	require.NoError(t, err)
+
+	jsonSchema, err := m.JSONSchema()
+	require.NoError(t, err)
+	goClient, err := m.Go("client")
+	require.NoError(t, err)
+
+	generated := map[string][]byte{
+		"metric.schema.json": jsonSchema,
+		"metric.proto":       m.Proto("metricol.v1"),
+		"client/metric.go":   goClient,
+	}
+	for name, want := range generated {
+		got, err := os.ReadFile(filepath.Join(apiDir, name))
+		require.NoError(t, err)
+		assert.Equal(t, string(want), string(got), "api/%s is stale, run go generate ./internal/server/delivery/model", name)
+	}
+}
+
+func TestMetricSchema_ClientRoundTrip(t *testing.T) {
+	delta := int64(5)
+	timestamp := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	sent := Metric{ID: "poll_count", MType: "counter", Delta: &delta, Timestamp: &timestamp}
+
+	body, err := json.Marshal(sent)
+	require.NoError(t, err)
+	var received client.Metric
+	require.NoError(t, json.Unmarshal(body, &received))
+	again, err := json.Marshal(received)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, string(body), string(again))
+}
@@ -0,0 +1,402 @@
+package delivery
+
+import (
+	"net"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/backup"
+	"github.com/gdyunin/metricol.git/internal/server/delivery/federation"
+	custMiddleware "github.com/gdyunin/metricol.git/internal/server/delivery/middleware"
+	"github.com/gdyunin/metricol.git/internal/server/internal/clockskew"
+	"github.com/gdyunin/metricol.git/internal/server/internal/controller"
+	"github.com/gdyunin/metricol.git/internal/server/internal/history"
+	"github.com/gdyunin/metricol.git/internal/server/internal/quota"
+	"github.com/gdyunin/metricol.git/internal/server/internal/ratelimit"
+	"github.com/gdyunin/metricol.git/internal/server/internal/reqrecord"
+	"github.com/gdyunin/metricol.git/internal/server/internal/retention"
+	"github.com/gdyunin/metricol.git/internal/server/internal/stream"
+	"github.com/gdyunin/metricol.git/internal/server/provisioning"
+	"github.com/gdyunin/metricol.git/pkg/buildinfo"
+	"github.com/gdyunin/metricol.git/pkg/configaudit"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Option configures optional behavior of an EchoServer.
+type Option func(*EchoServer)
+
+// WithMetricRateLimit limits how many updates per second the server accepts for a single metric.
+// Updates above the limit are rejected with 429 Too Many Requests. A non-positive value disables the limit.
+//
+// Parameters:
+//   - perSecond: The maximum number of updates per second for one metric.
+//
+// Returns:
+//   - Option: The option applying the limit.
+func WithMetricRateLimit(perSecond int) Option {
+	return func(s *EchoServer) {
+		s.serviceOpts = append(s.serviceOpts, controller.WithUpdateRateLimit(perSecond))
+		s.limits.MetricRateLimit = max(perSecond, 0)
+	}
+}
+
+// WithClientRateLimit limits how many requests per second every client may send to the /update and /updates
+// routes, with a token bucket per client. Requests above the limit are rejected with 429 Too Many Requests
+// and a Retry-After header. A non-positive rate disables the limit.
+//
+// Parameters:
+//   - perSecond: The sustained number of requests per second allowed to a client.
+//   - burst: The number of requests a client may send at once.
+//   - byAPIKey: Whether clients are told apart by their X-API-Key header rather than their IP address.
+//
+// Returns:
+//   - Option: The option applying the limit.
+func WithClientRateLimit(perSecond float64, burst int, byAPIKey bool) Option {
+	return func(s *EchoServer) {
+		if perSecond <= 0 {
+			return
+		}
+		s.clientLimiter = ratelimit.NewLimiter(perSecond, burst)
+		s.limitByAPIKey = byAPIKey
+		s.serviceOpts = append(s.serviceOpts, controller.WithClientRateLimiter(s.clientLimiter))
+	}
+}
+
+// WithCardinalityLimits caps the number of distinct metrics the server accepts.
+// Updates introducing metrics beyond a limit are rejected with 422 Unprocessable Entity.
+//
+// Parameters:
+//   - maxSeries: The maximum total number of distinct metrics; non-positive means unlimited.
+//   - prefixLimits: The maximum number of distinct metrics per metric name prefix.
+//
+// Returns:
+//   - Option: The option applying the limits.
+func WithCardinalityLimits(maxSeries int, prefixLimits map[string]int) Option {
+	return func(s *EchoServer) {
+		s.serviceOpts = append(s.serviceOpts, controller.WithCardinalityLimits(maxSeries, prefixLimits))
+		s.limits.MaxSeries = max(maxSeries, 0)
+	}
+}
+
+// WithGaugeSmoothing stores an exponentially weighted moving average of every gauge matching a pattern
+// next to its raw value, as a gauge named after it with the "_ewma" suffix.
+//
+// Parameters:
+//   - alphas: The weight of new samples, in (0, 1], keyed by the glob pattern of gauge names.
+//
+// Returns:
+//   - Option: The option enabling smoothing.
+func WithGaugeSmoothing(alphas map[string]float64) Option {
+	return func(s *EchoServer) {
+		s.serviceOpts = append(s.serviceOpts, controller.WithGaugeSmoothing(alphas))
+	}
+}
+
+// WithMetricNameFilter accepts only metrics whose names match an allow pattern, if any are given, and match
+// no deny pattern. Refused metrics are silently dropped, or the update is rejected with 403 Forbidden if reject
+// is set. Patterns are globs as in path.Match.
+//
+// Parameters:
+//   - allow: The patterns of accepted metric names.
+//   - deny: The patterns of refused metric names.
+//   - reject: Whether to reject updates containing refused metrics instead of dropping them.
+//
+// Returns:
+//   - Option: The option applying the filter.
+func WithMetricNameFilter(allow, deny []string, reject bool) Option {
+	return func(s *EchoServer) {
+		s.serviceOpts = append(s.serviceOpts, controller.WithNameFilter(allow, deny, reject))
+		s.nameAllow, s.nameDeny = allow, deny
+	}
+}
+
+// WithTrustedSubnet accepts requests only from clients in the subnet, identified by the X-Real-IP header
+// or the remote address; others are rejected with 403 Forbidden. A nil subnet accepts every client.
+//
+// Parameters:
+//   - subnet: The trusted subnet.
+//
+// Returns:
+//   - Option: The option restricting the clients.
+func WithTrustedSubnet(subnet *net.IPNet) Option {
+	return func(s *EchoServer) {
+		s.trustedNet = subnet
+	}
+}
+
+// WithStream configures the live stream hub serving GET /stream.
+// An unknown policy falls back to dropping the oldest buffered update.
+//
+// Parameters:
+//   - bufferSize: The number of updates buffered per subscriber.
+//   - policy: The slow subscriber policy: "drop-oldest", "drop-newest" or "disconnect".
+//
+// Returns:
+//   - Option: The option configuring the hub.
+func WithStream(bufferSize int, policy string) Option {
+	return func(s *EchoServer) {
+		dropPolicy, err := stream.ParseDropPolicy(policy)
+		if err != nil {
+			s.logger.Warnf("Using default stream drop policy: %v", err)
+		}
+		s.hub = stream.NewHub(bufferSize, dropPolicy)
+	}
+}
+
+// WithMaxClockSkew rejects metric updates from agents whose clock differs from the server clock by more
+// than maxSkew with 400 Bad Request. Skew is measured from the X-Agent-Time header and is always
+// reported as a self-metric; a non-positive value only reports it.
+//
+// Parameters:
+//   - maxSkew: The largest accepted absolute clock skew.
+//
+// Returns:
+//   - Option: The option applying the bound.
+func WithMaxClockSkew(maxSkew time.Duration) Option {
+	return func(s *EchoServer) {
+		s.skew = clockskew.NewTracker(maxSkew)
+	}
+}
+
+// WithWriteBuffer buffers metric updates in memory and writes them to the repository every interval
+// or once maxPending metrics are buffered, coalescing repeated updates of the same metric.
+// A non-positive interval disables buffering.
+//
+// Parameters:
+//   - interval: The period between flushes.
+//   - maxPending: The number of buffered metrics forcing an immediate flush.
+//
+// Returns:
+//   - Option: The option enabling the buffer.
+func WithWriteBuffer(interval time.Duration, maxPending int) Option {
+	return func(s *EchoServer) {
+		s.serviceOpts = append(s.serviceOpts, controller.WithWriteBuffer(interval, maxPending))
+	}
+}
+
+// WithRetention deletes metrics once their time-to-live since the last update has passed, checking every interval.
+// Update and Pushgateway requests may also set the expiry of their metrics with the X-Expires-At header,
+// given as an RFC 3339 time or Unix seconds, which overrides the policy until the metrics are updated without it.
+// A non-positive interval disables expiry.
+//
+// Parameters:
+//   - policy: The time-to-live of metrics by type and name; nil expires only metrics sent with X-Expires-At.
+//   - interval: The period between deletions of expired metrics.
+//
+// Returns:
+//   - Option: The option enabling expiry.
+func WithRetention(policy *retention.Policy, interval time.Duration) Option {
+	return func(s *EchoServer) {
+		if interval <= 0 {
+			return
+		}
+		s.expiry = true
+		s.serviceOpts = append(s.serviceOpts, controller.WithRetention(retention.NewTracker(policy), interval))
+	}
+}
+
+// WithAdminCredentials protects the /admin and /debug routes with a bearer token and/or basic auth credentials.
+// The credentials are independent of the signing key agents use for metric updates.
+// If neither a token nor a user is set, the routes stay unprotected.
+//
+// Parameters:
+//   - token: The accepted bearer token; empty disables token access.
+//   - user: The accepted basic auth user; empty disables basic auth.
+//   - password: The accepted basic auth password.
+//
+// Returns:
+//   - Option: The option setting the credentials.
+func WithAdminCredentials(token, user, password string) Option {
+	return func(s *EchoServer) {
+		s.adminCreds = custMiddleware.AdminCredentials{Token: token, User: user, Password: password}
+	}
+}
+
+// WithRequestRecording enables recording of requests sent with the X-Debug-Record header.
+// The most recent records are kept in memory and served by GET /debug/requests.
+//
+// Parameters:
+//   - capacity: The number of recorded requests kept.
+//
+// Returns:
+//   - Option: The option enabling the recording.
+func WithRequestRecording(capacity int) Option {
+	return func(s *EchoServer) {
+		s.recordings = reqrecord.NewBuffer(capacity)
+	}
+}
+
+// WithAuditRequests describes every request for the audit log, so the mutations recorded by an audited
+// repository name the agent, API key, user, client IP and request ID they were made by.
+//
+// Returns:
+//   - Option: The option enabling the request descriptions.
+func WithAuditRequests() Option {
+	return func(s *EchoServer) {
+		s.auditRequests = true
+	}
+}
+
+// WithBuildInfo sets the build info served by GET /api/version and exposed as the metricol_build_info
+// info metric. Without it, every build field is reported as unknown.
+//
+// Parameters:
+//   - info: The build info of the server.
+//
+// Returns:
+//   - Option: The option setting the build info.
+func WithBuildInfo(info buildinfo.Info) Option {
+	return func(s *EchoServer) {
+		s.buildInfo = info
+	}
+}
+
+// WithConfigAudit serves the settings and their sources from /api/config, protected like the /admin routes.
+//
+// Parameters:
+//   - entries: The settings reported by the configuration audit, with secrets redacted.
+//
+// Returns:
+//   - Option: An option enabling the endpoint.
+func WithConfigAudit(entries []configaudit.Entry) Option {
+	return func(s *EchoServer) {
+		s.configAudit = entries
+	}
+}
+
+// WithMinAgentVersion rejects metric updates from agents older than the given version with 426 Upgrade Required,
+// so a protocol change can be rolled out without old agents writing incompatible data.
+// Agents that send no version header are not checked. An empty version disables the check.
+//
+// Parameters:
+//   - version: The oldest supported agent version.
+//
+// Returns:
+//   - Option: The option setting the minimum version.
+func WithMinAgentVersion(version string) Option {
+	return func(s *EchoServer) {
+		s.minAgentVersion = version
+	}
+}
+
+// WithFederation names this server and lists the remote peers whose metrics /api/metrics aggregates
+// with the local ones, each labeled with the name of its source.
+//
+// Parameters:
+//   - name: The source name of the local metrics; empty keeps the default.
+//   - peers: The base URLs of the peers keyed by name; empty disables federation.
+//
+// Returns:
+//   - Option: The option enabling federation.
+func WithFederation(name string, peers map[string]string) Option {
+	return func(s *EchoServer) {
+		if name != "" {
+			s.sourceName = name
+		}
+		if len(peers) > 0 {
+			s.peers = federation.New(peers, federation.DefaultTimeout)
+		}
+	}
+}
+
+// WithProvisioning serves the provisioned dashboards and alert rules under /api and the provisioning file under
+// /admin/provisioning, where it can be reloaded. The allowed metrics of the file, if set, replace the patterns
+// of WithMetricNameFilter; a reloaded file without them restores those patterns.
+//
+// Parameters:
+//   - p: The provisioner holding the loaded file; nil disables provisioning.
+//
+// Returns:
+//   - Option: The option enabling provisioning.
+func WithProvisioning(p *provisioning.Provisioner) Option {
+	return func(s *EchoServer) {
+		s.provisioner = p
+	}
+}
+
+// WithBackup configures how /admin/snapshot seals the backups it exports and which backups /admin/import
+// accepts. Without it backups are neither signed nor encrypted.
+//
+// Parameters:
+//   - codec: The codec sealing and opening backups.
+//
+// Returns:
+//   - Option: The option configuring backups.
+func WithBackup(codec *backup.Codec) Option {
+	return func(s *EchoServer) {
+		s.backup = codec
+	}
+}
+
+// WithBackupDir keeps every backup exported by /admin/snapshot in the directory and serves /api/diff,
+// which compares two kept backups, or a kept backup with the stored metrics.
+//
+// Parameters:
+//   - dir: The directory keeping the backups; nil disables both.
+//
+// Returns:
+//   - Option: The option enabling the backup directory.
+func WithBackupDir(dir *backup.Dir) Option {
+	return func(s *EchoServer) {
+		s.backupDir = dir
+	}
+}
+
+// WithHistory keeps counter samples for the retention and serves their per-second rates under /api/rate/:name.
+//
+// Parameters:
+//   - retention: How long counter samples are kept; zero or less disables the history.
+//
+// Returns:
+//   - Option: The option enabling the history.
+func WithHistory(retention time.Duration) Option {
+	return func(s *EchoServer) {
+		if retention <= 0 {
+			return
+		}
+		s.history = history.NewStore(retention)
+		s.serviceOpts = append(s.serviceOpts, controller.WithHistory(s.history))
+	}
+}
+
+// WithQuotas enforces soft quotas per API key, sent by clients in the X-API-Key header, on the update and
+// Pushgateway routes. Requests exceeding the batch size quota are rejected with 413 Request Entity Too Large
+// and requests exceeding the metrics or updates quota with 429 Too Many Requests. The usage of every key
+// is reported under /admin/usage.
+//
+// Parameters:
+//   - limits: The quotas by API key, with quota.AnyKey for other requests; empty disables quotas.
+//
+// Returns:
+//   - Option: The option enabling quotas.
+func WithQuotas(limits map[string]quota.Limits) Option {
+	return func(s *EchoServer) {
+		if len(limits) == 0 {
+			return
+		}
+		s.quotas = quota.NewTracker(limits)
+		s.serviceOpts = append(s.serviceOpts, controller.WithQuotas(s.quotas))
+	}
+}
+
+// WithAdminAddress serves the /admin and /debug routes on a second listener, e.g. bound to localhost only,
+// instead of the public address, so management endpoints are not exposed with the metrics API.
+// The trusted subnet applies to the admin listener too, and without admin credentials the listener is only
+// started on a loopback address.
+//
+// Parameters:
+//   - addr: The address of the admin listener; empty serves the routes on the public address.
+//
+// Returns:
+//   - Option: The option applying the admin address.
+func WithAdminAddress(addr string) Option {
+	return func(s *EchoServer) {
+		if addr == "" || addr == s.addr {
+			return
+		}
+		s.adminAddr = addr
+		s.adminEcho = echo.New()
+		s.adminEcho.HideBanner = true
+		s.adminEcho.HidePort = true
+	}
+}
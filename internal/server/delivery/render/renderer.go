@@ -6,14 +6,19 @@ import (
 	"fmt"
 	"html/template"
 	"io"
+	"sync"
 
 	"github.com/labstack/echo/v4"
 )
 
 // Renderer is responsible for rendering HTML templates.
 // It holds a pointer to a set of parsed HTML templates that are used to generate the final output.
+// Templates can also be parsed on first use, see NewLazyRenderer.
 type Renderer struct {
-	templates *template.Template // templates holds the parsed HTML templates.
+	templates *template.Template                 // templates holds the parsed HTML templates.
+	load      func() (*template.Template, error) // load parses the templates on first use, if set.
+	loadErr   error                              // loadErr holds the error of the first load.
+	once      sync.Once                          // once ensures the templates are loaded only once.
 }
 
 // NewRenderer creates and returns a new Renderer instance.
@@ -27,6 +32,19 @@ func NewRenderer(templates *template.Template) *Renderer {
 	return &Renderer{templates: templates}
 }
 
+// NewLazyRenderer creates a Renderer that parses its templates on the first render rather than at startup,
+// so servers that never serve HTML pages do not pay for parsing them. A failed load is cached and
+// returned by every render.
+//
+// Parameters:
+//   - load: The function parsing the templates.
+//
+// Returns:
+//   - *Renderer: A new instance of Renderer loading its templates on first use.
+func NewLazyRenderer(load func() (*template.Template, error)) *Renderer {
+	return &Renderer{load: load}
+}
+
 // Render renders a template with the given name and data, writing the output to the provided writer.
 // This method implements the echo.Renderer interface, allowing it to be used as a custom renderer in Echo.
 //
@@ -39,6 +57,14 @@ func NewRenderer(templates *template.Template) *Renderer {
 // Returns:
 //   - error: An error if rendering fails; otherwise, nil.
 func (t *Renderer) Render(w io.Writer, name string, data interface{}, _ echo.Context) error {
+	if t.load != nil {
+		t.once.Do(func() {
+			t.templates, t.loadErr = t.load()
+		})
+		if t.loadErr != nil {
+			return fmt.Errorf("failed to load templates: %w", t.loadErr)
+		}
+	}
 	if err := t.templates.ExecuteTemplate(w, name, data); err != nil {
 		return fmt.Errorf("template rendering failed for template '%s' with data '%v': %w", name, data, err)
 	}
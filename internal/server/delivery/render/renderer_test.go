@@ -2,10 +2,12 @@ package render
 
 import (
 	"bytes"
+	"errors"
 	"html/template"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRenderer_Render(t *testing.T) {
@@ -50,3 +52,34 @@ func TestRenderer_Render(t *testing.T) {
 		})
 	}
 }
+
+func TestLazyRenderer_Render(t *testing.T) {
+	loads := 0
+	renderer := NewLazyRenderer(func() (*template.Template, error) {
+		loads++
+		return template.New("test").Parse(`{{.Title}}`) //nolint:wrapcheck // Test helper.
+	})
+	assert.Equal(t, 0, loads)
+
+	for range 2 {
+		var buf bytes.Buffer
+		require.NoError(t, renderer.Render(&buf, "test", map[string]string{"Title": "Hello"}, nil))
+		assert.Equal(t, "Hello", buf.String())
+	}
+	assert.Equal(t, 1, loads)
+}
+
+func TestLazyRenderer_LoadError(t *testing.T) {
+	loadErr := errors.New("no templates")
+	loads := 0
+	renderer := NewLazyRenderer(func() (*template.Template, error) {
+		loads++
+		return nil, loadErr
+	})
+
+	for range 2 {
+		var buf bytes.Buffer
+		require.ErrorIs(t, renderer.Render(&buf, "test", nil, nil), loadErr)
+	}
+	assert.Equal(t, 1, loads)
+}
@@ -0,0 +1,133 @@
+// Package clockskew tracks the difference between agent clocks and the server clock.
+// The last observed skew of every agent is exposed as a self-metric, so operators can spot agents
+// whose timestamps would corrupt the metric history.
+package clockskew
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/selfmetric"
+)
+
+const (
+	// Const selfMetricSkewPrefix prefixes the per-agent clock skew self-metric, measured in seconds.
+	selfMetricSkewPrefix = "metricol_clock_skew_seconds_"
+	// Const selfMetricRejected counts updates rejected because of clock skew.
+	selfMetricRejected = "metricol_clock_skew_rejected"
+	// Const defaultMaxAgents bounds the number of agents tracked, so arbitrary agent identifiers
+	// cannot grow the self-metric set without limit.
+	defaultMaxAgents = 1024
+)
+
+// Tracker records the last observed clock skew of every agent.
+type Tracker struct {
+	skews     map[string]time.Duration // skews maps an agent identifier to its last observed skew.
+	registry  *selfmetric.Registry     // registry receives the per-agent gauges; nil until registered.
+	mu        *sync.Mutex              // mu protects skews and registry.
+	maxSkew   time.Duration            // maxSkew is the largest accepted skew; zero disables rejection.
+	maxAgents int                      // maxAgents limits the number of tracked agents.
+	rejected  int64                    // rejected counts updates rejected because of clock skew.
+}
+
+// NewTracker creates a Tracker.
+//
+// Parameters:
+//   - maxSkew: The largest accepted absolute skew; a non-positive value only reports skew and never rejects.
+//
+// Returns:
+//   - *Tracker: A pointer to the created Tracker.
+func NewTracker(maxSkew time.Duration) *Tracker {
+	if maxSkew < 0 {
+		maxSkew = 0
+	}
+	return &Tracker{
+		skews:     make(map[string]time.Duration),
+		mu:        &sync.Mutex{},
+		maxSkew:   maxSkew,
+		maxAgents: defaultMaxAgents,
+	}
+}
+
+// Observe records the skew of an agent, computed as the agent time minus the server time.
+// A positive skew means the agent clock runs ahead of the server.
+//
+// Parameters:
+//   - agent: The agent identifier.
+//   - agentTime: The time reported by the agent when it sent the request.
+//   - serverTime: The time the server received the request.
+//
+// Returns:
+//   - time.Duration: The observed skew.
+//   - bool: False if the skew exceeds the configured bound and the update should be rejected.
+func (t *Tracker) Observe(agent string, agentTime, serverTime time.Time) (time.Duration, bool) {
+	skew := agentTime.Sub(serverTime)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, known := t.skews[agent]; known || len(t.skews) < t.maxAgents {
+		if !known && t.registry != nil {
+			t.registerAgent(agent)
+		}
+		t.skews[agent] = skew
+	}
+
+	if t.maxSkew > 0 && (skew > t.maxSkew || skew < -t.maxSkew) {
+		t.rejected++
+		return skew, false
+	}
+	return skew, true
+}
+
+// Skew returns the last observed skew of an agent.
+//
+// Parameters:
+//   - agent: The agent identifier.
+//
+// Returns:
+//   - time.Duration: The last observed skew.
+//   - bool: False if the agent has not been observed.
+func (t *Tracker) Skew(agent string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	skew, ok := t.skews[agent]
+	return skew, ok
+}
+
+// MaxSkew returns the largest accepted absolute skew; zero means skew is only reported.
+//
+// Returns:
+//   - time.Duration: The configured bound.
+func (t *Tracker) MaxSkew() time.Duration {
+	return t.maxSkew
+}
+
+// RegisterSelfMetrics exposes the per-agent skew and the rejection count through the self-metric registry.
+// Agents observed later are registered as they appear.
+//
+// Parameters:
+//   - r: The registry to register the metrics in.
+func (t *Tracker) RegisterSelfMetrics(r *selfmetric.Registry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.registry = r
+	r.RegisterCounter(selfMetricRejected, func() int64 {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return t.rejected
+	})
+	for agent := range t.skews {
+		t.registerAgent(agent)
+	}
+}
+
+// registerAgent registers the skew gauge of an agent. The caller must hold mu.
+func (t *Tracker) registerAgent(agent string) {
+	t.registry.RegisterGauge(selfMetricSkewPrefix+agent, func() float64 {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return t.skews[agent].Seconds()
+	})
+}
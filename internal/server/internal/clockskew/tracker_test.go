@@ -0,0 +1,82 @@
+package clockskew
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/selfmetric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker_Observe(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		agentTime    time.Time
+		maxSkew      time.Duration
+		expectedSkew time.Duration
+		expectedOK   bool
+	}{
+		{name: "Report only", agentTime: now.Add(time.Hour), expectedSkew: time.Hour, expectedOK: true},
+		{
+			name:         "Ahead within bound",
+			agentTime:    now.Add(time.Second),
+			maxSkew:      time.Minute,
+			expectedSkew: time.Second,
+			expectedOK:   true,
+		},
+		{name: "Ahead beyond bound", agentTime: now.Add(time.Hour), maxSkew: time.Minute, expectedSkew: time.Hour},
+		{name: "Behind beyond bound", agentTime: now.Add(-time.Hour), maxSkew: time.Minute, expectedSkew: -time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewTracker(tt.maxSkew)
+			skew, ok := tracker.Observe("agent", tt.agentTime, now)
+			assert.Equal(t, tt.expectedSkew, skew)
+			assert.Equal(t, tt.expectedOK, ok)
+
+			stored, found := tracker.Skew("agent")
+			require.True(t, found)
+			assert.Equal(t, tt.expectedSkew, stored)
+		})
+	}
+}
+
+func TestTracker_RegisterSelfMetrics(t *testing.T) {
+	now := time.Now()
+	tracker := NewTracker(time.Minute)
+	tracker.Observe("early", now.Add(2*time.Second), now)
+
+	registry := selfmetric.NewRegistry()
+	tracker.RegisterSelfMetrics(registry)
+	tracker.Observe("late", now.Add(-time.Hour), now)
+
+	early, ok := registry.Find("gauge", selfMetricSkewPrefix+"early")
+	require.True(t, ok)
+	assert.InDelta(t, 2.0, early.Value, 1e-9)
+
+	late, ok := registry.Find("gauge", selfMetricSkewPrefix+"late")
+	require.True(t, ok)
+	assert.InDelta(t, -3600.0, late.Value, 1e-9)
+
+	rejected, ok := registry.Find("counter", selfMetricRejected)
+	require.True(t, ok)
+	assert.Equal(t, int64(1), rejected.Value)
+}
+
+func TestTracker_MaxAgents(t *testing.T) {
+	now := time.Now()
+	tracker := NewTracker(0)
+	tracker.maxAgents = 1
+
+	tracker.Observe("first", now, now)
+	tracker.Observe("second", now, now)
+
+	_, ok := tracker.Skew("first")
+	assert.True(t, ok)
+	_, ok = tracker.Skew("second")
+	assert.False(t, ok)
+}
@@ -0,0 +1,230 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+)
+
+const (
+	// Const selfMetricSeries is the self-metric exposing the total number of distinct metrics.
+	selfMetricSeries = "metricol_series"
+	// Const selfMetricSeriesPrefix prefixes self-metrics exposing the number of distinct metrics per name prefix.
+	selfMetricSeriesPrefix = "metricol_series_prefix_"
+)
+
+// ErrCardinalityLimit is returned when a new metric would exceed a cardinality limit.
+var ErrCardinalityLimit = errors.New("metric cardinality limit exceeded")
+
+// CardinalityError describes which cardinality limit rejected a metric.
+type CardinalityError struct {
+	Metric string // Metric is the rejected metric in "type/name" form.
+	Prefix string // Prefix is the name prefix whose limit was hit; empty for the total limit.
+	Limit  int    // Limit is the limit that was hit.
+}
+
+// Error implements the error interface.
+func (e *CardinalityError) Error() string {
+	if e.Prefix == "" {
+		return fmt.Sprintf("%s: at most %d distinct metrics allowed, new metric %s rejected",
+			ErrCardinalityLimit, e.Limit, e.Metric)
+	}
+	return fmt.Sprintf("%s: at most %d distinct metrics with prefix %q allowed, new metric %s rejected",
+		ErrCardinalityLimit, e.Limit, e.Prefix, e.Metric)
+}
+
+// Unwrap allows errors.Is to match ErrCardinalityLimit.
+func (e *CardinalityError) Unwrap() error {
+	return ErrCardinalityLimit
+}
+
+// cardinalityGuard tracks distinct metrics and rejects new ones beyond the configured limits.
+// The set of known metrics is loaded from the repository on first use.
+type cardinalityGuard struct {
+	known        map[string]struct{} // known holds keys of all stored metrics.
+	prefixCounts map[string]int      // prefixCounts holds the number of known metrics per limited prefix.
+	prefixLimits map[string]int      // prefixLimits maps a name prefix to its limit.
+	mu           *sync.Mutex         // mu protects the guard state.
+	maxSeries    int                 // maxSeries is the total limit; non-positive means unlimited.
+	loaded       bool                // loaded reports whether known was seeded from the repository.
+}
+
+// newCardinalityGuard creates a guard with the given limits.
+func newCardinalityGuard(maxSeries int, prefixLimits map[string]int) *cardinalityGuard {
+	return &cardinalityGuard{
+		known:        make(map[string]struct{}),
+		prefixCounts: make(map[string]int),
+		prefixLimits: prefixLimits,
+		mu:           &sync.Mutex{},
+		maxSeries:    maxSeries,
+	}
+}
+
+// admit registers the new metrics of the batch, or rejects the whole batch if any limit would be exceeded.
+// It returns the keys that were registered so they can be released if storing the batch fails.
+func (g *cardinalityGuard) admit(
+	ctx context.Context,
+	repo repository.Repository,
+	batch entity.Metrics,
+) ([]*entity.Metric, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	added, err := g.evaluate(ctx, repo, batch)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range added {
+		g.add(m.Type, m.Name)
+	}
+	return added, nil
+}
+
+// check reports whether the batch would be admitted, without registering its metrics.
+// It returns the metrics that would be new.
+func (g *cardinalityGuard) check(
+	ctx context.Context,
+	repo repository.Repository,
+	batch entity.Metrics,
+) ([]*entity.Metric, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.evaluate(ctx, repo, batch)
+}
+
+// evaluate returns the new metrics of the batch, or an error if any limit would be exceeded.
+// The caller must hold mu.
+func (g *cardinalityGuard) evaluate(
+	ctx context.Context,
+	repo repository.Repository,
+	batch entity.Metrics,
+) ([]*entity.Metric, error) {
+	if err := g.load(ctx, repo); err != nil {
+		return nil, err
+	}
+
+	added := make([]*entity.Metric, 0)
+	total := len(g.known)
+	prefixCounts := make(map[string]int, len(g.prefixCounts))
+	for p, c := range g.prefixCounts {
+		prefixCounts[p] = c
+	}
+
+	for _, m := range batch {
+		if _, ok := g.known[cardinalityKey(m.Type, m.Name)]; ok {
+			continue
+		}
+
+		total++
+		if g.maxSeries > 0 && total > g.maxSeries {
+			return nil, &CardinalityError{Metric: m.Type + "/" + m.Name, Limit: g.maxSeries}
+		}
+		for prefix, limit := range g.prefixLimits {
+			if !strings.HasPrefix(m.Name, prefix) {
+				continue
+			}
+			prefixCounts[prefix]++
+			if prefixCounts[prefix] > limit {
+				return nil, &CardinalityError{Metric: m.Type + "/" + m.Name, Prefix: prefix, Limit: limit}
+			}
+		}
+		added = append(added, m)
+	}
+	return added, nil
+}
+
+// release forgets the given metrics.
+func (g *cardinalityGuard) release(metrics []*entity.Metric) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, m := range metrics {
+		g.remove(m.Type, m.Name)
+	}
+}
+
+// forget removes a deleted metric from the guard.
+func (g *cardinalityGuard) forget(metricType, name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.remove(metricType, name)
+}
+
+// remember adds a restored metric to the guard without checking limits.
+func (g *cardinalityGuard) remember(metricType, name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.loaded {
+		g.add(metricType, name)
+	}
+}
+
+// total returns the number of known metrics.
+func (g *cardinalityGuard) total() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return float64(len(g.known))
+}
+
+// prefixTotal returns the number of known metrics with the given prefix.
+func (g *cardinalityGuard) prefixTotal(prefix string) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return float64(g.prefixCounts[prefix])
+}
+
+// load seeds the known metrics from the repository once. The caller must hold mu.
+func (g *cardinalityGuard) load(ctx context.Context, repo repository.Repository) error {
+	if g.loaded {
+		return nil
+	}
+
+	all, err := repo.All(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load metrics for cardinality tracking: %w", err)
+	}
+	if all != nil {
+		for _, m := range *all {
+			g.add(m.Type, m.Name)
+		}
+	}
+	g.loaded = true
+	return nil
+}
+
+// add records a metric. The caller must hold mu.
+func (g *cardinalityGuard) add(metricType, name string) {
+	k := cardinalityKey(metricType, name)
+	if _, ok := g.known[k]; ok {
+		return
+	}
+	g.known[k] = struct{}{}
+	for prefix := range g.prefixLimits {
+		if strings.HasPrefix(name, prefix) {
+			g.prefixCounts[prefix]++
+		}
+	}
+}
+
+// remove forgets a metric. The caller must hold mu.
+func (g *cardinalityGuard) remove(metricType, name string) {
+	k := cardinalityKey(metricType, name)
+	if _, ok := g.known[k]; !ok {
+		return
+	}
+	delete(g.known, k)
+	for prefix := range g.prefixLimits {
+		if strings.HasPrefix(name, prefix) {
+			g.prefixCounts[prefix]--
+		}
+	}
+}
+
+// cardinalityKey builds the key identifying a distinct metric.
+func cardinalityKey(metricType, name string) string {
+	return metricType + "|" + name
+}
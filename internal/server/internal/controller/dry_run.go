@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+)
+
+// ErrInvalidMetric is returned by PushMetrics and ValidateMetrics when a metric is malformed.
+var ErrInvalidMetric = errors.New("invalid metric")
+
+// ValidationResult describes how PushMetrics would handle a batch of metrics.
+type ValidationResult struct {
+	Dropped  []string // Dropped lists metrics, in "type/name" form, the name filter would silently drop.
+	Stale    []string // Stale lists metrics, in "type/name" form, older than the stored ones.
+	New      []string // New lists metrics, in "type/name" form, that do not exist yet.
+	Accepted int      // Accepted is the number of distinct metrics that would be stored.
+}
+
+// ValidateMetrics runs the checks of PushMetrics against a batch without storing it or changing any state:
+// metric validation, the name filter and the cardinality limits. Rate limits are not consumed.
+//
+// Parameters:
+//   - ctx: The context for the operation, supporting cancellation and timeouts.
+//   - metrics: A pointer to the batch to validate. It must not be nil and is not modified.
+//
+// Returns:
+//   - *ValidationResult: How the batch would be handled.
+//   - error: ErrInvalidMetric, ErrMetricNotAllowed or a CardinalityError if PushMetrics would reject the batch,
+//     or an error if reading the repository fails.
+func (s *MetricService) ValidateMetrics(ctx context.Context, metrics *entity.Metrics) (*ValidationResult, error) {
+	if metrics == nil {
+		return nil, errors.New("metrics batch is nil")
+	}
+
+	validateCtx, cancel := context.WithTimeout(ctx, pushTimeout)
+	defer cancel()
+
+	result := &ValidationResult{Dropped: make([]string, 0), Stale: make([]string, 0), New: make([]string, 0)}
+	receivedAt := time.Now()
+	batch := make(entity.Metrics, 0, metrics.Length())
+	for _, original := range *metrics {
+		if err := s.validate(original); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidMetric, err)
+		}
+		m := *original
+		key := m.Type + "/" + m.Name
+
+		if s.names != nil && !s.names.accepts(m.Name) {
+			if s.names.reject {
+				return nil, fmt.Errorf("metrics batch rejected: %w: type=%s, name=%s", ErrMetricNotAllowed, m.Type, m.Name)
+			}
+			result.Dropped = append(result.Dropped, key)
+			continue
+		}
+
+		if m.Timestamp.IsZero() {
+			m.Timestamp = receivedAt
+		} else if !entity.IsCounter(m.Type) {
+			stale, err := s.isOutOfOrder(validateCtx, &m)
+			if err != nil {
+				return nil, fmt.Errorf("failed check order of %s: %w", m.Name, err)
+			}
+			if stale {
+				result.Stale = append(result.Stale, key)
+				continue
+			}
+		}
+		batch = append(batch, &m)
+	}
+	batch.MergeDuplicates()
+	result.Accepted = len(batch)
+
+	added, err := s.newMetrics(validateCtx, batch)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range added {
+		result.New = append(result.New, m.Type+"/"+m.Name)
+	}
+	return result, nil
+}
+
+// newMetrics returns the metrics of the batch that are not stored yet. With cardinality limits configured,
+// it fails if storing the batch would exceed them.
+func (s *MetricService) newMetrics(ctx context.Context, batch entity.Metrics) ([]*entity.Metric, error) {
+	if s.cardinality != nil {
+		added, err := s.cardinality.check(ctx, s.repo, batch)
+		if err != nil {
+			return nil, fmt.Errorf("metrics batch rejected: %w", err)
+		}
+		return added, nil
+	}
+
+	added := make([]*entity.Metric, 0)
+	for _, m := range batch {
+		_, err := s.repo.Find(ctx, m.Type, m.Name)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, repository.ErrNotFoundInRepo) {
+			return nil, fmt.Errorf("retrieval failed for '%s': %w", m.Name, err)
+		}
+		added = append(added, m)
+	}
+	return added, nil
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateMetrics(t *testing.T) {
+	stored := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	repo := new(MockRepository)
+	repo.On("Find", mock.Anything, entity.MetricTypeGauge, "load").
+		Return(&entity.Metric{Name: "load", Type: entity.MetricTypeGauge, Value: 1.0, Timestamp: stored}, nil)
+	repo.On("Find", mock.Anything, mock.Anything, mock.Anything).Return(nil, repository.ErrNotFoundInRepo)
+	service := NewMetricService(repo, WithNameFilter(nil, []string{"debug_*"}, false))
+
+	batch := entity.Metrics{
+		{Name: "load", Type: entity.MetricTypeGauge, Value: 0.5, Timestamp: stored.Add(-time.Minute)},
+		{Name: "hits", Type: entity.MetricTypeCounter, Value: int64(1)},
+		{Name: "hits", Type: entity.MetricTypeCounter, Value: int64(2)},
+		{Name: "debug_x", Type: entity.MetricTypeGauge, Value: 1.0},
+	}
+
+	result, err := service.ValidateMetrics(context.Background(), &batch)
+	require.NoError(t, err)
+	assert.Equal(t, &ValidationResult{
+		Dropped:  []string{"gauge/debug_x"},
+		Stale:    []string{"gauge/load"},
+		New:      []string{"counter/hits"},
+		Accepted: 1,
+	}, result)
+
+	assert.True(t, batch[1].Timestamp.IsZero(), "the batch must not be modified")
+	assert.Equal(t, int64(0), service.names.dropped.Load(), "dry runs must not count dropped metrics")
+	repo.AssertNotCalled(t, "UpdateBatch", mock.Anything, mock.Anything)
+}
+
+func TestValidateMetricsErrors(t *testing.T) {
+	tests := []struct {
+		setup       func(*MockRepository)
+		expectedErr error
+		batch       entity.Metrics
+		opts        []Option
+		name        string
+	}{
+		{
+			name:        "invalid metric",
+			batch:       entity.Metrics{{Name: "load", Type: entity.MetricTypeGauge}},
+			expectedErr: ErrInvalidMetric,
+		},
+		{
+			name:        "name rejected",
+			batch:       entity.Metrics{{Name: "debug_x", Type: entity.MetricTypeGauge, Value: 1.0}},
+			opts:        []Option{WithNameFilter(nil, []string{"debug_*"}, true)},
+			expectedErr: ErrMetricNotAllowed,
+		},
+		{
+			name:  "cardinality limit",
+			batch: entity.Metrics{{Name: "load", Type: entity.MetricTypeGauge, Value: 1.0}},
+			opts:  []Option{WithCardinalityLimits(1, nil)},
+			setup: func(repo *MockRepository) {
+				repo.On("All", mock.Anything).Return(&entity.Metrics{{Name: "other", Type: entity.MetricTypeGauge}}, nil)
+			},
+			expectedErr: ErrCardinalityLimit,
+		},
+		{
+			name:  "repository failure",
+			batch: entity.Metrics{{Name: "load", Type: entity.MetricTypeGauge, Value: 1.0}},
+			setup: func(repo *MockRepository) {
+				repo.On("Find", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("db down"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockRepository)
+			if tt.setup != nil {
+				tt.setup(repo)
+			}
+			service := NewMetricService(repo, tt.opts...)
+
+			_, err := service.ValidateMetrics(context.Background(), &tt.batch)
+			require.Error(t, err)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			}
+		})
+	}
+}
@@ -7,9 +7,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/internal/history"
+	"github.com/gdyunin/metricol.git/internal/server/internal/quota"
+	"github.com/gdyunin/metricol.git/internal/server/internal/retention"
+	"github.com/gdyunin/metricol.git/internal/server/internal/selfmetric"
+	"github.com/gdyunin/metricol.git/internal/server/internal/stream"
 	"github.com/gdyunin/metricol.git/internal/server/repository"
 	"github.com/gdyunin/metricol.git/pkg/convert"
 )
@@ -18,25 +26,100 @@ const (
 	pushTimeout    = 3 * time.Second
 	pullTimeout    = 3 * time.Second
 	pullAllTimeout = 3 * time.Second
+	deleteTimeout  = 3 * time.Second
+
+	// selfMetricOutOfOrder counts samples dropped because they were older than the stored ones.
+	selfMetricOutOfOrder = "metricol_out_of_order_dropped"
+	// selfMetricBuildInfo describes the server build.
+	selfMetricBuildInfo = "metricol_build_info"
+)
+
+var (
+	// ErrNotFoundInRepository is returned when a requested metric does not exist.
+	ErrNotFoundInRepository = errors.New("not found in repository")
+	// ErrRateLimited is returned when a metric is updated more often than allowed.
+	ErrRateLimited = errors.New("metric update rate limit exceeded")
+	// ErrOutOfOrder is returned when a sample is older than the value already stored.
+	ErrOutOfOrder = errors.New("metric sample is older than the stored one")
 )
 
-var ErrNotFoundInRepository = errors.New("not found in repository")
+// selfMetricSource is implemented by repositories exposing their own state as self-metrics.
+type selfMetricSource interface {
+	RegisterSelfMetrics(r *selfmetric.Registry)
+}
 
 // MetricService provides methods to manage and manipulate metrics.
 // It interacts with a repository to validate, store, update, and retrieve metrics.
 type MetricService struct {
-	repo repository.Repository // repo is the repository for storing and retrieving metrics.
+	repo        repository.Repository // repo is the repository for storing and retrieving metrics.
+	rateLimiter *metricRateLimiter    // rateLimiter caps per-metric update frequency; nil disables it.
+	cardinality *cardinalityGuard     // cardinality caps the number of distinct metrics; nil disables it.
+	names       *nameFilter           // names filters metrics by name; without patterns it accepts all names.
+	smoother    *smoother             // smoother stores smoothed copies of configured gauges; nil disables it.
+	selfMetrics *selfmetric.Registry  // selfMetrics holds metrics describing the server itself.
+	hub         *stream.Hub           // hub receives stored updates for live streaming; nil disables it.
+	history     *history.Store        // history keeps recent counter samples for rates; nil disables it.
+	quotas      *quota.Tracker        // quotas enforces per-key quotas on updates; nil disables them.
+	buffer      *writeBuffer          // buffer coalesces writes in front of repo; nil disables it.
+	sweeper     *sweeper              // sweeper deletes expired metrics; nil disables expiry.
+	outOfOrder  atomic.Int64          // outOfOrder counts samples dropped for being older than stored ones.
 }
 
 // NewMetricService creates and returns a new instance of MetricService.
 //
 // Parameters:
 //   - repo: The repository that handles metric data persistence.
+//   - opts: Optional settings such as update rate limiting.
 //
 // Returns:
 //   - *MetricService: A pointer to the newly created MetricService instance.
-func NewMetricService(repo repository.Repository) *MetricService {
-	return &MetricService{repo: repo}
+func NewMetricService(repo repository.Repository, opts ...Option) *MetricService {
+	s := &MetricService{repo: repo, selfMetrics: selfmetric.NewRegistry(), names: &nameFilter{}}
+	s.selfMetrics.RegisterCounter(selfMetricOutOfOrder, s.outOfOrder.Load)
+	if source, ok := repository.Base(repo).(selfMetricSource); ok {
+		source.RegisterSelfMetrics(s.selfMetrics)
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Start runs the background work of the service, such as flushing the write buffer and deleting expired
+// metrics, until the context is canceled. It returns immediately if there is no background work.
+//
+// Parameters:
+//   - ctx: The context controlling the background work lifecycle.
+func (s *MetricService) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	if s.sweeper != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.runSweeper(ctx)
+		}()
+	}
+	if s.buffer != nil {
+		s.buffer.run(ctx)
+	}
+	wg.Wait()
+}
+
+// Flush writes all buffered updates to the repository. It is a no-op without a write buffer.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//
+// Returns:
+//   - error: An error if the repository update fails.
+func (s *MetricService) Flush(ctx context.Context) error {
+	if s.buffer == nil {
+		return nil
+	}
+	if err := s.buffer.flush(ctx); err != nil {
+		return fmt.Errorf("failed to flush write buffer: %w", err)
+	}
+	return nil
 }
 
 // PushMetric validates the given metric and stores it in the repository.
@@ -48,28 +131,56 @@ func NewMetricService(repo repository.Repository) *MetricService {
 //
 // Returns:
 //   - *entity.Metric: A pointer to the stored metric if the operation is successful.
-//   - error: An error if the metric is invalid or if the repository operation fails.
+//   - error: ErrInvalidMetric if the metric is invalid, ErrOutOfOrder if it is older than the stored one,
+//     ErrMetricNotAllowed if its name is rejected, or an error if the repository operation fails.
+//     A metric silently dropped by the name filter is returned unchanged without being stored.
 func (s *MetricService) PushMetric(ctx context.Context, metric *entity.Metric) (*entity.Metric, error) {
+	if metric != nil {
+		keep, err := s.names.check(metric.Type, metric.Name)
+		if err != nil {
+			return nil, fmt.Errorf("error while update: %w", err)
+		}
+		if !keep {
+			return metric, nil
+		}
+	}
+
 	batch := entity.Metrics{metric}
 	updated, err := s.PushMetrics(ctx, &batch)
 	if err != nil {
 		return nil, fmt.Errorf("error while update: %w", err)
 	}
+	if updated.Length() == 0 {
+		return nil, fmt.Errorf("%w: type=%s, name=%s", ErrOutOfOrder, metric.Type, metric.Name)
+	}
 	return updated.First(), nil
 }
 
 // PushMetrics validates and stores a batch of metrics in the repository.
 // It iterates over each metric, validates it, prepares counter metrics,
 // merges duplicate entries, and then updates the repository with the batch.
+// Metrics refused by the name filter are dropped, or fail the batch with ErrMetricNotAllowed.
+// Metrics without a timestamp are stamped with the time of receipt. Gauge and info samples carrying
+// an explicit timestamp older than the stored one are dropped from the batch; counter deltas are always added.
+// Gauges configured for smoothing are stored together with their smoothed gauges.
 //
 // Parameters:
 //   - ctx: The context for the operation, supporting cancellation and timeouts.
 //   - metrics: A pointer to a collection of metrics to be stored. It must not be nil.
 //
 // Returns:
-//   - *entity.Metrics: A pointer to the updated collection of metrics after storage.
-//   - error: An error if any metric fails validation, counter preparation, or if the repository update fails.
+//   - *entity.Metrics: A pointer to the updated collection of metrics after storage, without dropped samples.
+//   - error: ErrInvalidMetric if any metric fails validation, or an error if counter preparation or
+//     the repository update fails.
 func (s *MetricService) PushMetrics(ctx context.Context, metrics *entity.Metrics) (*entity.Metrics, error) {
+	ctx, span := startSpan(ctx, "PushMetrics", attrBatchSize.Int(metrics.Length()))
+	pushed, err := s.pushMetrics(ctx, metrics)
+	endSpan(span, err)
+	return pushed, err
+}
+
+// pushMetrics implements PushMetrics.
+func (s *MetricService) pushMetrics(ctx context.Context, metrics *entity.Metrics) (*entity.Metrics, error) {
 	if metrics == nil {
 		return nil, errors.New("metrics batch is nil")
 	}
@@ -77,14 +188,48 @@ func (s *MetricService) PushMetrics(ctx context.Context, metrics *entity.Metrics
 	pushCtx, cancel := context.WithTimeout(ctx, pushTimeout)
 	defer cancel()
 
-	preparedMetricsBatch := make(entity.Metrics, 0, metrics.Length())
+	receivedAt := time.Now()
+	received := make(entity.Metrics, 0, metrics.Length())
 	for _, m := range *metrics {
 		if err := s.validate(m); err != nil {
-			return nil, fmt.Errorf("invalid metric: %w", err)
+			return nil, fmt.Errorf("%w: %w", ErrInvalidMetric, err)
+		}
+		keep, err := s.names.check(m.Type, m.Name)
+		if err != nil {
+			return nil, fmt.Errorf("metrics batch rejected: %w", err)
 		}
+		if !keep {
+			continue
+		}
+
+		if m.Timestamp.IsZero() {
+			m.Timestamp = receivedAt
+		}
+		if m.Type == entity.MetricTypeFloatCounter {
+			// Float counters are stored as float64 even if they were first sent as whole numbers.
+			m.Value, _ = convert.AnyToFloat64(m.Value)
+		}
+		received = append(received, m)
+	}
+	// Repeated counters are summed before the stored value is added, so it is added only once.
+	received.MergeDuplicates()
 
-		if m.Type != entity.MetricTypeCounter {
+	preparedMetricsBatch := make(entity.Metrics, 0, len(received))
+	for _, m := range received {
+		if !entity.IsCounter(m.Type) {
+			// Metrics stamped with the time of receipt are never out of order.
+			if !m.Timestamp.Equal(receivedAt) {
+				stale, err := s.isOutOfOrder(pushCtx, m)
+				if err != nil {
+					return nil, fmt.Errorf("failed check order of %s: %w", m.Name, err)
+				}
+				if stale {
+					s.outOfOrder.Add(1)
+					continue
+				}
+			}
 			preparedMetricsBatch = append(preparedMetricsBatch, m)
+			continue
 		}
 
 		preparedMetric, err := s.prepareCounter(pushCtx, m)
@@ -93,16 +238,98 @@ func (s *MetricService) PushMetrics(ctx context.Context, metrics *entity.Metrics
 		}
 		preparedMetricsBatch = append(preparedMetricsBatch, preparedMetric)
 	}
-	preparedMetricsBatch.MergeDuplicates()
+	if len(preparedMetricsBatch) == 0 {
+		return &preparedMetricsBatch, nil
+	}
+
+	stored := preparedMetricsBatch
+	if s.smoother != nil {
+		smoothed, err := s.smoother.smooth(pushCtx, s.repo, preparedMetricsBatch)
+		if err != nil {
+			return nil, fmt.Errorf("failed smooth gauges: %w", err)
+		}
+		stored = append(stored[:len(stored):len(stored)], smoothed...)
+	}
+
+	var admitted []*entity.Metric
+	if s.cardinality != nil {
+		var err error
+		if admitted, err = s.cardinality.admit(pushCtx, s.repo, stored); err != nil {
+			return nil, fmt.Errorf("metrics batch rejected: %w", err)
+		}
+	}
+	// Quotas are used only by batches that pass every other check, and given back if storing fails.
+	var admission *quota.Admission
+	if s.quotas != nil {
+		var err error
+		if admission, err = s.quotas.Admit(quota.KeyFrom(ctx), *metrics); err != nil {
+			s.releaseAdmitted(admitted, nil)
+			return nil, fmt.Errorf("metrics batch rejected: %w", err)
+		}
+	}
+
+	// Rate limits are checked after the cardinality limits, so rejected series get no token bucket.
+	if s.rateLimiter != nil {
+		if m, ok := s.rateLimiter.allowBatch(preparedMetricsBatch); !ok {
+			s.releaseAdmitted(admitted, admission)
+			return nil, fmt.Errorf("%w: type=%s, name=%s", ErrRateLimited, m.Type, m.Name)
+		}
+	}
 
-	if err := s.repo.UpdateBatch(pushCtx, &preparedMetricsBatch); err != nil {
+	if err := s.repo.UpdateBatch(pushCtx, &stored); err != nil {
+		s.releaseAdmitted(admitted, admission)
 		return nil, fmt.Errorf("failed store metrics batch: %w", err)
 	}
+
+	if s.hub != nil {
+		s.hub.Publish(stored)
+	}
+	if s.history != nil {
+		s.history.Record(stored)
+	}
+	if s.sweeper != nil {
+		s.sweeper.tracker.Observe(stored, retention.ExpiresAtFrom(ctx))
+	}
 	return &preparedMetricsBatch, nil
 }
 
-// prepareCounter processes a counter metric by retrieving any existing value from the repository,
-// converting the current and existing values to int64, and summing them.
+// releaseAdmitted gives back the cardinality and quota use of a batch that was rejected or failed to be stored.
+//
+// Parameters:
+//   - admitted: The metrics the cardinality guard admitted for the batch.
+//   - admission: The quota use recorded for the batch, nil if none.
+func (s *MetricService) releaseAdmitted(admitted []*entity.Metric, admission *quota.Admission) {
+	if s.cardinality != nil {
+		s.cardinality.release(admitted)
+	}
+	if s.quotas != nil {
+		s.quotas.Release(admission)
+	}
+}
+
+// isOutOfOrder reports whether a metric is older than the stored metric with the same type and name.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - metric: A pointer to the incoming metric.
+//
+// Returns:
+//   - bool: True if the stored metric has a later timestamp.
+//   - error: An error if retrieval of the stored metric fails.
+func (s *MetricService) isOutOfOrder(ctx context.Context, metric *entity.Metric) (bool, error) {
+	existingMetric, err := s.repo.Find(ctx, metric.Type, metric.Name)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFoundInRepo) {
+			return false, nil
+		}
+		return false, fmt.Errorf("retrieval failed for '%s': %w", metric.Name, err)
+	}
+	return metric.Timestamp.Before(existingMetric.Timestamp), nil
+}
+
+// prepareCounter processes a counter or float counter metric by retrieving any existing value from
+// the repository and adding the new value to it, as int64 for counters and as float64 for float counters.
+// The later of the two timestamps is kept.
 // If the metric does not already exist, the original metric is returned.
 //
 // Parameters:
@@ -121,20 +348,19 @@ func (s *MetricService) prepareCounter(ctx context.Context, metric *entity.Metri
 		return nil, fmt.Errorf("retrieval failed for counter '%s': %w", metric.Name, err)
 	}
 
-	existingValue, err := convert.AnyToInt64(existingMetric.Value)
-	if err != nil {
-		return nil, fmt.Errorf("conversion failed for counter '%s': %w", metric.Name, err)
-	}
-
-	newValue, err := convert.AnyToInt64(metric.Value)
+	value, err := entity.SumCounter(metric.Type, existingMetric.Value, metric.Value)
 	if err != nil {
 		return nil, fmt.Errorf("conversion failed for counter '%s': %w", metric.Name, err)
 	}
 
 	updatedMetric := &entity.Metric{
-		Value: existingValue + newValue,
-		Name:  metric.Name,
-		Type:  entity.MetricTypeCounter,
+		Timestamp: metric.Timestamp,
+		Value:     value,
+		Name:      metric.Name,
+		Type:      metric.Type,
+	}
+	if existingMetric.Timestamp.After(updatedMetric.Timestamp) {
+		updatedMetric.Timestamp = existingMetric.Timestamp
 	}
 	return updatedMetric, nil
 }
@@ -150,12 +376,23 @@ func (s *MetricService) prepareCounter(ctx context.Context, metric *entity.Metri
 //   - *entity.Metric: A pointer to the retrieved metric if found.
 //   - error: An error if the metric is not found or if the repository operation fails.
 func (s *MetricService) Pull(ctx context.Context, metricType, name string) (*entity.Metric, error) {
+	ctx, span := startSpan(ctx, "Pull", attrMetricType.String(metricType), attrMetricName.String(name))
+	metric, err := s.pull(ctx, metricType, name)
+	endSpan(span, err)
+	return metric, err
+}
+
+// pull implements Pull.
+func (s *MetricService) pull(ctx context.Context, metricType, name string) (*entity.Metric, error) {
 	pullCtx, cancel := context.WithTimeout(ctx, pullTimeout)
 	defer cancel()
 
 	metric, err := s.repo.Find(pullCtx, metricType, name)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFoundInRepo) {
+			if self, ok := s.selfMetrics.Find(metricType, name); ok {
+				return self, nil
+			}
 			return nil, fmt.Errorf(
 				"%w: metric with type=%s and name=%s not exist",
 				ErrNotFoundInRepository,
@@ -177,6 +414,14 @@ func (s *MetricService) Pull(ctx context.Context, metricType, name string) (*ent
 //   - *entity.Metrics: A pointer to the collection of all metrics retrieved.
 //   - error: An error if the repository operation fails.
 func (s *MetricService) PullAll(ctx context.Context) (*entity.Metrics, error) {
+	ctx, span := startSpan(ctx, "PullAll")
+	metrics, err := s.pullAll(ctx)
+	endSpan(span, err)
+	return metrics, err
+}
+
+// pullAll implements PullAll.
+func (s *MetricService) pullAll(ctx context.Context) (*entity.Metrics, error) {
 	pullCtx, cancel := context.WithTimeout(ctx, pullAllTimeout)
 	defer cancel()
 
@@ -184,9 +429,241 @@ func (s *MetricService) PullAll(ctx context.Context) (*entity.Metrics, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve all metrics: %w", err)
 	}
+
+	if self := s.selfMetrics.All(); len(self) > 0 {
+		if metrics == nil {
+			metrics = &entity.Metrics{}
+		}
+		*metrics = append(*metrics, self...)
+	}
 	return metrics, nil
 }
 
+// PullPage retrieves one page of the metrics. The self-metrics of the server follow the stored metrics,
+// as in PullAll, so they fill the pages after the last stored metric.
+//
+// Parameters:
+//   - ctx: The context for the operation, supporting cancellation and timeouts.
+//   - query: The offset, the limit and the order of the page.
+//
+// Returns:
+//   - *repository.Page: The metrics of the page and the total number of metrics, self-metrics included.
+//   - error: An error wrapping repository.ErrInvalidPageQuery if the query is invalid, or an error if
+//     the repository operation fails.
+func (s *MetricService) PullPage(ctx context.Context, query repository.PageQuery) (*repository.Page, error) {
+	ctx, span := startSpan(ctx, "PullPage")
+	page, err := s.pullPage(ctx, query)
+	endSpan(span, err)
+	return page, err
+}
+
+// pullPage implements PullPage.
+func (s *MetricService) pullPage(ctx context.Context, query repository.PageQuery) (*repository.Page, error) {
+	pullCtx, cancel := context.WithTimeout(ctx, pullAllTimeout)
+	defer cancel()
+
+	page, err := s.repo.Page(pullCtx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve metrics page: %w", err)
+	}
+
+	self := s.selfMetrics.All()
+	if len(self) == 0 {
+		return page, nil
+	}
+	// The stored metrics end within or before this page, so the page is completed with self-metrics.
+	stored := page.Total
+	page.Total += len(self)
+	if query.Limit > 0 && len(page.Metrics) >= query.Limit {
+		return page, nil
+	}
+	selfQuery := repository.PageQuery{SortBy: query.SortBy, Offset: max(query.Offset-stored, 0)}
+	if query.Limit > 0 {
+		selfQuery.Limit = query.Limit - len(page.Metrics)
+	}
+	page.Metrics = append(page.Metrics, repository.PageOf(self, selfQuery).Metrics...)
+	return page, nil
+}
+
+// Query retrieves the metrics matching a filter, the self-metrics of the server included.
+//
+// Parameters:
+//   - ctx: The context for the operation, supporting cancellation and timeouts.
+//   - filter: The type, the name prefix and the name expression the metrics must match.
+//
+// Returns:
+//   - *entity.Metrics: The matching metrics, sorted by name and then by type.
+//   - error: An error if the repository operation fails.
+func (s *MetricService) Query(ctx context.Context, filter repository.MetricFilter) (*entity.Metrics, error) {
+	ctx, span := startSpan(ctx, "Query")
+	metrics, err := s.query(ctx, filter)
+	endSpan(span, err)
+	return metrics, err
+}
+
+// query implements Query.
+func (s *MetricService) query(ctx context.Context, filter repository.MetricFilter) (*entity.Metrics, error) {
+	pullCtx, cancel := context.WithTimeout(ctx, pullAllTimeout)
+	defer cancel()
+
+	metrics, err := s.repo.Query(pullCtx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metrics: %w", err)
+	}
+	if metrics == nil {
+		metrics = &entity.Metrics{}
+	}
+
+	if self := repository.Filter(s.selfMetrics.All(), filter); len(self) > 0 {
+		matched := repository.Filter(append(*metrics, self...), repository.MetricFilter{})
+		return &matched, nil
+	}
+	return metrics, nil
+}
+
+// Delete soft-deletes a metric by its type and name.
+//
+// Parameters:
+//   - ctx: The context for the operation, supporting cancellation and timeouts.
+//   - metricType: The type of the metric.
+//   - name: The name of the metric to delete.
+//
+// Returns:
+//   - error: ErrNotFoundInRepository if the metric does not exist, or an error if the repository operation fails.
+func (s *MetricService) Delete(ctx context.Context, metricType, name string) error {
+	ctx, span := startSpan(ctx, "Delete", attrMetricType.String(metricType), attrMetricName.String(name))
+	err := s.delete(ctx, metricType, name)
+	endSpan(span, err)
+	return err
+}
+
+// delete implements Delete.
+func (s *MetricService) delete(ctx context.Context, metricType, name string) error {
+	deleteCtx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	if err := s.repo.Delete(deleteCtx, metricType, name); err != nil {
+		if errors.Is(err, repository.ErrNotFoundInRepo) {
+			return fmt.Errorf(
+				"%w: metric with type=%s and name=%s not exist",
+				ErrNotFoundInRepository,
+				metricType,
+				name,
+			)
+		}
+		return fmt.Errorf("deletion failed for type '%s', name '%s': %w", metricType, name, err)
+	}
+
+	if s.cardinality != nil {
+		s.cardinality.forget(metricType, name)
+	}
+	if s.rateLimiter != nil {
+		s.rateLimiter.forget(metricType, name)
+	}
+	if s.history != nil && entity.IsCounter(metricType) {
+		s.history.Forget(name)
+	}
+	if s.sweeper != nil {
+		s.sweeper.tracker.Forget(metricType, name)
+	}
+	return nil
+}
+
+// Undelete restores a soft-deleted metric and returns it.
+//
+// Parameters:
+//   - ctx: The context for the operation, supporting cancellation and timeouts.
+//   - metricType: The type of the metric.
+//   - name: The name of the metric to restore.
+//
+// Returns:
+//   - *entity.Metric: A pointer to the restored metric.
+//   - error: ErrNotFoundInRepository if there is nothing to restore, or an error if the repository operation fails.
+func (s *MetricService) Undelete(ctx context.Context, metricType, name string) (*entity.Metric, error) {
+	undeleteCtx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+
+	if err := s.repo.Undelete(undeleteCtx, metricType, name); err != nil {
+		if errors.Is(err, repository.ErrNotFoundInRepo) {
+			return nil, fmt.Errorf(
+				"%w: deleted metric with type=%s and name=%s not exist",
+				ErrNotFoundInRepository,
+				metricType,
+				name,
+			)
+		}
+		return nil, fmt.Errorf("undeletion failed for type '%s', name '%s': %w", metricType, name, err)
+	}
+
+	if s.cardinality != nil {
+		s.cardinality.remember(metricType, name)
+	}
+	return s.Pull(ctx, metricType, name)
+}
+
+// Reset zeroes a counter or float counter, e.g. after the agent reporting it restarts, or soft-deletes
+// a metric of another type, which has no zero to reset to. The zeroed counter is stored like an update,
+// so every repository persists it, and its history starts over.
+//
+// Parameters:
+//   - ctx: The context for the operation, supporting cancellation and timeouts.
+//   - metricType: The type of the metric.
+//   - name: The name of the metric to reset.
+//
+// Returns:
+//   - *entity.Metric: A pointer to the zeroed counter; nil if the metric was deleted.
+//   - error: ErrNotFoundInRepository if the metric does not exist, or an error if the repository operation fails.
+func (s *MetricService) Reset(ctx context.Context, metricType, name string) (*entity.Metric, error) {
+	ctx, span := startSpan(ctx, "Reset", attrMetricType.String(metricType), attrMetricName.String(name))
+	reset, err := s.reset(ctx, metricType, name)
+	endSpan(span, err)
+	return reset, err
+}
+
+// reset implements Reset.
+func (s *MetricService) reset(ctx context.Context, metricType, name string) (*entity.Metric, error) {
+	if !entity.IsCounter(metricType) {
+		return nil, s.delete(ctx, metricType, name)
+	}
+
+	resetCtx, cancel := context.WithTimeout(ctx, pushTimeout)
+	defer cancel()
+
+	if _, err := s.repo.Find(resetCtx, metricType, name); err != nil {
+		if errors.Is(err, repository.ErrNotFoundInRepo) {
+			return nil, fmt.Errorf(
+				"%w: metric with type=%s and name=%s not exist",
+				ErrNotFoundInRepository,
+				metricType,
+				name,
+			)
+		}
+		return nil, fmt.Errorf("failed to find metric type '%s', name '%s': %w", metricType, name, err)
+	}
+
+	var zero any = int64(0)
+	if metricType == entity.MetricTypeFloatCounter {
+		zero = 0.0
+	}
+	reset := &entity.Metric{Name: name, Type: metricType, Value: zero, Timestamp: time.Now()}
+	if err := s.repo.Update(resetCtx, reset); err != nil {
+		return nil, fmt.Errorf("reset failed for type '%s', name '%s': %w", metricType, name, err)
+	}
+
+	stored := entity.Metrics{reset}
+	if s.hub != nil {
+		s.hub.Publish(stored)
+	}
+	if s.history != nil {
+		s.history.Forget(name)
+		s.history.Record(stored)
+	}
+	if s.sweeper != nil {
+		s.sweeper.tracker.Observe(stored, time.Time{})
+	}
+	return reset, nil
+}
+
 // CheckConnection verifies connectivity to the repository by invoking its connection check.
 //
 // Parameters:
@@ -203,6 +680,8 @@ func (s *MetricService) CheckConnection(ctx context.Context) error {
 
 // validate checks if the provided metric is valid.
 // A valid metric must not be nil and must have a non-empty name, type, and a non-nil value.
+// Info metrics must additionally hold a string or a boolean, and float counters a finite,
+// non-negative number, as they only ever grow.
 //
 // Parameters:
 //   - metric: A pointer to the metric to validate.
@@ -222,5 +701,17 @@ func (s *MetricService) validate(metric *entity.Metric) error {
 	if metric.Value == nil {
 		return errors.New("metric value is missing")
 	}
+	if metric.Type == entity.MetricTypeInfo && !entity.IsInfoValue(metric.Value) {
+		return fmt.Errorf("info metric value must be a string or a boolean, got %T", metric.Value)
+	}
+	if metric.Type == entity.MetricTypeFloatCounter {
+		value, err := convert.AnyToFloat64(metric.Value)
+		if err != nil {
+			return fmt.Errorf("float counter metric value must be a number, got %T", metric.Value)
+		}
+		if math.IsNaN(value) || math.IsInf(value, 0) || value < 0 {
+			return fmt.Errorf("float counter metric value must be a finite non-negative number, got %v", value)
+		}
+	}
 	return nil
 }
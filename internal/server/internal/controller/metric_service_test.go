@@ -2,12 +2,20 @@ package controller
 
 import (
 	"context"
+	"math"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/internal/history"
+	"github.com/gdyunin/metricol.git/internal/server/internal/quota"
 	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/gdyunin/metricol.git/pkg/buildinfo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 )
 
 type MockRepository struct {
@@ -42,6 +50,39 @@ func (m *MockRepository) All(ctx context.Context) (*entity.Metrics, error) {
 	return metrics, args.Error(1) //nolint:wrapcheck // for tests
 }
 
+func (m *MockRepository) Page(ctx context.Context, query repository.PageQuery) (*repository.Page, error) {
+	args := m.Called(ctx, query)
+	page, ok := args.Get(0).(*repository.Page)
+	if !ok && args.Get(0) != nil {
+		panic("unexpected type returned from mock")
+	}
+	return page, args.Error(1) //nolint:wrapcheck // for tests
+}
+
+func (m *MockRepository) Query(ctx context.Context, filter repository.MetricFilter) (*entity.Metrics, error) {
+	args := m.Called(ctx, filter)
+	metrics, ok := args.Get(0).(*entity.Metrics)
+	if !ok && args.Get(0) != nil {
+		panic("unexpected type returned from mock")
+	}
+	return metrics, args.Error(1) //nolint:wrapcheck // for tests
+}
+
+func (m *MockRepository) Delete(ctx context.Context, metricType, name string) error {
+	args := m.Called(ctx, metricType, name)
+	return args.Error(0) //nolint:wrapcheck // for tests
+}
+
+func (m *MockRepository) Undelete(ctx context.Context, metricType, name string) error {
+	args := m.Called(ctx, metricType, name)
+	return args.Error(0) //nolint:wrapcheck // for tests
+}
+
+func (m *MockRepository) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Int(0), args.Error(1) //nolint:wrapcheck // for tests
+}
+
 func (m *MockRepository) CheckConnection(ctx context.Context) error {
 	args := m.Called(ctx)
 	return args.Error(0) //nolint:wrapcheck // for tests
@@ -72,6 +113,139 @@ func TestPushMetric(t *testing.T) {
 	}
 }
 
+func TestPushMetricsNonCounter(t *testing.T) {
+	repo := new(MockRepository)
+	service := NewMetricService(repo)
+
+	repo.On("UpdateBatch", mock.Anything, mock.Anything).Return(nil)
+	batch := entity.Metrics{
+		{Name: "version", Type: entity.MetricTypeInfo, Value: "v1.2.3"},
+		{Name: "load", Type: entity.MetricTypeGauge, Value: 0.5},
+	}
+
+	stored, err := service.PushMetrics(context.Background(), &batch)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, batch, *stored)
+	repo.AssertNotCalled(t, "Find", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPushMetricsRepeatedCounter(t *testing.T) {
+	repo := repository.NewInMemoryRepository(zap.NewNop().Sugar())
+	service := NewMetricService(repo)
+	ctx := context.Background()
+
+	first := entity.Metrics{{Name: "requests", Type: entity.MetricTypeCounter, Value: int64(10)}}
+	_, err := service.PushMetrics(ctx, &first)
+	require.NoError(t, err)
+
+	batch := entity.Metrics{
+		{Name: "requests", Type: entity.MetricTypeCounter, Value: int64(5)},
+		{Name: "requests", Type: entity.MetricTypeCounter, Value: int64(7)},
+	}
+	_, err = service.PushMetrics(ctx, &batch)
+	require.NoError(t, err)
+
+	stored, err := repo.Find(ctx, entity.MetricTypeCounter, "requests")
+	require.NoError(t, err)
+	assert.Equal(t, int64(22), stored.Value, "the stored value is added once for a repeated counter")
+}
+
+func TestPushMetricsFloatCounter(t *testing.T) {
+	repo := repository.NewInMemoryRepository(zap.NewNop().Sugar())
+	service := NewMetricService(repo)
+	ctx := context.Background()
+
+	for _, value := range []any{int64(1), 0.25, 0.5} {
+		m := &entity.Metric{Name: "cpu_seconds", Type: entity.MetricTypeFloatCounter, Value: value}
+		_, err := service.PushMetric(ctx, m)
+		require.NoError(t, err)
+	}
+	_, err := service.PushMetric(ctx, &entity.Metric{Name: "cpu_seconds", Type: entity.MetricTypeCounter, Value: int64(2)})
+	require.NoError(t, err)
+
+	stored, err := repo.Find(ctx, entity.MetricTypeFloatCounter, "cpu_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 1.75, stored.Value, "float counters are stored as float64")
+	counter, err := repo.Find(ctx, entity.MetricTypeCounter, "cpu_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), counter.Value, "float counters are kept apart from counters of the same name")
+
+	_, err = service.PushMetric(ctx, &entity.Metric{Name: "cpu_seconds", Type: entity.MetricTypeFloatCounter, Value: -1.0})
+	assert.ErrorIs(t, err, ErrInvalidMetric, "float counters never decrease")
+}
+
+func TestPushMetricsOutOfOrder(t *testing.T) {
+	stored := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		metric      *entity.Metric
+		name        string
+		expectedErr error
+	}{
+		{
+			name: "Newer gauge sample",
+			metric: &entity.Metric{
+				Name: "load", Type: entity.MetricTypeGauge, Value: 1.0, Timestamp: stored.Add(time.Minute),
+			},
+		},
+		{
+			name: "Older gauge sample",
+			metric: &entity.Metric{
+				Name: "load", Type: entity.MetricTypeGauge, Value: 1.0, Timestamp: stored.Add(-time.Minute),
+			},
+			expectedErr: ErrOutOfOrder,
+		},
+		{
+			name: "Older counter delta is still added",
+			metric: &entity.Metric{
+				Name: "load", Type: entity.MetricTypeCounter, Value: int64(1), Timestamp: stored.Add(-time.Minute),
+			},
+		},
+		{
+			name:   "Sample without timestamp",
+			metric: &entity.Metric{Name: "load", Type: entity.MetricTypeGauge, Value: 1.0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockRepository)
+			service := NewMetricService(repo)
+			repo.On("Find", mock.Anything, tt.metric.Type, tt.metric.Name).
+				Return(&entity.Metric{Name: tt.metric.Name, Type: tt.metric.Type, Value: int64(1), Timestamp: stored}, nil)
+			repo.On("UpdateBatch", mock.Anything, mock.Anything).Return(nil)
+
+			got, err := service.PushMetric(context.Background(), tt.metric)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				repo.AssertNotCalled(t, "UpdateBatch", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			assert.False(t, got.Timestamp.Before(stored), "stored timestamp must not go backwards")
+		})
+	}
+}
+
+func TestPushMetricsOutOfOrderInMemory(t *testing.T) {
+	repo := repository.NewInMemoryRepository(zap.NewNop().Sugar())
+	service := NewMetricService(repo)
+	ctx := context.Background()
+	stored := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	newer := &entity.Metric{Name: "load", Type: entity.MetricTypeGauge, Value: 1.0, Timestamp: stored}
+	_, err := service.PushMetric(ctx, newer)
+	require.NoError(t, err)
+
+	older := &entity.Metric{Name: "load", Type: entity.MetricTypeGauge, Value: 2.0, Timestamp: stored.Add(-time.Minute)}
+	_, err = service.PushMetric(ctx, older)
+	require.ErrorIs(t, err, ErrOutOfOrder)
+
+	got, err := repo.Find(ctx, entity.MetricTypeGauge, "load")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, got.Value, "the older sample is not stored")
+	assert.Equal(t, stored, got.Timestamp)
+}
+
 func TestPull(t *testing.T) {
 	repo := new(MockRepository)
 	service := NewMetricService(repo)
@@ -128,6 +302,238 @@ func TestPullAll(t *testing.T) {
 	}
 }
 
+func TestPullBuildInfo(t *testing.T) {
+	repo := new(MockRepository)
+	repo.On("Find", mock.Anything, entity.MetricTypeInfo, selfMetricBuildInfo).Return(nil, repository.ErrNotFoundInRepo)
+	service := NewMetricService(repo, WithBuildInfo(buildinfo.New("1.2.0", "2024-05-01", "abc123")))
+
+	metric, err := service.Pull(context.Background(), entity.MetricTypeInfo, selfMetricBuildInfo)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.0 (commit abc123, built 2024-05-01)", metric.Value)
+}
+
+func TestDeleteAndUndelete(t *testing.T) {
+	repo := new(MockRepository)
+	service := NewMetricService(repo)
+	ctx := context.Background()
+
+	repo.On("Delete", mock.Anything, "gauge", "known").Return(nil)
+	repo.On("Delete", mock.Anything, "gauge", "unknown").Return(repository.ErrNotFoundInRepo)
+	repo.On("Undelete", mock.Anything, "gauge", "known").Return(nil)
+	repo.On("Undelete", mock.Anything, "gauge", "unknown").Return(repository.ErrNotFoundInRepo)
+	repo.On("Find", mock.Anything, "gauge", "known").
+		Return(&entity.Metric{Name: "known", Type: "gauge", Value: 1.5}, nil)
+
+	assert.NoError(t, service.Delete(ctx, "gauge", "known"))
+	assert.ErrorIs(t, service.Delete(ctx, "gauge", "unknown"), ErrNotFoundInRepository)
+
+	restored, err := service.Undelete(ctx, "gauge", "known")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.5, restored.Value)
+
+	_, err = service.Undelete(ctx, "gauge", "unknown")
+	assert.ErrorIs(t, err, ErrNotFoundInRepository)
+}
+
+func TestReset(t *testing.T) {
+	ctx := context.Background()
+	store := history.NewStore(time.Hour)
+	repo := repository.NewInMemoryRepository(zap.NewNop().Sugar())
+	service := NewMetricService(repo, WithHistory(store))
+
+	for _, m := range []*entity.Metric{
+		{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(5)},
+		{Name: "CPUSeconds", Type: entity.MetricTypeFloatCounter, Value: 1.5},
+		{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 2.5},
+	} {
+		_, err := service.PushMetric(ctx, m)
+		require.NoError(t, err)
+	}
+
+	reset, err := service.Reset(ctx, entity.MetricTypeCounter, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), reset.Value)
+	require.Len(t, store.Range("PollCount", time.Time{}), 1, "the history starts over")
+
+	_, err = service.PushMetric(ctx, &entity.Metric{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(2)})
+	require.NoError(t, err)
+	counter, err := repo.Find(ctx, entity.MetricTypeCounter, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), counter.Value, "updates count from zero after a reset")
+
+	reset, err = service.Reset(ctx, entity.MetricTypeFloatCounter, "CPUSeconds")
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, reset.Value)
+
+	reset, err = service.Reset(ctx, entity.MetricTypeGauge, "Alloc")
+	require.NoError(t, err)
+	assert.Nil(t, reset, "gauges are deleted")
+	_, err = repo.Find(ctx, entity.MetricTypeGauge, "Alloc")
+	assert.ErrorIs(t, err, repository.ErrNotFoundInRepo)
+
+	_, err = service.Reset(ctx, entity.MetricTypeCounter, "unknown")
+	assert.ErrorIs(t, err, ErrNotFoundInRepository)
+	_, err = service.Reset(ctx, entity.MetricTypeGauge, "Alloc")
+	assert.ErrorIs(t, err, ErrNotFoundInRepository)
+}
+
+func TestPushMetricsRateLimit(t *testing.T) {
+	repo := new(MockRepository)
+	service := NewMetricService(repo, WithUpdateRateLimit(1))
+	ctx := context.Background()
+
+	repo.On("Find", mock.Anything, mock.Anything, mock.Anything).Return(nil, repository.ErrNotFoundInRepo)
+	repo.On("UpdateBatch", mock.Anything, mock.Anything).Return(nil)
+
+	_, err := service.PushMetric(ctx, &entity.Metric{Name: "limited", Type: "gauge", Value: 1.0})
+	assert.NoError(t, err)
+
+	_, err = service.PushMetric(ctx, &entity.Metric{Name: "limited", Type: "gauge", Value: 2.0})
+	assert.ErrorIs(t, err, ErrRateLimited)
+
+	_, err = service.PushMetric(ctx, &entity.Metric{Name: "other", Type: "gauge", Value: 3.0})
+	assert.NoError(t, err, "limits must be tracked per metric")
+
+	repo.AssertNumberOfCalls(t, "UpdateBatch", 2)
+}
+
+func TestPushMetricsRateLimitBatch(t *testing.T) {
+	repo := repository.NewInMemoryRepository(zap.NewNop().Sugar())
+	service := NewMetricService(repo, WithUpdateRateLimit(1), WithCardinalityLimits(1, nil))
+	ctx := context.Background()
+
+	_, err := service.PushMetric(ctx, &entity.Metric{Name: "limited", Type: entity.MetricTypeGauge, Value: 1.0})
+	require.NoError(t, err)
+
+	_, err = service.PushMetric(ctx, &entity.Metric{Name: "rejected", Type: entity.MetricTypeGauge, Value: 1.0})
+	require.ErrorIs(t, err, ErrCardinalityLimit)
+	assert.Len(t, service.rateLimiter.limiters, 1, "series rejected by the cardinality limits get no bucket")
+
+	require.NoError(t, service.Delete(ctx, entity.MetricTypeGauge, "limited"))
+	assert.Empty(t, service.rateLimiter.limiters, "the bucket of a deleted metric is removed")
+
+	service = NewMetricService(repo, WithUpdateRateLimit(1))
+	_, err = service.PushMetric(ctx, &entity.Metric{Name: "limited", Type: entity.MetricTypeGauge, Value: 1.0})
+	require.NoError(t, err)
+	batch := entity.Metrics{
+		{Name: "fresh", Type: entity.MetricTypeGauge, Value: 2.0},
+		{Name: "limited", Type: entity.MetricTypeGauge, Value: 2.0},
+	}
+	_, err = service.PushMetrics(ctx, &batch)
+	require.ErrorIs(t, err, ErrRateLimited)
+	_, err = service.PushMetric(ctx, &entity.Metric{Name: "fresh", Type: entity.MetricTypeGauge, Value: 3.0})
+	assert.NoError(t, err, "the tokens of a rejected batch are refunded")
+}
+
+func TestMetricRateLimiterPrune(t *testing.T) {
+	limiter := newMetricRateLimiter(1000)
+	gauge := func(name string) entity.Metrics {
+		return entity.Metrics{{Name: name, Type: entity.MetricTypeGauge}}
+	}
+	for i := range minRateLimiterPrune {
+		_, ok := limiter.allowBatch(gauge(strconv.Itoa(i)))
+		require.True(t, ok)
+	}
+	require.Len(t, limiter.limiters, minRateLimiterPrune)
+
+	time.Sleep(10 * time.Millisecond) // Every bucket refills a token per millisecond.
+	_, ok := limiter.allowBatch(gauge("new"))
+	require.True(t, ok)
+	assert.Len(t, limiter.limiters, 1, "full buckets are pruned")
+}
+
+func TestPushMetricsCardinalityLimits(t *testing.T) {
+	repo := new(MockRepository)
+	service := NewMetricService(repo, WithCardinalityLimits(3, map[string]int{"Random": 1}))
+	ctx := context.Background()
+
+	repo.On("All", mock.Anything).Return(&entity.Metrics{{Name: "Existing", Type: "gauge", Value: 1.0}}, nil)
+	repo.On("Find", mock.Anything, mock.Anything, mock.Anything).Return(nil, repository.ErrNotFoundInRepo)
+	repo.On("UpdateBatch", mock.Anything, mock.Anything).Return(nil)
+	repo.On("Delete", mock.Anything, "gauge", "Other").Return(nil)
+
+	push := func(name string) error {
+		_, err := service.PushMetric(ctx, &entity.Metric{Name: name, Type: "gauge", Value: 1.0})
+		return err
+	}
+
+	assert.NoError(t, push("Existing"))
+	assert.NoError(t, push("RandomA"))
+
+	var cardinalityErr *CardinalityError
+	err := push("RandomB")
+	assert.ErrorIs(t, err, ErrCardinalityLimit)
+	require.ErrorAs(t, err, &cardinalityErr)
+	assert.Equal(t, "Random", cardinalityErr.Prefix)
+
+	assert.NoError(t, push("Other"))
+	err = push("Another")
+	require.ErrorAs(t, err, &cardinalityErr)
+	assert.Equal(t, "", cardinalityErr.Prefix)
+	assert.Equal(t, 3, cardinalityErr.Limit)
+
+	series, err := service.Pull(ctx, "gauge", selfMetricSeries)
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, series.Value)
+
+	require.NoError(t, service.Delete(ctx, "gauge", "Other"))
+	assert.NoError(t, push("Another"), "deleted metrics must free their slot")
+	repo.AssertNumberOfCalls(t, "All", 1)
+}
+
+func TestPushMetricsNameFilter(t *testing.T) {
+	tests := []struct {
+		expectedErr error
+		name        string
+		expected    []string
+		reject      bool
+	}{
+		{name: "refused metrics are dropped", expected: []string{"HeapAlloc"}},
+		{name: "refused metrics reject the batch", reject: true, expectedErr: ErrMetricNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockRepository)
+			service := NewMetricService(repo, WithNameFilter([]string{"Heap*"}, []string{"HeapReleased"}, tt.reject))
+			repo.On("UpdateBatch", mock.Anything, mock.Anything).Return(nil)
+
+			batch := entity.Metrics{
+				{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 1.0},
+				{Name: "HeapReleased", Type: entity.MetricTypeGauge, Value: 2.0},
+				{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 3.0},
+			}
+			stored, err := service.PushMetrics(context.Background(), &batch)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				repo.AssertNotCalled(t, "UpdateBatch", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			names := make([]string, 0, stored.Length())
+			for _, m := range *stored {
+				names = append(names, m.Name)
+			}
+			assert.Equal(t, tt.expected, names)
+
+			dropped, ok := service.selfMetrics.Find(entity.MetricTypeCounter, selfMetricFilteredDropped)
+			require.True(t, ok)
+			assert.Equal(t, int64(2), dropped.Value)
+		})
+	}
+}
+
+func TestPushMetricNameFilterDropsSilently(t *testing.T) {
+	repo := new(MockRepository)
+	service := NewMetricService(repo, WithNameFilter(nil, []string{"Alloc"}, false))
+
+	metric := &entity.Metric{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.0}
+	returned, err := service.PushMetric(context.Background(), metric)
+	require.NoError(t, err)
+	assert.Equal(t, metric, returned)
+	repo.AssertNotCalled(t, "UpdateBatch", mock.Anything, mock.Anything)
+}
+
 func TestCheckConnection(t *testing.T) {
 	repo := new(MockRepository)
 	service := NewMetricService(repo)
@@ -165,6 +571,30 @@ func TestValidate(t *testing.T) {
 			metric:    &entity.Metric{Name: "test", Type: "counter", Value: nil},
 			expectErr: true,
 		},
+		{name: "Valid string info", metric: &entity.Metric{Name: "version", Type: "info", Value: "v1.2.3"}},
+		{name: "Valid boolean info", metric: &entity.Metric{Name: "feature", Type: "info", Value: true}},
+		{
+			name:      "Numeric info value",
+			metric:    &entity.Metric{Name: "version", Type: "info", Value: 1.5},
+			expectErr: true,
+		},
+		{name: "Valid float counter", metric: &entity.Metric{Name: "cpu_seconds", Type: "fcounter", Value: 0.25}},
+		{name: "Integer float counter", metric: &entity.Metric{Name: "cpu_seconds", Type: "fcounter", Value: int64(1)}},
+		{
+			name:      "Negative float counter",
+			metric:    &entity.Metric{Name: "cpu_seconds", Type: "fcounter", Value: -0.25},
+			expectErr: true,
+		},
+		{
+			name:      "NaN float counter",
+			metric:    &entity.Metric{Name: "cpu_seconds", Type: "fcounter", Value: math.NaN()},
+			expectErr: true,
+		},
+		{
+			name:      "String float counter",
+			metric:    &entity.Metric{Name: "cpu_seconds", Type: "fcounter", Value: "0.25"},
+			expectErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -174,3 +604,69 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestMetricService_History(t *testing.T) {
+	ctx := context.Background()
+	store := history.NewStore(time.Hour)
+	repo := repository.NewInMemoryRepository(zap.NewNop().Sugar())
+	service := NewMetricService(repo, WithHistory(store))
+
+	for _, m := range []*entity.Metric{
+		{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(3)},
+		{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(4)},
+		{Name: "RandomValue", Type: entity.MetricTypeGauge, Value: 1.5},
+	} {
+		_, err := service.PushMetric(ctx, m)
+		require.NoError(t, err)
+	}
+
+	samples := store.Range("PollCount", time.Time{})
+	require.Len(t, samples, 2)
+	assert.InDelta(t, 3.0, samples[0].Value, 1e-9)
+	assert.InDelta(t, 7.0, samples[1].Value, 1e-9, "the stored total is recorded, not the delta")
+	assert.Empty(t, store.Range("RandomValue", time.Time{}), "gauges are not recorded")
+
+	require.NoError(t, service.Delete(ctx, entity.MetricTypeCounter, "PollCount"))
+	assert.Empty(t, store.Range("PollCount", time.Time{}))
+}
+
+func TestMetricService_Quotas(t *testing.T) {
+	repo := repository.NewInMemoryRepository(zap.NewNop().Sugar())
+	service := NewMetricService(repo, WithQuotas(quota.NewTracker(map[string]quota.Limits{"team-a": {BatchSize: 1}})))
+	batch := entity.Metrics{
+		{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.0},
+		{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 2.0},
+	}
+
+	_, err := service.PushMetrics(quota.WithKey(context.Background(), "team-a"), &batch)
+	require.ErrorIs(t, err, quota.ErrBatchTooLarge)
+	all, err := repo.All(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, *all, "nothing of a rejected batch is stored")
+
+	_, err = service.PushMetrics(context.Background(), &batch)
+	assert.NoError(t, err, "requests without a configured key are not limited")
+}
+
+func TestMetricService_QuotasRejectedBatch(t *testing.T) {
+	repo := repository.NewInMemoryRepository(zap.NewNop().Sugar())
+	tracker := quota.NewTracker(map[string]quota.Limits{"team-a": {Metrics: 10, UpdatesPerMinute: 10}})
+	service := NewMetricService(repo, WithQuotas(tracker), WithUpdateRateLimit(1), WithCardinalityLimits(2, nil))
+	ctx := quota.WithKey(context.Background(), "team-a")
+
+	_, err := service.PushMetric(ctx, &entity.Metric{Name: "limited", Type: entity.MetricTypeGauge, Value: 1.0})
+	require.NoError(t, err)
+	_, err = service.PushMetric(ctx, &entity.Metric{Name: "limited", Type: entity.MetricTypeGauge, Value: 2.0})
+	require.ErrorIs(t, err, ErrRateLimited)
+	batch := entity.Metrics{
+		{Name: "first", Type: entity.MetricTypeGauge, Value: 1.0},
+		{Name: "second", Type: entity.MetricTypeGauge, Value: 1.0},
+	}
+	_, err = service.PushMetrics(ctx, &batch)
+	require.ErrorIs(t, err, ErrCardinalityLimit)
+
+	usage := tracker.Usage()
+	require.Len(t, usage, 1)
+	assert.Equal(t, 1, usage[0].Metrics, "rejected batches add no metrics")
+	assert.Equal(t, int64(1), usage[0].Updates, "rejected batches use no updates")
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sync/atomic"
+)
+
+// Const selfMetricFilteredDropped counts metrics rejected or dropped by the metric name filter.
+const selfMetricFilteredDropped = "metricol_filtered_dropped"
+
+// ErrMetricNotAllowed is returned when a metric name is rejected by the metric name filter.
+var ErrMetricNotAllowed = errors.New("metric name is not allowed")
+
+// nameFilter accepts metrics by name using glob patterns (see path.Match). A name is accepted if it matches
+// any allow pattern, or no allow patterns are configured, and matches no deny pattern.
+// The patterns can be replaced while metrics are being filtered.
+type nameFilter struct {
+	patterns atomic.Pointer[namePatterns] // patterns are the patterns in effect.
+	dropped  atomic.Int64                 // dropped counts refused metrics.
+	reject   bool                         // reject fails the whole request instead of silently dropping refused metrics.
+}
+
+// namePatterns holds the patterns of a nameFilter.
+type namePatterns struct {
+	allow []string // allow lists the patterns of accepted metric names.
+	deny  []string // deny lists the patterns of refused metric names.
+}
+
+// newNameFilter creates a nameFilter with the given patterns.
+func newNameFilter(allow, deny []string, reject bool) *nameFilter {
+	f := &nameFilter{reject: reject}
+	f.setPatterns(allow, deny)
+	return f
+}
+
+// setPatterns replaces the patterns of the filter.
+func (f *nameFilter) setPatterns(allow, deny []string) {
+	f.patterns.Store(&namePatterns{allow: allow, deny: deny})
+}
+
+// accepts reports whether a metric with the given name passes the filter.
+func (f *nameFilter) accepts(name string) bool {
+	p := f.patterns.Load()
+	if p == nil {
+		return true
+	}
+	if len(p.allow) > 0 && !matchAnyPattern(p.allow, name) {
+		return false
+	}
+	return !matchAnyPattern(p.deny, name)
+}
+
+// check decides what to do with a metric: keep it, silently drop it or reject the request.
+// Refused metrics are counted in both cases.
+//
+// Returns:
+//   - bool: True if the metric should be kept.
+//   - error: ErrMetricNotAllowed if the metric is refused and the filter rejects requests.
+func (f *nameFilter) check(metricType, name string) (bool, error) {
+	if f == nil || f.accepts(name) {
+		return true, nil
+	}
+	f.dropped.Add(1)
+	if f.reject {
+		return false, fmt.Errorf("%w: type=%s, name=%s", ErrMetricNotAllowed, metricType, name)
+	}
+	return false, nil
+}
+
+// SetNameFilter replaces the patterns of the metric name filter while the service is running,
+// keeping the configured choice between dropping and rejecting refused metrics.
+//
+// Parameters:
+//   - allow: The patterns of accepted metric names; empty accepts all names not denied.
+//   - deny: The patterns of refused metric names.
+func (s *MetricService) SetNameFilter(allow, deny []string) {
+	s.names.setPatterns(allow, deny)
+	s.selfMetrics.RegisterCounter(selfMetricFilteredDropped, s.names.dropped.Load)
+}
+
+// matchAnyPattern reports whether name matches any of the glob patterns. Malformed patterns match nothing.
+func matchAnyPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
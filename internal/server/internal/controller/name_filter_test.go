@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNameFilter_Accepts(t *testing.T) {
+	tests := []struct {
+		name     string
+		metric   string
+		allow    []string
+		deny     []string
+		expected bool
+	}{
+		{name: "no allow list accepts", metric: "Alloc", deny: []string{"Heap*"}, expected: true},
+		{name: "denied", metric: "HeapAlloc", deny: []string{"Heap*"}, expected: false},
+		{name: "allowed", metric: "HeapAlloc", allow: []string{"Heap*"}, expected: true},
+		{name: "not allowed", metric: "Alloc", allow: []string{"Heap*"}, expected: false},
+		{name: "deny wins", metric: "HeapReleased", allow: []string{"Heap*"}, deny: []string{"HeapReleased"}},
+		{name: "malformed pattern matches nothing", metric: "Heap[", allow: []string{"Heap["}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newNameFilter(tt.allow, tt.deny, false)
+			assert.Equal(t, tt.expected, f.accepts(tt.metric))
+		})
+	}
+}
+
+func TestNameFilter_Check(t *testing.T) {
+	var nilFilter *nameFilter
+	keep, err := nilFilter.check("gauge", "Alloc")
+	require.NoError(t, err)
+	assert.True(t, keep, "nil filter must accept all metrics")
+
+	drop := newNameFilter(nil, []string{"Alloc"}, false)
+	keep, err = drop.check("gauge", "Alloc")
+	require.NoError(t, err)
+	assert.False(t, keep)
+	assert.Equal(t, int64(1), drop.dropped.Load())
+
+	reject := newNameFilter(nil, []string{"Alloc"}, true)
+	_, err = reject.check("gauge", "Alloc")
+	assert.ErrorIs(t, err, ErrMetricNotAllowed)
+	assert.Equal(t, int64(1), reject.dropped.Load())
+}
+
+func TestNameFilter_SetPatterns(t *testing.T) {
+	f := newNameFilter([]string{"Heap*"}, nil, false)
+	assert.False(t, f.accepts("Alloc"))
+
+	f.setPatterns(nil, []string{"HeapReleased"})
+	assert.True(t, f.accepts("Alloc"))
+	assert.False(t, f.accepts("HeapReleased"))
+
+	assert.True(t, (&nameFilter{}).accepts("Alloc"), "a filter without patterns must accept all metrics")
+}
+
+func TestMetricService_SetNameFilter(t *testing.T) {
+	service := NewMetricService(nil)
+	keep, err := service.names.check("gauge", "Alloc")
+	require.NoError(t, err)
+	assert.True(t, keep)
+
+	service.SetNameFilter(nil, []string{"Alloc"})
+	keep, err = service.names.check("gauge", "Alloc")
+	require.NoError(t, err)
+	assert.False(t, keep)
+
+	dropped, ok := service.selfMetrics.Find("counter", selfMetricFilteredDropped)
+	require.True(t, ok)
+	assert.Equal(t, int64(1), dropped.Value)
+}
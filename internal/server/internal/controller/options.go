@@ -0,0 +1,243 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/clockskew"
+	"github.com/gdyunin/metricol.git/internal/server/internal/history"
+	"github.com/gdyunin/metricol.git/internal/server/internal/quota"
+	"github.com/gdyunin/metricol.git/internal/server/internal/ratelimit"
+	"github.com/gdyunin/metricol.git/internal/server/internal/retention"
+	"github.com/gdyunin/metricol.git/internal/server/internal/routestats"
+	"github.com/gdyunin/metricol.git/internal/server/internal/stream"
+	"github.com/gdyunin/metricol.git/pkg/buildinfo"
+)
+
+// Option configures optional behavior of a MetricService.
+type Option func(*MetricService)
+
+// WithUpdateRateLimit limits how often a single metric may be updated.
+// A non-positive rate disables the limit.
+//
+// Parameters:
+//   - perSecond: The maximum number of updates per second allowed for one metric.
+//
+// Returns:
+//   - Option: The option applying the limit.
+func WithUpdateRateLimit(perSecond int) Option {
+	return func(s *MetricService) {
+		if perSecond > 0 {
+			s.rateLimiter = newMetricRateLimiter(perSecond)
+		}
+	}
+}
+
+// WithCardinalityLimits caps the number of distinct metrics the service accepts.
+// New metrics beyond a limit are rejected with ErrCardinalityLimit, while existing metrics can still be updated.
+// The current cardinality is exposed as self-metrics.
+//
+// Parameters:
+//   - maxSeries: The maximum total number of distinct metrics; non-positive means unlimited.
+//   - prefixLimits: The maximum number of distinct metrics per metric name prefix.
+//
+// Returns:
+//   - Option: The option applying the limits.
+func WithCardinalityLimits(maxSeries int, prefixLimits map[string]int) Option {
+	return func(s *MetricService) {
+		if maxSeries <= 0 && len(prefixLimits) == 0 {
+			return
+		}
+
+		guard := newCardinalityGuard(maxSeries, prefixLimits)
+		s.cardinality = guard
+		s.selfMetrics.RegisterGauge(selfMetricSeries, guard.total)
+		for prefix := range prefixLimits {
+			s.selfMetrics.RegisterGauge(selfMetricSeriesPrefix+prefix, func() float64 {
+				return guard.prefixTotal(prefix)
+			})
+		}
+	}
+}
+
+// WithStreamHub publishes every stored update to the live stream hub and exposes the hub state as self-metrics.
+//
+// Parameters:
+//   - hub: The hub receiving updates.
+//
+// Returns:
+//   - Option: The option enabling publishing.
+func WithStreamHub(hub *stream.Hub) Option {
+	return func(s *MetricService) {
+		s.hub = hub
+		hub.RegisterSelfMetrics(s.selfMetrics)
+	}
+}
+
+// WithHistory records every stored counter sample in the history, so rates can be computed over a window,
+// and exposes the history size as a self-metric.
+//
+// Parameters:
+//   - store: The history receiving counter samples.
+//
+// Returns:
+//   - Option: The option enabling recording.
+func WithHistory(store *history.Store) Option {
+	return func(s *MetricService) {
+		s.history = store
+		store.RegisterSelfMetrics(s.selfMetrics)
+	}
+}
+
+// WithRetention deletes expired metrics every interval while Start runs, see retention.Tracker, and exposes
+// the number of deleted metrics as a self-metric. The explicit expiry carried by the request context, see
+// retention.WithExpiresAt, applies to the stored metrics of a batch. A non-positive interval disables expiry.
+//
+// Parameters:
+//   - tracker: The tracker deciding which metrics have expired.
+//   - interval: The period between sweeps.
+//
+// Returns:
+//   - Option: The option enabling expiry.
+func WithRetention(tracker *retention.Tracker, interval time.Duration) Option {
+	return func(s *MetricService) {
+		if interval <= 0 {
+			return
+		}
+		s.sweeper = &sweeper{tracker: tracker, interval: interval}
+		s.selfMetrics.RegisterCounter(selfMetricExpired, s.sweeper.expired.Load)
+	}
+}
+
+// WithQuotas checks every batch against the quotas of the API key carried by the request context,
+// see quota.WithKey, and rejects batches exceeding them with errors matching quota.ErrBatchTooLarge
+// or quota.ErrQuotaExceeded.
+//
+// Parameters:
+//   - tracker: The tracker enforcing the quotas.
+//
+// Returns:
+//   - Option: The option enabling quotas.
+func WithQuotas(tracker *quota.Tracker) Option {
+	return func(s *MetricService) {
+		s.quotas = tracker
+		tracker.RegisterSelfMetrics(s.selfMetrics)
+	}
+}
+
+// WithClockSkewTracker exposes the agent clock skew observed by the tracker as self-metrics.
+//
+// Parameters:
+//   - tracker: The tracker recording agent clock skew.
+//
+// Returns:
+//   - Option: The option registering the self-metrics.
+func WithClockSkewTracker(tracker *clockskew.Tracker) Option {
+	return func(s *MetricService) {
+		tracker.RegisterSelfMetrics(s.selfMetrics)
+	}
+}
+
+// WithClientRateLimiter exposes the requests rejected by the per-client rate limiter as self-metrics.
+//
+// Parameters:
+//   - limiter: The limiter enforcing the per-client rate.
+//
+// Returns:
+//   - Option: The option registering the self-metrics.
+func WithClientRateLimiter(limiter *ratelimit.Limiter) Option {
+	return func(s *MetricService) {
+		limiter.RegisterSelfMetrics(s.selfMetrics)
+	}
+}
+
+// WithRouteStats exposes the per-route request statistics collected by the recorder as self-metrics.
+//
+// Parameters:
+//   - recorder: The recorder collecting request statistics.
+//
+// Returns:
+//   - Option: The option registering the self-metrics.
+func WithRouteStats(recorder *routestats.Recorder) Option {
+	return func(s *MetricService) {
+		recorder.RegisterSelfMetrics(s.selfMetrics)
+	}
+}
+
+// WithBuildInfo exposes the server build as the metricol_build_info info self-metric.
+//
+// Parameters:
+//   - info: The build info of the server.
+//
+// Returns:
+//   - Option: The option registering the self-metric.
+func WithBuildInfo(info buildinfo.Info) Option {
+	return func(s *MetricService) {
+		s.selfMetrics.RegisterInfo(selfMetricBuildInfo, info.String)
+	}
+}
+
+// WithWriteBuffer puts a write-behind buffer in front of the repository. Updates of the same metric arriving
+// between flushes are coalesced, and the buffer is written to the repository every interval or once it holds
+// maxPending metrics, trading bounded staleness of the repository for far fewer writes.
+// Reads always see buffered values. The buffer is flushed periodically only while Start runs.
+// A non-positive interval disables the buffer.
+//
+// Parameters:
+//   - interval: The period between flushes.
+//   - maxPending: The number of buffered metrics forcing an immediate flush; non-positive means no size limit.
+//
+// Returns:
+//   - Option: The option enabling the buffer.
+func WithWriteBuffer(interval time.Duration, maxPending int) Option {
+	return func(s *MetricService) {
+		if interval <= 0 {
+			return
+		}
+		s.buffer = newWriteBuffer(s.repo, interval, maxPending)
+		s.repo = s.buffer
+		s.buffer.registerSelfMetrics(s.selfMetrics)
+	}
+}
+
+// WithNameFilter accepts only metrics whose names match an allow pattern, if any are given, and match no deny
+// pattern. Patterns are globs as in path.Match; malformed ones match nothing. Refused metrics
+// are dropped from the batch, or the whole batch is rejected with ErrMetricNotAllowed if reject is set.
+// The number of refused metrics is exposed as a self-metric. Without patterns the option is a no-op.
+//
+// Parameters:
+//   - allow: The patterns of accepted metric names.
+//   - deny: The patterns of refused metric names.
+//   - reject: Whether to reject batches containing refused metrics instead of dropping them.
+//
+// Returns:
+//   - Option: The option applying the filter.
+func WithNameFilter(allow, deny []string, reject bool) Option {
+	return func(s *MetricService) {
+		if len(allow) == 0 && len(deny) == 0 {
+			return
+		}
+		s.names.reject = reject
+		s.names.setPatterns(allow, deny)
+		s.selfMetrics.RegisterCounter(selfMetricFilteredDropped, s.names.dropped.Load)
+	}
+}
+
+// WithGaugeSmoothing stores an exponentially weighted moving average (EWMA) of every gauge matching a pattern
+// next to its raw value, as a gauge named after it with the SmoothedSuffix, e.g. RandomValue_ewma.
+// Every sample updates the average as alpha*sample + (1-alpha)*average, so a smaller alpha smooths more.
+// Patterns are globs as in path.Match; if several match a gauge, the longest wins. Without patterns
+// the option is a no-op.
+//
+// Parameters:
+//   - alphas: The weight of new samples, in (0, 1], keyed by the pattern of gauge names.
+//
+// Returns:
+//   - Option: The option enabling smoothing.
+func WithGaugeSmoothing(alphas map[string]float64) Option {
+	return func(s *MetricService) {
+		if len(alphas) == 0 {
+			return
+		}
+		s.smoother = &smoother{alphas: alphas}
+	}
+}
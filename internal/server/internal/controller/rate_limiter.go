@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"golang.org/x/time/rate"
+)
+
+// minRateLimiterPrune is the number of token buckets below which full buckets are not pruned.
+const minRateLimiterPrune = 1024
+
+// metricRateLimiter tracks a token bucket per metric to cap its update frequency.
+// A full bucket behaves like a new one, so full buckets are pruned once the map grows,
+// which bounds it by the metrics updated within the last second or so.
+type metricRateLimiter struct {
+	limiters map[string]*rate.Limiter // limiters maps a metric key to its token bucket.
+	mu       *sync.Mutex              // mu protects the limiters map.
+	limit    rate.Limit               // limit is the allowed number of updates per second.
+	burst    int                      // burst is the bucket capacity.
+	pruneAt  int                      // pruneAt is the number of buckets at which full buckets are pruned.
+}
+
+// newMetricRateLimiter creates a limiter allowing perSecond updates per second for every metric.
+func newMetricRateLimiter(perSecond int) *metricRateLimiter {
+	return &metricRateLimiter{
+		limiters: make(map[string]*rate.Limiter),
+		mu:       &sync.Mutex{},
+		limit:    rate.Limit(perSecond),
+		burst:    perSecond,
+		pruneAt:  minRateLimiterPrune,
+	}
+}
+
+// allowBatch reports whether every metric of the batch may be updated now and consumes a token of each
+// if so. If any metric is over its limit, the tokens taken for the others are refunded and the limited
+// metric is returned.
+func (l *metricRateLimiter) allowBatch(batch entity.Metrics) (*entity.Metric, bool) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	reservations := make([]*rate.Reservation, 0, len(batch))
+	for _, m := range batch {
+		r := l.bucket(m).ReserveN(now, 1)
+		if !r.OK() || r.DelayFrom(now) > 0 {
+			r.CancelAt(now)
+			for _, taken := range reservations {
+				taken.CancelAt(now)
+			}
+			return m, false
+		}
+		reservations = append(reservations, r)
+	}
+	return nil, true
+}
+
+// forget removes the bucket of a deleted metric.
+func (l *metricRateLimiter) forget(metricType, name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.limiters, metricType+"|"+name)
+}
+
+// bucket returns the token bucket of a metric, creating it if needed. It must be called with mu held.
+func (l *metricRateLimiter) bucket(m *entity.Metric) *rate.Limiter {
+	key := m.Type + "|" + m.Name
+	if limiter, ok := l.limiters[key]; ok {
+		return limiter
+	}
+	if len(l.limiters) >= l.pruneAt {
+		l.prune()
+	}
+	limiter := rate.NewLimiter(l.limit, l.burst)
+	l.limiters[key] = limiter
+	return limiter
+}
+
+// prune removes the full buckets and doubles the threshold of the next pruning past the remaining ones.
+// It must be called with mu held.
+func (l *metricRateLimiter) prune() {
+	now := time.Now()
+	for key, limiter := range l.limiters {
+		if limiter.TokensAt(now) >= float64(l.burst) {
+			delete(l.limiters, key)
+		}
+	}
+	l.pruneAt = max(minRateLimiterPrune, 2*len(l.limiters))
+}
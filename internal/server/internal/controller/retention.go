@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/retention"
+)
+
+// selfMetricExpired counts metrics deleted because they expired.
+const selfMetricExpired = "metricol_retention_expired"
+
+// sweeper periodically deletes expired metrics.
+type sweeper struct {
+	tracker  *retention.Tracker // tracker decides which metrics have expired.
+	interval time.Duration      // interval is the period between sweeps.
+	expired  atomic.Int64       // expired counts the metrics deleted because they expired.
+}
+
+// runSweeper deletes expired metrics every sweep interval until the context is canceled.
+//
+// Parameters:
+//   - ctx: The context controlling the sweeper lifecycle.
+func (s *MetricService) runSweeper(ctx context.Context) {
+	ticker := time.NewTicker(s.sweeper.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// A failed sweep is retried on the next tick.
+			_, _ = s.sweepExpired(ctx, time.Now())
+		}
+	}
+}
+
+// sweepExpired deletes the stored metrics that have expired at the given time. The metrics are deleted
+// through Delete, so they stay restorable until their tombstones are purged.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - now: The current time.
+//
+// Returns:
+//   - int: The number of deleted metrics.
+//   - error: An error if the metrics cannot be read or a deletion fails.
+func (s *MetricService) sweepExpired(ctx context.Context, now time.Time) (int, error) {
+	if !s.sweeper.tracker.Active() {
+		return 0, nil
+	}
+
+	readCtx, cancel := context.WithTimeout(ctx, pullAllTimeout)
+	metrics, err := s.repo.All(readCtx)
+	cancel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read metrics: %w", err)
+	}
+
+	deleted := 0
+	var errs []error
+	for _, m := range *metrics {
+		if m == nil || !s.sweeper.tracker.Expired(m, now) {
+			continue
+		}
+		if err = s.Delete(ctx, m.Type, m.Name); err != nil && !errors.Is(err, ErrNotFoundInRepository) {
+			errs = append(errs, err)
+			continue
+		}
+		deleted++
+	}
+	s.sweeper.expired.Add(int64(deleted))
+	if err = errors.Join(errs...); err != nil {
+		return deleted, fmt.Errorf("failed to delete expired metrics: %w", err)
+	}
+	return deleted, nil
+}
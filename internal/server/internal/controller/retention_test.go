@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/internal/retention"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSweepExpired(t *testing.T) {
+	policy, err := retention.NewPolicy(nil, map[string]time.Duration{"Heap*": time.Hour})
+	require.NoError(t, err)
+	repo := repository.NewInMemoryRepository(zap.NewNop().Sugar())
+	service := NewMetricService(repo, WithRetention(retention.NewTracker(policy), time.Minute))
+	ctx := context.Background()
+	now := time.Now()
+
+	_, err = service.PushMetric(ctx, &entity.Metric{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 1.0})
+	require.NoError(t, err)
+	_, err = service.PushMetric(ctx, &entity.Metric{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.0})
+	require.NoError(t, err)
+	jobCtx := retention.WithExpiresAt(ctx, now.Add(time.Minute))
+	_, err = service.PushMetric(jobCtx, &entity.Metric{Name: "job_done", Type: entity.MetricTypeCounter, Value: int64(1)})
+	require.NoError(t, err)
+
+	deleted, err := service.sweepExpired(ctx, now.Add(30*time.Second))
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+
+	deleted, err = service.sweepExpired(ctx, now.Add(2*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	_, err = service.Pull(ctx, entity.MetricTypeCounter, "job_done")
+	assert.ErrorIs(t, err, ErrNotFoundInRepository, "the explicit expiry has passed")
+
+	deleted, err = service.sweepExpired(ctx, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	_, err = service.Pull(ctx, entity.MetricTypeGauge, "HeapAlloc")
+	assert.ErrorIs(t, err, ErrNotFoundInRepository, "the time-to-live of the pattern has passed")
+	_, err = service.Pull(ctx, entity.MetricTypeGauge, "Alloc")
+	assert.NoError(t, err, "metrics without a time-to-live are kept")
+
+	expired, err := service.Pull(ctx, entity.MetricTypeCounter, selfMetricExpired)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), expired.Value)
+}
+
+func TestSweepExpiredInactive(t *testing.T) {
+	repo := new(MockRepository)
+	service := NewMetricService(repo, WithRetention(retention.NewTracker(nil), time.Minute))
+
+	deleted, err := service.sweepExpired(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+	repo.AssertNotCalled(t, "All")
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+)
+
+// SmoothedSuffix is appended to the name of a gauge to name the gauge holding its smoothed value.
+const SmoothedSuffix = "_ewma"
+
+// smoother computes exponentially weighted moving averages (EWMA) of the gauges matching its patterns.
+// The smoothed value is stored as a separate gauge next to the raw one, so the previous average survives
+// restarts together with the data.
+type smoother struct {
+	alphas map[string]float64 // alphas maps a glob pattern to the weight of new samples, in (0, 1].
+}
+
+// alpha returns the weight of new samples of the named gauge. If several patterns match, the longest wins.
+func (sm *smoother) alpha(name string) (float64, bool) {
+	if strings.HasSuffix(name, SmoothedSuffix) {
+		return 0, false
+	}
+
+	var (
+		best  string
+		alpha float64
+		found bool
+	)
+	for pattern, a := range sm.alphas {
+		if ok, _ := path.Match(pattern, name); ok && (!found || len(pattern) > len(best)) {
+			best, alpha, found = pattern, a, true
+		}
+	}
+	return alpha, found
+}
+
+// smooth computes the smoothed gauges of the batch from the stored averages. The first sample of a gauge
+// seeds its average.
+func (sm *smoother) smooth(
+	ctx context.Context,
+	repo repository.Repository,
+	batch entity.Metrics,
+) (entity.Metrics, error) {
+	var smoothed entity.Metrics
+	for _, m := range batch {
+		if m.Type != entity.MetricTypeGauge {
+			continue
+		}
+		alpha, ok := sm.alpha(m.Name)
+		if !ok {
+			continue
+		}
+		value, ok := m.Value.(float64)
+		if !ok {
+			continue
+		}
+
+		name := m.Name + SmoothedSuffix
+		previous, err := repo.Find(ctx, entity.MetricTypeGauge, name)
+		switch {
+		case errors.Is(err, repository.ErrNotFoundInRepo):
+		case err != nil:
+			return nil, fmt.Errorf("retrieval failed for '%s': %w", name, err)
+		default:
+			if average, ok := previous.Value.(float64); ok {
+				value = alpha*value + (1-alpha)*average
+			}
+		}
+
+		smoothed = append(smoothed, &entity.Metric{
+			Timestamp: m.Timestamp,
+			Value:     value,
+			Name:      name,
+			Type:      entity.MetricTypeGauge,
+		})
+	}
+	return smoothed, nil
+}
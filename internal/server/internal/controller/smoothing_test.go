@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSmoother_Alpha(t *testing.T) {
+	sm := &smoother{alphas: map[string]float64{"CPU*": 0.5, "CPUutilization1": 0.1}}
+
+	tests := []struct {
+		name     string
+		expected float64
+		found    bool
+	}{
+		{name: "CPUutilization2", expected: 0.5, found: true},
+		{name: "CPUutilization1", expected: 0.1, found: true},
+		{name: "RandomValue", found: false},
+		{name: "CPUutilization2" + SmoothedSuffix, found: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alpha, found := sm.alpha(tt.name)
+			assert.Equal(t, tt.found, found)
+			assert.InDelta(t, tt.expected, alpha, 1e-9)
+		})
+	}
+}
+
+func TestMetricService_GaugeSmoothing(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryRepository(zap.NewNop().Sugar())
+	service := NewMetricService(repo, WithGaugeSmoothing(map[string]float64{"Random*": 0.25}))
+
+	for _, v := range []float64{10, 20, 0} {
+		stored, err := service.PushMetric(ctx, &entity.Metric{Name: "RandomValue", Type: entity.MetricTypeGauge, Value: v})
+		require.NoError(t, err)
+		assert.Equal(t, "RandomValue", stored.Name, "the raw metric is returned")
+	}
+
+	raw, err := service.Pull(ctx, entity.MetricTypeGauge, "RandomValue")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.0, raw.Value, 1e-9)
+
+	// 10, then 0.25*20 + 0.75*10 = 12.5, then 0.25*0 + 0.75*12.5 = 9.375.
+	smoothed, err := service.Pull(ctx, entity.MetricTypeGauge, "RandomValue"+SmoothedSuffix)
+	require.NoError(t, err)
+	assert.InDelta(t, 9.375, smoothed.Value, 1e-9)
+}
+
+func TestMetricService_GaugeSmoothingSkipsOtherMetrics(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewInMemoryRepository(zap.NewNop().Sugar())
+	service := NewMetricService(repo, WithGaugeSmoothing(map[string]float64{"*": 0.5}))
+
+	batch := entity.Metrics{
+		{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(1)},
+		{Name: "Version", Type: entity.MetricTypeInfo, Value: "1.0"},
+		{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.0},
+	}
+	stored, err := service.PushMetrics(ctx, &batch)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stored.Length())
+
+	all, err := repo.All(ctx)
+	require.NoError(t, err)
+	names := make([]string, 0, all.Length())
+	for _, m := range *all {
+		names = append(names, m.Name)
+	}
+	assert.ElementsMatch(t, []string{"PollCount", "Version", "Alloc", "Alloc" + SmoothedSuffix}, names)
+}
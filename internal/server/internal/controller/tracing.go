@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gdyunin/metricol.git/pkg/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName names the tracer of the service spans.
+const tracerName = "github.com/gdyunin/metricol.git/internal/server/internal/controller"
+
+// Span attributes describing the metrics an operation works on.
+var (
+	attrMetricType = attribute.Key("metricol.metric.type")
+	attrMetricName = attribute.Key("metricol.metric.name")
+	attrBatchSize  = attribute.Key("metricol.batch.size")
+)
+
+// startSpan starts the span of a service operation with the global tracer provider, which stays a no-op
+// until tracing is set up.
+//
+// Parameters:
+//   - ctx: The context of the operation.
+//   - operation: The name of the operation, e.g. "PushMetrics".
+//   - attrs: The attributes of the span.
+//
+// Returns:
+//   - context.Context: The context carrying the span.
+//   - trace.Span: The started span.
+func startSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, "MetricService."+operation, trace.WithAttributes(attrs...))
+}
+
+// endSpan ends the span of a service operation. Metrics that do not exist are an answer rather than
+// a failure, so they do not mark the span as failed.
+//
+// Parameters:
+//   - span: The span of the operation.
+//   - err: The error the operation failed with, or nil.
+func endSpan(span trace.Span, err error) {
+	if errors.Is(err, ErrNotFoundInRepository) {
+		err = nil
+	}
+	tracing.End(span, err)
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+)
+
+func TestMetricServiceSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	repo := repository.NewTracedRepository(repository.NewInMemoryRepository(zap.NewNop().Sugar()), provider, "memory")
+	service := NewMetricService(repo)
+	ctx := context.Background()
+
+	batch := entity.Metrics{{Name: "load", Type: entity.MetricTypeGauge, Value: 1.0}}
+	_, err := service.PushMetrics(ctx, &batch)
+	require.NoError(t, err)
+	_, err = service.Pull(ctx, entity.MetricTypeGauge, "missing")
+	require.ErrorIs(t, err, ErrNotFoundInRepository)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 4)
+	update, push, find, pull := spans[0], spans[1], spans[2], spans[3]
+
+	assert.Equal(t, "MetricService.PushMetrics", push.Name())
+	assert.Contains(t, push.Attributes(), attrBatchSize.Int(1))
+	assert.Equal(t, "repository.UpdateBatch", update.Name())
+	assert.Equal(t, push.SpanContext().SpanID(), update.Parent().SpanID(), "storage spans nest in service spans")
+
+	assert.Equal(t, "MetricService.Pull", pull.Name())
+	assert.Equal(t, pull.SpanContext().SpanID(), find.Parent().SpanID())
+	assert.Equal(t, codes.Unset, pull.Status().Code, "a missing metric is not a failure")
+}
@@ -0,0 +1,288 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/internal/selfmetric"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+)
+
+const (
+	// selfMetricBufferPending reports the number of metrics waiting to be flushed.
+	selfMetricBufferPending = "metricol_write_buffer_pending"
+	// selfMetricBufferCoalesced counts updates replaced by a later update before being flushed.
+	selfMetricBufferCoalesced = "metricol_write_buffer_coalesced"
+	// selfMetricBufferFlushErrors counts failed flushes; their metrics are retried on the next flush.
+	selfMetricBufferFlushErrors = "metricol_write_buffer_flush_errors"
+)
+
+// writeBuffer is a write-behind repository decorator. Updates are kept in memory and coalesced per metric,
+// so a metric updated many times between flushes is written once. Reads see buffered values immediately,
+// while the underlying repository lags behind by at most one flush interval.
+type writeBuffer struct {
+	repository.Repository                           // Repository is the repository receiving flushed metrics.
+	pending               map[string]*entity.Metric // pending maps a metric key to its latest buffered value.
+	flushing              map[string]*entity.Metric // flushing holds the metrics being written by a running flush.
+	mu                    *sync.Mutex               // mu protects pending.
+	flushMu               *sync.Mutex               // flushMu serializes flushes, so batches are written in order.
+	interval              time.Duration             // interval is the period between flushes.
+	maxPending            int                       // maxPending is the number of buffered metrics forcing a flush.
+	coalesced             atomic.Int64              // coalesced counts updates replaced before being flushed.
+	flushErrors           atomic.Int64              // flushErrors counts failed flushes.
+}
+
+// newWriteBuffer creates a writeBuffer in front of repo.
+func newWriteBuffer(repo repository.Repository, interval time.Duration, maxPending int) *writeBuffer {
+	return &writeBuffer{
+		Repository: repo,
+		pending:    make(map[string]*entity.Metric),
+		mu:         &sync.Mutex{},
+		flushMu:    &sync.Mutex{},
+		interval:   interval,
+		maxPending: maxPending,
+	}
+}
+
+// Update buffers a single metric.
+func (b *writeBuffer) Update(ctx context.Context, metric *entity.Metric) error {
+	return b.UpdateBatch(ctx, &entity.Metrics{metric})
+}
+
+// UpdateBatch buffers a batch of metrics, replacing earlier buffered values of the same metrics.
+// Once the buffer holds maxPending metrics it is flushed synchronously, which bounds memory use
+// and pushes back on clients when the repository falls behind.
+func (b *writeBuffer) UpdateBatch(ctx context.Context, metrics *entity.Metrics) error {
+	b.mu.Lock()
+	for _, m := range *metrics {
+		k := bufferKey(m.Type, m.Name)
+		if _, ok := b.pending[k]; ok {
+			b.coalesced.Add(1)
+		}
+		stored := *m
+		b.pending[k] = &stored
+	}
+	full := b.maxPending > 0 && len(b.pending) >= b.maxPending
+	b.mu.Unlock()
+
+	if full {
+		if err := b.flush(ctx); err != nil {
+			return fmt.Errorf("failed to flush full write buffer: %w", err)
+		}
+	}
+	return nil
+}
+
+// Find returns the buffered value of a metric, falling back to the repository.
+func (b *writeBuffer) Find(ctx context.Context, metricType, metricName string) (*entity.Metric, error) {
+	b.mu.Lock()
+	m, ok := b.lookup(bufferKey(metricType, metricName))
+	b.mu.Unlock()
+	if ok {
+		return m, nil
+	}
+
+	found, err := b.Repository.Find(ctx, metricType, metricName)
+	if err != nil {
+		return nil, fmt.Errorf("buffered repository find failed: %w", err)
+	}
+	return found, nil
+}
+
+// All returns the repository metrics with buffered values applied on top.
+func (b *writeBuffer) All(ctx context.Context) (*entity.Metrics, error) {
+	stored, err := b.Repository.All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("buffered repository all failed: %w", err)
+	}
+	if stored == nil {
+		stored = &entity.Metrics{}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.pending) == 0 && len(b.flushing) == 0 {
+		return stored, nil
+	}
+
+	metrics := make(entity.Metrics, 0, len(*stored)+len(b.pending)+len(b.flushing))
+	seen := make(map[string]struct{}, len(b.pending)+len(b.flushing))
+	for _, m := range *stored {
+		k := bufferKey(m.Type, m.Name)
+		if buffered, ok := b.lookup(k); ok {
+			metrics = append(metrics, buffered)
+			seen[k] = struct{}{}
+			continue
+		}
+		metrics = append(metrics, m)
+	}
+	for _, source := range []map[string]*entity.Metric{b.pending, b.flushing} {
+		for k := range source {
+			if _, ok := seen[k]; ok {
+				continue
+			}
+			buffered, _ := b.lookup(k)
+			metrics = append(metrics, buffered)
+			seen[k] = struct{}{}
+		}
+	}
+	return &metrics, nil
+}
+
+// Page returns a page of the repository metrics. While updates are buffered, the page is cut out of All
+// instead, so it holds the buffered values and the metrics that were not flushed yet.
+func (b *writeBuffer) Page(ctx context.Context, query repository.PageQuery) (*repository.Page, error) {
+	b.mu.Lock()
+	buffered := len(b.pending) > 0 || len(b.flushing) > 0
+	b.mu.Unlock()
+
+	if !buffered {
+		page, err := b.Repository.Page(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("buffered repository page failed: %w", err)
+		}
+		return page, nil
+	}
+	if err := query.Validate(); err != nil {
+		return nil, fmt.Errorf("buffered repository page failed: %w", err)
+	}
+	all, err := b.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return repository.PageOf(*all, query), nil
+}
+
+// Query returns the repository metrics matching a filter. While updates are buffered, the metrics are
+// filtered out of All instead, so they hold the buffered values and the metrics that were not flushed yet.
+func (b *writeBuffer) Query(ctx context.Context, filter repository.MetricFilter) (*entity.Metrics, error) {
+	b.mu.Lock()
+	buffered := len(b.pending) > 0 || len(b.flushing) > 0
+	b.mu.Unlock()
+
+	if !buffered {
+		metrics, err := b.Repository.Query(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("buffered repository query failed: %w", err)
+		}
+		return metrics, nil
+	}
+	all, err := b.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matched := repository.Filter(*all, filter)
+	return &matched, nil
+}
+
+// Delete flushes the buffer and soft-deletes the metric, so a buffered metric can be deleted as well.
+func (b *writeBuffer) Delete(ctx context.Context, metricType, metricName string) error {
+	if err := b.flush(ctx); err != nil {
+		return fmt.Errorf("failed to flush write buffer before delete: %w", err)
+	}
+	if err := b.Repository.Delete(ctx, metricType, metricName); err != nil {
+		return fmt.Errorf("buffered repository delete failed: %w", err)
+	}
+	return nil
+}
+
+// Undelete flushes the buffer and restores the metric, so the restored value is not overwritten later.
+func (b *writeBuffer) Undelete(ctx context.Context, metricType, metricName string) error {
+	if err := b.flush(ctx); err != nil {
+		return fmt.Errorf("failed to flush write buffer before undelete: %w", err)
+	}
+	if err := b.Repository.Undelete(ctx, metricType, metricName); err != nil {
+		return fmt.Errorf("buffered repository undelete failed: %w", err)
+	}
+	return nil
+}
+
+// run flushes the buffer every interval until the context is canceled.
+func (b *writeBuffer) run(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			flushCtx, cancel := context.WithTimeout(ctx, pushTimeout)
+			_ = b.flush(flushCtx) // A failed flush is counted and retried on the next tick.
+			cancel()
+		}
+	}
+}
+
+// flush writes all buffered metrics to the repository in one batch.
+// If the write fails, the metrics are returned to the buffer unless a newer value arrived meanwhile.
+func (b *writeBuffer) flush(ctx context.Context) error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	if len(b.pending) == 0 {
+		b.mu.Unlock()
+		return nil
+	}
+	batch := make(entity.Metrics, 0, len(b.pending))
+	for _, m := range b.pending {
+		batch = append(batch, m)
+	}
+	b.flushing = b.pending
+	b.pending = make(map[string]*entity.Metric, len(batch))
+	b.mu.Unlock()
+
+	err := b.Repository.UpdateBatch(ctx, &batch)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		b.flushErrors.Add(1)
+		for k, m := range b.flushing {
+			if _, newer := b.pending[k]; !newer {
+				b.pending[k] = m
+			}
+		}
+	}
+	b.flushing = nil
+	if err != nil {
+		return fmt.Errorf("failed to flush %d buffered metrics: %w", len(batch), err)
+	}
+	return nil
+}
+
+// lookup returns a copy of the buffered value of a metric, preferring values not yet picked up by a flush.
+// The caller must hold mu.
+func (b *writeBuffer) lookup(k string) (*entity.Metric, bool) {
+	m, ok := b.pending[k]
+	if !ok {
+		m, ok = b.flushing[k]
+	}
+	if !ok {
+		return nil, false
+	}
+	found := *m
+	return &found, true
+}
+
+// registerSelfMetrics exposes the buffer state through the self-metric registry.
+func (b *writeBuffer) registerSelfMetrics(r *selfmetric.Registry) {
+	r.RegisterGauge(selfMetricBufferPending, func() float64 {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return float64(len(b.pending))
+	})
+	r.RegisterCounter(selfMetricBufferCoalesced, b.coalesced.Load)
+	r.RegisterCounter(selfMetricBufferFlushErrors, b.flushErrors.Load)
+}
+
+// bufferKey builds the key identifying a buffered metric.
+func bufferKey(metricType, name string) string {
+	return metricType + "|" + name
+}
@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestWriteBufferCoalescesUpdates(t *testing.T) {
+	repo := repository.NewInMemoryRepository(zap.NewNop().Sugar())
+	service := NewMetricService(repo, WithWriteBuffer(time.Hour, 0))
+	ctx := context.Background()
+
+	for i := range 5 {
+		_, err := service.PushMetric(ctx, &entity.Metric{Name: "gauge", Type: "gauge", Value: float64(i)})
+		require.NoError(t, err)
+		_, err = service.PushMetric(ctx, &entity.Metric{Name: "counter", Type: "counter", Value: int64(1)})
+		require.NoError(t, err)
+	}
+
+	_, err := repo.Find(ctx, "gauge", "gauge")
+	assert.ErrorIs(t, err, repository.ErrNotFoundInRepo, "updates must not reach the repository before a flush")
+
+	counter, err := service.Pull(ctx, "counter", "counter")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), counter.Value, "reads must see buffered updates")
+
+	coalesced, err := service.Pull(ctx, "counter", selfMetricBufferCoalesced)
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), coalesced.Value)
+
+	require.NoError(t, service.Flush(ctx))
+
+	gauge, err := repo.Find(ctx, "gauge", "gauge")
+	require.NoError(t, err)
+	assert.Equal(t, 4.0, gauge.Value)
+	counter, err = repo.Find(ctx, "counter", "counter")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), counter.Value)
+}
+
+func TestWriteBufferFlushesWhenFull(t *testing.T) {
+	repo := new(MockRepository)
+	service := NewMetricService(repo, WithWriteBuffer(time.Hour, 2))
+	ctx := context.Background()
+
+	repo.On("Find", mock.Anything, mock.Anything, mock.Anything).Return(nil, repository.ErrNotFoundInRepo)
+	repo.On("UpdateBatch", mock.Anything, mock.Anything).Return(nil)
+
+	_, err := service.PushMetric(ctx, &entity.Metric{Name: "a", Type: "gauge", Value: 1.0})
+	require.NoError(t, err)
+	repo.AssertNotCalled(t, "UpdateBatch", mock.Anything, mock.Anything)
+
+	_, err = service.PushMetric(ctx, &entity.Metric{Name: "b", Type: "gauge", Value: 1.0})
+	require.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "UpdateBatch", 1)
+}
+
+func TestWriteBufferRetriesFailedFlush(t *testing.T) {
+	repo := new(MockRepository)
+	service := NewMetricService(repo, WithWriteBuffer(time.Hour, 0))
+	ctx := context.Background()
+
+	repo.On("Find", mock.Anything, mock.Anything, mock.Anything).Return(nil, repository.ErrNotFoundInRepo)
+	repo.On("UpdateBatch", mock.Anything, mock.Anything).Return(errors.New("db down")).Once()
+	repo.On("UpdateBatch", mock.Anything, mock.MatchedBy(func(m *entity.Metrics) bool {
+		return m.Length() == 1 && m.First().Value == 2.0
+	})).Return(nil).Once()
+
+	_, err := service.PushMetric(ctx, &entity.Metric{Name: "a", Type: "gauge", Value: 1.0})
+	require.NoError(t, err)
+	assert.Error(t, service.Flush(ctx))
+
+	_, err = service.PushMetric(ctx, &entity.Metric{Name: "a", Type: "gauge", Value: 2.0})
+	require.NoError(t, err)
+	require.NoError(t, service.Flush(ctx), "the newer value must replace the one that failed to flush")
+
+	flushErrors, err := service.Pull(ctx, "counter", selfMetricBufferFlushErrors)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), flushErrors.Value)
+	repo.AssertExpectations(t)
+}
+
+func TestWriteBufferAllAndDelete(t *testing.T) {
+	repo := repository.NewInMemoryRepository(zap.NewNop().Sugar())
+	require.NoError(t, repo.Update(context.Background(), &entity.Metric{Name: "stored", Type: "gauge", Value: 1.0}))
+	service := NewMetricService(repo, WithWriteBuffer(time.Hour, 0))
+	ctx := context.Background()
+
+	_, err := service.PushMetric(ctx, &entity.Metric{Name: "stored", Type: "gauge", Value: 2.0})
+	require.NoError(t, err)
+	_, err = service.PushMetric(ctx, &entity.Metric{Name: "buffered", Type: "gauge", Value: 3.0})
+	require.NoError(t, err)
+
+	all, err := service.buffer.All(ctx)
+	require.NoError(t, err)
+	values := make(map[string]any)
+	for _, m := range *all {
+		values[m.Name] = m.Value
+	}
+	assert.Equal(t, map[string]any{"stored": 2.0, "buffered": 3.0}, values)
+
+	require.NoError(t, service.Delete(ctx, "gauge", "buffered"), "buffered metrics must be deletable")
+	_, err = service.Pull(ctx, "gauge", "buffered")
+	assert.ErrorIs(t, err, ErrNotFoundInRepository)
+}
+
+func TestWriteBufferPullPage(t *testing.T) {
+	repo := repository.NewInMemoryRepository(zap.NewNop().Sugar())
+	require.NoError(t, repo.Update(context.Background(), &entity.Metric{Name: "stored", Type: "gauge", Value: 1.0}))
+	service := NewMetricService(repo, WithWriteBuffer(time.Hour, 0))
+	ctx := context.Background()
+
+	for _, flush := range []bool{false, true} {
+		_, err := service.PushMetric(ctx, &entity.Metric{Name: "buffered", Type: "gauge", Value: 3.0})
+		require.NoError(t, err)
+		if flush {
+			require.NoError(t, service.Flush(ctx))
+		}
+
+		all, err := service.PullAll(ctx)
+		require.NoError(t, err)
+		paged := make(entity.Metrics, 0, len(*all))
+		for offset := 0; ; offset += 2 {
+			page, err := service.PullPage(ctx, repository.PageQuery{Offset: offset, Limit: 2})
+			require.NoError(t, err)
+			require.Equal(t, len(*all), page.Total)
+			if len(page.Metrics) == 0 {
+				break
+			}
+			paged = append(paged, page.Metrics...)
+		}
+		assert.ElementsMatch(t, *all, paged, "paging must cover stored, buffered and self metrics once")
+	}
+
+	_, err := service.PullPage(ctx, repository.PageQuery{SortBy: "value"})
+	assert.ErrorIs(t, err, repository.ErrInvalidPageQuery)
+}
+
+func TestWriteBufferQuery(t *testing.T) {
+	repo := repository.NewInMemoryRepository(zap.NewNop().Sugar())
+	require.NoError(t, repo.Update(context.Background(), &entity.Metric{Name: "stored", Type: "gauge", Value: 1.0}))
+	service := NewMetricService(repo, WithWriteBuffer(time.Hour, 0))
+	ctx := context.Background()
+
+	_, err := service.PushMetric(ctx, &entity.Metric{Name: "stored", Type: "gauge", Value: 2.0})
+	require.NoError(t, err)
+	_, err = service.PushMetric(ctx, &entity.Metric{Name: "stream", Type: "counter", Value: int64(1)})
+	require.NoError(t, err)
+
+	for _, flush := range []bool{false, true} {
+		if flush {
+			require.NoError(t, service.Flush(ctx))
+		}
+		metrics, err := service.Query(ctx, repository.MetricFilter{NamePrefix: "st"})
+		require.NoError(t, err)
+		require.Len(t, *metrics, 2)
+		assert.Equal(t, 2.0, (*metrics)[0].Value, "buffered values must be queried")
+		assert.Equal(t, "stream", (*metrics)[1].Name)
+	}
+
+	coalesced, err := service.Query(ctx, repository.MetricFilter{NamePrefix: selfMetricBufferCoalesced})
+	require.NoError(t, err)
+	require.Len(t, *coalesced, 1, "self-metrics must be queried")
+}
+
+func TestWriteBufferStartFlushesPeriodically(t *testing.T) {
+	repo := repository.NewInMemoryRepository(zap.NewNop().Sugar())
+	service := NewMetricService(repo, WithWriteBuffer(10*time.Millisecond, 0))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go service.Start(ctx)
+
+	_, err := service.PushMetric(ctx, &entity.Metric{Name: "a", Type: "gauge", Value: 1.0})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, findErr := repo.Find(ctx, "gauge", "a")
+		return findErr == nil
+	}, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// Meta holds the free-form annotations attached to a metric by the people operating it.
+type Meta struct {
+	UpdatedAt time.Time `json:"updated_at"`        // UpdatedAt is the moment the annotations were last changed.
+	Owner     string    `json:"owner,omitempty"`   // Owner is the team responsible for the metric.
+	Runbook   string    `json:"runbook,omitempty"` // Runbook links to the instructions for acting on the metric.
+	Notes     string    `json:"notes,omitempty"`   // Notes is any other information about the metric.
+}
+
+// IsEmpty reports whether no annotation is set.
+//
+// Returns:
+//   - bool: True if the owner, runbook and notes are all empty.
+func (m *Meta) IsEmpty() bool {
+	return m.Owner == "" && m.Runbook == "" && m.Notes == ""
+}
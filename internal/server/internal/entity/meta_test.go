@@ -0,0 +1,16 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeta_IsEmpty(t *testing.T) {
+	assert.True(t, (&Meta{}).IsEmpty())
+	assert.True(t, (&Meta{UpdatedAt: time.Now()}).IsEmpty(), "the update time is not an annotation")
+	assert.False(t, (&Meta{Owner: "platform"}).IsEmpty())
+	assert.False(t, (&Meta{Runbook: "https://wiki/runbooks/heap"}).IsEmpty())
+	assert.False(t, (&Meta{Notes: "sampled every 2s"}).IsEmpty())
+}
@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gdyunin/metricol.git/pkg/convert"
 )
@@ -15,21 +16,91 @@ import (
 const (
 	// MetricTypeCounter defines the metric type for counters.
 	MetricTypeCounter = "counter"
+	// MetricTypeFloatCounter defines the metric type for counters of fractional quantities, such as CPU seconds.
+	MetricTypeFloatCounter = "fcounter"
 	// MetricTypeGauge defines the metric type for gauges.
 	MetricTypeGauge = "gauge"
+	// MetricTypeInfo defines the metric type for static facts such as version strings or feature flags.
+	MetricTypeInfo = "info"
 )
 
 // Metric represents a single metric with a name, type, and value.
 // It is used to encapsulate the measurement data.
 type Metric struct {
-	Value any    `json:"value"` // Value holds the metric's value.
-	Name  string `json:"name"`  // Name is the identifier of the metric.
-	Type  string `json:"type"`  // Type specifies the metric's category, e.g., "counter" or "gauge".
+	Timestamp time.Time `json:"timestamp"` // Timestamp is the moment the value was observed.
+	Value     any       `json:"value"`     // Value holds the metric's value.
+	Name      string    `json:"name"`      // Name is the identifier of the metric.
+	Type      string    `json:"type"`      // Type specifies the metric's category, e.g., "counter", "gauge" or "info".
+}
+
+// IsCounter reports whether metrics of a type accumulate the values they are updated with.
+//
+// Parameters:
+//   - metricType: The metric type.
+//
+// Returns:
+//   - bool: True for integer and float counters.
+func IsCounter(metricType string) bool {
+	return metricType == MetricTypeCounter || metricType == MetricTypeFloatCounter
+}
+
+// SumCounter adds two values of a counter: as int64 for counters and as float64 for float counters.
+//
+// Parameters:
+//   - metricType: The counter type.
+//   - a: The first value.
+//   - b: The second value.
+//
+// Returns:
+//   - any: The sum.
+//   - error: An error if a value is not numeric or the type is not a counter type.
+func SumCounter(metricType string, a, b any) (any, error) {
+	switch metricType {
+	case MetricTypeCounter:
+		x, err := convert.AnyToInt64(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid counter value: %w", err)
+		}
+		y, err := convert.AnyToInt64(b)
+		if err != nil {
+			return nil, fmt.Errorf("invalid counter value: %w", err)
+		}
+		return x + y, nil
+	case MetricTypeFloatCounter:
+		x, err := convert.AnyToFloat64(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float counter value: %w", err)
+		}
+		y, err := convert.AnyToFloat64(b)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float counter value: %w", err)
+		}
+		return x + y, nil
+	default:
+		return nil, fmt.Errorf("metric type %q is not a counter", metricType)
+	}
+}
+
+// IsInfoValue reports whether a value can be stored in an info metric.
+// Info metrics hold either a string or a boolean.
+//
+// Parameters:
+//   - value: The value to check.
+//
+// Returns:
+//   - bool: True if the value is a string or a boolean.
+func IsInfoValue(value any) bool {
+	switch value.(type) {
+	case string, bool:
+		return true
+	default:
+		return false
+	}
 }
 
 // UnmarshalJSON implements custom JSON unmarshalling for the Metric type.
 // It parses the JSON data into a Metric and performs type conversion for counter metrics.
-// If the metric is of type "counter", it converts the value to int64.
+// If the metric is of type "counter", it converts the value to int64; for "fcounter", to float64.
 //
 // Parameters:
 //   - data: A byte slice containing the JSON representation of a Metric.
@@ -61,10 +132,37 @@ func (m *Metric) UnmarshalJSON(data []byte) error {
 		}
 		m.Value = v
 	}
+	if m.Type == MetricTypeFloatCounter {
+		v, err := convert.AnyToFloat64(m.Value)
+		if err != nil {
+			return fmt.Errorf(
+				"invalid value for float counter metric \"%s\": expected number, got %T",
+				m.Name,
+				m.Value,
+			)
+		}
+		m.Value = v
+	}
 
 	return nil
 }
 
+// Float64 returns the value of a gauge or counter as a float64.
+//
+// Returns:
+//   - float64: The numeric value.
+//   - bool: False if the value is not numeric, as for info metrics.
+func (m *Metric) Float64() (float64, bool) {
+	switch v := m.Value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
 // Metrics represents a collection of Metric pointers.
 type Metrics []*Metric
 
@@ -120,7 +218,9 @@ func (m *Metrics) String() string {
 
 // MergeDuplicates merges duplicate metrics in the collection.
 // Two metrics are considered duplicates if they share the same name and type.
-// For counter metrics, their values are summed; for gauge metrics, the latest value replaces the previous one.
+// For counter and float counter metrics, their values are summed and the latest timestamp is kept; for gauge
+// and info metrics, the value with the latest timestamp wins, and among equal timestamps the one that comes
+// later in the batch.
 // The merged collection replaces the original one.
 //
 // Returns:
@@ -139,13 +239,17 @@ func (m *Metrics) MergeDuplicates() {
 
 		key := metric.Name + "|" + metric.Type
 		if existing, found := merged[key]; found {
-			if metric.Type == MetricTypeCounter {
-				existingVal, _ := convert.AnyToInt64(existing.Value)
-				repeatVal, _ := convert.AnyToInt64(metric.Value)
-				existing.Value = existingVal + repeatVal
-			} else {
-				// For gauge metrics, replace with the latest value.
+			if IsCounter(metric.Type) {
+				if sum, err := SumCounter(metric.Type, existing.Value, metric.Value); err == nil {
+					existing.Value = sum
+				}
+				if metric.Timestamp.After(existing.Timestamp) {
+					existing.Timestamp = metric.Timestamp
+				}
+			} else if !metric.Timestamp.Before(existing.Timestamp) {
+				// For gauge and info metrics, replace with the latest value.
 				existing.Value = metric.Value
+				existing.Timestamp = metric.Timestamp
 			}
 		} else {
 			merged[key] = metric
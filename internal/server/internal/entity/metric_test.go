@@ -3,8 +3,10 @@ package entity
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUnmarshalJSON(t *testing.T) {
@@ -15,6 +17,13 @@ func TestUnmarshalJSON(t *testing.T) {
 	}{
 		{name: "Valid counter", input: `{"name":"metric1","type":"counter","value":10}`},
 		{name: "Valid gauge", input: `{"name":"metric2","type":"gauge","value":3.14}`},
+		{name: "Valid info", input: `{"name":"version","type":"info","value":"v1.2.3"}`},
+		{name: "Valid float counter", input: `{"name":"cpu_seconds","type":"fcounter","value":0.25}`},
+		{
+			name:      "Invalid float counter value",
+			input:     `{"name":"cpu_seconds","type":"fcounter","value":"0.25"}`,
+			expectErr: true,
+		},
 		{
 			name:      "Invalid counter value",
 			input:     `{"name":"metric3","type":"counter","value":"invalid"}`,
@@ -124,3 +133,126 @@ func TestMergeDuplicates(t *testing.T) {
 		})
 	}
 }
+
+func TestIsInfoValue(t *testing.T) {
+	tests := []struct {
+		value    any
+		name     string
+		expected bool
+	}{
+		{name: "String", value: "v1.2.3", expected: true},
+		{name: "Empty string", value: "", expected: true},
+		{name: "Boolean", value: false, expected: true},
+		{name: "Float", value: 1.5, expected: false},
+		{name: "Integer", value: int64(1), expected: false},
+		{name: "Nil", value: nil, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsInfoValue(tt.value))
+		})
+	}
+}
+
+func TestMetric_Float64(t *testing.T) {
+	tests := []struct {
+		value      any
+		name       string
+		expected   float64
+		expectedOK bool
+	}{
+		{name: "Float", value: 1.5, expected: 1.5, expectedOK: true},
+		{name: "Integer", value: int64(3), expected: 3, expectedOK: true},
+		{name: "String", value: "v1.2.3"},
+		{name: "Nil", value: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := (&Metric{Value: tt.value}).Float64()
+			assert.Equal(t, tt.expectedOK, ok)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestMergeDuplicatesTimestamps(t *testing.T) {
+	early := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	late := early.Add(time.Minute)
+
+	tests := []struct {
+		expectedValue any
+		expectedTime  time.Time
+		name          string
+		metrics       Metrics
+	}{
+		{
+			name: "Later gauge sample wins regardless of order",
+			metrics: Metrics{
+				{Name: "load", Type: MetricTypeGauge, Value: 2.0, Timestamp: late},
+				{Name: "load", Type: MetricTypeGauge, Value: 1.0, Timestamp: early},
+			},
+			expectedValue: 2.0,
+			expectedTime:  late,
+		},
+		{
+			name: "Counter keeps latest timestamp",
+			metrics: Metrics{
+				{Name: "hits", Type: MetricTypeCounter, Value: int64(1), Timestamp: late},
+				{Name: "hits", Type: MetricTypeCounter, Value: int64(2), Timestamp: early},
+			},
+			expectedValue: int64(3),
+			expectedTime:  late,
+		},
+		{
+			name: "Float counter values are summed",
+			metrics: Metrics{
+				{Name: "cpu_seconds", Type: MetricTypeFloatCounter, Value: 0.25, Timestamp: early},
+				{Name: "cpu_seconds", Type: MetricTypeFloatCounter, Value: 0.5, Timestamp: late},
+			},
+			expectedValue: 0.75,
+			expectedTime:  late,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.metrics.MergeDuplicates()
+			require.Equal(t, 1, tt.metrics.Length())
+			assert.Equal(t, tt.expectedValue, tt.metrics.First().Value)
+			assert.Equal(t, tt.expectedTime, tt.metrics.First().Timestamp)
+		})
+	}
+}
+
+func TestSumCounter(t *testing.T) {
+	tests := []struct {
+		a          any
+		b          any
+		expected   any
+		name       string
+		metricType string
+		expectErr  bool
+	}{
+		{name: "Counter", metricType: MetricTypeCounter, a: int64(2), b: int64(3), expected: int64(5)},
+		{name: "Counter of decoded numbers", metricType: MetricTypeCounter, a: 2.0, b: int64(3), expected: int64(5)},
+		{name: "Float counter", metricType: MetricTypeFloatCounter, a: 0.5, b: 0.25, expected: 0.75},
+		{name: "Float counter of integers", metricType: MetricTypeFloatCounter, a: int64(1), b: 0.5, expected: 1.5},
+		{name: "Not numeric", metricType: MetricTypeFloatCounter, a: "1", b: 0.5, expectErr: true},
+		{name: "Not a counter", metricType: MetricTypeGauge, a: 1.0, b: 2.0, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sum, err := SumCounter(tt.metricType, tt.a, tt.b)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, sum)
+			assert.True(t, IsCounter(tt.metricType))
+		})
+	}
+}
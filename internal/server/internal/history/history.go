@@ -0,0 +1,141 @@
+// Package history keeps the recent samples of counters in memory, so rates can be computed over a window
+// even though the repositories only store the latest value of every metric.
+package history
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/internal/selfmetric"
+	"github.com/gdyunin/metricol.git/pkg/convert"
+)
+
+const (
+	// Const selfMetricSamples is the self-metric exposing the number of samples held.
+	selfMetricSamples = "metricol_history_samples"
+	// Const defaultMaxSamples bounds the samples kept per counter, so frequent updates cannot grow
+	// the history without limit within the retention.
+	defaultMaxSamples = 4096
+)
+
+// Sample is a counter value observed at a point in time.
+type Sample struct {
+	Time  time.Time // Time is the moment the value was observed.
+	Value float64   // Value is the counter total.
+}
+
+// Store holds the samples of every counter observed within the retention.
+type Store struct {
+	series     map[string][]Sample // series maps a counter name to its samples in time order.
+	now        func() time.Time    // now reads the clock the retention is measured against.
+	mu         *sync.RWMutex       // mu protects series.
+	retention  time.Duration       // retention is how long samples are kept.
+	maxSamples int                 // maxSamples limits the samples kept per counter.
+}
+
+// NewStore creates a Store keeping samples for the retention.
+//
+// Parameters:
+//   - retention: How long samples are kept.
+//
+// Returns:
+//   - *Store: A pointer to the created Store.
+func NewStore(retention time.Duration) *Store {
+	return &Store{
+		series:     make(map[string][]Sample),
+		now:        time.Now,
+		mu:         &sync.RWMutex{},
+		retention:  retention,
+		maxSamples: defaultMaxSamples,
+	}
+}
+
+// Retention returns how long samples are kept.
+//
+// Returns:
+//   - time.Duration: The retention.
+func (s *Store) Retention() time.Duration {
+	return s.retention
+}
+
+// Record keeps the counters and float counters of a stored batch and drops the samples that left
+// the retention. Other metric types are ignored.
+//
+// Parameters:
+//   - metrics: The stored metrics.
+func (s *Store) Record(metrics entity.Metrics) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := s.now().Add(-s.retention)
+	for _, m := range metrics {
+		if !entity.IsCounter(m.Type) {
+			continue
+		}
+		v, err := convert.AnyToFloat64(m.Value)
+		if err != nil {
+			continue
+		}
+		s.series[m.Name] = s.insert(s.series[m.Name], Sample{Time: m.Timestamp, Value: v}, cutoff)
+	}
+}
+
+// insert adds a sample in time order and trims the samples before the cutoff and beyond maxSamples.
+func (s *Store) insert(samples []Sample, sample Sample, cutoff time.Time) []Sample {
+	i := sort.Search(len(samples), func(i int) bool { return samples[i].Time.After(sample.Time) })
+	samples = append(samples, Sample{})
+	copy(samples[i+1:], samples[i:])
+	samples[i] = sample
+
+	start := sort.Search(len(samples), func(i int) bool { return !samples[i].Time.Before(cutoff) })
+	start = max(start, len(samples)-s.maxSamples)
+	if start > 0 {
+		samples = append(samples[:0:0], samples[start:]...)
+	}
+	return samples
+}
+
+// Range returns the samples of a counter observed since the given time.
+//
+// Parameters:
+//   - name: The counter name.
+//   - from: The earliest sample time returned.
+//
+// Returns:
+//   - []Sample: A copy of the samples in time order; empty if the counter has none.
+func (s *Store) Range(name string, from time.Time) []Sample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	samples := s.series[name]
+	start := sort.Search(len(samples), func(i int) bool { return !samples[i].Time.Before(from) })
+	return append([]Sample(nil), samples[start:]...)
+}
+
+// Forget drops the samples of a counter, e.g. after it is deleted.
+//
+// Parameters:
+//   - name: The counter name.
+func (s *Store) Forget(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.series, name)
+}
+
+// RegisterSelfMetrics exposes the number of samples held as a self-metric.
+//
+// Parameters:
+//   - r: The registry receiving the self-metric.
+func (s *Store) RegisterSelfMetrics(r *selfmetric.Registry) {
+	r.RegisterGauge(selfMetricSamples, func() float64 {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		total := 0
+		for _, samples := range s.series {
+			total += len(samples)
+		}
+		return float64(total)
+	})
+}
@@ -0,0 +1,93 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/internal/selfmetric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var base = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func counter(name string, value int64, at time.Duration) *entity.Metric {
+	return &entity.Metric{Name: name, Type: entity.MetricTypeCounter, Value: value, Timestamp: base.Add(at)}
+}
+
+func newTestStore(retention time.Duration, now time.Duration) *Store {
+	s := NewStore(retention)
+	s.now = func() time.Time { return base.Add(now) }
+	return s
+}
+
+func TestStore_RecordAndRange(t *testing.T) {
+	s := newTestStore(time.Hour, time.Minute)
+	s.Record(entity.Metrics{
+		counter("PollCount", 1, 0),
+		{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.0, Timestamp: base},
+	})
+	s.Record(entity.Metrics{counter("PollCount", 3, 20*time.Second)})
+	s.Record(entity.Metrics{counter("PollCount", 2, 10*time.Second)})
+
+	assert.Equal(t, []Sample{
+		{Time: base, Value: 1},
+		{Time: base.Add(10 * time.Second), Value: 2},
+		{Time: base.Add(20 * time.Second), Value: 3},
+	}, s.Range("PollCount", base), "samples are kept in time order")
+	assert.Len(t, s.Range("PollCount", base.Add(5*time.Second)), 2)
+	assert.Empty(t, s.Range("Alloc", base), "gauges are not recorded")
+	assert.Empty(t, s.Range("Missing", base))
+}
+
+func TestStore_RecordFloatCounter(t *testing.T) {
+	s := newTestStore(time.Hour, time.Minute)
+	s.Record(entity.Metrics{
+		{Name: "cpu_seconds", Type: entity.MetricTypeFloatCounter, Value: 0.25, Timestamp: base},
+		{Name: "cpu_seconds", Type: entity.MetricTypeFloatCounter, Value: 1.75, Timestamp: base.Add(time.Second)},
+	})
+
+	assert.Equal(t, []Sample{
+		{Time: base, Value: 0.25},
+		{Time: base.Add(time.Second), Value: 1.75},
+	}, s.Range("cpu_seconds", base))
+}
+
+func TestStore_Retention(t *testing.T) {
+	s := newTestStore(time.Minute, 2*time.Minute)
+	s.Record(entity.Metrics{counter("PollCount", 1, 0)})
+	s.Record(entity.Metrics{counter("PollCount", 2, 90*time.Second)})
+
+	samples := s.Range("PollCount", time.Time{})
+	require.Len(t, samples, 1)
+	assert.InDelta(t, 2.0, samples[0].Value, 1e-9)
+}
+
+func TestStore_MaxSamples(t *testing.T) {
+	s := newTestStore(time.Hour, time.Minute)
+	s.maxSamples = 3
+	for i := range 5 {
+		s.Record(entity.Metrics{counter("PollCount", int64(i), time.Duration(i)*time.Second)})
+	}
+
+	samples := s.Range("PollCount", time.Time{})
+	require.Len(t, samples, 3)
+	assert.InDelta(t, 2.0, samples[0].Value, 1e-9)
+}
+
+func TestStore_ForgetAndSelfMetrics(t *testing.T) {
+	s := newTestStore(time.Hour, time.Minute)
+	registry := selfmetric.NewRegistry()
+	s.RegisterSelfMetrics(registry)
+
+	s.Record(entity.Metrics{counter("a", 1, 0), counter("b", 1, 0), counter("b", 2, time.Second)})
+	m, ok := registry.Find(entity.MetricTypeGauge, selfMetricSamples)
+	require.True(t, ok)
+	assert.InDelta(t, 3.0, m.Value, 1e-9)
+
+	s.Forget("b")
+	assert.Empty(t, s.Range("b", time.Time{}))
+	m, _ = registry.Find(entity.MetricTypeGauge, selfMetricSamples)
+	assert.InDelta(t, 1.0, m.Value, 1e-9)
+}
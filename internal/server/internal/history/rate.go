@@ -0,0 +1,45 @@
+package history
+
+import "time"
+
+// Rate is the per-second rate of a counter over its samples.
+type Rate struct {
+	From     time.Time // From is the time of the first sample.
+	To       time.Time // To is the time of the last sample.
+	PerSec   float64   // PerSec is the increase divided by the time between the first and the last sample.
+	Increase float64   // Increase is the growth of the counter, corrected for resets.
+	Resets   int       // Resets counts the samples lower than their predecessors.
+}
+
+// ComputeRate computes the rate of a counter from its samples in time order. A sample lower than
+// its predecessor is a reset: the counter restarted from zero, so the sample value itself is the growth
+// since the reset.
+//
+// Parameters:
+//   - samples: The samples in time order.
+//
+// Returns:
+//   - Rate: The computed rate.
+//   - bool: False if there are fewer than two samples or they were observed at the same time.
+func ComputeRate(samples []Sample) (Rate, bool) {
+	if len(samples) < 2 {
+		return Rate{}, false
+	}
+	first, last := samples[0], samples[len(samples)-1]
+	elapsed := last.Time.Sub(first.Time).Seconds()
+	if elapsed <= 0 {
+		return Rate{}, false
+	}
+
+	r := Rate{From: first.Time, To: last.Time}
+	for i := 1; i < len(samples); i++ {
+		delta := samples[i].Value - samples[i-1].Value
+		if delta < 0 {
+			r.Resets++
+			delta = samples[i].Value
+		}
+		r.Increase += delta
+	}
+	r.PerSec = r.Increase / elapsed
+	return r, true
+}
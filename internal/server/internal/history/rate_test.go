@@ -0,0 +1,45 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeRate(t *testing.T) {
+	at := func(seconds int, value float64) Sample {
+		return Sample{Time: base.Add(time.Duration(seconds) * time.Second), Value: value}
+	}
+
+	tests := []struct {
+		name     string
+		samples  []Sample
+		expected Rate
+		ok       bool
+	}{
+		{
+			name:     "Steady growth",
+			samples:  []Sample{at(0, 10), at(10, 20), at(20, 30)},
+			expected: Rate{From: base, To: base.Add(20 * time.Second), PerSec: 1, Increase: 20},
+			ok:       true,
+		},
+		{
+			name:     "Reset",
+			samples:  []Sample{at(0, 10), at(10, 30), at(20, 5), at(30, 25)},
+			expected: Rate{From: base, To: base.Add(30 * time.Second), PerSec: 1.5, Increase: 45, Resets: 1},
+			ok:       true,
+		},
+		{name: "Single sample", samples: []Sample{at(0, 10)}},
+		{name: "No samples"},
+		{name: "Same time", samples: []Sample{at(0, 10), at(0, 20)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, ok := ComputeRate(tt.samples)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, rate)
+		})
+	}
+}
@@ -0,0 +1,309 @@
+// Package quota enforces soft per-key quotas on metric updates. Clients present an API key in the
+// X-API-Key header; every configured key has limits on the number of distinct metrics it writes,
+// the metrics it updates per minute and the metrics in a single request. Usage is tracked per key
+// so operators can see which tenant is approaching its limits.
+package quota
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/internal/selfmetric"
+)
+
+const (
+	// Const AnyKey configures the limits shared by requests whose key is missing or not configured.
+	AnyKey = "*"
+	// Const QuotaMetrics names the limit on distinct metrics written with a key.
+	QuotaMetrics = "metrics"
+	// Const QuotaUpdates names the limit on metrics updated with a key per minute.
+	QuotaUpdates = "updates"
+	// Const QuotaBatch names the limit on metrics in a single request.
+	QuotaBatch = "batch"
+	// Const selfMetricRejected counts update requests rejected because of a quota.
+	selfMetricRejected = "metricol_quota_rejected"
+	// Const keyIDLength is the number of hex digits of the key hash identifying a key in reports.
+	keyIDLength = 12
+)
+
+var (
+	// ErrQuotaExceeded is returned when a request would exceed the metrics or updates quota of its key.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrBatchTooLarge is returned when a request carries more metrics than the batch quota of its key.
+	ErrBatchTooLarge = errors.New("batch size quota exceeded")
+)
+
+// quotaUnits describes what every quota counts.
+var quotaUnits = map[string]string{
+	QuotaMetrics: "distinct metrics",
+	QuotaUpdates: "metric updates per minute",
+	QuotaBatch:   "metrics per request",
+}
+
+// Limits are the quotas of a key. Zero or less leaves a quota unlimited.
+type Limits struct {
+	Metrics          int `json:"metrics"`            // Metrics caps the distinct metrics written with the key.
+	UpdatesPerMinute int `json:"updates_per_minute"` // UpdatesPerMinute caps the metrics updated per minute.
+	BatchSize        int `json:"batch_size"`         // BatchSize caps the metrics in a single request.
+}
+
+// Error describes which quota rejected a request.
+type Error struct {
+	KeyID string // KeyID identifies the key whose quota was hit.
+	Quota string // Quota is QuotaMetrics, QuotaUpdates or QuotaBatch.
+	Limit int    // Limit is the quota that was hit.
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: key %s allows at most %d %s", e.Unwrap(), e.KeyID, e.Limit, quotaUnits[e.Quota])
+}
+
+// Unwrap allows errors.Is to match ErrBatchTooLarge or ErrQuotaExceeded.
+func (e *Error) Unwrap() error {
+	if e.Quota == QuotaBatch {
+		return ErrBatchTooLarge
+	}
+	return ErrQuotaExceeded
+}
+
+// Usage reports the use a key makes of its quotas.
+type Usage struct {
+	KeyID             string `json:"key_id"`              // KeyID identifies the key without revealing it.
+	Limits            Limits `json:"limits"`              // Limits are the quotas of the key.
+	Metrics           int    `json:"metrics"`             // Metrics is the number of distinct metrics written.
+	UpdatesThisMinute int    `json:"updates_this_minute"` // UpdatesThisMinute counts metrics updated this minute.
+	Updates           int64  `json:"updates"`             // Updates is the number of metrics updated since start.
+	Rejected          int64  `json:"rejected"`            // Rejected is the number of rejected requests.
+}
+
+// usage holds the counters of a configured key.
+type usage struct {
+	metrics     map[string]struct{} // metrics holds the keys of the distinct metrics written.
+	windowStart time.Time           // windowStart is the start of the current minute.
+	window      int                 // window is the number of metrics updated in the current minute.
+	updates     int64               // updates is the number of metrics updated since start.
+	rejected    int64               // rejected is the number of rejected requests.
+}
+
+// Tracker enforces the quotas of the configured keys.
+type Tracker struct {
+	limits   map[string]Limits // limits maps a configured key to its quotas.
+	usage    map[string]*usage // usage maps a configured key to its counters.
+	now      func() time.Time  // now reads the clock the update windows are measured against.
+	mu       *sync.Mutex       // mu protects usage.
+	rejected int64             // rejected counts all rejected requests.
+}
+
+// NewTracker creates a Tracker for the given keys. Requests whose key is missing or not configured share
+// the limits of AnyKey if it is configured and are not limited otherwise.
+//
+// Parameters:
+//   - limits: The quotas by API key.
+//
+// Returns:
+//   - *Tracker: A pointer to the created Tracker.
+func NewTracker(limits map[string]Limits) *Tracker {
+	t := &Tracker{
+		limits: limits,
+		usage:  make(map[string]*usage, len(limits)),
+		now:    time.Now,
+		mu:     &sync.Mutex{},
+	}
+	for key := range limits {
+		t.usage[key] = &usage{metrics: make(map[string]struct{})}
+	}
+	return t
+}
+
+// Admission records the use an admitted request made of its quotas, so it can be released if the request
+// fails later.
+type Admission struct {
+	windowStart time.Time // windowStart is the update window the request was counted in.
+	key         string    // key is the configured key whose quotas were used.
+	added       []string  // added holds the metrics the request added to the distinct metrics of the key.
+	updates     int       // updates is the number of metrics the request counted as updated.
+}
+
+// Admit checks a request against the quotas of its key and records its use if it is admitted.
+// A rejected request changes no counter except the number of rejections.
+//
+// Parameters:
+//   - key: The API key of the request; empty if it has none.
+//   - batch: The metrics of the request.
+//
+// Returns:
+//   - *Admission: The recorded use, nil if the key has no quotas.
+//   - error: An *Error matching ErrBatchTooLarge or ErrQuotaExceeded if a quota would be exceeded.
+func (t *Tracker) Admit(key string, batch entity.Metrics) (*Admission, error) {
+	if _, ok := t.limits[key]; !ok {
+		key = AnyKey
+	}
+	limits, ok := t.limits[key]
+	if !ok {
+		return nil, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	u := t.usage[key]
+	if err := t.check(key, limits, u, batch); err != nil {
+		u.rejected++
+		t.rejected++
+		return nil, err
+	}
+
+	admission := &Admission{windowStart: u.windowStart, key: key, updates: len(batch)}
+	for _, m := range batch {
+		mk := m.Type + "|" + m.Name
+		if _, known := u.metrics[mk]; !known {
+			u.metrics[mk] = struct{}{}
+			admission.added = append(admission.added, mk)
+		}
+	}
+	u.window += len(batch)
+	u.updates += int64(len(batch))
+	return admission, nil
+}
+
+// Release gives back the use recorded by Admit for a request that failed after it was admitted.
+//
+// Parameters:
+//   - admission: The use to give back; nil is ignored.
+func (t *Tracker) Release(admission *Admission) {
+	if admission == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	u := t.usage[admission.key]
+	for _, mk := range admission.added {
+		delete(u.metrics, mk)
+	}
+	if u.windowStart.Equal(admission.windowStart) {
+		u.window -= admission.updates
+	}
+	u.updates -= int64(admission.updates)
+}
+
+// check reports the quota the batch would exceed. It starts a new update window when the minute
+// has passed. The caller must hold mu.
+func (t *Tracker) check(key string, limits Limits, u *usage, batch entity.Metrics) error {
+	if limits.BatchSize > 0 && len(batch) > limits.BatchSize {
+		return &Error{KeyID: KeyID(key), Quota: QuotaBatch, Limit: limits.BatchSize}
+	}
+
+	if now := t.now().Truncate(time.Minute); !now.Equal(u.windowStart) {
+		u.windowStart = now
+		u.window = 0
+	}
+	if limits.UpdatesPerMinute > 0 && u.window+len(batch) > limits.UpdatesPerMinute {
+		return &Error{KeyID: KeyID(key), Quota: QuotaUpdates, Limit: limits.UpdatesPerMinute}
+	}
+
+	if limits.Metrics > 0 {
+		added := make(map[string]struct{})
+		for _, m := range batch {
+			mk := m.Type + "|" + m.Name
+			if _, known := u.metrics[mk]; !known {
+				added[mk] = struct{}{}
+			}
+		}
+		if len(u.metrics)+len(added) > limits.Metrics {
+			return &Error{KeyID: KeyID(key), Quota: QuotaMetrics, Limit: limits.Metrics}
+		}
+	}
+	return nil
+}
+
+// Usage reports the use of every configured key, ordered by key identifier.
+//
+// Returns:
+//   - []Usage: The usage of the configured keys.
+func (t *Tracker) Usage() []Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	window := t.now().Truncate(time.Minute)
+	report := make([]Usage, 0, len(t.usage))
+	for key, u := range t.usage {
+		entry := Usage{
+			KeyID:    KeyID(key),
+			Limits:   t.limits[key],
+			Metrics:  len(u.metrics),
+			Updates:  u.updates,
+			Rejected: u.rejected,
+		}
+		if u.windowStart.Equal(window) {
+			entry.UpdatesThisMinute = u.window
+		}
+		report = append(report, entry)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].KeyID < report[j].KeyID })
+	return report
+}
+
+// RegisterSelfMetrics exposes the number of rejected requests as a self-metric.
+//
+// Parameters:
+//   - r: The registry to register the metric in.
+func (t *Tracker) RegisterSelfMetrics(r *selfmetric.Registry) {
+	r.RegisterCounter(selfMetricRejected, func() int64 {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return t.rejected
+	})
+}
+
+// KeyID identifies a key in reports and errors without revealing it: AnyKey stays as is and other keys
+// are replaced with the first hex digits of their SHA-256 hash.
+//
+// Parameters:
+//   - key: The API key.
+//
+// Returns:
+//   - string: The key identifier.
+func KeyID(key string) string {
+	if key == AnyKey {
+		return AnyKey
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:keyIDLength]
+}
+
+// keyContextKey is the context key holding the API key of a request.
+type keyContextKey struct{}
+
+// WithKey returns a copy of the context carrying the API key of the request.
+//
+// Parameters:
+//   - ctx: The parent context.
+//   - key: The API key.
+//
+// Returns:
+//   - context.Context: The context carrying the key.
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyContextKey{}, key)
+}
+
+// KeyFrom returns the API key carried by the context.
+//
+// Parameters:
+//   - ctx: The context of the request.
+//
+// Returns:
+//   - string: The API key, empty if the context carries none.
+func KeyFrom(ctx context.Context) string {
+	key, _ := ctx.Value(keyContextKey{}).(string)
+	return key
+}
@@ -0,0 +1,139 @@
+package quota
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/internal/selfmetric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gauges(names ...string) entity.Metrics {
+	batch := make(entity.Metrics, 0, len(names))
+	for _, name := range names {
+		batch = append(batch, &entity.Metric{Name: name, Type: entity.MetricTypeGauge, Value: 1.0})
+	}
+	return batch
+}
+
+// admit admits a batch and returns only the error.
+func admit(tracker *Tracker, key string, batch entity.Metrics) error {
+	_, err := tracker.Admit(key, batch)
+	return err
+}
+
+func TestTracker_BatchSize(t *testing.T) {
+	tracker := NewTracker(map[string]Limits{"team-a": {BatchSize: 2}})
+
+	assert.NoError(t, admit(tracker, "team-a", gauges("a", "b")))
+	err := admit(tracker, "team-a", gauges("a", "b", "c"))
+	assert.ErrorIs(t, err, ErrBatchTooLarge)
+	assert.NotErrorIs(t, err, ErrQuotaExceeded)
+	assert.NoError(t, admit(tracker, "team-b", gauges("a", "b", "c")), "keys without quotas are not limited")
+}
+
+func TestTracker_UpdatesPerMinute(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 10, 0, time.UTC)
+	tracker := NewTracker(map[string]Limits{"team-a": {UpdatesPerMinute: 3}})
+	tracker.now = func() time.Time { return now }
+
+	require.NoError(t, admit(tracker, "team-a", gauges("a", "b")))
+	assert.ErrorIs(t, admit(tracker, "team-a", gauges("a", "b")), ErrQuotaExceeded)
+	require.NoError(t, admit(tracker, "team-a", gauges("a")), "the rejected batch was not counted")
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, admit(tracker, "team-a", gauges("a", "b", "c")), "a new minute starts a new window")
+}
+
+func TestTracker_Metrics(t *testing.T) {
+	tracker := NewTracker(map[string]Limits{"team-a": {Metrics: 2}})
+
+	require.NoError(t, admit(tracker, "team-a", gauges("a", "b")))
+	require.NoError(t, admit(tracker, "team-a", gauges("a", "b")), "known metrics can be updated")
+
+	var quotaErr *Error
+	require.ErrorAs(t, admit(tracker, "team-a", gauges("c")), &quotaErr)
+	assert.Equal(t, QuotaMetrics, quotaErr.Quota)
+	assert.Equal(t, 2, quotaErr.Limit)
+	assert.Equal(t, KeyID("team-a"), quotaErr.KeyID)
+	assert.NotContains(t, quotaErr.Error(), "team-a", "errors do not reveal the key")
+}
+
+func TestTracker_AnyKey(t *testing.T) {
+	tracker := NewTracker(map[string]Limits{AnyKey: {Metrics: 2}, "team-a": {}})
+
+	require.NoError(t, admit(tracker, "", gauges("a")))
+	require.NoError(t, admit(tracker, "unknown", gauges("b")))
+	assert.ErrorIs(t, admit(tracker, "", gauges("c")), ErrQuotaExceeded, "unconfigured keys share the quota")
+	assert.NoError(t, admit(tracker, "team-a", gauges("c", "d", "e")), "configured keys use their own quotas")
+}
+
+func TestTracker_Release(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 10, 0, time.UTC)
+	tracker := NewTracker(map[string]Limits{"team-a": {Metrics: 2, UpdatesPerMinute: 3}})
+	tracker.now = func() time.Time { return now }
+
+	require.NoError(t, admit(tracker, "team-a", gauges("a")))
+	admission, err := tracker.Admit("team-a", gauges("a", "b"))
+	require.NoError(t, err)
+	tracker.Release(admission)
+	tracker.Release(nil)
+
+	usage := tracker.Usage()
+	require.Len(t, usage, 1)
+	assert.Equal(t, 1, usage[0].Metrics, "metrics known before the request are kept")
+	assert.Equal(t, 1, usage[0].UpdatesThisMinute)
+	assert.Equal(t, int64(1), usage[0].Updates)
+	assert.NoError(t, admit(tracker, "team-a", gauges("a", "c")), "the released use is available again")
+
+	noQuota, err := tracker.Admit("team-b", gauges("a"))
+	require.NoError(t, err)
+	assert.Nil(t, noQuota, "keys without quotas record no use")
+}
+
+func TestTracker_Usage(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 10, 0, time.UTC)
+	limits := Limits{Metrics: 10, UpdatesPerMinute: 5, BatchSize: 3}
+	tracker := NewTracker(map[string]Limits{"team-a": limits, AnyKey: {}})
+	tracker.now = func() time.Time { return now }
+
+	require.NoError(t, admit(tracker, "team-a", gauges("a", "b")))
+	require.NoError(t, admit(tracker, "team-a", gauges("a")))
+	require.Error(t, admit(tracker, "team-a", gauges("a", "b", "c", "d")))
+
+	registry := selfmetric.NewRegistry()
+	tracker.RegisterSelfMetrics(registry)
+	rejected, ok := registry.Find(entity.MetricTypeCounter, selfMetricRejected)
+	require.True(t, ok)
+	assert.Equal(t, int64(1), rejected.Value)
+
+	now = now.Add(time.Minute)
+	usage := tracker.Usage()
+	require.Len(t, usage, 2)
+	assert.Equal(t, Usage{KeyID: AnyKey}, usage[0])
+	assert.Equal(t, Usage{
+		KeyID:    KeyID("team-a"),
+		Limits:   limits,
+		Metrics:  2,
+		Updates:  3,
+		Rejected: 1,
+	}, usage[1], "the last window is over")
+}
+
+func TestKeyID(t *testing.T) {
+	assert.Equal(t, AnyKey, KeyID(AnyKey))
+	id := KeyID("secret")
+	assert.Len(t, id, keyIDLength)
+	assert.NotEqual(t, id, KeyID("secret2"))
+	_, err := strconv.ParseUint(id, 16, 64)
+	assert.NoError(t, err)
+}
+
+func TestKeyContext(t *testing.T) {
+	assert.Empty(t, KeyFrom(context.Background()))
+	assert.Equal(t, "team-a", KeyFrom(WithKey(context.Background(), "team-a")))
+}
@@ -0,0 +1,135 @@
+// Package ratelimit caps the request rate of every client with a token bucket per client, so bursty agents
+// cannot overwhelm the storage at peak times. Buckets of clients that stay idle long enough to refill
+// are dropped, so the number of tracked clients follows the number of active ones.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/selfmetric"
+	"github.com/gdyunin/metricol.git/pkg/clock"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// Const selfMetricRejected counts requests rejected because of the client rate limit.
+	selfMetricRejected = "metricol_rate_limit_rejected"
+	// Const selfMetricClients reports the number of clients with a token bucket.
+	selfMetricClients = "metricol_rate_limit_clients"
+	// Const sweepInterval is the minimum period between scans for idle buckets.
+	sweepInterval = time.Minute
+)
+
+// bucket is the token bucket of a client.
+type bucket struct {
+	lastSeen time.Time     // lastSeen is when the client last sent a request.
+	limiter  *rate.Limiter // limiter holds the tokens of the client.
+}
+
+// Limiter keeps a token bucket per client.
+type Limiter struct {
+	lastSweep time.Time          // lastSweep is when idle buckets were last dropped.
+	clock     clock.Clock        // clock provides the current time.
+	buckets   map[string]*bucket // buckets maps a client to its token bucket.
+	mu        *sync.Mutex        // mu protects buckets, lastSweep and rejected.
+	idle      time.Duration      // idle is how long a bucket takes to refill; idler buckets are dropped.
+	limit     rate.Limit         // limit is the number of requests per second allowed to a client.
+	burst     int                // burst is the number of requests a client may send at once.
+	rejected  int64              // rejected counts requests rejected because of the limit.
+}
+
+// NewLimiter creates a Limiter.
+//
+// Parameters:
+//   - perSecond: The sustained number of requests per second allowed to every client.
+//   - burst: The number of requests a client may send at once; values below one are raised to one.
+//
+// Returns:
+//   - *Limiter: A pointer to the created Limiter.
+func NewLimiter(perSecond float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	clk := clock.Real()
+	return &Limiter{
+		lastSweep: clk.Now(),
+		clock:     clk,
+		buckets:   make(map[string]*bucket),
+		mu:        &sync.Mutex{},
+		idle:      time.Duration(float64(burst) / perSecond * float64(time.Second)),
+		limit:     rate.Limit(perSecond),
+		burst:     burst,
+	}
+}
+
+// Allow takes a token from the bucket of the client.
+//
+// Parameters:
+//   - client: The client identifier, such as its IP address.
+//
+// Returns:
+//   - bool: False if the client has exhausted its bucket and the request should be rejected.
+//   - time.Duration: How long the client should wait before retrying; zero if the request is allowed.
+func (l *Limiter) Allow(client string) (bool, time.Duration) {
+	now := l.clock.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.buckets[client] = b
+	}
+	b.lastSeen = now
+
+	reservation := b.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		l.rejected++
+		return false, delay
+	}
+	return true, 0
+}
+
+// Clients returns the number of clients with a token bucket.
+//
+// Returns:
+//   - int: The number of tracked clients.
+func (l *Limiter) Clients() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+// RegisterSelfMetrics exposes the rejection count and the number of tracked clients as self-metrics.
+//
+// Parameters:
+//   - r: The registry to register the metrics in.
+func (l *Limiter) RegisterSelfMetrics(r *selfmetric.Registry) {
+	r.RegisterCounter(selfMetricRejected, func() int64 {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return l.rejected
+	})
+	r.RegisterGauge(selfMetricClients, func() float64 {
+		return float64(l.Clients())
+	})
+}
+
+// sweep drops the buckets of clients idle long enough for their bucket to refill; mu must be held.
+// Such a bucket is full, so a client coming back gets the same tokens from a new one.
+func (l *Limiter) sweep(now time.Time) {
+	l.lastSweep = now
+	for client, b := range l.buckets {
+		if now.Sub(b.lastSeen) > l.idle {
+			delete(l.buckets, client)
+		}
+	}
+}
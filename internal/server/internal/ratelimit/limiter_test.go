@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/selfmetric"
+	"github.com/gdyunin/metricol.git/pkg/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLimiter creates a Limiter driven by a fake clock.
+func newTestLimiter(perSecond float64, burst int) (*Limiter, *clock.Fake) {
+	clk := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	l := NewLimiter(perSecond, burst)
+	l.clock = clk
+	l.lastSweep = clk.Now()
+	return l, clk
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	l, clk := newTestLimiter(2, 3)
+
+	for range 3 {
+		ok, delay := l.Allow("10.0.0.1")
+		require.True(t, ok)
+		assert.Zero(t, delay)
+	}
+	ok, delay := l.Allow("10.0.0.1")
+	require.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, delay)
+
+	// Other clients have their own bucket.
+	ok, _ = l.Allow("10.0.0.2")
+	assert.True(t, ok)
+
+	// A rejected request does not consume a token.
+	clk.Advance(500 * time.Millisecond)
+	ok, _ = l.Allow("10.0.0.1")
+	assert.True(t, ok)
+	ok, _ = l.Allow("10.0.0.1")
+	assert.False(t, ok)
+}
+
+func TestLimiter_DropsIdleBuckets(t *testing.T) {
+	l, clk := newTestLimiter(10, 10)
+
+	l.Allow("10.0.0.1")
+	l.Allow("10.0.0.2")
+	require.Equal(t, 2, l.Clients())
+
+	clk.Advance(sweepInterval)
+	l.Allow("10.0.0.2")
+	assert.Equal(t, 1, l.Clients())
+}
+
+func TestLimiter_RegisterSelfMetrics(t *testing.T) {
+	l, _ := newTestLimiter(1, 1)
+	r := selfmetric.NewRegistry()
+	l.RegisterSelfMetrics(r)
+
+	l.Allow("10.0.0.1")
+	l.Allow("10.0.0.1")
+
+	rejected, ok := r.Find("counter", selfMetricRejected)
+	require.True(t, ok)
+	assert.Equal(t, int64(1), rejected.Value)
+	clients, ok := r.Find("gauge", selfMetricClients)
+	require.True(t, ok)
+	assert.InDelta(t, 1.0, clients.Value, 1e-9)
+}
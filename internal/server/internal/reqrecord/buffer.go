@@ -0,0 +1,97 @@
+// Package reqrecord keeps the most recent recorded requests and responses in a fixed-size ring buffer,
+// so interoperability issues with agents can be inspected after the fact.
+package reqrecord
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Record holds a captured request together with the response it was answered with.
+type Record struct {
+	Time            time.Time   `json:"time"`                // Time is when the request was received.
+	RequestHeaders  http.Header `json:"request_headers"`     // RequestHeaders are the request headers.
+	ResponseHeaders http.Header `json:"response_headers"`    // ResponseHeaders are the response headers.
+	ID              string      `json:"id"`                  // ID is the request ID.
+	Method          string      `json:"method"`              // Method is the request method.
+	Path            string      `json:"path"`                // Path is the request path with the query.
+	RequestBody     string      `json:"request_body"`        // RequestBody is the decoded request body.
+	ResponseBody    string      `json:"response_body"`       // ResponseBody is the uncompressed response body.
+	DurationSeconds float64     `json:"duration_seconds"`    // DurationSeconds is the handling time.
+	Status          int         `json:"status"`              // Status is the response status code.
+	Truncated       bool        `json:"truncated,omitempty"` // Truncated reports that a body was cut short.
+}
+
+// Buffer is a concurrency-safe ring buffer of records; once full, the oldest record is overwritten.
+type Buffer struct {
+	records []Record    // records holds the stored records.
+	mu      *sync.Mutex // mu protects records and next.
+	next    int         // next is the index the next record is written to.
+	full    bool        // full reports that every slot holds a record.
+}
+
+// NewBuffer creates a Buffer keeping at most capacity records. A non-positive capacity is raised to 1.
+//
+// Parameters:
+//   - capacity: The maximum number of records kept.
+//
+// Returns:
+//   - *Buffer: A pointer to the created Buffer.
+func NewBuffer(capacity int) *Buffer {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &Buffer{records: make([]Record, capacity), mu: &sync.Mutex{}}
+}
+
+// Add stores a record, overwriting the oldest one if the buffer is full.
+//
+// Parameters:
+//   - record: The record to store.
+func (b *Buffer) Add(record Record) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.records[b.next] = record
+	b.next = (b.next + 1) % len(b.records)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// List returns the stored records, most recent first.
+//
+// Returns:
+//   - []Record: The stored records.
+func (b *Buffer) List() []Record {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	size := b.next
+	if b.full {
+		size = len(b.records)
+	}
+	list := make([]Record, 0, size)
+	for i := 1; i <= size; i++ {
+		list = append(list, b.records[(b.next-i+len(b.records))%len(b.records)])
+	}
+	return list
+}
+
+// Find returns the most recent record with the request ID.
+//
+// Parameters:
+//   - id: The request ID.
+//
+// Returns:
+//   - Record: The found record.
+//   - bool: False if no stored record has the ID.
+func (b *Buffer) Find(id string) (Record, bool) {
+	for _, record := range b.List() {
+		if record.ID == id {
+			return record, true
+		}
+	}
+	return Record{}, false
+}
@@ -0,0 +1,42 @@
+package reqrecord
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ids(records []Record) []string {
+	list := make([]string, 0, len(records))
+	for _, r := range records {
+		list = append(list, r.ID)
+	}
+	return list
+}
+
+func TestBuffer(t *testing.T) {
+	buffer := NewBuffer(3)
+	assert.Empty(t, buffer.List())
+
+	buffer.Add(Record{ID: "1"})
+	buffer.Add(Record{ID: "2"})
+	assert.Equal(t, []string{"2", "1"}, ids(buffer.List()))
+
+	buffer.Add(Record{ID: "3"})
+	buffer.Add(Record{ID: "4"})
+	assert.Equal(t, []string{"4", "3", "2"}, ids(buffer.List()))
+
+	record, ok := buffer.Find("3")
+	assert.True(t, ok)
+	assert.Equal(t, "3", record.ID)
+
+	_, ok = buffer.Find("1")
+	assert.False(t, ok)
+}
+
+func TestNewBuffer_NonPositiveCapacity(t *testing.T) {
+	buffer := NewBuffer(0)
+	buffer.Add(Record{ID: "1"})
+	buffer.Add(Record{ID: "2"})
+	assert.Equal(t, []string{"2"}, ids(buffer.List()))
+}
@@ -0,0 +1,253 @@
+// Package retention decides when stored metrics expire. A Policy sets a time-to-live per metric type or per
+// metric name pattern, counted from the last update of a metric, and clients may give the metrics of an update
+// request an explicit expiry with the X-Expires-At header. Expired metrics are deleted by the sweeper of
+// the metric controller, which works through the repository interface and so with every storage.
+package retention
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+)
+
+// Policy holds the time-to-live of metrics by type and by name pattern.
+type Policy struct {
+	types map[string]time.Duration // types maps a metric type to its time-to-live.
+	names map[string]time.Duration // names maps a name glob pattern to its time-to-live.
+}
+
+// NewPolicy creates a Policy.
+//
+// Parameters:
+//   - types: The time-to-live of every listed metric type.
+//   - names: The time-to-live of the metrics whose names match a glob pattern as in path.Match.
+//
+// Returns:
+//   - *Policy: A pointer to the created Policy.
+//   - error: An error if a pattern is malformed or a time-to-live is not positive.
+func NewPolicy(types, names map[string]time.Duration) (*Policy, error) {
+	p := &Policy{types: make(map[string]time.Duration), names: make(map[string]time.Duration)}
+	for metricType, ttl := range types {
+		if ttl <= 0 {
+			return nil, fmt.Errorf("time-to-live of type %q must be positive, got %s", metricType, ttl)
+		}
+		p.types[metricType] = ttl
+	}
+	for pattern, ttl := range names {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+		if ttl <= 0 {
+			return nil, fmt.Errorf("time-to-live of pattern %q must be positive, got %s", pattern, ttl)
+		}
+		p.names[pattern] = ttl
+	}
+	return p, nil
+}
+
+// TTL returns the time-to-live of a metric. A matching name pattern takes precedence over the type of
+// the metric; among several matching patterns the longest one, being the most specific, wins.
+//
+// Parameters:
+//   - metricType: The metric type.
+//   - name: The metric name.
+//
+// Returns:
+//   - time.Duration: The time-to-live; zero if the metric is kept forever.
+func (p *Policy) TTL(metricType, name string) time.Duration {
+	if p == nil {
+		return 0
+	}
+	best := ""
+	var ttl time.Duration
+	for pattern, patternTTL := range p.names {
+		if matched, _ := path.Match(pattern, name); !matched {
+			continue
+		}
+		if ttl == 0 || len(pattern) > len(best) || len(pattern) == len(best) && pattern < best {
+			best, ttl = pattern, patternTTL
+		}
+	}
+	if ttl > 0 {
+		return ttl
+	}
+	return p.types[metricType]
+}
+
+// Empty reports whether the policy expires no metric.
+//
+// Returns:
+//   - bool: True if no time-to-live is configured.
+func (p *Policy) Empty() bool {
+	return p == nil || len(p.types) == 0 && len(p.names) == 0
+}
+
+// metricKey identifies a metric by its type and name.
+type metricKey struct {
+	metricType string // metricType is the metric type.
+	name       string // name is the metric name.
+}
+
+// Tracker combines the policy with the update times and explicit expiries of metrics. Both are kept in memory,
+// as not every repository stores the time of the last update, so after a restart the time-to-live of a metric
+// is counted from its first sweep and its explicit expiry is replaced by the policy.
+type Tracker struct {
+	policy    *Policy                 // policy sets the time-to-live of metrics without an explicit expiry.
+	updated   map[metricKey]time.Time // updated holds the last update of metrics, or their first sweep.
+	deadlines map[metricKey]time.Time // deadlines holds the explicit expiry of metrics.
+	mu        *sync.Mutex             // mu protects updated and deadlines.
+}
+
+// NewTracker creates a Tracker.
+//
+// Parameters:
+//   - policy: The time-to-live of metrics; nil expires only metrics with an explicit expiry.
+//
+// Returns:
+//   - *Tracker: A pointer to the created Tracker.
+func NewTracker(policy *Policy) *Tracker {
+	return &Tracker{
+		policy:    policy,
+		updated:   make(map[metricKey]time.Time),
+		deadlines: make(map[metricKey]time.Time),
+		mu:        &sync.Mutex{},
+	}
+}
+
+// Observe records stored metrics. An update with an explicit expiry sets it; an update without one clears
+// the previous expiry, so the policy applies again.
+//
+// Parameters:
+//   - metrics: The stored metrics, stamped with the time of their update.
+//   - expiresAt: The explicit expiry of the metrics; zero if the update carries none.
+func (t *Tracker) Observe(metrics entity.Metrics, expiresAt time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, m := range metrics {
+		if m == nil {
+			continue
+		}
+		key := metricKey{metricType: m.Type, name: m.Name}
+		if t.policy.TTL(m.Type, m.Name) > 0 && !m.Timestamp.IsZero() {
+			t.updated[key] = m.Timestamp
+		}
+		if expiresAt.IsZero() {
+			delete(t.deadlines, key)
+			continue
+		}
+		t.deadlines[key] = expiresAt
+	}
+}
+
+// Forget drops what is known about a deleted metric.
+//
+// Parameters:
+//   - metricType: The metric type.
+//   - name: The metric name.
+func (t *Tracker) Forget(metricType, name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := metricKey{metricType: metricType, name: name}
+	delete(t.updated, key)
+	delete(t.deadlines, key)
+}
+
+// Active reports whether any metric can expire, so the sweeper can skip reading all metrics otherwise.
+//
+// Returns:
+//   - bool: True if the policy is not empty or a metric has an explicit expiry.
+func (t *Tracker) Active() bool {
+	if !t.policy.Empty() {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.deadlines) > 0
+}
+
+// Expired reports whether a stored metric has expired: its explicit expiry has passed or, without one,
+// its last update is older than its time-to-live. The last update is the later of the observed one and
+// the timestamp kept by the repository; a metric with neither is considered updated now.
+//
+// Parameters:
+//   - m: The stored metric.
+//   - now: The current time.
+//
+// Returns:
+//   - bool: True if the metric is to be deleted.
+func (t *Tracker) Expired(m *entity.Metric, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := metricKey{metricType: m.Type, name: m.Name}
+	if deadline, ok := t.deadlines[key]; ok {
+		return !now.Before(deadline)
+	}
+
+	ttl := t.policy.TTL(m.Type, m.Name)
+	if ttl == 0 {
+		return false
+	}
+	updated, ok := t.updated[key]
+	if m.Timestamp.After(updated) {
+		updated, ok = m.Timestamp, true
+	}
+	if !ok {
+		t.updated[key] = now
+		return false
+	}
+	return now.Sub(updated) >= ttl
+}
+
+// expiresAtContextKey is the context key holding the explicit expiry of an update request.
+type expiresAtContextKey struct{}
+
+// WithExpiresAt returns a copy of the context carrying the explicit expiry of the metrics of a request.
+//
+// Parameters:
+//   - ctx: The parent context.
+//   - expiresAt: The expiry.
+//
+// Returns:
+//   - context.Context: The context carrying the expiry.
+func WithExpiresAt(ctx context.Context, expiresAt time.Time) context.Context {
+	return context.WithValue(ctx, expiresAtContextKey{}, expiresAt)
+}
+
+// ExpiresAtFrom returns the explicit expiry carried by the context.
+//
+// Parameters:
+//   - ctx: The context of the request.
+//
+// Returns:
+//   - time.Time: The expiry, zero if the context carries none.
+func ExpiresAtFrom(ctx context.Context) time.Time {
+	expiresAt, _ := ctx.Value(expiresAtContextKey{}).(time.Time)
+	return expiresAt
+}
+
+// ParseExpiresAt parses an explicit expiry, given as an RFC 3339 time or as Unix seconds.
+//
+// Parameters:
+//   - value: The expiry.
+//
+// Returns:
+//   - time.Time: The parsed expiry.
+//   - error: An error if the value is neither an RFC 3339 time nor a positive number of Unix seconds.
+func ParseExpiresAt(value string) (time.Time, error) {
+	if expiresAt, err := time.Parse(time.RFC3339, value); err == nil {
+		return expiresAt, nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}, fmt.Errorf("expected an RFC 3339 time or Unix seconds, got %q", value)
+	}
+	return time.Unix(seconds, 0), nil
+}
@@ -0,0 +1,140 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var base = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func TestPolicy_TTL(t *testing.T) {
+	policy, err := NewPolicy(
+		map[string]time.Duration{entity.MetricTypeGauge: time.Hour},
+		map[string]time.Duration{"job_*": time.Minute, "job_backup_*": 10 * time.Minute},
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		metricType string
+		metric     string
+		expected   time.Duration
+	}{
+		{name: "Type", metricType: entity.MetricTypeGauge, metric: "Alloc", expected: time.Hour},
+		{name: "Pattern over type", metricType: entity.MetricTypeGauge, metric: "job_import", expected: time.Minute},
+		{
+			name:       "Longest pattern",
+			metricType: entity.MetricTypeCounter,
+			metric:     "job_backup_files",
+			expected:   10 * time.Minute,
+		},
+		{name: "Kept forever", metricType: entity.MetricTypeCounter, metric: "PollCount"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, policy.TTL(tt.metricType, tt.metric))
+		})
+	}
+	assert.False(t, policy.Empty())
+	assert.True(t, (*Policy)(nil).Empty())
+}
+
+func TestNewPolicy_Invalid(t *testing.T) {
+	_, err := NewPolicy(map[string]time.Duration{entity.MetricTypeGauge: 0}, nil)
+	assert.Error(t, err)
+	_, err = NewPolicy(nil, map[string]time.Duration{"job_[": time.Minute})
+	assert.Error(t, err)
+	_, err = NewPolicy(nil, map[string]time.Duration{"job_*": -time.Minute})
+	assert.Error(t, err)
+}
+
+func TestTracker_Expired(t *testing.T) {
+	policy, err := NewPolicy(map[string]time.Duration{entity.MetricTypeGauge: time.Hour}, nil)
+	require.NoError(t, err)
+	tracker := NewTracker(policy)
+
+	stale := &entity.Metric{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.0, Timestamp: base}
+	fresh := &entity.Metric{Name: "Heap", Type: entity.MetricTypeGauge, Value: 1.0, Timestamp: base.Add(time.Hour)}
+	job := &entity.Metric{Name: "job_done", Type: entity.MetricTypeCounter, Value: int64(1), Timestamp: base}
+	now := base.Add(90 * time.Minute)
+
+	assert.True(t, tracker.Expired(stale, now))
+	assert.False(t, tracker.Expired(fresh, now))
+	assert.False(t, tracker.Expired(job, now), "counters have no time-to-live")
+
+	tracker.Observe(entity.Metrics{job, fresh}, base.Add(time.Minute))
+	assert.True(t, tracker.Expired(job, now), "the explicit expiry has passed")
+	assert.True(t, tracker.Expired(fresh, now), "the explicit expiry overrides the policy")
+
+	tracker.Observe(entity.Metrics{fresh}, time.Time{})
+	assert.False(t, tracker.Expired(fresh, now), "an update without an expiry restores the policy")
+	tracker.Forget(job.Type, job.Name)
+	assert.False(t, tracker.Expired(job, now))
+}
+
+func TestTracker_ExpiredWithoutTimestamp(t *testing.T) {
+	policy, err := NewPolicy(map[string]time.Duration{entity.MetricTypeGauge: time.Hour}, nil)
+	require.NoError(t, err)
+	tracker := NewTracker(policy)
+
+	restored := &entity.Metric{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.0}
+	assert.False(t, tracker.Expired(restored, base), "the time-to-live starts at the first sweep")
+	assert.False(t, tracker.Expired(restored, base.Add(30*time.Minute)))
+	assert.True(t, tracker.Expired(restored, base.Add(time.Hour)))
+
+	updated := &entity.Metric{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 2.0, Timestamp: base.Add(time.Hour)}
+	tracker.Observe(entity.Metrics{updated}, time.Time{})
+	assert.False(t, tracker.Expired(restored, base.Add(90*time.Minute)), "the observed update counts")
+}
+
+func TestTracker_Active(t *testing.T) {
+	tracker := NewTracker(nil)
+	assert.False(t, tracker.Active())
+
+	m := &entity.Metric{Name: "job_done", Type: entity.MetricTypeCounter, Value: int64(1)}
+	tracker.Observe(entity.Metrics{m}, base)
+	assert.True(t, tracker.Active())
+	tracker.Forget(m.Type, m.Name)
+	assert.False(t, tracker.Active())
+
+	policy, err := NewPolicy(map[string]time.Duration{entity.MetricTypeGauge: time.Hour}, nil)
+	require.NoError(t, err)
+	assert.True(t, NewTracker(policy).Active())
+}
+
+func TestExpiresAtContext(t *testing.T) {
+	assert.True(t, ExpiresAtFrom(context.Background()).IsZero())
+	assert.Equal(t, base, ExpiresAtFrom(WithExpiresAt(context.Background(), base)))
+}
+
+func TestParseExpiresAt(t *testing.T) {
+	tests := []struct {
+		expected time.Time
+		name     string
+		value    string
+		wantErr  bool
+	}{
+		{name: "RFC 3339", value: "2024-05-01T12:00:00Z", expected: base},
+		{name: "Unix seconds", value: "1714564800", expected: base},
+		{name: "Zero seconds", value: "0", wantErr: true},
+		{name: "Garbage", value: "tomorrow", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expiresAt, err := ParseExpiresAt(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.expected.Equal(expiresAt))
+		})
+	}
+}
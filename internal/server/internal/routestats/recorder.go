@@ -0,0 +1,179 @@
+// Package routestats records request durations and response status codes per HTTP route.
+// The statistics are exposed as self-metrics, so operators can see which endpoints are slow
+// and how often requests fail.
+package routestats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/selfmetric"
+)
+
+const (
+	// Const selfMetricRequestsPrefix prefixes the per-route and per-status request counters,
+	// e.g. metricol_http_requests_post_update_400.
+	selfMetricRequestsPrefix = "metricol_http_requests_"
+	// Const selfMetricDurationPrefix prefixes the per-route request duration histogram,
+	// e.g. metricol_http_request_duration_post_update_le_50ms.
+	selfMetricDurationPrefix = "metricol_http_request_duration_"
+	// Const unmatchedRoute names requests that matched no route, so unknown paths cannot grow the metric set.
+	unmatchedRoute = "unmatched"
+)
+
+// durationBuckets holds the upper bounds of the request duration histogram buckets.
+var durationBuckets = []time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+}
+
+// routeStats holds the statistics of a single route.
+type routeStats struct {
+	statuses map[int]int64 // statuses counts responses per status code.
+	buckets  []int64       // buckets counts requests not slower than the matching durationBuckets bound.
+	count    int64         // count is the total number of requests.
+	sum      time.Duration // sum is the total duration of all requests.
+}
+
+// Recorder records request statistics per route.
+type Recorder struct {
+	routes   map[string]*routeStats // routes maps a route name to its statistics.
+	registry *selfmetric.Registry   // registry receives the per-route metrics; nil until registered.
+	mu       *sync.Mutex            // mu protects routes and registry.
+}
+
+// NewRecorder creates an empty Recorder.
+//
+// Returns:
+//   - *Recorder: A pointer to the created Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{routes: make(map[string]*routeStats), mu: &sync.Mutex{}}
+}
+
+// Observe records a handled request.
+//
+// Parameters:
+//   - method: The HTTP method of the request.
+//   - route: The route pattern the request matched, e.g. /update/:type/:id/:value; empty if none matched.
+//   - status: The response status code.
+//   - elapsed: The time spent handling the request.
+func (r *Recorder) Observe(method, route string, status int, elapsed time.Duration) {
+	name := RouteName(method, route)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats, ok := r.routes[name]
+	if !ok {
+		stats = &routeStats{statuses: make(map[int]int64), buckets: make([]int64, len(durationBuckets))}
+		r.routes[name] = stats
+		if r.registry != nil {
+			r.registerRoute(name)
+		}
+	}
+	if _, seen := stats.statuses[status]; !seen && r.registry != nil {
+		r.registerStatus(name, status)
+	}
+
+	stats.statuses[status]++
+	stats.count++
+	stats.sum += elapsed
+	for i, bound := range durationBuckets {
+		if elapsed <= bound {
+			stats.buckets[i]++
+		}
+	}
+}
+
+// RegisterSelfMetrics exposes the route statistics through the self-metric registry.
+// Routes and status codes observed later are registered as they appear.
+//
+// Parameters:
+//   - registry: The registry to register the metrics in.
+func (r *Recorder) RegisterSelfMetrics(registry *selfmetric.Registry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.registry = registry
+	for name, stats := range r.routes {
+		r.registerRoute(name)
+		for status := range stats.statuses {
+			r.registerStatus(name, status)
+		}
+	}
+}
+
+// RouteName builds the metric name fragment identifying a route, e.g. "post_update_type_id_value".
+//
+// Parameters:
+//   - method: The HTTP method.
+//   - route: The route pattern; empty if no route matched.
+//
+// Returns:
+//   - string: The route name.
+func RouteName(method, route string) string {
+	if route == "" {
+		return unmatchedRoute
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	underscore := true
+	for _, ch := range strings.ToLower(route) {
+		if (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') {
+			if underscore {
+				b.WriteByte('_')
+				underscore = false
+			}
+			b.WriteRune(ch)
+			continue
+		}
+		underscore = true
+	}
+	if b.Len() == len(method) {
+		b.WriteString("_root")
+	}
+	return b.String()
+}
+
+// registerRoute registers the duration histogram of a route. The caller must hold mu.
+func (r *Recorder) registerRoute(name string) {
+	prefix := selfMetricDurationPrefix + name + "_"
+	for i, bound := range durationBuckets {
+		r.registry.RegisterCounter(prefix+"le_"+strconv.FormatInt(bound.Milliseconds(), 10)+"ms", func() int64 {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			return r.routes[name].buckets[i]
+		})
+	}
+	r.registry.RegisterCounter(prefix+"count", func() int64 {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.routes[name].count
+	})
+	r.registry.RegisterGauge(prefix+"sum_seconds", func() float64 {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.routes[name].sum.Seconds()
+	})
+}
+
+// registerStatus registers the response counter of a route and status code. The caller must hold mu.
+func (r *Recorder) registerStatus(name string, status int) {
+	r.registry.RegisterCounter(fmt.Sprintf("%s%s_%d", selfMetricRequestsPrefix, name, status), func() int64 {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.routes[name].statuses[status]
+	})
+}
@@ -0,0 +1,79 @@
+package routestats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/selfmetric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteName(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		route    string
+		expected string
+	}{
+		{name: "Unmatched", method: "GET", route: "", expected: unmatchedRoute},
+		{name: "Root", method: "GET", route: "/", expected: "get_root"},
+		{name: "Static", method: "POST", route: "/updates/", expected: "post_updates"},
+		{name: "Parameters", method: "POST", route: "/update/:type/:id/:value", expected: "post_update_type_id_value"},
+		{name: "Nested", method: "GET", route: "/api/admin/migrations", expected: "get_api_admin_migrations"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, RouteName(tt.method, tt.route))
+		})
+	}
+}
+
+func TestRecorder_Observe(t *testing.T) {
+	recorder := NewRecorder()
+	recorder.Observe("POST", "/update/", 200, 3*time.Millisecond)
+	recorder.Observe("POST", "/update/", 400, 70*time.Millisecond)
+	recorder.Observe("POST", "/update/", 200, 2*time.Second)
+
+	stats := recorder.routes["post_update"]
+	require.NotNil(t, stats)
+	assert.Equal(t, int64(3), stats.count)
+	assert.Equal(t, map[int]int64{200: 2, 400: 1}, stats.statuses)
+	assert.Equal(t, 2*time.Second+73*time.Millisecond, stats.sum)
+	assert.Equal(t, []int64{1, 1, 1, 1, 2, 2, 2, 2, 3, 3}, stats.buckets)
+}
+
+func TestRecorder_RegisterSelfMetrics(t *testing.T) {
+	recorder := NewRecorder()
+	recorder.Observe("GET", "/value/:type/:id", 200, 20*time.Millisecond)
+
+	registry := selfmetric.NewRegistry()
+	recorder.RegisterSelfMetrics(registry)
+	recorder.Observe("GET", "/value/:type/:id", 404, time.Millisecond)
+	recorder.Observe("GET", "", 404, time.Millisecond)
+
+	ok200, found := registry.Find("counter", selfMetricRequestsPrefix+"get_value_type_id_200")
+	require.True(t, found)
+	assert.Equal(t, int64(1), ok200.Value)
+
+	notFound, found := registry.Find("counter", selfMetricRequestsPrefix+"get_value_type_id_404")
+	require.True(t, found)
+	assert.Equal(t, int64(1), notFound.Value)
+
+	unmatched, found := registry.Find("counter", selfMetricRequestsPrefix+"unmatched_404")
+	require.True(t, found)
+	assert.Equal(t, int64(1), unmatched.Value)
+
+	count, found := registry.Find("counter", selfMetricDurationPrefix+"get_value_type_id_count")
+	require.True(t, found)
+	assert.Equal(t, int64(2), count.Value)
+
+	fast, found := registry.Find("counter", selfMetricDurationPrefix+"get_value_type_id_le_5ms")
+	require.True(t, found)
+	assert.Equal(t, int64(1), fast.Value)
+
+	sum, found := registry.Find("gauge", selfMetricDurationPrefix+"get_value_type_id_sum_seconds")
+	require.True(t, found)
+	assert.InDelta(t, 0.021, sum.Value, 1e-9)
+}
@@ -0,0 +1,117 @@
+// Package selfmetric provides a registry of metrics that describe the server itself.
+// Self-metrics are computed on demand from registered callbacks and are never written to the repository,
+// so they always reflect the current state of the running process.
+package selfmetric
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+)
+
+// Registry holds self-metric callbacks keyed by metric type and name.
+type Registry struct {
+	sources map[string]func() any // sources maps a metric key to the function computing its value.
+	mu      *sync.RWMutex         // mu protects the sources map.
+}
+
+// NewRegistry creates an empty Registry.
+//
+// Returns:
+//   - *Registry: A pointer to the created Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		sources: make(map[string]func() any),
+		mu:      &sync.RWMutex{},
+	}
+}
+
+// RegisterGauge registers a gauge self-metric. A later registration with the same name replaces the earlier one.
+//
+// Parameters:
+//   - name: The metric name.
+//   - fn: The function returning the current gauge value.
+func (r *Registry) RegisterGauge(name string, fn func() float64) {
+	r.register(entity.MetricTypeGauge, name, func() any { return fn() })
+}
+
+// RegisterCounter registers a counter self-metric. A later registration with the same name replaces the earlier one.
+//
+// Parameters:
+//   - name: The metric name.
+//   - fn: The function returning the current counter value.
+func (r *Registry) RegisterCounter(name string, fn func() int64) {
+	r.register(entity.MetricTypeCounter, name, func() any { return fn() })
+}
+
+// RegisterInfo registers an info self-metric holding a string, such as a version.
+// A later registration with the same name replaces the earlier one.
+//
+// Parameters:
+//   - name: The metric name.
+//   - fn: The function returning the current value.
+func (r *Registry) RegisterInfo(name string, fn func() string) {
+	r.register(entity.MetricTypeInfo, name, func() any { return fn() })
+}
+
+// Find computes a single self-metric.
+//
+// Parameters:
+//   - metricType: The metric type.
+//   - name: The metric name.
+//
+// Returns:
+//   - *entity.Metric: The computed metric.
+//   - bool: False if no such self-metric is registered.
+func (r *Registry) Find(metricType, name string) (*entity.Metric, bool) {
+	r.mu.RLock()
+	fn, ok := r.sources[key(metricType, name)]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return &entity.Metric{Name: name, Type: metricType, Value: fn()}, true
+}
+
+// All computes every registered self-metric, sorted by type and name.
+//
+// Returns:
+//   - entity.Metrics: The computed self-metrics.
+func (r *Registry) All() entity.Metrics {
+	r.mu.RLock()
+	keys := make([]string, 0, len(r.sources))
+	for k := range r.sources {
+		keys = append(keys, k)
+	}
+	r.mu.RUnlock()
+	sort.Strings(keys)
+
+	metrics := make(entity.Metrics, 0, len(keys))
+	for _, k := range keys {
+		metricType, name := splitKey(k)
+		if m, ok := r.Find(metricType, name); ok {
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics
+}
+
+// register stores a callback under the metric key.
+func (r *Registry) register(metricType, name string, fn func() any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sources[key(metricType, name)] = fn
+}
+
+// key builds the registry key of a metric.
+func key(metricType, name string) string {
+	return metricType + "|" + name
+}
+
+// splitKey reverses key.
+func splitKey(k string) (metricType, name string) {
+	metricType, name, _ = strings.Cut(k, "|")
+	return metricType, name
+}
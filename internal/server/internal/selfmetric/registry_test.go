@@ -0,0 +1,48 @@
+package selfmetric
+
+import (
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	value := 1.5
+	r.RegisterGauge("g", func() float64 { return value })
+	r.RegisterCounter("c", func() int64 { return 7 })
+	r.RegisterInfo("i", func() string { return "v1" })
+
+	tests := []struct {
+		expected   any
+		name       string
+		metricType string
+		metricName string
+		found      bool
+	}{
+		{name: "Gauge", metricType: entity.MetricTypeGauge, metricName: "g", expected: 1.5, found: true},
+		{name: "Counter", metricType: entity.MetricTypeCounter, metricName: "c", expected: int64(7), found: true},
+		{name: "Info", metricType: entity.MetricTypeInfo, metricName: "i", expected: "v1", found: true},
+		{name: "Wrong type", metricType: entity.MetricTypeCounter, metricName: "g"},
+		{name: "Unknown", metricType: entity.MetricTypeGauge, metricName: "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, ok := r.Find(tt.metricType, tt.metricName)
+			require.Equal(t, tt.found, ok)
+			if ok {
+				assert.Equal(t, tt.expected, m.Value)
+			}
+		})
+	}
+
+	value = 2.5
+	all := r.All()
+	require.Len(t, all, 3)
+	assert.Equal(t, "c", all[0].Name)
+	assert.Equal(t, 2.5, all[1].Value, "values must be computed on demand")
+	assert.Equal(t, "i", all[2].Name)
+}
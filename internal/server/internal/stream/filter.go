@@ -0,0 +1,108 @@
+package stream
+
+import (
+	"math"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+)
+
+// Filter selects the updates delivered to a subscriber, so focused dashboards receive only what they show.
+// The zero Filter delivers every update.
+type Filter struct {
+	Names    []string // Names lists the metric names delivered; empty delivers every name.
+	Types    []string // Types lists the metric types delivered; empty delivers every type.
+	MinDelta float64  // MinDelta is the smallest change of a value delivered; 0 delivers every update.
+}
+
+// metricKey identifies a metric in the last delivered values of a subscriber.
+type metricKey struct {
+	Type string // Type is the metric type.
+	Name string // Name is the metric name.
+}
+
+// matcher applies a Filter to the updates of one subscriber.
+type matcher struct {
+	names    map[string]struct{}   // names is the set of delivered names, nil for every name.
+	types    map[string]struct{}   // types is the set of delivered types, nil for every type.
+	last     map[metricKey]float64 // last holds the last delivered value of every metric, nil without MinDelta.
+	minDelta float64               // minDelta is the smallest change delivered.
+}
+
+// newMatcher prepares a filter for matching.
+//
+// Parameters:
+//   - f: The filter to apply.
+//
+// Returns:
+//   - *matcher: The matcher applying the filter.
+func newMatcher(f Filter) *matcher {
+	m := &matcher{
+		names:    toSet(f.Names),
+		types:    toSet(f.Types),
+		minDelta: max(f.MinDelta, 0),
+	}
+	if m.minDelta > 0 {
+		m.last = make(map[metricKey]float64)
+	}
+	return m
+}
+
+// match reports whether an update passes the filter and, if so, remembers its value as the last delivered.
+// The first update of a metric and updates with non-numeric values always pass the change threshold.
+//
+// Parameters:
+//   - metric: The update to check.
+//
+// Returns:
+//   - bool: True if the update is delivered.
+func (m *matcher) match(metric *entity.Metric) bool {
+	if !inSet(m.names, metric.Name) || !inSet(m.types, metric.Type) {
+		return false
+	}
+	if m.last == nil {
+		return true
+	}
+	value, ok := numeric(metric.Value)
+	if !ok {
+		return true
+	}
+	key := metricKey{Type: metric.Type, Name: metric.Name}
+	if last, seen := m.last[key]; seen && math.Abs(value-last) < m.minDelta {
+		return false
+	}
+	m.last[key] = value
+	return true
+}
+
+// toSet converts a list to a set, nil for an empty list.
+func toSet(values []string) map[string]struct{} {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+// inSet reports whether a value is in a set; a nil set contains every value.
+func inSet(set map[string]struct{}, value string) bool {
+	if set == nil {
+		return true
+	}
+	_, ok := set[value]
+	return ok
+}
+
+// numeric converts a metric value to a float64.
+func numeric(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,94 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcher(t *testing.T) {
+	gauge := func(name string, value any) *entity.Metric {
+		return &entity.Metric{Name: name, Type: entity.MetricTypeGauge, Value: value}
+	}
+	counter := func(name string, value int64) *entity.Metric {
+		return &entity.Metric{Name: name, Type: entity.MetricTypeCounter, Value: value}
+	}
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		updates  []*entity.Metric
+		expected []bool
+	}{
+		{
+			name:     "Zero filter delivers everything",
+			updates:  []*entity.Metric{gauge("Alloc", 1.0), gauge("Alloc", 1.0), counter("PollCount", 1)},
+			expected: []bool{true, true, true},
+		},
+		{
+			name:     "Names",
+			filter:   Filter{Names: []string{"Alloc", "HeapInuse"}},
+			updates:  []*entity.Metric{gauge("Alloc", 1.0), gauge("Sys", 1.0), gauge("HeapInuse", 1.0)},
+			expected: []bool{true, false, true},
+		},
+		{
+			name:     "Types",
+			filter:   Filter{Types: []string{entity.MetricTypeCounter}},
+			updates:  []*entity.Metric{gauge("Alloc", 1.0), counter("PollCount", 1)},
+			expected: []bool{false, true},
+		},
+		{
+			name:   "Delta is measured from the last delivered value",
+			filter: Filter{MinDelta: 1},
+			updates: []*entity.Metric{
+				gauge("Alloc", 10.0),
+				gauge("Alloc", 10.5),
+				gauge("Alloc", 10.9),
+				gauge("Alloc", 11.0),
+				gauge("Alloc", 10.0),
+			},
+			expected: []bool{true, false, false, true, true},
+		},
+		{
+			name:     "Delta is tracked per metric",
+			filter:   Filter{MinDelta: 5},
+			updates:  []*entity.Metric{counter("a", 1), counter("b", 2), counter("a", 3), counter("a", 6)},
+			expected: []bool{true, true, false, true},
+		},
+		{
+			name:     "Non-numeric values pass the delta",
+			filter:   Filter{MinDelta: 1},
+			updates:  []*entity.Metric{gauge("Label", "x"), gauge("Label", "x")},
+			expected: []bool{true, true},
+		},
+		{
+			name:     "Negative delta delivers everything",
+			filter:   Filter{MinDelta: -1},
+			updates:  []*entity.Metric{gauge("Alloc", 1.0), gauge("Alloc", 1.0)},
+			expected: []bool{true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMatcher(tt.filter)
+			got := make([]bool, 0, len(tt.updates))
+			for _, update := range tt.updates {
+				got = append(got, m.match(update))
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestHub_FilteredUpdatesAreNotDropped(t *testing.T) {
+	hub := NewHub(1, DropNewest)
+	sub := hub.Subscribe(Filter{Names: []string{"Alloc"}})
+	defer hub.Unsubscribe(sub)
+
+	hub.Publish(gauges("Sys", "HeapInuse", "Alloc", "Sys"))
+
+	assert.Equal(t, []string{"Alloc"}, drain(sub))
+	assert.Zero(t, sub.Dropped())
+}
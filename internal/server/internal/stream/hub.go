@@ -0,0 +1,235 @@
+// Package stream implements a publish/subscribe hub for live metric updates.
+// Every subscriber owns a bounded buffer, and a drop policy decides what happens when a subscriber
+// falls behind, so a stalled consumer can never make the server accumulate unbounded memory.
+package stream
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/internal/selfmetric"
+)
+
+const (
+	// Const selfMetricSubscribers is the self-metric exposing the number of connected subscribers.
+	selfMetricSubscribers = "metricol_stream_subscribers"
+	// Const selfMetricDropped is the self-metric counting updates dropped for slow subscribers.
+	selfMetricDropped = "metricol_stream_dropped"
+	// Const selfMetricDisconnected is the self-metric counting subscribers disconnected for being too slow.
+	selfMetricDisconnected = "metricol_stream_disconnected"
+)
+
+// DropPolicy defines what the hub does when a subscriber's buffer is full.
+type DropPolicy int
+
+const (
+	// DropOldest discards the oldest buffered update to make room for the new one.
+	DropOldest DropPolicy = iota
+	// DropNewest discards the new update and keeps the buffered ones.
+	DropNewest
+	// Disconnect closes the subscription of a subscriber that cannot keep up.
+	Disconnect
+)
+
+// dropPolicyNames maps configuration names to drop policies.
+var dropPolicyNames = map[string]DropPolicy{
+	"drop-oldest": DropOldest,
+	"drop-newest": DropNewest,
+	"disconnect":  Disconnect,
+}
+
+// ParseDropPolicy converts a configuration name ("drop-oldest", "drop-newest" or "disconnect") to a DropPolicy.
+//
+// Parameters:
+//   - name: The policy name.
+//
+// Returns:
+//   - DropPolicy: The parsed policy.
+//   - error: An error if the name is unknown.
+func ParseDropPolicy(name string) (DropPolicy, error) {
+	policy, ok := dropPolicyNames[name]
+	if !ok {
+		return DropOldest, fmt.Errorf("unknown drop policy %q", name)
+	}
+	return policy, nil
+}
+
+// Hub fans metric updates out to subscribers.
+type Hub struct {
+	subs         map[*Subscriber]struct{} // subs holds the active subscribers.
+	mu           *sync.Mutex              // mu serializes publishing and subscription changes.
+	dropped      *atomic.Int64            // dropped counts updates discarded for slow subscribers.
+	disconnected *atomic.Int64            // disconnected counts subscribers closed for being too slow.
+	bufferSize   int                      // bufferSize is the capacity of every subscriber buffer.
+	policy       DropPolicy               // policy is applied when a subscriber buffer is full.
+}
+
+// NewHub creates a new Hub.
+//
+// Parameters:
+//   - bufferSize: The number of updates buffered per subscriber; values below 1 are treated as 1.
+//   - policy: The policy applied to subscribers whose buffer is full.
+//
+// Returns:
+//   - *Hub: A pointer to the created Hub.
+func NewHub(bufferSize int, policy DropPolicy) *Hub {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	return &Hub{
+		subs:         make(map[*Subscriber]struct{}),
+		mu:           &sync.Mutex{},
+		dropped:      &atomic.Int64{},
+		disconnected: &atomic.Int64{},
+		bufferSize:   bufferSize,
+		policy:       policy,
+	}
+}
+
+// Subscribe registers a new subscriber. Updates the filter rejects are skipped before they reach the buffer
+// of the subscriber, so they neither count as dropped nor push out the updates it wants.
+//
+// Parameters:
+//   - filter: The filter selecting the updates delivered to the subscriber.
+//
+// Returns:
+//   - *Subscriber: The subscriber receiving published updates.
+func (h *Hub) Subscribe(filter Filter) *Subscriber {
+	sub := &Subscriber{
+		events:  make(chan *entity.Metric, h.bufferSize),
+		done:    make(chan struct{}),
+		dropped: &atomic.Int64{},
+		filter:  newMatcher(filter),
+	}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+// Unsubscribe removes a subscriber. It is safe to call more than once.
+//
+// Parameters:
+//   - sub: The subscriber to remove.
+func (h *Hub) Unsubscribe(sub *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(sub)
+}
+
+// Publish delivers updated metrics to every subscriber whose filter accepts them, without blocking on slow ones.
+//
+// Parameters:
+//   - metrics: The updated metrics.
+func (h *Hub) Publish(metrics entity.Metrics) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		for _, m := range metrics {
+			if m == nil || !sub.filter.match(m) {
+				continue
+			}
+			if !h.deliver(sub, &entity.Metric{Name: m.Name, Type: m.Type, Value: m.Value}) {
+				break
+			}
+		}
+	}
+}
+
+// RegisterSelfMetrics exposes the hub state through the self-metric registry.
+//
+// Parameters:
+//   - r: The registry to register the metrics in.
+func (h *Hub) RegisterSelfMetrics(r *selfmetric.Registry) {
+	r.RegisterGauge(selfMetricSubscribers, func() float64 {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		return float64(len(h.subs))
+	})
+	r.RegisterCounter(selfMetricDropped, h.dropped.Load)
+	r.RegisterCounter(selfMetricDisconnected, h.disconnected.Load)
+}
+
+// deliver sends one update to a subscriber applying the drop policy. The caller must hold mu.
+// It returns false if the subscriber was disconnected.
+func (h *Hub) deliver(sub *Subscriber, m *entity.Metric) bool {
+	select {
+	case sub.events <- m:
+		return true
+	default:
+	}
+
+	switch h.policy {
+	case DropNewest:
+		h.drop(sub)
+	case Disconnect:
+		h.remove(sub)
+		h.disconnected.Add(1)
+		return false
+	default:
+		// The subscriber may have drained the buffer meanwhile, so neither step is guaranteed to succeed.
+		select {
+		case <-sub.events:
+			h.drop(sub)
+		default:
+		}
+		select {
+		case sub.events <- m:
+		default:
+			h.drop(sub)
+		}
+	}
+	return true
+}
+
+// drop accounts for a discarded update.
+func (h *Hub) drop(sub *Subscriber) {
+	sub.dropped.Add(1)
+	h.dropped.Add(1)
+}
+
+// remove deletes a subscriber and signals its closure. The caller must hold mu.
+func (h *Hub) remove(sub *Subscriber) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.done)
+}
+
+// Subscriber receives updates published to a Hub.
+type Subscriber struct {
+	events  chan *entity.Metric // events buffers updates waiting to be consumed.
+	done    chan struct{}       // done is closed when the subscription ends.
+	dropped *atomic.Int64       // dropped counts updates discarded for this subscriber.
+	filter  *matcher            // filter selects the delivered updates; it is only used under the hub mutex.
+}
+
+// Events returns the channel delivering updates.
+//
+// Returns:
+//   - <-chan *entity.Metric: The update channel.
+func (s *Subscriber) Events() <-chan *entity.Metric {
+	return s.events
+}
+
+// Done returns a channel closed when the subscription ends, either by Unsubscribe or
+// because the hub disconnected a subscriber that could not keep up.
+//
+// Returns:
+//   - <-chan struct{}: The closure channel.
+func (s *Subscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+// Dropped returns the number of updates discarded for this subscriber.
+//
+// Returns:
+//   - int64: The number of dropped updates.
+func (s *Subscriber) Dropped() int64 {
+	return s.dropped.Load()
+}
@@ -0,0 +1,130 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/internal/selfmetric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gauges(names ...string) entity.Metrics {
+	metrics := make(entity.Metrics, 0, len(names))
+	for _, n := range names {
+		metrics = append(metrics, &entity.Metric{Name: n, Type: entity.MetricTypeGauge, Value: 1.0})
+	}
+	return metrics
+}
+
+func drain(sub *Subscriber) []string {
+	names := make([]string, 0)
+	for {
+		select {
+		case m := <-sub.Events():
+			names = append(names, m.Name)
+		default:
+			return names
+		}
+	}
+}
+
+func TestHubDropPolicies(t *testing.T) {
+	tests := []struct {
+		name                 string
+		expectedNames        []string
+		policy               DropPolicy
+		expectedDropped      int64
+		expectedDisconnected int64
+		expectClosedSubs     bool
+	}{
+		{
+			name:            "Drop oldest",
+			policy:          DropOldest,
+			expectedNames:   []string{"c", "d"},
+			expectedDropped: 2,
+		},
+		{
+			name:            "Drop newest",
+			policy:          DropNewest,
+			expectedNames:   []string{"a", "b"},
+			expectedDropped: 2,
+		},
+		{
+			name:                 "Disconnect",
+			policy:               Disconnect,
+			expectedNames:        []string{"a", "b"},
+			expectedDisconnected: 1,
+			expectClosedSubs:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub(2, tt.policy)
+			sub := hub.Subscribe(Filter{})
+
+			hub.Publish(gauges("a", "b", "c", "d"))
+
+			assert.Equal(t, tt.expectedNames, drain(sub))
+			assert.Equal(t, tt.expectedDropped, sub.Dropped())
+			assert.Equal(t, tt.expectedDropped, hub.dropped.Load())
+			assert.Equal(t, tt.expectedDisconnected, hub.disconnected.Load())
+
+			select {
+			case <-sub.Done():
+				assert.True(t, tt.expectClosedSubs, "subscription closed unexpectedly")
+			default:
+				assert.False(t, tt.expectClosedSubs, "subscription should be closed")
+			}
+		})
+	}
+}
+
+func TestHubSlowSubscriberDoesNotAffectOthers(t *testing.T) {
+	hub := NewHub(1, Disconnect)
+	slow := hub.Subscribe(Filter{})
+	fast := hub.Subscribe(Filter{})
+
+	hub.Publish(gauges("a"))
+	assert.Equal(t, []string{"a"}, drain(fast))
+
+	hub.Publish(gauges("b"))
+	assert.Equal(t, []string{"b"}, drain(fast))
+	<-slow.Done()
+
+	registry := selfmetric.NewRegistry()
+	hub.RegisterSelfMetrics(registry)
+	subs, ok := registry.Find(entity.MetricTypeGauge, selfMetricSubscribers)
+	require.True(t, ok)
+	assert.Equal(t, 1.0, subs.Value)
+
+	hub.Unsubscribe(fast)
+	hub.Unsubscribe(fast)
+	<-fast.Done()
+}
+
+func TestParseDropPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		expected    DropPolicy
+		expectError bool
+	}{
+		{name: "drop-oldest", expected: DropOldest},
+		{name: "drop-newest", expected: DropNewest},
+		{name: "disconnect", expected: Disconnect},
+		{name: "block", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParseDropPolicy(tt.name)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, policy)
+		})
+	}
+}
@@ -0,0 +1,208 @@
+// Package keyring manages the server's signing and encryption keys during rotation.
+//
+// A rotation is started by configuring a next key next to the current one. During the grace window
+// both keys are accepted and the next key is advertised to agents, which switch to it on their own.
+// When the window ends the next key becomes the only accepted key.
+package keyring
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdyunin/metricol.git/pkg/pubkey"
+	"github.com/gdyunin/metricol.git/pkg/sign"
+)
+
+const (
+	// HeaderSigningKeyID advertises the identifier of the current signing key.
+	HeaderSigningKeyID = "X-Signing-Key-ID"
+	// HeaderNextSigningKeyID advertises the identifier of the signing key being rotated in.
+	HeaderNextSigningKeyID = "X-Signing-Key-Next-ID"
+	// HeaderCryptoKeyID advertises the fingerprint of the current encryption key.
+	HeaderCryptoKeyID = "X-Crypto-Key-ID"
+	// HeaderNextCryptoKeyID advertises the fingerprint of the encryption key being rotated in.
+	HeaderNextCryptoKeyID = "X-Crypto-Key-Next-ID"
+
+	// StatusCurrent marks a key that is in use.
+	StatusCurrent = "current"
+	// StatusNext marks a key that is being rotated in.
+	StatusNext = "next"
+)
+
+// Advertisement holds the key identifiers announced to agents. Empty fields mean "no such key".
+type Advertisement struct {
+	SigningKeyID     string `json:"signing_key_id,omitempty"`      // SigningKeyID identifies the current signing key.
+	NextSigningKeyID string `json:"next_signing_key_id,omitempty"` // NextSigningKeyID identifies the next signing key.
+	CryptoKeyID      string `json:"crypto_key_id,omitempty"`       // CryptoKeyID identifies the current encryption key.
+	NextCryptoKeyID  string `json:"next_crypto_key_id,omitempty"`  // NextCryptoKeyID identifies the next encryption key.
+}
+
+// PublicKey describes a public encryption key offered to agents.
+type PublicKey struct {
+	ID        string `json:"id"`         // ID is the key fingerprint.
+	PublicKey string `json:"public_key"` // PublicKey is the RSA or X25519 public key in PEM format.
+	Status    string `json:"status"`     // Status is StatusCurrent or StatusNext.
+}
+
+// cryptoKey is an RSA or X25519 private key with its derived public part.
+type cryptoKey struct {
+	privatePEM string // privatePEM is the private key in PEM format.
+	publicPEM  string // publicPEM is the derived public key in PEM format.
+	id         string // id is the public key fingerprint.
+}
+
+// Keyring holds the current and next keys and decides which of them are in effect.
+type Keyring struct {
+	rotateAt    time.Time        // rotateAt is the moment the next keys replace the current ones.
+	now         func() time.Time // now returns the current time.
+	crypto      cryptoKey        // crypto is the current encryption key.
+	nextCrypto  cryptoKey        // nextCrypto is the encryption key being rotated in.
+	signing     string           // signing is the current signing key.
+	nextSigning string           // nextSigning is the signing key being rotated in.
+}
+
+// New creates a Keyring. The grace window starts immediately.
+//
+// Parameters:
+//   - signingKey: The current signing key; empty disables signing.
+//   - nextSigningKey: The signing key to rotate to; empty if no rotation is planned.
+//   - cryptoKey: The current RSA or X25519 private key in PEM format; empty disables encryption.
+//   - nextCryptoKey: The RSA or X25519 private key to rotate to; empty if no rotation is planned.
+//   - grace: How long both current and next keys are accepted.
+//
+// Returns:
+//   - *Keyring: A pointer to the created Keyring.
+//   - error: An error if a private key cannot be parsed.
+func New(signingKey, nextSigningKey, cryptoKey, nextCryptoKey string, grace time.Duration) (*Keyring, error) {
+	current, err := newCryptoKey(cryptoKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid crypto key: %w", err)
+	}
+	next, err := newCryptoKey(nextCryptoKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid next crypto key: %w", err)
+	}
+
+	return &Keyring{
+		rotateAt:    time.Now().Add(grace),
+		now:         time.Now,
+		crypto:      current,
+		nextCrypto:  next,
+		signing:     signingKey,
+		nextSigning: nextSigningKey,
+	}, nil
+}
+
+// SigningKeys returns the signing keys accepted for request verification.
+//
+// Returns:
+//   - []string: The accepted keys; empty if signing is disabled.
+func (k *Keyring) SigningKeys() []string {
+	if k.nextSigning == "" {
+		return nonEmpty(k.signing)
+	}
+	if k.rotated() {
+		return nonEmpty(k.nextSigning)
+	}
+	return nonEmpty(k.signing, k.nextSigning)
+}
+
+// SigningKey returns the signing key used for response signatures.
+//
+// Returns:
+//   - string: The current signing key; empty if signing is disabled.
+func (k *Keyring) SigningKey() string {
+	if k.nextSigning != "" && k.rotated() {
+		return k.nextSigning
+	}
+	return k.signing
+}
+
+// CryptoKeys returns the RSA and X25519 private keys accepted for payload decryption.
+//
+// Returns:
+//   - []string: The accepted keys in PEM format; empty if encryption is disabled.
+func (k *Keyring) CryptoKeys() []string {
+	if k.nextCrypto.id == "" {
+		return nonEmpty(k.crypto.privatePEM)
+	}
+	if k.rotated() {
+		return nonEmpty(k.nextCrypto.privatePEM)
+	}
+	return nonEmpty(k.crypto.privatePEM, k.nextCrypto.privatePEM)
+}
+
+// PublicKeys returns the public encryption keys currently offered to agents.
+//
+// Returns:
+//   - []PublicKey: The current key followed by the next key, if any.
+func (k *Keyring) PublicKeys() []PublicKey {
+	current, next := k.cryptoPair()
+	keys := make([]PublicKey, 0, 2)
+	if current.id != "" {
+		keys = append(keys, PublicKey{ID: current.id, PublicKey: current.publicPEM, Status: StatusCurrent})
+	}
+	if next.id != "" {
+		keys = append(keys, PublicKey{ID: next.id, PublicKey: next.publicPEM, Status: StatusNext})
+	}
+	return keys
+}
+
+// Advertisement returns the key identifiers announced to agents.
+//
+// Returns:
+//   - Advertisement: The current and next key identifiers.
+func (k *Keyring) Advertisement() Advertisement {
+	adv := Advertisement{SigningKeyID: sign.KeyID(k.SigningKey())}
+	if k.nextSigning != "" && !k.rotated() {
+		adv.NextSigningKeyID = sign.KeyID(k.nextSigning)
+	}
+
+	current, next := k.cryptoPair()
+	adv.CryptoKeyID = current.id
+	adv.NextCryptoKeyID = next.id
+	return adv
+}
+
+// cryptoPair returns the encryption keys acting as current and next at this moment.
+func (k *Keyring) cryptoPair() (cryptoKey, cryptoKey) {
+	if k.nextCrypto.id == "" {
+		return k.crypto, cryptoKey{}
+	}
+	if k.rotated() {
+		return k.nextCrypto, cryptoKey{}
+	}
+	return k.crypto, k.nextCrypto
+}
+
+// rotated reports whether the grace window has ended.
+func (k *Keyring) rotated() bool {
+	return !k.now().Before(k.rotateAt)
+}
+
+// newCryptoKey parses a private key and derives its public part; an empty key yields an empty cryptoKey.
+func newCryptoKey(privatePEM string) (cryptoKey, error) {
+	if privatePEM == "" {
+		return cryptoKey{}, nil
+	}
+	publicPEM, err := pubkey.FromPrivateKeyPEM(privatePEM)
+	if err != nil {
+		return cryptoKey{}, fmt.Errorf("failed to derive public key: %w", err)
+	}
+	id, err := pubkey.Fingerprint(publicPEM)
+	if err != nil {
+		return cryptoKey{}, fmt.Errorf("failed to compute fingerprint: %w", err)
+	}
+	return cryptoKey{privatePEM: privatePEM, publicPEM: publicPEM, id: id}, nil
+}
+
+// nonEmpty returns the non-empty keys.
+func nonEmpty(keys ...string) []string {
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key != "" {
+			result = append(result, key)
+		}
+	}
+	return result
+}
@@ -0,0 +1,96 @@
+package keyring
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/pkg/sign"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func privateKeyPEM(t *testing.T) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+}
+
+func TestKeyringRotation(t *testing.T) {
+	oldCrypto, newCrypto := privateKeyPEM(t), privateKeyPEM(t)
+	ring, err := New("old", "new", oldCrypto, newCrypto, time.Hour)
+	require.NoError(t, err)
+
+	now := time.Now()
+	ring.now = func() time.Time { return now }
+
+	t.Run("During grace window", func(t *testing.T) {
+		assert.Equal(t, []string{"old", "new"}, ring.SigningKeys())
+		assert.Equal(t, "old", ring.SigningKey())
+		assert.Equal(t, []string{oldCrypto, newCrypto}, ring.CryptoKeys())
+
+		adv := ring.Advertisement()
+		assert.Equal(t, sign.KeyID("old"), adv.SigningKeyID)
+		assert.Equal(t, sign.KeyID("new"), adv.NextSigningKeyID)
+		assert.NotEmpty(t, adv.CryptoKeyID)
+		assert.NotEmpty(t, adv.NextCryptoKeyID)
+
+		keys := ring.PublicKeys()
+		require.Len(t, keys, 2)
+		assert.Equal(t, StatusCurrent, keys[0].Status)
+		assert.Equal(t, adv.NextCryptoKeyID, keys[1].ID)
+	})
+
+	t.Run("After grace window", func(t *testing.T) {
+		nextCryptoID := ring.Advertisement().NextCryptoKeyID
+		ring.now = func() time.Time { return now.Add(2 * time.Hour) }
+
+		assert.Equal(t, []string{"new"}, ring.SigningKeys())
+		assert.Equal(t, "new", ring.SigningKey())
+		assert.Equal(t, []string{newCrypto}, ring.CryptoKeys())
+		assert.Equal(t, Advertisement{SigningKeyID: sign.KeyID("new"), CryptoKeyID: nextCryptoID}, ring.Advertisement())
+		require.Len(t, ring.PublicKeys(), 1)
+	})
+}
+
+func TestKeyringWithoutRotation(t *testing.T) {
+	tests := []struct {
+		name           string
+		signing        string
+		expectedKeys   []string
+		expectedCrypto int
+		withCrypto     bool
+	}{
+		{name: "Nothing configured", expectedKeys: []string{}},
+		{name: "Signing only", signing: "key", expectedKeys: []string{"key"}},
+		{name: "Signing and crypto", signing: "key", expectedKeys: []string{"key"}, withCrypto: true, expectedCrypto: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var crypto string
+			if tt.withCrypto {
+				crypto = privateKeyPEM(t)
+			}
+			ring, err := New(tt.signing, "", crypto, "", 0)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedKeys, ring.SigningKeys())
+			assert.Equal(t, tt.signing, ring.SigningKey())
+			assert.Len(t, ring.CryptoKeys(), tt.expectedCrypto)
+			assert.Empty(t, ring.Advertisement().NextSigningKeyID)
+			assert.Empty(t, ring.Advertisement().NextCryptoKeyID)
+		})
+	}
+}
+
+func TestKeyringInvalidKey(t *testing.T) {
+	_, err := New("", "", "garbage", "", 0)
+	assert.Error(t, err)
+	_, err = New("", "", "", "garbage", 0)
+	assert.Error(t, err)
+}
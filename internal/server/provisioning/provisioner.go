@@ -0,0 +1,89 @@
+package provisioning
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// State is the provisioning file in effect.
+type State struct {
+	LoadedAt time.Time `json:"loaded_at"` // LoadedAt is the time the file was loaded.
+	File     *File     `json:"file"`      // File is the loaded content.
+	Path     string    `json:"path"`      // Path is the path the file was loaded from.
+}
+
+// Provisioner keeps the provisioning file in effect and notifies subscribers when it is reloaded.
+type Provisioner struct {
+	state       atomic.Pointer[State] // state is the file in effect.
+	mu          *sync.Mutex           // mu serializes reloads and subscriptions, so subscribers see every file in order.
+	path        string                // path is the path of the provisioning file.
+	subscribers []func(*File)         // subscribers apply every loaded file.
+}
+
+// NewProvisioner loads the provisioning file and creates a Provisioner holding it.
+//
+// Parameters:
+//   - path: The path to the YAML provisioning file.
+//
+// Returns:
+//   - *Provisioner: A pointer to the created Provisioner.
+//   - error: An error if the file cannot be loaded.
+func NewProvisioner(path string) (*Provisioner, error) {
+	p := &Provisioner{path: path, mu: &sync.Mutex{}}
+	if _, err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// State returns the provisioning file in effect.
+//
+// Returns:
+//   - State: The loaded file, its path and the load time.
+func (p *Provisioner) State() State {
+	return *p.state.Load()
+}
+
+// Current returns the content of the provisioning file in effect.
+//
+// Returns:
+//   - *File: The loaded content; it must not be modified.
+func (p *Provisioner) Current() *File {
+	return p.state.Load().File
+}
+
+// Reload reads the provisioning file again and, if it is valid, puts it in effect and passes it to the
+// subscribers. An invalid file leaves the previous one in effect.
+//
+// Returns:
+//   - State: The state in effect after the reload.
+//   - error: An error if the file cannot be loaded.
+func (p *Provisioner) Reload() (State, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f, err := Load(p.path)
+	if err != nil {
+		return State{}, fmt.Errorf("failed to reload provisioning: %w", err)
+	}
+	state := &State{File: f, Path: p.path, LoadedAt: time.Now()}
+	p.state.Store(state)
+	for _, apply := range p.subscribers {
+		apply(f)
+	}
+	return *state, nil
+}
+
+// Subscribe registers a function applying every loaded file and calls it with the file in effect.
+//
+// Parameters:
+//   - apply: The function applying a loaded file.
+func (p *Provisioner) Subscribe(apply func(*File)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.subscribers = append(p.subscribers, apply)
+	apply(p.Current())
+}
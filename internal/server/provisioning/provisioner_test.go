@@ -0,0 +1,47 @@
+package provisioning
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvisioner_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "provisioning.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("dashboards:\n  - name: memory\n"), 0o600))
+
+	p, err := NewProvisioner(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, p.State().Path)
+	assert.False(t, p.State().LoadedAt.IsZero())
+
+	var applied []*File
+	p.Subscribe(func(f *File) { applied = append(applied, f) })
+	require.Len(t, applied, 1, "subscribers must receive the file in effect")
+	assert.Equal(t, "memory", applied[0].Dashboards[0].Name)
+
+	require.NoError(t, os.WriteFile(path, []byte("dashboards:\n  - name: cpu\n"), 0o600))
+	state, err := p.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, "cpu", state.File.Dashboards[0].Name)
+	assert.Equal(t, "cpu", p.Current().Dashboards[0].Name)
+	require.Len(t, applied, 2)
+	assert.Equal(t, "cpu", applied[1].Dashboards[0].Name)
+
+	require.NoError(t, os.WriteFile(path, []byte("dashboards: ["), 0o600))
+	_, err = p.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, "cpu", p.Current().Dashboards[0].Name, "an invalid file must leave the previous one in effect")
+	assert.Len(t, applied, 2)
+}
+
+func TestNewProvisioner_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "provisioning.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("alert_rules:\n  - name: a\n"), 0o600))
+
+	_, err := NewProvisioner(path)
+	assert.Error(t, err)
+}
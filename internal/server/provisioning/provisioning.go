@@ -0,0 +1,222 @@
+// Package provisioning loads the declarative server provisioning file: the dashboards listed by /api/dashboards,
+// the alert rules evaluated by /api/alerts and the allowed metric names. Keeping these in one YAML file makes
+// the server behave the same in every environment the file is deployed to. The file is read at startup and
+// can be reloaded through the admin API; a file that fails to load or validate leaves the previous one in effect.
+package provisioning
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Alert rule comparison operators.
+const (
+	OpGreater      = ">"
+	OpGreaterEqual = ">="
+	OpLess         = "<"
+	OpLessEqual    = "<="
+	OpEqual        = "=="
+	OpNotEqual     = "!="
+)
+
+const (
+	// metricTypeGauge is the type of metrics alert rules watch by default.
+	metricTypeGauge = "gauge"
+	// metricTypeCounter is the type of counter metrics.
+	metricTypeCounter = "counter"
+	// metricTypeFloatCounter is the type of float counter metrics.
+	metricTypeFloatCounter = "fcounter"
+	// defaultSeverity is the severity of alert rules that do not set one.
+	defaultSeverity = "warning"
+)
+
+// File is the content of a provisioning file.
+type File struct {
+	// AllowedMetrics replaces the configured name filter if set.
+	AllowedMetrics *AllowedMetrics `json:"allowed_metrics,omitempty" yaml:"allowed_metrics"`
+	// Dashboards are the provisioned dashboards.
+	Dashboards []Dashboard `json:"dashboards" yaml:"dashboards"`
+	// AlertRules are the provisioned alert rules.
+	AlertRules []AlertRule `json:"alert_rules" yaml:"alert_rules"`
+}
+
+// AllowedMetrics holds the glob patterns (see path.Match) of the metric names the server stores.
+type AllowedMetrics struct {
+	Allow []string `json:"allow,omitempty" yaml:"allow"` // Allow lists the patterns of accepted names; empty accepts all.
+	Deny  []string `json:"deny,omitempty"  yaml:"deny"`  // Deny lists the patterns of refused names.
+}
+
+// Dashboard is a named group of panels.
+type Dashboard struct {
+	Name   string  `json:"name"   yaml:"name"`   // Name identifies the dashboard in /api/dashboards/:name.
+	Title  string  `json:"title"  yaml:"title"`  // Title is the human-readable dashboard title.
+	Panels []Panel `json:"panels" yaml:"panels"` // Panels are the panels of the dashboard.
+}
+
+// Panel shows the metrics whose names match its patterns.
+type Panel struct {
+	Title   string   `json:"title"   yaml:"title"`   // Title is the human-readable panel title.
+	Metrics []string `json:"metrics" yaml:"metrics"` // Metrics are the glob patterns of the shown metric names.
+}
+
+// AlertRule compares the current value of a metric with a threshold.
+type AlertRule struct {
+	Name      string  `json:"name"              yaml:"name"`      // Name identifies the rule.
+	Metric    string  `json:"metric"            yaml:"metric"`    // Metric is the name of the watched metric.
+	Type      string  `json:"type"              yaml:"type"`      // Type is the watched metric type; gauge by default.
+	Op        string  `json:"op"                yaml:"op"`        // Op is the comparison operator, e.g. ">".
+	Severity  string  `json:"severity"          yaml:"severity"`  // Severity is sent with the alert; warning by default.
+	Summary   string  `json:"summary,omitempty" yaml:"summary"`   // Summary describes the alert to people.
+	Threshold float64 `json:"threshold"         yaml:"threshold"` // Threshold is the value the metric is compared with.
+}
+
+// Fires reports whether the rule fires for a metric value.
+//
+// Parameters:
+//   - value: The current value of the watched metric.
+//
+// Returns:
+//   - bool: True if the value compared with the threshold satisfies the operator.
+func (r AlertRule) Fires(value float64) bool {
+	switch r.Op {
+	case OpGreater:
+		return value > r.Threshold
+	case OpGreaterEqual:
+		return value >= r.Threshold
+	case OpLess:
+		return value < r.Threshold
+	case OpLessEqual:
+		return value <= r.Threshold
+	case OpEqual:
+		return value == r.Threshold
+	case OpNotEqual:
+		return value != r.Threshold
+	default:
+		return false
+	}
+}
+
+// Load reads and validates a provisioning file.
+//
+// Parameters:
+//   - filePath: The path to the YAML file.
+//
+// Returns:
+//   - *File: The validated content with defaults applied.
+//   - error: An error if the file cannot be read, parsed or validated.
+func Load(filePath string) (*File, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provisioning file: %w", err)
+	}
+	f, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("provisioning file %q: %w", filePath, err)
+	}
+	return f, nil
+}
+
+// Parse parses and validates provisioning YAML. Unknown keys are rejected, so typos do not go unnoticed.
+//
+// Parameters:
+//   - data: The YAML document.
+//
+// Returns:
+//   - *File: The validated content with defaults applied; an empty document yields an empty File.
+//   - error: An error if the document is malformed or invalid.
+func Parse(data []byte) (*File, error) {
+	f := &File{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if f.Dashboards == nil {
+		f.Dashboards = []Dashboard{}
+	}
+	if f.AlertRules == nil {
+		f.AlertRules = []AlertRule{}
+	}
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// validate checks the file and fills in the defaults of alert rules.
+func (f *File) validate() error {
+	if f.AllowedMetrics != nil {
+		if err := validatePatterns(f.AllowedMetrics.Allow); err != nil {
+			return fmt.Errorf("allowed_metrics.allow: %w", err)
+		}
+		if err := validatePatterns(f.AllowedMetrics.Deny); err != nil {
+			return fmt.Errorf("allowed_metrics.deny: %w", err)
+		}
+	}
+
+	dashboards := make(map[string]struct{}, len(f.Dashboards))
+	for i, d := range f.Dashboards {
+		if d.Name == "" {
+			return fmt.Errorf("dashboard #%d: name is required", i+1)
+		}
+		if _, exists := dashboards[d.Name]; exists {
+			return fmt.Errorf("dashboard %q is repeated", d.Name)
+		}
+		dashboards[d.Name] = struct{}{}
+		for j, p := range d.Panels {
+			if len(p.Metrics) == 0 {
+				return fmt.Errorf("dashboard %q: panel #%d: metrics are required", d.Name, j+1)
+			}
+			if err := validatePatterns(p.Metrics); err != nil {
+				return fmt.Errorf("dashboard %q: panel #%d: %w", d.Name, j+1, err)
+			}
+		}
+	}
+
+	rules := make(map[string]struct{}, len(f.AlertRules))
+	for i := range f.AlertRules {
+		r := &f.AlertRules[i]
+		if r.Name == "" {
+			return fmt.Errorf("alert rule #%d: name is required", i+1)
+		}
+		if _, exists := rules[r.Name]; exists {
+			return fmt.Errorf("alert rule %q is repeated", r.Name)
+		}
+		rules[r.Name] = struct{}{}
+		if r.Metric == "" {
+			return fmt.Errorf("alert rule %q: metric is required", r.Name)
+		}
+		switch r.Type {
+		case "":
+			r.Type = metricTypeGauge
+		case metricTypeGauge, metricTypeCounter, metricTypeFloatCounter:
+		default:
+			return fmt.Errorf("alert rule %q: unsupported metric type %q", r.Name, r.Type)
+		}
+		switch r.Op {
+		case OpGreater, OpGreaterEqual, OpLess, OpLessEqual, OpEqual, OpNotEqual:
+		default:
+			return fmt.Errorf("alert rule %q: unsupported operator %q", r.Name, r.Op)
+		}
+		if r.Severity == "" {
+			r.Severity = defaultSeverity
+		}
+	}
+	return nil
+}
+
+// validatePatterns checks that every glob pattern is well-formed.
+func validatePatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
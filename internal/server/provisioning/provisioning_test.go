@@ -0,0 +1,141 @@
+package provisioning
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sampleYAML is a provisioning file using every section.
+const sampleYAML = `
+allowed_metrics:
+  allow: ["Heap*", "PollCount"]
+  deny: ["HeapReleased"]
+dashboards:
+  - name: memory
+    title: Memory
+    panels:
+      - title: Heap
+        metrics: ["Heap*"]
+alert_rules:
+  - name: heap_high
+    metric: HeapAlloc
+    op: ">"
+    threshold: 1e9
+    summary: Heap is above 1 GB.
+  - name: no_polls
+    metric: PollCount
+    type: counter
+    op: "=="
+    threshold: 0
+    severity: critical
+  - name: cpu_busy
+    metric: cpu_seconds
+    type: fcounter
+    op: ">"
+    threshold: 3600
+`
+
+func TestParse(t *testing.T) {
+	f, err := Parse([]byte(sampleYAML))
+	require.NoError(t, err)
+
+	allowed := &AllowedMetrics{Allow: []string{"Heap*", "PollCount"}, Deny: []string{"HeapReleased"}}
+	assert.Equal(t, allowed, f.AllowedMetrics)
+	assert.Equal(t, []Dashboard{{
+		Name:   "memory",
+		Title:  "Memory",
+		Panels: []Panel{{Title: "Heap", Metrics: []string{"Heap*"}}},
+	}}, f.Dashboards)
+	assert.Equal(t, []AlertRule{
+		{
+			Name:      "heap_high",
+			Metric:    "HeapAlloc",
+			Type:      "gauge",
+			Op:        ">",
+			Severity:  "warning",
+			Summary:   "Heap is above 1 GB.",
+			Threshold: 1e9,
+		},
+		{Name: "no_polls", Metric: "PollCount", Type: "counter", Op: "==", Severity: "critical"},
+		{Name: "cpu_busy", Metric: "cpu_seconds", Type: "fcounter", Op: ">", Severity: "warning", Threshold: 3600},
+	}, f.AlertRules)
+}
+
+func TestParse_Empty(t *testing.T) {
+	f, err := Parse(nil)
+	require.NoError(t, err)
+	assert.Nil(t, f.AllowedMetrics)
+	assert.Empty(t, f.Dashboards)
+	assert.Empty(t, f.AlertRules)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+	}{
+		{name: "Malformed YAML", yaml: "dashboards: ["},
+		{name: "Unknown key", yaml: "dashbaords: []"},
+		{name: "Invalid allow pattern", yaml: "allowed_metrics:\n  allow: [\"Heap[\"]"},
+		{name: "Invalid deny pattern", yaml: "allowed_metrics:\n  deny: [\"Heap[\"]"},
+		{name: "Dashboard without name", yaml: "dashboards:\n  - title: Memory"},
+		{name: "Repeated dashboard", yaml: "dashboards:\n  - name: a\n  - name: a"},
+		{name: "Panel without metrics", yaml: "dashboards:\n  - name: a\n    panels:\n      - title: Heap"},
+		{name: "Invalid panel pattern", yaml: "dashboards:\n  - name: a\n    panels:\n      - metrics: [\"[\"]"},
+		{name: "Rule without name", yaml: "alert_rules:\n  - metric: Alloc\n    op: \">\""},
+		{
+			name: "Repeated rule",
+			yaml: "alert_rules:\n  - {name: a, metric: x, op: \">\"}\n  - {name: a, metric: y, op: \">\"}",
+		},
+		{name: "Rule without metric", yaml: "alert_rules:\n  - name: a\n    op: \">\""},
+		{name: "Unsupported type", yaml: "alert_rules:\n  - {name: a, metric: x, type: info, op: \">\"}"},
+		{name: "Unsupported operator", yaml: "alert_rules:\n  - {name: a, metric: x, op: \"=>\"}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.yaml))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAlertRule_Fires(t *testing.T) {
+	tests := []struct {
+		op       string
+		value    float64
+		expected bool
+	}{
+		{op: OpGreater, value: 11, expected: true},
+		{op: OpGreater, value: 10, expected: false},
+		{op: OpGreaterEqual, value: 10, expected: true},
+		{op: OpLess, value: 9, expected: true},
+		{op: OpLess, value: 10, expected: false},
+		{op: OpLessEqual, value: 10, expected: true},
+		{op: OpEqual, value: 10, expected: true},
+		{op: OpNotEqual, value: 10, expected: false},
+		{op: "=>", value: 10, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.op, func(t *testing.T) {
+			assert.Equal(t, tt.expected, AlertRule{Op: tt.op, Threshold: 10}.Fires(tt.value))
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "provisioning.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(sampleYAML), 0o600))
+
+	f, err := Load(path)
+	require.NoError(t, err)
+	assert.Len(t, f.AlertRules, 3)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
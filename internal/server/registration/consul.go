@@ -0,0 +1,135 @@
+package registration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	// consulReadinessPath is the server endpoint Consul probes to check the instance health.
+	consulReadinessPath = "/readyz"
+	// consulDeregisterAfter is how long an instance may stay critical before Consul removes it.
+	consulDeregisterAfter = "1m"
+)
+
+// consulCheck is the health check of a Consul service registration.
+type consulCheck struct {
+	HTTP                           string `json:"HTTP"`
+	Interval                       string `json:"Interval"`
+	Timeout                        string `json:"Timeout"`
+	DeregisterCriticalServiceAfter string `json:"DeregisterCriticalServiceAfter"`
+}
+
+// consulService is the body of a Consul service registration.
+type consulService struct {
+	Check   consulCheck `json:"Check"`
+	ID      string      `json:"ID"`
+	Name    string      `json:"Name"`
+	Address string      `json:"Address"`
+	Port    int         `json:"Port"`
+}
+
+// Consul registers the server in the Consul agent catalog with an HTTP health check of its readiness endpoint,
+// so Consul stops returning the instance to agents as soon as the server is not ready.
+type Consul struct {
+	client        *resty.Client // client calls the Consul agent HTTP API.
+	id            string        // id is the registered service ID, empty before Register.
+	mu            sync.Mutex    // mu protects id.
+	checkInterval time.Duration // checkInterval is the period of the Consul health checks.
+}
+
+// NewConsul creates a Consul registry client.
+//
+// Parameters:
+//   - endpoint: The base URL of the Consul agent HTTP API, e.g. "http://localhost:8500".
+//   - checkInterval: The period of the health checks Consul runs against the server.
+//
+// Returns:
+//   - *Consul: The registry client.
+func NewConsul(endpoint string, checkInterval time.Duration) *Consul {
+	return &Consul{
+		client:        resty.New().SetBaseURL(endpoint).SetTimeout(requestTimeout),
+		checkInterval: checkInterval,
+	}
+}
+
+// Register registers the instance as a Consul service.
+//
+// Parameters:
+//   - ctx: The context of the request.
+//   - instance: The server instance.
+//
+// Returns:
+//   - error: An error if Consul rejects the registration or cannot be reached.
+func (c *Consul) Register(ctx context.Context, instance Instance) error {
+	body := consulService{
+		ID:      instance.ID,
+		Name:    instance.Service,
+		Address: instance.Host,
+		Port:    instance.Port,
+		Check: consulCheck{
+			HTTP:                           "http://" + instance.Address() + consulReadinessPath,
+			Interval:                       c.checkInterval.String(),
+			Timeout:                        requestTimeout.String(),
+			DeregisterCriticalServiceAfter: consulDeregisterAfter,
+		},
+	}
+	if err := c.put(ctx, "/v1/agent/service/register", body); err != nil {
+		return fmt.Errorf("failed to register in Consul: %w", err)
+	}
+	c.mu.Lock()
+	c.id = instance.ID
+	c.mu.Unlock()
+	return nil
+}
+
+// Refresh does nothing: Consul tracks the instance health with its own checks.
+//
+// Returns:
+//   - error: Always nil.
+func (c *Consul) Refresh(context.Context) error {
+	return nil
+}
+
+// Deregister removes the Consul service registration.
+//
+// Parameters:
+//   - ctx: The context of the request.
+//
+// Returns:
+//   - error: An error if Consul cannot be reached or rejects the request.
+func (c *Consul) Deregister(ctx context.Context) error {
+	c.mu.Lock()
+	id := c.id
+	c.id = ""
+	c.mu.Unlock()
+	if id == "" {
+		return nil
+	}
+	if err := c.put(ctx, "/v1/agent/service/deregister/"+url.PathEscape(id), nil); err != nil {
+		return fmt.Errorf("failed to deregister from Consul: %w", err)
+	}
+	return nil
+}
+
+// put sends a PUT request to the Consul API.
+func (c *Consul) put(ctx context.Context, path string, body any) error {
+	req := c.client.R().SetContext(ctx)
+	if body != nil {
+		req.SetBody(body)
+	}
+	resp, err := req.Put(path)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("unexpected status %s: %s", resp.Status(), resp.String())
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package registration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsul_RegisterAndDeregister(t *testing.T) {
+	var registered consulService
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/v1/agent/service/register" {
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&registered))
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	c := NewConsul(ts.URL, 10*time.Second)
+	instance := Instance{ID: "metricol-10.0.0.1-8080", Service: "metricol", Host: "10.0.0.1", Port: 8080}
+	require.NoError(t, c.Register(context.Background(), instance))
+	require.NoError(t, c.Refresh(context.Background()))
+	require.NoError(t, c.Deregister(context.Background()))
+	require.NoError(t, c.Deregister(context.Background()), "deregistering twice does nothing")
+
+	assert.Equal(t, []string{
+		"/v1/agent/service/register",
+		"/v1/agent/service/deregister/metricol-10.0.0.1-8080",
+	}, paths)
+	assert.Equal(t, "metricol", registered.Name)
+	assert.Equal(t, 8080, registered.Port)
+	assert.Equal(t, "http://10.0.0.1:8080/readyz", registered.Check.HTTP)
+	assert.Equal(t, "10s", registered.Check.Interval)
+}
+
+func TestConsul_RegisterRejected(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "Invalid check", http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	c := NewConsul(ts.URL, 10*time.Second)
+	require.Error(t, c.Register(context.Background(), Instance{ID: "id", Service: "metricol"}))
+	require.NoError(t, c.Deregister(context.Background()), "nothing to deregister after a failed registration")
+}
@@ -0,0 +1,160 @@
+package registration
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// errLeaseExpired is returned by Refresh once the etcd lease of the registration has expired.
+var errLeaseExpired = errors.New("etcd lease expired")
+
+// etcdLease is a lease in the etcd v3 JSON API, which encodes 64-bit integers as strings.
+type etcdLease struct {
+	ID  string `json:"ID,omitempty"`
+	TTL string `json:"TTL,omitempty"`
+}
+
+// etcdKeepAlive is the response of the etcd v3 JSON API to a lease keep-alive.
+type etcdKeepAlive struct {
+	Result etcdLease `json:"result"`
+}
+
+// Etcd registers the server as a key under EtcdKeyPrefix holding its address, attached to a lease.
+// The lease is kept alive by Refresh, so the key disappears once the server stops refreshing it.
+type Etcd struct {
+	client *resty.Client // client calls the etcd v3 JSON API.
+	lease  string        // lease is the ID of the lease the key is attached to, empty before Register.
+	mu     sync.Mutex    // mu protects lease.
+	ttl    time.Duration // ttl is the lease time to live.
+}
+
+// NewEtcd creates an etcd registry client.
+//
+// Parameters:
+//   - endpoint: The base URL of the etcd v3 JSON API, e.g. "http://localhost:2379".
+//   - ttl: The time to live of the registration lease; Refresh must be called more often.
+//
+// Returns:
+//   - *Etcd: The registry client.
+func NewEtcd(endpoint string, ttl time.Duration) *Etcd {
+	return &Etcd{
+		client: resty.New().SetBaseURL(endpoint).SetTimeout(requestTimeout),
+		ttl:    ttl,
+	}
+}
+
+// EtcdKey returns the etcd key of a registered instance.
+//
+// Parameters:
+//   - service: The service name.
+//   - id: The instance ID.
+//
+// Returns:
+//   - string: The key, e.g. "/services/metricol/metricol-host-8080".
+func EtcdKey(service, id string) string {
+	return EtcdKeyPrefix + service + "/" + id
+}
+
+// Register grants a lease and puts the instance address under its key with that lease.
+//
+// Parameters:
+//   - ctx: The context of the requests.
+//   - instance: The server instance.
+//
+// Returns:
+//   - error: An error if etcd cannot be reached or rejects a request.
+func (e *Etcd) Register(ctx context.Context, instance Instance) error {
+	var lease etcdLease
+	ttl := max(int64(e.ttl/time.Second), 1)
+	if err := e.post(ctx, "/v3/lease/grant", map[string]any{"TTL": ttl}, &lease); err != nil {
+		return fmt.Errorf("failed to grant etcd lease: %w", err)
+	}
+	if lease.ID == "" {
+		return errors.New("failed to grant etcd lease: no lease ID in the response")
+	}
+
+	put := map[string]any{
+		"key":   base64.StdEncoding.EncodeToString([]byte(EtcdKey(instance.Service, instance.ID))),
+		"value": base64.StdEncoding.EncodeToString([]byte(instance.Address())),
+		"lease": lease.ID,
+	}
+	if err := e.post(ctx, "/v3/kv/put", put, nil); err != nil {
+		return fmt.Errorf("failed to put etcd key: %w", err)
+	}
+
+	e.mu.Lock()
+	e.lease = lease.ID
+	e.mu.Unlock()
+	return nil
+}
+
+// Refresh keeps the lease of the registration alive.
+//
+// Parameters:
+//   - ctx: The context of the request.
+//
+// Returns:
+//   - error: An error if the lease has expired or etcd cannot be reached.
+func (e *Etcd) Refresh(ctx context.Context) error {
+	e.mu.Lock()
+	id := e.lease
+	e.mu.Unlock()
+	if id == "" {
+		return errLeaseExpired
+	}
+
+	var resp etcdKeepAlive
+	if err := e.post(ctx, "/v3/lease/keepalive", etcdLease{ID: id}, &resp); err != nil {
+		return fmt.Errorf("failed to keep etcd lease alive: %w", err)
+	}
+	if resp.Result.TTL == "" || resp.Result.TTL == "0" {
+		return errLeaseExpired
+	}
+	return nil
+}
+
+// Deregister revokes the lease, which deletes the key of the registration.
+//
+// Parameters:
+//   - ctx: The context of the request.
+//
+// Returns:
+//   - error: An error if etcd cannot be reached or rejects the request.
+func (e *Etcd) Deregister(ctx context.Context) error {
+	e.mu.Lock()
+	id := e.lease
+	e.lease = ""
+	e.mu.Unlock()
+	if id == "" {
+		return nil
+	}
+	if err := e.post(ctx, "/v3/lease/revoke", etcdLease{ID: id}, nil); err != nil {
+		return fmt.Errorf("failed to revoke etcd lease: %w", err)
+	}
+	return nil
+}
+
+// post sends a POST request to the etcd API and decodes the response into result, if it is not nil.
+func (e *Etcd) post(ctx context.Context, path string, body any, result any) error {
+	resp, err := e.client.R().SetContext(ctx).SetBody(body).Post(path)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("unexpected status %s: %s", resp.Status(), resp.String())
+	}
+	if result != nil {
+		if err := json.Unmarshal(resp.Body(), result); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package registration
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEtcd serves the lease and put endpoints of the etcd v3 JSON API.
+type fakeEtcd struct {
+	kv      map[string]string
+	revoked []string
+	ttl     string
+}
+
+func (f *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	switch r.URL.Path {
+	case "/v3/lease/grant":
+		_, _ = w.Write([]byte(`{"ID":"7587","TTL":"30"}`))
+	case "/v3/kv/put":
+		key, _ := base64.StdEncoding.DecodeString(body["key"].(string))     //nolint:forcetypeassert // test data
+		value, _ := base64.StdEncoding.DecodeString(body["value"].(string)) //nolint:forcetypeassert // test data
+		f.kv[string(key)] = string(value)
+		_, _ = w.Write([]byte(`{}`))
+	case "/v3/lease/keepalive":
+		_, _ = w.Write([]byte(`{"result":{"ID":"7587","TTL":"` + f.ttl + `"}}`))
+	case "/v3/lease/revoke":
+		f.revoked = append(f.revoked, body["ID"].(string)) //nolint:forcetypeassert // test data
+		_, _ = w.Write([]byte(`{}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestEtcd_Lifecycle(t *testing.T) {
+	fake := &fakeEtcd{kv: map[string]string{}, ttl: "30"}
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	e := NewEtcd(ts.URL, 30*time.Second)
+	instance := Instance{ID: "metricol-10.0.0.1-8080", Service: "metricol", Host: "10.0.0.1", Port: 8080}
+	require.NoError(t, e.Register(context.Background(), instance))
+	assert.Equal(t, map[string]string{"/services/metricol/metricol-10.0.0.1-8080": "10.0.0.1:8080"}, fake.kv)
+
+	require.NoError(t, e.Refresh(context.Background()))
+	fake.ttl = ""
+	require.ErrorIs(t, e.Refresh(context.Background()), errLeaseExpired)
+
+	require.NoError(t, e.Deregister(context.Background()))
+	assert.Equal(t, []string{"7587"}, fake.revoked)
+	require.ErrorIs(t, e.Refresh(context.Background()), errLeaseExpired)
+}
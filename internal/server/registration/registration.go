@@ -0,0 +1,232 @@
+// Package registration registers the server in a service registry, Consul or etcd, so agents in dynamic
+// environments can discover it. The registration follows the server health: Consul probes the readiness
+// endpoint itself, while the etcd lease is kept alive only as long as the server is ready.
+package registration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdyunin/metricol.git/pkg/clock"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// SchemeConsul selects the Consul registry.
+	SchemeConsul = "consul"
+	// SchemeEtcd selects the etcd registry.
+	SchemeEtcd = "etcd"
+	// DefaultServiceName is the service name used when the registry URL has no path.
+	DefaultServiceName = "metricol"
+	// EtcdKeyPrefix is the prefix of the etcd keys of registered instances, followed by "<service>/<id>".
+	EtcdKeyPrefix = "/services/"
+	// requestTimeout limits every request to the registry.
+	requestTimeout = 5 * time.Second
+	// etcdLeaseFactor is the number of refresh periods an etcd lease outlives, so a missed refresh is tolerated.
+	etcdLeaseFactor = 3
+)
+
+// ErrUnsupportedRegistry is returned for a registry URL with an unknown scheme.
+var ErrUnsupportedRegistry = errors.New("unsupported registry, expected consul:// or etcd://")
+
+// Instance describes the registered server.
+type Instance struct {
+	ID      string // ID identifies the instance within the service.
+	Service string // Service is the service name agents look up.
+	Host    string // Host is the host agents connect to.
+	Port    int    // Port is the port agents connect to.
+}
+
+// Address returns the address agents connect to.
+//
+// Returns:
+//   - string: The address in the host:port form.
+func (i Instance) Address() string {
+	return net.JoinHostPort(i.Host, strconv.Itoa(i.Port))
+}
+
+// Registry is a service registry the server registers in.
+type Registry interface {
+	// Register adds the instance to the registry.
+	Register(ctx context.Context, instance Instance) error
+	// Refresh tells the registry the instance is still alive; it fails if the registration was lost.
+	Refresh(ctx context.Context) error
+	// Deregister removes the instance from the registry.
+	Deregister(ctx context.Context) error
+}
+
+// Target is a parsed registry URL, e.g. "consul://localhost:8500/metricol".
+type Target struct {
+	Scheme   string // Scheme is SchemeConsul or SchemeEtcd.
+	Endpoint string // Endpoint is the HTTP base URL of the registry API.
+	Service  string // Service is the service name, DefaultServiceName if the URL has no path.
+}
+
+// ParseURL parses a registry URL of the form consul://host:port/service or etcd://host:port/service.
+//
+// Parameters:
+//   - raw: The registry URL.
+//
+// Returns:
+//   - Target: The parsed registry.
+//   - error: An error if the URL is malformed or its scheme is not supported.
+func ParseURL(raw string) (Target, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Target{}, fmt.Errorf("invalid registry URL %q: %w", raw, err)
+	}
+	if u.Scheme != SchemeConsul && u.Scheme != SchemeEtcd {
+		return Target{}, fmt.Errorf("registry URL %q: %w", raw, ErrUnsupportedRegistry)
+	}
+	if u.Host == "" {
+		return Target{}, fmt.Errorf("registry URL %q has no host", raw)
+	}
+	service := strings.Trim(u.Path, "/")
+	if service == "" {
+		service = DefaultServiceName
+	}
+	if strings.Contains(service, "/") {
+		return Target{}, fmt.Errorf("registry URL %q: the service name must not contain \"/\"", raw)
+	}
+	return Target{Scheme: u.Scheme, Endpoint: "http://" + u.Host, Service: service}, nil
+}
+
+// New creates the client of the registry a URL points to.
+//
+// Parameters:
+//   - raw: The registry URL, e.g. "consul://localhost:8500/metricol".
+//   - checkInterval: The period of the health checks: the Consul check interval and the period the etcd lease
+//     must be refreshed within, which outlives it etcdLeaseFactor times.
+//
+// Returns:
+//   - Registry: The registry client.
+//   - string: The service name to register under.
+//   - error: An error if the URL is invalid.
+func New(raw string, checkInterval time.Duration) (Registry, string, error) {
+	target, err := ParseURL(raw)
+	if err != nil {
+		return nil, "", err
+	}
+	if target.Scheme == SchemeConsul {
+		return NewConsul(target.Endpoint, checkInterval), target.Service, nil
+	}
+	return NewEtcd(target.Endpoint, etcdLeaseFactor*checkInterval), target.Service, nil
+}
+
+// Registrar keeps the server registered while it runs and removes the registration on shutdown.
+type Registrar struct {
+	registry Registry           // registry is the registry the instance is registered in.
+	ready    func() bool        // ready reports whether the server can serve agents.
+	clock    clock.Clock        // clock drives the refresh loop.
+	logger   *zap.SugaredLogger // logger reports registration changes and failures.
+	instance Instance           // instance is the registered server.
+	interval time.Duration      // interval is the period between refreshes.
+}
+
+// NewRegistrar creates a Registrar.
+//
+// Parameters:
+//   - registry: The registry to register in.
+//   - instance: The server instance.
+//   - ready: The function reporting whether the server is ready; the registration is not refreshed while it is not.
+//   - interval: The period between refreshes.
+//   - logger: The logger reporting registration changes and failures.
+//
+// Returns:
+//   - *Registrar: The registrar.
+func NewRegistrar(
+	registry Registry,
+	instance Instance,
+	ready func() bool,
+	interval time.Duration,
+	logger *zap.SugaredLogger,
+) *Registrar {
+	return &Registrar{
+		registry: registry,
+		ready:    ready,
+		clock:    clock.Real(),
+		logger:   logger,
+		instance: instance,
+		interval: interval,
+	}
+}
+
+// Start registers the instance and refreshes the registration every interval until the context is canceled,
+// then deregisters it. A lost or failed registration is retried on the next tick. While the server is not ready
+// the registration is neither made nor refreshed, so a registration with a lease expires.
+//
+// Parameters:
+//   - ctx: The context controlling the registration lifecycle.
+func (r *Registrar) Start(ctx context.Context) {
+	registered := r.isReady() && r.register(ctx)
+
+	ticker := r.clock.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if registered {
+				r.deregister()
+			}
+			return
+		case <-ticker.C():
+			registered = r.tick(ctx, registered)
+		}
+	}
+}
+
+// tick refreshes the registration, registering the instance again if it is missing, and returns
+// whether the instance is registered.
+func (r *Registrar) tick(ctx context.Context, registered bool) bool {
+	if !r.isReady() {
+		r.logger.Warn("Server is not ready, skipping the registration refresh")
+		return registered
+	}
+	if !registered {
+		return r.register(ctx)
+	}
+
+	refreshCtx, cancel := r.clock.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	if err := r.registry.Refresh(refreshCtx); err != nil {
+		r.logger.Warnf("Registration of %s was lost, registering again: %v", r.instance.ID, err)
+		return r.register(ctx)
+	}
+	return true
+}
+
+// isReady reports whether the server is ready to be registered.
+func (r *Registrar) isReady() bool {
+	return r.ready == nil || r.ready()
+}
+
+// register registers the instance and returns whether it succeeded.
+func (r *Registrar) register(ctx context.Context) bool {
+	registerCtx, cancel := r.clock.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	if err := r.registry.Register(registerCtx, r.instance); err != nil {
+		r.logger.Errorf("Failed to register %s as %s: %v", r.instance.Address(), r.instance.Service, err)
+		return false
+	}
+	r.logger.Infof("Registered %s as %s with ID %s", r.instance.Address(), r.instance.Service, r.instance.ID)
+	return true
+}
+
+// deregister removes the registration, with a context of its own since the run context is already canceled.
+func (r *Registrar) deregister() {
+	ctx, cancel := r.clock.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	if err := r.registry.Deregister(ctx); err != nil {
+		r.logger.Errorf("Failed to deregister %s: %v", r.instance.ID, err)
+		return
+	}
+	r.logger.Infof("Deregistered %s", r.instance.ID)
+}
@@ -0,0 +1,117 @@
+package registration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/pkg/clock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeRegistry reports every call on calls and fails Refresh with refreshErr.
+type fakeRegistry struct {
+	calls      chan string
+	refreshErr error
+}
+
+func (f *fakeRegistry) Register(context.Context, Instance) error {
+	f.calls <- "register"
+	return nil
+}
+
+func (f *fakeRegistry) Refresh(context.Context) error {
+	f.calls <- "refresh"
+	return f.refreshErr
+}
+
+func (f *fakeRegistry) Deregister(context.Context) error {
+	f.calls <- "deregister"
+	return nil
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected Target
+		wantErr  bool
+	}{
+		{
+			name:     "consul with service",
+			raw:      "consul://localhost:8500/metrics",
+			expected: Target{Scheme: SchemeConsul, Endpoint: "http://localhost:8500", Service: "metrics"},
+		},
+		{
+			name:     "etcd with default service",
+			raw:      "etcd://[::1]:2379",
+			expected: Target{Scheme: SchemeEtcd, Endpoint: "http://[::1]:2379", Service: DefaultServiceName},
+		},
+		{name: "unsupported scheme", raw: "zookeeper://localhost:2181", wantErr: true},
+		{name: "no host", raw: "consul:///metricol", wantErr: true},
+		{name: "nested service", raw: "etcd://localhost:2379/a/b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseURL(tt.raw)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestRegistrar_Start(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fake := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	registry := &fakeRegistry{calls: make(chan string), refreshErr: errors.New("lease expired")}
+	instance := Instance{ID: "metricol-1", Service: "metricol", Host: "10.0.0.1", Port: 8080}
+	r := NewRegistrar(registry, instance, nil, 10*time.Second, zap.NewNop().Sugar())
+	r.clock = fake
+
+	done := make(chan struct{})
+	go func() {
+		r.Start(ctx)
+		close(done)
+	}()
+
+	assert.Equal(t, "register", <-registry.calls)
+	fake.BlockUntil(1)
+	fake.Advance(10 * time.Second)
+	assert.Equal(t, "refresh", <-registry.calls)
+	assert.Equal(t, "register", <-registry.calls, "a lost registration is made again")
+
+	cancel()
+	assert.Equal(t, "deregister", <-registry.calls)
+	<-done
+}
+
+func TestRegistrar_TickWhileNotReady(t *testing.T) {
+	registry := &fakeRegistry{calls: make(chan string, 1)}
+	ready := false
+	isReady := func() bool { return ready }
+	r := NewRegistrar(registry, Instance{ID: "metricol-1"}, isReady, time.Second, zap.NewNop().Sugar())
+
+	assert.False(t, r.tick(context.Background(), false), "an unready server is not registered")
+	assert.True(t, r.tick(context.Background(), true), "an unready server keeps its registration to expire")
+	assert.Empty(t, registry.calls)
+
+	ready = true
+	assert.True(t, r.tick(context.Background(), true))
+	assert.Equal(t, "refresh", <-registry.calls)
+}
+
+func TestInstance_Address(t *testing.T) {
+	assert.Equal(t, "10.0.0.1:8080", Instance{Host: "10.0.0.1", Port: 8080}.Address())
+	assert.Equal(t, "[2001:db8::1]:8080", Instance{Host: "2001:db8::1", Port: 8080}.Address())
+}
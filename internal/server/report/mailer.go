@@ -0,0 +1,84 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Mailer sends report emails through an SMTP server. The connection is upgraded with STARTTLS when the server
+// supports it.
+type Mailer struct {
+	auth smtp.Auth // auth authenticates to the server, nil without credentials.
+	// send delivers a message; smtp.SendMail in production.
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+	addr string   // addr is the host:port of the SMTP server.
+	from string   // from is the sender address.
+	to   []string // to are the recipient addresses.
+}
+
+// NewMailer validates the settings and creates a Mailer.
+//
+// Parameters:
+//   - addr: The host:port of the SMTP server.
+//   - username: The user to authenticate as with PLAIN authentication; empty sends without authentication.
+//   - password: The password of the user.
+//   - from: The sender address.
+//   - to: The recipient addresses.
+//
+// Returns:
+//   - *Mailer: A pointer to the created Mailer.
+//   - error: An error if the server address is malformed, or the sender or the recipients are missing.
+func NewMailer(addr, username, password, from string, to []string) (*Mailer, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP server address %q: %w", addr, err)
+	}
+	if from == "" {
+		return nil, errors.New("the sender address is required")
+	}
+	if len(to) == 0 {
+		return nil, errors.New("at least one recipient is required")
+	}
+	for _, address := range append([]string{from}, to...) {
+		if strings.ContainsAny(address, "\r\n") {
+			return nil, fmt.Errorf("invalid email address %q", address)
+		}
+	}
+
+	m := &Mailer{send: smtp.SendMail, addr: addr, from: from, to: to}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m, nil
+}
+
+// Send emails a plain-text message to the recipients.
+//
+// Parameters:
+//   - subject: The subject of the email.
+//   - body: The plain-text body.
+//
+// Returns:
+//   - error: An error if the SMTP server refuses the message.
+func (m *Mailer) Send(subject, body string) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", m.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(m.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+
+	if err := m.send(m.addr, m.auth, m.from, m.to, msg.Bytes()); err != nil {
+		return fmt.Errorf("failed to send email via %s: %w", m.addr, err)
+	}
+	return nil
+}
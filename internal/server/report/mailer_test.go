@@ -0,0 +1,59 @@
+package report
+
+import (
+	"errors"
+	"net/smtp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMailer_Send(t *testing.T) {
+	m, err := NewMailer("smtp.example.com:587", "reports", "secret", "metricol@example.com",
+		[]string{"ops@example.com", "dev@example.com"})
+	require.NoError(t, err)
+	require.NotNil(t, m.auth)
+
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMsg []byte
+	m.send = func(addr string, _ smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotFrom, gotTo, gotMsg = addr, from, to, msg
+		return nil
+	}
+
+	require.NoError(t, m.Send("Metricol report", "line one\nline two\n"))
+	assert.Equal(t, "smtp.example.com:587", gotAddr)
+	assert.Equal(t, "metricol@example.com", gotFrom)
+	assert.Equal(t, []string{"ops@example.com", "dev@example.com"}, gotTo)
+	assert.Contains(t, string(gotMsg), "To: ops@example.com, dev@example.com\r\n")
+	assert.Contains(t, string(gotMsg), "Subject: Metricol report\r\n")
+	assert.Contains(t, string(gotMsg), "\r\n\r\nline one\r\nline two\r\n")
+
+	m.send = func(string, smtp.Auth, string, []string, []byte) error {
+		return errors.New("relay denied")
+	}
+	assert.Error(t, m.Send("Metricol report", "body"))
+}
+
+func TestNewMailer_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		addr string
+		from string
+		to   []string
+	}{
+		{name: "address without port", addr: "smtp.example.com", from: "a@example.com", to: []string{"b@example.com"}},
+		{name: "no sender", addr: "smtp.example.com:25", to: []string{"b@example.com"}},
+		{name: "no recipients", addr: "smtp.example.com:25", from: "a@example.com"},
+		{name: "header injection", addr: "smtp.example.com:25", from: "a@example.com", to: []string{"b@x\r\nBcc: c@x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMailer(tt.addr, "", "", tt.from, tt.to)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+// Package report emails a summary of the stored metrics on a cron-like schedule: the metrics with the largest
+// values, the largest changes since the previous report and the states of the provisioned alert rules.
+// Reports are sent through an SMTP server, so operators get an overview without opening the dashboards.
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/provisioning"
+	"github.com/gdyunin/metricol.git/pkg/clock"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultTop is how many metrics a report lists as top metrics and as changes.
+	DefaultTop = 10
+	// readTimeout bounds reading the metrics of a report.
+	readTimeout = time.Minute
+)
+
+// MetricsReader reads the stored metrics.
+type MetricsReader interface {
+	All(ctx context.Context) (*entity.Metrics, error)
+}
+
+// AlertRules holds the provisioning file with the alert rules.
+type AlertRules interface {
+	Current() *provisioning.File
+}
+
+// Sender delivers a report.
+type Sender interface {
+	Send(subject, body string) error
+}
+
+// Job sends a report of the stored metrics on a schedule. Changes are measured against the previous report
+// sent by the job, so the first report lists no changes.
+type Job struct {
+	since    time.Time          // since is the time of the previous report.
+	metrics  MetricsReader      // metrics reads the stored metrics.
+	rules    AlertRules         // rules holds the alert rules, nil without provisioning.
+	sender   Sender             // sender delivers the reports.
+	clock    clock.Clock        // clock drives the schedule.
+	logger   *zap.SugaredLogger // logger is used for logging the sent reports.
+	schedule *Schedule          // schedule is when reports are sent.
+	previous map[string]float64 // previous are the values at the previous report, nil before the first one.
+	top      int                // top is how many metrics are listed as top metrics and as changes.
+}
+
+// NewJob creates a Job.
+//
+// Parameters:
+//   - schedule: When reports are sent.
+//   - metrics: The source of the stored metrics.
+//   - rules: The source of the alert rules; nil reports no alerts.
+//   - sender: The delivery of the reports.
+//   - logger: Logger for report operations.
+//
+// Returns:
+//   - *Job: A pointer to the created Job.
+func NewJob(
+	schedule *Schedule,
+	metrics MetricsReader,
+	rules AlertRules,
+	sender Sender,
+	logger *zap.SugaredLogger,
+) *Job {
+	return &Job{
+		schedule: schedule,
+		metrics:  metrics,
+		rules:    rules,
+		sender:   sender,
+		clock:    clock.Real(),
+		logger:   logger,
+		top:      DefaultTop,
+	}
+}
+
+// Start sends the reports on schedule until the context is canceled. A report that cannot be sent is logged
+// and skipped.
+//
+// Parameters:
+//   - ctx: The context controlling the job lifecycle.
+func (j *Job) Start(ctx context.Context) {
+	j.logger.Infof("Sending reports on schedule %q", j.schedule)
+	for {
+		next := j.schedule.Next(j.clock.Now())
+		if next.IsZero() {
+			j.logger.Errorf("Schedule %q never runs again: stopping reports", j.schedule)
+			return
+		}
+		timer := j.clock.NewTimer(next.Sub(j.clock.Now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			j.logger.Info("Context canceled: stopping reports")
+			return
+		case <-timer.C():
+			if err := j.sendOnce(ctx); err != nil {
+				j.logger.Errorf("Failed to send report: %v", err)
+			}
+		}
+	}
+}
+
+// sendOnce summarizes the stored metrics and sends the report.
+func (j *Job) sendOnce(ctx context.Context) error {
+	metrics, err := j.readMetrics(ctx)
+	if err != nil {
+		return err
+	}
+	var rules []provisioning.AlertRule
+	if j.rules != nil {
+		rules = j.rules.Current().AlertRules
+	}
+
+	now := j.clock.Now()
+	summary, values := Summarize(now, metrics, j.previous, j.since, rules, j.top)
+	var body bytes.Buffer
+	if err := summary.Render(&body); err != nil {
+		return err
+	}
+	subject := fmt.Sprintf("Metricol report: %d metrics, %d alerts firing", summary.Metrics, len(summary.Alerts.Firing))
+	if err := j.sender.Send(subject, body.String()); err != nil {
+		return err //nolint:wrapcheck // the mailer names the server.
+	}
+
+	j.previous, j.since = values, now
+	j.logger.Infof("Sent report of %d metrics", summary.Metrics)
+	return nil
+}
+
+// readMetrics reads the stored metrics within readTimeout.
+func (j *Job) readMetrics(ctx context.Context) (*entity.Metrics, error) {
+	readCtx, cancel := j.clock.WithTimeout(ctx, readTimeout)
+	defer cancel()
+
+	metrics, err := j.metrics.All(readCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metrics: %w", err)
+	}
+	return metrics, nil
+}
@@ -0,0 +1,60 @@
+package report
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+	"github.com/gdyunin/metricol.git/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// mailbox records the sent reports.
+type mailbox struct {
+	subjects chan string
+	bodies   chan string
+}
+
+func (m *mailbox) Send(subject, body string) error {
+	m.subjects <- subject
+	m.bodies <- body
+	return nil
+}
+
+func TestJob_Start(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo := repository.NewInMemoryRepository(zap.NewNop().Sugar())
+	require.NoError(t, repo.Update(ctx, &entity.Metric{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 100.0}))
+	schedule, err := ParseSchedule("@hourly")
+	require.NoError(t, err)
+	box := &mailbox{subjects: make(chan string), bodies: make(chan string)}
+	fake := clock.NewFake(time.Date(2025, time.January, 15, 10, 30, 0, 0, time.UTC))
+	job := NewJob(schedule, repo, nil, box, zap.NewNop().Sugar())
+	job.clock = fake
+
+	done := make(chan struct{})
+	go func() {
+		job.Start(ctx)
+		close(done)
+	}()
+
+	fake.BlockUntil(1)
+	fake.Advance(30 * time.Minute)
+	assert.Equal(t, "Metricol report: 1 metrics, 0 alerts firing", <-box.subjects)
+	assert.NotContains(t, <-box.bodies, "Largest changes", "the first report has nothing to compare with")
+
+	require.NoError(t, repo.Update(ctx, &entity.Metric{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 250.0}))
+	fake.BlockUntil(1)
+	fake.Advance(time.Hour)
+	<-box.subjects
+	assert.Contains(t, <-box.bodies, "gauge/HeapAlloc: 100 -> 250 (+150)")
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,195 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scheduleHorizon bounds the search for the next run, so schedules that never fire, e.g. on February 30,
+// are detected.
+const scheduleHorizon = 5 * 366 * 24 * time.Hour
+
+// scheduleMacros are the shorthands accepted in place of the five schedule fields.
+var scheduleMacros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// Schedule is a cron-like schedule: the minute, hour, day of month, month and day of week fields, each "*",
+// a value, a range "a-b", a step "*/n" or "a-b/n", or a comma-separated list of those. Days of the week run
+// from 0, Sunday, to 6; 7 is Sunday too. As in cron, when both days are restricted a day matching either runs
+// the job. Times are in the location of the time the next run is computed from.
+type Schedule struct {
+	minutes  fieldSet // minutes are the minutes of the hour the job runs at.
+	hours    fieldSet // hours are the hours of the day the job runs at.
+	days     fieldSet // days are the days of the month the job runs on.
+	months   fieldSet // months are the months the job runs in.
+	weekdays fieldSet // weekdays are the days of the week the job runs on.
+	anyDay   bool     // anyDay is set when the day of month field is "*".
+	anyWeek  bool     // anyWeek is set when the day of week field is "*".
+	expr     string   // expr is the schedule as configured.
+}
+
+// fieldSet marks the values a schedule field matches.
+type fieldSet uint64
+
+// has reports whether the field matches the value.
+func (f fieldSet) has(v int) bool {
+	return f&(1<<uint(v)) != 0
+}
+
+// ParseSchedule parses a cron-like schedule such as "0 8 * * 1-5" or one of @hourly, @daily, @weekly and
+// @monthly.
+//
+// Parameters:
+//   - expr: The schedule.
+//
+// Returns:
+//   - *Schedule: The parsed schedule.
+//   - error: An error if the schedule is malformed or never runs.
+func ParseSchedule(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	fieldsExpr := expr
+	if macro, ok := scheduleMacros[expr]; ok {
+		fieldsExpr = macro
+	}
+	fields := strings.Fields(fieldsExpr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q must have 5 fields: minute hour day month weekday", expr)
+	}
+
+	s := &Schedule{expr: expr, anyDay: fields[2] == "*", anyWeek: fields[4] == "*"}
+	bounds := []struct {
+		set      *fieldSet
+		name     string
+		min, max int
+	}{
+		{&s.minutes, "minute", 0, 59},
+		{&s.hours, "hour", 0, 23},
+		{&s.days, "day of month", 1, 31},
+		{&s.months, "month", 1, 12},
+		{&s.weekdays, "day of week", 0, 7},
+	}
+	for i, b := range bounds {
+		set, err := parseField(fields[i], b.min, b.max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in schedule %q: %w", b.name, expr, err)
+		}
+		*b.set = set
+	}
+	if s.weekdays.has(7) {
+		s.weekdays |= 1
+	}
+
+	if s.Next(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, fmt.Errorf("schedule %q never runs", expr)
+	}
+	return s, nil
+}
+
+// String returns the schedule as configured.
+//
+// Returns:
+//   - string: The schedule.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next returns the first time after t the schedule runs at, to the minute.
+//
+// Parameters:
+//   - t: The time to search from.
+//
+// Returns:
+//   - time.Time: The next run, in the location of t, or the zero time if the schedule never runs.
+func (s *Schedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(scheduleHorizon)
+	for !next.After(limit) {
+		switch {
+		case !s.months.has(int(next.Month())):
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+		case !s.dayMatches(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+		case !s.hours.has(next.Hour()):
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+		case !s.minutes.has(next.Minute()):
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches reports whether the schedule runs on the day of t.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	day, weekday := s.days.has(t.Day()), s.weekdays.has(int(t.Weekday()))
+	switch {
+	case s.anyDay && s.anyWeek:
+		return true
+	case s.anyDay:
+		return weekday
+	case s.anyWeek:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+// parseField parses one schedule field.
+func parseField(field string, minValue, maxValue int) (fieldSet, error) {
+	var set fieldSet
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+		}
+
+		low, high := minValue, maxValue
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = parseValue(from, minValue, maxValue); err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				if high, err = parseValue(to, minValue, maxValue); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				high = maxValue
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// parseValue parses a schedule field value within its bounds.
+func parseValue(raw string, minValue, maxValue int) (int, error) {
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, errors.New("invalid value " + strconv.Quote(raw))
+	}
+	if v < minValue || v > maxValue {
+		return 0, fmt.Errorf("value %d is not between %d and %d", v, minValue, maxValue)
+	}
+	return v, nil
+}
@@ -0,0 +1,62 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// Wednesday.
+	from := time.Date(2025, time.January, 15, 10, 30, 45, 0, time.UTC)
+	tests := []struct {
+		expected time.Time
+		name     string
+		expr     string
+	}{
+		{name: "every minute", expr: "* * * * *", expected: time.Date(2025, time.January, 15, 10, 31, 0, 0, time.UTC)},
+		{name: "hourly", expr: "@hourly", expected: time.Date(2025, time.January, 15, 11, 0, 0, 0, time.UTC)},
+		{name: "daily", expr: "@daily", expected: time.Date(2025, time.January, 16, 0, 0, 0, 0, time.UTC)},
+		{name: "step", expr: "*/20 * * * *", expected: time.Date(2025, time.January, 15, 10, 40, 0, 0, time.UTC)},
+		{
+			name:     "weekdays at 8",
+			expr:     "0 8 * * 1-5",
+			expected: time.Date(2025, time.January, 16, 8, 0, 0, 0, time.UTC),
+		},
+		{name: "sunday as 7", expr: "0 9 * * 7", expected: time.Date(2025, time.January, 19, 9, 0, 0, 0, time.UTC)},
+		{
+			name:     "list of days of month",
+			expr:     "15 6 1,20 * *",
+			expected: time.Date(2025, time.January, 20, 6, 15, 0, 0, time.UTC),
+		},
+		{
+			name:     "day of month or day of week",
+			expr:     "0 0 1 * 5",
+			expected: time.Date(2025, time.January, 17, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "next year",
+			expr:     "0 0 1 1 *",
+			expected: time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{name: "leap day", expr: "0 0 29 2 *", expected: time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseSchedule(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, s.Next(from))
+		})
+	}
+}
+
+func TestParseSchedule_Errors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "5-1 * * * *",
+		"*/0 * * * *", "a * * * *", "0 0 30 2 *", "@yearly"} {
+		_, err := ParseSchedule(expr)
+		assert.Error(t, err, expr)
+	}
+}
@@ -0,0 +1,200 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/template"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/provisioning"
+)
+
+// Entry is a numeric metric listed in a summary.
+type Entry struct {
+	Type  string  // Type is the metric type.
+	Name  string  // Name is the metric name.
+	Value float64 // Value is the current value.
+}
+
+// Change is a metric whose value changed since the previous report.
+type Change struct {
+	Type  string  // Type is the metric type.
+	Name  string  // Name is the metric name.
+	From  float64 // From is the value at the previous report.
+	To    float64 // To is the current value.
+	Delta float64 // Delta is To minus From.
+}
+
+// Alerts counts the provisioned alert rules by state.
+type Alerts struct {
+	Firing []provisioning.AlertRule // Firing are the rules whose metric satisfies the rule.
+	OK     int                      // OK counts the rules whose metric does not satisfy the rule.
+	NoData int                      // NoData counts the rules whose metric is not stored.
+}
+
+// Summary describes the stored metrics at the time of a report.
+type Summary struct {
+	Generated time.Time // Generated is the time of the report.
+	Since     time.Time // Since is the time of the previous report, zero for the first one.
+	Top       []Entry   // Top are the numeric metrics with the largest values.
+	Changes   []Change  // Changes are the metrics whose values changed the most since the previous report.
+	Alerts    Alerts    // Alerts are the states of the provisioned alert rules.
+	Metrics   int       // Metrics counts the stored metrics.
+	Added     int       // Added counts the metrics stored since the previous report.
+	Removed   int       // Removed counts the metrics removed since the previous report.
+}
+
+// reportTimeLayout formats the times in a report.
+const reportTimeLayout = "2006-01-02 15:04 MST"
+
+// summaryTemplate renders a Summary as the plain-text body of a report email.
+var summaryTemplate = template.Must(template.New("summary").Funcs(template.FuncMap{
+	"stamp": func(t time.Time) string { return t.Format(reportTimeLayout) },
+}).Parse(`Metricol report of {{stamp .Generated}}
+
+Metrics stored: {{.Metrics}}
+{{- if not .Since.IsZero}} ({{.Added}} added, {{.Removed}} removed since {{stamp .Since}}){{end}}
+
+Alerts: {{len .Alerts.Firing}} firing, {{.Alerts.OK}} ok, {{.Alerts.NoData}} without data
+{{- range .Alerts.Firing}}
+  - {{.Name}} ({{.Severity}}): {{.Type}}/{{.Metric}} {{.Op}} {{.Threshold}}{{if .Summary}} - {{.Summary}}{{end}}
+{{- end}}
+
+Top metrics:
+{{- range .Top}}
+  {{.Type}}/{{.Name}} = {{printf "%g" .Value}}
+{{- else}}
+  none
+{{- end}}
+{{if not .Since.IsZero}}
+Largest changes:
+{{- range .Changes}}
+  {{.Type}}/{{.Name}}: {{printf "%g" .From}} -> {{printf "%g" .To}} ({{printf "%+g" .Delta}})
+{{- else}}
+  none
+{{- end}}
+{{end}}`))
+
+// Summarize describes the stored metrics.
+//
+// Parameters:
+//   - now: The time of the report.
+//   - metrics: The stored metrics.
+//   - previous: The numeric values at the previous report keyed by type and name, nil for the first report.
+//   - since: The time of the previous report.
+//   - rules: The provisioned alert rules.
+//   - top: How many metrics to list as top metrics and as changes.
+//
+// Returns:
+//   - *Summary: The summary.
+//   - map[string]float64: The numeric values keyed by type and name, to compare the next report with.
+func Summarize(
+	now time.Time,
+	metrics *entity.Metrics,
+	previous map[string]float64,
+	since time.Time,
+	rules []provisioning.AlertRule,
+	top int,
+) (*Summary, map[string]float64) {
+	s := &Summary{Generated: now, Metrics: metrics.Length()}
+	values := make(map[string]float64, metrics.Length())
+	for _, m := range *metrics {
+		v, ok := m.Float64()
+		if !ok {
+			continue
+		}
+		values[metricKey(m.Type, m.Name)] = v
+		s.Top = append(s.Top, Entry{Type: m.Type, Name: m.Name, Value: v})
+	}
+	sort.Slice(s.Top, func(i, j int) bool {
+		if s.Top[i].Value != s.Top[j].Value {
+			return s.Top[i].Value > s.Top[j].Value
+		}
+		return metricKey(s.Top[i].Type, s.Top[i].Name) < metricKey(s.Top[j].Type, s.Top[j].Name)
+	})
+	s.Top = s.Top[:min(top, len(s.Top))]
+
+	if previous != nil {
+		s.Since = since
+		s.Changes, s.Added, s.Removed = compare(metrics, previous, values, top)
+	}
+	s.Alerts = evaluate(rules, values)
+	return s, values
+}
+
+// Render writes the summary as the plain-text body of a report email.
+//
+// Parameters:
+//   - w: The writer receiving the text.
+//
+// Returns:
+//   - error: An error if writing fails.
+func (s *Summary) Render(w io.Writer) error {
+	if err := summaryTemplate.Execute(w, s); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+	return nil
+}
+
+// compare finds the largest changes and counts the metrics added and removed since the previous report.
+func compare(
+	metrics *entity.Metrics,
+	previous, values map[string]float64,
+	top int,
+) ([]Change, int, int) {
+	var changes []Change
+	added := 0
+	for _, m := range *metrics {
+		key := metricKey(m.Type, m.Name)
+		to, numeric := values[key]
+		from, known := previous[key]
+		switch {
+		case !known && !numeric:
+			// Values that are not numbers are not remembered, so they cannot be told apart from added metrics.
+		case !known:
+			added++
+		case to != from:
+			changes = append(changes, Change{Type: m.Type, Name: m.Name, From: from, To: to, Delta: to - from})
+		}
+	}
+	removed := 0
+	for key := range previous {
+		if _, ok := values[key]; !ok {
+			removed++
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		di, dj := math.Abs(changes[i].Delta), math.Abs(changes[j].Delta)
+		if di != dj {
+			return di > dj
+		}
+		return metricKey(changes[i].Type, changes[i].Name) < metricKey(changes[j].Type, changes[j].Name)
+	})
+	return changes[:min(top, len(changes))], added, removed
+}
+
+// evaluate counts the alert rules by state against the current values.
+func evaluate(rules []provisioning.AlertRule, values map[string]float64) Alerts {
+	var alerts Alerts
+	for _, r := range rules {
+		v, ok := values[metricKey(r.Type, r.Metric)]
+		switch {
+		case !ok:
+			alerts.NoData++
+		case r.Fires(v):
+			alerts.Firing = append(alerts.Firing, r)
+		default:
+			alerts.OK++
+		}
+	}
+	return alerts
+}
+
+// metricKey identifies a metric by type and name.
+func metricKey(metricType, name string) string {
+	return metricType + "/" + name
+}
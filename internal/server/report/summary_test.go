@@ -0,0 +1,68 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/provisioning"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	now := time.Date(2025, time.January, 15, 8, 0, 0, 0, time.UTC)
+	since := now.Add(-24 * time.Hour)
+	metrics := &entity.Metrics{
+		{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 300.0},
+		{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(50)},
+		{Name: "Load", Type: entity.MetricTypeGauge, Value: 0.5},
+		{Name: "Fresh", Type: entity.MetricTypeGauge, Value: 1.0},
+	}
+	previous := map[string]float64{"gauge/HeapAlloc": 100, "counter/PollCount": 40, "gauge/Load": 0.5, "gauge/Gone": 1}
+	rules := []provisioning.AlertRule{
+		{Name: "heap", Metric: "HeapAlloc", Type: entity.MetricTypeGauge, Op: provisioning.OpGreater, Threshold: 200},
+		{Name: "load", Metric: "Load", Type: entity.MetricTypeGauge, Op: provisioning.OpGreater, Threshold: 1},
+		{Name: "missing", Metric: "Missing", Type: entity.MetricTypeGauge, Op: provisioning.OpGreater, Threshold: 1},
+	}
+
+	s, values := Summarize(now, metrics, previous, since, rules, 2)
+	assert.Equal(t, 4, s.Metrics)
+	assert.Equal(t, []Entry{
+		{Type: entity.MetricTypeGauge, Name: "HeapAlloc", Value: 300},
+		{Type: entity.MetricTypeCounter, Name: "PollCount", Value: 50},
+	}, s.Top)
+	assert.Equal(t, []Change{
+		{Type: entity.MetricTypeGauge, Name: "HeapAlloc", From: 100, To: 300, Delta: 200},
+		{Type: entity.MetricTypeCounter, Name: "PollCount", From: 40, To: 50, Delta: 10},
+	}, s.Changes)
+	assert.Equal(t, 1, s.Added)
+	assert.Equal(t, 1, s.Removed)
+	assert.Equal(t, since, s.Since)
+	require.Len(t, s.Alerts.Firing, 1)
+	assert.Equal(t, "heap", s.Alerts.Firing[0].Name)
+	assert.Equal(t, 1, s.Alerts.OK)
+	assert.Equal(t, 1, s.Alerts.NoData)
+	assert.Equal(t, 50.0, values["counter/PollCount"])
+
+	var body strings.Builder
+	require.NoError(t, s.Render(&body))
+	assert.Contains(t, body.String(), "Metrics stored: 4 (1 added, 1 removed since 2025-01-14 08:00 UTC)")
+	assert.Contains(t, body.String(), "Alerts: 1 firing, 1 ok, 1 without data")
+	assert.Contains(t, body.String(), "gauge/HeapAlloc = 300")
+	assert.Contains(t, body.String(), "gauge/HeapAlloc: 100 -> 300 (+200)")
+}
+
+func TestSummarize_FirstReport(t *testing.T) {
+	metrics := &entity.Metrics{{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 300.0}}
+
+	s, _ := Summarize(time.Now(), metrics, nil, time.Time{}, nil, DefaultTop)
+	assert.Empty(t, s.Changes)
+	assert.Zero(t, s.Added)
+
+	var body strings.Builder
+	require.NoError(t, s.Render(&body))
+	assert.NotContains(t, body.String(), "Largest changes")
+	assert.Contains(t, body.String(), "Alerts: 0 firing, 0 ok, 0 without data")
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/audit"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+
+	"go.uber.org/zap"
+)
+
+// AuditedRepository decorates a Repository so every successful mutation is recorded in an audit log,
+// together with the request described by audit.RequestFrom. Writes flushed from the write buffer of
+// the metric service are made outside of any request and are recorded without one.
+type AuditedRepository struct {
+	Repository                    // Repository is the decorated repository.
+	sink       audit.Sink         // sink stores the audit entries.
+	logger     *zap.SugaredLogger // logger reports entries that could not be stored.
+	now        func() time.Time   // now returns the time entries are stamped with.
+}
+
+// NewAuditedRepository wraps a repository so its mutations are audited. An entry that cannot be stored is
+// logged and does not fail the mutation, which has already been made.
+//
+// Parameters:
+//   - repo: The repository to decorate.
+//   - sink: The sink storing the audit entries.
+//   - logger: The logger reporting failed audit writes.
+//
+// Returns:
+//   - *AuditedRepository: A pointer to the decorating repository.
+func NewAuditedRepository(repo Repository, sink audit.Sink, logger *zap.SugaredLogger) *AuditedRepository {
+	return &AuditedRepository{
+		Repository: repo,
+		sink:       sink,
+		logger:     logger,
+		now:        time.Now,
+	}
+}
+
+// Unwrap returns the decorated repository, so optional capabilities such as migration reporting stay reachable.
+//
+// Returns:
+//   - Repository: The decorated repository.
+func (r *AuditedRepository) Unwrap() Repository {
+	return r.Repository
+}
+
+// Update adds or updates a metric and records it.
+func (r *AuditedRepository) Update(ctx context.Context, metric *entity.Metric) error {
+	if err := r.Repository.Update(ctx, metric); err != nil {
+		return err //nolint:wrapcheck // the decorator is transparent
+	}
+	r.record(ctx, audit.OpUpdate, []audit.Metric{{Type: metric.Type, Name: metric.Name, Value: metric.Value}})
+	return nil
+}
+
+// UpdateBatch adds or updates a batch of metrics and records them in a single entry.
+func (r *AuditedRepository) UpdateBatch(ctx context.Context, metrics *entity.Metrics) error {
+	if err := r.Repository.UpdateBatch(ctx, metrics); err != nil {
+		return err //nolint:wrapcheck // the decorator is transparent
+	}
+	touched := make([]audit.Metric, 0, metrics.Length())
+	if metrics != nil {
+		for _, metric := range *metrics {
+			touched = append(touched, audit.Metric{Type: metric.Type, Name: metric.Name, Value: metric.Value})
+		}
+	}
+	r.record(ctx, audit.OpUpdateBatch, touched)
+	return nil
+}
+
+// Delete soft-deletes a metric and records it.
+func (r *AuditedRepository) Delete(ctx context.Context, metricType string, metricName string) error {
+	if err := r.Repository.Delete(ctx, metricType, metricName); err != nil {
+		return err //nolint:wrapcheck // the decorator is transparent
+	}
+	r.record(ctx, audit.OpDelete, []audit.Metric{{Type: metricType, Name: metricName}})
+	return nil
+}
+
+// Undelete restores a soft-deleted metric and records it.
+func (r *AuditedRepository) Undelete(ctx context.Context, metricType string, metricName string) error {
+	if err := r.Repository.Undelete(ctx, metricType, metricName); err != nil {
+		return err //nolint:wrapcheck // the decorator is transparent
+	}
+	r.record(ctx, audit.OpUndelete, []audit.Metric{{Type: metricType, Name: metricName}})
+	return nil
+}
+
+// record stores an entry of a completed operation, logging the failure to store it.
+//
+// Parameters:
+//   - ctx: The context of the operation, carrying the description of the request.
+//   - operation: The audited operation.
+//   - metrics: The metrics the operation touched.
+func (r *AuditedRepository) record(ctx context.Context, operation string, metrics []audit.Metric) {
+	entry := audit.Entry{
+		Time:      r.now(),
+		Operation: operation,
+		Request:   audit.RequestFrom(ctx),
+		Metrics:   metrics,
+	}
+	// The mutation is made, so the entry is stored even if the request was canceled meanwhile.
+	if err := r.sink.Write(context.WithoutCancel(ctx), entry); err != nil {
+		r.logger.Errorf("Failed to write audit entry for %s of %d metric(s): %v", operation, len(metrics), err)
+	}
+}
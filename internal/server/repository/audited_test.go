@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/audit"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// memorySink collects the audit entries written to it.
+type memorySink struct {
+	err     error
+	entries []audit.Entry
+}
+
+func (s *memorySink) Write(_ context.Context, entry audit.Entry) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *memorySink) Close() error { return nil }
+
+func newTestAudited(sink audit.Sink) *AuditedRepository {
+	repo := NewAuditedRepository(NewInMemoryRepository(zap.NewNop().Sugar()), sink, zap.NewNop().Sugar())
+	repo.now = func() time.Time { return time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC) }
+	return repo
+}
+
+func TestAuditedRepository_Mutations(t *testing.T) {
+	sink := &memorySink{}
+	repo := newTestAudited(sink)
+	req := audit.Request{Agent: "host-1", SourceIP: "10.0.0.7", RequestID: "req-1"}
+	ctx := audit.WithRequest(context.Background(), req)
+
+	require.NoError(t, repo.Update(ctx, &entity.Metric{Name: "g", Type: entity.MetricTypeGauge, Value: 1.5}))
+	require.NoError(t, repo.UpdateBatch(ctx, &entity.Metrics{
+		{Name: "c", Type: entity.MetricTypeCounter, Value: int64(2)},
+		{Name: "g", Type: entity.MetricTypeGauge, Value: 2.5},
+	}))
+	require.NoError(t, repo.Delete(ctx, entity.MetricTypeGauge, "g"))
+	require.NoError(t, repo.Undelete(ctx, entity.MetricTypeGauge, "g"))
+
+	at := repo.now()
+	expected := []audit.Entry{
+		{
+			Time:      at,
+			Operation: audit.OpUpdate,
+			Request:   req,
+			Metrics:   []audit.Metric{{Type: entity.MetricTypeGauge, Name: "g", Value: 1.5}},
+		},
+		{
+			Time:      at,
+			Operation: audit.OpUpdateBatch,
+			Request:   req,
+			Metrics: []audit.Metric{
+				{Type: entity.MetricTypeCounter, Name: "c", Value: int64(2)},
+				{Type: entity.MetricTypeGauge, Name: "g", Value: 2.5},
+			},
+		},
+		{
+			Time:      at,
+			Operation: audit.OpDelete,
+			Request:   req,
+			Metrics:   []audit.Metric{{Type: entity.MetricTypeGauge, Name: "g"}},
+		},
+		{
+			Time:      at,
+			Operation: audit.OpUndelete,
+			Request:   req,
+			Metrics:   []audit.Metric{{Type: entity.MetricTypeGauge, Name: "g"}},
+		},
+	}
+	assert.Equal(t, expected, sink.entries)
+}
+
+func TestAuditedRepository_FailedMutationIsNotRecorded(t *testing.T) {
+	sink := &memorySink{}
+	repo := newTestAudited(sink)
+
+	err := repo.Delete(context.Background(), entity.MetricTypeGauge, "missing")
+	require.Error(t, err)
+	assert.Empty(t, sink.entries)
+}
+
+func TestAuditedRepository_SinkErrorDoesNotFailMutation(t *testing.T) {
+	repo := newTestAudited(&memorySink{err: errors.New("disk full")})
+	ctx := context.Background()
+
+	require.NoError(t, repo.Update(ctx, &entity.Metric{Name: "g", Type: entity.MetricTypeGauge, Value: 1.0}))
+	found, err := repo.Find(ctx, entity.MetricTypeGauge, "g")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, found.Value)
+}
+
+func TestAuditedRepository_Unwrap(t *testing.T) {
+	inner := NewInMemoryRepository(zap.NewNop().Sugar())
+	repo := NewAuditedRepository(inner, &memorySink{}, zap.NewNop().Sugar())
+	assert.Same(t, inner, Base(repo))
+}
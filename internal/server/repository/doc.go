@@ -2,7 +2,7 @@
 // It provides methods for updating, retrieving, and checking the connection of metric data.
 //
 // The Repository interface specifies the basic operations for a metric repository, including Update,
-// UpdateBatch, Find, All, and CheckConnection. This allows various implementations to be used
+// UpdateBatch, Find, All, Delete, Undelete, Purge and CheckConnection. This allows various implementations to be used
 // interchangeably based on the application's needs.
 //
 // Implementations provided in this package include:
@@ -19,6 +19,17 @@
 //     A repository that persists metrics in a PostgreSQL database. It supports inserting/updating metrics,
 //     batch operations via transactions, and automatic database migrations using embedded SQL files.
 //     It also features connection checks with retry logic.
+//     Building with the nopostgres tag leaves the driver and the migrations out of the binary; the
+//     PostgreSQL constructors then return ErrPostgresDisabled.
+//
+// FaultyRepository decorates any of them with injected latency and errors for tests and staging.
+//
+// Deletion is soft: a deleted metric leaves a tombstone that can be undone with Undelete until
+// TombstonePurger removes it after the configured retention window.
+//
+// All three implement MetaStore, which keeps free-form annotations of metrics such as their owner
+// and runbook apart from the values: in memory, in a file next to the storage file, or in the
+// metric_meta table.
 //
 // These implementations provide flexible storage solutions for metrics in diverse environments.
 package repository
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/clock"
+)
+
+// ErrInjectedFault is returned by FaultyRepository for operations it chose to fail.
+var ErrInjectedFault = errors.New("injected repository fault")
+
+// FaultyRepository decorates a Repository with artificial latency and random failures.
+// It is meant for tests and staging, to exercise the timeout, retry and degradation paths
+// of the code using the repository; it must not be used in production.
+type FaultyRepository struct {
+	Repository                // Repository is the decorated repository.
+	clock      clock.Clock    // clock times the injected delays.
+	random     func() float64 // random returns a number in [0, 1) deciding whether an operation fails.
+	delay      time.Duration  // delay is added to every operation.
+	errorRate  float64        // errorRate is the share of operations failing with ErrInjectedFault.
+}
+
+// NewFaultyRepository wraps a repository so every operation is delayed and a share of them fail.
+//
+// Parameters:
+//   - repo: The repository to decorate.
+//   - delay: The latency added to every operation; the wait is cut short if the context is canceled.
+//   - errorRate: The share of operations failing with ErrInjectedFault, between 0 and 1.
+//
+// Returns:
+//   - *FaultyRepository: A pointer to the decorating repository.
+func NewFaultyRepository(repo Repository, delay time.Duration, errorRate float64) *FaultyRepository {
+	return &FaultyRepository{
+		Repository: repo,
+		clock:      clock.Real(),
+		random:     rand.Float64, //nolint:gosec // fault injection does not need a secure source
+		delay:      delay,
+		errorRate:  min(max(errorRate, 0), 1),
+	}
+}
+
+// Unwrap returns the decorated repository, so optional capabilities such as migration reporting stay reachable.
+//
+// Returns:
+//   - Repository: The decorated repository.
+func (r *FaultyRepository) Unwrap() Repository {
+	return r.Repository
+}
+
+// Update adds or updates a metric after the injected delay, unless a fault is injected.
+func (r *FaultyRepository) Update(ctx context.Context, metric *entity.Metric) error {
+	if err := r.inject(ctx, "update"); err != nil {
+		return err
+	}
+	return r.Repository.Update(ctx, metric) //nolint:wrapcheck // the decorator is transparent
+}
+
+// UpdateBatch adds or updates metrics after the injected delay, unless a fault is injected.
+func (r *FaultyRepository) UpdateBatch(ctx context.Context, metrics *entity.Metrics) error {
+	if err := r.inject(ctx, "update batch"); err != nil {
+		return err
+	}
+	return r.Repository.UpdateBatch(ctx, metrics) //nolint:wrapcheck // the decorator is transparent
+}
+
+// Find retrieves a metric after the injected delay, unless a fault is injected.
+func (r *FaultyRepository) Find(ctx context.Context, metricType string, metricName string) (*entity.Metric, error) {
+	if err := r.inject(ctx, "find"); err != nil {
+		return nil, err
+	}
+	return r.Repository.Find(ctx, metricType, metricName) //nolint:wrapcheck // the decorator is transparent
+}
+
+// All retrieves all metrics after the injected delay, unless a fault is injected.
+func (r *FaultyRepository) All(ctx context.Context) (*entity.Metrics, error) {
+	if err := r.inject(ctx, "all"); err != nil {
+		return nil, err
+	}
+	return r.Repository.All(ctx) //nolint:wrapcheck // the decorator is transparent
+}
+
+// Page retrieves a page of metrics after the injected delay, unless a fault is injected.
+func (r *FaultyRepository) Page(ctx context.Context, query PageQuery) (*Page, error) {
+	if err := r.inject(ctx, "page"); err != nil {
+		return nil, err
+	}
+	return r.Repository.Page(ctx, query) //nolint:wrapcheck // the decorator is transparent
+}
+
+// Query retrieves the matching metrics after the injected delay, unless a fault is injected.
+func (r *FaultyRepository) Query(ctx context.Context, filter MetricFilter) (*entity.Metrics, error) {
+	if err := r.inject(ctx, "query"); err != nil {
+		return nil, err
+	}
+	return r.Repository.Query(ctx, filter) //nolint:wrapcheck // the decorator is transparent
+}
+
+// Delete soft-deletes a metric after the injected delay, unless a fault is injected.
+func (r *FaultyRepository) Delete(ctx context.Context, metricType string, metricName string) error {
+	if err := r.inject(ctx, "delete"); err != nil {
+		return err
+	}
+	return r.Repository.Delete(ctx, metricType, metricName) //nolint:wrapcheck // the decorator is transparent
+}
+
+// Undelete restores a soft-deleted metric after the injected delay, unless a fault is injected.
+func (r *FaultyRepository) Undelete(ctx context.Context, metricType string, metricName string) error {
+	if err := r.inject(ctx, "undelete"); err != nil {
+		return err
+	}
+	return r.Repository.Undelete(ctx, metricType, metricName) //nolint:wrapcheck // the decorator is transparent
+}
+
+// Purge removes old tombstones after the injected delay, unless a fault is injected.
+func (r *FaultyRepository) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	if err := r.inject(ctx, "purge"); err != nil {
+		return 0, err
+	}
+	return r.Repository.Purge(ctx, deletedBefore) //nolint:wrapcheck // the decorator is transparent
+}
+
+// CheckConnection checks the connection after the injected delay, unless a fault is injected.
+func (r *FaultyRepository) CheckConnection(ctx context.Context) error {
+	if err := r.inject(ctx, "check connection"); err != nil {
+		return err
+	}
+	return r.Repository.CheckConnection(ctx) //nolint:wrapcheck // the decorator is transparent
+}
+
+// inject waits for the configured delay and decides whether the operation fails.
+//
+// Parameters:
+//   - ctx: The context of the operation; canceling it ends the wait.
+//   - operation: The operation name used in the error message.
+//
+// Returns:
+//   - error: ErrInjectedFault if a fault is injected, the context error if it ended during the delay, nil otherwise.
+func (r *FaultyRepository) inject(ctx context.Context, operation string) error {
+	if r.delay > 0 {
+		timer := r.clock.NewTimer(r.delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s interrupted by injected delay: %w", operation, ctx.Err())
+		case <-timer.C():
+		}
+	}
+
+	if r.errorRate > 0 && r.random() < r.errorRate {
+		return fmt.Errorf("%s failed: %w", operation, ErrInjectedFault)
+	}
+	return nil
+}
+
+// Base returns the innermost repository, unwrapping decorators such as FaultyRepository.
+//
+// Parameters:
+//   - repo: The possibly decorated repository.
+//
+// Returns:
+//   - Repository: The repository without decorators.
+func Base(repo Repository) Repository {
+	for {
+		wrapper, ok := repo.(interface{ Unwrap() Repository })
+		if !ok {
+			return repo
+		}
+		repo = wrapper.Unwrap()
+	}
+}
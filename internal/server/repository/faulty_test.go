@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestFaultyRepository_ErrorRate(t *testing.T) {
+	tests := []struct {
+		name        string
+		errorRate   float64
+		random      float64
+		expectFault bool
+	}{
+		{name: "No faults", errorRate: 0, random: 0, expectFault: false},
+		{name: "Below rate", errorRate: 0.5, random: 0.4, expectFault: true},
+		{name: "Above rate", errorRate: 0.5, random: 0.6, expectFault: false},
+		{name: "Rate above one is capped", errorRate: 3, random: 0.99, expectFault: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := NewInMemoryRepository(zap.NewNop().Sugar())
+			repo := NewFaultyRepository(inner, 0, tt.errorRate)
+			repo.random = func() float64 { return tt.random }
+
+			ctx := context.Background()
+			err := repo.Update(ctx, &entity.Metric{Name: "x", Type: entity.MetricTypeGauge, Value: 1.0})
+			if tt.expectFault {
+				require.ErrorIs(t, err, ErrInjectedFault)
+				_, findErr := inner.Find(ctx, entity.MetricTypeGauge, "x")
+				assert.ErrorIs(t, findErr, ErrNotFoundInRepo)
+				return
+			}
+			require.NoError(t, err)
+			found, err := repo.Find(ctx, entity.MetricTypeGauge, "x")
+			require.NoError(t, err)
+			assert.Equal(t, 1.0, found.Value)
+		})
+	}
+}
+
+func TestFaultyRepository_Delay(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	repo := NewFaultyRepository(NewInMemoryRepository(zap.NewNop().Sugar()), time.Minute, 0)
+	repo.clock = fake
+
+	done := make(chan error, 1)
+	go func() { done <- repo.CheckConnection(context.Background()) }()
+
+	fake.BlockUntil(1)
+	select {
+	case <-done:
+		t.Fatal("operation finished before the injected delay elapsed")
+	default:
+	}
+	fake.Advance(time.Minute)
+	require.NoError(t, <-done)
+
+	ctx, cancel := fake.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	go func() {
+		_, err := repo.All(ctx)
+		done <- err
+	}()
+	fake.BlockUntil(2)
+	fake.Advance(time.Second)
+	assert.ErrorIs(t, <-done, context.DeadlineExceeded)
+}
+
+func TestBase(t *testing.T) {
+	inner := NewInMemoryRepository(zap.NewNop().Sugar())
+	assert.Same(t, inner, Base(inner))
+	assert.Same(t, inner, Base(NewFaultyRepository(NewFaultyRepository(inner, 0, 0), 0, 0)))
+}
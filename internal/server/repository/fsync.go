@@ -0,0 +1,38 @@
+package repository
+
+import "fmt"
+
+// FsyncPolicy defines when the file repository forces written data to stable storage.
+type FsyncPolicy int
+
+const (
+	// FsyncNever leaves flushing written data to disk to the operating system.
+	FsyncNever FsyncPolicy = iota
+	// FsyncInterval forces written data to disk periodically, bounding the data lost on a crash.
+	FsyncInterval
+	// FsyncAlways forces data to disk after every write, trading write latency for durability.
+	FsyncAlways
+)
+
+// fsyncPolicyNames maps configuration names to fsync policies.
+var fsyncPolicyNames = map[string]FsyncPolicy{
+	"never":    FsyncNever,
+	"interval": FsyncInterval,
+	"always":   FsyncAlways,
+}
+
+// ParseFsyncPolicy converts a configuration name ("always", "interval" or "never") to an FsyncPolicy.
+//
+// Parameters:
+//   - name: The policy name.
+//
+// Returns:
+//   - FsyncPolicy: The parsed policy.
+//   - error: An error if the name is unknown.
+func ParseFsyncPolicy(name string) (FsyncPolicy, error) {
+	policy, ok := fsyncPolicyNames[name]
+	if !ok {
+		return FsyncNever, fmt.Errorf("unknown fsync policy %q", name)
+	}
+	return policy, nil
+}
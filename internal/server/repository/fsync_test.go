@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFsyncPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		expected  FsyncPolicy
+		expectErr bool
+	}{
+		{name: "Always", input: "always", expected: FsyncAlways},
+		{name: "Interval", input: "interval", expected: FsyncInterval},
+		{name: "Never", input: "never", expected: FsyncNever},
+		{name: "Unknown", input: "sometimes", expected: FsyncNever, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParseFsyncPolicy(tt.input)
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, policy)
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package repository
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -9,11 +8,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/internal/selfmetric"
 	"github.com/gdyunin/metricol.git/pkg/retry"
-	"github.com/labstack/gommon/log"
 	"go.uber.org/zap"
 )
 
@@ -26,18 +27,80 @@ const (
 	makeDirTimeout = 2 * time.Second
 	// Const makeFileTimeout specifies the timeout for file creation.
 	makeFileTimeout = 2 * time.Second
+	// Const selfMetricFileSize reports the size of the storage file in bytes.
+	selfMetricFileSize = "metricol_storage_file_bytes"
+	// Const selfMetricWALSize reports the size of the write-ahead log in bytes.
+	selfMetricWALSize = "metricol_storage_wal_bytes"
+	// Const selfMetricCompactions counts compactions of the write-ahead log into the storage file.
+	selfMetricCompactions = "metricol_storage_compactions"
+	// Const metaFileSuffix names the file holding the annotations next to the storage file.
+	metaFileSuffix = ".meta"
 )
 
 // InFileRepository represents a file-backed repository for metrics storage.
 // It extends an in-memory repository by adding file synchronization capabilities.
+//
+// The metrics are kept in two files: the storage file holds a snapshot of all metrics, and a write-ahead
+// log next to it holds the metrics changed since. Writes only append the changed metrics to the log;
+// once the log outgrows its limit it is compacted into a new snapshot, which is written to a temporary
+// file and renamed over the old one, so a crash never leaves a truncated snapshot. The snapshot starts
+// with a checksum header; restoring verifies it, loads the snapshot and replays the log over it, and
+// moves a snapshot failing the check aside instead of loading part of it.
 type InFileRepository struct {
-	*InMemoryRepository                    // Embedded in-memory repository.
-	logger              *zap.SugaredLogger // Logger for repository operations.
-	stopCh              chan struct{}      // Channel to signal stopping the auto-flush process.
-	filepath            string             // Path of the storage file.
-	autoFlushInterval   time.Duration      // Interval for automatically flushing data to the file.
-	synchronized        bool               // Flag indicating whether the repository is in synchronized mode.
-	restoreOnBuild      bool               // Flag indicating whether to restore data from file upon initialization.
+	*InMemoryRepository                     // Embedded in-memory repository.
+	logger              *zap.SugaredLogger  // Logger for repository operations.
+	stopCh              chan struct{}       // Channel closed to stop the auto-flush process.
+	flushDone           chan struct{}       // Channel closed once the auto-flush process ends; nil if it is not running.
+	stopOnce            sync.Once           // Makes Shutdown stop the background processes only once.
+	flushMu             *sync.Mutex         // Serializes writes to the storage file and the write-ahead log.
+	pendingMu           *sync.Mutex         // Protects pending.
+	pending             map[walKey]struct{} // Metrics changed since the last auto-flush.
+	fileSize            atomic.Int64        // Current size of the storage file in bytes.
+	walSize             atomic.Int64        // Current size of the write-ahead log in bytes.
+	compactions         atomic.Int64        // Number of compactions of the write-ahead log.
+	maxFileSize         int64               // Log size triggering a compaction; non-positive uses defaultWALLimit.
+	fsyncStopCh         chan struct{}       // Channel closed to stop the periodic fsync process; nil if it is not running.
+	fsyncPolicy         FsyncPolicy         // Policy deciding when written data is forced to stable storage.
+	fsyncInterval       time.Duration       // Period between syncs under FsyncInterval.
+	dirty               atomic.Bool         // Whether the log was written since the last periodic sync.
+	filepath            string              // Path of the storage file.
+	walPath             string              // Path of the write-ahead log.
+	autoFlushInterval   time.Duration       // Interval for automatically flushing data to the file.
+	synchronized        bool                // Flag indicating whether the repository is in synchronized mode.
+	restoreOnBuild      bool                // Flag indicating whether to restore data from file upon initialization.
+}
+
+// InFileOption configures optional behavior of an InFileRepository.
+type InFileOption func(*InFileRepository)
+
+// WithMaxFileSize sets the size of the write-ahead log that triggers its compaction into a new snapshot
+// of the storage file. A non-positive size uses the default limit of 4 MiB.
+//
+// Parameters:
+//   - maxBytes: The log size in bytes that triggers compaction.
+//
+// Returns:
+//   - InFileOption: The option applying the limit.
+func WithMaxFileSize(maxBytes int64) InFileOption {
+	return func(r *InFileRepository) {
+		r.maxFileSize = maxBytes
+	}
+}
+
+// WithFsyncPolicy sets when written data is forced to stable storage. FsyncInterval syncs data written
+// during the last interval; a non-positive interval leaves syncing to the operating system.
+//
+// Parameters:
+//   - policy: The fsync policy.
+//   - interval: The period between syncs under FsyncInterval.
+//
+// Returns:
+//   - InFileOption: The option applying the policy.
+func WithFsyncPolicy(policy FsyncPolicy, interval time.Duration) InFileOption {
+	return func(r *InFileRepository) {
+		r.fsyncPolicy = policy
+		r.fsyncInterval = interval
+	}
 }
 
 // NewInFileRepository creates a new instance of InFileRepository.
@@ -49,30 +112,46 @@ type InFileRepository struct {
 //   - filename: Name of the storage file.
 //   - interval: Auto-flush interval in seconds; if zero, the repository operates in synchronized mode.
 //   - restore: Indicates if data should be restored from file during initialization.
+//   - opts: Optional settings such as the file size limit.
 //
 // Returns:
 //   - *InFileRepository: A pointer to the created InFileRepository instance.
+//   - error: An error if the storage directory or file cannot be created.
 func NewInFileRepository(
 	logger *zap.SugaredLogger,
 	path string,
 	filename string,
 	interval time.Duration,
 	restore bool,
-) *InFileRepository {
+	opts ...InFileOption,
+) (*InFileRepository, error) {
 	ifr := InFileRepository{
 		InMemoryRepository: NewInMemoryRepository(logger.Named("memory")),
 		logger:             logger,
 		synchronized:       interval == 0,
 		stopCh:             make(chan struct{}),
+		flushMu:            &sync.Mutex{},
+		pendingMu:          &sync.Mutex{},
+		pending:            make(map[walKey]struct{}),
 		filepath:           filepath.Join(path, filename),
+		walPath:            filepath.Join(path, filename) + walFileSuffix,
 		restoreOnBuild:     restore,
 		autoFlushInterval:  interval,
 	}
-	return ifr.mustBuild()
+	for _, opt := range opts {
+		opt(&ifr)
+	}
+	if ifr.maxFileSize <= 0 {
+		ifr.maxFileSize = defaultWALLimit
+	}
+	if err := ifr.build(); err != nil {
+		return nil, fmt.Errorf("failed to build file repository: %w", err)
+	}
+	return &ifr, nil
 }
 
 // Update adds or updates a metric in the repository.
-// It first updates the in-memory repository and then flushes to file if in synchronized mode.
+// It first updates the in-memory repository and then logs the metric if in synchronized mode.
 //
 // Parameters:
 //   - ctx: The context for the operation.
@@ -91,13 +170,12 @@ func (r *InFileRepository) Update(ctx context.Context, metric *entity.Metric) er
 		)
 	}
 
-	if r.synchronized {
-		r.flush(ctx)
-	}
+	r.changed(ctx, walKey{Type: metric.Type, Name: metric.Name})
 	return nil
 }
 
 // UpdateBatch adds or updates a batch of metrics in the repository.
+// In synchronized mode the whole batch is logged at once.
 //
 // Parameters:
 //   - ctx: The context for the operation.
@@ -111,41 +189,361 @@ func (r *InFileRepository) UpdateBatch(ctx context.Context, metrics *entity.Metr
 	}
 
 	for _, m := range *metrics {
-		if err := r.Update(ctx, m); err != nil {
+		if err := r.InMemoryRepository.Update(ctx, m); err != nil {
 			return fmt.Errorf("failed update one of metrics: %w", err)
 		}
 	}
 
+	keys := make([]walKey, 0, len(*metrics))
+	for _, m := range *metrics {
+		keys = append(keys, walKey{Type: m.Type, Name: m.Name})
+	}
+	r.changed(ctx, keys...)
+	return nil
+}
+
+// Delete soft-deletes a metric in memory and logs the deletion if in synchronized mode.
+// Tombstones are kept in memory only, so a deleted metric can be undeleted until the next compaction
+// of the log, after which it is gone from the files.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - metricType: The type of the metric.
+//   - name: The name of the metric.
+//
+// Returns:
+//   - error: An error if the metric does not exist.
+func (r *InFileRepository) Delete(ctx context.Context, metricType string, name string) error {
+	if err := r.InMemoryRepository.Delete(ctx, metricType, name); err != nil {
+		return fmt.Errorf("failed to delete metric in memory: %w", err)
+	}
+
+	r.changed(ctx, walKey{Type: metricType, Name: name})
+	return nil
+}
+
+// Undelete restores a soft-deleted metric in memory and logs it if in synchronized mode.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - metricType: The type of the metric.
+//   - name: The name of the metric.
+//
+// Returns:
+//   - error: An error if there is no tombstone for the metric.
+func (r *InFileRepository) Undelete(ctx context.Context, metricType string, name string) error {
+	if err := r.InMemoryRepository.Undelete(ctx, metricType, name); err != nil {
+		return fmt.Errorf("failed to undelete metric in memory: %w", err)
+	}
+
+	r.changed(ctx, walKey{Type: metricType, Name: name})
+	return nil
+}
+
+// SetMeta replaces the annotations of a stored metric in memory and rewrites the annotations file,
+// which is kept next to the storage file. Annotations change rarely, so they are written at once
+// whatever the flush mode.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - metricType: The type of the metric.
+//   - name: The name of the metric.
+//   - meta: The new annotations.
+//
+// Returns:
+//   - error: An error if the metric is not stored or the annotations file cannot be written.
+func (r *InFileRepository) SetMeta(ctx context.Context, metricType string, name string, meta *entity.Meta) error {
+	if err := r.InMemoryRepository.SetMeta(ctx, metricType, name, meta); err != nil {
+		return fmt.Errorf("failed to set annotations in memory: %w", err)
+	}
+
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+	if err := r.writeMeta(); err != nil {
+		return fmt.Errorf("failed to write annotations file: path=%s, error=%w", r.metaPath(), err)
+	}
+	return nil
+}
+
+// metaRecord is a line of the annotations file.
+type metaRecord struct {
+	Meta entity.Meta `json:"meta"` // Meta holds the annotations.
+	Type string      `json:"type"` // Type is the type of the annotated metric.
+	Name string      `json:"name"` // Name is the name of the annotated metric.
+}
+
+// metaPath returns the path of the annotations file.
+func (r *InFileRepository) metaPath() string {
+	return r.filepath + metaFileSuffix
+}
+
+// writeMeta replaces the annotations file with the annotations of all metrics. The file is written
+// to a temporary file first and renamed, so a crash never leaves it half-written. The caller must hold flushMu.
+func (r *InFileRepository) writeMeta() error {
+	var buf bytes.Buffer
+	for metricType, byName := range r.metaSnapshot() {
+		for name, meta := range byName {
+			data, err := json.Marshal(metaRecord{Type: metricType, Name: name, Meta: meta})
+			if err != nil {
+				return fmt.Errorf("failed to serialize annotations: type=%s, name=%s, error=%w", metricType, name, err)
+			}
+			buf.Write(append(data, '\n'))
+		}
+	}
+
+	tmp := r.metaPath() + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), fileDefaultPerm); err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := os.Rename(tmp, r.metaPath()); err != nil {
+		return fmt.Errorf("failed to replace annotations file: %w", err)
+	}
 	return nil
 }
 
-// Shutdown gracefully stops the auto-flush process.
+// restoreMeta loads the annotations file into memory. A missing file means no metric is annotated.
+//
+// Returns:
+//   - error: An error if the file exists but cannot be read.
+func (r *InFileRepository) restoreMeta() error {
+	data, err := os.ReadFile(r.metaPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read annotations file: path=%s, error=%w", r.metaPath(), err)
+	}
+
+	r.InMemoryRepository.mu.Lock()
+	defer r.InMemoryRepository.mu.Unlock()
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+		var record metaRecord
+		if err = json.Unmarshal(line, &record); err != nil {
+			r.logger.Warnf("failed to deserialize annotations: raw=%s, error=%v", string(line), err)
+			continue
+		}
+		r.setMeta(record.Type, record.Name, &record.Meta)
+	}
+	return nil
+}
+
+// Shutdown gracefully stops the auto-flush and periodic fsync processes and waits for the final flush.
+// It returns at once in synchronized mode, where no auto-flush process runs, and may be called repeatedly.
 func (r *InFileRepository) Shutdown() {
-	r.stopCh <- struct{}{}
+	r.stopOnce.Do(func() {
+		if r.fsyncStopCh != nil {
+			close(r.fsyncStopCh)
+		}
+		close(r.stopCh)
+	})
+	if r.flushDone != nil {
+		<-r.flushDone
+	}
 }
 
-// flush writes all metrics to the storage file.
-// It retrieves all metrics, serializes them to JSON lines, and writes them to file.
+// changed records changed metrics: in synchronized mode they are logged at once, otherwise they are
+// logged by the next auto-flush.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - keys: The changed metrics.
+func (r *InFileRepository) changed(ctx context.Context, keys ...walKey) {
+	if r.synchronized {
+		r.persist(ctx, keys)
+		return
+	}
+
+	r.pendingMu.Lock()
+	defer r.pendingMu.Unlock()
+	for _, key := range keys {
+		r.pending[key] = struct{}{}
+	}
+}
+
+// flush logs the metrics changed since the last flush.
 func (r *InFileRepository) flush(ctx context.Context) {
+	r.pendingMu.Lock()
+	keys := make([]walKey, 0, len(r.pending))
+	for key := range r.pending {
+		keys = append(keys, key)
+	}
+	clear(r.pending)
+	r.pendingMu.Unlock()
+
+	if len(keys) > 0 {
+		r.persist(ctx, keys)
+	}
+}
+
+// persist appends the current state of changed metrics to the write-ahead log and compacts the log once
+// it outgrows its limit. If the log cannot be written, the metrics are saved by a compaction instead.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - keys: The changed metrics.
+func (r *InFileRepository) persist(ctx context.Context, keys []walKey) {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+
+	// The state is read under flushMu, so the last record of a metric always holds its latest state
+	// even if concurrent writes are logged in a different order.
+	data, err := encodeWAL(walRecords(ctx, r.InMemoryRepository, keys))
+	if err == nil {
+		err = r.appendToLog(data)
+	}
+	if err != nil {
+		r.logger.Errorf("failed to append to write-ahead log, compacting it: path=%s, error=%v", r.walPath, err)
+		r.compact(ctx)
+		return
+	}
+
+	if size := r.walSize.Load(); size > r.maxFileSize {
+		r.logger.Infof("Compacting write-ahead log: path=%s, size=%d, limit=%d", r.walPath, size, r.maxFileSize)
+		r.compact(ctx)
+	}
+}
+
+// compact writes a snapshot of all metrics to the storage file and empties the write-ahead log.
+// The snapshot is written to a temporary file that replaces the storage file only once it is complete,
+// so a crash leaves either the old or the new snapshot, and the log replays correctly over both.
+// Writers are not blocked while the snapshot is serialized. The caller must hold flushMu.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+func (r *InFileRepository) compact(ctx context.Context) {
 	metrics, err := r.All(ctx)
 	if err != nil || metrics == nil {
-		r.logger.Warnf("failed to retrieve metrics for flushing: error=%v", err)
+		r.logger.Warnf("failed to retrieve metrics for compaction: error=%v", err)
+		return
+	}
+
+	data := withSnapshotHeader(r.encode(*metrics))
+	if err := r.writeSnapshot(data); err != nil {
+		r.logger.Errorf("failed to write storage file: path=%s, error=%v", r.filepath, err)
 		return
 	}
+	r.fileSize.Store(int64(len(data)))
+	r.compactions.Add(1)
 
-	file, err := os.OpenFile(r.filepath, os.O_WRONLY, fileDefaultPerm)
+	if err := os.Truncate(r.walPath, 0); err != nil && !errors.Is(err, os.ErrNotExist) {
+		// The log still replays correctly over the new snapshot, so it is only emptied later.
+		r.logger.Errorf("failed to empty write-ahead log: path=%s, error=%v", r.walPath, err)
+		return
+	}
+	r.walSize.Store(0)
+}
+
+// writeSnapshot atomically replaces the storage file. Unless the fsync policy leaves syncing to the system,
+// the new file is synced before it replaces the old one and the directory is synced after the rename.
+//
+// Parameters:
+//   - data: The content of the storage file.
+//
+// Returns:
+//   - error: An error if the temporary file cannot be written or renamed.
+func (r *InFileRepository) writeSnapshot(data []byte) error {
+	tmp := r.filepath + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileDefaultPerm)
 	if err != nil {
-		r.logger.Errorf("unable to open file for writing: path=%s, error=%v", r.filepath, err)
+		return fmt.Errorf("unable to create temporary file: %w", err)
+	}
+
+	_, err = file.Write(data)
+	if err == nil && r.fsyncPolicy != FsyncNever {
+		err = file.Sync()
+	}
+	if err = errors.Join(err, file.Close()); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := os.Rename(tmp, r.filepath); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to replace storage file: %w", err)
+	}
+	if r.fsyncPolicy != FsyncNever {
+		return syncDir(r.filepath)
+	}
+	return nil
+}
+
+// appendToLog appends encoded records to the write-ahead log, creating it if needed. The caller must hold flushMu.
+func (r *InFileRepository) appendToLog(data []byte) error {
+	file, err := os.OpenFile(r.walPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, fileDefaultPerm)
+	if err != nil {
+		return fmt.Errorf("unable to open log for appending: %w", err)
+	}
+
+	n, writeErr := file.Write(data)
+	r.walSize.Add(int64(n))
+	if writeErr == nil {
+		writeErr = r.afterWrite(file)
+	}
+	if err = errors.Join(writeErr, file.Close()); err != nil {
+		return fmt.Errorf("failed to append to log: %w", err)
+	}
+	return nil
+}
+
+// afterWrite applies the fsync policy to a file that has just been written.
+func (r *InFileRepository) afterWrite(file *os.File) error {
+	switch r.fsyncPolicy {
+	case FsyncAlways:
+		if err := file.Sync(); err != nil {
+			return fmt.Errorf("fsync failed: %w", err)
+		}
+	case FsyncInterval:
+		r.dirty.Store(true)
+	case FsyncNever:
+	}
+	return nil
+}
+
+// syncFile forces the content of the write-ahead log to stable storage. The storage file is synced
+// when it is written.
+func (r *InFileRepository) syncFile() {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+
+	file, err := os.OpenFile(r.walPath, os.O_WRONLY, fileDefaultPerm)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			r.logger.Errorf("unable to open log for fsync: path=%s, error=%v", r.walPath, err)
+		}
 		return
 	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			log.Errorf("File close error: %v", err)
+	if err = errors.Join(file.Sync(), file.Close()); err != nil {
+		r.logger.Errorf("failed to sync log: path=%s, error=%v", r.walPath, err)
+	}
+}
+
+// startFsync periodically forces written data to stable storage until a stop signal is received
+// via the fsyncStopCh channel. Data written since the last sync is synced before returning.
+func (r *InFileRepository) startFsync() {
+	ticker := time.NewTicker(r.fsyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if r.dirty.Swap(false) {
+				r.syncFile()
+			}
+		case <-r.fsyncStopCh:
+			if r.dirty.Swap(false) {
+				r.syncFile()
+			}
+			return
 		}
-	}()
+	}
+}
 
+// encode serializes metrics to JSON lines, skipping metrics that cannot be serialized.
+func (r *InFileRepository) encode(metrics entity.Metrics) []byte {
 	var buf bytes.Buffer
-	for _, m := range *metrics {
+	for _, m := range metrics {
 		data, err := json.Marshal(&m)
 		if err != nil {
 			r.logger.Warnf(
@@ -160,40 +558,61 @@ func (r *InFileRepository) flush(ctx context.Context) {
 		data = append(data, '\n')
 		buf.Write(data)
 	}
+	return buf.Bytes()
+}
 
-	writer := bufio.NewWriter(file)
-	if _, err := writer.Write(buf.Bytes()); err != nil {
-		r.logger.Errorf("failed to write metrics to file: path=%s, error=%v", r.filepath, err)
-		return
-	}
-
-	if err := writer.Flush(); err != nil {
-		r.logger.Errorf("failed to flush writer to file: path=%s, error=%v", r.filepath, err)
-	}
+// RegisterSelfMetrics exposes the sizes of the storage file and the write-ahead log and the number of compactions
+// through the self-metric registry.
+//
+// Parameters:
+//   - registry: The registry to register the metrics in.
+func (r *InFileRepository) RegisterSelfMetrics(registry *selfmetric.Registry) {
+	registry.RegisterGauge(selfMetricFileSize, func() float64 { return float64(r.fileSize.Load()) })
+	registry.RegisterGauge(selfMetricWALSize, func() float64 { return float64(r.walSize.Load()) })
+	registry.RegisterCounter(selfMetricCompactions, r.compactions.Load)
 }
 
-// mustBuild initializes the repository by restoring data (if enabled),
-// ensuring necessary directories and files exist, and starting auto-flush if required.
+// build initializes the repository by restoring data (if enabled), ensuring necessary directories and files
+// exist, compacting the write-ahead log left by the previous run and starting auto-flush if required.
+// Without restoring, the compaction discards the metrics of the previous run. If restoring fails, the
+// storage file and the log are moved aside first, so the compaction does not overwrite them.
 //
 // Returns:
-//   - *InFileRepository: A pointer to the fully initialized InFileRepository.
-func (r *InFileRepository) mustBuild() *InFileRepository {
+//   - error: An error if the storage directory or file cannot be created.
+func (r *InFileRepository) build() error {
 	if r.restoreOnBuild {
 		if err := r.shouldRestore(); err != nil {
+			// Compacting over storage that was not fully read would drop the records left unread.
 			r.logger.Warnf("Restore skipped with error: %v", err)
+			r.quarantine()
+		}
+		if err := r.restoreMeta(); err != nil {
+			r.logger.Warnf("Annotations restore skipped with error: %v", err)
 		}
 	}
 
-	r.mustMakeDir()
-	r.mustMakeFile()
+	if err := r.makeDir(); err != nil {
+		return err
+	}
+	if err := r.makeFile(); err != nil {
+		return err
+	}
+	r.flushMu.Lock()
+	r.compact(context.Background())
+	r.flushMu.Unlock()
 	if !r.synchronized {
+		r.flushDone = make(chan struct{})
 		go r.startAutoFlush()
 	}
+	if r.fsyncPolicy == FsyncInterval && r.fsyncInterval > 0 {
+		r.fsyncStopCh = make(chan struct{})
+		go r.startFsync()
+	}
 
-	return r
+	return nil
 }
 
-// shouldRestore restores metrics from the storage file into memory.
+// shouldRestore restores metrics from the storage file and the write-ahead log into memory.
 //
 // Returns:
 //   - error: An error if restoration fails.
@@ -201,12 +620,21 @@ func (r *InFileRepository) shouldRestore() error {
 	if err := r.restore(); err != nil {
 		return fmt.Errorf("failed to restore metrics: path=%s, error=%w", r.filepath, err)
 	}
+	replayed, err := readWAL(context.Background(), r.InMemoryRepository, r.walPath, r.logger.Warnf)
+	if err != nil {
+		return fmt.Errorf("failed to replay write-ahead log: path=%s, error=%w", r.walPath, err)
+	}
+	if replayed > 0 {
+		r.logger.Infof("Replayed %d write-ahead log record(s): path=%s", replayed, r.walPath)
+	}
 	return nil
 }
 
-// mustMakeDir ensures the directory for the storage file exists.
-// It retries the directory creation and panics if it ultimately fails.
-func (r *InFileRepository) mustMakeDir() {
+// makeDir ensures the directory for the storage file exists, retrying the directory creation.
+//
+// Returns:
+//   - error: An error if the directory cannot be created after retries.
+func (r *InFileRepository) makeDir() error {
 	ctx, cancel := context.WithTimeout(context.Background(), makeDirTimeout)
 	defer cancel()
 
@@ -219,17 +647,20 @@ func (r *InFileRepository) mustMakeDir() {
 			return os.MkdirAll(filepath.Dir(r.filepath), dirDefaultPerm)
 		},
 	); err != nil {
-		panic(fmt.Sprintf(
-			"failed to create directory after retries: path=%s, error=%v",
+		return fmt.Errorf(
+			"failed to create directory after retries: path=%s, error=%w",
 			filepath.Dir(r.filepath),
 			err,
-		))
+		)
 	}
+	return nil
 }
 
-// mustMakeFile ensures the storage file exists.
-// It retries the file creation and panics if it ultimately fails.
-func (r *InFileRepository) mustMakeFile() {
+// makeFile ensures the storage file exists, retrying the file creation.
+//
+// Returns:
+//   - error: An error if the file cannot be created after retries.
+func (r *InFileRepository) makeFile() error {
 	ctx, cancel := context.WithTimeout(context.Background(), makeFileTimeout)
 	defer cancel()
 
@@ -250,28 +681,37 @@ func (r *InFileRepository) mustMakeFile() {
 			return nil
 		},
 	); err != nil {
-		panic(fmt.Sprintf("unable to create file after retries: path=%s, error=%v", r.filepath, err))
+		return fmt.Errorf("unable to create file after retries: path=%s, error=%w", r.filepath, err)
 	}
+	return nil
 }
 
 // restore reads metrics from the storage file and loads them into the in-memory repository.
+// The file is checked against its checksum header first, so a corrupted file is not loaded at all.
+// A missing file holds no metrics.
 //
 // Returns:
-//   - error: An error if restoration fails.
+//   - error: An error if restoration fails, ErrCorruptSnapshot if the file does not match its header.
 func (r *InFileRepository) restore() error {
-	file, err := os.Open(r.filepath)
+	content, err := os.ReadFile(r.filepath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to open file for restoration: path=%s, error=%w", r.filepath, err)
 	}
-	defer func() {
-		if err = file.Close(); err != nil {
-			log.Errorf("File close error: %v", err)
-		}
-	}()
+	body, verified, err := verifySnapshot(content)
+	if err != nil {
+		return err
+	}
+	if !verified && len(content) > 0 {
+		r.logger.Warnf("Storage file has no checksum header, restoring it unverified: path=%s", r.filepath)
+	}
 
-	reader := bufio.NewScanner(file)
-	for reader.Scan() {
-		data := reader.Bytes()
+	for _, data := range bytes.Split(body, []byte{'\n'}) {
+		if len(data) == 0 {
+			continue
+		}
 
 		metric := entity.Metric{}
 		if err = json.Unmarshal(data, &metric); err != nil {
@@ -294,9 +734,24 @@ func (r *InFileRepository) restore() error {
 	return nil
 }
 
-// startAutoFlush starts a background process that periodically flushes metrics to the storage file.
-// It continues until a stop signal is received via the stopCh channel.
+// quarantine moves a storage file that could not be restored and its write-ahead log aside, so they are
+// not overwritten by the next compaction and can be inspected or repaired.
+func (r *InFileRepository) quarantine() {
+	for _, path := range []string{r.filepath, r.walPath} {
+		if err := os.Rename(path, path+corruptFileSuffix); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				r.logger.Errorf("failed to move unrestored file aside: path=%s, error=%v", path, err)
+			}
+			continue
+		}
+		r.logger.Errorf("Unrestored storage moved aside: path=%s", path+corruptFileSuffix)
+	}
+}
+
+// startAutoFlush starts a background process that periodically logs the changed metrics.
+// It continues until the stopCh channel is closed, flushing once more before it closes flushDone.
 func (r *InFileRepository) startAutoFlush() {
+	defer close(r.flushDone)
 	ticker := time.NewTicker(r.autoFlushInterval)
 	defer ticker.Stop()
 
@@ -308,6 +763,9 @@ func (r *InFileRepository) startAutoFlush() {
 			cancel()
 		case <-r.stopCh:
 			r.flush(context.TODO())
+			if r.fsyncPolicy != FsyncNever {
+				r.syncFile()
+			}
 			return
 		}
 	}
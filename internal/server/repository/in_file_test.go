@@ -1,12 +1,17 @@
 package repository
 
 import (
+	"bytes"
 	"context"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/internal/selfmetric"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -26,15 +31,18 @@ func TestNewInFileRepository(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			repo := NewInFileRepository(logger, tt.path, tt.filename, tt.interval, tt.restore)
-			assert.NotNil(t, repo, "Repository should not be nil")
+			repo, buildErr := NewInFileRepository(logger, tt.path, tt.filename, tt.interval, tt.restore)
+			require.NoError(t, buildErr)
+			require.NotNil(t, repo, "Repository should not be nil")
+			repo.Shutdown()
 		})
 	}
 }
 
 func TestUpdateBatchInFile(t *testing.T) {
 	logger := zap.NewNop().Sugar()
-	repo := NewInFileRepository(logger, "/tmp", "test.json", 0, false)
+	repo, buildErr := NewInFileRepository(logger, "/tmp", "test.json", 0, false)
+	require.NoError(t, buildErr)
 
 	t.Run("Nil metrics should return error", func(t *testing.T) {
 		err := repo.UpdateBatch(context.Background(), nil)
@@ -43,15 +51,16 @@ func TestUpdateBatchInFile(t *testing.T) {
 	})
 }
 
-func TestMustMakeFile(t *testing.T) {
+func TestMakeFile(t *testing.T) {
 	logger := zap.NewNop().Sugar()
 	tempFile := "/tmp/test_metrics.json"
 	defer func() { _ = os.Remove(tempFile) }()
 
-	repo := NewInFileRepository(logger, "/tmp", "test_metrics.json", 0, false)
+	repo, buildErr := NewInFileRepository(logger, "/tmp", "test_metrics.json", 0, false)
+	require.NoError(t, buildErr)
 	assert.NotNil(t, repo)
 
-	repo.mustMakeFile()
+	require.NoError(t, repo.makeFile())
 	_, err := os.Stat(tempFile)
 	assert.NoError(t, err, "File should be created")
 }
@@ -61,7 +70,8 @@ func TestRestore(t *testing.T) {
 	tempFile := "/tmp/test_restore.json"
 	defer func() { _ = os.Remove(tempFile) }()
 
-	repo := NewInFileRepository(logger, "/tmp", "test_restore.json", 0, true)
+	repo, buildErr := NewInFileRepository(logger, "/tmp", "test_restore.json", 0, true)
+	require.NoError(t, buildErr)
 	assert.NotNil(t, repo)
 
 	err := repo.restore()
@@ -70,18 +80,281 @@ func TestRestore(t *testing.T) {
 
 func TestShutdown(t *testing.T) {
 	logger := zap.NewNop().Sugar()
-	repo := NewInFileRepository(logger, "/tmp", "test.json", 1*time.Second, false)
-	assert.NotNil(t, repo)
+	tests := []struct {
+		name     string
+		interval time.Duration
+	}{
+		{name: "Auto flush", interval: time.Hour},
+		{name: "Synchronized", interval: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			repo, buildErr := NewInFileRepository(logger, dir, "test.json", tt.interval, false)
+			require.NoError(t, buildErr)
+			metric := &entity.Metric{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 1.5}
+			require.NoError(t, repo.Update(context.Background(), metric))
+
+			done := make(chan struct{})
+			go func() {
+				repo.Shutdown()
+				repo.Shutdown()
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(3 * time.Second):
+				require.Fail(t, "Shutdown did not return in time")
+			}
+
+			restored, buildErr := NewInFileRepository(logger, dir, "test.json", 0, true)
+			require.NoError(t, buildErr)
+			got, err := restored.Find(context.Background(), entity.MetricTypeGauge, "HeapAlloc")
+			require.NoError(t, err, "the metric is flushed before Shutdown returns")
+			assert.Equal(t, metric.Value, got.Value)
+		})
+	}
+}
+
+func TestWALCompaction(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo, buildErr := NewInFileRepository(logger, dir, "metrics.json", 0, false, WithMaxFileSize(300))
+	require.NoError(t, buildErr)
+	startupCompactions := repo.compactions.Load()
+	emptySnapshot, err := os.ReadFile(filepath.Join(dir, "metrics.json"))
+	require.NoError(t, err)
+
+	for i := range 3 {
+		require.NoError(t, repo.Update(ctx, &entity.Metric{Name: "gauge", Type: "gauge", Value: float64(i)}))
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "metrics.json"+walFileSuffix))
+	require.NoError(t, err)
+	assert.Equal(t, 3, bytes.Count(data, []byte("\n")), "updates must be appended to the log")
+	snapshot, err := os.ReadFile(filepath.Join(dir, "metrics.json"))
+	require.NoError(t, err)
+	assert.Equal(t, emptySnapshot, snapshot, "updates must not rewrite the storage file")
+	assert.Equal(t, startupCompactions, repo.compactions.Load())
 
-	go func() {
-		time.Sleep(2 * time.Second)
-		repo.Shutdown()
-	}()
+	for i := range 10 {
+		require.NoError(t, repo.Update(ctx, &entity.Metric{Name: "gauge", Type: "gauge", Value: float64(i)}))
+	}
+	assert.Greater(t, repo.compactions.Load(), startupCompactions, "exceeding the limit must compact the log")
+	assert.LessOrEqual(t, repo.walSize.Load(), int64(300))
+
+	registry := selfmetric.NewRegistry()
+	repo.RegisterSelfMetrics(registry)
+	size, ok := registry.Find("gauge", selfMetricFileSize)
+	require.True(t, ok)
+	info, err := os.Stat(filepath.Join(dir, "metrics.json"))
+	require.NoError(t, err)
+	assert.Equal(t, float64(info.Size()), size.Value)
+	walSize, ok := registry.Find("gauge", selfMetricWALSize)
+	require.True(t, ok)
+	info, err = os.Stat(filepath.Join(dir, "metrics.json"+walFileSuffix))
+	require.NoError(t, err)
+	assert.Equal(t, float64(info.Size()), walSize.Value)
+
+	restored, buildErr := NewInFileRepository(logger, dir, "metrics.json", 0, true)
+	require.NoError(t, buildErr)
+	metric, err := restored.Find(ctx, "gauge", "gauge")
+	require.NoError(t, err)
+	assert.Equal(t, 9.0, metric.Value, "restore must keep the latest logged value")
+}
+
+func TestWALRecovery(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo, buildErr := NewInFileRepository(logger, dir, "metrics.json", 0, false)
+	require.NoError(t, buildErr)
+	require.NoError(t, repo.Update(ctx, &entity.Metric{Name: "kept", Type: entity.MetricTypeGauge, Value: 1.5}))
+	counter := &entity.Metric{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(7)}
+	require.NoError(t, repo.Update(ctx, counter))
+	require.NoError(t, repo.Update(ctx, &entity.Metric{Name: "gone", Type: entity.MetricTypeGauge, Value: 2.5}))
+	require.NoError(t, repo.Delete(ctx, entity.MetricTypeGauge, "gone"))
+
+	// A crash in the middle of an append leaves a partial last line behind.
+	wal, err := os.OpenFile(filepath.Join(dir, "metrics.json"+walFileSuffix), os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = wal.WriteString(`{"metric":{"name":"kept","type":"gau`)
+	require.NoError(t, err)
+	require.NoError(t, wal.Close())
 
-	select {
-	case <-repo.stopCh:
-		assert.True(t, true, "Shutdown should send a stop signal")
-	case <-time.After(3 * time.Second):
-		assert.Fail(t, "Shutdown did not send stop signal in time")
+	restored, buildErr := NewInFileRepository(logger, dir, "metrics.json", 0, true)
+	require.NoError(t, buildErr)
+	kept, err := restored.Find(ctx, entity.MetricTypeGauge, "kept")
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, kept.Value)
+	pollCount, err := restored.Find(ctx, entity.MetricTypeCounter, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), pollCount.Value)
+	_, err = restored.Find(ctx, entity.MetricTypeGauge, "gone")
+	assert.ErrorIs(t, err, ErrNotFoundInRepo)
+
+	// Restoring folds the log into a new snapshot.
+	info, err := os.Stat(filepath.Join(dir, "metrics.json"+walFileSuffix))
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+	assert.NoFileExists(t, filepath.Join(dir, "metrics.json.tmp"))
+}
+
+func TestAutoFlushLogsChangedMetricsOnly(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo, buildErr := NewInFileRepository(logger, dir, "metrics.json", time.Hour, false)
+	require.NoError(t, buildErr)
+	defer repo.Shutdown()
+
+	for _, name := range []string{"a", "b", "c"} {
+		require.NoError(t, repo.Update(ctx, &entity.Metric{Name: name, Type: entity.MetricTypeGauge, Value: 1.0}))
 	}
+	repo.flush(ctx)
+	require.NoError(t, repo.Update(ctx, &entity.Metric{Name: "b", Type: entity.MetricTypeGauge, Value: 2.0}))
+	require.NoError(t, repo.Update(ctx, &entity.Metric{Name: "b", Type: entity.MetricTypeGauge, Value: 3.0}))
+	repo.flush(ctx)
+	repo.flush(ctx)
+
+	data, err := os.ReadFile(filepath.Join(dir, "metrics.json"+walFileSuffix))
+	require.NoError(t, err)
+	assert.Equal(t, 4, bytes.Count(data, []byte("\n")), "every flush must log the metrics changed since the last one")
+}
+
+func TestFsyncPolicy(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		policy        FsyncPolicy
+		expectedDirty bool
+	}{
+		{name: "Always syncs on write", policy: FsyncAlways},
+		{name: "Interval defers sync", policy: FsyncInterval, expectedDirty: true},
+		{name: "Never leaves sync to the system", policy: FsyncNever},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A long interval keeps the background sync from racing with the assertions.
+			repo, buildErr := NewInFileRepository(
+				logger, t.TempDir(), "metrics.json", 0, false, WithFsyncPolicy(tt.policy, time.Hour),
+			)
+			require.NoError(t, buildErr)
+			require.NoError(t, repo.Update(ctx, &entity.Metric{Name: "gauge", Type: "gauge", Value: 1.0}))
+			assert.Equal(t, tt.expectedDirty, repo.dirty.Load())
+
+			if repo.fsyncStopCh != nil {
+				close(repo.fsyncStopCh)
+				assert.Eventually(t, func() bool { return !repo.dirty.Load() }, time.Second, 5*time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestNewInFileRepositoryError(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	notDir := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(notDir, nil, fileDefaultPerm))
+
+	repo, err := NewInFileRepository(logger, notDir, "metrics.json", 0, false)
+	assert.Error(t, err, "a storage path below a regular file cannot be created")
+	assert.Nil(t, repo)
+}
+
+func TestMetaRestore(t *testing.T) {
+	ctx := context.Background()
+	logger := zap.NewNop().Sugar()
+	dir := t.TempDir()
+
+	repo, err := NewInFileRepository(logger, dir, "metrics.json", 0, false)
+	require.NoError(t, err)
+	metric := &entity.Metric{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(1)}
+	require.NoError(t, repo.Update(ctx, metric))
+	meta := &entity.Meta{Owner: "agents", Notes: "incremented on every poll"}
+	require.NoError(t, repo.SetMeta(ctx, entity.MetricTypeCounter, "PollCount", meta))
+	assert.FileExists(t, filepath.Join(dir, "metrics.json"+metaFileSuffix))
+
+	restored, err := NewInFileRepository(logger, dir, "metrics.json", 0, true)
+	require.NoError(t, err)
+	got, err := restored.Meta(ctx, entity.MetricTypeCounter, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, meta, got)
+}
+
+func TestRestoreCorruptSnapshot(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	dir := t.TempDir()
+	ctx := context.Background()
+	path := filepath.Join(dir, "metrics.json")
+
+	repo, err := NewInFileRepository(logger, dir, "metrics.json", time.Hour, false)
+	require.NoError(t, err)
+	for _, name := range []string{"a", "b", "c"} {
+		require.NoError(t, repo.Update(ctx, &entity.Metric{Name: name, Type: entity.MetricTypeGauge, Value: 1.0}))
+	}
+	repo.Shutdown()
+	restored, err := NewInFileRepository(logger, dir, "metrics.json", 0, true)
+	require.NoError(t, err)
+	all, err := restored.All(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, all.Length(), "a restart folds the log into a checksummed snapshot")
+
+	// A kill in the middle of a write used to leave a cut short file behind.
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data[:len(data)-20], fileDefaultPerm))
+
+	corrupted, err := NewInFileRepository(logger, dir, "metrics.json", 0, true)
+	require.NoError(t, err)
+	all, err = corrupted.All(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, all.Length(), "a corrupted snapshot must not be half-loaded")
+
+	moved, err := os.ReadFile(path + corruptFileSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, data[:len(data)-20], moved, "the corrupted snapshot is kept for inspection")
+}
+
+func TestRestoreUnreadableWAL(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	dir := t.TempDir()
+	walPath := filepath.Join(dir, "metrics.json"+walFileSuffix)
+
+	// A log that cannot be read must not be emptied by the compaction that follows a restore.
+	require.NoError(t, os.Mkdir(walPath, dirDefaultPerm))
+	require.NoError(t, os.WriteFile(filepath.Join(walPath, "record"), []byte("kept"), fileDefaultPerm))
+
+	repo, err := NewInFileRepository(logger, dir, "metrics.json", 0, true)
+	require.NoError(t, err)
+	defer repo.Shutdown()
+
+	kept, err := os.ReadFile(filepath.Join(walPath+corruptFileSuffix, "record"))
+	require.NoError(t, err)
+	assert.Equal(t, "kept", string(kept), "the unread log is kept for inspection")
+}
+
+func TestRestoreLegacySnapshot(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	dir := t.TempDir()
+	legacy := "{\"name\":\"a\",\"type\":\"gauge\",\"value\":1.5}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "metrics.json"), []byte(legacy), fileDefaultPerm))
+
+	repo, err := NewInFileRepository(logger, dir, "metrics.json", 0, true)
+	require.NoError(t, err)
+	metric, err := repo.Find(context.Background(), entity.MetricTypeGauge, "a")
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, metric.Value)
+
+	data, err := os.ReadFile(filepath.Join(dir, "metrics.json"))
+	require.NoError(t, err)
+	_, verified, err := verifySnapshot(data)
+	require.NoError(t, err)
+	assert.True(t, verified, "the legacy file is rewritten with a checksum header")
 }
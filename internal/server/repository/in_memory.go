@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"sync"
+	"time"
 
 	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
 
@@ -13,10 +15,26 @@ import (
 
 // InMemoryRepository implements a thread-safe in-memory storage for metrics.
 // Metrics are stored in a nested map organized by metric type and name.
+// Deleted metrics are moved to a separate tombstones map until they are restored or purged.
+//
+// Full reads use copy-on-write snapshots: taking a snapshot only copies references to the per-type maps,
+// which are then read without holding the lock. A writer clones a per-type map still referenced by a snapshot
+// before changing it, so snapshots stay stable and writers never wait for a full read to finish.
 type InMemoryRepository struct {
-	storage map[string]map[string]any // storage maps metric type to a map of metric name to value.
-	mu      *sync.RWMutex             // mu synchronizes access to the storage.
-	logger  *zap.SugaredLogger        // logger is used for logging repository operations.
+	storage    map[string]map[string]any         // storage maps metric type to a map of metric name to value.
+	tombstones map[string]map[string]*tombstone  // tombstones holds soft-deleted metrics by type and name.
+	meta       map[string]map[string]entity.Meta // meta holds the annotations of metrics by type and name.
+	timestamps map[string]map[string]time.Time   // timestamps holds the sample times of metrics by type and name.
+	shared     map[string]struct{}               // shared holds the metric types whose maps a snapshot may still read.
+	mu         *sync.RWMutex                     // mu synchronizes access to the storage.
+	logger     *zap.SugaredLogger                // logger is used for logging repository operations.
+}
+
+// tombstone keeps the last value of a soft-deleted metric along with the deletion time.
+type tombstone struct {
+	deletedAt time.Time // deletedAt is the moment the metric was deleted.
+	timestamp time.Time // timestamp is the sample time of the value.
+	value     any       // value is the metric value at the moment of deletion.
 }
 
 // NewInMemoryRepository creates a new instance of InMemoryRepository.
@@ -28,14 +46,18 @@ type InMemoryRepository struct {
 //   - *InMemoryRepository: A pointer to the newly created InMemoryRepository.
 func NewInMemoryRepository(logger *zap.SugaredLogger) *InMemoryRepository {
 	return &InMemoryRepository{
-		storage: make(map[string]map[string]any),
-		mu:      &sync.RWMutex{},
-		logger:  logger,
+		storage:    make(map[string]map[string]any),
+		tombstones: make(map[string]map[string]*tombstone),
+		meta:       make(map[string]map[string]entity.Meta),
+		timestamps: make(map[string]map[string]time.Time),
+		shared:     make(map[string]struct{}),
+		mu:         &sync.RWMutex{},
+		logger:     logger,
 	}
 }
 
 // Update adds or updates a metric in the repository.
-// It stores the metric value under its type and name.
+// It stores the metric value and timestamp under its type and name.
 //
 // Parameters:
 //   - ctx: The context for the operation.
@@ -51,11 +73,10 @@ func (r *InMemoryRepository) Update(_ context.Context, metric *entity.Metric) er
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if r.storage[metric.Type] == nil {
-		r.storage[metric.Type] = make(map[string]any)
-	}
-
-	r.storage[metric.Type][metric.Name] = metric.Value
+	r.writable(metric.Type)[metric.Name] = metric.Value
+	r.setTimestamp(metric.Type, metric.Name, metric.Timestamp)
+	// A fresh write supersedes any earlier deletion of the same metric.
+	delete(r.tombstones[metric.Type], metric.Name)
 	return nil
 }
 
@@ -103,14 +124,16 @@ func (r *InMemoryRepository) Find(_ context.Context, metricType string, name str
 	}
 
 	return &entity.Metric{
-		Value: value,
-		Name:  name,
-		Type:  metricType,
+		Timestamp: r.timestamps[metricType][name],
+		Value:     value,
+		Name:      name,
+		Type:      metricType,
 	}, nil
 }
 
 // All retrieves all metrics stored in the repository.
-// It compiles the metrics from the internal storage map into a Metrics slice.
+// It compiles the metrics from a consistent snapshot of the storage into a Metrics slice
+// without blocking writers while doing so.
 //
 // Parameters:
 //   - ctx: The context for the operation.
@@ -119,11 +142,10 @@ func (r *InMemoryRepository) Find(_ context.Context, metricType string, name str
 //   - *entity.Metrics: A pointer to the collection of all metrics.
 //   - error: An error if retrieval fails.
 func (r *InMemoryRepository) All(_ context.Context) (*entity.Metrics, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	snapshot := r.snapshot()
 
 	metrics := entity.Metrics{}
-	for metricType, metricMap := range r.storage {
+	for metricType, metricMap := range snapshot {
 		for name, value := range metricMap {
 			metrics = append(metrics, &entity.Metric{
 				Value: value,
@@ -136,6 +158,206 @@ func (r *InMemoryRepository) All(_ context.Context) (*entity.Metrics, error) {
 	return &metrics, nil
 }
 
+// Page retrieves one page of the metrics, sorted from a consistent snapshot of the storage.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - query: The offset, the limit and the order of the page.
+//
+// Returns:
+//   - *Page: The metrics of the page and the total number of metrics.
+//   - error: ErrInvalidPageQuery if the query is invalid.
+func (r *InMemoryRepository) Page(ctx context.Context, query PageQuery) (*Page, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	metrics, err := r.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return PageOf(*metrics, query), nil
+}
+
+// Query retrieves the metrics matching a filter from a consistent snapshot of the storage.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - filter: The type, the name prefix and the name expression the metrics must match.
+//
+// Returns:
+//   - *entity.Metrics: The matching metrics, sorted by name and then by type.
+//   - error: An error if retrieval fails.
+func (r *InMemoryRepository) Query(ctx context.Context, filter MetricFilter) (*entity.Metrics, error) {
+	metrics, err := r.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matched := Filter(*metrics, filter)
+	return &matched, nil
+}
+
+// Delete soft-deletes a metric by moving it from the storage into the tombstones map.
+// The metric disappears from Find and All but can be restored with Undelete until purged.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - metricType: The type of the metric.
+//   - name: The name of the metric.
+//
+// Returns:
+//   - error: ErrNotFoundInRepo if the metric does not exist.
+func (r *InMemoryRepository) Delete(_ context.Context, metricType string, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	value, exist := r.storage[metricType][name]
+	if !exist {
+		return fmt.Errorf("%w: type=%s, name=%s", ErrNotFoundInRepo, metricType, name)
+	}
+
+	if r.tombstones[metricType] == nil {
+		r.tombstones[metricType] = make(map[string]*tombstone)
+	}
+	r.tombstones[metricType][name] = &tombstone{
+		value:     value,
+		timestamp: r.timestamps[metricType][name],
+		deletedAt: time.Now(),
+	}
+	delete(r.writable(metricType), name)
+	delete(r.timestamps[metricType], name)
+	return nil
+}
+
+// Undelete restores a soft-deleted metric with the value it had at the moment of deletion.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - metricType: The type of the metric.
+//   - name: The name of the metric.
+//
+// Returns:
+//   - error: ErrNotFoundInRepo if there is no tombstone for the metric.
+func (r *InMemoryRepository) Undelete(_ context.Context, metricType string, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ts, exist := r.tombstones[metricType][name]
+	if !exist {
+		return fmt.Errorf("%w: deleted metric type=%s, name=%s", ErrNotFoundInRepo, metricType, name)
+	}
+
+	r.writable(metricType)[name] = ts.value
+	r.setTimestamp(metricType, name, ts.timestamp)
+	delete(r.tombstones[metricType], name)
+	return nil
+}
+
+// Purge permanently removes tombstones of metrics deleted before the given moment.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - deletedBefore: Tombstones older than this moment are removed.
+//
+// Returns:
+//   - int: The number of purged metrics.
+//   - error: Always nil.
+func (r *InMemoryRepository) Purge(_ context.Context, deletedBefore time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int
+	for _, byName := range r.tombstones {
+		for name, ts := range byName {
+			if ts.deletedAt.Before(deletedBefore) {
+				delete(byName, name)
+				purged++
+			}
+		}
+	}
+	return purged, nil
+}
+
+// Meta retrieves the annotations of a metric.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - metricType: The type of the metric.
+//   - name: The name of the metric.
+//
+// Returns:
+//   - *entity.Meta: A copy of the annotations.
+//   - error: ErrNotFoundInRepo if the metric has no annotations.
+func (r *InMemoryRepository) Meta(_ context.Context, metricType string, name string) (*entity.Meta, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	meta, exist := r.meta[metricType][name]
+	if !exist {
+		return nil, fmt.Errorf("%w: annotations of type=%s, name=%s", ErrNotFoundInRepo, metricType, name)
+	}
+	return &meta, nil
+}
+
+// SetMeta replaces the annotations of a stored metric. Empty annotations remove them.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - metricType: The type of the metric.
+//   - name: The name of the metric.
+//   - meta: The new annotations.
+//
+// Returns:
+//   - error: ErrNotFoundInRepo if the metric is not stored.
+func (r *InMemoryRepository) SetMeta(_ context.Context, metricType string, name string, meta *entity.Meta) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exist := r.storage[metricType][name]; !exist {
+		return fmt.Errorf("%w: type=%s, name=%s", ErrNotFoundInRepo, metricType, name)
+	}
+	r.setMeta(metricType, name, meta)
+	return nil
+}
+
+// setMeta stores or removes annotations without checking the metric. The caller must hold the write lock.
+func (r *InMemoryRepository) setMeta(metricType string, name string, meta *entity.Meta) {
+	if meta.IsEmpty() {
+		delete(r.meta[metricType], name)
+		return
+	}
+	if r.meta[metricType] == nil {
+		r.meta[metricType] = make(map[string]entity.Meta)
+	}
+	r.meta[metricType][name] = *meta
+}
+
+// setTimestamp stores or, for a zero time, removes the sample time of a metric. The caller must hold
+// the write lock.
+func (r *InMemoryRepository) setTimestamp(metricType string, name string, timestamp time.Time) {
+	if timestamp.IsZero() {
+		delete(r.timestamps[metricType], name)
+		return
+	}
+	if r.timestamps[metricType] == nil {
+		r.timestamps[metricType] = make(map[string]time.Time)
+	}
+	r.timestamps[metricType][name] = timestamp
+}
+
+// metaSnapshot returns a copy of the annotations of all metrics by type and name.
+func (r *InMemoryRepository) metaSnapshot() map[string]map[string]entity.Meta {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshot := make(map[string]map[string]entity.Meta, len(r.meta))
+	for metricType, byName := range r.meta {
+		if len(byName) > 0 {
+			snapshot[metricType] = maps.Clone(byName)
+		}
+	}
+	return snapshot
+}
+
 // CheckConnection checks the connection status of the repository.
 // Since the repository is in-memory, it always returns nil.
 //
@@ -147,3 +369,42 @@ func (r *InMemoryRepository) All(_ context.Context) (*entity.Metrics, error) {
 func (r *InMemoryRepository) CheckConnection(_ context.Context) error {
 	return nil
 }
+
+// snapshot returns a stable view of the storage. The returned maps must not be modified.
+//
+// Returns:
+//   - map[string]map[string]any: The metric values by type and name at the moment of the call.
+func (r *InMemoryRepository) snapshot() map[string]map[string]any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := make(map[string]map[string]any, len(r.storage))
+	for metricType, metricMap := range r.storage {
+		snapshot[metricType] = metricMap
+		r.shared[metricType] = struct{}{}
+	}
+	return snapshot
+}
+
+// writable returns the storage map of a metric type that may be modified, creating it if needed.
+// A map still referenced by a snapshot is cloned first. The caller must hold the write lock.
+//
+// Parameters:
+//   - metricType: The type of the metric.
+//
+// Returns:
+//   - map[string]any: The map of metric name to value owned by the storage.
+func (r *InMemoryRepository) writable(metricType string) map[string]any {
+	metricMap := r.storage[metricType]
+	if metricMap == nil {
+		metricMap = make(map[string]any)
+		r.storage[metricType] = metricMap
+		return metricMap
+	}
+	if _, shared := r.shared[metricType]; shared {
+		metricMap = maps.Clone(metricMap)
+		r.storage[metricType] = metricMap
+		delete(r.shared, metricType)
+	}
+	return metricMap
+}
@@ -2,10 +2,14 @@ package repository
 
 import (
 	"context"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -59,7 +63,7 @@ func TestFind(t *testing.T) {
 	repo := NewInMemoryRepository(logger)
 	ctx := context.Background()
 
-	metric := &entity.Metric{Name: "test", Type: "gauge", Value: 42.0}
+	metric := &entity.Metric{Name: "test", Type: "gauge", Value: 42.0, Timestamp: time.Unix(1700000000, 0)}
 	_ = repo.Update(ctx, metric)
 
 	t.Run("Existing metric", func(t *testing.T) {
@@ -88,7 +92,7 @@ func TestAll(t *testing.T) {
 
 	result, err := repo.All(ctx)
 	assert.NoError(t, err)
-	assert.Equal(t, &metrics, result)
+	assert.ElementsMatch(t, metrics, *result)
 }
 
 func TestCheckConnection(t *testing.T) {
@@ -99,3 +103,127 @@ func TestCheckConnection(t *testing.T) {
 	err := repo.CheckConnection(ctx)
 	assert.NoError(t, err)
 }
+
+func TestSoftDelete(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	repo := NewInMemoryRepository(logger)
+	ctx := context.Background()
+
+	metric := &entity.Metric{Name: "temp", Type: "gauge", Value: 36.6, Timestamp: time.Unix(1700000000, 0)}
+	_ = repo.Update(ctx, metric)
+
+	t.Run("Delete hides metric", func(t *testing.T) {
+		assert.NoError(t, repo.Delete(ctx, "gauge", "temp"))
+		_, err := repo.Find(ctx, "gauge", "temp")
+		assert.ErrorIs(t, err, ErrNotFoundInRepo)
+	})
+
+	t.Run("Delete missing metric", func(t *testing.T) {
+		assert.ErrorIs(t, repo.Delete(ctx, "gauge", "missing"), ErrNotFoundInRepo)
+	})
+
+	t.Run("Undelete restores value and timestamp", func(t *testing.T) {
+		assert.NoError(t, repo.Undelete(ctx, "gauge", "temp"))
+		result, err := repo.Find(ctx, "gauge", "temp")
+		assert.NoError(t, err)
+		assert.Equal(t, metric, result)
+		assert.ErrorIs(t, repo.Undelete(ctx, "gauge", "temp"), ErrNotFoundInRepo)
+	})
+
+	t.Run("Purge removes expired tombstones", func(t *testing.T) {
+		assert.NoError(t, repo.Delete(ctx, "gauge", "temp"))
+
+		purged, err := repo.Purge(ctx, time.Now().Add(-time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, 0, purged)
+
+		purged, err = repo.Purge(ctx, time.Now().Add(time.Second))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, purged)
+		assert.ErrorIs(t, repo.Undelete(ctx, "gauge", "temp"), ErrNotFoundInRepo)
+	})
+
+	t.Run("Update clears tombstone", func(t *testing.T) {
+		_ = repo.Update(ctx, metric)
+		assert.NoError(t, repo.Delete(ctx, "gauge", "temp"))
+		_ = repo.Update(ctx, &entity.Metric{Name: "temp", Type: "gauge", Value: 1.0})
+		assert.ErrorIs(t, repo.Undelete(ctx, "gauge", "temp"), ErrNotFoundInRepo)
+	})
+}
+
+func TestSnapshotIsolation(t *testing.T) {
+	repo := NewInMemoryRepository(zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	require.NoError(t, repo.Update(ctx, &entity.Metric{Name: "kept", Type: "gauge", Value: 1.0}))
+	require.NoError(t, repo.Update(ctx, &entity.Metric{Name: "deleted", Type: "gauge", Value: 2.0}))
+
+	snapshot := repo.snapshot()
+
+	require.NoError(t, repo.Update(ctx, &entity.Metric{Name: "kept", Type: "gauge", Value: 10.0}))
+	require.NoError(t, repo.Update(ctx, &entity.Metric{Name: "added", Type: "gauge", Value: 3.0}))
+	require.NoError(t, repo.Delete(ctx, "gauge", "deleted"))
+
+	assert.Equal(t, map[string]any{"kept": 1.0, "deleted": 2.0}, snapshot["gauge"], "snapshot must not see later writes")
+
+	current, err := repo.Find(ctx, "gauge", "kept")
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, current.Value)
+	_, err = repo.Find(ctx, "gauge", "deleted")
+	assert.ErrorIs(t, err, ErrNotFoundInRepo)
+}
+
+func TestAllConcurrentWithWrites(t *testing.T) {
+	repo := NewInMemoryRepository(zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range 500 {
+			_ = repo.Update(ctx, &entity.Metric{Name: "m" + strconv.Itoa(i%50), Type: "gauge", Value: float64(i)})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			all, err := repo.All(ctx)
+			assert.NoError(t, err)
+			assert.LessOrEqual(t, all.Length(), 50)
+		}
+	}()
+	wg.Wait()
+
+	all, err := repo.All(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 50, all.Length())
+}
+
+func TestMeta(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryRepository(zap.NewNop().Sugar())
+	require.NoError(t, repo.Update(ctx, &entity.Metric{Name: "HeapAlloc", Type: entity.MetricTypeGauge, Value: 1.0}))
+
+	_, err := repo.Meta(ctx, entity.MetricTypeGauge, "HeapAlloc")
+	assert.ErrorIs(t, err, ErrNotFoundInRepo, "no annotations yet")
+
+	err = repo.SetMeta(ctx, entity.MetricTypeGauge, "Missing", &entity.Meta{Owner: "platform"})
+	assert.ErrorIs(t, err, ErrNotFoundInRepo, "only stored metrics can be annotated")
+
+	meta := &entity.Meta{Owner: "platform", Runbook: "https://wiki/heap"}
+	require.NoError(t, repo.SetMeta(ctx, entity.MetricTypeGauge, "HeapAlloc", meta))
+	got, err := repo.Meta(ctx, entity.MetricTypeGauge, "HeapAlloc")
+	require.NoError(t, err)
+	assert.Equal(t, meta, got)
+
+	// Annotations outlive deletion.
+	require.NoError(t, repo.Delete(ctx, entity.MetricTypeGauge, "HeapAlloc"))
+	_, err = repo.Meta(ctx, entity.MetricTypeGauge, "HeapAlloc")
+	assert.NoError(t, err)
+
+	require.NoError(t, repo.Undelete(ctx, entity.MetricTypeGauge, "HeapAlloc"))
+	require.NoError(t, repo.SetMeta(ctx, entity.MetricTypeGauge, "HeapAlloc", &entity.Meta{}))
+	_, err = repo.Meta(ctx, entity.MetricTypeGauge, "HeapAlloc")
+	assert.ErrorIs(t, err, ErrNotFoundInRepo, "empty annotations are removed")
+}
@@ -0,0 +1 @@
+ALTER TABLE metrics ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ NULL;
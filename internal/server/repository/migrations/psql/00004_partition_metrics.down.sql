@@ -0,0 +1,19 @@
+ALTER TABLE metrics RENAME TO metrics_partitioned;
+ALTER INDEX IF EXISTS metrics_pkey RENAME TO metrics_partitioned_pkey;
+
+CREATE TABLE metrics (
+    id SERIAL PRIMARY KEY,
+    m_type TEXT NOT NULL,
+    m_name TEXT NOT NULL,
+    m_value JSONB NOT NULL,
+    deleted_at TIMESTAMPTZ NULL,
+    m_ts TIMESTAMPTZ NULL,
+    CONSTRAINT unique_type_name UNIQUE (m_type, m_name)
+);
+CREATE INDEX IF NOT EXISTS idx_metrics_type_name ON metrics (m_type, m_name);
+
+INSERT INTO metrics (m_type, m_name, m_value, deleted_at, m_ts)
+SELECT m_type, m_name, m_value, deleted_at, m_ts
+FROM metrics_partitioned;
+
+DROP TABLE metrics_partitioned;
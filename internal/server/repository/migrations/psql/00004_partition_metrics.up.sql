@@ -0,0 +1,42 @@
+-- Metrics are hash-partitioned by name into 16 list partitions keyed by m_shard.
+-- The shard is computed by the application (FNV-1a of the name modulo 16), so writers know the target
+-- partition up front; metricol_shard mirrors that hash to move existing rows.
+CREATE OR REPLACE FUNCTION metricol_shard(name TEXT) RETURNS SMALLINT AS $$
+DECLARE
+    h BIGINT := 2166136261;
+    raw BYTEA := convert_to(name, 'UTF8');
+BEGIN
+    FOR i IN 0..octet_length(raw) - 1 LOOP
+        h := ((h # get_byte(raw, i)) * 16777619) % 4294967296;
+    END LOOP;
+    RETURN (h % 16)::SMALLINT;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE STRICT;
+
+ALTER TABLE metrics RENAME TO metrics_unpartitioned;
+ALTER INDEX IF EXISTS metrics_pkey RENAME TO metrics_unpartitioned_pkey;
+
+CREATE TABLE metrics (
+    m_shard SMALLINT NOT NULL,
+    m_type TEXT NOT NULL,
+    m_name TEXT NOT NULL,
+    m_value JSONB NOT NULL,
+    m_ts TIMESTAMPTZ NULL,
+    deleted_at TIMESTAMPTZ NULL,
+    PRIMARY KEY (m_shard, m_type, m_name)
+) PARTITION BY LIST (m_shard);
+
+DO $$
+    BEGIN
+        FOR i IN 0..15 LOOP
+            EXECUTE format('CREATE TABLE metrics_p%s PARTITION OF metrics FOR VALUES IN (%s)', lpad(i::TEXT, 2, '0'), i);
+        END LOOP;
+    END
+$$;
+
+INSERT INTO metrics (m_shard, m_type, m_name, m_value, m_ts, deleted_at)
+SELECT metricol_shard(m_name), m_type, m_name, m_value, m_ts, deleted_at
+FROM metrics_unpartitioned;
+
+DROP TABLE metrics_unpartitioned;
+DROP FUNCTION metricol_shard(TEXT);
@@ -0,0 +1,8 @@
+-- Annotations are keyed by type and name only, without a foreign key to metrics, so they outlive
+-- the purge of a metric and apply again when it is reported anew.
+CREATE TABLE IF NOT EXISTS metric_meta (
+    m_type TEXT NOT NULL,
+    m_name TEXT NOT NULL,
+    meta JSONB NOT NULL,
+    PRIMARY KEY (m_type, m_name)
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_metrics_live_type;
+DROP INDEX IF EXISTS idx_metrics_live_name;
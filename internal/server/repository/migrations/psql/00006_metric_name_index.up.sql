@@ -0,0 +1,4 @@
+-- Pages of the main page are sorted by name or by type; the indexes let every partition return its rows
+-- in order, so a page is merged from the partitions instead of sorting all metrics.
+CREATE INDEX IF NOT EXISTS idx_metrics_live_name ON metrics (m_name, m_type) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_metrics_live_type ON metrics (m_type, m_name) WHERE deleted_at IS NULL;
@@ -0,0 +1,125 @@
+//go:build !nopostgres
+
+package repository
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+//go:embed migrations/psql/*.sql
+var migrationsDir embed.FS
+
+// Migrator applies, rolls back and reports the embedded PostgreSQL migrations.
+type Migrator struct {
+	migrate  *migrate.Migrate // migrate runs the migrations against the database.
+	versions []uint           // versions lists the embedded migration versions in ascending order.
+}
+
+// NewMigrator creates a Migrator for the database at dsn without connecting to it through the repository,
+// so the schema can be managed before the server starts serving metrics.
+//
+// Parameters:
+//   - dsn: The connection string of the database.
+//
+// Returns:
+//   - *Migrator: The migrator; it must be closed with Close.
+//   - error: An error if the embedded migrations cannot be read or the database cannot be reached.
+func NewMigrator(dsn string) (*Migrator, error) {
+	versions, err := embeddedMigrationVersions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	d, err := iofs.New(migrationsDir, "migrations/psql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to return an iofs driver: %w", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", d, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a new migrate instance: %w", err)
+	}
+	return &Migrator{migrate: m, versions: versions}, nil
+}
+
+// Up applies all pending migrations. Having nothing to apply is not an error.
+//
+// Returns:
+//   - error: An error if a migration fails.
+func (m *Migrator) Up() error {
+	if err := m.migrate.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to apply migrations to the DB: %w", err)
+	}
+	return nil
+}
+
+// Down rolls back the most recently applied migration.
+//
+// Returns:
+//   - error: An error if no migration is applied or the rollback fails.
+func (m *Migrator) Down() error {
+	if err := m.migrate.Steps(-1); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return errors.New("failed to roll back migration: no migration is applied")
+		}
+		return fmt.Errorf("failed to roll back migration: %w", err)
+	}
+	return nil
+}
+
+// Status reports the current schema version together with the applied and pending migrations.
+//
+// Returns:
+//   - *MigrationStatus: The schema version of the database.
+//   - error: An error if the version cannot be read.
+func (m *Migrator) Status() (*MigrationStatus, error) {
+	status := MigrationStatus{Applied: make([]uint, 0), Pending: make([]uint, 0)}
+
+	version, dirty, err := m.migrate.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	status.Version, status.Dirty = version, dirty
+
+	for _, v := range m.versions {
+		if err == nil && v <= version {
+			status.Applied = append(status.Applied, v)
+			continue
+		}
+		status.Pending = append(status.Pending, v)
+	}
+	return &status, nil
+}
+
+// Close releases the database connection held by the migrator.
+func (m *Migrator) Close() {
+	_, _ = m.migrate.Close()
+}
+
+// embeddedMigrationVersions returns the versions of the embedded migrations in ascending order.
+func embeddedMigrationVersions() ([]uint, error) {
+	d, err := iofs.New(migrationsDir, "migrations/psql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to return an iofs driver: %w", err)
+	}
+	defer func() { _ = d.Close() }()
+
+	versions := make([]uint, 0)
+	version, err := d.First()
+	for err == nil {
+		versions = append(versions, version)
+		version, err = d.Next(version)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read migration versions: %w", err)
+	}
+	return versions, nil
+}
@@ -0,0 +1,44 @@
+//go:build !nopostgres
+
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedMigrationVersions(t *testing.T) {
+	versions, err := embeddedMigrationVersions()
+	require.NoError(t, err)
+	assert.Equal(t, []uint{1, 2, 3, 4, 5, 6}, versions)
+}
+
+func TestNewMigratorInvalidDSN(t *testing.T) {
+	_, err := NewMigrator("not a dsn")
+	assert.Error(t, err)
+}
+
+func TestMigrationStatusErr(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  MigrationStatus
+		wantErr bool
+	}{
+		{name: "Up to date", status: MigrationStatus{Applied: []uint{1, 2}, Version: 2}},
+		{name: "Pending", status: MigrationStatus{Applied: []uint{1}, Pending: []uint{2}, Version: 1}, wantErr: true},
+		{name: "Dirty", status: MigrationStatus{Applied: []uint{1, 2}, Version: 2, Dirty: true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.status.Err()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrPendingMigrations)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
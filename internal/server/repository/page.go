@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+)
+
+const (
+	// Const SortByName orders metrics by name, then by type.
+	SortByName = "name"
+	// Const SortByType orders metrics by type, then by name.
+	SortByType = "type"
+)
+
+// ErrInvalidPageQuery is returned when a page is requested with a negative offset or limit or an unknown order.
+var ErrInvalidPageQuery = errors.New("invalid page query")
+
+// PageQuery selects a page of the stored metrics.
+type PageQuery struct {
+	SortBy string // SortBy is SortByName or SortByType; empty sorts by name.
+	Offset int    // Offset is the number of metrics skipped.
+	Limit  int    // Limit caps the metrics of the page; zero returns all metrics after Offset.
+}
+
+// Page is a page of the stored metrics.
+type Page struct {
+	Metrics entity.Metrics // Metrics are the metrics of the page, in the requested order.
+	Total   int            // Total is the number of stored metrics across all pages.
+}
+
+// Validate checks the offset, the limit and the order of the query.
+//
+// Returns:
+//   - error: ErrInvalidPageQuery if the query cannot be served.
+func (q PageQuery) Validate() error {
+	if q.Offset < 0 || q.Limit < 0 {
+		return fmt.Errorf("%w: offset %d and limit %d must not be negative", ErrInvalidPageQuery, q.Offset, q.Limit)
+	}
+	switch q.SortBy {
+	case "", SortByName, SortByType:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown order %q", ErrInvalidPageQuery, q.SortBy)
+	}
+}
+
+// PageOf sorts metrics in the order of the query and cuts out the requested page. It serves paging for
+// storages that hold every metric in memory anyway; the metrics slice itself is not reordered.
+//
+// Parameters:
+//   - metrics: All metrics.
+//   - query: The page to cut out; it must be valid.
+//
+// Returns:
+//   - *Page: The page.
+func PageOf(metrics entity.Metrics, query PageQuery) *Page {
+	sorted := slices.Clone(metrics)
+	slices.SortFunc(sorted, metricOrder(query.SortBy))
+
+	start := min(query.Offset, len(sorted))
+	end := len(sorted)
+	if query.Limit > 0 {
+		end = min(start+query.Limit, end)
+	}
+	return &Page{Metrics: sorted[start:end], Total: len(sorted)}
+}
+
+// metricOrder returns the comparison ordering metrics as requested.
+//
+// Parameters:
+//   - sortBy: SortByName or SortByType; empty sorts by name.
+//
+// Returns:
+//   - func(a, b *entity.Metric) int: The comparison.
+func metricOrder(sortBy string) func(a, b *entity.Metric) int {
+	if sortBy == SortByType {
+		return func(a, b *entity.Metric) int {
+			return cmp.Or(cmp.Compare(a.Type, b.Type), cmp.Compare(a.Name, b.Name))
+		}
+	}
+	return func(a, b *entity.Metric) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Type, b.Type))
+	}
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageQuery_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   PageQuery
+		wantErr bool
+	}{
+		{name: "Zero query", query: PageQuery{}},
+		{name: "By type", query: PageQuery{SortBy: SortByType, Offset: 10, Limit: 5}},
+		{name: "Negative offset", query: PageQuery{Offset: -1}, wantErr: true},
+		{name: "Negative limit", query: PageQuery{Limit: -1}, wantErr: true},
+		{name: "Unknown order", query: PageQuery{SortBy: "value"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidPageQuery)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPageOf(t *testing.T) {
+	metrics := entity.Metrics{
+		{Name: "c", Type: entity.MetricTypeGauge},
+		{Name: "a", Type: entity.MetricTypeGauge},
+		{Name: "b", Type: entity.MetricTypeCounter},
+		{Name: "a", Type: entity.MetricTypeCounter},
+	}
+	tests := []struct {
+		name  string
+		query PageQuery
+		want  []string
+	}{
+		{name: "All by name", query: PageQuery{}, want: []string{"a/counter", "a/gauge", "b/counter", "c/gauge"}},
+		{
+			name:  "All by type",
+			query: PageQuery{SortBy: SortByType},
+			want:  []string{"a/counter", "b/counter", "a/gauge", "c/gauge"},
+		},
+		{name: "Middle page", query: PageQuery{Offset: 1, Limit: 2}, want: []string{"a/gauge", "b/counter"}},
+		{name: "Short last page", query: PageQuery{Offset: 3, Limit: 2}, want: []string{"c/gauge"}},
+		{name: "Offset past the end", query: PageQuery{Offset: 10, Limit: 2}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := PageOf(metrics, tt.query)
+			require.Equal(t, len(metrics), page.Total)
+			got := make([]string, 0, len(page.Metrics))
+			for _, m := range page.Metrics {
+				got = append(got, m.Name+"/"+m.Type)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	assert.Equal(t, "c", metrics[0].Name, "PageOf must not reorder its input")
+}
@@ -0,0 +1,71 @@
+//go:build !nopostgres
+
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/selfmetric"
+)
+
+const (
+	// Const poolPrimary names the connection pool of the primary database.
+	poolPrimary = "primary"
+	// Const poolReplica names the connection pool of the read-only replica.
+	poolReplica = "replica"
+	// Const selfMetricPoolPrefix prefixes the self-metrics of the primary pool; the replica pool
+	// metrics additionally carry the pool name, e.g. metricol_db_replica_open_connections.
+	selfMetricPoolPrefix = "metricol_db_"
+)
+
+// newPoolStats converts the statistics reported by database/sql.
+func newPoolStats(s sql.DBStats) PoolStats {
+	return PoolStats{
+		MaxOpenConnections:  s.MaxOpenConnections,
+		OpenConnections:     s.OpenConnections,
+		InUse:               s.InUse,
+		Idle:                s.Idle,
+		WaitCount:           s.WaitCount,
+		WaitDurationSeconds: s.WaitDuration.Seconds(),
+		MaxIdleClosed:       s.MaxIdleClosed,
+		MaxIdleTimeClosed:   s.MaxIdleTimeClosed,
+		MaxLifetimeClosed:   s.MaxLifetimeClosed,
+	}
+}
+
+// PoolStats reports the statistics of the connection pools, keyed by pool name ("primary" and,
+// if configured, "replica").
+//
+// Returns:
+//   - map[string]PoolStats: The statistics of every connection pool.
+func (p *PostgreSQL) PoolStats() map[string]PoolStats {
+	stats := map[string]PoolStats{poolPrimary: newPoolStats(p.db.Stats())}
+	if p.replica != nil {
+		stats[poolReplica] = newPoolStats(p.replica.Stats())
+	}
+	return stats
+}
+
+// registerPoolSelfMetrics exposes the statistics of a connection pool through the self-metric registry.
+//
+// Parameters:
+//   - registry: The registry to register the metrics in.
+//   - prefix: The prefix of the metric names.
+//   - db: The connection pool.
+func registerPoolSelfMetrics(registry *selfmetric.Registry, prefix string, db *sql.DB) {
+	registry.RegisterGauge(prefix+"open_connections", func() float64 {
+		return float64(db.Stats().OpenConnections)
+	})
+	registry.RegisterGauge(prefix+"in_use_connections", func() float64 {
+		return float64(db.Stats().InUse)
+	})
+	registry.RegisterGauge(prefix+"idle_connections", func() float64 {
+		return float64(db.Stats().Idle)
+	})
+	registry.RegisterCounter(prefix+"wait_count", func() int64 {
+		return db.Stats().WaitCount
+	})
+	registry.RegisterGauge(prefix+"wait_duration_seconds", func() float64 {
+		return db.Stats().WaitDuration.Seconds()
+	})
+}
@@ -0,0 +1,64 @@
+//go:build !nopostgres
+
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/internal/selfmetric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgreSQL_PoolStats(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	p := newTestPostgreSQL(db)
+	p.db.SetMaxOpenConns(7)
+
+	stats := p.PoolStats()
+	assert.Equal(t, []string{poolPrimary}, mapKeys(stats))
+	assert.Equal(t, 7, stats[poolPrimary].MaxOpenConnections)
+
+	replica, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = replica.Close() }()
+	p.replica = replica
+
+	assert.ElementsMatch(t, []string{poolPrimary, poolReplica}, mapKeys(p.PoolStats()))
+}
+
+func TestPostgreSQL_RegisterPoolSelfMetrics(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	replica, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = replica.Close() }()
+
+	p := newTestPostgreSQL(db)
+	p.replica = replica
+	registry := selfmetric.NewRegistry()
+	p.RegisterSelfMetrics(registry)
+
+	for _, name := range []string{"metricol_db_open_connections", "metricol_db_replica_open_connections"} {
+		_, ok := registry.Find(entity.MetricTypeGauge, name)
+		assert.True(t, ok, "gauge %s must be registered", name)
+	}
+	for _, name := range []string{"metricol_db_wait_count", selfMetricReplicaFallbacks} {
+		_, ok := registry.Find(entity.MetricTypeCounter, name)
+		assert.True(t, ok, "counter %s must be registered", name)
+	}
+}
+
+// mapKeys returns the keys of the pool statistics.
+func mapKeys(stats map[string]PoolStats) []string {
+	keys := make([]string, 0, len(stats))
+	for k := range stats {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -1,20 +1,25 @@
+//go:build !nopostgres
+
 package repository
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
-	"embed"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/internal/server/internal/selfmetric"
 	"github.com/gdyunin/metricol.git/pkg/retry"
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	"github.com/golang-migrate/migrate/v4/source/iofs"
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/labstack/gommon/log"
 	"go.uber.org/zap"
 )
@@ -22,6 +27,67 @@ import (
 const (
 	// Const defaultPSQLConnectionCheckTimeout specifies the timeout for a PostgreSQL connection check.
 	defaultPSQLConnectionCheckTimeout = time.Second
+	// Const defaultReplicaRetryDelay is how long reads skip a failed replica before trying it again.
+	defaultReplicaRetryDelay = 5 * time.Second
+	// Const defaultHealthCheckInterval is the period between pings of a healthy database.
+	defaultHealthCheckInterval = 5 * time.Second
+	// Const defaultReconnectBackoff is the first delay between reconnection attempts; it doubles on every failure.
+	defaultReconnectBackoff = time.Second
+	// Const maxReconnectBackoff caps the delay between reconnection attempts.
+	maxReconnectBackoff = 30 * time.Second
+	// Const selfMetricReplicaFallbacks counts reads served by the primary because the replica failed.
+	selfMetricReplicaFallbacks = "metricol_db_replica_fallbacks"
+	// Const metricPartitions is the number of partitions of the metrics table.
+	// It must match the partitions created by the 00004_partition_metrics migration.
+	metricPartitions = 16
+	// Const upsertQueryFmt is the upsert statement; the table is a partition of the metrics table.
+	upsertQueryFmt = `
+		INSERT INTO public.%s (m_shard, m_type, m_name, m_value, m_ts)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (m_shard, m_type, m_name)
+		DO UPDATE SET m_value = EXCLUDED.m_value, m_ts = EXCLUDED.m_ts, deleted_at = NULL;
+	`
+	// Const batchUpsertQueryFmt is the multi-row upsert statement; the table is a partition of the metrics table
+	// and the rows are a list of value tuples.
+	batchUpsertQueryFmt = `
+		INSERT INTO public.%s (m_shard, m_type, m_name, m_value, m_ts)
+		VALUES %s
+		ON CONFLICT (m_shard, m_type, m_name)
+		DO UPDATE SET m_value = EXCLUDED.m_value, m_ts = EXCLUDED.m_ts, deleted_at = NULL;
+	`
+	// Const upsertColumns is the number of parameters of every upserted row.
+	upsertColumns = 5
+	// Const batchUpsertRows caps the rows of one multi-row upsert, keeping it far below the PostgreSQL
+	// limit of 65535 parameters per statement.
+	batchUpsertRows = 1000
+	// Const pageQueryFmt selects a page of the live metrics; the order is a list of columns.
+	pageQueryFmt = `
+		SELECT m_name, m_type, m_value, m_ts
+		FROM metrics
+		WHERE deleted_at IS NULL
+		ORDER BY %s
+		LIMIT $1 OFFSET $2;
+	`
+	// Const filterQuery selects the live metrics of a type, or of any type if $1 is empty, whose names
+	// start with $2.
+	filterQuery = `
+		SELECT m_name, m_type, m_value, m_ts
+		FROM metrics
+		WHERE deleted_at IS NULL AND ($1::text = '' OR m_type = $1) AND starts_with(m_name, $2);
+	`
+	// Const findQuery selects a live metric; it filters on the partition key so only one partition is scanned.
+	findQuery = `
+		SELECT m_name, m_type, m_value, m_ts
+		FROM metrics
+		WHERE m_shard = $3
+		  AND m_type = $1
+		  AND m_name = $2
+		  AND deleted_at IS NULL;
+	`
+	// Const defaultMaxIdleConns is the number of idle connections kept by default, as in database/sql.
+	defaultMaxIdleConns = 2
+	// Const defaultStatementCache is the default capacity of the per-connection statement cache of the driver.
+	defaultStatementCache = 512
 )
 
 var (
@@ -34,37 +100,194 @@ var (
 // PostgreSQL represents the PostgreSQL repository.
 // It holds the database connection and provides methods to interact with metrics stored in the database.
 type PostgreSQL struct {
-	db     *sql.DB            // db is the database connection.
-	logger *zap.SugaredLogger // logger is used for logging repository operations.
-	dsn    string             // dsn is the Data Source Name for the PostgreSQL connection.
+	db                *sql.DB            // db is the database connection.
+	replica           *sql.DB            // replica is the read-only replica connection, nil if not configured.
+	logger            *zap.SugaredLogger // logger is used for logging repository operations.
+	dsn               string             // dsn is the Data Source Name for the PostgreSQL connection.
+	replicaDSN        string             // replicaDSN is the Data Source Name of the read-only replica.
+	replicaRetryDelay time.Duration      // replicaRetryDelay is how long reads skip the replica after it fails.
+	replicaDownUntil  atomic.Int64       // replicaDownUntil is the Unix time in ns until which reads skip the replica.
+	replicaFallbacks  atomic.Int64       // replicaFallbacks counts reads served by the primary after a replica failure.
+	stopCh            chan struct{}      // stopCh stops the connection supervisor on shutdown.
+	healthCheckPeriod time.Duration      // healthCheckPeriod is the period between pings of a healthy database.
+	reconnectBackoff  time.Duration      // reconnectBackoff is the first delay between reconnection attempts.
+	healthy           atomic.Bool        // healthy reports whether the last connection check succeeded.
+	manualMigrations  bool               // manualMigrations disables applying migrations on startup.
+	lazyConnect       bool               // lazyConnect lets startup succeed while the database is unreachable.
+	maxOpenConns      int                // maxOpenConns caps the open connections of each pool; zero is unlimited.
+	maxIdleConns      int                // maxIdleConns is the number of idle connections kept by each pool.
+	connMaxLifetime   time.Duration      // connMaxLifetime is how long a connection is reused; zero is forever.
+	statementCache    int                // statementCache is the per-connection statement cache capacity.
+	stmts             stmtCache          // stmts holds the prepared statements of Update and Find.
+}
+
+// PostgreSQLOption configures optional behavior of a PostgreSQL repository.
+type PostgreSQLOption func(*PostgreSQL)
+
+// WithReplica routes Find and All to a read-only replica while writes keep going to the primary.
+// If a replica read fails, the read is retried on the primary and the replica is skipped for a while.
+// An empty DSN disables the replica.
+//
+// Parameters:
+//   - dsn: The connection string of the read-only replica.
+//
+// Returns:
+//   - PostgreSQLOption: The option configuring the replica.
+func WithReplica(dsn string) PostgreSQLOption {
+	return func(p *PostgreSQL) {
+		p.replicaDSN = dsn
+	}
+}
+
+// WithAutoMigrate controls whether pending migrations are applied on startup.
+// When disabled, startup fails with ErrPendingMigrations instead, so schema changes can go through
+// change control and be applied with the -migrate-up command.
+//
+// Parameters:
+//   - enabled: Whether to apply pending migrations on startup.
+//
+// Returns:
+//   - PostgreSQLOption: The option configuring startup migrations.
+func WithAutoMigrate(enabled bool) PostgreSQLOption {
+	return func(p *PostgreSQL) {
+		p.manualMigrations = !enabled
+	}
+}
+
+// WithLazyConnect lets the server start while the database is unreachable. The connection check and
+// migrations are then retried in the background, and operations fail until the database becomes available.
+//
+// Parameters:
+//   - enabled: Whether to connect lazily.
+//
+// Returns:
+//   - PostgreSQLOption: The option configuring lazy connect.
+func WithLazyConnect(enabled bool) PostgreSQLOption {
+	return func(p *PostgreSQL) {
+		p.lazyConnect = enabled
+	}
+}
+
+// WithConnPool sizes the connection pools of the primary and the replica. Keeping enough idle connections
+// avoids opening a new connection for every burst of requests, while a lifetime lets connections be
+// rebalanced, e.g. after a failover behind a proxy.
+//
+// Parameters:
+//   - maxOpen: The maximum number of open connections of each pool; zero or less is unlimited.
+//   - maxIdle: The number of idle connections kept by each pool; zero or less keeps none.
+//   - maxLifetime: How long a connection is reused before it is closed; zero or less reuses it forever.
+//
+// Returns:
+//   - PostgreSQLOption: The option configuring the connection pools.
+func WithConnPool(maxOpen, maxIdle int, maxLifetime time.Duration) PostgreSQLOption {
+	return func(p *PostgreSQL) {
+		p.maxOpenConns = maxOpen
+		p.maxIdleConns = maxIdle
+		p.connMaxLifetime = maxLifetime
+	}
+}
+
+// WithStatementCache sets the capacity of the statement cache the driver keeps on every connection,
+// so repeated queries are parsed once per connection. Zero disables the cache, and queries are then
+// described before every execution instead.
+//
+// Parameters:
+//   - capacity: The number of statements cached per connection.
+//
+// Returns:
+//   - PostgreSQLOption: The option configuring the statement cache.
+func WithStatementCache(capacity int) PostgreSQLOption {
+	return func(p *PostgreSQL) {
+		p.statementCache = capacity
+	}
 }
 
 // NewPostgreSQL creates a new PostgreSQL repository instance by establishing a database connection.
-// It also runs necessary migrations to ensure the database schema is up-to-date.
+// It also runs necessary migrations to ensure the database schema is up-to-date, unless disabled
+// with WithAutoMigrate.
+//
+// The replica, if configured, is not required to be reachable at startup.
 //
 // Parameters:
 //   - logger: A logger for repository operations.
 //   - connString: The connection string to establish the database connection.
+//   - opts: Optional settings, e.g. WithReplica.
 //
 // Returns:
 //   - *PostgreSQL: A pointer to the initialized PostgreSQL repository.
-//   - error: An error if the database connection fails.
-func NewPostgreSQL(logger *zap.SugaredLogger, connString string) (*PostgreSQL, error) {
-	db, err := sql.Open("pgx", connString)
+//   - error: An error if the database connection fails, unless connecting lazily.
+func NewPostgreSQL(logger *zap.SugaredLogger, connString string, opts ...PostgreSQLOption) (*PostgreSQL, error) {
+	psql := &PostgreSQL{
+		dsn:               connString,
+		logger:            logger,
+		replicaRetryDelay: defaultReplicaRetryDelay,
+		stopCh:            make(chan struct{}),
+		healthCheckPeriod: defaultHealthCheckInterval,
+		reconnectBackoff:  defaultReconnectBackoff,
+		maxIdleConns:      defaultMaxIdleConns,
+		statementCache:    defaultStatementCache,
+	}
+	for _, opt := range opts {
+		opt(psql)
+	}
+
+	var err error
+	psql.db, err = psql.open(connString)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	if psql.replicaDSN != "" {
+		psql.replica, err = psql.open(psql.replicaDSN)
+		if err != nil {
+			_ = psql.db.Close()
+			return nil, fmt.Errorf("failed to open replica database: %w", err)
+		}
+	}
 
-	psql := PostgreSQL{
-		db:     db,
-		dsn:    connString,
-		logger: logger,
+	built := true
+	if err = psql.build(context.Background()); err != nil {
+		if !psql.lazyConnect {
+			psql.close()
+			return nil, fmt.Errorf("failed to build PostgreSQL repository: %w", err)
+		}
+		logger.Warnf("Database is not ready, retrying in the background: %v", err)
+		built = false
 	}
-	return psql.mustBuild(), nil
+	psql.healthy.Store(built)
+	go psql.supervise(!built)
+	return psql, nil
+}
+
+// open opens a connection pool configured with the pool and statement cache settings of the repository.
+//
+// Parameters:
+//   - dsn: The connection string.
+//
+// Returns:
+//   - *sql.DB: The connection pool.
+//   - error: An error if the connection string is invalid.
+func (p *PostgreSQL) open(dsn string) (*sql.DB, error) {
+	cfg, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse connection string: %w", err)
+	}
+	cfg.StatementCacheCapacity = p.statementCache
+	if p.statementCache <= 0 {
+		// The default mode needs the statement cache, so without it queries are described instead.
+		cfg.StatementCacheCapacity = 0
+		cfg.DefaultQueryExecMode = pgx.QueryExecModeDescribeExec
+	}
+
+	db := stdlib.OpenDB(*cfg)
+	db.SetMaxOpenConns(p.maxOpenConns)
+	db.SetMaxIdleConns(p.maxIdleConns)
+	db.SetConnMaxLifetime(p.connMaxLifetime)
+	return db, nil
 }
 
 // Update inserts a new metric into the database or updates it if it already exists.
-// The metric value is serialized into JSON format before storage.
+// The metric value is serialized into JSON format before storage, and the row is written
+// straight into the partition holding the metric name with a statement prepared once per partition.
 //
 // Parameters:
 //   - ctx: The context for the operation.
@@ -77,18 +300,24 @@ func (p *PostgreSQL) Update(ctx context.Context, metric *entity.Metric) error {
 		return errors.New("metric should be non-nil, but got nil")
 	}
 
-	query := `
-		INSERT INTO public.metrics (m_type, m_name, m_value)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (m_type, m_name)
-		DO UPDATE SET m_value = EXCLUDED.m_value;
-	`
-
 	mValue, err := json.Marshal(metric.Value)
 	if err != nil {
 		return fmt.Errorf("failed to marshal metric value: %w", err)
 	}
-	_, err = p.db.ExecContext(ctx, query, metric.Type, metric.Name, mValue)
+
+	shard := metricShard(metric.Name)
+	stmt, err := p.stmts.get(ctx, p.db, fmt.Sprintf(upsertQueryFmt, partitionTable(shard)))
+	if err != nil {
+		return fmt.Errorf(QueryErrFmt, ErrQueryExecuteFailed, err)
+	}
+	_, err = stmt.ExecContext(
+		ctx,
+		shard,
+		metric.Type,
+		metric.Name,
+		mValue,
+		nullTime(metric.Timestamp),
+	)
 	if err != nil {
 		return fmt.Errorf(QueryErrFmt, ErrQueryExecuteFailed, err)
 	}
@@ -97,25 +326,29 @@ func (p *PostgreSQL) Update(ctx context.Context, metric *entity.Metric) error {
 }
 
 // UpdateBatch inserts or updates a batch of metrics in the database using a transaction.
-// Each metric is serialized to JSON format prior to execution.
+// Metrics are written with one multi-row upsert per partition and up to batchUpsertRows rows, rather than
+// one statement per metric, so large batches take a few round trips. Rows are written in partition order,
+// so concurrent batches lock rows in the same order; of several updates of a metric, the last one wins.
 //
 // Parameters:
 //   - ctx: The context for the operation.
-//   - metrics: A pointer to the collection of Metrics to store.
+//   - metrics: A pointer to a collection of metrics to be updated.
 //
 // Returns:
-//   - error: An error if the operation fails.
+//   - error: An error if the batch update fails.
 func (p *PostgreSQL) UpdateBatch(ctx context.Context, metrics *entity.Metrics) error {
 	if metrics == nil {
 		return errors.New("metrics should be non-nil, but got nil")
 	}
 
-	query := `
-		INSERT INTO public.metrics (m_type, m_name, m_value)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (m_type, m_name)
-		DO UPDATE SET m_value = EXCLUDED.m_value;
-	`
+	for _, m := range *metrics {
+		if m == nil {
+			return errors.New("metric should be non-nil, but got nil")
+		}
+	}
+	ordered := slices.Clone(*metrics)
+	slices.SortStableFunc(ordered, compareByPartition)
+	ordered = latestUpdates(ordered)
 
 	tx, err := p.db.Begin()
 	if err != nil {
@@ -127,31 +360,64 @@ func (p *PostgreSQL) UpdateBatch(ctx context.Context, metrics *entity.Metrics) e
 		}
 	}()
 
-	for _, m := range *metrics {
-		if m == nil {
-			return errors.New("metric should be non-nil, but got nil")
+	for start := 0; start < len(ordered); {
+		shard := metricShard(ordered[start].Name)
+		end := start + 1
+		for end < len(ordered) && end-start < batchUpsertRows && metricShard(ordered[end].Name) == shard {
+			end++
 		}
+		if err = upsertRows(ctx, tx, shard, ordered[start:end]); err != nil {
+			return err
+		}
+		start = end
+	}
 
-		var mValue []byte
-		mValue, err = json.Marshal(m.Value)
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed at commit transaction: %w", err)
+	}
+	return nil
+}
+
+// upsertRows writes metrics of one partition with a single multi-row upsert.
+func upsertRows(ctx context.Context, tx *sql.Tx, shard int16, metrics entity.Metrics) error {
+	var values strings.Builder
+	args := make([]any, 0, len(metrics)*upsertColumns)
+	for i, m := range metrics {
+		mValue, err := json.Marshal(m.Value)
 		if err != nil {
 			return fmt.Errorf("failed to marshal metric value: %w", err)
 		}
 
-		_, err = tx.ExecContext(ctx, query, m.Type, m.Name, mValue)
-		if err != nil {
-			return fmt.Errorf(QueryErrFmt, ErrQueryExecuteFailed, err)
+		if i > 0 {
+			values.WriteString(", ")
 		}
+		n := i * upsertColumns
+		fmt.Fprintf(&values, "($%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5) //nolint:mnd // Column numbers.
+		args = append(args, shard, m.Type, m.Name, mValue, nullTime(m.Timestamp))
 	}
 
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed at commit transaction: %w", err)
+	query := fmt.Sprintf(batchUpsertQueryFmt, partitionTable(shard), values.String())
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf(QueryErrFmt, ErrQueryExecuteFailed, err)
 	}
 	return nil
 }
 
+// latestUpdates keeps the last of adjacent updates of the same metric in metrics sorted with
+// compareByPartition, as a multi-row upsert cannot update a row twice.
+func latestUpdates(metrics entity.Metrics) entity.Metrics {
+	latest := metrics[:0]
+	for i, m := range metrics {
+		if i+1 < len(metrics) && compareByPartition(m, metrics[i+1]) == 0 {
+			continue
+		}
+		latest = append(latest, m)
+	}
+	return latest
+}
+
 // Find retrieves a metric from the database based on its type and name.
-// The stored JSON value is unmarshaled into the Metric's Value field.
+// The stored JSON value is unmarshaled into the Metric's Value field. The query is prepared once per connection pool.
 //
 // Parameters:
 //   - ctx: The context for the operation.
@@ -162,17 +428,33 @@ func (p *PostgreSQL) UpdateBatch(ctx context.Context, metrics *entity.Metrics) e
 //   - *entity.Metric: A pointer to the retrieved Metric.
 //   - error: An error if the metric is not found or retrieval fails.
 func (p *PostgreSQL) Find(ctx context.Context, metricType string, metricName string) (*entity.Metric, error) {
-	query := `
-		SELECT m_name, m_type, m_value 
-		FROM metrics
-		WHERE m_type = $1
-		  AND m_name = $2;
-	`
+	var m *entity.Metric
+	err := p.read(ctx, func(db *sql.DB) error {
+		var err error
+		m, err = p.find(ctx, db, metricType, metricName)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// find retrieves a live metric using the given connection.
+func (p *PostgreSQL) find(ctx context.Context, db *sql.DB, metricType, metricName string) (*entity.Metric, error) {
+	stmt, err := p.stmts.get(ctx, db, findQuery)
+	if err != nil {
+		return nil, fmt.Errorf(QueryErrFmt, ErrQueryExecuteFailed, err)
+	}
 
 	m := entity.Metric{}
-	var rawValue []byte
+	var (
+		rawValue  []byte
+		timestamp sql.NullTime
+	)
 
-	err := p.db.QueryRowContext(ctx, query, metricType, metricName).Scan(&m.Name, &m.Type, &rawValue)
+	err = stmt.QueryRowContext(ctx, metricType, metricName, metricShard(metricName)).
+		Scan(&m.Name, &m.Type, &rawValue, &timestamp)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("%w: type=%s, name=%s", ErrNotFoundInRepo, metricType, metricName)
@@ -184,6 +466,7 @@ func (p *PostgreSQL) Find(ctx context.Context, metricType string, metricName str
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode JSON value: %w", err)
 	}
+	m.Timestamp = timestamp.Time
 
 	return &m, nil
 }
@@ -198,10 +481,102 @@ func (p *PostgreSQL) Find(ctx context.Context, metricType string, metricName str
 //   - *entity.Metrics: A pointer to the collection of all metrics.
 //   - error: An error if the retrieval fails.
 func (p *PostgreSQL) All(ctx context.Context) (*entity.Metrics, error) {
-	metrics := make(entity.Metrics, 0)
-	query := `SELECT m_name, m_type, m_value FROM metrics;`
+	var metrics *entity.Metrics
+	err := p.read(ctx, func(db *sql.DB) error {
+		var err error
+		metrics, err = p.all(ctx, db)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// all retrieves all live metrics using the given connection.
+func (p *PostgreSQL) all(ctx context.Context, db *sql.DB) (*entity.Metrics, error) {
+	return queryMetrics(ctx, db, `SELECT m_name, m_type, m_value, m_ts FROM metrics WHERE deleted_at IS NULL;`)
+}
+
+// Page retrieves one page of the live metrics, sorted and cut out by the database, so only the metrics
+// of the page are transferred. Reads go to the replica if one is configured.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - query: The offset, the limit and the order of the page.
+//
+// Returns:
+//   - *Page: The metrics of the page and the total number of metrics.
+//   - error: ErrInvalidPageQuery if the query is invalid, or an error if the retrieval fails.
+func (p *PostgreSQL) Page(ctx context.Context, query PageQuery) (*Page, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	var page *Page
+	err := p.read(ctx, func(db *sql.DB) error {
+		var err error
+		page, err = p.page(ctx, db, query)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
 
-	rows, err := p.db.QueryContext(ctx, query)
+// page retrieves one page of the live metrics using the given connection.
+func (p *PostgreSQL) page(ctx context.Context, db *sql.DB, query PageQuery) (*Page, error) {
+	page := &Page{}
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM metrics WHERE deleted_at IS NULL;`).Scan(&page.Total)
+	if err != nil {
+		return nil, fmt.Errorf(QueryErrFmt, ErrQueryExecuteFailed, err)
+	}
+
+	// The C collation compares bytes like PageOf, so pages match the other storages whatever the database locale.
+	orderBy := `m_name COLLATE "C", m_type COLLATE "C"`
+	if query.SortBy == SortByType {
+		orderBy = `m_type COLLATE "C", m_name COLLATE "C"`
+	}
+	// A NULL limit is LIMIT ALL.
+	limit := sql.NullInt64{Int64: int64(query.Limit), Valid: query.Limit > 0}
+	metrics, err := queryMetrics(ctx, db, fmt.Sprintf(pageQueryFmt, orderBy), limit, query.Offset)
+	if err != nil {
+		return nil, err
+	}
+	page.Metrics = *metrics
+	return page, nil
+}
+
+// Query retrieves the live metrics matching a filter. The type and the name prefix are matched by the
+// database; the name expression is matched and the metrics are sorted afterwards, because the regular
+// expressions and the collations of PostgreSQL and Go differ in details and the filter must behave the
+// same on every backend. Reads go to the replica if one is configured.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - filter: The type, the name prefix and the name expression the metrics must match.
+//
+// Returns:
+//   - *entity.Metrics: The matching metrics, sorted by name and then by type.
+//   - error: An error if the retrieval fails.
+func (p *PostgreSQL) Query(ctx context.Context, filter MetricFilter) (*entity.Metrics, error) {
+	var metrics *entity.Metrics
+	err := p.read(ctx, func(db *sql.DB) error {
+		var err error
+		metrics, err = queryMetrics(ctx, db, filterQuery, filter.Type, filter.NamePrefix)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	matched := Filter(*metrics, filter)
+	return &matched, nil
+}
+
+// queryMetrics runs a query selecting m_name, m_type, m_value and m_ts and decodes the rows into metrics.
+func queryMetrics(ctx context.Context, db *sql.DB, query string, args ...any) (*entity.Metrics, error) {
+	metrics := make(entity.Metrics, 0)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf(QueryErrFmt, ErrQueryExecuteFailed, err)
 	}
@@ -213,9 +588,12 @@ func (p *PostgreSQL) All(ctx context.Context) (*entity.Metrics, error) {
 
 	for rows.Next() {
 		m := entity.Metric{}
-		var rawValue []byte
+		var (
+			rawValue  []byte
+			timestamp sql.NullTime
+		)
 
-		err = rows.Scan(&m.Name, &m.Type, &rawValue)
+		err = rows.Scan(&m.Name, &m.Type, &rawValue, &timestamp)
 		if err != nil {
 			return nil, fmt.Errorf("failed to process database response: %w", err)
 		}
@@ -224,6 +602,7 @@ func (p *PostgreSQL) All(ctx context.Context) (*entity.Metrics, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to decode JSON value: %w", err)
 		}
+		m.Timestamp = timestamp.Time
 
 		metrics = append(metrics, &m)
 	}
@@ -235,6 +614,217 @@ func (p *PostgreSQL) All(ctx context.Context) (*entity.Metrics, error) {
 	return &metrics, nil
 }
 
+// Delete soft-deletes a metric by stamping its deleted_at column.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - metricType: The type of the metric.
+//   - metricName: The name of the metric.
+//
+// Returns:
+//   - error: ErrNotFoundInRepo if there is no live metric, or an error if the query fails.
+func (p *PostgreSQL) Delete(ctx context.Context, metricType string, metricName string) error {
+	query := `
+		UPDATE metrics
+		SET deleted_at = now()
+		WHERE m_shard = $3
+		  AND m_type = $1
+		  AND m_name = $2
+		  AND deleted_at IS NULL;
+	`
+
+	return p.execAffectingOne(ctx, query, metricType, metricName)
+}
+
+// Undelete restores a soft-deleted metric by clearing its deleted_at column.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - metricType: The type of the metric.
+//   - metricName: The name of the metric.
+//
+// Returns:
+//   - error: ErrNotFoundInRepo if there is no tombstone, or an error if the query fails.
+func (p *PostgreSQL) Undelete(ctx context.Context, metricType string, metricName string) error {
+	query := `
+		UPDATE metrics
+		SET deleted_at = NULL
+		WHERE m_shard = $3
+		  AND m_type = $1
+		  AND m_name = $2
+		  AND deleted_at IS NOT NULL;
+	`
+
+	return p.execAffectingOne(ctx, query, metricType, metricName)
+}
+
+// Purge permanently removes metrics soft-deleted before the given moment.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - deletedBefore: Tombstones older than this moment are removed.
+//
+// Returns:
+//   - int: The number of purged metrics.
+//   - error: An error if the query fails.
+func (p *PostgreSQL) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	query := `DELETE FROM metrics WHERE deleted_at IS NOT NULL AND deleted_at < $1;`
+
+	res, err := p.db.ExecContext(ctx, query, deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf(QueryErrFmt, ErrQueryExecuteFailed, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return int(affected), nil
+}
+
+// Meta retrieves the annotations of a metric.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - metricType: The type of the metric.
+//   - metricName: The name of the metric.
+//
+// Returns:
+//   - *entity.Meta: The annotations of the metric.
+//   - error: ErrNotFoundInRepo if the metric has no annotations, or an error if the query fails.
+func (p *PostgreSQL) Meta(ctx context.Context, metricType string, metricName string) (*entity.Meta, error) {
+	query := `SELECT meta FROM metric_meta WHERE m_type = $1 AND m_name = $2;`
+
+	var meta *entity.Meta
+	err := p.read(ctx, func(db *sql.DB) error {
+		var raw []byte
+		if err := db.QueryRowContext(ctx, query, metricType, metricName).Scan(&raw); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: annotations of type=%s, name=%s", ErrNotFoundInRepo, metricType, metricName)
+			}
+			return fmt.Errorf(QueryErrFmt, ErrQueryExecuteFailed, err)
+		}
+		meta = &entity.Meta{}
+		if err := json.Unmarshal(raw, meta); err != nil {
+			return fmt.Errorf("failed to decode JSON annotations: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// SetMeta replaces the annotations of a live metric. Empty annotations remove them.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - metricType: The type of the metric.
+//   - metricName: The name of the metric.
+//   - meta: The new annotations.
+//
+// Returns:
+//   - error: ErrNotFoundInRepo if there is no live metric, or an error if the query fails.
+func (p *PostgreSQL) SetMeta(ctx context.Context, metricType string, metricName string, meta *entity.Meta) error {
+	if _, err := p.find(ctx, p.db, metricType, metricName); err != nil {
+		return err
+	}
+
+	if meta.IsEmpty() {
+		query := `DELETE FROM metric_meta WHERE m_type = $1 AND m_name = $2;`
+		if _, err := p.db.ExecContext(ctx, query, metricType, metricName); err != nil {
+			return fmt.Errorf(QueryErrFmt, ErrQueryExecuteFailed, err)
+		}
+		return nil
+	}
+
+	raw, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON annotations: %w", err)
+	}
+	query := `
+		INSERT INTO metric_meta (m_type, m_name, meta)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (m_type, m_name)
+		DO UPDATE SET meta = EXCLUDED.meta;
+	`
+	if _, err = p.db.ExecContext(ctx, query, metricType, metricName, raw); err != nil {
+		return fmt.Errorf(QueryErrFmt, ErrQueryExecuteFailed, err)
+	}
+	return nil
+}
+
+// execAffectingOne runs a type/name scoped statement and reports ErrNotFoundInRepo when no rows were changed.
+// The statement receives the type, the name and the shard of the metric as $1, $2 and $3.
+func (p *PostgreSQL) execAffectingOne(ctx context.Context, query string, metricType, metricName string) error {
+	res, err := p.db.ExecContext(ctx, query, metricType, metricName, metricShard(metricName))
+	if err != nil {
+		return fmt.Errorf(QueryErrFmt, ErrQueryExecuteFailed, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: type=%s, name=%s", ErrNotFoundInRepo, metricType, metricName)
+	}
+	return nil
+}
+
+// read runs a read query on the replica, falling back to the primary if the replica is not configured,
+// was recently down or fails now. A missing metric is a valid answer and is not retried on the primary.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - query: The read to run against the chosen connection.
+//
+// Returns:
+//   - error: The error of the last connection tried.
+func (p *PostgreSQL) read(ctx context.Context, query func(db *sql.DB) error) error {
+	if p.replica == nil || time.Now().UnixNano() < p.replicaDownUntil.Load() {
+		return query(p.db)
+	}
+
+	err := query(p.replica)
+	if err == nil || errors.Is(err, ErrNotFoundInRepo) || ctx.Err() != nil {
+		return err
+	}
+
+	p.replicaDownUntil.Store(time.Now().Add(p.replicaRetryDelay).UnixNano())
+	p.replicaFallbacks.Add(1)
+	p.logger.Warnf("Replica read failed, falling back to the primary for %s: %v", p.replicaRetryDelay, err)
+	return query(p.db)
+}
+
+// metricShard returns the partition holding a metric name: the 32-bit FNV-1a hash of the name modulo
+// metricPartitions. The 00004_partition_metrics migration uses the same hash to move existing rows.
+func metricShard(name string) int16 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return int16(h.Sum32() % metricPartitions)
+}
+
+// partitionTable returns the name of the metrics table partition for a shard.
+func partitionTable(shard int16) string {
+	return fmt.Sprintf("metrics_p%02d", shard)
+}
+
+// compareByPartition orders metrics by partition, then by type and name.
+func compareByPartition(a, b *entity.Metric) int {
+	return cmp.Or(
+		cmp.Compare(metricShard(a.Name), metricShard(b.Name)),
+		cmp.Compare(a.Type, b.Type),
+		cmp.Compare(a.Name, b.Name),
+	)
+}
+
+// nullTime converts a metric timestamp to a nullable column value; the zero time is stored as NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
 // CheckConnection verifies if the database connection is alive by pinging the database.
 //
 // Parameters:
@@ -277,45 +867,144 @@ func (p *PostgreSQL) CheckConnectionWithRetry(
 	return nil
 }
 
-// Shutdown gracefully shuts down the repository by closing the database connection.
+// Ready reports whether the database was reachable at the last connection check.
+// It is cheap enough to be called on every readiness probe.
+//
+// Returns:
+//   - bool: True if the database is healthy.
+func (p *PostgreSQL) Ready() bool {
+	return p.healthy.Load()
+}
+
+// Shutdown gracefully shuts down the repository by stopping the connection supervisor
+// and closing the database connection.
 func (p *PostgreSQL) Shutdown() {
+	if p.stopCh != nil {
+		close(p.stopCh)
+	}
 	p.close()
 }
 
-// close closes the database connection if it is not already closed.
+// close closes the database connections if they are not already closed.
 func (p *PostgreSQL) close() {
+	p.stmts.close()
 	if p.db != nil {
 		_ = p.db.Close()
 	}
+	if p.replica != nil {
+		_ = p.replica.Close()
+	}
 }
 
-// mustBuild initializes the repository by checking the connection and running migrations.
-// It panics if the connection check or migrations fail.
+// RegisterSelfMetrics exposes the connection pool statistics and, if a replica is configured,
+// the replica pool statistics and the number of replica fallbacks through the self-metric registry.
 //
-// Returns:
-//   - *PostgreSQL: A pointer to the fully initialized PostgreSQL repository.
-func (p *PostgreSQL) mustBuild() *PostgreSQL {
-	var err error
+// Parameters:
+//   - registry: The registry to register the metrics in.
+func (p *PostgreSQL) RegisterSelfMetrics(registry *selfmetric.Registry) {
+	registerPoolSelfMetrics(registry, selfMetricPoolPrefix, p.db)
+	if p.replica == nil {
+		return
+	}
+	registerPoolSelfMetrics(registry, selfMetricPoolPrefix+poolReplica+"_", p.replica)
+	registry.RegisterCounter(selfMetricReplicaFallbacks, p.replicaFallbacks.Load)
+}
 
-	err = p.CheckConnectionWithRetry(
-		context.Background(),
-		defaultAttemptsDefaultCount,
-		defaultPSQLConnectionCheckTimeout,
-	)
+// build initializes the repository by checking the connection and running migrations.
+//
+// Parameters:
+//   - ctx: The context for the connection check.
+//
+// Returns:
+//   - error: An error if the connection check or migrations fail.
+func (p *PostgreSQL) build(ctx context.Context) error {
+	err := p.CheckConnectionWithRetry(ctx, defaultAttemptsDefaultCount, defaultPSQLConnectionCheckTimeout)
 	if err != nil {
-		panic(fmt.Sprintf("failed to check connection to the repository: %v", err))
+		return fmt.Errorf("failed to check connection to the repository: %w", err)
 	}
 
-	err = p.runMigrations()
-	if err != nil {
-		panic(fmt.Sprintf("failed to execute migrations: %v", err))
+	if p.manualMigrations {
+		if err = p.checkMigrations(); err != nil {
+			return fmt.Errorf("database schema is not up to date: %w", err)
+		}
+		return nil
 	}
 
-	return p
+	if err = p.runMigrations(); err != nil {
+		return fmt.Errorf("failed to execute migrations: %w", err)
+	}
+	return nil
 }
 
-//go:embed migrations/psql/*.sql
-var migrationsDir embed.FS
+// supervise pings the database every health check period. Once a ping fails the repository is marked
+// unhealthy and reconnection is attempted with exponential backoff until a ping succeeds again.
+// If the repository was never built, reconnecting also runs the connection check and migrations.
+// It returns when the repository is shut down.
+//
+// Parameters:
+//   - needsBuild: Whether the repository failed to build on startup.
+func (p *PostgreSQL) supervise(needsBuild bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-p.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	backoff := time.Duration(0)
+	delay := p.healthCheckPeriod
+	if needsBuild {
+		backoff, delay = p.reconnectBackoff, p.reconnectBackoff
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		var err error
+		if needsBuild {
+			err = p.build(ctx)
+		} else {
+			checkCtx, cancelCheck := context.WithTimeout(ctx, defaultPSQLConnectionCheckTimeout)
+			err = p.CheckConnection(checkCtx)
+			cancelCheck()
+		}
+
+		if err != nil {
+			backoff = nextReconnectBackoff(backoff, p.reconnectBackoff)
+			if p.healthy.Swap(false) {
+				p.logger.Warnf("Database connection lost, reconnecting in %s: %v", backoff, err)
+			} else {
+				p.logger.Warnf("Database is still not ready, retrying in %s: %v", backoff, err)
+			}
+			timer.Reset(backoff)
+			continue
+		}
+
+		if !p.healthy.Swap(true) {
+			p.logger.Info("Database connection established")
+		}
+		needsBuild = false
+		backoff = 0
+		timer.Reset(p.healthCheckPeriod)
+	}
+}
+
+// nextReconnectBackoff doubles the reconnection delay, starting at initial and capped at maxReconnectBackoff.
+func nextReconnectBackoff(current, initial time.Duration) time.Duration {
+	if current <= 0 {
+		return initial
+	}
+	return min(2*current, maxReconnectBackoff)
+}
 
 // runMigrations applies database migrations using the embedded SQL files.
 // It ensures that the necessary database tables exist.
@@ -323,20 +1012,45 @@ var migrationsDir embed.FS
 // Returns:
 //   - error: An error if the migrations cannot be applied.
 func (p *PostgreSQL) runMigrations() error {
-	d, err := iofs.New(migrationsDir, "migrations/psql")
+	m, err := NewMigrator(p.dsn)
 	if err != nil {
-		return fmt.Errorf("failed to return an iofs driver: %w", err)
+		return fmt.Errorf("failed to create migrator: %w", err)
+	}
+	defer m.Close()
+
+	if err = m.Up(); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
 	}
+	return nil
+}
 
-	m, err := migrate.NewWithSourceInstance("iofs", d, p.dsn)
+// checkMigrations verifies that every embedded migration is applied and the schema is not dirty.
+//
+// Returns:
+//   - error: ErrPendingMigrations if the schema is out of date, or an error if the check fails.
+func (p *PostgreSQL) checkMigrations() error {
+	status, err := p.MigrationStatus()
 	if err != nil {
-		return fmt.Errorf("failed to get a new migrate instance: %w", err)
+		return fmt.Errorf("failed to check migrations: %w", err)
 	}
+	return status.Err()
+}
 
-	if err := m.Up(); err != nil {
-		if !errors.Is(err, migrate.ErrNoChange) {
-			return fmt.Errorf("failed to apply migrations to the DB: %w", err)
-		}
+// MigrationStatus reports the schema version of the primary database.
+//
+// Returns:
+//   - *MigrationStatus: The applied and pending migrations.
+//   - error: An error if the schema version cannot be read.
+func (p *PostgreSQL) MigrationStatus() (*MigrationStatus, error) {
+	m, err := NewMigrator(p.dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrator: %w", err)
 	}
-	return nil
+	defer m.Close()
+
+	status, err := m.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration status: %w", err)
+	}
+	return status, nil
 }
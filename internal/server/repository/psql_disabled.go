@@ -0,0 +1,73 @@
+//go:build nopostgres
+
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrPostgresDisabled is returned by the PostgreSQL constructors of binaries built with the nopostgres tag,
+// which leave out the database driver and the migrations for a smaller and faster starting memory-only build.
+var ErrPostgresDisabled = errors.New("PostgreSQL support is not compiled in, rebuild without the nopostgres tag")
+
+// PostgreSQL stands in for the PostgreSQL repository in builds without PostgreSQL support.
+type PostgreSQL struct {
+	Repository
+}
+
+// PostgreSQLOption configures optional behavior of a PostgreSQL repository.
+type PostgreSQLOption func(*PostgreSQL)
+
+// WithReplica is accepted for compatibility and does nothing in builds without PostgreSQL support.
+func WithReplica(string) PostgreSQLOption { return func(*PostgreSQL) {} }
+
+// WithAutoMigrate is accepted for compatibility and does nothing in builds without PostgreSQL support.
+func WithAutoMigrate(bool) PostgreSQLOption { return func(*PostgreSQL) {} }
+
+// WithLazyConnect is accepted for compatibility and does nothing in builds without PostgreSQL support.
+func WithLazyConnect(bool) PostgreSQLOption { return func(*PostgreSQL) {} }
+
+// WithConnPool is accepted for compatibility and does nothing in builds without PostgreSQL support.
+func WithConnPool(int, int, time.Duration) PostgreSQLOption { return func(*PostgreSQL) {} }
+
+// WithStatementCache is accepted for compatibility and does nothing in builds without PostgreSQL support.
+func WithStatementCache(int) PostgreSQLOption { return func(*PostgreSQL) {} }
+
+// NewPostgreSQL always fails in builds without PostgreSQL support.
+//
+// Returns:
+//   - *PostgreSQL: Always nil.
+//   - error: ErrPostgresDisabled.
+func NewPostgreSQL(*zap.SugaredLogger, string, ...PostgreSQLOption) (*PostgreSQL, error) {
+	return nil, ErrPostgresDisabled
+}
+
+// Shutdown does nothing in builds without PostgreSQL support.
+func (p *PostgreSQL) Shutdown() {}
+
+// Migrator stands in for the migrator in builds without PostgreSQL support.
+type Migrator struct{}
+
+// NewMigrator always fails in builds without PostgreSQL support.
+//
+// Returns:
+//   - *Migrator: Always nil.
+//   - error: ErrPostgresDisabled.
+func NewMigrator(string) (*Migrator, error) {
+	return nil, ErrPostgresDisabled
+}
+
+// Up always fails in builds without PostgreSQL support.
+func (m *Migrator) Up() error { return ErrPostgresDisabled }
+
+// Down always fails in builds without PostgreSQL support.
+func (m *Migrator) Down() error { return ErrPostgresDisabled }
+
+// Status always fails in builds without PostgreSQL support.
+func (m *Migrator) Status() (*MigrationStatus, error) { return nil, ErrPostgresDisabled }
+
+// Close does nothing in builds without PostgreSQL support.
+func (m *Migrator) Close() {}
@@ -0,0 +1,18 @@
+//go:build nopostgres
+
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPostgresDisabled(t *testing.T) {
+	_, err := NewPostgreSQL(zap.NewNop().Sugar(), "postgres://localhost/metrics", WithAutoMigrate(true))
+	require.ErrorIs(t, err, ErrPostgresDisabled)
+
+	_, err = NewMigrator("postgres://localhost/metrics")
+	require.ErrorIs(t, err, ErrPostgresDisabled)
+}
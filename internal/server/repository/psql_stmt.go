@@ -0,0 +1,65 @@
+//go:build !nopostgres
+
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// stmtKey identifies a prepared statement by its connection pool and query text.
+type stmtKey struct {
+	db    *sql.DB // db is the connection pool the statement is prepared on.
+	query string  // query is the text of the statement.
+}
+
+// stmtCache keeps the statements of the hot paths prepared, so a query is parsed and planned once per
+// connection instead of on every call. database/sql prepares a statement again on every new connection
+// of the pool it is used on. The zero value is ready to use.
+type stmtCache struct {
+	stmts map[stmtKey]*sql.Stmt // stmts holds the prepared statements.
+	mu    sync.Mutex            // mu protects stmts.
+}
+
+// get returns the statement of a query prepared on a connection pool, preparing it on first use.
+// A statement that fails to prepare is not cached, so the next call tries again.
+//
+// Parameters:
+//   - ctx: The context of the preparation.
+//   - db: The connection pool.
+//   - query: The text of the statement.
+//
+// Returns:
+//   - *sql.Stmt: The prepared statement.
+//   - error: An error if the statement cannot be prepared.
+func (c *stmtCache) get(ctx context.Context, db *sql.DB, query string) (*sql.Stmt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := stmtKey{db: db, query: query}
+	if stmt, ok := c.stmts[key]; ok {
+		return stmt, nil
+	}
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	if c.stmts == nil {
+		c.stmts = make(map[stmtKey]*sql.Stmt)
+	}
+	c.stmts[key] = stmt
+	return stmt, nil
+}
+
+// close closes every prepared statement.
+func (c *stmtCache) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, stmt := range c.stmts {
+		_ = stmt.Close()
+		delete(c.stmts, key)
+	}
+}
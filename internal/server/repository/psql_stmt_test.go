@@ -0,0 +1,60 @@
+//go:build !nopostgres
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStmtCache_PreparesOnce(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	p := newTestPostgreSQL(db)
+
+	prepared := mock.ExpectPrepare(upsertQueryPattern)
+	prepared.ExpectExec().WillReturnResult(sqlmock.NewResult(1, 1))
+	prepared.ExpectExec().WillReturnResult(sqlmock.NewResult(1, 1))
+	prepared.WillBeClosed()
+
+	for _, value := range []float64{1, 2} {
+		require.NoError(t, p.Update(context.Background(), &entity.Metric{Type: "gauge", Name: "test", Value: value}))
+	}
+	p.close()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStmtCache_FailedPrepareIsRetried(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	p := newTestPostgreSQL(db)
+
+	mock.ExpectPrepare(upsertQueryPattern).WillReturnError(errors.New("connection reset"))
+	mock.ExpectPrepare(upsertQueryPattern).ExpectExec().WillReturnResult(sqlmock.NewResult(1, 1))
+
+	metric := &entity.Metric{Type: "gauge", Name: "test", Value: 1.5}
+	require.ErrorIs(t, p.Update(context.Background(), metric), ErrQueryExecuteFailed)
+	require.NoError(t, p.Update(context.Background(), metric))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgreSQL_OpenConfiguresPool(t *testing.T) {
+	p := &PostgreSQL{maxOpenConns: 7, maxIdleConns: 3, connMaxLifetime: time.Minute}
+
+	db, err := p.open("postgres://user@localhost:5432/metrics")
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	assert.Equal(t, 7, db.Stats().MaxOpenConnections)
+
+	_, err = p.open("postgres://user@localhost:bad-port/metrics")
+	assert.Error(t, err)
+}
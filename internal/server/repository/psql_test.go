@@ -1,8 +1,11 @@
+//go:build !nopostgres
+
 package repository
 
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,6 +19,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// upsertQueryPattern matches the upsert statement into any partition of the metrics table.
+const upsertQueryPattern = `INSERT INTO public\.metrics_p\d{2} \(m_shard, m_type, m_name, m_value, m_ts\)`
+
 // newTestPostgreSQL returns a repository instance with the provided sql.DB and a no‑op logger.
 func newTestPostgreSQL(db *sql.DB) *PostgreSQL {
 	return &PostgreSQL{
@@ -59,15 +65,10 @@ func TestPostgreSQL_Update(t *testing.T) {
 				Value: 10,
 			},
 			setup: func(mock sqlmock.Sqlmock) {
-				query := regexp.QuoteMeta(`
-		INSERT INTO public.metrics (m_type, m_name, m_value)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (m_type, m_name)
-		DO UPDATE SET m_value = EXCLUDED.m_value;
-	`)
+				query := upsertQueryPattern
 				// json.Marshal(10) returns "10"
-				mock.ExpectExec(query).
-					WithArgs("counter", "test", []byte("10")).
+				mock.ExpectPrepare(query).ExpectExec().
+					WithArgs(metricShard("test"), "counter", "test", []byte("10"), nil).
 					WillReturnError(errors.New("exec error"))
 			},
 			wantErr: true,
@@ -81,15 +82,26 @@ func TestPostgreSQL_Update(t *testing.T) {
 				Value: 10,
 			},
 			setup: func(mock sqlmock.Sqlmock) {
-				query := regexp.QuoteMeta(`
-		INSERT INTO public.metrics (m_type, m_name, m_value)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (m_type, m_name)
-		DO UPDATE SET m_value = EXCLUDED.m_value;
-	`)
+				query := upsertQueryPattern
 				jsonVal, _ := json.Marshal(10)
-				mock.ExpectExec(query).
-					WithArgs("counter", "test", jsonVal).
+				mock.ExpectPrepare(query).ExpectExec().
+					WithArgs(metricShard("test"), "counter", "test", jsonVal, nil).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			wantErr: false,
+		},
+		{
+			name: "successful update with timestamp",
+			metric: &entity.Metric{
+				Type:      "gauge",
+				Name:      "test",
+				Value:     1.5,
+				Timestamp: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+			},
+			setup: func(mock sqlmock.Sqlmock) {
+				query := upsertQueryPattern
+				mock.ExpectPrepare(query).ExpectExec().
+					WithArgs(metricShard("test"), "gauge", "test", []byte("1.5"), time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 			},
 			wantErr: false,
@@ -139,11 +151,7 @@ func TestPostgreSQL_UpdateBatch(t *testing.T) {
 		{
 			name:    "contains nil metric",
 			metrics: &entity.Metrics{nil},
-			setup: func(mock sqlmock.Sqlmock) {
-				// Expect Begin and then Rollback (since the nil metric is found after Begin)
-				mock.ExpectBegin()
-				mock.ExpectRollback()
-			},
+			setup:   func(mock sqlmock.Sqlmock) {},
 			wantErr: true,
 			errMsg:  "metric should be non-nil",
 		},
@@ -177,15 +185,10 @@ func TestPostgreSQL_UpdateBatch(t *testing.T) {
 			},
 			setup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				query := regexp.QuoteMeta(`
-		INSERT INTO public.metrics (m_type, m_name, m_value)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (m_type, m_name)
-		DO UPDATE SET m_value = EXCLUDED.m_value;
-	`)
+				query := upsertQueryPattern
 				jsonVal, _ := json.Marshal(5)
 				mock.ExpectExec(query).
-					WithArgs("counter", "test", jsonVal).
+					WithArgs(metricShard("test"), "counter", "test", jsonVal, nil).
 					WillReturnError(errors.New("exec error"))
 				// Rollback is triggered by the defer.
 				mock.ExpectRollback()
@@ -200,15 +203,10 @@ func TestPostgreSQL_UpdateBatch(t *testing.T) {
 			},
 			setup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				query := regexp.QuoteMeta(`
-		INSERT INTO public.metrics (m_type, m_name, m_value)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (m_type, m_name)
-		DO UPDATE SET m_value = EXCLUDED.m_value;
-	`)
+				query := upsertQueryPattern
 				jsonVal, _ := json.Marshal(5)
 				mock.ExpectExec(query).
-					WithArgs("counter", "test", jsonVal).
+					WithArgs(metricShard("test"), "counter", "test", jsonVal, nil).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit().WillReturnError(errors.New("commit error"))
 			},
@@ -223,19 +221,31 @@ func TestPostgreSQL_UpdateBatch(t *testing.T) {
 			},
 			setup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				query := regexp.QuoteMeta(`
-		INSERT INTO public.metrics (m_type, m_name, m_value)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (m_type, m_name)
-		DO UPDATE SET m_value = EXCLUDED.m_value;
-	`)
+				query := upsertQueryPattern
 				jsonVal1, _ := json.Marshal(3)
 				jsonVal2, _ := json.Marshal(7)
-				mock.ExpectExec(query).
-					WithArgs("gauge", "test", jsonVal1).
-					WillReturnResult(sqlmock.NewResult(1, 1))
-				mock.ExpectExec(query).
-					WithArgs("counter", "test2", jsonVal2).
+				// Both names fall into the same partition, so they are written in one statement ordered by type.
+				mock.ExpectExec(query+` VALUES \(\$1, \$2, \$3, \$4, \$5\), \(\$6, \$7, \$8, \$9, \$10\) `).
+					WithArgs(
+						metricShard("test2"), "counter", "test2", jsonVal2, nil,
+						metricShard("test"), "gauge", "test", jsonVal1, nil,
+					).
+					WillReturnResult(sqlmock.NewResult(2, 2))
+				mock.ExpectCommit()
+			},
+			wantErr: false,
+		},
+		{
+			name: "repeated metric keeps the last update",
+			metrics: &entity.Metrics{
+				&entity.Metric{Type: "gauge", Name: "test", Value: 3},
+				&entity.Metric{Type: "gauge", Name: "test", Value: 4},
+			},
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				jsonVal, _ := json.Marshal(4)
+				mock.ExpectExec(upsertQueryPattern).
+					WithArgs(metricShard("test"), "gauge", "test", jsonVal, nil).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit()
 			},
@@ -268,6 +278,35 @@ func TestPostgreSQL_UpdateBatch(t *testing.T) {
 	}
 }
 
+func TestPostgreSQL_UpdateBatchPartitionOrder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	p := newTestPostgreSQL(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO public\.metrics_p05 `).
+		WithArgs(int16(5), "gauge", "test", []byte("1"), nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO public\.metrics_p06 `).
+		WithArgs(int16(6), "gauge", "Alloc", []byte("2"), nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = p.UpdateBatch(context.Background(), &entity.Metrics{
+		&entity.Metric{Type: "gauge", Name: "Alloc", Value: 2},
+		&entity.Metric{Type: "gauge", Name: "test", Value: 1},
+	})
+	if err != nil {
+		t.Errorf("UpdateBatch() unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
 func TestPostgreSQL_Find(t *testing.T) {
 	tests := []struct {
 		setup      func(mock sqlmock.Sqlmock)
@@ -284,15 +323,17 @@ func TestPostgreSQL_Find(t *testing.T) {
 			metricName: "nonexistent",
 			setup: func(mock sqlmock.Sqlmock) {
 				query := regexp.QuoteMeta(`
-		SELECT m_name, m_type, m_value 
+		SELECT m_name, m_type, m_value, m_ts
 		FROM metrics
-		WHERE m_type = $1
-		  AND m_name = $2;
+		WHERE m_shard = $3
+		  AND m_type = $1
+		  AND m_name = $2
+		  AND deleted_at IS NULL;
 	`)
 				// No rows returned.
-				rows := sqlmock.NewRows([]string{"m_name", "m_type", "m_value"})
-				mock.ExpectQuery(query).
-					WithArgs("counter", "nonexistent").
+				rows := sqlmock.NewRows([]string{"m_name", "m_type", "m_value", "m_ts"})
+				mock.ExpectPrepare(query).ExpectQuery().
+					WithArgs("counter", "nonexistent", metricShard("nonexistent")).
 					WillReturnRows(rows)
 			},
 			wantMetric: nil,
@@ -305,13 +346,15 @@ func TestPostgreSQL_Find(t *testing.T) {
 			metricName: "test",
 			setup: func(mock sqlmock.Sqlmock) {
 				query := regexp.QuoteMeta(`
-		SELECT m_name, m_type, m_value 
+		SELECT m_name, m_type, m_value, m_ts
 		FROM metrics
-		WHERE m_type = $1
-		  AND m_name = $2;
+		WHERE m_shard = $3
+		  AND m_type = $1
+		  AND m_name = $2
+		  AND deleted_at IS NULL;
 	`)
-				mock.ExpectQuery(query).
-					WithArgs("gauge", "test").
+				mock.ExpectPrepare(query).ExpectQuery().
+					WithArgs("gauge", "test", metricShard("test")).
 					WillReturnError(errors.New("query error"))
 			},
 			wantMetric: nil,
@@ -324,16 +367,18 @@ func TestPostgreSQL_Find(t *testing.T) {
 			metricName: "test",
 			setup: func(mock sqlmock.Sqlmock) {
 				query := regexp.QuoteMeta(`
-		SELECT m_name, m_type, m_value 
+		SELECT m_name, m_type, m_value, m_ts
 		FROM metrics
-		WHERE m_type = $1
-		  AND m_name = $2;
+		WHERE m_shard = $3
+		  AND m_type = $1
+		  AND m_name = $2
+		  AND deleted_at IS NULL;
 	`)
 				// Return invalid JSON in the m_value column.
-				rows := sqlmock.NewRows([]string{"m_name", "m_type", "m_value"}).
-					AddRow("test", "gauge", []byte("invalid json"))
-				mock.ExpectQuery(query).
-					WithArgs("gauge", "test").
+				rows := sqlmock.NewRows([]string{"m_name", "m_type", "m_value", "m_ts"}).
+					AddRow("test", "gauge", []byte("invalid json"), nil)
+				mock.ExpectPrepare(query).ExpectQuery().
+					WithArgs("gauge", "test", metricShard("test")).
 					WillReturnRows(rows)
 			},
 			wantMetric: nil,
@@ -346,16 +391,18 @@ func TestPostgreSQL_Find(t *testing.T) {
 			metricName: "test",
 			setup: func(mock sqlmock.Sqlmock) {
 				query := regexp.QuoteMeta(`
-		SELECT m_name, m_type, m_value 
+		SELECT m_name, m_type, m_value, m_ts
 		FROM metrics
-		WHERE m_type = $1
-		  AND m_name = $2;
+		WHERE m_shard = $3
+		  AND m_type = $1
+		  AND m_name = $2
+		  AND deleted_at IS NULL;
 	`)
 				jsonVal, _ := json.Marshal(10)
-				rows := sqlmock.NewRows([]string{"m_name", "m_type", "m_value"}).
-					AddRow("test", "counter", jsonVal)
-				mock.ExpectQuery(query).
-					WithArgs("counter", "test").
+				rows := sqlmock.NewRows([]string{"m_name", "m_type", "m_value", "m_ts"}).
+					AddRow("test", "counter", jsonVal, nil)
+				mock.ExpectPrepare(query).ExpectQuery().
+					WithArgs("counter", "test", metricShard("test")).
 					WillReturnRows(rows)
 			},
 			wantMetric: &entity.Metric{Type: "counter", Name: "test", Value: int64(10)},
@@ -406,7 +453,7 @@ func TestPostgreSQL_All(t *testing.T) {
 		{
 			name: "query error",
 			setup: func(mock sqlmock.Sqlmock) {
-				query := regexp.QuoteMeta("SELECT m_name, m_type, m_value FROM metrics;")
+				query := regexp.QuoteMeta("SELECT m_name, m_type, m_value, m_ts FROM metrics WHERE deleted_at IS NULL;")
 				mock.ExpectQuery(query).WillReturnError(errors.New("query error"))
 			},
 			wantMetrics: nil,
@@ -416,7 +463,7 @@ func TestPostgreSQL_All(t *testing.T) {
 		{
 			name: "row scan error",
 			setup: func(mock sqlmock.Sqlmock) {
-				query := regexp.QuoteMeta("SELECT m_name, m_type, m_value FROM metrics;")
+				query := regexp.QuoteMeta("SELECT m_name, m_type, m_value, m_ts FROM metrics WHERE deleted_at IS NULL;")
 				// Provide fewer columns than expected to force a scan error.
 				rows := sqlmock.NewRows([]string{"m_name", "m_type"}).
 					AddRow("test", "gauge")
@@ -429,9 +476,9 @@ func TestPostgreSQL_All(t *testing.T) {
 		{
 			name: "JSON unmarshal error",
 			setup: func(mock sqlmock.Sqlmock) {
-				query := regexp.QuoteMeta("SELECT m_name, m_type, m_value FROM metrics;")
-				rows := sqlmock.NewRows([]string{"m_name", "m_type", "m_value"}).
-					AddRow("test", "gauge", []byte("invalid json"))
+				query := regexp.QuoteMeta("SELECT m_name, m_type, m_value, m_ts FROM metrics WHERE deleted_at IS NULL;")
+				rows := sqlmock.NewRows([]string{"m_name", "m_type", "m_value", "m_ts"}).
+					AddRow("test", "gauge", []byte("invalid json"), nil)
 				mock.ExpectQuery(query).WillReturnRows(rows)
 			},
 			wantMetrics: nil,
@@ -441,12 +488,12 @@ func TestPostgreSQL_All(t *testing.T) {
 		{
 			name: "successful all",
 			setup: func(mock sqlmock.Sqlmock) {
-				query := regexp.QuoteMeta("SELECT m_name, m_type, m_value FROM metrics;")
+				query := regexp.QuoteMeta("SELECT m_name, m_type, m_value, m_ts FROM metrics WHERE deleted_at IS NULL;")
 				jsonVal1, _ := json.Marshal(5)
 				jsonVal2, _ := json.Marshal(10)
-				rows := sqlmock.NewRows([]string{"m_name", "m_type", "m_value"}).
-					AddRow("test1", "counter", jsonVal1).
-					AddRow("test2", "gauge", jsonVal2)
+				rows := sqlmock.NewRows([]string{"m_name", "m_type", "m_value", "m_ts"}).
+					AddRow("test1", "counter", jsonVal1, nil).
+					AddRow("test2", "gauge", jsonVal2, nil)
 				mock.ExpectQuery(query).WillReturnRows(rows)
 			},
 			wantMetrics: entity.Metrics{
@@ -617,3 +664,584 @@ func TestPostgreSQL_Shutdown(t *testing.T) {
 		t.Errorf("expected error after shutdown, got nil")
 	}
 }
+
+func TestPostgreSQL_Delete(t *testing.T) {
+	query := regexp.QuoteMeta(`
+		UPDATE metrics
+		SET deleted_at = now()
+		WHERE m_shard = $3
+		  AND m_type = $1
+		  AND m_name = $2
+		  AND deleted_at IS NULL;
+	`)
+
+	tests := []struct {
+		setup   func(mock sqlmock.Sqlmock)
+		name    string
+		wantErr error
+	}{
+		{
+			name: "successful delete",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(query).WithArgs("gauge", "test", metricShard("test")).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name: "metric not found",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(query).WithArgs("gauge", "test", metricShard("test")).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			wantErr: ErrNotFoundInRepo,
+		},
+		{
+			name: "exec error",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(query).WithArgs("gauge", "test", metricShard("test")).WillReturnError(errors.New("exec error"))
+			},
+			wantErr: ErrQueryExecuteFailed,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock database: %v", err)
+			}
+			defer func() { _ = db.Close() }()
+			p := newTestPostgreSQL(db)
+
+			tc.setup(mock)
+			err = p.Delete(context.Background(), "gauge", "test")
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("Delete() error = %v, want %v", err, tc.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestPostgreSQL_Purge(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	p := newTestPostgreSQL(db)
+
+	cutoff := time.Now()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM metrics WHERE deleted_at IS NOT NULL AND deleted_at < $1;`)).
+		WithArgs(cutoff).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	purged, err := p.Purge(context.Background(), cutoff)
+	if err != nil {
+		t.Fatalf("Purge() unexpected error: %v", err)
+	}
+	if purged != 3 {
+		t.Errorf("Purge() = %d, want 3", purged)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestMetricShard(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected int16
+	}{
+		{name: "", expected: 5},
+		{name: "test", expected: 5},
+		{name: "test2", expected: 5},
+		{name: "Alloc", expected: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shard := metricShard(tt.name)
+			if shard != tt.expected {
+				t.Errorf("metricShard(%q) = %d, want %d", tt.name, shard, tt.expected)
+			}
+			if got, want := partitionTable(shard), fmt.Sprintf("metrics_p%02d", tt.expected); got != want {
+				t.Errorf("partitionTable(%d) = %q, want %q", shard, got, want)
+			}
+		})
+	}
+}
+
+func TestPostgreSQL_ReplicaReads(t *testing.T) {
+	allQuery := regexp.QuoteMeta("SELECT m_name, m_type, m_value, m_ts FROM metrics WHERE deleted_at IS NULL;")
+	findQuery := regexp.QuoteMeta("SELECT m_name, m_type, m_value, m_ts")
+	tests := []struct {
+		setup         func(primary, replica sqlmock.Sqlmock)
+		read          func(p *PostgreSQL) error
+		name          string
+		wantFallbacks int64
+		wantErr       bool
+	}{
+		{
+			name: "reads go to the replica",
+			setup: func(_, replica sqlmock.Sqlmock) {
+				replica.ExpectQuery(allQuery).
+					WillReturnRows(sqlmock.NewRows([]string{"m_name", "m_type", "m_value", "m_ts"}))
+			},
+			read: func(p *PostgreSQL) error {
+				_, err := p.All(context.Background())
+				return err
+			},
+		},
+		{
+			name: "failed replica falls back to the primary",
+			setup: func(primary, replica sqlmock.Sqlmock) {
+				replica.ExpectQuery(allQuery).WillReturnError(errors.New("connection refused"))
+				primary.ExpectQuery(allQuery).
+					WillReturnRows(sqlmock.NewRows([]string{"m_name", "m_type", "m_value", "m_ts"}))
+			},
+			read: func(p *PostgreSQL) error {
+				_, err := p.All(context.Background())
+				return err
+			},
+			wantFallbacks: 1,
+		},
+		{
+			name: "missing metric is not retried on the primary",
+			setup: func(_, replica sqlmock.Sqlmock) {
+				replica.ExpectPrepare(findQuery).ExpectQuery().WillReturnError(sql.ErrNoRows)
+			},
+			read: func(p *PostgreSQL) error {
+				_, err := p.Find(context.Background(), "gauge", "test")
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "replica is skipped after a failure",
+			setup: func(primary, replica sqlmock.Sqlmock) {
+				replica.ExpectPrepare(findQuery).ExpectQuery().WillReturnError(errors.New("connection refused"))
+				primary.ExpectPrepare(findQuery).ExpectQuery().WillReturnError(sql.ErrNoRows)
+				primary.ExpectQuery(allQuery).
+					WillReturnRows(sqlmock.NewRows([]string{"m_name", "m_type", "m_value", "m_ts"}))
+			},
+			read: func(p *PostgreSQL) error {
+				_, _ = p.Find(context.Background(), "gauge", "test")
+				_, err := p.All(context.Background())
+				return err
+			},
+			wantFallbacks: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, primary, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock: %v", err)
+			}
+			defer func() { _ = db.Close() }()
+			replicaDB, replica, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock: %v", err)
+			}
+			defer func() { _ = replicaDB.Close() }()
+
+			p := newTestPostgreSQL(db)
+			p.replica = replicaDB
+			p.replicaRetryDelay = time.Minute
+
+			tc.setup(primary, replica)
+			if err := tc.read(p); (err != nil) != tc.wantErr {
+				t.Errorf("read error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got := p.replicaFallbacks.Load(); got != tc.wantFallbacks {
+				t.Errorf("replica fallbacks = %d, want %d", got, tc.wantFallbacks)
+			}
+			if err := primary.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled primary expectations: %v", err)
+			}
+			if err := replica.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled replica expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestPostgreSQL_BuildConnectionFailure(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	p := newTestPostgreSQL(db)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.build(ctx); err == nil || !strings.Contains(err.Error(), "failed to check connection") {
+		t.Errorf("build() error = %v, want connection check failure", err)
+	}
+}
+
+func TestPostgreSQL_SupervisorTracksHealth(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	p := newTestPostgreSQL(db)
+	p.stopCh = make(chan struct{})
+	p.healthCheckPeriod = 10 * time.Millisecond
+	p.reconnectBackoff = 50 * time.Millisecond
+	p.healthy.Store(true)
+
+	// The database stays down for three attempts, then every ping succeeds.
+	lost := make(chan struct{})
+	for range 3 {
+		mock.ExpectPing().WillReturnError(errors.New("connection reset"))
+	}
+	for range 1000 {
+		mock.ExpectPing()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.supervise(false)
+		close(done)
+	}()
+	go func() {
+		for p.Ready() {
+			time.Sleep(time.Millisecond)
+		}
+		close(lost)
+	}()
+
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatal("a failed ping did not mark the repository unhealthy")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for !p.Ready() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !p.Ready() {
+		t.Error("a successful ping did not mark the repository healthy again")
+	}
+
+	p.Shutdown()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("the supervisor was not stopped by Shutdown")
+	}
+}
+
+func TestNextReconnectBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  time.Duration
+		expected time.Duration
+	}{
+		{name: "first attempt", current: 0, expected: time.Second},
+		{name: "doubles", current: 2 * time.Second, expected: 4 * time.Second},
+		{name: "capped", current: 20 * time.Second, expected: maxReconnectBackoff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextReconnectBackoff(tt.current, time.Second); got != tt.expected {
+				t.Errorf("nextReconnectBackoff(%s) = %s, want %s", tt.current, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPostgreSQL_Meta(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	p := newTestPostgreSQL(db)
+	query := regexp.QuoteMeta(`SELECT meta FROM metric_meta WHERE m_type = $1 AND m_name = $2;`)
+
+	mock.ExpectQuery(query).
+		WithArgs("gauge", "HeapAlloc").
+		WillReturnRows(sqlmock.NewRows([]string{"meta"}).AddRow([]byte(`{"owner":"platform","notes":"heap"}`)))
+	meta, err := p.Meta(context.Background(), "gauge", "HeapAlloc")
+	if err != nil {
+		t.Fatalf("Meta() error = %v", err)
+	}
+	if meta.Owner != "platform" || meta.Notes != "heap" {
+		t.Errorf("Meta() got = %+v", meta)
+	}
+
+	mock.ExpectQuery(query).WithArgs("gauge", "Alloc").WillReturnRows(sqlmock.NewRows([]string{"meta"}))
+	if _, err = p.Meta(context.Background(), "gauge", "Alloc"); !errors.Is(err, ErrNotFoundInRepo) {
+		t.Errorf("Meta() error = %v, want ErrNotFoundInRepo", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestPostgreSQL_SetMeta(t *testing.T) {
+	findQuery := `SELECT m_name, m_type, m_value, m_ts\s+FROM metrics`
+	tests := []struct {
+		setup   func(mock sqlmock.Sqlmock)
+		meta    *entity.Meta
+		name    string
+		wantErr error
+	}{
+		{
+			name: "Upsert",
+			meta: &entity.Meta{Owner: "platform"},
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPrepare(findQuery).ExpectQuery().
+					WithArgs("gauge", "HeapAlloc", metricShard("HeapAlloc")).
+					WillReturnRows(sqlmock.NewRows([]string{"m_name", "m_type", "m_value", "m_ts"}).
+						AddRow("HeapAlloc", "gauge", []byte(`1.5`), nil))
+				mock.ExpectExec(`INSERT INTO metric_meta \(m_type, m_name, meta\)`).
+					WithArgs("gauge", "HeapAlloc", sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+		{
+			name: "Remove empty",
+			meta: &entity.Meta{},
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPrepare(findQuery).ExpectQuery().
+					WithArgs("gauge", "HeapAlloc", metricShard("HeapAlloc")).
+					WillReturnRows(sqlmock.NewRows([]string{"m_name", "m_type", "m_value", "m_ts"}).
+						AddRow("HeapAlloc", "gauge", []byte(`1.5`), nil))
+				mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM metric_meta WHERE m_type = $1 AND m_name = $2;`)).
+					WithArgs("gauge", "HeapAlloc").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:    "Missing metric",
+			meta:    &entity.Meta{Owner: "platform"},
+			wantErr: ErrNotFoundInRepo,
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPrepare(findQuery).ExpectQuery().
+					WithArgs("gauge", "HeapAlloc", metricShard("HeapAlloc")).
+					WillReturnRows(sqlmock.NewRows([]string{"m_name", "m_type", "m_value", "m_ts"}))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to open sqlmock: %v", err)
+			}
+			defer func() { _ = db.Close() }()
+			p := newTestPostgreSQL(db)
+
+			tc.setup(mock)
+			err = p.SetMeta(context.Background(), "gauge", "HeapAlloc", tc.meta)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("SetMeta() error = %v, want %v", err, tc.wantErr)
+			}
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestPostgreSQL_UpdateBatchChunks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	p := newTestPostgreSQL(db)
+
+	// Names of a single partition, more than fit into one statement.
+	var metrics entity.Metrics
+	for i := 0; len(metrics) < batchUpsertRows+1; i++ {
+		name := fmt.Sprintf("m%d", i)
+		if metricShard(name) == 0 {
+			metrics = append(metrics, &entity.Metric{Type: "gauge", Name: name, Value: 1})
+		}
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO public\.metrics_p00 `).WillReturnResult(sqlmock.NewResult(0, batchUpsertRows))
+	mock.ExpectExec(`INSERT INTO public\.metrics_p00 .* VALUES \(\$1, \$2, \$3, \$4, \$5\) `).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err = p.UpdateBatch(context.Background(), &metrics); err != nil {
+		t.Errorf("UpdateBatch() unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+// roundTripDriver is a database driver answering every statement after a simulated network round trip,
+// so benchmarks show the cost of the round trips a write takes rather than the cost of the database.
+type roundTripDriver struct {
+	rtt time.Duration // rtt is the simulated round trip time.
+}
+
+func (d *roundTripDriver) Open(string) (driver.Conn, error) { return d, nil }
+
+func (d *roundTripDriver) Connect(context.Context) (driver.Conn, error) { return d, nil }
+
+func (d *roundTripDriver) Driver() driver.Driver { return d }
+
+func (d *roundTripDriver) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (d *roundTripDriver) Close() error { return nil }
+
+func (d *roundTripDriver) Begin() (driver.Tx, error) {
+	d.roundTrip()
+	return d, nil
+}
+
+func (d *roundTripDriver) Commit() error {
+	d.roundTrip()
+	return nil
+}
+
+func (d *roundTripDriver) Rollback() error { return nil }
+
+func (d *roundTripDriver) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	d.roundTrip()
+	return driver.RowsAffected(1), nil
+}
+
+// roundTrip waits for the round trip time; it spins, as sleeps this short overshoot on most platforms.
+func (d *roundTripDriver) roundTrip() {
+	for start := time.Now(); time.Since(start) < d.rtt; { //nolint:revive // Busy wait.
+	}
+}
+
+// updateBatchPerRow writes metrics with one upsert per metric, as UpdateBatch did before multi-row upserts;
+// it is the baseline of BenchmarkPostgreSQL_UpdateBatch.
+func updateBatchPerRow(ctx context.Context, db *sql.DB, metrics entity.Metrics) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed at begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, m := range metrics {
+		mValue, err := json.Marshal(m.Value)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metric value: %w", err)
+		}
+		shard := metricShard(m.Name)
+		_, err = tx.ExecContext(
+			ctx,
+			fmt.Sprintf(upsertQueryFmt, partitionTable(shard)),
+			shard, m.Type, m.Name, mValue, nullTime(m.Timestamp),
+		)
+		if err != nil {
+			return fmt.Errorf(QueryErrFmt, ErrQueryExecuteFailed, err)
+		}
+	}
+	return tx.Commit() //nolint:wrapcheck // Benchmark baseline.
+}
+
+// BenchmarkPostgreSQL_UpdateBatch compares multi-row upserts with one upsert per metric
+// over a connection with a 100µs round trip, as to a database on the local network.
+func BenchmarkPostgreSQL_UpdateBatch(b *testing.B) {
+	db := sql.OpenDB(&roundTripDriver{rtt: 100 * time.Microsecond})
+	defer func() { _ = db.Close() }()
+	p := newTestPostgreSQL(db)
+
+	for _, size := range []int{100, 1000, 10000} {
+		metrics := make(entity.Metrics, size)
+		for i := range metrics {
+			metrics[i] = &entity.Metric{Type: entity.MetricTypeGauge, Name: fmt.Sprintf("metric_%d", i), Value: float64(i)}
+		}
+
+		b.Run(fmt.Sprintf("multi-row/%d", size), func(b *testing.B) {
+			for range b.N {
+				if err := p.UpdateBatch(context.Background(), &metrics); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("per-row/%d", size), func(b *testing.B) {
+			for range b.N {
+				if err := updateBatchPerRow(context.Background(), db, metrics); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestPostgreSQL_Page(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	p := newTestPostgreSQL(db)
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM metrics`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(`ORDER BY m_type COLLATE "C", m_name COLLATE "C"\s+LIMIT \$1 OFFSET \$2`).
+		WithArgs(sql.NullInt64{Int64: 2, Valid: true}, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"m_name", "m_type", "m_value", "m_ts"}).
+			AddRow("b", "gauge", []byte("1.5"), nil))
+
+	page, err := p.Page(context.Background(), PageQuery{SortBy: SortByType, Offset: 1, Limit: 2})
+	if err != nil {
+		t.Fatalf("Page() error = %v", err)
+	}
+	if page.Total != 3 || len(page.Metrics) != 1 || page.Metrics[0].Name != "b" {
+		t.Errorf("Page() = %d metrics of %d, want metric b of 3", len(page.Metrics), page.Total)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+
+	if _, err = p.Page(context.Background(), PageQuery{Offset: -1}); !errors.Is(err, ErrInvalidPageQuery) {
+		t.Errorf("Page() error = %v, want %v", err, ErrInvalidPageQuery)
+	}
+}
+
+func TestPostgreSQL_Query(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	p := newTestPostgreSQL(db)
+
+	rows := sqlmock.NewRows([]string{"m_name", "m_type", "m_value", "m_ts"}).
+		AddRow("heap_sys", "gauge", []byte("2.5"), nil).
+		AddRow("heap_alloc", "gauge", []byte("1.5"), nil)
+	mock.ExpectQuery(`m_type = \$1\) AND starts_with\(m_name, \$2\)`).
+		WithArgs("gauge", "heap_").
+		WillReturnRows(rows)
+
+	filter := MetricFilter{Type: "gauge", NamePrefix: "heap_", Name: regexp.MustCompile(`alloc|sys`)}
+	metrics, err := p.Query(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(*metrics) != 2 || (*metrics)[0].Name != "heap_alloc" || (*metrics)[1].Name != "heap_sys" {
+		t.Errorf("Query() = %v, want heap_alloc and heap_sys in order", *metrics)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+
+	mock.ExpectQuery(`starts_with`).WillReturnError(errors.New("connection reset"))
+	if _, err = p.Query(context.Background(), MetricFilter{}); !errors.Is(err, ErrQueryExecuteFailed) {
+		t.Errorf("Query() error = %v, want %v", err, ErrQueryExecuteFailed)
+	}
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The types below describe the PostgreSQL repository to the admin and debug handlers.
+// They are kept apart from it, so they exist in builds without PostgreSQL support too.
+
+// ErrPendingMigrations is returned when automatic migration is disabled and the schema is out of date.
+var ErrPendingMigrations = errors.New("pending migrations")
+
+// MigrationStatus describes the schema version of a PostgreSQL database.
+type MigrationStatus struct {
+	Applied []uint `json:"applied"` // Applied lists the embedded migrations applied to the database.
+	Pending []uint `json:"pending"` // Pending lists the embedded migrations not applied yet.
+	Version uint   `json:"version"` // Version is the current schema version, 0 if no migration was applied.
+	Dirty   bool   `json:"dirty"`   // Dirty reports that the last migration failed and needs manual repair.
+}
+
+// Err reports whether the schema is ready to be used without applying migrations.
+//
+// Returns:
+//   - error: ErrPendingMigrations if migrations are pending or the last one failed, nil otherwise.
+func (s *MigrationStatus) Err() error {
+	if s.Dirty {
+		return fmt.Errorf("%w: migration %d failed and must be repaired manually", ErrPendingMigrations, s.Version)
+	}
+	if len(s.Pending) > 0 {
+		return fmt.Errorf("%w: %v, apply them with -migrate-up", ErrPendingMigrations, s.Pending)
+	}
+	return nil
+}
+
+// PoolStats is a snapshot of the statistics of a database connection pool.
+type PoolStats struct {
+	MaxOpenConnections  int     `json:"max_open_connections"`  // Maximum number of open connections, 0 if unlimited.
+	OpenConnections     int     `json:"open_connections"`      // Number of established connections, in use or idle.
+	InUse               int     `json:"in_use"`                // Number of connections currently in use.
+	Idle                int     `json:"idle"`                  // Number of idle connections.
+	WaitCount           int64   `json:"wait_count"`            // Total number of connections waited for.
+	WaitDurationSeconds float64 `json:"wait_duration_seconds"` // Total time blocked waiting for a connection.
+	MaxIdleClosed       int64   `json:"max_idle_closed"`       // Connections closed due to the idle connection limit.
+	MaxIdleTimeClosed   int64   `json:"max_idle_time_closed"`  // Connections closed due to the idle time limit.
+	MaxLifetimeClosed   int64   `json:"max_lifetime_closed"`   // Connections closed due to the lifetime limit.
+}
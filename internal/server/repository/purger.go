@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/gdyunin/metricol.git/pkg/clock"
+	"go.uber.org/zap"
+)
+
+// TombstonePurger periodically removes soft-deleted metrics whose retention window has expired.
+// Until a tombstone is purged, the deletion can be undone through Repository.Undelete.
+type TombstonePurger struct {
+	repo      Repository         // repo is the repository to purge.
+	clock     clock.Clock        // clock drives the purge loop and computes the retention cut-off.
+	logger    *zap.SugaredLogger // logger is used for logging purge results.
+	retention time.Duration      // retention is how long deleted metrics are kept restorable.
+	interval  time.Duration      // interval is the period between purge runs.
+}
+
+// NewTombstonePurger creates a new TombstonePurger.
+//
+// Parameters:
+//   - repo: The repository whose tombstones are purged.
+//   - retention: How long a deleted metric stays restorable.
+//   - interval: The period between purge runs.
+//   - logger: Logger for purge operations.
+//
+// Returns:
+//   - *TombstonePurger: A pointer to the created TombstonePurger.
+func NewTombstonePurger(
+	repo Repository,
+	retention time.Duration,
+	interval time.Duration,
+	logger *zap.SugaredLogger,
+) *TombstonePurger {
+	return &TombstonePurger{
+		repo:      repo,
+		clock:     clock.Real(),
+		retention: retention,
+		interval:  interval,
+		logger:    logger,
+	}
+}
+
+// Start runs the purge loop until the context is canceled.
+//
+// Parameters:
+//   - ctx: The context controlling the purge loop lifecycle.
+func (p *TombstonePurger) Start(ctx context.Context) {
+	ticker := p.clock.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.logger.Info("Context canceled: stopping tombstone purger")
+			return
+		case <-ticker.C():
+			p.purgeOnce(ctx)
+		}
+	}
+}
+
+// purgeOnce removes all tombstones older than the retention window.
+func (p *TombstonePurger) purgeOnce(ctx context.Context) {
+	purgeCtx, cancel := p.clock.WithTimeout(ctx, p.interval)
+	defer cancel()
+
+	purged, err := p.repo.Purge(purgeCtx, p.clock.Now().Add(-p.retention))
+	if err != nil {
+		p.logger.Errorf("Failed to purge deleted metrics: %v", err)
+		return
+	}
+	if purged > 0 {
+		p.logger.Infof("Purged %d deleted metrics older than %s", purged, p.retention)
+	}
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// purgeRecorder records the cut-off of every Purge call.
+type purgeRecorder struct {
+	Repository
+	cutoffs chan time.Time
+}
+
+func (r *purgeRecorder) Purge(_ context.Context, deletedBefore time.Time) (int, error) {
+	r.cutoffs <- deletedBefore
+	return 0, nil
+}
+
+func TestTombstonePurger_Start(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	repo := &purgeRecorder{Repository: NewInMemoryRepository(zap.NewNop().Sugar()), cutoffs: make(chan time.Time)}
+	purger := NewTombstonePurger(repo, time.Hour, time.Minute, zap.NewNop().Sugar())
+	purger.clock = fake
+
+	done := make(chan struct{})
+	go func() {
+		purger.Start(ctx)
+		close(done)
+	}()
+
+	for i := 1; i <= 2; i++ {
+		fake.BlockUntil(1)
+		fake.Advance(time.Minute)
+		assert.Equal(t, start.Add(time.Duration(i)*time.Minute-time.Hour), <-repo.cutoffs)
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+)
+
+// MetricFilter selects the stored metrics by type and name. The zero value matches every metric.
+type MetricFilter struct {
+	Name       *regexp.Regexp // Name matches the metric names; nil matches any name.
+	Type       string         // Type is the metric type; empty matches any type.
+	NamePrefix string         // NamePrefix starts the metric names; empty matches any name.
+}
+
+// Matches reports whether a metric passes the filter.
+//
+// Parameters:
+//   - metric: The metric.
+//
+// Returns:
+//   - bool: true if the metric matches the type, the name prefix and the name expression.
+func (f MetricFilter) Matches(metric *entity.Metric) bool {
+	if f.Type != "" && metric.Type != f.Type {
+		return false
+	}
+	if !strings.HasPrefix(metric.Name, f.NamePrefix) {
+		return false
+	}
+	return f.Name == nil || f.Name.MatchString(metric.Name)
+}
+
+// Filter returns the metrics passing the filter, sorted by name and then by type. It serves filtering for
+// storages that hold every metric in memory anyway; the metrics slice itself is not modified.
+//
+// Parameters:
+//   - metrics: All metrics.
+//   - filter: The filter.
+//
+// Returns:
+//   - entity.Metrics: The matching metrics.
+func Filter(metrics entity.Metrics, filter MetricFilter) entity.Metrics {
+	matched := make(entity.Metrics, 0)
+	for _, m := range metrics {
+		if filter.Matches(m) {
+			matched = append(matched, m)
+		}
+	}
+	slices.SortFunc(matched, metricOrder(SortByName))
+	return matched
+}
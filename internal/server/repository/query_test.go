@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestFilter(t *testing.T) {
+	metrics := entity.Metrics{
+		{Name: "heap_sys", Type: entity.MetricTypeGauge},
+		{Name: "poll_count", Type: entity.MetricTypeCounter},
+		{Name: "heap_alloc", Type: entity.MetricTypeGauge},
+		{Name: "heap_alloc", Type: entity.MetricTypeCounter},
+	}
+	tests := []struct {
+		name   string
+		filter MetricFilter
+		want   []string
+	}{
+		{
+			name:   "Zero filter",
+			filter: MetricFilter{},
+			want:   []string{"heap_alloc/counter", "heap_alloc/gauge", "heap_sys/gauge", "poll_count/counter"},
+		},
+		{
+			name:   "Type",
+			filter: MetricFilter{Type: entity.MetricTypeCounter},
+			want:   []string{"heap_alloc/counter", "poll_count/counter"},
+		},
+		{
+			name:   "Prefix and type",
+			filter: MetricFilter{Type: entity.MetricTypeGauge, NamePrefix: "heap_"},
+			want:   []string{"heap_alloc/gauge", "heap_sys/gauge"},
+		},
+		{
+			name:   "Regexp",
+			filter: MetricFilter{Name: regexp.MustCompile(`_(sys|count)$`)},
+			want:   []string{"heap_sys/gauge", "poll_count/counter"},
+		},
+		{
+			name:   "No match",
+			filter: MetricFilter{NamePrefix: "heap_", Name: regexp.MustCompile(`count`)},
+			want:   []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, m := range Filter(metrics, tt.filter) {
+				got = append(got, m.Name+"/"+m.Type)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+	assert.Equal(t, "heap_sys", metrics[0].Name, "Filter must not reorder its input")
+}
+
+func TestInMemoryRepository_Query(t *testing.T) {
+	repo := NewInMemoryRepository(zap.NewNop().Sugar())
+	ctx := context.Background()
+	require.NoError(t, repo.Update(ctx, &entity.Metric{Name: "b", Type: entity.MetricTypeGauge, Value: 1.0}))
+	require.NoError(t, repo.Update(ctx, &entity.Metric{Name: "a", Type: entity.MetricTypeGauge, Value: 2.0}))
+	require.NoError(t, repo.Update(ctx, &entity.Metric{Name: "c", Type: entity.MetricTypeCounter, Value: int64(3)}))
+	require.NoError(t, repo.Delete(ctx, entity.MetricTypeGauge, "b"))
+
+	metrics, err := repo.Query(ctx, MetricFilter{Type: entity.MetricTypeGauge})
+	require.NoError(t, err)
+	require.Len(t, *metrics, 1, "deleted metrics must not be listed")
+	assert.Equal(t, "a", (*metrics)[0].Name)
+}
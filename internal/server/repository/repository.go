@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
 )
@@ -62,6 +63,63 @@ type Repository interface {
 	//   - error: An error if the operation fails.
 	All(context.Context) (*entity.Metrics, error)
 
+	// Page retrieves one page of the metrics in a stable order, so large storages can be browsed
+	// without loading every metric at once.
+	//
+	// Parameters:
+	//   - ctx: The context for the operation.
+	//   - query: The offset, the limit and the order of the page.
+	//
+	// Returns:
+	//   - *Page: The metrics of the page and the total number of metrics.
+	//   - error: ErrInvalidPageQuery if the query is invalid, or another error if the operation fails.
+	Page(ctx context.Context, query PageQuery) (*Page, error)
+
+	// Query retrieves the metrics matching a filter, sorted by name and then by type, so clients need
+	// not pull every metric and filter them themselves.
+	//
+	// Parameters:
+	//   - ctx: The context for the operation.
+	//   - filter: The type, the name prefix and the name expression the metrics must match.
+	//
+	// Returns:
+	//   - *entity.Metrics: The matching metrics.
+	//   - error: An error if the operation fails.
+	Query(ctx context.Context, filter MetricFilter) (*entity.Metrics, error)
+
+	// Delete soft-deletes a metric, leaving a tombstone that can be undone with Undelete.
+	//
+	// Parameters:
+	//   - ctx: The context for the operation.
+	//   - metricType: The type of the metric.
+	//   - metricName: The name of the metric.
+	//
+	// Returns:
+	//   - error: ErrNotFoundInRepo if the metric does not exist, or another error if the operation fails.
+	Delete(ctx context.Context, metricType string, metricName string) error
+
+	// Undelete restores a soft-deleted metric that has not been purged yet.
+	//
+	// Parameters:
+	//   - ctx: The context for the operation.
+	//   - metricType: The type of the metric.
+	//   - metricName: The name of the metric.
+	//
+	// Returns:
+	//   - error: ErrNotFoundInRepo if there is no tombstone for the metric, or another error if the operation fails.
+	Undelete(ctx context.Context, metricType string, metricName string) error
+
+	// Purge permanently removes metrics soft-deleted before the given moment.
+	//
+	// Parameters:
+	//   - ctx: The context for the operation.
+	//   - deletedBefore: Tombstones older than this moment are removed.
+	//
+	// Returns:
+	//   - int: The number of purged metrics.
+	//   - error: An error if the operation fails.
+	Purge(ctx context.Context, deletedBefore time.Time) (int, error)
+
 	// CheckConnection verifies the repository's connection.
 	//
 	// Parameters:
@@ -71,3 +129,32 @@ type Repository interface {
 	//   - error: An error if the connection check fails.
 	CheckConnection(context.Context) error
 }
+
+// MetaStore is implemented by repositories that persist the annotations of metrics. Annotations are kept
+// apart from the values, so updates never touch them, and they outlive deletion: a metric that is purged
+// and reported again keeps its owner and runbook.
+type MetaStore interface {
+	// Meta retrieves the annotations of a metric.
+	//
+	// Parameters:
+	//   - ctx: The context for the operation.
+	//   - metricType: The type of the metric.
+	//   - metricName: The name of the metric.
+	//
+	// Returns:
+	//   - *entity.Meta: The annotations of the metric.
+	//   - error: ErrNotFoundInRepo if the metric has no annotations, or another error if the operation fails.
+	Meta(ctx context.Context, metricType string, metricName string) (*entity.Meta, error)
+
+	// SetMeta replaces the annotations of a stored metric. Empty annotations remove them.
+	//
+	// Parameters:
+	//   - ctx: The context for the operation.
+	//   - metricType: The type of the metric.
+	//   - metricName: The name of the metric.
+	//   - meta: The new annotations.
+	//
+	// Returns:
+	//   - error: ErrNotFoundInRepo if the metric is not stored, or another error if the operation fails.
+	SetMeta(ctx context.Context, metricType string, metricName string, meta *entity.Meta) error
+}
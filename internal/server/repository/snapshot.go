@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// Const snapshotVersion is the version of the storage file format written by this build.
+	snapshotVersion = 1
+	// Const corruptFileSuffix names the copy an unrestorable storage file is moved to, so it can be inspected.
+	corruptFileSuffix = ".corrupt"
+)
+
+// ErrCorruptSnapshot is returned when the storage file does not match its checksum header.
+var ErrCorruptSnapshot = errors.New("storage file is corrupted")
+
+// castagnoli is the CRC-32C table the snapshot checksum is computed with.
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// snapshotHeader is the first line of the storage file, describing the metric lines that follow it.
+type snapshotHeader struct {
+	Version  int    `json:"metricol_snapshot"` // Version is the format version, always positive.
+	Checksum uint32 `json:"crc32c"`            // Checksum is the CRC-32C of the metric lines.
+	Size     int    `json:"size"`              // Size is the length of the metric lines in bytes.
+}
+
+// withSnapshotHeader prefixes encoded metric lines with their checksum header.
+//
+// Parameters:
+//   - body: The metric lines.
+//
+// Returns:
+//   - []byte: The content of the storage file.
+func withSnapshotHeader(body []byte) []byte {
+	header, _ := json.Marshal(snapshotHeader{
+		Version:  snapshotVersion,
+		Checksum: crc32.Checksum(body, castagnoli),
+		Size:     len(body),
+	})
+	data := make([]byte, 0, len(header)+1+len(body))
+	data = append(append(data, header...), '\n')
+	return append(data, body...)
+}
+
+// verifySnapshot checks the content of the storage file against its checksum header. Files written before
+// the header was introduced have none and are accepted as they are.
+//
+// Parameters:
+//   - data: The content of the storage file.
+//
+// Returns:
+//   - []byte: The metric lines.
+//   - bool: Whether the file has a checksum header.
+//   - error: ErrCorruptSnapshot if the metric lines do not match the header.
+func verifySnapshot(data []byte) ([]byte, bool, error) {
+	first, body, _ := bytes.Cut(data, []byte{'\n'})
+	var header snapshotHeader
+	if err := json.Unmarshal(first, &header); err != nil || header.Version <= 0 {
+		return data, false, nil
+	}
+
+	if header.Version > snapshotVersion {
+		return nil, true, fmt.Errorf("%w: unsupported format version %d", ErrCorruptSnapshot, header.Version)
+	}
+	if len(body) != header.Size {
+		return nil, true, fmt.Errorf("%w: %d bytes of metrics, header expects %d", ErrCorruptSnapshot, len(body), header.Size)
+	}
+	if sum := crc32.Checksum(body, castagnoli); sum != header.Checksum {
+		return nil, true, fmt.Errorf("%w: checksum %08x, header expects %08x", ErrCorruptSnapshot, sum, header.Checksum)
+	}
+	return body, true, nil
+}
+
+// syncDir forces a rename in a directory to stable storage.
+//
+// Parameters:
+//   - path: A path in the directory.
+//
+// Returns:
+//   - error: An error if the directory cannot be synced.
+func syncDir(path string) error {
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return fmt.Errorf("failed to open directory: %w", err)
+	}
+	if err = errors.Join(dir.Sync(), dir.Close()); err != nil {
+		return fmt.Errorf("failed to sync directory: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySnapshot(t *testing.T) {
+	body := []byte("{\"name\":\"a\",\"type\":\"gauge\",\"value\":1}\n{\"name\":\"b\",\"type\":\"gauge\",\"value\":2}\n")
+	valid := withSnapshotHeader(body)
+
+	tests := []struct {
+		name         string
+		data         []byte
+		expectedBody []byte
+		verified     bool
+		wantErr      bool
+	}{
+		{name: "Valid", data: valid, expectedBody: body, verified: true},
+		{name: "Empty body", data: withSnapshotHeader(nil), expectedBody: []byte{}, verified: true},
+		{name: "Legacy file without header", data: body, expectedBody: body},
+		{name: "Empty legacy file", data: []byte{}, expectedBody: []byte{}},
+		{name: "Truncated", data: valid[:len(valid)-10], verified: true, wantErr: true},
+		{name: "Header only", data: valid[:bytes.IndexByte(valid, '\n')+1], verified: true, wantErr: true},
+		{
+			name:     "Altered value",
+			data:     bytes.Replace(valid, []byte(`"value":2`), []byte(`"value":3`), 1),
+			verified: true,
+			wantErr:  true,
+		},
+		{
+			name:     "Newer version",
+			data:     append([]byte("{\"metricol_snapshot\":99,\"crc32c\":0,\"size\":0}\n"), body...),
+			verified: true,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, verified, err := verifySnapshot(tt.data)
+			assert.Equal(t, tt.verified, verified)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrCorruptSnapshot)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, string(tt.expectedBody), string(got))
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/gdyunin/metricol.git/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName names the tracer of the repository spans.
+const tracerName = "github.com/gdyunin/metricol.git/internal/server/repository"
+
+// Span attributes describing the metrics an operation works on.
+var (
+	attrMetricType = attribute.Key("metricol.metric.type")
+	attrMetricName = attribute.Key("metricol.metric.name")
+	attrBatchSize  = attribute.Key("metricol.batch.size")
+)
+
+// TracedRepository decorates a Repository with an OpenTelemetry span around every operation, so traces
+// show how long the storage took and which operations failed.
+type TracedRepository struct {
+	Repository                    // Repository is the decorated repository.
+	tracer     trace.Tracer       // tracer starts the spans.
+	system     attribute.KeyValue // system is the attribute naming the kind of the storage.
+}
+
+// NewTracedRepository wraps a repository so every operation is traced.
+//
+// Parameters:
+//   - repo: The repository to decorate.
+//   - provider: The tracer provider the spans are started with.
+//   - system: The kind of the storage, e.g. "postgresql", "file" or "memory".
+//
+// Returns:
+//   - *TracedRepository: A pointer to the decorating repository.
+func NewTracedRepository(repo Repository, provider trace.TracerProvider, system string) *TracedRepository {
+	return &TracedRepository{
+		Repository: repo,
+		tracer:     provider.Tracer(tracerName),
+		system:     semconv.DBSystemKey.String(system),
+	}
+}
+
+// Unwrap returns the decorated repository, so optional capabilities such as migration reporting stay reachable.
+//
+// Returns:
+//   - Repository: The decorated repository.
+func (r *TracedRepository) Unwrap() Repository {
+	return r.Repository
+}
+
+// Update adds or updates a metric in a span.
+func (r *TracedRepository) Update(ctx context.Context, metric *entity.Metric) (err error) {
+	ctx, span := r.start(ctx, "Update", attrMetricType.String(metric.Type), attrMetricName.String(metric.Name))
+	defer func() { tracing.End(span, err) }()
+	return r.Repository.Update(ctx, metric) //nolint:wrapcheck // the decorator is transparent
+}
+
+// UpdateBatch adds or updates a batch of metrics in a span.
+func (r *TracedRepository) UpdateBatch(ctx context.Context, metrics *entity.Metrics) (err error) {
+	ctx, span := r.start(ctx, "UpdateBatch", attrBatchSize.Int(metrics.Length()))
+	defer func() { tracing.End(span, err) }()
+	return r.Repository.UpdateBatch(ctx, metrics) //nolint:wrapcheck // the decorator is transparent
+}
+
+// Find retrieves a metric in a span. A metric that is not found does not mark the span as failed.
+func (r *TracedRepository) Find(ctx context.Context, metricType, metricName string) (*entity.Metric, error) {
+	ctx, span := r.start(ctx, "Find", attrMetricType.String(metricType), attrMetricName.String(metricName))
+	metric, err := r.Repository.Find(ctx, metricType, metricName)
+	if errors.Is(err, ErrNotFoundInRepo) {
+		tracing.End(span, nil)
+	} else {
+		tracing.End(span, err)
+	}
+	return metric, err //nolint:wrapcheck // the decorator is transparent
+}
+
+// All retrieves all metrics in a span.
+func (r *TracedRepository) All(ctx context.Context) (metrics *entity.Metrics, err error) {
+	ctx, span := r.start(ctx, "All")
+	defer func() { tracing.End(span, err) }()
+	return r.Repository.All(ctx) //nolint:wrapcheck // the decorator is transparent
+}
+
+// Page retrieves a page of metrics in a span.
+func (r *TracedRepository) Page(ctx context.Context, query PageQuery) (page *Page, err error) {
+	ctx, span := r.start(ctx, "Page")
+	defer func() { tracing.End(span, err) }()
+	return r.Repository.Page(ctx, query) //nolint:wrapcheck // the decorator is transparent
+}
+
+// Query retrieves the matching metrics in a span.
+func (r *TracedRepository) Query(ctx context.Context, filter MetricFilter) (metrics *entity.Metrics, err error) {
+	ctx, span := r.start(ctx, "Query")
+	defer func() { tracing.End(span, err) }()
+	return r.Repository.Query(ctx, filter) //nolint:wrapcheck // the decorator is transparent
+}
+
+// Delete soft-deletes a metric in a span.
+func (r *TracedRepository) Delete(ctx context.Context, metricType string, metricName string) (err error) {
+	ctx, span := r.start(ctx, "Delete", attrMetricType.String(metricType), attrMetricName.String(metricName))
+	defer func() { tracing.End(span, err) }()
+	return r.Repository.Delete(ctx, metricType, metricName) //nolint:wrapcheck // the decorator is transparent
+}
+
+// Undelete restores a soft-deleted metric in a span.
+func (r *TracedRepository) Undelete(ctx context.Context, metricType string, metricName string) (err error) {
+	ctx, span := r.start(ctx, "Undelete", attrMetricType.String(metricType), attrMetricName.String(metricName))
+	defer func() { tracing.End(span, err) }()
+	return r.Repository.Undelete(ctx, metricType, metricName) //nolint:wrapcheck // the decorator is transparent
+}
+
+// Purge permanently removes old tombstones in a span.
+func (r *TracedRepository) Purge(ctx context.Context, deletedBefore time.Time) (purged int, err error) {
+	ctx, span := r.start(ctx, "Purge")
+	defer func() { tracing.End(span, err) }()
+	return r.Repository.Purge(ctx, deletedBefore) //nolint:wrapcheck // the decorator is transparent
+}
+
+// CheckConnection checks the connection in a span.
+func (r *TracedRepository) CheckConnection(ctx context.Context) (err error) {
+	ctx, span := r.start(ctx, "CheckConnection")
+	defer func() { tracing.End(span, err) }()
+	return r.Repository.CheckConnection(ctx) //nolint:wrapcheck // the decorator is transparent
+}
+
+// start starts the client span of an operation.
+func (r *TracedRepository) start(
+	ctx context.Context,
+	operation string,
+	attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	return r.tracer.Start(ctx, "repository."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append(attrs, r.system, semconv.DBOperationName(operation))...),
+	)
+}
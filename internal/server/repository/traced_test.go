@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+func TestTracedRepository(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	inner := NewInMemoryRepository(zap.NewNop().Sugar())
+	repo := NewTracedRepository(NewFaultyRepository(inner, 0, 0), provider, "memory")
+	ctx := context.Background()
+
+	batch := entity.Metrics{
+		{Name: "a", Type: entity.MetricTypeGauge, Value: 1.0},
+		{Name: "b", Type: entity.MetricTypeGauge, Value: 2.0},
+	}
+	require.NoError(t, repo.UpdateBatch(ctx, &batch))
+	found, err := repo.Find(ctx, entity.MetricTypeGauge, "a")
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, found.Value)
+	_, err = repo.Find(ctx, entity.MetricTypeGauge, "missing")
+	require.ErrorIs(t, err, ErrNotFoundInRepo)
+	require.ErrorIs(t, repo.Delete(ctx, entity.MetricTypeGauge, "missing"), ErrNotFoundInRepo)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 4)
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name())
+		assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+		assert.Contains(t, span.Attributes(), semconv.DBSystemKey.String("memory"))
+	}
+	assert.Equal(t, []string{"repository.UpdateBatch", "repository.Find", "repository.Find", "repository.Delete"}, names)
+	assert.Contains(t, spans[0].Attributes(), attrBatchSize.Int(2))
+	assert.Equal(t, codes.Unset, spans[2].Status().Code, "a missing metric is not a failure of Find")
+	assert.Equal(t, codes.Error, spans[3].Status().Code)
+
+	assert.Same(t, inner, Base(repo), "the traced repository unwraps to the storage")
+}
+
+func TestTracedRepository_Parent(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	repo := NewTracedRepository(NewInMemoryRepository(zap.NewNop().Sugar()), provider, "memory")
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	require.NoError(t, repo.CheckConnection(ctx))
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID(), "storage spans join the request trace")
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+)
+
+const (
+	// Const walFileSuffix names the write-ahead log next to the storage file.
+	walFileSuffix = ".wal"
+	// Const defaultWALLimit is the write-ahead log size in bytes triggering a compaction if no limit is set.
+	defaultWALLimit = 4 << 20
+)
+
+// walKey identifies a metric in the write-ahead log.
+type walKey struct {
+	Type string `json:"type"` // Type is the metric type.
+	Name string `json:"name"` // Name is the metric name.
+}
+
+// walRecord is a line of the write-ahead log. Every record carries the state of a metric rather than
+// a change to it, so replaying a record twice, or over a snapshot that already holds it, is harmless.
+type walRecord struct {
+	Metric  *entity.Metric `json:"metric,omitempty"`  // Metric is the stored value of a written metric.
+	Deleted *walKey        `json:"deleted,omitempty"` // Deleted identifies a deleted metric.
+}
+
+// walRecords reads the current state of the metrics from memory: the stored value of live metrics and
+// a deletion record for metrics that are gone.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - repo: The in-memory repository holding the state.
+//   - keys: The metrics to read.
+//
+// Returns:
+//   - []walRecord: The records, one per metric.
+func walRecords(ctx context.Context, repo *InMemoryRepository, keys []walKey) []walRecord {
+	records := make([]walRecord, 0, len(keys))
+	for _, key := range keys {
+		metric, err := repo.Find(ctx, key.Type, key.Name)
+		if err != nil {
+			records = append(records, walRecord{Deleted: &walKey{Type: key.Type, Name: key.Name}})
+			continue
+		}
+		records = append(records, walRecord{Metric: metric})
+	}
+	return records
+}
+
+// encodeWAL serializes records to JSON lines.
+//
+// Parameters:
+//   - records: The records to serialize.
+//
+// Returns:
+//   - []byte: The serialized records.
+//   - error: An error if a record cannot be serialized.
+func encodeWAL(records []walRecord) ([]byte, error) {
+	var data []byte
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize log record: %w", err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+	return data, nil
+}
+
+// replayWAL applies the records of a write-ahead log to the in-memory repository. A line that cannot be
+// decoded, such as the last line of a log cut short by a crash, is reported and skipped.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - repo: The in-memory repository to apply the records to.
+//   - r: The write-ahead log.
+//   - warn: Reports the skipped lines.
+//
+// Returns:
+//   - int: The number of applied records.
+//   - error: An error if the log cannot be read.
+func replayWAL(ctx context.Context, repo *InMemoryRepository, r io.Reader, warn func(string, ...any)) (int, error) {
+	applied := 0
+	reader := bufio.NewReader(r)
+	for {
+		// Records are read whole rather than scanned, so a metric of any size replays.
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return applied, fmt.Errorf("failed to read log: %w", readErr)
+		}
+		if line = bytes.TrimSuffix(line, []byte{'\n'}); len(line) > 0 && applyWALRecord(ctx, repo, line, warn) {
+			applied++
+		}
+		if readErr != nil {
+			return applied, nil
+		}
+	}
+}
+
+// applyWALRecord applies a single line of the write-ahead log to the in-memory repository.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - repo: The in-memory repository to apply the record to.
+//   - line: The line of the log.
+//   - warn: Reports the line if it is skipped.
+//
+// Returns:
+//   - bool: True if the record was applied, false if it was skipped.
+func applyWALRecord(ctx context.Context, repo *InMemoryRepository, line []byte, warn func(string, ...any)) bool {
+	var record walRecord
+	if err := json.Unmarshal(line, &record); err != nil {
+		warn("failed to deserialize log record: raw=%s, error=%v", string(line), err)
+		return false
+	}
+	switch {
+	case record.Metric != nil:
+		if err := repo.Update(ctx, record.Metric); err != nil {
+			warn("failed to replay log record: raw=%s, error=%v", string(line), err)
+			return false
+		}
+	case record.Deleted != nil:
+		// The metric may be unknown if it was deleted before the last compaction.
+		if err := repo.Delete(ctx, record.Deleted.Type, record.Deleted.Name); err != nil &&
+			!errors.Is(err, ErrNotFoundInRepo) {
+			warn("failed to replay log record: raw=%s, error=%v", string(line), err)
+			return false
+		}
+	default:
+		warn("empty log record: raw=%s", string(line))
+		return false
+	}
+	return true
+}
+
+// readWAL replays the write-ahead log at path. A missing log holds no records.
+//
+// Parameters:
+//   - ctx: The context for the operation.
+//   - repo: The in-memory repository to apply the records to.
+//   - path: The path of the log.
+//   - warn: Reports the skipped lines.
+//
+// Returns:
+//   - int: The number of applied records.
+//   - error: An error if the log exists but cannot be read.
+func readWAL(ctx context.Context, repo *InMemoryRepository, path string, warn func(string, ...any)) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to open log: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	return replayWAL(ctx, repo, file, warn)
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/internal/server/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestWALRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := NewInMemoryRepository(zap.NewNop().Sugar())
+	require.NoError(t, source.Update(ctx, &entity.Metric{Name: "Alloc", Type: entity.MetricTypeGauge, Value: 1.5}))
+	counter := &entity.Metric{Name: "PollCount", Type: entity.MetricTypeCounter, Value: int64(3)}
+	require.NoError(t, source.Update(ctx, counter))
+
+	data, err := encodeWAL(walRecords(ctx, source, []walKey{
+		{Type: entity.MetricTypeGauge, Name: "Alloc"},
+		{Type: entity.MetricTypeCounter, Name: "PollCount"},
+		{Type: entity.MetricTypeGauge, Name: "Missing"},
+	}))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `{"deleted":{"type":"gauge","name":"Missing"}}`)
+
+	target := NewInMemoryRepository(zap.NewNop().Sugar())
+	require.NoError(t, target.Update(ctx, &entity.Metric{Name: "Missing", Type: entity.MetricTypeGauge, Value: 9.0}))
+	applied, err := replayWAL(ctx, target, strings.NewReader(string(data)), t.Logf)
+	require.NoError(t, err)
+	assert.Equal(t, 3, applied)
+
+	alloc, err := target.Find(ctx, entity.MetricTypeGauge, "Alloc")
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, alloc.Value)
+	count, err := target.Find(ctx, entity.MetricTypeCounter, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count.Value)
+	_, err = target.Find(ctx, entity.MetricTypeGauge, "Missing")
+	assert.ErrorIs(t, err, ErrNotFoundInRepo)
+}
+
+func TestReplayWAL_SkipsBadLines(t *testing.T) {
+	log := strings.Join([]string{
+		`{"metric":{"name":"a","type":"gauge","value":1}}`,
+		``,
+		`not json`,
+		`{}`,
+		`{"deleted":{"type":"gauge","name":"never-stored"}}`,
+		`{"metric":{"name":"a","type":"gauge","value":2}}`,
+		`{"metric":{"name":"b","ty`,
+	}, "\n")
+
+	var warnings []string
+	warn := func(format string, args ...any) { warnings = append(warnings, fmt.Sprintf(format, args...)) }
+	repo := NewInMemoryRepository(zap.NewNop().Sugar())
+	applied, err := replayWAL(context.Background(), repo, strings.NewReader(log), warn)
+	require.NoError(t, err)
+	assert.Equal(t, 3, applied)
+	assert.Len(t, warnings, 3)
+
+	a, err := repo.Find(context.Background(), entity.MetricTypeGauge, "a")
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, a.Value)
+}
+
+func TestReadWAL_Missing(t *testing.T) {
+	repo := NewInMemoryRepository(zap.NewNop().Sugar())
+	applied, err := readWAL(context.Background(), repo, filepath.Join(t.TempDir(), "missing.wal"), t.Logf)
+	require.NoError(t, err)
+	assert.Zero(t, applied)
+}
+
+func TestReplayWAL_LongRecord(t *testing.T) {
+	ctx := context.Background()
+	source := NewInMemoryRepository(zap.NewNop().Sugar())
+	name := strings.Repeat("n", 128<<10)
+	require.NoError(t, source.Update(ctx, &entity.Metric{Name: name, Type: entity.MetricTypeGauge, Value: 1.5}))
+	data, err := encodeWAL(walRecords(ctx, source, []walKey{{Type: entity.MetricTypeGauge, Name: name}}))
+	require.NoError(t, err)
+
+	target := NewInMemoryRepository(zap.NewNop().Sugar())
+	applied, err := replayWAL(ctx, target, strings.NewReader(string(data)), t.Logf)
+	require.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	metric, err := target.Find(ctx, entity.MetricTypeGauge, name)
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, metric.Value)
+}
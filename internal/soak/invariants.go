@@ -0,0 +1,142 @@
+package soak
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+)
+
+// counterMonitor reads the counters of the server and checks that none of them ever decreases.
+type counterMonitor struct {
+	last map[string]int64 // last holds the values seen by the latest check by counter name.
+}
+
+// newCounterMonitor creates a monitor that has not seen any counter yet.
+//
+// Returns:
+//   - *counterMonitor: The monitor.
+func newCounterMonitor() *counterMonitor {
+	return &counterMonitor{last: make(map[string]int64)}
+}
+
+// check reads every counter from /api/metrics and compares it with the previous check.
+//
+// Parameters:
+//   - ctx: The context of the request.
+//   - client: The HTTP client.
+//   - baseURL: The base URL of the server.
+//
+// Returns:
+//   - error: An error if the counters cannot be read or one of them went backwards or disappeared.
+func (m *counterMonitor) check(ctx context.Context, client *http.Client, baseURL string) error {
+	current, err := readCounters(ctx, client, baseURL)
+	if err != nil {
+		return err
+	}
+	return m.observe(current)
+}
+
+// observe compares counter values with the previous ones and keeps them for the next comparison.
+//
+// Parameters:
+//   - current: The current counter values by name.
+//
+// Returns:
+//   - error: An error listing the counters that went backwards or disappeared.
+func (m *counterMonitor) observe(current map[string]int64) error {
+	var broken []string
+	for name, before := range m.last {
+		now, ok := current[name]
+		switch {
+		case !ok:
+			broken = append(broken, fmt.Sprintf("%s disappeared at %d", name, before))
+		case now < before:
+			broken = append(broken, fmt.Sprintf("%s went from %d to %d", name, before, now))
+		}
+	}
+	m.last = current
+	if len(broken) == 0 {
+		return nil
+	}
+	sort.Strings(broken)
+	return fmt.Errorf("counters are not monotonic: %s", strings.Join(broken, ", "))
+}
+
+// readCounters reads the values of every counter stored on the server.
+//
+// Parameters:
+//   - ctx: The context of the request.
+//   - client: The HTTP client.
+//   - baseURL: The base URL of the server.
+//
+// Returns:
+//   - map[string]int64: The counter values by name.
+//   - error: An error if the metrics cannot be read.
+func readCounters(ctx context.Context, client *http.Client, baseURL string) (map[string]int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/api/metrics", http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metrics request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metrics: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to read metrics: status %d", resp.StatusCode)
+	}
+
+	var metrics model.FederatedMetrics
+	if err = json.NewDecoder(resp.Body).Decode(&metrics); err != nil {
+		return nil, fmt.Errorf("failed to decode metrics: %w", err)
+	}
+	counters := make(map[string]int64)
+	for _, metric := range metrics.Metrics {
+		if metric.MType == metricTypeCounter && metric.Delta != nil {
+			counters[metric.ID] = *metric.Delta
+		}
+	}
+	return counters, nil
+}
+
+// memorySampler tracks the heap size and the number of goroutines, and checks the heap against a limit.
+type memorySampler struct {
+	limit          uint64 // limit is the heap size in bytes the run must stay below; zero disables the check.
+	last           uint64 // last is the heap size seen by the latest sample.
+	peak           uint64 // peak is the largest heap size seen.
+	peakGoroutines int    // peakGoroutines is the largest number of goroutines seen.
+}
+
+// sample reads the current heap size and number of goroutines.
+//
+// Returns:
+//   - error: An error if the heap has grown beyond the limit.
+func (s *memorySampler) sample() error {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return s.observe(stats.HeapAlloc, runtime.NumGoroutine())
+}
+
+// observe records a heap size and number of goroutines.
+//
+// Parameters:
+//   - heap: The heap size in bytes.
+//   - goroutines: The number of goroutines.
+//
+// Returns:
+//   - error: An error if the heap size is beyond the limit.
+func (s *memorySampler) observe(heap uint64, goroutines int) error {
+	s.last = heap
+	s.peak = max(s.peak, heap)
+	s.peakGoroutines = max(s.peakGoroutines, goroutines)
+	if s.limit > 0 && heap > s.limit {
+		return fmt.Errorf("heap grew beyond the limit: %dMB > %dMB", heap/bytesInMB, s.limit/bytesInMB)
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+// Package soak runs the agent and the server in-process for a long time under a randomized workload
+// and checks invariants that only break slowly: goroutines leaking past shutdown, memory growing without
+// bound and counters going backwards or losing acknowledged updates. It backs cmd/soak and is meant to
+// validate redesigns, such as the worker-pool sender, before they reach production.
+package soak
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/agent/agent"
+	"github.com/gdyunin/metricol.git/internal/server/delivery"
+	"github.com/gdyunin/metricol.git/internal/server/keyring"
+	"github.com/gdyunin/metricol.git/internal/server/repository"
+
+	"go.uber.org/goleak"
+	"go.uber.org/zap"
+)
+
+const (
+	// Const readyTimeout bounds the wait for the in-process server to accept requests.
+	readyTimeout = 5 * time.Second
+	// Const readyPollInterval is the period between readiness probes of the server.
+	readyPollInterval = 10 * time.Millisecond
+	// Const requestTimeout bounds every request of the workload and the invariant checks.
+	requestTimeout = 5 * time.Second
+	// Const bytesInMB converts megabytes to bytes.
+	bytesInMB = 1 << 20
+	// Const leakGrace is how long goroutines may take to end after shutdown, long enough for
+	// a collection in flight, such as the one-second CPU sampling of gopsutil, to finish.
+	leakGrace = 5 * time.Second
+	// Const leakPollInterval is the period between leak checks within leakGrace.
+	leakPollInterval = 100 * time.Millisecond
+)
+
+// Config describes a soak run.
+type Config struct {
+	Seed           int64         // Seed makes the randomized workload reproducible.
+	Duration       time.Duration // Duration is how long the workload runs.
+	CheckInterval  time.Duration // CheckInterval is the period between invariant checks.
+	PollInterval   time.Duration // PollInterval is the collection interval of the agent.
+	ReportInterval time.Duration // ReportInterval is the send interval of the agent.
+	Workers        int           // Workers is the number of concurrent workload clients.
+	Counters       int           // Counters is the number of counters the workload updates.
+	Gauges         int           // Gauges is the number of gauges the workload updates.
+	MaxBatch       int           // MaxBatch is the largest batch the workload sends at once.
+	MaxHeapMB      int           // MaxHeapMB is the heap size the run must stay below.
+	Verbose        bool          // Verbose passes the logs of the agent and the server through.
+}
+
+// Result is the outcome of a soak run.
+type Result struct {
+	Violations     []string // Violations describe every broken invariant; empty if the run passed.
+	Requests       int64    // Requests is the number of workload requests sent.
+	Failed         int64    // Failed is the number of workload requests that were not acknowledged.
+	Checks         int      // Checks is the number of periodic invariant checks.
+	PeakHeapBytes  uint64   // PeakHeapBytes is the largest heap size observed.
+	PeakGoroutines int      // PeakGoroutines is the largest number of goroutines observed.
+}
+
+// Passed reports whether no invariant was broken.
+//
+// Returns:
+//   - bool: True if the run has no violations.
+func (r *Result) Passed() bool {
+	return len(r.Violations) == 0
+}
+
+// Run starts an in-memory server and an agent reporting to it, drives a randomized workload against
+// the server for cfg.Duration and checks the invariants every cfg.CheckInterval. Once the workload ends,
+// everything is shut down and the process must be left without the goroutines the run started.
+//
+// Parameters:
+//   - ctx: The context canceling the run early; the final checks still run.
+//   - cfg: The run configuration.
+//   - logger: The logger of the run; with cfg.Verbose the agent and the server log through it too.
+//
+// Returns:
+//   - *Result: The outcome of the run.
+//   - error: An error if the server or the agent cannot be started.
+func Run(ctx context.Context, cfg Config, logger *zap.SugaredLogger) (*Result, error) {
+	// Goroutines running before the soak, such as the signal handler of the caller, are not leaks.
+	preexisting := goleak.IgnoreCurrent()
+
+	addr, err := freeAddress()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := keyring.New("", "", "", "", 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create keyring: %w", err)
+	}
+	components := zap.NewNop().Sugar()
+	if cfg.Verbose {
+		components = logger
+	}
+	repo := repository.NewInMemoryRepository(components.Named("repository"))
+	server := delivery.NewEchoServer(addr, keys, repo, components.Named("server"))
+
+	serverCtx, stopServer := context.WithCancel(context.Background())
+	defer stopServer()
+	serverDone := make(chan struct{})
+	go func() {
+		defer close(serverDone)
+		server.Start(serverCtx)
+	}()
+
+	client := &http.Client{Timeout: requestTimeout}
+	baseURL := "http://" + addr
+	if err = waitReady(ctx, client, baseURL); err != nil {
+		stopServer()
+		<-serverDone
+		return nil, err
+	}
+
+	result := &Result{}
+	monitor := newCounterMonitor()
+	memory := &memorySampler{limit: uint64(cfg.MaxHeapMB) * bytesInMB}
+	load := newWorkload(client, baseURL, cfg)
+
+	runCtx, stopRun := context.WithTimeout(ctx, cfg.Duration)
+	defer stopRun()
+
+	metricsAgent := agent.NewAgent(cfg.PollInterval, cfg.ReportInterval, components.Named("agent"), 1, addr, "", "")
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		metricsAgent.Start(runCtx)
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		load.run(runCtx)
+	}()
+
+	logger.Infof(
+		"Soak started: server=%s, duration=%s, workers=%d, seed=%d", addr, cfg.Duration, cfg.Workers, cfg.Seed,
+	)
+	// The checks outlive an early cancellation of the run, so the final one still runs.
+	checkCtx := context.WithoutCancel(ctx)
+	check := func() {
+		result.Checks++
+		if err := monitor.check(checkCtx, client, baseURL); err != nil {
+			result.Violations = append(result.Violations, err.Error())
+		}
+		if err := memory.sample(); err != nil {
+			result.Violations = append(result.Violations, err.Error())
+		}
+		logger.Infof(
+			"Check %d: requests=%d, failed=%d, heap=%dMB, goroutines=%d, violations=%d",
+			result.Checks,
+			load.requests.Load(),
+			load.failed.Load(),
+			memory.last/bytesInMB,
+			runtime.NumGoroutine(),
+			len(result.Violations),
+		)
+	}
+
+	ticker := time.NewTicker(cfg.CheckInterval)
+	for running := true; running; {
+		select {
+		case <-runCtx.Done():
+			running = false
+		case <-ticker.C:
+			check()
+		}
+	}
+	ticker.Stop()
+	wg.Wait()
+
+	// The workload has stopped, so the final values must account for every acknowledged update.
+	check()
+	result.Violations = append(result.Violations, load.verify(monitor.last)...)
+
+	stopServer()
+	<-serverDone
+	client.CloseIdleConnections()
+	if err = findLeaks(preexisting); err != nil {
+		result.Violations = append(result.Violations, fmt.Sprintf("goroutines leaked after shutdown: %v", err))
+	}
+
+	result.Requests = load.requests.Load()
+	result.Failed = load.failed.Load()
+	result.PeakHeapBytes = memory.peak
+	result.PeakGoroutines = memory.peakGoroutines
+	return result, nil
+}
+
+// findLeaks looks for goroutines started by the run until none is left or leakGrace elapses.
+//
+// Parameters:
+//   - preexisting: The option ignoring the goroutines running before the run.
+//
+// Returns:
+//   - error: An error listing the goroutines still running after leakGrace.
+func findLeaks(preexisting goleak.Option) error {
+	deadline := time.Now().Add(leakGrace)
+	for {
+		err := goleak.Find(preexisting)
+		if err == nil || time.Now().After(deadline) {
+			return err //nolint:wrapcheck // The goroutine dump is the error.
+		}
+		time.Sleep(leakPollInterval)
+	}
+}
+
+// freeAddress returns a loopback address with a port free at the time of the call.
+//
+// Returns:
+//   - string: The address in host:port form.
+//   - error: An error if no port can be reserved.
+func freeAddress() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to reserve a port: %w", err)
+	}
+	addr := l.Addr().String()
+	if err = l.Close(); err != nil {
+		return "", fmt.Errorf("failed to release the reserved port: %w", err)
+	}
+	return addr, nil
+}
+
+// waitReady polls the /ping route of the server until it answers or readyTimeout elapses.
+//
+// Parameters:
+//   - ctx: The context canceling the wait.
+//   - client: The HTTP client.
+//   - baseURL: The base URL of the server.
+//
+// Returns:
+//   - error: An error if the server does not become ready in time.
+func waitReady(ctx context.Context, client *http.Client, baseURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/ping", http.NoBody)
+		if err != nil {
+			return fmt.Errorf("failed to create readiness probe: %w", err)
+		}
+		if resp, err := client.Do(req); err == nil {
+			_ = resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.New("server did not become ready in time")
+		case <-time.After(readyPollInterval):
+		}
+	}
+}
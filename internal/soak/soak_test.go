@@ -0,0 +1,44 @@
+package soak
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("soak run takes seconds")
+	}
+
+	result, err := Run(context.Background(), Config{
+		Seed:           1,
+		Duration:       2 * time.Second,
+		CheckInterval:  200 * time.Millisecond,
+		PollInterval:   100 * time.Millisecond,
+		ReportInterval: 300 * time.Millisecond,
+		Workers:        4,
+		Counters:       5,
+		Gauges:         5,
+		MaxBatch:       20,
+		MaxHeapMB:      512,
+	}, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	assert.True(t, result.Passed(), "violations: %v", result.Violations)
+	assert.Positive(t, result.Requests)
+	assert.GreaterOrEqual(t, result.Checks, 5)
+	assert.Positive(t, result.PeakHeapBytes)
+}
+
+func TestRun_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Run(ctx, Config{Duration: time.Minute, CheckInterval: time.Second}, zap.NewNop().Sugar())
+	require.Error(t, err, "the server cannot be probed with a canceled context")
+}
@@ -0,0 +1,231 @@
+package soak
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdyunin/metricol.git/internal/server/delivery/model"
+)
+
+const (
+	// Const metricTypeCounter is the type of counter metrics.
+	metricTypeCounter = "counter"
+	// Const metricTypeGauge is the type of gauge metrics.
+	metricTypeGauge = "gauge"
+	// Const counterPrefix names the counters of the workload.
+	counterPrefix = "SoakCounter"
+	// Const gaugePrefix names the gauges of the workload.
+	gaugePrefix = "SoakGauge"
+	// Const maxDelta is the largest increment the workload sends for a counter.
+	maxDelta = 100
+	// Const maxPause is the longest pause between two requests of a worker.
+	maxPause = 10 * time.Millisecond
+	// Const maxIdle is the longest idle period a worker sometimes takes, so the load comes in bursts.
+	maxIdle = 200 * time.Millisecond
+	// Const idleChance is the chance a worker idles after a request.
+	idleChance = 0.05
+	// Const singleChance is the chance a worker sends a single metric to /update instead of a batch.
+	singleChance = 0.3
+)
+
+// workload sends random counter increments and gauge values to the server from several workers,
+// tracking the increments the server acknowledged and those whose outcome is unknown.
+type workload struct {
+	client    *http.Client
+	acked     map[string]int64 // acked sums the acknowledged increments by counter name.
+	uncertain map[string]int64 // uncertain sums the increments of requests that failed without a response.
+	baseURL   string
+	cfg       Config
+	requests  atomic.Int64 // requests counts the requests sent.
+	failed    atomic.Int64 // failed counts the requests that were not acknowledged.
+	mu        sync.Mutex   // mu protects acked and uncertain.
+}
+
+// newWorkload creates a workload against a server.
+//
+// Parameters:
+//   - client: The HTTP client.
+//   - baseURL: The base URL of the server.
+//   - cfg: The run configuration.
+//
+// Returns:
+//   - *workload: The workload.
+func newWorkload(client *http.Client, baseURL string, cfg Config) *workload {
+	return &workload{
+		client:    client,
+		baseURL:   baseURL,
+		cfg:       cfg,
+		acked:     make(map[string]int64),
+		uncertain: make(map[string]int64),
+	}
+}
+
+// run drives the workers until ctx is canceled.
+//
+// Parameters:
+//   - ctx: The context stopping the workers.
+func (w *workload) run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := range w.cfg.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.worker(ctx, rand.New(rand.NewSource(w.cfg.Seed+int64(i)))) //nolint:gosec // Reproducible load.
+		}()
+	}
+	wg.Wait()
+}
+
+// worker sends random batches and single metrics, pausing randomly between them.
+//
+// Parameters:
+//   - ctx: The context stopping the worker.
+//   - rnd: The random source of the worker.
+func (w *workload) worker(ctx context.Context, rnd *rand.Rand) {
+	for ctx.Err() == nil {
+		if rnd.Float64() < singleChance {
+			single := w.randomMetrics(rnd, 1)
+			w.send(ctx, "/update", single[0], single)
+		} else {
+			batch := w.randomMetrics(rnd, 1+rnd.Intn(max(w.cfg.MaxBatch, 1)))
+			w.send(ctx, "/updates", batch, batch)
+		}
+
+		pause := time.Duration(rnd.Int63n(int64(maxPause)))
+		if rnd.Float64() < idleChance {
+			pause = time.Duration(rnd.Int63n(int64(maxIdle)))
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(pause):
+		}
+	}
+}
+
+// randomMetrics creates random counter increments and gauge values.
+//
+// Parameters:
+//   - rnd: The random source.
+//   - n: The number of metrics.
+//
+// Returns:
+//   - model.Metrics: The metrics.
+func (w *workload) randomMetrics(rnd *rand.Rand, n int) model.Metrics {
+	metrics := make(model.Metrics, 0, n)
+	for range n {
+		if w.cfg.Gauges > 0 && (w.cfg.Counters == 0 || rnd.Intn(2) == 0) {
+			value := rnd.NormFloat64() * 1000
+			metrics = append(metrics, &model.Metric{
+				ID:    fmt.Sprintf("%s%d", gaugePrefix, rnd.Intn(w.cfg.Gauges)),
+				MType: metricTypeGauge,
+				Value: &value,
+			})
+			continue
+		}
+		delta := 1 + rnd.Int63n(maxDelta)
+		metrics = append(metrics, &model.Metric{
+			ID:    fmt.Sprintf("%s%d", counterPrefix, rnd.Intn(max(w.cfg.Counters, 1))),
+			MType: metricTypeCounter,
+			Delta: &delta,
+		})
+	}
+	return metrics
+}
+
+// send posts a payload and accounts the counter increments it carries. Increments of a request that got
+// no response, including those canceled by the end of the run, may or may not have been applied,
+// so they are tracked apart from the acknowledged ones.
+//
+// Parameters:
+//   - ctx: The context of the request.
+//   - path: The route the payload is posted to.
+//   - payload: The request body.
+//   - metrics: The metrics carried by the payload.
+func (w *workload) send(ctx context.Context, path string, payload any, metrics model.Metrics) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	w.requests.Add(1)
+	resp, err := w.client.Do(req)
+	if err != nil {
+		w.failed.Add(1)
+		w.account(w.uncertain, metrics)
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		w.failed.Add(1)
+		return
+	}
+	w.account(w.acked, metrics)
+}
+
+// account adds the counter increments of the metrics to the sums.
+//
+// Parameters:
+//   - sums: The sums by counter name, acked or uncertain.
+//   - metrics: The metrics.
+func (w *workload) account(sums map[string]int64, metrics model.Metrics) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, m := range metrics {
+		if m.MType == metricTypeCounter && m.Delta != nil {
+			sums[m.ID] += *m.Delta
+		}
+	}
+}
+
+// verify checks that the final counter values hold every acknowledged increment and nothing
+// beyond the increments sent.
+//
+// Parameters:
+//   - final: The final counter values by name.
+//
+// Returns:
+//   - []string: A description of every counter that lost or gained increments.
+func (w *workload) verify(final map[string]int64) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	names := make([]string, 0, len(w.acked)+len(w.uncertain))
+	for name := range w.acked {
+		names = append(names, name)
+	}
+	for name := range w.uncertain {
+		if _, ok := w.acked[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var violations []string
+	for _, name := range names {
+		acked, uncertain := w.acked[name], w.uncertain[name]
+		switch got := final[name]; {
+		case got < acked:
+			violations = append(violations, fmt.Sprintf(
+				"counter %s lost acknowledged increments: got %d, acknowledged %d", name, got, acked,
+			))
+		case got > acked+uncertain:
+			violations = append(violations, fmt.Sprintf(
+				"counter %s holds increments never sent: got %d, sent at most %d", name, got, acked+uncertain,
+			))
+		}
+	}
+	return violations
+}
@@ -0,0 +1,92 @@
+// Package buildinfo describes the build of a binary: the version, build date and commit the linker embeds with
+// -ldflags "-X main.buildVersion=...", and the Go version it was built with. Agents send their build info in
+// request headers, so the server can check their compatibility.
+package buildinfo
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+)
+
+const (
+	// Unknown is the value of build fields the linker did not set.
+	Unknown = "N/A"
+
+	// HeaderAgentVersion carries the agent version.
+	HeaderAgentVersion = "X-Agent-Version"
+	// HeaderAgentBuildDate carries the agent build date.
+	HeaderAgentBuildDate = "X-Agent-Build-Date"
+	// HeaderAgentCommit carries the agent build commit.
+	HeaderAgentCommit = "X-Agent-Commit"
+)
+
+// Info describes a build.
+type Info struct {
+	Version   string `json:"version"`    // Version is the release version.
+	Date      string `json:"date"`       // Date is the build date.
+	Commit    string `json:"commit"`     // Commit is the source commit.
+	GoVersion string `json:"go_version"` // GoVersion is the Go release the binary was built with.
+}
+
+// New creates the build info of the running binary. Empty fields are reported as Unknown.
+//
+// Parameters:
+//   - version: The release version.
+//   - date: The build date.
+//   - commit: The source commit.
+//
+// Returns:
+//   - Info: The build info.
+func New(version, date, commit string) Info {
+	return Info{
+		Version:   orUnknown(version),
+		Date:      orUnknown(date),
+		Commit:    orUnknown(commit),
+		GoVersion: runtime.Version(),
+	}
+}
+
+// String formats the build info as "version (commit commit, built date)".
+func (i Info) String() string {
+	return fmt.Sprintf("%s (commit %s, built %s)", i.Version, i.Commit, i.Date)
+}
+
+// AgentHeaders returns the headers carrying the build info on agent requests.
+//
+// Returns:
+//   - map[string]string: The header values keyed by header name.
+func (i Info) AgentHeaders() map[string]string {
+	return map[string]string{
+		HeaderAgentVersion:   i.Version,
+		HeaderAgentBuildDate: i.Date,
+		HeaderAgentCommit:    i.Commit,
+	}
+}
+
+// FromAgentHeaders reads the build info an agent sent with a request. The Go version is not sent
+// and stays empty; missing fields are reported as Unknown.
+//
+// Parameters:
+//   - header: The request headers.
+//
+// Returns:
+//   - Info: The agent build info.
+//   - bool: False if the request carries no build info at all.
+func FromAgentHeaders(header http.Header) (Info, bool) {
+	version := header.Get(HeaderAgentVersion)
+	date := header.Get(HeaderAgentBuildDate)
+	commit := header.Get(HeaderAgentCommit)
+	if version == "" && date == "" && commit == "" {
+		return Info{}, false
+	}
+	return Info{Version: orUnknown(version), Date: orUnknown(date), Commit: orUnknown(commit)}, true
+}
+
+// orUnknown returns value, or Unknown if it is empty.
+func orUnknown(value string) string {
+	if value == "" {
+		return Unknown
+	}
+	return value
+}
@@ -0,0 +1,56 @@
+package buildinfo
+
+import (
+	"net/http"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	info := New("1.2.0", "", "abc123")
+	assert.Equal(t, Info{Version: "1.2.0", Date: Unknown, Commit: "abc123", GoVersion: runtime.Version()}, info)
+	assert.Equal(t, "1.2.0 (commit abc123, built N/A)", info.String())
+}
+
+func TestAgentHeadersRoundTrip(t *testing.T) {
+	info := New("1.2.0", "2024-05-01", "abc123")
+
+	header := make(http.Header)
+	for name, value := range info.AgentHeaders() {
+		header.Set(name, value)
+	}
+
+	got, ok := FromAgentHeaders(header)
+	assert.True(t, ok)
+	assert.Equal(t, Info{Version: "1.2.0", Date: "2024-05-01", Commit: "abc123"}, got)
+}
+
+func TestFromAgentHeaders(t *testing.T) {
+	tests := []struct {
+		header   http.Header
+		name     string
+		expected Info
+		ok       bool
+	}{
+		{
+			name:   "no build headers",
+			header: http.Header{},
+		},
+		{
+			name:     "version only",
+			header:   http.Header{HeaderAgentVersion: []string{"1.0.0"}},
+			expected: Info{Version: "1.0.0", Date: Unknown, Commit: Unknown},
+			ok:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := FromAgentHeaders(tt.header)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
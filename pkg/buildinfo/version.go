@@ -0,0 +1,78 @@
+package buildinfo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxVersionParts is the number of numeric parts of a version: major, minor and patch.
+const maxVersionParts = 3
+
+// CompareVersions compares two versions of the form [v]MAJOR[.MINOR[.PATCH]], where missing parts are zero.
+// Pre-release and build suffixes after "-" or "+" are ignored.
+//
+// Parameters:
+//   - a: The first version.
+//   - b: The second version.
+//
+// Returns:
+//   - int: -1 if a is older than b, 0 if they are equal and 1 if a is newer.
+//   - error: An error if either version cannot be parsed.
+func CompareVersions(a, b string) (int, error) {
+	pa, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	pb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := range pa {
+		switch {
+		case pa[i] < pb[i]:
+			return -1, nil
+		case pa[i] > pb[i]:
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// ValidateVersion checks that a version can be compared with CompareVersions.
+//
+// Parameters:
+//   - version: The version to check.
+//
+// Returns:
+//   - error: An error if the version cannot be parsed.
+func ValidateVersion(version string) error {
+	_, err := parseVersion(version)
+	return err
+}
+
+// parseVersion splits a version into its numeric parts.
+func parseVersion(version string) ([maxVersionParts]int, error) {
+	var parts [maxVersionParts]int
+
+	core := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+	if core == "" {
+		return parts, fmt.Errorf("invalid version %q: no version number", version)
+	}
+
+	fields := strings.Split(core, ".")
+	if len(fields) > maxVersionParts {
+		return parts, fmt.Errorf("invalid version %q: more than %d parts", version, maxVersionParts)
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, fmt.Errorf("invalid version %q: part %q is not a number", version, field)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
@@ -0,0 +1,46 @@
+package buildinfo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		expected int
+		wantErr  bool
+	}{
+		{name: "equal", a: "1.2.3", b: "1.2.3", expected: 0},
+		{name: "v prefix", a: "v1.2.3", b: "1.2.3", expected: 0},
+		{name: "missing parts are zero", a: "1.2", b: "1.2.0", expected: 0},
+		{name: "older patch", a: "1.2.3", b: "1.2.4", expected: -1},
+		{name: "newer minor", a: "1.10.0", b: "1.9.9", expected: 1},
+		{name: "older major", a: "1.99", b: "2", expected: -1},
+		{name: "suffixes ignored", a: "1.2.3-rc.1", b: "1.2.3+build.5", expected: 0},
+		{name: "unknown version", a: Unknown, b: "1.0.0", wantErr: true},
+		{name: "empty version", a: "1.0.0", b: "", wantErr: true},
+		{name: "too many parts", a: "1.2.3.4", b: "1.0.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CompareVersions(tt.a, tt.b)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestValidateVersion(t *testing.T) {
+	assert.NoError(t, ValidateVersion("v2.1"))
+	assert.Error(t, ValidateVersion("latest"))
+}
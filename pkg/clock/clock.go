@@ -0,0 +1,114 @@
+// Package clock abstracts the passing of time behind the Clock interface.
+// Components take a Clock for their tickers, timers and timeouts, so production code uses the real clock
+// while tests drive a Fake clock forward deterministically instead of sleeping.
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the time and creates timers, tickers and timeouts.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// Since returns the time elapsed since t.
+	Since(t time.Time) time.Duration
+	// After returns a channel receiving the current time once d has elapsed.
+	After(d time.Duration) <-chan time.Time
+	// NewTimer creates a Timer firing once after d.
+	NewTimer(d time.Duration) Timer
+	// NewTicker creates a Ticker firing every d. It panics if d is not positive.
+	NewTicker(d time.Duration) Ticker
+	// WithTimeout returns a copy of parent that is canceled with context.DeadlineExceeded once d has elapsed.
+	WithTimeout(parent context.Context, d time.Duration) (context.Context, context.CancelFunc)
+}
+
+// Timer fires once on its channel, like time.Timer.
+type Timer interface {
+	// C returns the channel the timer fires on.
+	C() <-chan time.Time
+	// Stop prevents the timer from firing and reports whether it was active.
+	Stop() bool
+	// Reset changes the timer to fire after d and reports whether it was active.
+	Reset(d time.Duration) bool
+}
+
+// Ticker fires periodically on its channel, like time.Ticker.
+type Ticker interface {
+	// C returns the channel the ticks are delivered on.
+	C() <-chan time.Time
+	// Stop turns the ticker off.
+	Stop()
+	// Reset stops the ticker and restarts it with period d.
+	Reset(d time.Duration)
+}
+
+// Real returns the Clock backed by the time package.
+//
+// Returns:
+//   - Clock: The real clock.
+func Real() Clock {
+	return realClock{}
+}
+
+// realClock implements Clock with the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{timer: time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{ticker: time.NewTicker(d)}
+}
+
+func (realClock) WithTimeout(parent context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(parent, d)
+}
+
+// realTimer adapts time.Timer to Timer.
+type realTimer struct {
+	timer *time.Timer // timer is the adapted timer.
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t realTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+func (t realTimer) Reset(d time.Duration) bool {
+	return t.timer.Reset(d)
+}
+
+// realTicker adapts time.Ticker to Ticker.
+type realTicker struct {
+	ticker *time.Ticker // ticker is the adapted ticker.
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t realTicker) Stop() {
+	t.ticker.Stop()
+}
+
+func (t realTicker) Reset(d time.Duration) {
+	t.ticker.Reset(d)
+}
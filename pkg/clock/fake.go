@@ -0,0 +1,240 @@
+package clock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Fake is a Clock that only moves when told to. Timers, tickers and timeouts created from it fire
+// when Advance or Set moves the time past their deadline, which makes time-dependent code testable
+// without sleeping.
+type Fake struct {
+	now     time.Time   // now is the current fake time.
+	cond    *sync.Cond  // cond signals changes of the waiters to BlockUntil.
+	mu      *sync.Mutex // mu protects now and waiters.
+	waiters []*waiter   // waiters holds the pending timers, tickers and timeouts.
+}
+
+// waiter is a pending timer, ticker or timeout of a Fake clock.
+type waiter struct {
+	at     time.Time           // at is when the waiter fires next.
+	fire   func(now time.Time) // fire is called with the fake time when the waiter fires.
+	period time.Duration       // period is the ticker period, 0 for waiters firing once.
+}
+
+// NewFake creates a Fake clock starting at the given time.
+//
+// Parameters:
+//   - start: The initial fake time.
+//
+// Returns:
+//   - *Fake: A pointer to the created Fake clock.
+func NewFake(start time.Time) *Fake {
+	mu := &sync.Mutex{}
+	return &Fake{now: start, mu: mu, cond: sync.NewCond(mu)}
+}
+
+// Now returns the current fake time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since returns the fake time elapsed since t.
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// After returns a channel receiving the fake time once d has elapsed on the fake clock.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// NewTimer creates a Timer firing once the fake clock has advanced by d.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: f, c: make(chan time.Time, 1)}
+	t.w = &waiter{fire: t.send}
+	t.Reset(d)
+	return t
+}
+
+// NewTicker creates a Ticker firing every time the fake clock advances by d.
+// Like time.Ticker, it drops ticks a slow receiver does not keep up with.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for clock.Fake.NewTicker")
+	}
+	t := &fakeTicker{clock: f, c: make(chan time.Time, 1)}
+	t.w = &waiter{fire: t.send}
+	t.Reset(d)
+	return t
+}
+
+// WithTimeout returns a copy of parent that is canceled with context.DeadlineExceeded once the fake clock
+// has advanced by d.
+func (f *Fake) WithTimeout(parent context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	timeout := &timeoutContext{Context: ctx, deadline: f.Now().Add(d)}
+
+	w := &waiter{at: timeout.deadline, fire: func(time.Time) { cancel(context.DeadlineExceeded) }}
+	f.add(w)
+
+	return timeout, func() {
+		f.remove(w)
+		cancel(context.Canceled)
+	}
+}
+
+// Advance moves the fake time forward by d, firing every waiter whose deadline has passed.
+//
+// Parameters:
+//   - d: The duration to advance by.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the fake time to t, firing every waiter whose deadline has passed. Moving backwards fires nothing.
+//
+// Parameters:
+//   - t: The new fake time.
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if t.Before(f.now) {
+		f.now = t
+		return
+	}
+	f.now = t
+
+	pending := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.at.After(t) {
+			pending = append(pending, w)
+			continue
+		}
+		w.fire(t)
+		if w.period > 0 {
+			for !w.at.After(t) {
+				w.at = w.at.Add(w.period)
+			}
+			pending = append(pending, w)
+		}
+	}
+	f.waiters = pending
+	f.cond.Broadcast()
+}
+
+// BlockUntil waits until at least n timers, tickers or timeouts are pending on the fake clock.
+// It lets a test advance the time only after the code under test started waiting.
+//
+// Parameters:
+//   - n: The number of pending waiters to wait for.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+// add registers a waiter.
+func (f *Fake) add(w *waiter) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.waiters = append(f.waiters, w)
+	f.cond.Broadcast()
+}
+
+// remove unregisters a waiter and reports whether it was pending.
+func (f *Fake) remove(w *waiter) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, pending := range f.waiters {
+		if pending == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			f.cond.Broadcast()
+			return true
+		}
+	}
+	return false
+}
+
+// fakeTimer is a Timer of a Fake clock.
+type fakeTimer struct {
+	clock *Fake          // clock is the clock the timer belongs to.
+	w     *waiter        // w is the registered waiter.
+	c     chan time.Time // c receives the fake time when the timer fires.
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	return t.clock.remove(t.w)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	active := t.clock.remove(t.w)
+	t.w.at = t.clock.Now().Add(d)
+	t.clock.add(t.w)
+	return active
+}
+
+func (t *fakeTimer) send(now time.Time) {
+	select {
+	case t.c <- now:
+	default:
+	}
+}
+
+// fakeTicker is a Ticker of a Fake clock.
+type fakeTicker struct {
+	clock *Fake          // clock is the clock the ticker belongs to.
+	w     *waiter        // w is the registered waiter.
+	c     chan time.Time // c receives the fake time on every tick.
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.remove(t.w)
+}
+
+func (t *fakeTicker) Reset(d time.Duration) {
+	t.clock.remove(t.w)
+	t.w.period = d
+	t.w.at = t.clock.Now().Add(d)
+	t.clock.add(t.w)
+}
+
+func (t *fakeTicker) send(now time.Time) {
+	select {
+	case t.c <- now:
+	default:
+	}
+}
+
+// timeoutContext is a context canceled by a Fake clock, reporting the fake deadline.
+type timeoutContext struct {
+	context.Context
+	deadline time.Time // deadline is the fake time the context is canceled at.
+}
+
+// Deadline returns the fake deadline.
+func (c *timeoutContext) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+// Err returns context.DeadlineExceeded if the fake deadline passed, and the parent error otherwise.
+func (c *timeoutContext) Err() error {
+	if c.Context.Err() == nil {
+		return nil
+	}
+	return context.Cause(c.Context) //nolint:wrapcheck // the cause is a context sentinel error
+}
@@ -0,0 +1,134 @@
+package clock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func fired(c <-chan time.Time) (time.Time, bool) {
+	select {
+	case now := <-c:
+		return now, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFake_Now(t *testing.T) {
+	c := NewFake(start)
+	assert.Equal(t, start, c.Now())
+
+	c.Advance(time.Minute)
+	assert.Equal(t, start.Add(time.Minute), c.Now())
+	assert.Equal(t, time.Minute, c.Since(start))
+}
+
+func TestFake_Timer(t *testing.T) {
+	c := NewFake(start)
+	timer := c.NewTimer(5 * time.Second)
+
+	c.Advance(4 * time.Second)
+	_, ok := fired(timer.C())
+	assert.False(t, ok)
+
+	c.Advance(time.Second)
+	now, ok := fired(timer.C())
+	require.True(t, ok)
+	assert.Equal(t, start.Add(5*time.Second), now)
+	assert.False(t, timer.Stop())
+
+	assert.False(t, timer.Reset(time.Second))
+	assert.True(t, timer.Stop())
+	c.Advance(time.Hour)
+	_, ok = fired(timer.C())
+	assert.False(t, ok)
+}
+
+func TestFake_After(t *testing.T) {
+	c := NewFake(start)
+	after := c.After(time.Second)
+
+	c.Advance(time.Second)
+	_, ok := fired(after)
+	assert.True(t, ok)
+}
+
+func TestFake_Ticker(t *testing.T) {
+	c := NewFake(start)
+	ticker := c.NewTicker(time.Second)
+
+	c.Advance(time.Second)
+	_, ok := fired(ticker.C())
+	assert.True(t, ok)
+
+	// Ticks a receiver does not keep up with are dropped.
+	c.Advance(3 * time.Second)
+	_, ok = fired(ticker.C())
+	assert.True(t, ok)
+	_, ok = fired(ticker.C())
+	assert.False(t, ok)
+
+	ticker.Reset(10 * time.Second)
+	c.Advance(5 * time.Second)
+	_, ok = fired(ticker.C())
+	assert.False(t, ok)
+	c.Advance(5 * time.Second)
+	_, ok = fired(ticker.C())
+	assert.True(t, ok)
+
+	ticker.Stop()
+	c.Advance(time.Minute)
+	_, ok = fired(ticker.C())
+	assert.False(t, ok)
+
+	assert.Panics(t, func() { c.NewTicker(0) })
+}
+
+func TestFake_WithTimeout(t *testing.T) {
+	c := NewFake(start)
+
+	ctx, cancel := c.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.Equal(t, start.Add(5*time.Second), deadline)
+
+	c.Advance(4 * time.Second)
+	require.NoError(t, ctx.Err())
+
+	c.Advance(time.Second)
+	<-ctx.Done()
+	assert.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+}
+
+func TestFake_WithTimeoutCanceled(t *testing.T) {
+	c := NewFake(start)
+
+	ctx, cancel := c.WithTimeout(context.Background(), 5*time.Second)
+	cancel()
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+
+	c.BlockUntil(0)
+	c.Advance(time.Minute)
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+}
+
+func TestFake_BlockUntil(t *testing.T) {
+	c := NewFake(start)
+	done := make(chan struct{})
+
+	go func() {
+		<-c.After(time.Second)
+		close(done)
+	}()
+
+	c.BlockUntil(1)
+	c.Advance(time.Second)
+	<-done
+}
@@ -0,0 +1,163 @@
+// Package configaudit reports where every setting of a configuration came from: its default, a command-line
+// flag, an environment variable or the configuration file. Both binaries log the report at startup and the
+// server serves it from /api/config, so a misconfigured deployment, e.g. a Helm value that never reached
+// the environment, can be spotted without reading the manifests. Secrets are redacted.
+package configaudit
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Redacted replaces the values of secret settings.
+const Redacted = "[REDACTED]"
+
+// Source is where the value of a setting came from.
+type Source string
+
+const (
+	// SourceDefault marks a setting left at its default.
+	SourceDefault Source = "default"
+	// SourceFlag marks a setting given as a command-line flag.
+	SourceFlag Source = "flag"
+	// SourceEnv marks a setting given as an environment variable.
+	SourceEnv Source = "env"
+	// SourceFile marks a setting read from the configuration file.
+	SourceFile Source = "file"
+)
+
+// secretHints are the parts of setting names whose values are redacted.
+var secretHints = []string{"key", "password", "token", "secret", "dsn", "quota", "webhook"}
+
+// Entry describes one setting.
+type Entry struct {
+	Value  any    `json:"value"`          // Value is the effective value, or Redacted for set secrets.
+	Name   string `json:"name"`           // Name is the name of the setting in the configuration file.
+	Env    string `json:"env,omitempty"`  // Env is the environment variable of the setting.
+	Flag   string `json:"flag,omitempty"` // Flag is the command-line flag of the setting.
+	Source Source `json:"source"`         // Source is where the value came from.
+}
+
+// String formats the entry for logs, e.g. `server_address="localhost:8080" (env ADDRESS)`.
+//
+// Returns:
+//   - string: The formatted entry.
+func (e Entry) String() string {
+	value := fmt.Sprintf("%v", e.Value)
+	if s, ok := e.Value.(string); ok {
+		value = fmt.Sprintf("%q", s)
+	}
+	switch e.Source {
+	case SourceEnv:
+		return fmt.Sprintf("%s=%s (env %s)", e.Name, value, e.Env)
+	case SourceFlag:
+		return fmt.Sprintf("%s=%s (flag -%s)", e.Name, value, e.Flag)
+	default:
+		return fmt.Sprintf("%s=%s (%s)", e.Name, value, e.Source)
+	}
+}
+
+// Audit describes every exported field of a configuration struct parsed the way both binaries parse theirs:
+// flags over defaults, environment variables over flags, and the file filling in the settings left
+// at their defaults. Settings are named after their json tags and matched to environment variables
+// by their env tags, and to flags by the field address the flag was bound to with flag.StringVar and the like.
+//
+// Parameters:
+//   - cfg: A pointer to the parsed configuration, the one the flags were bound to.
+//   - defaults: The default configuration of the same type.
+//   - flags: The parsed flag set, usually flag.CommandLine.
+//   - lookupEnv: The environment lookup, usually os.LookupEnv.
+//
+// Returns:
+//   - []Entry: The settings in field order.
+func Audit(cfg, defaults any, flags *flag.FlagSet, lookupEnv func(string) (string, bool)) []Entry {
+	v := reflect.ValueOf(cfg).Elem()
+	d := reflect.Indirect(reflect.ValueOf(defaults))
+
+	bound := make(map[uintptr]string)
+	flags.VisitAll(func(f *flag.Flag) {
+		if p := reflect.ValueOf(f.Value); p.Kind() == reflect.Pointer {
+			bound[p.Pointer()] = f.Name
+		}
+	})
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	entries := make([]Entry, 0, v.NumField())
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		value := v.Field(i)
+		e := Entry{
+			Name:  tagName(field.Tag.Get("json"), field.Name),
+			Env:   tagName(field.Tag.Get("env"), ""),
+			Flag:  bound[value.Addr().Pointer()],
+			Value: value.Interface(),
+		}
+
+		switch {
+		case e.Env != "" && inEnv(lookupEnv, e.Env):
+			e.Source = SourceEnv
+		case e.Flag != "" && set[e.Flag]:
+			e.Source = SourceFlag
+		case !reflect.DeepEqual(value.Interface(), d.Field(i).Interface()):
+			e.Source = SourceFile
+		default:
+			e.Source = SourceDefault
+		}
+		if secret(e.Name) && isText(value) && !value.IsZero() {
+			e.Value = Redacted
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// Log writes the settings as one startup log section, a line per setting.
+//
+// Parameters:
+//   - logger: The logger to write to.
+//   - entries: The settings reported by Audit.
+func Log(logger *zap.SugaredLogger, entries []Entry) {
+	logger.Infof("Configuration (%d settings):", len(entries))
+	for _, e := range entries {
+		logger.Infof("  %s", e)
+	}
+}
+
+// inEnv reports whether the environment variable is set.
+func inEnv(lookupEnv func(string) (string, bool), name string) bool {
+	_, ok := lookupEnv(name)
+	return ok
+}
+
+// isText reports whether a value is a string or a list of strings, the only kinds secrets are stored as.
+func isText(v reflect.Value) bool {
+	return v.Kind() == reflect.String || (v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String)
+}
+
+// tagName returns the name part of a struct tag value, or fallback if the tag is empty.
+func tagName(tag, fallback string) string {
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" || name == "-" {
+		return fallback
+	}
+	return name
+}
+
+// secret reports whether the setting with the given name holds a secret.
+func secret(name string) bool {
+	name = strings.ToLower(name)
+	for _, hint := range secretHints {
+		if strings.Contains(name, hint) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package configaudit
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type testConfig struct {
+	Address  string   `env:"ADDRESS"      json:"address,omitempty"`
+	APIKey   string   `env:"API_KEY"      json:"api_key,omitempty"`
+	Store    string   `env:"STORE"        json:"store,omitempty"`
+	Tags     []string `env:"TAGS"         json:"tags,omitempty"`
+	Interval int      `env:"INTERVAL"     json:"interval,omitempty"`
+	KeyFetch bool     `env:"KEY_FETCH"    json:"key_fetch,omitempty"`
+	Verbose  bool     `json:"verbose,omitempty"`
+}
+
+func TestAudit(t *testing.T) {
+	defaults := testConfig{Address: "localhost:8080", Interval: 10}
+	cfg := defaults
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.StringVar(&cfg.Address, "a", cfg.Address, "")
+	flags.IntVar(&cfg.Interval, "i", cfg.Interval, "")
+	flags.BoolVar(&cfg.Verbose, "v", cfg.Verbose, "")
+	flags.BoolVar(&cfg.KeyFetch, "key-fetch", cfg.KeyFetch, "")
+	require.NoError(t, flags.Parse([]string{"-a", "flaghost:1", "-i", "20", "-key-fetch"}))
+
+	// The environment overrides the address flag, and the file sets the store and the tags.
+	cfg.Address = "envhost:2"
+	cfg.APIKey = "s3cr3t"
+	cfg.Store = "/tmp/metrics.json"
+	cfg.Tags = []string{"a"}
+	env := map[string]string{"ADDRESS": "envhost:2", "API_KEY": "s3cr3t"}
+	lookupEnv := func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+
+	entries := Audit(&cfg, defaults, flags, lookupEnv)
+	assert.Equal(t, []Entry{
+		{Name: "address", Env: "ADDRESS", Flag: "a", Value: "envhost:2", Source: SourceEnv},
+		{Name: "api_key", Env: "API_KEY", Value: Redacted, Source: SourceEnv},
+		{Name: "store", Env: "STORE", Value: "/tmp/metrics.json", Source: SourceFile},
+		{Name: "tags", Env: "TAGS", Value: []string{"a"}, Source: SourceFile},
+		{Name: "interval", Env: "INTERVAL", Flag: "i", Value: 20, Source: SourceFlag},
+		{Name: "key_fetch", Env: "KEY_FETCH", Flag: "key-fetch", Value: true, Source: SourceFlag},
+		{Name: "verbose", Flag: "v", Value: false, Source: SourceDefault},
+	}, entries)
+}
+
+func TestEntry_String(t *testing.T) {
+	assert.Equal(t, `address="h:1" (env ADDRESS)`,
+		Entry{Name: "address", Env: "ADDRESS", Value: "h:1", Source: SourceEnv}.String())
+	assert.Equal(t, `interval=20 (flag -i)`, Entry{Name: "interval", Flag: "i", Value: 20, Source: SourceFlag}.String())
+	assert.Equal(t, `tags=[a b] (file)`, Entry{Name: "tags", Value: []string{"a", "b"}, Source: SourceFile}.String())
+	assert.Equal(t, `verbose=false (default)`, Entry{Name: "verbose", Value: false, Source: SourceDefault}.String())
+}
+
+func TestLog(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	Log(zap.New(core).Sugar(), []Entry{{Name: "address", Env: "ADDRESS", Value: "h:1", Source: SourceEnv}})
+
+	messages := make([]string, 0, logs.Len())
+	for _, entry := range logs.All() {
+		messages = append(messages, entry.Message)
+	}
+	assert.Equal(t, []string{"Configuration (1 settings):", `  address="h:1" (env ADDRESS)`}, messages)
+}
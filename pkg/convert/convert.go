@@ -1,6 +1,6 @@
 // Package convert provides utility functions for numeric conversions.
-// It includes functions to convert integer values representing seconds into time.Duration
-// and to convert values of various numeric types to int64. The functions are designed to be simple,
+// It includes functions to convert integer values representing seconds or milliseconds into time.Duration
+// and to convert values of various numeric types to int64 or float64. The functions are designed to be simple,
 // ensuring that the project has a straightforward mechanism for numeric conversions.
 package convert
 
@@ -23,6 +23,18 @@ func IntegerToSeconds[T constraints.Integer](seconds T) time.Duration {
 	return time.Duration(seconds) * time.Second
 }
 
+// IntegerToMilliseconds converts an integer representing milliseconds into a time.Duration.
+// It multiplies the provided integer by time.Millisecond.
+//
+// Parameters:
+//   - milliseconds: An integer value representing milliseconds.
+//
+// Returns:
+//   - time.Duration: The equivalent duration in milliseconds.
+func IntegerToMilliseconds[T constraints.Integer](milliseconds T) time.Duration {
+	return time.Duration(milliseconds) * time.Millisecond
+}
+
 // AnyToInt64 converts various numeric types to int64.
 // It supports int64, float64, int, and uint types. If the conversion is unsupported,
 // it returns an error indicating the input value's type.
@@ -47,3 +59,28 @@ func AnyToInt64[T any](number T) (int64, error) {
 		return 0, fmt.Errorf("type %T is unsupported for conversion to int64", v)
 	}
 }
+
+// AnyToFloat64 converts various numeric types to float64.
+// It supports float64, int64, int, and uint types. If the conversion is unsupported,
+// it returns an error indicating the input value's type.
+//
+// Parameters:
+//   - number: A value of any type to be converted to float64.
+//
+// Returns:
+//   - float64: The converted value if successful.
+//   - error: An error if the conversion is not possible.
+func AnyToFloat64[T any](number T) (float64, error) {
+	switch v := any(number).(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	default:
+		return 0, fmt.Errorf("type %T is unsupported for conversion to float64", v)
+	}
+}
@@ -27,6 +27,26 @@ func TestIntegerToSeconds(t *testing.T) {
 	}
 }
 
+func TestIntegerToMilliseconds(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    int
+		expected time.Duration
+	}{
+		{name: "Zero milliseconds"},
+		{name: "One millisecond", input: 1, expected: time.Millisecond},
+		{name: "Half a second", input: 500, expected: 500 * time.Millisecond},
+		{name: "Negative value", input: -5, expected: -5 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := IntegerToMilliseconds(tt.input)
+			assert.Equal(t, tt.expected, actual, "Failed for input %d", tt.input)
+		})
+	}
+}
+
 func TestAnyToInt64(t *testing.T) {
 	tests := []struct {
 		input     interface{}
@@ -55,3 +75,32 @@ func TestAnyToInt64(t *testing.T) {
 		})
 	}
 }
+
+func TestAnyToFloat64(t *testing.T) {
+	tests := []struct {
+		input     interface{}
+		name      string
+		expected  float64
+		expectErr bool
+	}{
+		{name: "Valid float64", input: float64(42.9), expected: 42.9},
+		{name: "Valid int64", input: int64(42), expected: 42},
+		{name: "Valid int", input: int(10), expected: 10},
+		{name: "Valid uint", input: uint(20), expected: 20},
+		{name: "Invalid string", input: "invalid", expectErr: true},
+		{name: "Invalid struct", input: struct{}{}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := AnyToFloat64(tt.input)
+
+			if tt.expectErr {
+				assert.Error(t, err, "Expected error for input %v", tt.input)
+			} else {
+				assert.NoError(t, err, "Did not expect error for input %v", tt.input)
+				assert.InDelta(t, tt.expected, actual, 0, "Failed for input %v", tt.input)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+// Package goroutines samples the running goroutines grouped by where they are blocked and where they were
+// started, and diffs every sample against the previous one. A group that keeps growing between samples,
+// e.g. one goroutine per ticker or per pooled connection that never ends, points at a leak.
+package goroutines
+
+import (
+	"bytes"
+	"cmp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdyunin/metricol.git/pkg/clock"
+)
+
+const (
+	// Const initialStackBuffer is the size of the first buffer the stacks are dumped into; it doubles until
+	// the dump fits.
+	initialStackBuffer = 64 << 10
+	// Const createdByPrefix starts the line naming the function that started a goroutine.
+	createdByPrefix = "created by "
+	// Const unknownFunction names the frames a stack dump does not carry.
+	unknownFunction = "unknown"
+)
+
+// Group is a set of goroutines running the same function and started by the same function.
+type Group struct {
+	Function  string `json:"function"`   // Function is the function on top of the stack.
+	CreatedBy string `json:"created_by"` // CreatedBy is the function that started the goroutines.
+	State     string `json:"state"`      // State is the state of the first goroutine of the group, e.g. "select".
+	Count     int    `json:"count"`      // Count is the number of goroutines in the group.
+	Delta     int    `json:"delta"`      // Delta is the change of Count since the previous sample.
+}
+
+// Sample is the outcome of sampling the goroutines.
+type Sample struct {
+	Taken  time.Time  `json:"taken"`           // Taken is when the sample was taken.
+	Since  *time.Time `json:"since,omitempty"` // Since is when the previous sample was taken, nil for the first one.
+	Groups []Group    `json:"groups"`          // Groups are sorted by growth, then by size.
+	Total  int        `json:"total"`           // Total is the number of goroutines.
+	Delta  int        `json:"delta"`           // Delta is the change of Total since the previous sample.
+}
+
+// groupKey identifies a group of goroutines.
+type groupKey struct {
+	function  string // function is the function on top of the stack.
+	createdBy string // createdBy is the function that started the goroutines.
+}
+
+// Sampler takes samples of the running goroutines and remembers the last one to diff the next against.
+type Sampler struct {
+	clock clock.Clock      // clock stamps the samples.
+	dump  func() []byte    // dump returns the stacks of all goroutines as formatted by runtime.Stack.
+	last  map[groupKey]int // last holds the group sizes of the previous sample.
+	taken time.Time        // taken is when the previous sample was taken.
+	mu    sync.Mutex       // mu serializes the samples.
+}
+
+// NewSampler creates a sampler of the goroutines of the process.
+//
+// Parameters:
+//   - clk: The clock stamping the samples.
+//
+// Returns:
+//   - *Sampler: The sampler.
+func NewSampler(clk clock.Clock) *Sampler {
+	return &Sampler{clock: clk, dump: allStacks}
+}
+
+// Sample groups the running goroutines and diffs the groups against the previous sample. Groups that
+// have ended since then are reported with a count of zero.
+//
+// Returns:
+//   - Sample: The sample.
+func (s *Sampler) Sample() Sample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	groups, states, total := parse(s.dump())
+	sample := Sample{Taken: s.clock.Now(), Total: total, Groups: make([]Group, 0, len(groups))}
+	if s.last != nil {
+		since := s.taken
+		sample.Since = &since
+	}
+
+	lastTotal := 0
+	for key, count := range s.last {
+		lastTotal += count
+		if _, ok := groups[key]; !ok {
+			sample.Groups = append(sample.Groups, Group{Function: key.function, CreatedBy: key.createdBy, Delta: -count})
+		}
+	}
+	for key, count := range groups {
+		sample.Groups = append(sample.Groups, Group{
+			Function:  key.function,
+			CreatedBy: key.createdBy,
+			State:     states[key],
+			Count:     count,
+			Delta:     count - s.last[key],
+		})
+	}
+	if s.last != nil {
+		sample.Delta = total - lastTotal
+	}
+	slices.SortFunc(sample.Groups, func(a, b Group) int {
+		return cmp.Or(
+			cmp.Compare(b.Delta, a.Delta),
+			cmp.Compare(b.Count, a.Count),
+			strings.Compare(a.Function, b.Function),
+			strings.Compare(a.CreatedBy, b.CreatedBy),
+		)
+	})
+
+	s.last = groups
+	s.taken = sample.Taken
+	return sample
+}
+
+// parse groups the goroutines of a stack dump.
+//
+// Parameters:
+//   - dump: The stacks as formatted by runtime.Stack.
+//
+// Returns:
+//   - map[groupKey]int: The group sizes.
+//   - map[groupKey]string: The state of the first goroutine of every group.
+//   - int: The number of goroutines.
+func parse(dump []byte) (map[groupKey]int, map[groupKey]string, int) {
+	groups := make(map[groupKey]int)
+	states := make(map[groupKey]string)
+	total := 0
+	for _, block := range bytes.Split(bytes.TrimSpace(dump), []byte("\n\n")) {
+		lines := strings.Split(string(block), "\n")
+		if !strings.HasPrefix(lines[0], "goroutine ") {
+			continue
+		}
+		total++
+
+		key := groupKey{function: unknownFunction, createdBy: unknownFunction}
+		if len(lines) > 1 {
+			key.function = functionName(lines[1])
+		}
+		for _, line := range lines[1:] {
+			if rest, ok := strings.CutPrefix(line, createdByPrefix); ok {
+				key.createdBy = functionName(rest)
+			}
+		}
+		groups[key]++
+		if _, ok := states[key]; !ok {
+			states[key] = state(lines[0])
+		}
+	}
+	return groups, states, total
+}
+
+// functionName strips the arguments and the parent goroutine from a frame of a stack dump,
+// e.g. "main.worker(0xc000010000)" or "main.start in goroutine 1" become "main.worker" and "main.start".
+//
+// Parameters:
+//   - frame: The frame.
+//
+// Returns:
+//   - string: The function name.
+func functionName(frame string) string {
+	frame, _, _ = strings.Cut(frame, " in goroutine ")
+	if i := strings.LastIndex(frame, "("); i > 0 && strings.HasSuffix(frame, ")") {
+		frame = frame[:i]
+	}
+	return frame
+}
+
+// state extracts the state from the header of a goroutine, e.g. "select" from
+// "goroutine 7 [select, 2 minutes]:".
+//
+// Parameters:
+//   - header: The header line.
+//
+// Returns:
+//   - string: The state, empty if the header has none.
+func state(header string) string {
+	_, rest, ok := strings.Cut(header, "[")
+	if !ok {
+		return ""
+	}
+	rest, _, _ = strings.Cut(rest, "]")
+	rest, _, _ = strings.Cut(rest, ",")
+	return rest
+}
+
+// allStacks dumps the stacks of all goroutines.
+//
+// Returns:
+//   - []byte: The stacks as formatted by runtime.Stack.
+func allStacks() []byte {
+	buf := make([]byte, initialStackBuffer)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf)) //nolint:mnd // The buffer doubles until the dump fits.
+	}
+}
@@ -0,0 +1,117 @@
+package goroutines
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	worker = `goroutine 7 [select, 2 minutes]:
+main.worker(0xc000010000)
+	/src/main.go:20 +0x45
+created by main.start in goroutine 1
+	/src/main.go:10 +0x25`
+	ticker = `goroutine 9 [chan receive]:
+main.tick()
+	/src/main.go:30 +0x20
+created by main.start in goroutine 1
+	/src/main.go:11 +0x25`
+	mainGoroutine = `goroutine 1 [running]:
+main.main()
+	/src/main.go:5 +0x10`
+)
+
+// dumpOf joins goroutine stacks the way runtime.Stack does.
+func dumpOf(stacks ...string) []byte {
+	dump := ""
+	for i, s := range stacks {
+		if i > 0 {
+			dump += "\n\n"
+		}
+		dump += s
+	}
+	return []byte(dump + "\n")
+}
+
+func TestSampler_Sample(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	dumps := [][]byte{
+		dumpOf(mainGoroutine, worker, ticker),
+		dumpOf(mainGoroutine, worker, worker, worker),
+	}
+	s := &Sampler{clock: clk, dump: func() []byte {
+		d := dumps[0]
+		dumps = dumps[1:]
+		return d
+	}}
+
+	first := s.Sample()
+	assert.Nil(t, first.Since)
+	assert.Equal(t, 3, first.Total)
+	assert.Equal(t, 0, first.Delta)
+	require.Len(t, first.Groups, 3)
+
+	taken := clk.Now()
+	clk.Advance(time.Minute)
+	second := s.Sample()
+	require.NotNil(t, second.Since)
+	assert.Equal(t, taken, *second.Since)
+	assert.Equal(t, 4, second.Total)
+	assert.Equal(t, 1, second.Delta)
+	assert.Equal(t, []Group{
+		{Function: "main.worker", CreatedBy: "main.start", State: "select", Count: 3, Delta: 2},
+		{Function: "main.main", CreatedBy: unknownFunction, State: "running", Count: 1, Delta: 0},
+		{Function: "main.tick", CreatedBy: "main.start", Count: 0, Delta: -1},
+	}, second.Groups)
+}
+
+// park reports that it runs and blocks until stop is closed.
+func park(wg *sync.WaitGroup, running chan<- struct{}, stop <-chan struct{}) {
+	defer wg.Done()
+	running <- struct{}{}
+	<-stop
+}
+
+func TestSampler_Real(t *testing.T) {
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+	s := NewSampler(clock.Real())
+	s.Sample()
+
+	running := make(chan struct{})
+	for range 3 {
+		wg.Add(1)
+		go park(&wg, running, stop)
+		<-running
+	}
+	sample := s.Sample()
+
+	assert.GreaterOrEqual(t, sample.Delta, 3)
+	require.NotEmpty(t, sample.Groups)
+	assert.Equal(t, "github.com/gdyunin/metricol.git/pkg/goroutines.park", sample.Groups[0].Function)
+	assert.Equal(t, 3, sample.Groups[0].Count)
+	assert.Equal(t, 3, sample.Groups[0].Delta)
+}
+
+func TestFunctionName(t *testing.T) {
+	tests := map[string]string{
+		"main.worker(0xc000010000, 0x1)":           "main.worker",
+		"main.start in goroutine 1":                "main.start",
+		"net/http.(*Server).Serve(0xc000200000)":   "net/http.(*Server).Serve",
+		"main.(*pool).run(...)":                    "main.(*pool).run",
+		"github.com/x/y.Func[...](0x1)":            "github.com/x/y.Func[...]",
+		"main.(*pool).start.func1 in goroutine 20": "main.(*pool).start.func1",
+	}
+	for frame, want := range tests {
+		assert.Equal(t, want, functionName(frame), frame)
+	}
+}
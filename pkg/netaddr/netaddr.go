@@ -0,0 +1,155 @@
+// Package netaddr validates the network addresses used in configuration: listen addresses of the server
+// and the agent endpoints, and the server address the agent connects to. IPv6 literals are supported
+// in their bracketed form, e.g. "[::1]:8080"; "[::]:8080" listens on all interfaces over both IPv4 and IPv6.
+package netaddr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ErrUnbracketedIPv6 is reported for an IPv6 literal written without brackets around it.
+var ErrUnbracketedIPv6 = errors.New("IPv6 literals must be enclosed in brackets, e.g. \"[::1]:8080\"")
+
+// ValidateListen checks an address to listen on in the host:port form. The host may be empty to listen on
+// all interfaces, a host name, an IPv4 literal or a bracketed IPv6 literal.
+//
+// Parameters:
+//   - addr: The address, e.g. "localhost:8080", ":8080" or "[::]:8080".
+//
+// Returns:
+//   - error: An error describing why the address is invalid; nil if it is valid.
+func ValidateListen(addr string) error {
+	host, port, err := splitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if err := validatePort(port); err != nil {
+		return err
+	}
+	return validateHost(host)
+}
+
+// ValidateServer checks the address of a server to connect to: host[:port] with an optional
+// http:// or https:// scheme and path, where host is a host name, an IPv4 literal or a bracketed IPv6 literal.
+//
+// Parameters:
+//   - addr: The address, e.g. "localhost:8080", "http://[2001:db8::1]:8080" or "https://metrics.example.com".
+//
+// Returns:
+//   - error: An error describing why the address is invalid; nil if it is valid.
+func ValidateServer(addr string) error {
+	hostPort := strings.TrimPrefix(strings.TrimPrefix(addr, "http://"), "https://")
+	if i := strings.IndexAny(hostPort, "/?#"); i >= 0 {
+		hostPort = hostPort[:i]
+	}
+	if hostPort == "" {
+		return fmt.Errorf("address %q has no host", addr)
+	}
+
+	host := hostPort
+	switch {
+	case isIPv6(hostPort):
+		return fmt.Errorf("address %q: %w", addr, ErrUnbracketedIPv6)
+	case strings.HasPrefix(hostPort, "[") && strings.HasSuffix(hostPort, "]"):
+		// A bracketed IPv6 literal without a port.
+		host = hostPort[1 : len(hostPort)-1]
+		if !isIPv6(host) {
+			return fmt.Errorf("invalid IPv6 literal %q", hostPort)
+		}
+	case strings.Contains(hostPort, ":"):
+		var port string
+		var err error
+		if host, port, err = splitHostPort(hostPort); err != nil {
+			return err
+		}
+		if err := validatePort(port); err != nil {
+			return err
+		}
+	}
+	if host == "" {
+		return fmt.Errorf("address %q has no host", addr)
+	}
+	return validateHost(host)
+}
+
+// IsLoopback reports whether a listen address in the host:port form is bound to the loopback interface
+// only, i.e. its host is "localhost" or a loopback IP literal. An empty host listens on all interfaces.
+//
+// Parameters:
+//   - addr: The address, e.g. "127.0.0.1:8081" or "[::1]:8081".
+//
+// Returns:
+//   - bool: True if only local clients can connect to the address.
+func IsLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// splitHostPort splits host:port, reporting unbracketed IPv6 literals with a hint.
+func splitHostPort(addr string) (string, string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		if strings.Count(addr, ":") > 1 && !strings.HasPrefix(addr, "[") {
+			return "", "", fmt.Errorf("address %q: %w", addr, ErrUnbracketedIPv6)
+		}
+		return "", "", fmt.Errorf("address %q is not in the host:port form: %w", addr, err)
+	}
+	return host, port, nil
+}
+
+// validatePort checks that port is a number between 0 and 65535.
+func validatePort(port string) error {
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 0 || n > math.MaxUint16 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+// validateHost checks that host is empty, an IP literal or a host name.
+func validateHost(host string) error {
+	if host == "" || net.ParseIP(host) != nil {
+		return nil
+	}
+	if i := strings.IndexByte(host, '%'); i > 0 && net.ParseIP(host[:i]) != nil {
+		// A link-local IPv6 literal with a zone, e.g. "fe80::1%eth0".
+		return nil
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return fmt.Errorf("invalid host %q", host)
+		}
+		for _, r := range label {
+			if !isHostRune(r) {
+				return fmt.Errorf("invalid host %q", host)
+			}
+		}
+	}
+	return nil
+}
+
+// isHostRune reports whether r may appear in a host name label.
+func isHostRune(r rune) bool {
+	return r == '-' || r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// isIPv6 reports whether s is an IPv6 literal without brackets, optionally with a zone.
+func isIPv6(s string) bool {
+	if i := strings.IndexByte(s, '%'); i > 0 {
+		s = s[:i]
+	}
+	ip := net.ParseIP(s)
+	return ip != nil && strings.Contains(s, ":")
+}
@@ -0,0 +1,88 @@
+package netaddr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateListen(t *testing.T) {
+	tests := []struct {
+		name    string
+		addr    string
+		wantErr error
+		invalid bool
+	}{
+		{name: "host name", addr: "localhost:8080"},
+		{name: "all interfaces", addr: ":8080"},
+		{name: "IPv4 literal", addr: "127.0.0.1:8080"},
+		{name: "dual stack", addr: "[::]:8080"},
+		{name: "IPv6 loopback", addr: "[::1]:0"},
+		{name: "IPv6 with zone", addr: "[fe80::1%eth0]:8080"},
+		{name: "unbracketed IPv6", addr: "::1:8080", wantErr: ErrUnbracketedIPv6},
+		{name: "missing port", addr: "localhost", invalid: true},
+		{name: "port out of range", addr: "localhost:65536", invalid: true},
+		{name: "named port", addr: "localhost:http", invalid: true},
+		{name: "invalid host", addr: "local host:8080", invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateListen(tt.addr)
+			switch {
+			case tt.wantErr != nil:
+				require.ErrorIs(t, err, tt.wantErr)
+			case tt.invalid:
+				require.Error(t, err)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		addr    string
+		wantErr error
+		invalid bool
+	}{
+		{name: "host and port", addr: "localhost:8080"},
+		{name: "host only", addr: "metrics.example.com"},
+		{name: "scheme and path", addr: "https://metrics.example.com/metricol"},
+		{name: "IPv6 with port", addr: "[2001:db8::1]:8080"},
+		{name: "IPv6 with scheme", addr: "http://[::1]:8080/"},
+		{name: "IPv6 without port", addr: "[::1]"},
+		{name: "unbracketed IPv6", addr: "2001:db8::1", wantErr: ErrUnbracketedIPv6},
+		{name: "unbracketed IPv6 with port", addr: "http://::1:8080", wantErr: ErrUnbracketedIPv6},
+		{name: "bracketed host name", addr: "[localhost]", invalid: true},
+		{name: "empty host", addr: "http://:8080", invalid: true},
+		{name: "empty", addr: "", invalid: true},
+		{name: "invalid port", addr: "localhost:80a", invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateServer(tt.addr)
+			switch {
+			case tt.wantErr != nil:
+				require.ErrorIs(t, err, tt.wantErr)
+			case tt.invalid:
+				require.Error(t, err)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestIsLoopback(t *testing.T) {
+	for _, addr := range []string{"localhost:8081", "127.0.0.1:8081", "127.0.0.2:8081", "[::1]:8081"} {
+		assert.True(t, IsLoopback(addr), addr)
+	}
+	for _, addr := range []string{":8081", "0.0.0.0:8081", "[::]:8081", "10.0.0.1:8081", "admin.example.com:8081", ""} {
+		assert.False(t, IsLoopback(addr), addr)
+	}
+}
@@ -0,0 +1,245 @@
+// Package promtext parses the Prometheus text exposition format and names series the way the rest of the
+// project stores them. It is shared by the server, which accepts Pushgateway pushes, and by the agent, which
+// scrapes the /metrics endpoints of co-located applications.
+package promtext
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// metricNamePattern matches valid Prometheus metric names.
+	metricNamePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	// labelNamePattern matches valid Prometheus label names.
+	labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Sample is one line of the Prometheus text exposition format.
+type Sample struct {
+	Labels       map[string]string // Labels are the labels of the sample.
+	Name         string            // Name is the metric name, including suffixes such as _bucket or _sum.
+	Value        float64           // Value is the sample value.
+	Timestamp    int64             // Timestamp is the optional sample time in milliseconds since the epoch.
+	HasTimestamp bool              // HasTimestamp reports whether the sample line carried a timestamp.
+}
+
+// Parse parses the Prometheus text exposition format. Comments, including HELP and TYPE lines, are skipped:
+// the metric type does not change how a sample is stored.
+//
+// Parameters:
+//   - r: The reader of the exposition.
+//
+// Returns:
+//   - []Sample: The parsed samples.
+//   - error: An error naming the first malformed line.
+func Parse(r io.Reader) ([]Sample, error) {
+	var samples []Sample
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		s, err := parseSample(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		samples = append(samples, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read exposition: %w", err)
+	}
+	return samples, nil
+}
+
+// parseSample parses a sample line: a metric name, optional labels in braces, a value and an optional timestamp.
+func parseSample(line string) (Sample, error) {
+	end := strings.IndexAny(line, "{ \t")
+	if end < 0 {
+		return Sample{}, fmt.Errorf("missing value in %q", line)
+	}
+	s := Sample{Name: line[:end], Labels: map[string]string{}}
+	if !metricNamePattern.MatchString(s.Name) {
+		return Sample{}, fmt.Errorf("invalid metric name %q", s.Name)
+	}
+
+	rest := line[end:]
+	if strings.HasPrefix(rest, "{") {
+		var err error
+		if rest, err = parseLabels(rest[1:], s.Labels); err != nil {
+			return Sample{}, fmt.Errorf("metric %q: %w", s.Name, err)
+		}
+	}
+
+	fields := strings.Fields(rest)
+	switch len(fields) {
+	case 1:
+	case 2:
+		ts, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return Sample{}, fmt.Errorf("metric %q: invalid timestamp %q", s.Name, fields[1])
+		}
+		s.Timestamp, s.HasTimestamp = ts, true
+	default:
+		return Sample{}, fmt.Errorf("metric %q: expected a value, got %q", s.Name, strings.TrimSpace(rest))
+	}
+
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return Sample{}, fmt.Errorf("metric %q: invalid value %q", s.Name, fields[0])
+	}
+	s.Value = value
+	return s, nil
+}
+
+// parseLabels parses `name="value",...}` into labels and returns the text after the closing brace.
+func parseLabels(text string, labels map[string]string) (string, error) {
+	for {
+		text = strings.TrimLeft(text, " \t")
+		if strings.HasPrefix(text, "}") {
+			return text[1:], nil
+		}
+
+		eq := strings.IndexByte(text, '=')
+		if eq < 0 {
+			return "", errors.New("unterminated labels")
+		}
+		name := strings.TrimSpace(text[:eq])
+		if !labelNamePattern.MatchString(name) {
+			return "", fmt.Errorf("invalid label name %q", name)
+		}
+		text = strings.TrimLeft(text[eq+1:], " \t")
+		if !strings.HasPrefix(text, `"`) {
+			return "", fmt.Errorf("label %q: value must be quoted", name)
+		}
+
+		value, rest, err := unquoteLabelValue(text[1:])
+		if err != nil {
+			return "", fmt.Errorf("label %q: %w", name, err)
+		}
+		labels[name] = value
+
+		text = strings.TrimLeft(rest, " \t")
+		text = strings.TrimPrefix(text, ",")
+	}
+}
+
+// unquoteLabelValue reads a label value up to the closing quote, resolving the \\, \" and \n escapes.
+func unquoteLabelValue(text string) (string, string, error) {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '"':
+			return b.String(), text[i+1:], nil
+		case '\\':
+			i++
+			if i == len(text) {
+				return "", "", errors.New("unterminated value")
+			}
+			switch text[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case '\\', '"':
+				b.WriteByte(text[i])
+			default:
+				return "", "", fmt.Errorf("invalid escape \\%c", text[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", errors.New("unterminated value")
+}
+
+// ValidLabelName reports whether name is a valid Prometheus label name.
+//
+// Parameters:
+//   - name: The label name to check.
+//
+// Returns:
+//   - bool: True if the name is valid.
+func ValidLabelName(name string) bool {
+	return labelNamePattern.MatchString(name)
+}
+
+// SeriesName builds the name a sample is stored under: the metric name followed by the sorted labels
+// in the Prometheus series notation, e.g. `http_requests_total{code="200",job="api"}`.
+//
+// Parameters:
+//   - name: The metric name.
+//   - labels: The labels of the series.
+//
+// Returns:
+//   - string: The series name; the bare metric name if there are no labels.
+func SeriesName(name string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return name
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteString(`="`)
+		b.WriteString(labelValueEscaper.Replace(labels[k]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// SplitSeriesName splits a series name built by SeriesName into the metric name and its labels.
+//
+// Parameters:
+//   - series: The series name, e.g. `http_requests_total{code="200"}`.
+//
+// Returns:
+//   - string: The metric name.
+//   - map[string]string: The labels; empty if the series has none.
+//   - error: An error if the labels are malformed.
+func SplitSeriesName(series string) (string, map[string]string, error) {
+	labels := map[string]string{}
+	name, rest, found := strings.Cut(series, "{")
+	if !found {
+		return series, labels, nil
+	}
+	rest, err := parseLabels(rest, labels)
+	if err != nil {
+		return "", nil, err
+	}
+	if rest != "" {
+		return "", nil, fmt.Errorf("unexpected %q after labels", rest)
+	}
+	return name, labels, nil
+}
+
+// labelValueEscaper escapes label values in series names.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// IsFinite reports whether a value can be stored; NaN and infinities cannot be encoded in JSON.
+//
+// Parameters:
+//   - v: The sample value.
+//
+// Returns:
+//   - bool: True if the value is neither NaN nor infinite.
+func IsFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
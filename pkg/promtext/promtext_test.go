@@ -0,0 +1,111 @@
+package promtext
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		expected    []Sample
+		expectError bool
+	}{
+		{
+			name: "Samples with comments",
+			body: "# HELP jobs_total Processed jobs.\n# TYPE jobs_total counter\n" +
+				"jobs_total 42\n\nlast_run_seconds{stage=\"load\", host=\"a\"} 1.5e3\n",
+			expected: []Sample{
+				{Name: "jobs_total", Labels: map[string]string{}, Value: 42},
+				{Name: "last_run_seconds", Labels: map[string]string{"stage": "load", "host": "a"}, Value: 1500},
+			},
+		},
+		{
+			name: "Escaped label values",
+			body: `path{dir="C:\\tmp",msg="say \"hi\"\nbye",} 1`,
+			expected: []Sample{
+				{Name: "path", Labels: map[string]string{"dir": `C:\tmp`, "msg": "say \"hi\"\nbye"}, Value: 1},
+			},
+		},
+		{
+			name: "Timestamp",
+			body: "jobs_total 42 1700000000000",
+			expected: []Sample{
+				{Name: "jobs_total", Labels: map[string]string{}, Value: 42, Timestamp: 1700000000000, HasTimestamp: true},
+			},
+		},
+		{name: "Invalid timestamp", body: "jobs_total 42 soon", expectError: true},
+		{name: "Missing value", body: "jobs_total", expectError: true},
+		{name: "Invalid value", body: "jobs_total many", expectError: true},
+		{name: "Invalid metric name", body: "1jobs 1", expectError: true},
+		{name: "Invalid label name", body: `jobs{1a="x"} 1`, expectError: true},
+		{name: "Unquoted label value", body: `jobs{a=x} 1`, expectError: true},
+		{name: "Unterminated label value", body: `jobs{a="x} 1`, expectError: true},
+		{name: "Invalid escape", body: `jobs{a="\t"} 1`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples, err := Parse(strings.NewReader(tt.body))
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, samples, len(tt.expected))
+			assert.Equal(t, tt.expected, samples)
+		})
+	}
+}
+
+func TestParse_NonFiniteValues(t *testing.T) {
+	samples, err := Parse(strings.NewReader("up +Inf\nlatency{le=\"+Inf\"} NaN\n"))
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.True(t, math.IsInf(samples[0].Value, 1))
+	assert.True(t, math.IsNaN(samples[1].Value))
+	assert.Equal(t, map[string]string{"le": "+Inf"}, samples[1].Labels)
+	assert.False(t, IsFinite(samples[0].Value))
+	assert.False(t, IsFinite(samples[1].Value))
+}
+
+func TestValidLabelName(t *testing.T) {
+	assert.True(t, ValidLabelName("job"))
+	assert.True(t, ValidLabelName("_instance1"))
+	assert.False(t, ValidLabelName("1job"))
+	assert.False(t, ValidLabelName("job-name"))
+}
+
+func TestSeriesName(t *testing.T) {
+	assert.Equal(t, "up", SeriesName("up", nil))
+	assert.Equal(
+		t,
+		`jobs_total{instance="a",job="batch"}`,
+		SeriesName("jobs_total", map[string]string{"job": "batch", "instance": "a"}),
+	)
+	assert.Equal(t, `m{v="a\"b\\c\nd"}`, SeriesName("m", map[string]string{"v": "a\"b\\c\nd"}))
+	assert.Equal(t, `{job="batch"}`, SeriesName("", map[string]string{"job": "batch"}))
+}
+
+func TestSplitSeriesName(t *testing.T) {
+	name, labels, err := SplitSeriesName("up")
+	require.NoError(t, err)
+	assert.Equal(t, "up", name)
+	assert.Empty(t, labels)
+
+	series := SeriesName("m", map[string]string{"job": "api", "v": "a\"b\\c\nd"})
+	name, labels, err = SplitSeriesName(series)
+	require.NoError(t, err)
+	assert.Equal(t, "m", name)
+	assert.Equal(t, map[string]string{"job": "api", "v": "a\"b\\c\nd"}, labels)
+
+	for _, bad := range []string{`m{job="api"`, `m{job=api}`, `m{job="api"}x`, `m{1x="a"}`} {
+		_, _, err = SplitSeriesName(bad)
+		require.Error(t, err, bad)
+	}
+}
@@ -0,0 +1,82 @@
+// Package pubkey provides helpers for distributing RSA and X25519 public keys.
+// It derives a public key from a private key and computes key fingerprints,
+// so that a key fetched over the network can be verified against a pinned value.
+package pubkey
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/gdyunin/metricol.git/pkg/x25519box"
+)
+
+const (
+	// Const privateKeyPEMType is the PEM block type of PKCS#1 RSA private keys.
+	privateKeyPEMType = "RSA PRIVATE KEY"
+	// Const publicKeyPEMType is the PEM block type of PKIX public keys.
+	publicKeyPEMType = "PUBLIC KEY"
+)
+
+// FromPrivateKeyPEM derives the PEM encoded PKIX public key from a PEM encoded PKCS#1 RSA private key
+// or a PKCS#8 X25519 private key.
+//
+// Parameters:
+//   - privateKeyPEM: The RSA or X25519 private key in PEM format.
+//
+// Returns:
+//   - string: The public key in PEM format.
+//   - error: An error if the private key cannot be parsed.
+func FromPrivateKeyPEM(privateKeyPEM string) (string, error) {
+	if key, err := x25519box.ParsePrivateKey(privateKeyPEM); err == nil {
+		return x25519box.EncodePublicKey(key.PublicKey()) //nolint:wrapcheck // the error is already descriptive.
+	}
+
+	block, _ := pem.Decode([]byte(privateKeyPEM))
+	if block == nil || block.Type != privateKeyPEMType {
+		return "", errors.New("invalid private key PEM format")
+	}
+
+	privKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	return encodePublicKey(&privKey.PublicKey)
+}
+
+// Fingerprint computes the hex encoded SHA-256 digest of the DER encoded public key.
+// Formatting differences of the PEM text, such as line endings, do not change the fingerprint.
+//
+// Parameters:
+//   - publicKeyPEM: The public key in PEM format.
+//
+// Returns:
+//   - string: The fingerprint.
+//   - error: An error if the public key cannot be parsed.
+func Fingerprint(publicKeyPEM string) (string, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil || block.Type != publicKeyPEMType {
+		return "", errors.New("invalid public key PEM format")
+	}
+
+	if _, err := x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+		return "", fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	digest := sha256.Sum256(block.Bytes)
+	return hex.EncodeToString(digest[:]), nil
+}
+
+// encodePublicKey encodes an RSA public key as PKIX PEM.
+func encodePublicKey(key *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: publicKeyPEMType, Bytes: der})), nil
+}
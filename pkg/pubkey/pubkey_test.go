@@ -0,0 +1,77 @@
+package pubkey
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/gdyunin/metricol.git/pkg/x25519box"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromPrivateKeyPEMAndFingerprint(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	privPEM := string(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	}))
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	digest := sha256.Sum256(der)
+
+	pubPEM, err := FromPrivateKeyPEM(privPEM)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		publicPEM   string
+		expected    string
+		expectError bool
+	}{
+		{name: "Derived key", publicPEM: pubPEM, expected: hex.EncodeToString(digest[:])},
+		{
+			name:      "CRLF line endings",
+			publicPEM: strings.ReplaceAll(pubPEM, "\n", "\r\n"),
+			expected:  hex.EncodeToString(digest[:]),
+		},
+		{name: "Not PEM", publicPEM: "garbage", expectError: true},
+		{name: "Private key instead of public", publicPEM: privPEM, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp, err := Fingerprint(tt.publicPEM)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, fp)
+		})
+	}
+}
+
+func TestFromPrivateKeyPEMInvalid(t *testing.T) {
+	_, err := FromPrivateKeyPEM("garbage")
+	assert.Error(t, err)
+}
+
+func TestFromPrivateKeyPEM_X25519(t *testing.T) {
+	privPEM, expectedPEM, err := x25519box.GenerateKey()
+	require.NoError(t, err)
+
+	pubPEM, err := FromPrivateKeyPEM(privPEM)
+	require.NoError(t, err)
+	assert.Equal(t, expectedPEM, pubPEM)
+
+	fingerprint, err := Fingerprint(pubPEM)
+	require.NoError(t, err)
+	assert.Len(t, fingerprint, sha256.Size*2)
+}
@@ -0,0 +1,111 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+)
+
+// commentWidth is the width the generated comments are wrapped at.
+const commentWidth = 100
+
+// Go renders the model as a Go struct a client can encode and decode the wire model with.
+//
+// Parameters:
+//   - pkg: The name of the Go package.
+//
+// Returns:
+//   - []byte: The formatted Go source.
+//   - error: An error if the source cannot be formatted.
+func (m *Model) Go(pkg string) ([]byte, error) {
+	usesTime := false
+	for _, f := range m.Fields {
+		usesTime = usesTime || f.Kind == KindTime
+	}
+
+	var b bytes.Buffer
+	b.WriteString(generatedHeader("//"))
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	if usesTime {
+		b.WriteString("import \"time\"\n\n")
+	}
+	writeComment(&b, "", describe(m.Name, m.Doc))
+	fmt.Fprintf(&b, "type %s struct {\n", m.Name)
+	for _, f := range m.Fields {
+		doc := f.Doc
+		if len(f.Enum) > 0 {
+			doc += " One of: " + strings.Join(f.Enum, ", ") + "."
+		}
+		writeComment(&b, "\t", describe(f.GoName, doc))
+		tag := f.Name
+		if f.Optional {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(&b, "\t%s %s `json:%q`\n", f.GoName, goType(f), tag)
+	}
+	b.WriteString("}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format the Go source of %s: %w", m.Name, err)
+	}
+	return src, nil
+}
+
+// goType returns the Go type holding the value of a field.
+func goType(f Field) string {
+	var t string
+	switch f.Kind {
+	case KindInteger:
+		t = "int64"
+	case KindNumber:
+		t = "float64"
+	case KindBoolean:
+		t = "bool"
+	case KindTime:
+		t = "time.Time"
+	case KindAny:
+		return "any"
+	default:
+		t = "string"
+	}
+	if f.Optional {
+		return "*" + t
+	}
+	return t
+}
+
+// generatedHeader returns the comment marking a file as generated, in the given comment syntax.
+func generatedHeader(comment string) string {
+	return comment + " Code generated by schemagen; DO NOT EDIT.\n\n"
+}
+
+// describe turns a noun phrase into a Go doc comment on a name, e.g. "Delta is the ...". An empty phrase
+// yields no comment.
+func describe(name, doc string) string {
+	if doc == "" {
+		return ""
+	}
+	return name + " is " + strings.ToLower(doc[:1]) + doc[1:]
+}
+
+// wrap splits a text into lines of at most width bytes, breaking between words.
+func wrap(text string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
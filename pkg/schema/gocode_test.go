@@ -0,0 +1,41 @@
+package schema
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModel_Go(t *testing.T) {
+	out, err := sampleModel(t).Go("client")
+	require.NoError(t, err)
+	want, err := format.Source([]byte("// Code generated by schemagen; DO NOT EDIT.\n\n" +
+		"package client\n\n" +
+		"import \"time\"\n\n" +
+		"// Sample is a sample.\n" +
+		"type Sample struct {\n" +
+		"\t// At is the moment.\n" +
+		"\tAt *time.Time `json:\"at,omitempty\"`\n" +
+		"\tExtra any `json:\"extra,omitempty\"`\n" +
+		"\t// Count is the count.\n" +
+		"\tCount *int64 `json:\"count,omitempty\"`\n" +
+		"\t// Name is the name. One of: a, b.\n" +
+		"\tName string `json:\"name\"`\n" +
+		"\tRatio *float64 `json:\"ratio,omitempty\"`\n" +
+		"\tEnabled bool `json:\"enabled\"`\n" +
+		"}\n"))
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(out))
+}
+
+func TestWrap(t *testing.T) {
+	lines := wrap(strings.Repeat("word ", 30), 20)
+	for _, line := range lines {
+		assert.LessOrEqual(t, len(line), 20)
+	}
+	assert.Equal(t, strings.Repeat("word ", 30), strings.Join(lines, " ")+" ")
+	assert.Empty(t, wrap("", 20))
+}
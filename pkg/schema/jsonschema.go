@@ -0,0 +1,77 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// jsonSchemaDraft is the dialect of the generated JSON schemas.
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// property is the JSON schema of a field.
+type property struct {
+	Type        any      `json:"type"`                  // Type is a JSON type or a list of JSON types.
+	Format      string   `json:"format,omitempty"`      // Format refines the type, e.g. "date-time".
+	Description string   `json:"description,omitempty"` // Description is the documentation of the field.
+	Enum        []string `json:"enum,omitempty"`        // Enum lists the allowed values.
+}
+
+// document is the JSON schema of a model.
+type document struct {
+	Schema      string              `json:"$schema"`            // Schema is the dialect.
+	Title       string              `json:"title"`              // Title is the name of the model.
+	Description string              `json:"description"`        // Description is the documentation of the model.
+	Type        string              `json:"type"`               // Type is always "object".
+	Properties  map[string]property `json:"properties"`         // Properties are the fields by JSON name.
+	Required    []string            `json:"required,omitempty"` // Required lists the fields that must be present.
+}
+
+// JSONSchema renders the model as a JSON schema (draft 2020-12).
+//
+// Returns:
+//   - []byte: The indented schema, ending with a newline.
+//   - error: An error if the schema cannot be encoded.
+func (m *Model) JSONSchema() ([]byte, error) {
+	doc := document{
+		Schema:      jsonSchemaDraft,
+		Title:       m.Name,
+		Description: m.Doc,
+		Type:        "object",
+		Properties:  make(map[string]property, len(m.Fields)),
+	}
+	for _, f := range m.Fields {
+		p := property{Type: jsonType(f.Kind), Description: f.Doc, Enum: f.Enum}
+		switch f.Kind {
+		case KindTime:
+			p.Format = "date-time"
+		case KindAny:
+			types := make([]string, 0, len(f.Kinds))
+			for _, k := range f.Kinds {
+				types = append(types, jsonType(k))
+			}
+			p.Type = types
+		}
+		doc.Properties[f.Name] = p
+		if !f.Optional {
+			doc.Required = append(doc.Required, f.Name)
+		}
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the JSON schema of %s: %w", m.Name, err)
+	}
+	return append(out, '\n'), nil
+}
+
+// jsonType returns the JSON type holding a kind.
+func jsonType(k Kind) string {
+	switch k {
+	case KindTime:
+		return "string"
+	case KindAny:
+		return ""
+	default:
+		return string(k)
+	}
+}
@@ -0,0 +1,28 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModel_JSONSchema(t *testing.T) {
+	out, err := sampleModel(t).JSONSchema()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "Sample",
+		"description": "A sample.",
+		"type": "object",
+		"properties": {
+			"at": {"type": "string", "format": "date-time", "description": "The moment."},
+			"extra": {"type": ["string", "boolean"]},
+			"count": {"type": "integer", "description": "The count."},
+			"name": {"type": "string", "description": "The name.", "enum": ["a", "b"]},
+			"ratio": {"type": "number"},
+			"enabled": {"type": "boolean"}
+		},
+		"required": ["name", "enabled"]
+	}`, string(out))
+}
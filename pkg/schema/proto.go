@@ -0,0 +1,92 @@
+package schema
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Proto renders the model as a proto3 message. Fields are numbered by their annotations, so the message
+// stays wire compatible when the Go struct is reordered.
+//
+// Parameters:
+//   - pkg: The protobuf package, e.g. "metricol.v1".
+//
+// Returns:
+//   - []byte: The protobuf definition.
+func (m *Model) Proto(pkg string) []byte {
+	fields := slices.Clone(m.Fields)
+	slices.SortFunc(fields, func(a, b Field) int { return cmp.Compare(a.Number, b.Number) })
+
+	imports := make(map[string]bool)
+	for _, f := range fields {
+		switch f.Kind {
+		case KindTime:
+			imports["google/protobuf/timestamp.proto"] = true
+		case KindAny:
+			imports["google/protobuf/struct.proto"] = true
+		}
+	}
+
+	var b bytes.Buffer
+	b.WriteString(generatedHeader("//"))
+	fmt.Fprintf(&b, "syntax = \"proto3\";\n\npackage %s;\n", pkg)
+	if len(imports) > 0 {
+		b.WriteString("\n")
+		for _, imp := range slices.Sorted(maps.Keys(imports)) {
+			fmt.Fprintf(&b, "import %q;\n", imp)
+		}
+	}
+
+	b.WriteString("\n")
+	writeComment(&b, "", m.Doc)
+	fmt.Fprintf(&b, "message %s {\n", m.Name)
+	for i, f := range fields {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		doc := f.Doc
+		if len(f.Enum) > 0 {
+			doc += " One of: " + strings.Join(f.Enum, ", ") + "."
+		}
+		writeComment(&b, "  ", doc)
+		label := ""
+		if f.Optional && f.Kind != KindTime && f.Kind != KindAny {
+			label = "optional "
+		}
+		fmt.Fprintf(&b, "  %s%s %s = %d;\n", label, protoType(f.Kind), f.Name, f.Number)
+	}
+	b.WriteString("}\n")
+	return b.Bytes()
+}
+
+// protoType returns the protobuf type holding a kind.
+func protoType(k Kind) string {
+	switch k {
+	case KindInteger:
+		return "int64"
+	case KindNumber:
+		return "double"
+	case KindBoolean:
+		return "bool"
+	case KindTime:
+		return "google.protobuf.Timestamp"
+	case KindAny:
+		return "google.protobuf.Value"
+	default:
+		return "string"
+	}
+}
+
+// writeComment writes a line comment with the given indentation, unless the text is empty.
+func writeComment(b *bytes.Buffer, indent, text string) {
+	if text == "" {
+		return
+	}
+	for _, line := range wrap(text, commentWidth-len(indent)-len("// ")) {
+		fmt.Fprintf(b, "%s// %s\n", indent, line)
+	}
+}
@@ -0,0 +1,37 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModel_Proto(t *testing.T) {
+	assert.Equal(t, `// Code generated by schemagen; DO NOT EDIT.
+
+syntax = "proto3";
+
+package test.v1;
+
+import "google/protobuf/struct.proto";
+import "google/protobuf/timestamp.proto";
+
+// A sample.
+message Sample {
+  // The name. One of: a, b.
+  string name = 1;
+
+  // The count.
+  optional int64 count = 2;
+
+  optional double ratio = 3;
+
+  bool enabled = 4;
+
+  // The moment.
+  google.protobuf.Timestamp at = 5;
+
+  google.protobuf.Value extra = 6;
+}
+`, string(sampleModel(t).Proto("test.v1")))
+}
@@ -0,0 +1,149 @@
+// Package schema describes JSON wire models and renders the description as a JSON schema, a protobuf
+// definition and typed Go client structs. The description is derived from the Go struct the server
+// decodes, so the generated artifacts cannot drift from what the server accepts: a field added to the
+// struct without being annotated is an error rather than a silently missing field.
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Kind is the type of the value of a field on the wire.
+type Kind string
+
+const (
+	// KindInteger is a 64-bit integer.
+	KindInteger Kind = "integer"
+	// KindNumber is a 64-bit floating-point number.
+	KindNumber Kind = "number"
+	// KindString is a string.
+	KindString Kind = "string"
+	// KindBoolean is a boolean.
+	KindBoolean Kind = "boolean"
+	// KindTime is a moment formatted as RFC 3339.
+	KindTime Kind = "time"
+	// KindAny is a value of one of several kinds, listed by the Kinds of its field.
+	KindAny Kind = "any"
+)
+
+// ErrUnannotated is returned when a field of a model has no annotation, e.g. because it was added
+// to the struct without a protobuf field number.
+var ErrUnannotated = errors.New("field is not annotated")
+
+// Annotation carries what the Go struct cannot tell about a field.
+type Annotation struct {
+	Doc    string   // Doc describes the field as a noun phrase, e.g. "The name of the metric.".
+	Enum   []string // Enum lists the allowed values of a string field; empty allows any value.
+	Kinds  []Kind   // Kinds lists the kinds a KindAny field can hold.
+	Number int      // Number is the protobuf field number; it must never change once published.
+}
+
+// Field is a field of a model.
+type Field struct {
+	Annotation        // Annotation holds the documentation, the allowed values and the field number.
+	Name       string // Name is the JSON name of the field.
+	GoName     string // GoName is the name of the Go struct field.
+	Kind       Kind   // Kind is the type of the value.
+	Optional   bool   // Optional is true if the field may be left out.
+}
+
+// Model is a wire model.
+type Model struct {
+	Name   string  // Name is the name of the model, e.g. "Metric".
+	Doc    string  // Doc describes the model as a noun phrase.
+	Fields []Field // Fields are the fields in the order of the Go struct.
+}
+
+// FromStruct describes the JSON encoding of a struct. Fields without a JSON name are skipped; pointer,
+// interface and omitempty fields are optional.
+//
+// Parameters:
+//   - name: The name of the model.
+//   - doc: The description of the model.
+//   - v: A value of the struct type.
+//   - annotations: The annotation of every field, by JSON name.
+//
+// Returns:
+//   - *Model: The description of the model.
+//   - error: ErrUnannotated if a field has no annotation or no field number, or an error if a field has
+//     a type that cannot be described.
+func FromStruct(name, doc string, v any, annotations map[string]Annotation) (*Model, error) {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("model %s must be a struct, got %s", name, t)
+	}
+
+	model := &Model{Name: name, Doc: doc}
+	numbers := make(map[int]string)
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		jsonName, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if !sf.IsExported() || jsonName == "-" || jsonName == "" {
+			continue
+		}
+		kind, pointer, err := kindOf(sf.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s of model %s: %w", sf.Name, name, err)
+		}
+		annotation, ok := annotations[jsonName]
+		if !ok || annotation.Number <= 0 {
+			return nil, fmt.Errorf("%w: field %q of model %s needs a positive field number", ErrUnannotated, jsonName, name)
+		}
+		if other, ok := numbers[annotation.Number]; ok {
+			return nil, fmt.Errorf("fields %q and %q of model %s share number %d", other, jsonName, name, annotation.Number)
+		}
+		numbers[annotation.Number] = jsonName
+
+		model.Fields = append(model.Fields, Field{
+			Annotation: annotation,
+			Name:       jsonName,
+			GoName:     sf.Name,
+			Kind:       kind,
+			Optional:   pointer || kind == KindAny || strings.Contains(opts, "omitempty"),
+		})
+	}
+	return model, nil
+}
+
+// timeType is the type of time.Time.
+var timeType = reflect.TypeFor[time.Time]()
+
+// kindOf maps a Go type to the kind of its JSON encoding.
+//
+// Parameters:
+//   - t: The Go type.
+//
+// Returns:
+//   - Kind: The kind.
+//   - bool: true if the type is a pointer.
+//   - error: An error if the type has no kind.
+func kindOf(t reflect.Type) (Kind, bool, error) {
+	pointer := t.Kind() == reflect.Pointer
+	if pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return KindTime, pointer, nil
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return KindInteger, pointer, nil
+	case reflect.Float32, reflect.Float64:
+		return KindNumber, pointer, nil
+	case reflect.String:
+		return KindString, pointer, nil
+	case reflect.Bool:
+		return KindBoolean, pointer, nil
+	case reflect.Interface:
+		return KindAny, pointer, nil
+	default:
+		return "", false, fmt.Errorf("unsupported type %s", t)
+	}
+}
@@ -0,0 +1,78 @@
+package schema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sample is a wire model with a field of every kind.
+type sample struct {
+	At       *time.Time `json:"at,omitempty"`
+	Extra    any        `json:"extra,omitempty"`
+	Count    *int64     `json:"count,omitempty"`
+	Name     string     `json:"name"`
+	internal string
+	Skipped  string  `json:"-"`
+	Ratio    float64 `json:"ratio,omitempty"`
+	Enabled  bool    `json:"enabled"`
+}
+
+// sampleAnnotations annotate every field of sample.
+var sampleAnnotations = map[string]Annotation{
+	"name":    {Number: 1, Doc: "The name.", Enum: []string{"a", "b"}},
+	"count":   {Number: 2, Doc: "The count."},
+	"ratio":   {Number: 3},
+	"enabled": {Number: 4},
+	"at":      {Number: 5, Doc: "The moment."},
+	"extra":   {Number: 6, Kinds: []Kind{KindString, KindBoolean}},
+}
+
+func sampleModel(t *testing.T) *Model {
+	t.Helper()
+	m, err := FromStruct("Sample", "A sample.", &sample{internal: "unused"}, sampleAnnotations)
+	require.NoError(t, err)
+	return m
+}
+
+func TestFromStruct(t *testing.T) {
+	m := sampleModel(t)
+	kinds := make(map[string]Kind)
+	optional := make(map[string]bool)
+	for _, f := range m.Fields {
+		kinds[f.Name] = f.Kind
+		optional[f.Name] = f.Optional
+	}
+	assert.Equal(t, map[string]Kind{
+		"at": KindTime, "extra": KindAny, "count": KindInteger,
+		"name": KindString, "ratio": KindNumber, "enabled": KindBoolean,
+	}, kinds)
+	assert.Equal(t, map[string]bool{
+		"at": true, "extra": true, "count": true, "name": false, "ratio": true, "enabled": false,
+	}, optional)
+}
+
+func TestFromStruct_Errors(t *testing.T) {
+	missing := map[string]Annotation{"name": {Number: 1}}
+	_, err := FromStruct("Sample", "", sample{}, missing)
+	assert.ErrorIs(t, err, ErrUnannotated)
+
+	duplicate := map[string]Annotation{}
+	for name, a := range sampleAnnotations {
+		duplicate[name] = a
+	}
+	duplicate["ratio"] = Annotation{Number: 1}
+	_, err = FromStruct("Sample", "", sample{}, duplicate)
+	assert.ErrorContains(t, err, "share number 1")
+
+	_, err = FromStruct("Sample", "", 42, sampleAnnotations)
+	assert.Error(t, err)
+
+	type unsupported struct {
+		Tags []string `json:"tags"`
+	}
+	_, err = FromStruct("Unsupported", "", unsupported{}, map[string]Annotation{"tags": {Number: 1}})
+	assert.ErrorContains(t, err, "unsupported type")
+}
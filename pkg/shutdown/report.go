@@ -0,0 +1,261 @@
+// Package shutdown records how a binary stopped: which components stopped, how long each took,
+// how many items they left unflushed and which errors they returned. Both binaries log the report
+// on termination and can write it to a file as JSON, so data lost during a deploy can be audited afterwards.
+package shutdown
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gdyunin/metricol.git/pkg/clock"
+
+	"go.uber.org/zap"
+)
+
+// Const reportFilePerm defines the permissions of the report file.
+const reportFilePerm = 0o600
+
+// Component describes how one component stopped.
+type Component struct {
+	Name       string  `json:"name"`            // Name identifies the component.
+	Error      string  `json:"error,omitempty"` // Error is the error the component stopped with.
+	DurationMS float64 `json:"duration_ms"`     // DurationMS is the time the component took to stop.
+	Unflushed  int     `json:"unflushed"`       // Unflushed is the number of items the component dropped.
+	Stopped    bool    `json:"stopped"`         // Stopped is false if the component did not stop in time.
+}
+
+// Summary is the report emitted on termination.
+type Summary struct {
+	Started    time.Time   `json:"started"`     // Started is when the shutdown began.
+	Service    string      `json:"service"`     // Service names the binary.
+	Components []Component `json:"components"`  // Components are the stopped components, then the pending ones.
+	DurationMS float64     `json:"duration_ms"` // DurationMS is the time the shutdown took until the report.
+	Unflushed  int         `json:"unflushed"`   // Unflushed is the total number of dropped items.
+	Errors     int         `json:"errors"`      // Errors is the number of components that stopped with an error.
+	Complete   bool        `json:"complete"`    // Complete is true if every expected component stopped.
+}
+
+// Report collects the components stopped during a shutdown. It is safe for concurrent use.
+type Report struct {
+	started    time.Time   // started is when Begin was first called.
+	clock      clock.Clock // clock measures the durations.
+	service    string      // service names the binary.
+	components []Component // components are the stopped components in the order they stopped.
+	expected   []string    // expected are the components that should stop, in the order they were expected.
+	emitOnce   sync.Once   // emitOnce makes Emit report only once.
+	mu         sync.Mutex  // mu protects started, components and expected.
+}
+
+// NewReport creates an empty report.
+//
+// Parameters:
+//   - service: The name of the binary.
+//   - clk: The clock measuring the durations.
+//
+// Returns:
+//   - *Report: The report.
+func NewReport(service string, clk clock.Clock) *Report {
+	return &Report{service: service, clock: clk}
+}
+
+// Begin marks the start of the shutdown. Only the first call counts.
+func (r *Report) Begin() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.beginLocked()
+}
+
+// Expect declares components that should stop. Those that have not stopped when the report is emitted
+// are listed as not stopped, e.g. because the shutdown timed out.
+//
+// Parameters:
+//   - names: The component names.
+func (r *Report) Expect(names ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expected = append(r.expected, names...)
+}
+
+// Stopped records a component that has stopped, taking as long as the shutdown so far.
+// A component stopped before Begin, e.g. one that failed, is recorded as taking no time.
+//
+// Parameters:
+//   - name: The component name.
+//   - unflushed: The number of items the component dropped.
+//   - err: The error the component stopped with, or nil.
+func (r *Report) Stopped(name string, unflushed int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var d time.Duration
+	if !r.started.IsZero() {
+		d = r.clock.Since(r.started)
+	}
+	r.addLocked(name, d, unflushed, err)
+}
+
+// Run runs a function stopping a component and records the component, taking as long as the function.
+//
+// Parameters:
+//   - name: The component name.
+//   - stop: The function stopping the component.
+func (r *Report) Run(name string, stop func() error) {
+	r.Begin()
+	start := r.clock.Now()
+	err := stop()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addLocked(name, r.clock.Since(start), 0, err)
+}
+
+// Summary returns the report as it stands. A report summarized before Begin begins the shutdown.
+//
+// Returns:
+//   - Summary: The components stopped so far, followed by the expected ones still pending.
+func (r *Report) Summary() Summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.beginLocked()
+
+	s := Summary{
+		Service:    r.service,
+		Started:    r.started,
+		DurationMS: milliseconds(r.clock.Since(r.started)),
+		Components: make([]Component, 0, len(r.components)),
+		Complete:   true,
+	}
+	stopped := make(map[string]bool, len(r.components))
+	for _, c := range r.components {
+		stopped[c.Name] = true
+		s.Components = append(s.Components, c)
+		s.Unflushed += c.Unflushed
+		if c.Error != "" {
+			s.Errors++
+		}
+	}
+	for _, name := range r.expected {
+		if !stopped[name] {
+			stopped[name] = true
+			s.Components = append(s.Components, Component{Name: name})
+			s.Complete = false
+		}
+	}
+	return s
+}
+
+// Emit logs the report and writes it to a file if a path is given. Only the first call emits,
+// so the regular and the forced exit paths may both call it.
+//
+// Parameters:
+//   - logger: The logger the report is written to.
+//   - path: The file the report is written to as JSON; empty to only log it.
+//
+// Returns:
+//   - error: An error if the report file cannot be written.
+func (r *Report) Emit(logger *zap.SugaredLogger, path string) error {
+	var err error
+	r.emitOnce.Do(func() {
+		s := r.Summary()
+		Log(logger, s)
+		if path != "" {
+			err = WriteFile(path, s)
+		}
+	})
+	return err
+}
+
+// Log writes a summary to the logger, a line per component followed by the totals.
+// Components that failed, dropped items or did not stop are logged as warnings.
+//
+// Parameters:
+//   - logger: The logger.
+//   - s: The summary.
+func Log(logger *zap.SugaredLogger, s Summary) {
+	for _, c := range s.Components {
+		fields := []any{
+			"component", c.Name,
+			"stopped", c.Stopped,
+			"duration_ms", c.DurationMS,
+			"unflushed", c.Unflushed,
+		}
+		if c.Error != "" {
+			fields = append(fields, "error", c.Error)
+		}
+		if c.Stopped && c.Error == "" && c.Unflushed == 0 {
+			logger.Infow("Component stopped", fields...)
+		} else {
+			logger.Warnw("Component stopped uncleanly", fields...)
+		}
+	}
+
+	fields := []any{
+		"service", s.Service,
+		"duration_ms", s.DurationMS,
+		"components", len(s.Components),
+		"unflushed", s.Unflushed,
+		"errors", s.Errors,
+		"complete", s.Complete,
+	}
+	if s.Complete && s.Errors == 0 && s.Unflushed == 0 {
+		logger.Infow("Shutdown report", fields...)
+	} else {
+		logger.Warnw("Shutdown report", fields...)
+	}
+}
+
+// WriteFile writes a summary to a file as JSON. The file is replaced atomically,
+// so a forced exit never leaves a partial report behind.
+//
+// Parameters:
+//   - path: The file path.
+//   - s: The summary.
+//
+// Returns:
+//   - error: An error if the file cannot be written.
+func WriteFile(path string, s Summary) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode shutdown report: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create shutdown report file: %w", err)
+	}
+	_, writeErr := tmp.Write(append(data, '\n'))
+	if err = errors.Join(writeErr, tmp.Chmod(reportFilePerm), tmp.Close()); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write shutdown report file: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to replace shutdown report file: %w", err)
+	}
+	return nil
+}
+
+// beginLocked marks the start of the shutdown unless it has begun; mu must be held.
+func (r *Report) beginLocked() {
+	if r.started.IsZero() {
+		r.started = r.clock.Now()
+	}
+}
+
+// addLocked records a stopped component; mu must be held.
+func (r *Report) addLocked(name string, d time.Duration, unflushed int, err error) {
+	c := Component{Name: name, DurationMS: milliseconds(d), Unflushed: unflushed, Stopped: true}
+	if err != nil {
+		c.Error = err.Error()
+	}
+	r.components = append(r.components, c)
+}
+
+// milliseconds converts a duration to fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,103 @@
+package shutdown
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gdyunin/metricol.git/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestReport_Summary(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	r := NewReport("agent", clk)
+	r.Expect("collector", "sender", "status server")
+
+	r.Begin()
+	clk.Advance(100 * time.Millisecond)
+	r.Stopped("collector", 0, nil)
+	clk.Advance(50 * time.Millisecond)
+	r.Stopped("sender", 42, nil)
+	r.Run("profiling server", func() error {
+		clk.Advance(10 * time.Millisecond)
+		return errors.New("listener closed")
+	})
+
+	assert.Equal(t, Summary{
+		Service: "agent",
+		Started: start,
+		Components: []Component{
+			{Name: "collector", DurationMS: 100, Stopped: true},
+			{Name: "sender", DurationMS: 150, Unflushed: 42, Stopped: true},
+			{Name: "profiling server", DurationMS: 10, Error: "listener closed", Stopped: true},
+			{Name: "status server"},
+		},
+		DurationMS: 160,
+		Unflushed:  42,
+		Errors:     1,
+		Complete:   false,
+	}, r.Summary())
+}
+
+func TestReport_BeginOnce(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	r := NewReport("server", clk)
+
+	r.Stopped("registration", 0, errors.New("registry unavailable"))
+	clk.Advance(time.Second)
+	r.Begin()
+	clk.Advance(time.Second)
+	r.Begin()
+
+	s := r.Summary()
+	assert.Equal(t, start.Add(time.Second), s.Started, "the first Begin starts the shutdown")
+	assert.InDelta(t, 1000, s.DurationMS, 0)
+	require.Len(t, s.Components, 1)
+	assert.Zero(t, s.Components[0].DurationMS, "a component stopped before the shutdown took no time")
+}
+
+func TestReport_Emit(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core).Sugar()
+	path := filepath.Join(t.TempDir(), "shutdown.json")
+
+	r := NewReport("server", clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+	r.Stopped("delivery", 0, nil)
+	r.Stopped("repository", 3, nil)
+	require.NoError(t, r.Emit(logger, path))
+	require.NoError(t, r.Emit(logger, path), "emitting twice is a no-op")
+
+	entries := logs.All()
+	require.Len(t, entries, 3)
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	assert.Equal(t, zapcore.WarnLevel, entries[1].Level, "dropped items are logged as a warning")
+	assert.Equal(t, "Shutdown report", entries[2].Message)
+	assert.Equal(t, zapcore.WarnLevel, entries[2].Level)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var s Summary
+	require.NoError(t, json.Unmarshal(data, &s))
+	assert.Equal(t, "server", s.Service)
+	assert.Equal(t, 3, s.Unflushed)
+	assert.Len(t, s.Components, 2)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(reportFilePerm), info.Mode().Perm())
+}
+
+func TestWriteFile_Error(t *testing.T) {
+	err := WriteFile(filepath.Join(t.TempDir(), "missing", "shutdown.json"), Summary{})
+	require.Error(t, err)
+}
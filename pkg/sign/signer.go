@@ -5,6 +5,8 @@ package sign
 import (
 	"crypto/hmac"
 	"crypto/sha256"
+	"encoding/hex"
+	"hash"
 )
 
 // MakeSign generates an HMAC-SHA256 signature for the provided data using the given key.
@@ -17,7 +19,51 @@ import (
 // Returns:
 //   - []byte: The generated HMAC-SHA256 signature.
 func MakeSign(data []byte, key string) []byte {
-	h := hmac.New(sha256.New, []byte(key))
+	h := New(key)
 	h.Write(data)
 	return h.Sum(nil)
 }
+
+// New returns an HMAC-SHA256 hash keyed with the given key, for signing data that arrives in parts.
+// Its sum equals MakeSign of all data written to it.
+//
+// Parameters:
+//   - key: The secret key used for signing the data.
+//
+// Returns:
+//   - hash.Hash: The keyed hash.
+func New(key string) hash.Hash {
+	return hmac.New(sha256.New, []byte(key))
+}
+
+// Verify reports whether signature is the HMAC-SHA256 signature of data under the given key.
+// The comparison takes constant time.
+//
+// Parameters:
+//   - data: The signed message.
+//   - signature: The signature to check.
+//   - key: The secret key the data was signed with.
+//
+// Returns:
+//   - bool: True if the signature is valid.
+func Verify(data, signature []byte, key string) bool {
+	return hmac.Equal(MakeSign(data, key), signature)
+}
+
+// keyIDLength is the number of hex characters in a key identifier.
+const keyIDLength = 16
+
+// KeyID derives a public identifier for a signing key.
+// The identifier is itself an HMAC, so it can be advertised without revealing the key.
+//
+// Parameters:
+//   - key: The secret signing key.
+//
+// Returns:
+//   - string: The key identifier, or an empty string if the key is empty.
+func KeyID(key string) string {
+	if key == "" {
+		return ""
+	}
+	return hex.EncodeToString(MakeSign([]byte("metricol signing key id"), key))[:keyIDLength]
+}
@@ -58,3 +58,26 @@ func TestMakeSign(t *testing.T) {
 		})
 	}
 }
+
+func TestKeyID(t *testing.T) {
+	assert.Equal(t, "", KeyID(""))
+	assert.Len(t, KeyID("secret"), keyIDLength)
+	assert.Equal(t, KeyID("secret"), KeyID("secret"))
+	assert.NotEqual(t, KeyID("secret"), KeyID("another"))
+	assert.NotContains(t, KeyID("secret"), "secret")
+}
+
+func TestNew(t *testing.T) {
+	h := New("secret")
+	h.Write([]byte("hel"))
+	h.Write([]byte("lo"))
+	assert.Equal(t, MakeSign([]byte("hello"), "secret"), h.Sum(nil))
+}
+
+func TestVerify(t *testing.T) {
+	signature := MakeSign([]byte("hello"), "secret")
+	assert.True(t, Verify([]byte("hello"), signature, "secret"))
+	assert.False(t, Verify([]byte("hello!"), signature, "secret"))
+	assert.False(t, Verify([]byte("hello"), signature, "another"))
+	assert.False(t, Verify([]byte("hello"), nil, "secret"))
+}
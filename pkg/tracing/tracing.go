@@ -0,0 +1,90 @@
+// Package tracing sets up OpenTelemetry tracing: spans are sampled by trace ID ratio, exported over
+// OTLP/HTTP and trace context travels between processes in W3C traceparent headers. Without an endpoint
+// no spans are exported, but trace context is still propagated, so a traced agent and an untraced
+// server, or the other way round, do not break the traces of the other side.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrInvalidSampleRatio is returned when the sampling ratio is outside [0, 1].
+var ErrInvalidSampleRatio = errors.New("trace sampling ratio must be between 0 and 1")
+
+// Config holds the tracing settings of a process.
+type Config struct {
+	Endpoint       string  // Endpoint is the OTLP/HTTP collector URL, e.g. "http://localhost:4318"; empty exports nothing.
+	ServiceName    string  // ServiceName names the process in the traces, e.g. "metricol-server".
+	ServiceVersion string  // ServiceVersion is the build version of the process.
+	SampleRatio    float64 // SampleRatio is the fraction of new traces that are sampled.
+}
+
+// Setup installs the global tracer provider and the W3C trace context propagator.
+//
+// Parameters:
+//   - ctx: The context of the exporter setup.
+//   - cfg: The tracing settings.
+//
+// Returns:
+//   - func(context.Context) error: Flushes the pending spans and stops the exporter; call it on shutdown.
+//   - error: ErrInvalidSampleRatio, or an error if the exporter cannot be created.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("%w, got %g", ErrInvalidSampleRatio, cfg.SampleRatio)
+	}
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	endpoint := cfg.Endpoint
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the OTLP trace exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(cfg.ServiceName),
+			semconv.ServiceVersion(cfg.ServiceVersion),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	return func(ctx context.Context) error {
+		if err := provider.Shutdown(ctx); err != nil {
+			return fmt.Errorf("failed to flush traces: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+// End records the outcome of an operation on its span and ends the span.
+//
+// Parameters:
+//   - span: The span of the operation.
+//   - err: The error the operation failed with, or nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
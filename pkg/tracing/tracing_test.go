@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetup(t *testing.T) {
+	previous := otel.GetTextMapPropagator()
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	tests := []struct {
+		name        string
+		cfg         Config
+		expectError error
+	}{
+		{name: "Ratio below zero", cfg: Config{SampleRatio: -0.1}, expectError: ErrInvalidSampleRatio},
+		{name: "Ratio above one", cfg: Config{SampleRatio: 1.5}, expectError: ErrInvalidSampleRatio},
+		{name: "Without endpoint", cfg: Config{SampleRatio: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+			shutdown, err := Setup(context.Background(), tt.cfg)
+			if tt.expectError != nil {
+				require.ErrorIs(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.NoError(t, shutdown(context.Background()))
+			assert.Contains(t, otel.GetTextMapPropagator().Fields(), "traceparent",
+				"trace context is propagated even when nothing is exported")
+		})
+	}
+}
+
+func TestSetup_Exporter(t *testing.T) {
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	// The exporter connects lazily, so an endpoint without a collector only fails when spans are flushed.
+	shutdown, err := Setup(context.Background(), Config{Endpoint: "localhost:4318", ServiceName: "test", SampleRatio: 1})
+	require.NoError(t, err)
+	_, isSDK := otel.GetTracerProvider().(*sdktrace.TracerProvider)
+	assert.True(t, isSDK)
+	require.NoError(t, shutdown(context.Background()))
+}
+
+func TestEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	_, ok := tracer.Start(context.Background(), "ok")
+	End(ok, nil)
+	_, failed := tracer.Start(context.Background(), "failed")
+	End(failed, errors.New("storage is down"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Empty(t, spans[0].Events())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "storage is down", spans[1].Status().Description)
+	require.Len(t, spans[1].Events(), 1)
+	assert.Equal(t, "exception", spans[1].Events()[0].Name)
+}
@@ -0,0 +1,128 @@
+package x25519box
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpen(t *testing.T) {
+	privatePEM, publicPEM, err := GenerateKey()
+	require.NoError(t, err)
+	otherPrivatePEM, _, err := GenerateKey()
+	require.NoError(t, err)
+
+	payload := []byte(`[{"id":"Alloc","type":"gauge","value":1.5}]`)
+	sealed, ephemeral, err := Seal(payload, publicPEM)
+	require.NoError(t, err)
+	assert.Len(t, ephemeral, 32)
+	assert.False(t, bytes.Contains(sealed, payload))
+
+	opened, err := Open(sealed, ephemeral, privatePEM)
+	require.NoError(t, err)
+	assert.Equal(t, payload, opened)
+
+	t.Run("Other key", func(t *testing.T) {
+		_, err := Open(sealed, ephemeral, otherPrivatePEM)
+		assert.Error(t, err)
+	})
+	t.Run("Tampered payload", func(t *testing.T) {
+		tampered := bytes.Clone(sealed)
+		tampered[len(tampered)-1] ^= 1
+		_, err := Open(tampered, ephemeral, privatePEM)
+		assert.Error(t, err)
+	})
+	t.Run("Invalid ephemeral key", func(t *testing.T) {
+		_, err := Open(sealed, ephemeral[:16], privatePEM)
+		assert.Error(t, err)
+	})
+	t.Run("Too short", func(t *testing.T) {
+		_, err := Open(sealed[:4], ephemeral, privatePEM)
+		assert.Error(t, err)
+	})
+
+	sealedAgain, ephemeralAgain, err := Seal(payload, publicPEM)
+	require.NoError(t, err)
+	assert.NotEqual(t, ephemeral, ephemeralAgain, "every payload must use a fresh ephemeral key")
+	assert.NotEqual(t, sealed, sealedAgain)
+}
+
+func TestKeyTypes(t *testing.T) {
+	privatePEM, publicPEM, err := GenerateKey()
+	require.NoError(t, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	rsaPrivatePEM := string(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(rsaKey),
+	}))
+	rsaPublicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	rsaPublicPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rsaPublicDER}))
+	rsaPKCS8DER, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	require.NoError(t, err)
+	rsaPKCS8PEM := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: rsaPKCS8DER}))
+
+	assert.True(t, IsPrivateKey(privatePEM))
+	assert.True(t, IsPublicKey(publicPEM))
+	assert.False(t, IsPrivateKey(publicPEM))
+	assert.False(t, IsPublicKey(privatePEM))
+	assert.False(t, IsPrivateKey(rsaPrivatePEM))
+	assert.False(t, IsPrivateKey(rsaPKCS8PEM))
+	assert.False(t, IsPublicKey(rsaPublicPEM))
+	assert.False(t, IsPublicKey("not a key"))
+
+	_, _, err = Seal([]byte("x"), rsaPublicPEM)
+	assert.Error(t, err)
+
+	key, err := ParsePrivateKey(privatePEM)
+	require.NoError(t, err)
+	encoded, err := EncodePublicKey(key.PublicKey())
+	require.NoError(t, err)
+	assert.Equal(t, publicPEM, encoded)
+}
+
+// benchmarkPayload is a batch of the size agents typically send.
+var benchmarkPayload = bytes.Repeat([]byte(`{"id":"HeapAlloc","type":"gauge","value":123456.789},`), 1000)
+
+func BenchmarkSealOpen(b *testing.B) {
+	privatePEM, publicPEM, err := GenerateKey()
+	require.NoError(b, err)
+
+	b.ResetTimer()
+	for range b.N {
+		sealed, ephemeral, err := Seal(benchmarkPayload, publicPEM)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err = Open(sealed, ephemeral, privatePEM); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkRSA2048KeyExchange measures the RSA key operations the hybrid scheme adds to every request,
+// for comparison with BenchmarkSealOpen.
+func BenchmarkRSA2048KeyExchange(b *testing.B) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(b, err)
+	sessionKey := make([]byte, 32)
+
+	b.ResetTimer()
+	for range b.N {
+		encrypted, err := rsa.EncryptPKCS1v15(rand.Reader, &key.PublicKey, sessionKey)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err = rsa.DecryptPKCS1v15(rand.Reader, key, encrypted); err != nil {
+			b.Fatal(err)
+		}
+	}
+}